@@ -0,0 +1,403 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/user"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joho/godotenv"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/config"
+	appuser "github.com/oksasatya/go-ddd-clean-architecture/internal/application"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/infrastructure/eventing"
+	pginfra "github.com/oksasatya/go-ddd-clean-architecture/internal/infrastructure/postgres"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/infrastructure/redisstore"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/events"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/keyspace"
+)
+
+const usage = `usage: adminctl <command> [flags] [args]
+
+<user> is a user id or email. Passwords are read from the first line of stdin when -password is omitted.
+
+commands:
+  create-user     -email E [-name N] [-password P] [-roles a,b] [-verified]   create an account
+  verify-email    <user>                                   mark the user's email verified
+  reset-password  [-password P] <user>                     set a password, end sessions, forget trusted devices
+  assign-role     [-revoke] <user> <role>                  grant (or with -revoke, remove) a role
+  revoke-sessions <user>                                   end every session and forget trusted devices
+  normalize-emails                                         recompute users.normalized_email after changing EMAIL_FOLD_*
+  reencrypt-secrets                                        move encrypted user values onto FIELD_ENCRYPTION_KEY_ID
+  audit           [-user U] [-action A] [-since 24h] [-n 50]   list audit log entries, oldest first
+
+Changes are recorded in the audit log as adminctl_* actions with the operator's OS user name.
+`
+
+type adminctl struct {
+	users    repository.UserRepository
+	pgUsers  *pginfra.UserRepository
+	roles    *appuser.RoleService
+	audit    *appuser.AuditService
+	sessions repository.SessionStore
+	rdb      *redis.Client
+	secrets  *appuser.UserSecretService
+	operator string
+}
+
+func main() {
+	_ = godotenv.Load()
+	cfg := config.Load()
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	helpers.SetEmailPolicy(helpers.EmailPolicyFromConfig(cfg))
+	ctx := context.Background()
+	logger := logrus.New()
+	logger.SetOutput(os.Stderr)
+	logger.SetLevel(logrus.WarnLevel)
+
+	pool, err := pginfra.NewPool(ctx, cfg.PostgresDSN(), 2, 0, cfg.DBMaxConnLife, cfg.DBPgBouncer)
+	if err != nil {
+		log.Fatalf("postgres: %v", err)
+	}
+	defer pool.Close()
+	keyspace.SetPrefix(cfg.RedisKeyPrefix)
+	keyspace.SetRegion(cfg.Region)
+	redisOpts, err := cfg.RedisOptions()
+	if err != nil {
+		log.Fatalf("redis config: %v", err)
+	}
+	rdb := helpers.NewRedisClientFromOptions(redisOpts)
+	defer func() { _ = rdb.Close() }()
+	if strings.EqualFold(cfg.SessionStore, "memory") {
+		logger.Warn("SESSION_STORE=memory: sessions live inside the server process and cannot be revoked from here")
+	}
+	var sessions repository.SessionStore = redisstore.NewSessionStore(rdb)
+	replicas, err := redisstore.RegionReplicas(cfg.SessionReplicaMap(), rdb)
+	if err != nil {
+		log.Fatalf("session replicas: %v", err)
+	}
+	if len(replicas) > 0 {
+		sessions = redisstore.NewReplicatingSessionStore(sessions, replicas, logger)
+	}
+
+	pgUsers := pginfra.NewUserRepository(pool)
+	var users repository.UserRepository = pgUsers
+	if pub := userEventPublisher(cfg); pub != nil {
+		defer pub.Close()
+		users = eventing.NewUserRepository(users, func(ev events.UserEvent) {
+			pubCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+			defer cancel()
+			if err := pub.PublishUserEvent(pubCtx, ev); err != nil {
+				log.Printf("publish %s: %v", ev.Type, err)
+			}
+		})
+	}
+	fieldCipher, err := helpers.NewFieldCipher(cfg.FieldEncryptionKeyMap(), cfg.FieldEncryptionKeyID)
+	if err != nil {
+		log.Fatalf("field encryption: %v", err)
+	}
+	a := &adminctl{
+		users:    users,
+		pgUsers:  pgUsers,
+		roles:    appuser.NewRoleService(pginfra.NewRoleRepository(pool), users, logger),
+		audit:    appuser.NewAuditService(pginfra.NewAuditRepository(pool), nil, nil, "", logger),
+		sessions: redisstore.NewInvalidatingSessionStore(sessions, helpers.NewSessionInvalidations(rdb, logger), logger),
+		rdb:      rdb,
+		secrets:  appuser.NewUserSecretService(pginfra.NewUserSecretRepository(pool), fieldCipher, logger),
+		operator: operator(),
+	}
+	ctx = repository.WithActor(ctx, "adminctl:"+a.operator)
+
+	cmd, args := os.Args[1], os.Args[2:]
+	switch cmd {
+	case "create-user":
+		fs := flag.NewFlagSet(cmd, flag.ExitOnError)
+		email := fs.String("email", "", "account email (required)")
+		name := fs.String("name", "", "display name (default: the email)")
+		password := fs.String("password", "", "password (default: read from stdin)")
+		roles := fs.String("roles", "", "comma-separated roles to grant")
+		verified := fs.Bool("verified", false, "mark the email verified")
+		_ = fs.Parse(args)
+		if *email == "" {
+			log.Fatal("create-user requires -email")
+		}
+		a.createUser(ctx, *email, *name, readPassword(*password), splitRoles(*roles), *verified)
+	case "verify-email":
+		a.verifyEmail(ctx, arg(args, 0, "verify-email requires <user>"))
+	case "reset-password":
+		fs := flag.NewFlagSet(cmd, flag.ExitOnError)
+		password := fs.String("password", "", "new password (default: read from stdin)")
+		_ = fs.Parse(args)
+		ref := arg(fs.Args(), 0, "reset-password requires <user>")
+		a.resetPassword(ctx, ref, readPassword(*password))
+	case "assign-role":
+		fs := flag.NewFlagSet(cmd, flag.ExitOnError)
+		revoke := fs.Bool("revoke", false, "remove the role instead")
+		_ = fs.Parse(args)
+		ref := arg(fs.Args(), 0, "assign-role requires <user> <role>")
+		role := arg(fs.Args(), 1, "assign-role requires <user> <role>")
+		a.assignRole(ctx, ref, role, *revoke)
+	case "revoke-sessions":
+		a.revokeSessions(ctx, arg(args, 0, "revoke-sessions requires <user>"))
+	case "normalize-emails":
+		a.normalizeEmails(ctx)
+	case "reencrypt-secrets":
+		a.reencryptSecrets(ctx)
+	case "audit":
+		fs := flag.NewFlagSet(cmd, flag.ExitOnError)
+		ref := fs.String("user", "", "only entries for this user (id or email)")
+		action := fs.String("action", "", "only this action")
+		since := fs.Duration("since", 24*time.Hour, "how far back to look")
+		n := fs.Int("n", 50, "max entries")
+		_ = fs.Parse(args)
+		a.listAudit(ctx, *ref, *action, *since, *n)
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
+
+func (a *adminctl) createUser(ctx context.Context, email, name, password string, roles []string, verified bool) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if u, err := a.users.GetByEmail(email); err == nil && u != nil {
+		log.Fatalf("%s already exists (id %s)", email, u.ID)
+	}
+	hash, err := helpers.HashPassword(password)
+	if err != nil {
+		log.Fatalf("hash password: %v", err)
+	}
+	if strings.TrimSpace(name) == "" {
+		name = email
+	}
+	u := &entity.User{Email: email, Password: hash, Name: strings.TrimSpace(name)}
+	if err := a.users.Create(ctx, u); err != nil {
+		log.Fatalf("create user: %v", err)
+	}
+	if verified {
+		if err := a.users.SetVerified(ctx, u.ID); err != nil {
+			log.Printf("mark verified: %v", err)
+		}
+	}
+	for _, r := range roles {
+		if err := a.roles.AssignRole(ctx, u.ID, r); err != nil {
+			log.Printf("assign role %s: %v", r, err)
+		}
+	}
+	a.record(ctx, u, "adminctl_create_user", map[string]any{"roles": roles, "verified": verified})
+	fmt.Printf("created user id=%s email=%s\n", u.ID, u.Email)
+}
+
+func (a *adminctl) verifyEmail(ctx context.Context, ref string) {
+	u := a.resolve(ref)
+	if u.IsVerified {
+		fmt.Printf("%s is already verified\n", u.Email)
+		return
+	}
+	if err := a.users.SetVerified(ctx, u.ID); err != nil {
+		log.Fatalf("verify: %v", err)
+	}
+	a.record(ctx, u, "adminctl_verify_email", nil)
+	fmt.Printf("verified %s\n", u.Email)
+}
+
+func (a *adminctl) resetPassword(ctx context.Context, ref, password string) {
+	u := a.resolve(ref)
+	hash, err := helpers.HashPassword(password)
+	if err != nil {
+		log.Fatalf("hash password: %v", err)
+	}
+	u.ChangePassword(hash)
+	if err := a.users.Update(ctx, u); err != nil {
+		log.Fatalf("update password: %v", err)
+	}
+	// Same as a reset via email: the old password may be compromised
+	revoked := a.endSessions(ctx, u)
+	a.record(ctx, u, "adminctl_reset_password", map[string]any{"sessions_revoked": revoked})
+	fmt.Printf("password reset for %s (sessions revoked: %t)\n", u.Email, revoked)
+}
+
+func (a *adminctl) assignRole(ctx context.Context, ref, role string, revoke bool) {
+	u := a.resolve(ref)
+	action := "adminctl_assign_role"
+	var err error
+	if revoke {
+		action = "adminctl_revoke_role"
+		err = a.roles.RevokeRole(ctx, u.ID, role)
+	} else {
+		err = a.roles.AssignRole(ctx, u.ID, role)
+	}
+	if err != nil {
+		log.Fatalf("%s %s: %v", strings.TrimPrefix(action, "adminctl_"), role, err)
+	}
+	a.record(ctx, u, action, map[string]any{"role": role})
+	fmt.Printf("%s: %s %s\n", u.Email, strings.TrimPrefix(action, "adminctl_"), role)
+}
+
+func (a *adminctl) revokeSessions(ctx context.Context, ref string) {
+	u := a.resolve(ref)
+	revoked := a.endSessions(ctx, u)
+	a.record(ctx, u, "adminctl_revoke_sessions", map[string]any{"sessions_revoked": revoked})
+	if !revoked {
+		log.Fatalf("sessions of %s not fully revoked", u.Email)
+	}
+	fmt.Printf("revoked every session of %s\n", u.Email)
+}
+
+func (a *adminctl) normalizeEmails(ctx context.Context) {
+	updated, conflicts, err := a.pgUsers.NormalizeEmails(ctx)
+	if err != nil {
+		log.Fatalf("normalize emails: %v", err)
+	}
+	for _, id := range conflicts {
+		fmt.Printf("conflict: %s folds onto another account's address; left unchanged\n", id)
+	}
+	fmt.Printf("normalized %d users, %d conflicts\n", updated, len(conflicts))
+	if len(conflicts) > 0 {
+		os.Exit(1)
+	}
+}
+
+// reencryptSecrets re-encrypts user_secrets rows written with an older key; run it after making a
+// new key current, and drop the old key from FIELD_ENCRYPTION_KEYS once it reports no failures
+func (a *adminctl) reencryptSecrets(ctx context.Context) {
+	if !a.secrets.Enabled() {
+		log.Fatal("FIELD_ENCRYPTION_KEYS is not set")
+	}
+	res, err := a.secrets.Rotate(ctx)
+	if err != nil {
+		log.Fatalf("re-encrypt secrets: %v", err)
+	}
+	fmt.Printf("re-encrypted %d values onto key %s, %d changed meanwhile, %d failed\n", res.Reencrypted, a.secrets.Cipher.KeyID(), res.Changed, res.Failed)
+	if res.Failed > 0 {
+		os.Exit(1)
+	}
+}
+
+func (a *adminctl) listAudit(ctx context.Context, ref, action string, since time.Duration, n int) {
+	f := entity.AuditFilter{Action: action, From: time.Now().Add(-since)}
+	if ref != "" {
+		f.UserID = a.resolve(ref).ID
+	}
+	entries, err := a.audit.Repo.ListAfter(f, 0, n)
+	if err != nil {
+		log.Fatalf("list audit log: %v", err)
+	}
+	for _, e := range entries {
+		md, _ := json.Marshal(e.Metadata)
+		fmt.Printf("%d\t%s\t%s\t%s\t%s\t%s\t%s\n", e.ID, e.CreatedAt.Format(time.RFC3339), e.Action, e.UserID, e.Email, e.IP, md)
+	}
+	fmt.Printf("%d entries since %s\n", len(entries), f.From.Format(time.RFC3339))
+}
+
+// endSessions revokes every session and forgets trusted devices; false when either step failed
+func (a *adminctl) endSessions(ctx context.Context, u *entity.User) bool {
+	ok := true
+	if err := a.sessions.Revoke(ctx, u.ID, ""); err != nil {
+		log.Printf("revoke sessions: %v", err)
+		ok = false
+	}
+	if err := helpers.ForgetTrustedDevices(ctx, a.rdb, u.ID); err != nil {
+		log.Printf("forget trusted devices: %v", err)
+		ok = false
+	}
+	return ok
+}
+
+// resolve looks a user up by id or email and exits when there is none
+func (a *adminctl) resolve(ref string) *entity.User {
+	var (
+		u   *entity.User
+		err error
+	)
+	if _, perr := uuid.Parse(ref); perr == nil {
+		u, err = a.users.GetByID(ref)
+	} else {
+		u, err = a.users.GetByEmail(strings.ToLower(strings.TrimSpace(ref)))
+	}
+	if err != nil || u == nil {
+		log.Fatalf("user %q not found", ref)
+	}
+	return u
+}
+
+func (a *adminctl) record(ctx context.Context, u *entity.User, action string, metadata map[string]any) {
+	if metadata == nil {
+		metadata = map[string]any{}
+	}
+	metadata["operator"] = a.operator
+	if err := a.audit.Record(ctx, entity.AuditLog{UserID: u.ID, Email: u.Email, Action: action, UserAgent: "adminctl", Metadata: metadata}); err != nil {
+		log.Printf("audit log not recorded: %v", err)
+	}
+}
+
+// userEventPublisher connects to the user events exchange so changes made here reach subscribers too
+func userEventPublisher(cfg *config.Config) *helpers.RabbitPublisher {
+	if cfg.RabbitMQURL == "" || cfg.UserEventsExchange == "" {
+		return nil
+	}
+	amqpURL, amqpTLS, err := helpers.RabbitDialConfig(cfg)
+	if err != nil {
+		log.Fatalf("rabbitmq config: %v", err)
+	}
+	pub, err := helpers.NewRabbitPublisher(amqpURL, amqpTLS, helpers.UserEventsTopology(cfg))
+	if err != nil {
+		log.Printf("user events disabled: %v", err)
+		return nil
+	}
+	return pub
+}
+
+func operator() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// readPassword returns p, or the first line of stdin; both must meet the API's 8 character minimum
+func readPassword(p string) string {
+	if p == "" {
+		fmt.Fprint(os.Stderr, "password: ")
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			log.Fatal("no password given (use -password or pipe it on stdin)")
+		}
+		p = strings.TrimRight(line, "\r\n")
+	}
+	if len(p) < 8 {
+		log.Fatal("password must be at least 8 characters")
+	}
+	return p
+}
+
+func splitRoles(v string) []string {
+	var out []string
+	for _, r := range strings.Split(v, ",") {
+		if r = strings.TrimSpace(r); r != "" {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+func arg(args []string, i int, msg string) string {
+	if len(args) <= i || args[i] == "" {
+		log.Fatal(msg)
+	}
+	return args[i]
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/joho/godotenv"
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/config"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+)
+
+const usage = `usage: dlq [-queue emails|webhooks|exports] <command> [flags]
+
+-queue picks the worker queue (WORKER_QUEUES names) whose DLQ is handled; emails by default.
+
+commands:
+  list    [-n 20]           list dead-lettered messages (they stay in the DLQ)
+  show    <index>           print the full message at <index> (as shown by list)
+  requeue [-n 1] [-all]     move messages from the DLQ back to the work queue
+  purge   [-yes]            delete every message in the DLQ
+  policy  [-apply]          print the broker policy that dead-letters the work queue into the DLQ
+                            (RABBITMQ_EMAIL_DLX=policy); -apply sets it via RABBITMQ_MANAGEMENT_URL
+`
+
+func main() {
+	_ = godotenv.Load()
+	cfg := config.Load()
+	argv, kind := os.Args[1:], helpers.WorkerQueueEmails
+	if len(argv) >= 2 && (argv[0] == "-queue" || argv[0] == "--queue") {
+		kind, argv = argv[1], argv[2:]
+	}
+	if len(argv) < 1 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	t, err := helpers.WorkerTopology(cfg, kind)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if cfg.RabbitMQURL == "" || t.Queue == "" || t.DLQ == "" {
+		log.Fatal("RabbitMQ not configured")
+	}
+
+	// policy only talks to the management API, so it works before the queues are reachable
+	if argv[0] == "policy" {
+		runPolicy(cfg, t, argv[1:])
+		return
+	}
+
+	amqpURL, amqpTLS, err := helpers.RabbitDialConfig(cfg)
+	if err != nil {
+		log.Fatalf("rabbitmq config: %v", err)
+	}
+	conn, err := helpers.DialRabbitMQ(amqpURL, amqpTLS)
+	if err != nil {
+		log.Fatalf("amqp dial: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	ch, err := conn.Channel()
+	if err != nil {
+		log.Fatalf("amqp channel: %v", err)
+	}
+	defer func() { _ = ch.Close() }()
+
+	if err := helpers.DeclareTopology(ch, t); err != nil {
+		log.Fatalf("queue declare: %v", err)
+	}
+
+	cmd, args := argv[0], argv[1:]
+	switch cmd {
+	case "list":
+		fs := flag.NewFlagSet("list", flag.ExitOnError)
+		n := fs.Int("n", 20, "max messages to list")
+		_ = fs.Parse(args)
+		msgs := peek(ch, t.DLQ, *n)
+		for i, m := range msgs {
+			fmt.Printf("%d\t%s\t%s\t%s\n", i, m.Timestamp.Format(time.RFC3339), deathReason(m), summary(m.Body))
+		}
+		fmt.Printf("%d message(s) shown from %s\n", len(msgs), t.DLQ)
+	case "show":
+		if len(args) < 1 {
+			log.Fatal("show requires <index>")
+		}
+		idx, err := strconv.Atoi(args[0])
+		if err != nil || idx < 0 {
+			log.Fatalf("invalid index %q", args[0])
+		}
+		msgs := peek(ch, t.DLQ, idx+1)
+		if idx >= len(msgs) {
+			log.Fatalf("no message at index %d (queue has %d)", idx, len(msgs))
+		}
+		m := msgs[idx]
+		out, _ := json.MarshalIndent(map[string]any{
+			"message_id": m.MessageId,
+			"timestamp":  m.Timestamp,
+			"reason":     deathReason(m),
+			"headers":    m.Headers,
+			"body":       json.RawMessage(m.Body),
+		}, "", "  ")
+		fmt.Println(string(out))
+	case "requeue":
+		fs := flag.NewFlagSet("requeue", flag.ExitOnError)
+		n := fs.Int("n", 1, "number of messages to requeue")
+		all := fs.Bool("all", false, "requeue every message")
+		_ = fs.Parse(args)
+		moved := requeue(ch, t.DLQ, t.Queue, *n, *all)
+		fmt.Printf("requeued %d message(s) to %s\n", moved, t.Queue)
+	case "purge":
+		fs := flag.NewFlagSet("purge", flag.ExitOnError)
+		yes := fs.Bool("yes", false, "confirm purge")
+		_ = fs.Parse(args)
+		if !*yes {
+			log.Fatal("refusing to purge without -yes")
+		}
+		count, err := ch.QueuePurge(t.DLQ, false)
+		if err != nil {
+			log.Fatalf("purge: %v", err)
+		}
+		fmt.Printf("purged %d message(s) from %s\n", count, t.DLQ)
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
+
+func runPolicy(cfg *config.Config, t helpers.RabbitTopology, args []string) {
+	fs := flag.NewFlagSet("policy", flag.ExitOnError)
+	apply := fs.Bool("apply", false, "set the policy via RABBITMQ_MANAGEMENT_URL")
+	_ = fs.Parse(args)
+	p := helpers.NewDeadLetterPolicy(t.Queue, t.DLQ)
+	if !*apply {
+		fmt.Println(p.Command(cfg.RabbitMQVHost))
+		return
+	}
+	if cfg.RabbitMQManagementURL == "" {
+		log.Fatal("RABBITMQ_MANAGEMENT_URL is required for -apply")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := p.Apply(ctx, cfg.RabbitMQManagementURL, cfg.RabbitMQVHost); err != nil {
+		log.Fatalf("apply policy: %v", err)
+	}
+	fmt.Printf("policy %s applied: %s dead-letters into %s\n", p.Name, t.Queue, t.DLQ)
+}
+
+// peek fetches up to n messages without consuming them; all are returned to the queue afterwards.
+func peek(ch *amqp.Channel, queue string, n int) []amqp.Delivery {
+	var msgs []amqp.Delivery
+	for len(msgs) < n {
+		m, ok, err := ch.Get(queue, false)
+		if err != nil {
+			log.Fatalf("get: %v", err)
+		}
+		if !ok {
+			break
+		}
+		msgs = append(msgs, m)
+	}
+	// Nack only after fetching so redelivered messages are not read twice
+	for _, m := range msgs {
+		_ = m.Nack(false, true)
+	}
+	return msgs
+}
+
+// requeue republishes DLQ messages onto the work queue and acks them from the DLQ.
+func requeue(ch *amqp.Channel, dlq, queue string, n int, all bool) int {
+	moved := 0
+	for all || moved < n {
+		m, ok, err := ch.Get(dlq, false)
+		if err != nil {
+			log.Fatalf("get: %v", err)
+		}
+		if !ok {
+			break
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		err = ch.PublishWithContext(ctx, "", queue, false, false, amqp.Publishing{
+			ContentType:  m.ContentType,
+			DeliveryMode: amqp.Persistent,
+			MessageId:    m.MessageId,
+			Type:         m.Type, // workers route on it
+			Timestamp:    time.Now().UTC(),
+			Body:         m.Body,
+		})
+		cancel()
+		if err != nil {
+			_ = m.Nack(false, true)
+			log.Fatalf("publish: %v", err)
+		}
+		_ = m.Ack(false)
+		moved++
+	}
+	return moved
+}
+
+// deathReason reads the first x-death entry RabbitMQ attaches to dead-lettered messages.
+func deathReason(m amqp.Delivery) string {
+	deaths, ok := m.Headers["x-death"].([]interface{})
+	if !ok || len(deaths) == 0 {
+		return "unknown"
+	}
+	if d, ok := deaths[0].(amqp.Table); ok {
+		return fmt.Sprintf("%v", d["reason"])
+	}
+	return "unknown"
+}
+
+func summary(body []byte) string {
+	var job struct {
+		To       string `json:"to"`
+		Template string `json:"template"`
+		Subject  string `json:"subject"`
+		URL      string `json:"url"`    // webhooks
+		Event    string `json:"event"`  // webhooks
+		JobID    string `json:"job_id"` // exports
+	}
+	if err := json.Unmarshal(body, &job); err != nil {
+		return "unparseable payload (" + strconv.Itoa(len(body)) + " bytes)"
+	}
+	switch {
+	case job.URL != "":
+		return "url=" + job.URL + " event=" + job.Event
+	case job.JobID != "":
+		return "export job=" + job.JobID
+	}
+	what := job.Template
+	if what == "" {
+		what = job.Subject
+	}
+	return "to=" + job.To + " " + what
+}
@@ -3,136 +3,175 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
+	"slices"
 	"strings"
 	"syscall"
 	"time"
 
-	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
 	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/redis/go-redis/v9"
 
 	"github.com/oksasatya/go-ddd-clean-architecture/config"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/worker"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/keyspace"
 	"github.com/oksasatya/go-ddd-clean-architecture/pkg/mailer"
 	mailtpl "github.com/oksasatya/go-ddd-clean-architecture/pkg/mailer/templates"
 )
 
 func main() {
 	cfg := config.Load()
-	if !cfg.MailSendEnabled {
-		log.Println("MAIL_SEND_ENABLED=false; email worker disabled (no real emails will be sent)")
-		return
-	}
-	if cfg.RabbitMQURL == "" || cfg.RabbitMQEmailQueue == "" {
+	keyspace.SetPrefix(cfg.RedisKeyPrefix)
+	if cfg.RabbitMQURL == "" {
 		log.Fatal("RabbitMQ not configured")
 	}
-	if cfg.MailgunDomain == "" || cfg.MailgunAPIKey == "" || cfg.MailgunSender == "" {
-		log.Fatal("Mailgun not configured")
-	}
 
-	conn, err := amqp.Dial(cfg.RabbitMQURL)
+	// Redis backs the per-recipient limit, dedup and retry budgets; without it they are off (fail open)
+	redisOpts, err := cfg.RedisOptions()
 	if err != nil {
-		log.Fatalf("amqp dial: %v", err)
+		log.Fatalf("invalid redis config: %v", err)
 	}
-	defer func() { _ = conn.Close() }()
-
-	ch, err := conn.Channel()
+	rdb := helpers.NewRedisClientFromOptions(redisOpts)
+	defer func() { _ = rdb.Close() }()
+
+	emailMetrics := worker.NewEmailMetrics()
+	deps := worker.Deps{Redis: rdb}
+	// MAIL_SEND_ENABLED=false leaves the email queue alone (no real emails will be sent)
+	if cfg.MailSendEnabled && slices.Contains(cfg.WorkerQueueList(), helpers.WorkerQueueEmails) {
+		deps.Email = newEmailConsumer(cfg, rdb, emailMetrics)
+	}
+	reg, err := worker.NewRegistryFromConfig(cfg, deps)
 	if err != nil {
-		log.Fatalf("amqp channel: %v", err)
+		log.Fatalf("WORKER_QUEUES: %v", err)
 	}
-	defer func() { _ = ch.Close() }()
-
-	// Prefetch biar fair dispatch
-	if err := ch.Qos(16, 0, false); err != nil {
-		log.Fatalf("qos: %v", err)
+	if len(reg.Queues()) == 0 {
+		log.Println("no worker queue to consume; worker disabled")
+		return
 	}
 
-	if _, err := ch.QueueDeclare(cfg.RabbitMQEmailQueue, true, false, false, false, nil); err != nil {
-		log.Fatalf("queue declare: %v", err)
+	amqpURL, amqpTLS, err := helpers.RabbitDialConfig(cfg)
+	if err != nil {
+		log.Fatalf("rabbitmq config: %v", err)
 	}
-
-	msgs, err := ch.Consume(cfg.RabbitMQEmailQueue, "", false, false, false, false, nil)
+	var conn *amqp.Connection
+	err = helpers.Retry(context.Background(), helpers.StartupRetry(cfg), "rabbitmq", nil, func() error {
+		c, dErr := helpers.DialRabbitMQ(amqpURL, amqpTLS)
+		if dErr != nil {
+			log.Printf("amqp dial failed: %v", dErr)
+			return dErr
+		}
+		conn = c
+		return nil
+	})
 	if err != nil {
-		log.Fatalf("consume: %v", err)
+		log.Fatalf("amqp dial: %v", err)
 	}
+	defer func() { _ = conn.Close() }()
 
-	mg := mailer.NewMailgun(cfg.MailgunDomain, cfg.MailgunAPIKey, cfg.MailgunSender)
-	ctx := context.Background()
-	resolver := mailtpl.IPAPIResolver{}
+	consumer := worker.NewConsumer(conn.Channel, reg)
+	consumer.Metrics = worker.NewQueueMetrics()
 
-	stop := make(chan os.Signal, 1)
-	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go helpers.ProfilerFromConfig(cfg, "email_worker", nil).Run(ctx)
 	done := make(chan struct{})
-
 	go func() {
-		for msg := range msgs {
-			var job mailer.EmailJob
-			if err := json.Unmarshal(msg.Body, &job); err != nil {
-				log.Printf("bad message: %v", err)
-				_ = msg.Nack(false, false)
-				continue
-			}
-
-			helpers.EnsureRecipientAndEmail(&job)
-			helpers.MapLegacyToUniversal(&job)
-
-			// Localize times if we can
-			helpers.LocalizeTimesIfPossible(ctx, resolver, job.Data)
-
-			// Render
-			subject := job.Subject
-			text := job.Text
-			html := job.HTML
-
-			if job.Template != "" {
-				if strings.EqualFold(job.Template, "universal") {
-					if loc, ok := job.Data["Location"]; !ok || fmt.Sprintf("%v", loc) == "" {
-						if ipVal, okIP := job.Data["IP"]; okIP {
-							if g, err := resolver.Lookup(ctx, fmt.Sprintf("%v", ipVal)); err == nil {
-								job.Data["Location"] = mailtpl.FormatGeo(g)
-							}
-						}
-					}
-					htmlStr, rerr := mailtpl.RenderHTML("universal", job.Data)
-					if rerr != nil {
-						log.Printf("render universal failed: %v", rerr)
-						_ = msg.Nack(false, false)
-						continue
-					}
-					html = htmlStr
-					subject = helpers.SubjectForUniversal(job.Data)
-				} else {
-					s, t, h, rerr := mailtpl.Render(job.Template, job.Data)
-					if rerr != nil {
-						log.Printf("render %s failed: %v", job.Template, rerr)
-						_ = msg.Nack(false, false)
-						continue
-					}
-					subject, text, html = s, t, h
-				}
-			}
-
-			// Send
-			c, cancel := context.WithTimeout(ctx, 15*time.Second)
-			if err := mg.Send(c, job.To, subject, text, html); err != nil {
-				cancel()
-				log.Printf("send failed: %v", err)
-				_ = msg.Nack(false, true)
-				continue
-			}
-			cancel()
-			_ = msg.Ack(false)
+		if err := consumer.Run(ctx); err != nil {
+			log.Fatalf("%v", err)
 		}
 		close(done)
 	}()
 
-	log.Printf("email worker listening on queue=%s", cfg.RabbitMQEmailQueue)
+	var consumed, watched []string
+	for _, q := range reg.Queues() {
+		consumed = append(consumed, q.Name())
+		watched = append(watched, q.Name(), q.Topology.DLQ)
+	}
+	if cfg.WorkerHealthAddr != "" {
+		probe := helpers.NewQueueProbe(conn.Channel, cfg.RabbitMQManagementURL, cfg.RabbitMQVHost, watched...)
+		srv := healthServer(cfg.WorkerHealthAddr, conn, probe, consumed, consumer.Metrics, emailMetrics)
+		go func() {
+			if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Printf("health server: %v", err)
+			}
+		}()
+		defer func() { _ = srv.Close() }()
+	}
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+
+	log.Printf("worker listening on queues=%s driver=%s", strings.Join(consumed, ","), cfg.MailDriver)
 	<-stop
 	log.Printf("shutting down...")
+	cancel()
 	select {
 	case <-done:
 	case <-time.After(2 * time.Second):
 	}
 }
+
+// newEmailConsumer sets up the mail driver and templates for the emails queue
+func newEmailConsumer(cfg *config.Config, rdb *redis.Client, metrics *worker.EmailMetrics) *worker.EmailConsumer {
+	sender, err := mailer.NewSender(cfg)
+	if err != nil {
+		log.Fatalf("mail driver: %v", err)
+	}
+	mailtpl.SetInlineCSS(cfg.MailInlineCSS)
+	mailtpl.SetLimits(cfg.MailTemplateTimeout, int64(cfg.MailTemplateMaxBytes))
+	if err := mailtpl.Setup(cfg.MailTemplatesDir); err != nil {
+		log.Fatalf("email templates: %v", err)
+	}
+	consumer := worker.NewEmailConsumer(sender, mailtpl.NewGeoResolver(cfg))
+	consumer.Limit = worker.NewRecipientLimit(rdb, cfg.EmailRecipientLimitHourly, cfg.EmailRecipientLimitDaily)
+	consumer.Dedup = worker.NewJobDedup(rdb, cfg.EmailDedupTTL)
+	consumer.Redis = rdb
+	consumer.CorrelationTTL = cfg.CorrelationTTL
+	consumer.Metrics = metrics
+	return consumer
+}
+
+// healthServer serves GET /healthz (503 when the AMQP connection is closed or a consumed queue has
+// no consumer, i.e. this worker is stuck) and GET /metrics with queue depth gauges and job counters.
+func healthServer(addr string, conn *amqp.Connection, probe *helpers.QueueProbe, queues []string, messages *worker.QueueMetrics, jobs *worker.EmailMetrics) *http.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+		defer cancel()
+		stats := probe.Stats(ctx)
+		status, code := "ok", http.StatusOK
+		if conn.IsClosed() {
+			status, code = "amqp connection closed", http.StatusServiceUnavailable
+		}
+		for _, s := range stats {
+			if slices.Contains(queues, s.Queue) && s.Error == "" && s.Consumers == 0 {
+				status, code = "no consumer on "+s.Queue, http.StatusServiceUnavailable
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		_ = json.NewEncoder(w).Encode(map[string]any{"status": status, "queues": stats})
+	})
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+		defer cancel()
+		var b strings.Builder
+		up := 1
+		if conn.IsClosed() {
+			up = 0
+		}
+		fmt.Fprintf(&b, "# HELP email_worker_up 1 while the worker's AMQP connection is open.\n# TYPE email_worker_up gauge\nemail_worker_up %d\n", up)
+		helpers.WriteQueueMetrics(&b, probe.Stats(ctx))
+		messages.WriteMetrics(&b)
+		jobs.WriteMetrics(&b)
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_, _ = w.Write([]byte(b.String()))
+	})
+	return &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+}
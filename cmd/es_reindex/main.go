@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/joho/godotenv"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/config"
+	appuser "github.com/oksasatya/go-ddd-clean-architecture/internal/application"
+	pginfra "github.com/oksasatya/go-ddd-clean-architecture/internal/infrastructure/postgres"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/keyspace"
+)
+
+// es_reindex rebuilds the users search index from Postgres into a new index and swaps the aliases.
+func main() {
+	_ = godotenv.Load()
+	cfg := config.Load()
+	keyspace.SetPrefix(cfg.RedisKeyPrefix)
+	logger := helpers.NewLogger(cfg.AppName, cfg.Env)
+	ctx := context.Background()
+
+	if len(cfg.ESAddrs()) == 0 {
+		log.Fatal("Elasticsearch not configured")
+	}
+	es, err := helpers.NewESClient(cfg.ESAddrs(), cfg.ElasticsearchUser, cfg.ElasticsearchPass)
+	if err != nil {
+		log.Fatalf("elasticsearch: %v", err)
+	}
+	pool, err := pginfra.NewPool(ctx, cfg.PostgresDSN(), cfg.DBMaxConns, cfg.DBMinConns, cfg.DBMaxConnLife, cfg.DBPgBouncer)
+	if err != nil {
+		log.Fatalf("postgres: %v", err)
+	}
+	defer pool.Close()
+	redisOpts, err := cfg.RedisOptions()
+	if err != nil {
+		log.Fatalf("redis config: %v", err)
+	}
+	rdb := helpers.NewRedisClientFromOptions(redisOpts)
+	defer func() { _ = rdb.Close() }()
+
+	idx := appuser.NewUserIndexService(pginfra.NewUserRepository(pool), es, rdb, logger, cfg.ESUsersIndex, cfg.ESUsersWriteAlias)
+	st, err := idx.Reindex(ctx)
+	if st != nil {
+		out, _ := json.MarshalIndent(st, "", "  ")
+		fmt.Println(string(out))
+	}
+	if err != nil {
+		log.Fatalf("reindex: %v", err)
+	}
+}
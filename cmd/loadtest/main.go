@@ -0,0 +1,240 @@
+// Command loadtest drives the login, refresh, profile and search flows against a running instance
+// and reports latency percentiles per flow, for comparing performance before and after a change.
+//
+//	go run cmd/loadtest/main.go -base http://localhost:8080 -c 8 -d 30s -email 'load%d@example.com'
+//
+// Each worker keeps its own cookie jar. Only one session per user is current, so concurrent
+// workers sharing an account log each other out; give -email a %d (worker index) to use one
+// account per worker. Per-IP rate limits and the account guard apply as usual: raise them on the
+// target instance, or 429s show up in the status counts.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type flow struct {
+	name string
+	run  func(c *http.Client, base string, w int) (int, error)
+}
+
+type sample struct {
+	flow   string
+	status int
+	dur    time.Duration
+	err    error
+}
+
+var (
+	base        = flag.String("base", "http://localhost:8080", "target base URL")
+	concurrency = flag.Int("c", 4, "concurrent workers")
+	duration    = flag.Duration("d", 30*time.Second, "test duration")
+	iterations  = flag.Int("n", 0, "iterations per worker (0 = run for -d)")
+	flowsFlag   = flag.String("flows", "login,refresh,profile,search", "flows per iteration, in order")
+	email       = flag.String("email", "admin@example.com", "login email; %d is replaced by the worker index")
+	password    = flag.String("password", "password123", "login password")
+	query       = flag.String("q", "a", "search query")
+	asJSON      = flag.Bool("json", false, "print the report as JSON")
+)
+
+func main() {
+	flag.Parse()
+	flows, err := selectFlows(*flowsFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+	target := strings.TrimRight(*base, "/")
+
+	samples := make(chan sample, 1024)
+	deadline := time.Now().Add(*duration)
+	var wg sync.WaitGroup
+	for w := 0; w < *concurrency; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			jar, _ := cookiejar.New(nil)
+			client := &http.Client{Jar: jar, Timeout: 30 * time.Second}
+			for i := 0; ; i++ {
+				if (*iterations > 0 && i >= *iterations) || (*iterations == 0 && time.Now().After(deadline)) {
+					return
+				}
+				for _, f := range flows {
+					start := time.Now()
+					status, err := f.run(client, target, w)
+					samples <- sample{flow: f.name, status: status, dur: time.Since(start), err: err}
+				}
+			}
+		}(w)
+	}
+	go func() { wg.Wait(); close(samples) }()
+
+	started := time.Now()
+	byFlow := map[string][]sample{}
+	for s := range samples {
+		byFlow[s.flow] = append(byFlow[s.flow], s)
+	}
+	elapsed := time.Since(started)
+
+	report := make([]flowReport, 0, len(flows))
+	for _, f := range flows {
+		report = append(report, summarize(f.name, byFlow[f.name], elapsed))
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(map[string]any{"concurrency": *concurrency, "elapsed_s": elapsed.Seconds(), "flows": report})
+		return
+	}
+	fmt.Printf("%d workers, %s\n\n", *concurrency, elapsed.Round(time.Millisecond))
+	fmt.Printf("%-8s %8s %8s %8s %9s %9s %9s %9s %9s  %s\n", "flow", "reqs", "errors", "rps", "p50", "p90", "p95", "p99", "max", "statuses")
+	for _, r := range report {
+		fmt.Printf("%-8s %8d %8d %8.1f %9s %9s %9s %9s %9s  %s\n", r.Flow, r.Requests, r.Errors, r.RPS,
+			ms(r.P50), ms(r.P90), ms(r.P95), ms(r.P99), ms(r.Max), statuses(r.Statuses))
+	}
+	for _, r := range report {
+		if r.FirstError != "" {
+			fmt.Printf("\n%s first error: %s", r.Flow, r.FirstError)
+		}
+	}
+	fmt.Println()
+}
+
+func selectFlows(list string) ([]flow, error) {
+	all := map[string]func(*http.Client, string, int) (int, error){
+		"login":   login,
+		"refresh": refresh,
+		"profile": profile,
+		"search":  search,
+	}
+	var out []flow
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		run, ok := all[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown flow %q (login, refresh, profile, search)", name)
+		}
+		out = append(out, flow{name: name, run: run})
+	}
+	return out, nil
+}
+
+func login(c *http.Client, base string, w int) (int, error) {
+	addr := *email
+	if strings.Contains(addr, "%d") {
+		addr = fmt.Sprintf(addr, w)
+	}
+	status, err := call(c, http.MethodPost, base+"/api/login", map[string]string{"email": addr, "password": *password})
+	if err == nil && status == http.StatusAccepted {
+		err = fmt.Errorf("login requires an OTP for %s; use an account on a trusted device or with OTP off", addr)
+	}
+	return status, err
+}
+
+func refresh(c *http.Client, base string, _ int) (int, error) {
+	return call(c, http.MethodPost, base+"/api/refresh", nil)
+}
+
+func profile(c *http.Client, base string, _ int) (int, error) {
+	return call(c, http.MethodGet, base+"/api/profile", nil)
+}
+
+func search(c *http.Client, base string, _ int) (int, error) {
+	return call(c, http.MethodGet, base+"/api/users/search?q="+url.QueryEscape(*query), nil)
+}
+
+// call sends a JSON request and drains the body; non-2xx answers are errors
+func call(c *http.Client, method, target string, body any) (int, error) {
+	var r io.Reader
+	if body != nil {
+		b, _ := json.Marshal(body)
+		r = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, target, r)
+	if err != nil {
+		return 0, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("%s %s: %d %s", method, req.URL.Path, resp.StatusCode, strings.TrimSpace(string(raw)))
+	}
+	return resp.StatusCode, nil
+}
+
+type flowReport struct {
+	Flow       string         `json:"flow"`
+	Requests   int            `json:"requests"`
+	Errors     int            `json:"errors"`
+	RPS        float64        `json:"rps"`
+	P50        time.Duration  `json:"p50_ns"`
+	P90        time.Duration  `json:"p90_ns"`
+	P95        time.Duration  `json:"p95_ns"`
+	P99        time.Duration  `json:"p99_ns"`
+	Max        time.Duration  `json:"max_ns"`
+	Statuses   map[string]int `json:"statuses"`
+	FirstError string         `json:"first_error,omitempty"`
+}
+
+func summarize(name string, samples []sample, elapsed time.Duration) flowReport {
+	r := flowReport{Flow: name, Requests: len(samples), Statuses: map[string]int{}}
+	if len(samples) == 0 {
+		return r
+	}
+	durs := make([]time.Duration, 0, len(samples))
+	for _, s := range samples {
+		durs = append(durs, s.dur)
+		key := fmt.Sprint(s.status)
+		if s.status == 0 {
+			key = "neterr"
+		}
+		r.Statuses[key]++
+		if s.err != nil {
+			r.Errors++
+			if r.FirstError == "" {
+				r.FirstError = s.err.Error()
+			}
+		}
+	}
+	sort.Slice(durs, func(i, j int) bool { return durs[i] < durs[j] })
+	pct := func(p float64) time.Duration { return durs[int(p*float64(len(durs)-1))] }
+	r.P50, r.P90, r.P95, r.P99, r.Max = pct(0.50), pct(0.90), pct(0.95), pct(0.99), durs[len(durs)-1]
+	if elapsed > 0 {
+		r.RPS = float64(len(samples)) / elapsed.Seconds()
+	}
+	return r
+}
+
+func ms(d time.Duration) string { return fmt.Sprintf("%.1fms", float64(d.Microseconds())/1000) }
+
+func statuses(m map[string]int) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s:%d", k, m[k]))
+	}
+	return strings.Join(parts, " ")
+}
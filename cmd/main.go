@@ -3,14 +3,23 @@ package main
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"syscall"
+	"text/tabwriter"
 	"time"
 
 	"cloud.google.com/go/storage"
@@ -18,51 +27,133 @@ import (
 	"github.com/elastic/go-elasticsearch/v8"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joho/godotenv"
+	"github.com/redis/go-redis/v9"
 	"github.com/sirupsen/logrus"
 
 	"github.com/golang-migrate/migrate/v4"
 	pgmigrate "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
 	_ "github.com/jackc/pgx/v5/stdlib"
 
 	"github.com/oksasatya/go-ddd-clean-architecture/config"
+	dbmigrations "github.com/oksasatya/go-ddd-clean-architecture/db"
 	"github.com/oksasatya/go-ddd-clean-architecture/internal/container"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/infrastructure/memory"
 	pginfra "github.com/oksasatya/go-ddd-clean-architecture/internal/infrastructure/postgres"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/infrastructure/redisstore"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/infrastructure/search"
 	"github.com/oksasatya/go-ddd-clean-architecture/internal/interface/middleware"
 	"github.com/oksasatya/go-ddd-clean-architecture/internal/router"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/router/route"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/worker"
 	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/keyspace"
 	"github.com/oksasatya/go-ddd-clean-architecture/pkg/mailer"
+	mailtpl "github.com/oksasatya/go-ddd-clean-architecture/pkg/mailer/templates"
 	"github.com/oksasatya/go-ddd-clean-architecture/pkg/validation"
 )
 
 func main() {
+	routesOnly := flag.Bool("routes", false, "print the registered routes and exit (connects like the server, skips migrations)")
+	schemaOut := flag.String("schema", "", "write the routes with their request/response schemas as JSON to this file (input of cmd/sdkgen) and exit")
+	flag.Parse()
+	if *schemaOut != "" {
+		*routesOnly = true
+	}
 	_ = godotenv.Load() // load .env if present
 
 	cfg := config.Load()
 	logger := helpers.NewLogger(cfg.AppName, cfg.Env)
 	gin.SetMode(cfg.GinMode)
+	disabled, err := cfg.DisabledModules()
+	if err != nil {
+		log.Fatalf("invalid MODULES_DISABLED: %v", err)
+	}
+	if disabled[config.ModuleEmail] {
+		// Handlers and workers check this before enqueueing any email
+		cfg.MailSendEnabled = false
+	}
+
+	// Components register their Close here; it runs in phase order once the server has stopped,
+	// or when main returns early (--routes, MIGRATE_DRY_RUN)
+	shutdown := helpers.NewShutdownRegistry(logger, cfg.ShutdownComponentTimeout)
+	container.SetShutdown(shutdown)
+	defer func() { _ = shutdown.Run(context.Background()) }()
 
 	// Initialize custom validator with locale translations (uses JSON field names, alias tags)
 	validation.Init(cfg.ValidationLocale)
+	validation.SetFormat(cfg.ValidationErrorFormat)
+	// Which email aliases resolve to the same account (EMAIL_FOLD_*)
+	helpers.SetEmailPolicy(helpers.EmailPolicyFromConfig(cfg))
 
 	ctx := context.Background()
 
-	// Initialize Postgres pool
-	pool, err := pginfra.NewPool(ctx, cfg.PostgresDSN(), cfg.DBMaxConns, cfg.DBMinConns, cfg.DBMaxConnLife)
+	retry := helpers.StartupRetry(cfg)
+
+	// Initialize Postgres pool (retried so orchestrators can start the DB after us)
+	var pool *pgxpool.Pool
+	err = helpers.Retry(ctx, retry, "postgres", logger, func() error {
+		p, pErr := pginfra.NewPool(ctx, cfg.PostgresDSN(), cfg.DBMaxConns, cfg.DBMinConns, cfg.DBMaxConnLife, cfg.DBPgBouncer)
+		if pErr != nil {
+			return pErr
+		}
+		pool = p
+		return nil
+	})
 	if err != nil {
 		log.Fatalf("failed to connect to postgres: %v", err)
 	}
-	defer pool.Close()
+	shutdown.Register("postgres", helpers.ShutdownStores, helpers.CloseFunc(pool.Close))
 
-	// Run migrations using database/sql with pgx stdlib
-	if err := runMigrations(cfg.PostgresDSN(), cfg.MigrationsDir, logger); err != nil && !errors.Is(migrate.ErrNoChange, err) {
-		log.Fatalf("migration failed: %v", err)
+	// Run migrations using database/sql with pgx stdlib (a --routes listing leaves the schema alone)
+	if !*routesOnly {
+		migrateOpts, mErr := migrateOptionsFromConfig(cfg)
+		if mErr != nil {
+			log.Fatalf("migration config: %v", mErr)
+		}
+		if err := runMigrations(pginfra.StdlibDSN(cfg.PostgresDSN(), cfg.DBPgBouncer), cfg.MigrationsDir, migrateOpts, logger); err != nil && !errors.Is(migrate.ErrNoChange, err) {
+			log.Fatalf("migration failed: %v", err)
+		}
+		if migrateOpts.DryRun {
+			logger.Info("MIGRATE_DRY_RUN=true: migration plan printed, exiting without starting the server")
+			return
+		}
 	}
 
-	// Redis
-	rdb := helpers.NewRedisClient(cfg.RedisAddr, cfg.RedisPassword, cfg.RedisDB)
-	defer func() { _ = rdb.Close() }()
+	// Redis; session and cache keys carry the local region (REGION)
+	keyspace.SetPrefix(cfg.RedisKeyPrefix)
+	keyspace.SetRegion(cfg.Region)
+	redisOpts, err := cfg.RedisOptions()
+	if err != nil {
+		log.Fatalf("invalid redis config: %v", err)
+	}
+	// Development with REDIS_ADDR empty: an in-process Redis stands in, so go run works without Docker
+	var memRedis *helpers.MemoryRedis
+	if cfg.RedisInMemory() {
+		if memRedis, err = helpers.StartMemoryRedis(); err != nil {
+			log.Fatalf("in-memory redis: %v", err)
+		}
+		redisOpts.Addr = memRedis.Addr()
+		logger.Warn("REDIS_ADDR is empty: using in-memory Redis (sessions, OTPs and rate limits are per-process and lost on restart)")
+	}
+	rdb := helpers.NewRedisClientFromOptions(redisOpts)
+	shutdown.Register("redis", helpers.ShutdownStores, helpers.CloseErr(rdb.Close))
+	if memRedis != nil {
+		shutdown.Register("in-memory redis", helpers.ShutdownStores, helpers.CloseFunc(memRedis.Close))
+	}
+	if rErr := helpers.Retry(ctx, retry, "redis", logger, func() error {
+		pingCtx, cancel := context.WithTimeout(ctx, 3*time.Second)
+		defer cancel()
+		return rdb.Ping(pingCtx).Err()
+	}); rErr != nil {
+		// Keep previous behavior: start anyway (rate limiting fails open)
+		logger.WithError(rErr).Error("redis unreachable after retries; continuing")
+	}
 
 	// GCS (available for DI in services that need it)
 	var gcsClient *storage.Client
@@ -72,28 +163,66 @@ func main() {
 			log.Fatalf("failed to init GCS client: %v", err)
 		}
 		container.SetGCS(gcsClient)
-		defer func() { _ = gcsClient.Close() }()
+		shutdown.Register("gcs", helpers.ShutdownClients, helpers.CloseErr(gcsClient.Close))
 	} else {
 		logger.Warn("GCS client not initialized (GCSCredentialsJSONPath is empty)")
 	}
 
 	// JWT
-	jwtManager := helpers.NewJWTManager(cfg.JWTAccessSecret, cfg.JWTRefreshSecret, cfg.AccessTTL, cfg.RefreshTTL)
+	jwtManager := helpers.NewJWTManager(cfg.JWTAccessSecret, cfg.JWTRefreshSecret, cfg.AccessTTL, cfg.RefreshTTL, cfg.JWTAudience)
+	jwtManager.Leeway = cfg.JWTLeeway
 
 	// RabbitMQ publisher for email queue
 	var rabbitPub *helpers.RabbitPublisher
-	if cfg.RabbitMQURL != "" {
-		rabbitPub, err = helpers.NewRabbitPublisher(cfg.RabbitMQURL, cfg.RabbitMQEmailQueue)
+	if cfg.RabbitMQURL != "" && !disabled[config.ModuleEmail] {
+		amqpURL, amqpTLS, dErr := helpers.RabbitDialConfig(cfg)
+		if dErr != nil {
+			log.Fatalf("invalid rabbitmq config: %v", dErr)
+		}
+		err = helpers.Retry(ctx, retry, "rabbitmq", logger, func() error {
+			p, pErr := helpers.NewRabbitPublisher(amqpURL, amqpTLS, helpers.EmailTopology(cfg))
+			if pErr != nil {
+				return pErr
+			}
+			rabbitPub = p
+			return nil
+		})
 		if err != nil {
 			logger.WithError(err).Warn("failed to connect to RabbitMQ; email enqueue will be unavailable")
 		} else {
-			defer rabbitPub.Close()
+			shutdown.Register("rabbitmq email publisher", helpers.ShutdownClients, helpers.CloseFunc(rabbitPub.Close))
+			// The other worker queues are published to from here too; declared up front so messages
+			// sent before a worker first starts are kept
+			for _, kind := range cfg.WorkerQueueList() {
+				if t, tErr := helpers.WorkerTopology(cfg, kind); tErr == nil && kind != helpers.WorkerQueueEmails {
+					if dErr := rabbitPub.Declare(t); dErr != nil {
+						logger.WithError(dErr).Warnf("worker queue %s not declared", kind)
+					}
+				}
+			}
+		}
+	}
+
+	// User lifecycle events exchange (its own connection, so event traffic never blocks email enqueue)
+	var userEventPub *helpers.RabbitPublisher
+	if cfg.RabbitMQURL != "" && cfg.UserEventsExchange != "" {
+		amqpURL, amqpTLS, dErr := helpers.RabbitDialConfig(cfg)
+		if dErr != nil {
+			log.Fatalf("invalid rabbitmq config: %v", dErr)
+		}
+		if p, pErr := helpers.NewRabbitPublisher(amqpURL, amqpTLS, helpers.UserEventsTopology(cfg)); pErr != nil {
+			logger.WithError(pErr).Warn("failed to connect to RabbitMQ; user events will not be published")
+		} else {
+			userEventPub = p
+			shutdown.Register("rabbitmq user events publisher", helpers.ShutdownClients, helpers.CloseFunc(userEventPub.Close))
 		}
 	}
 
 	// Mailgun client (used by background worker; also exposed for any direct sends if needed)
 	var mgClient *mailer.Mailgun
-	if cfg.MailgunDomain != "" && cfg.MailgunAPIKey != "" && cfg.MailgunSender != "" {
+	if disabled[config.ModuleEmail] {
+		logger.Info("email module disabled; RabbitMQ email queue and Mailgun not connected")
+	} else if cfg.MailgunDomain != "" && cfg.MailgunAPIKey != "" && cfg.MailgunSender != "" {
 		mgClient = mailer.NewMailgun(cfg.MailgunDomain, cfg.MailgunAPIKey, cfg.MailgunSender)
 	} else {
 		logger.Warn("Mailgun not fully configured; worker will fail to send emails")
@@ -101,7 +230,7 @@ func main() {
 
 	// Elasticsearch client
 	var esClient *elasticsearch.Client
-	if len(cfg.ESAddrs()) > 0 {
+	if len(cfg.ESAddrs()) > 0 && !disabled[config.ModuleSearch] {
 		if c, esErr := helpers.NewESClient(cfg.ESAddrs(), cfg.ElasticsearchUser, cfg.ElasticsearchPass); esErr != nil {
 			logger.WithError(esErr).Warn("failed to init Elasticsearch client")
 		} else {
@@ -114,75 +243,132 @@ func main() {
 	container.SetLogger(logger)
 	container.SetPGPool(pool)
 	container.SetRedis(rdb)
+	redisBatch := helpers.NewRedisBatch(rdb, helpers.NewRedisBatchMetrics())
+	redisBatch.Size = cfg.RedisBatchSize
+	container.SetRedisBatch(redisBatch)
+	invalidations := helpers.NewSessionInvalidations(rdb, logger)
+	container.SetSessionInvalidations(invalidations)
+	container.SetSessionStore(newSessionStore(cfg, rdb, redisBatch, invalidations, logger))
 	container.SetGCS(gcsClient)
 	container.SetJWT(jwtManager)
 	container.SetRabbitPub(rabbitPub)
+	container.SetUserEventPub(userEventPub)
 	container.SetMailgun(mgClient)
 	container.SetES(esClient)
+	if disabled[config.ModuleSearch] {
+		container.SetSearch(search.Noop{})
+	} else {
+		container.SetSearch(newSearchService(cfg, esClient, pool, logger))
+	}
+	if cfg.SearchCanaryBackend != "" && !disabled[config.ModuleSearch] {
+		container.SetSearchCanary(searchBackend("SEARCH_CANARY_BACKEND", cfg.SearchCanaryBackend, cfg, esClient, pool, logger))
+	}
+	featureDefaults, err := helpers.ParseFeatureFlags(cfg.FeatureFlags)
+	if err != nil {
+		log.Fatalf("invalid FEATURE_FLAGS: %v", err)
+	}
+	container.SetFeatures(helpers.NewFeatures(featureDefaults))
+	// Circuit breakers for optional dependencies; open ones are listed in response meta.degraded
+	breakers := helpers.NewBreakers(cfg.BreakerFailureThreshold, cfg.BreakerCooldown)
+	container.SetBreakers(breakers)
+	if rabbitPub != nil {
+		rabbitPub.Breaker = breakers.Get(helpers.DependencyEmail)
+	}
+	geo := mailtpl.NewGeoResolver(cfg)
+	if _, noop := geo.(mailtpl.NoopResolver); !noop {
+		geo = helpers.BreakerGeo{Resolver: geo, Breaker: breakers.Get(helpers.DependencyGeo)}
+	}
+	container.SetGeo(geo)
+	// Background dependency probes for load shedding and GET /api/status
+	deps := newDependencyMonitor(cfg, pool, rdb, rabbitPub, esClient, mgClient, breakers)
+	container.SetDependencies(deps)
+	depsCtx, stopDeps := context.WithCancel(ctx)
+	shutdown.Register("dependency probes", helpers.ShutdownClients, helpers.CloseFunc(stopDeps))
+	go deps.Run(depsCtx)
+	drain := helpers.NewDrainState()
+	container.SetDrain(drain)
+	bus := helpers.NewEventBus(cfg.EventBusBuffer, cfg.EventBusWorkers, logger)
+	container.SetEventBus(bus)
+	// Closed after the audit writer flushes, so its queued indexing events are still handled
+	shutdown.Register("event bus", helpers.ShutdownEvents, helpers.CloseCtx(bus.Close))
+
+	// Session revocations from any replica purge local state here
+	invalidateCtx, stopInvalidations := context.WithCancel(ctx)
+	shutdown.Register("session invalidations", helpers.ShutdownClients, helpers.CloseFunc(stopInvalidations))
+	go invalidations.Run(invalidateCtx)
+
+	// Continuous profiling (PROFILING_ENABLED)
+	if prof := helpers.ProfilerFromConfig(cfg, "api", logger); prof != nil {
+		profCtx, stopProfiler := context.WithCancel(ctx)
+		shutdown.Register("profiler", helpers.ShutdownClients, helpers.CloseFunc(stopProfiler))
+		go prof.Run(profCtx)
+		logger.Infof("continuous profiling to %s (version %s)", cfg.ProfilingServerURL, helpers.BuildVersion())
+	}
+
+	// Error tracker for recovered panics (ERROR_TRACKER_DSN)
+	tracker, err := helpers.ErrorReporterFromConfig(cfg, logger)
+	if err != nil {
+		log.Fatalf("invalid ERROR_TRACKER_DSN: %v", err)
+	}
+	var reporter helpers.ErrorReporter
+	if tracker != nil {
+		reporter = tracker
+		trackerCtx, stopTracker := context.WithCancel(ctx)
+		shutdown.Register("error tracker", helpers.ShutdownClients, helpers.CloseFunc(stopTracker))
+		go tracker.Run(trackerCtx)
+	}
 
 	// Gin engine and global middleware
 	r := gin.New()
-	r.Use(gin.Recovery())
-
-	// Trusted proxies: for dev use none; in prod, whitelist Cloudflare ranges
-	if cfg.Env == "production" {
-		_ = r.SetTrustedProxies([]string{
-			// Cloudflare IPv4
-			"173.245.48.0/20",
-			"103.21.244.0/22",
-			"103.22.200.0/22",
-			"103.31.4.0/22",
-			"141.101.64.0/18",
-			"108.162.192.0/18",
-			"190.93.240.0/20",
-			"188.114.96.0/20",
-			"197.234.240.0/22",
-			"198.41.128.0/17",
-			"162.158.0.0/15",
-			"104.16.0.0/13",
-			"104.24.0.0/14",
-			"172.64.0.0/13",
-			"131.0.72.0/22",
-			// Cloudflare IPv6
-			"2400:cb00::/32",
-			"2606:4700::/32",
-			"2803:f800::/32",
-			"2405:b500::/32",
-			"2405:8100::/32",
-			"2a06:98c0::/29",
-			"2c0f:f248::/32",
-		})
-	} else {
-		_ = r.SetTrustedProxies(nil)
-	}
-
-	// Request ID then Real IP extraction
-	r.Use(middleware.RequestIDMiddleware())
-	r.Use(middleware.RealIP())
-	// CORS
-	corsCfg := cors.Config{
-		AllowOrigins:     cfg.CORSOrigins(),
-		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
-		AllowCredentials: true,
-		MaxAge:           12 * time.Hour,
-	}
-	r.Use(cors.New(corsCfg))
-	// Enable access log only when explicitly turned on
-	if cfg.HTTPLogEnabled {
-		// Also skip debug metrics paths when logging is enabled
-		r.Use(gin.LoggerWithConfig(gin.LoggerConfig{SkipPaths: []string{"/debug/vars", "/api/debug/vars"}}))
-	}
-
-	// Temporarily disable rate limiter
-	r.Use(middleware.RateLimit(
-		rdb,
-		300,
-		time.Minute,
-		middleware.KeyByIPAndPath(),
-		middleware.AllowPrivateIP(),
-	))
+	r.Use(middleware.Recovery(logger, reporter))
+
+	// Trusted proxies (TRUSTED_PROXIES); the cloudflare preset is refreshed in the background
+	trusted, err := helpers.NewTrustedProxies(cfg.TrustedProxyList())
+	if err != nil {
+		log.Fatalf("invalid TRUSTED_PROXIES: %v", err)
+	}
+	if err := r.SetTrustedProxies(trusted.CIDRs()); err != nil {
+		log.Fatalf("invalid TRUSTED_PROXIES: %v", err)
+	}
+	refreshCtx, stopRefresh := context.WithCancel(ctx)
+	shutdown.Register("proxy and cors refresh", helpers.ShutdownClients, helpers.CloseFunc(stopRefresh))
+	go trusted.RefreshCloudflare(refreshCtx, cfg.CloudflareIPsRefresh, logger)
+
+	// CORS origins: CORS_ALLOWED_ORIGINS plus the Redis-managed allowlist, reloaded in the background
+	corsOrigins, err := helpers.NewCORSOrigins(rdb, logger, cfg.CORSOrigins())
+	if err != nil {
+		log.Fatalf("invalid CORS_ALLOWED_ORIGINS: %v", err)
+	}
+	container.SetCORSOrigins(corsOrigins)
+	go corsOrigins.Run(refreshCtx, cfg.CORSRefreshInterval)
+
+	// Rate limit bypass entries (IPs, CIDRs, users, API keys) managed at runtime in Redis
+	rateBypass := helpers.NewRateBypass(rdb, logger)
+	container.SetRateBypass(rateBypass)
+	go rateBypass.Run(refreshCtx, cfg.RateBypassRefreshInterval)
+
+	// Global middleware, assembled in HTTP_MIDDLEWARE order (timing first so duration_ms and the
+	// latency histograms cover the whole chain)
+	latency := helpers.NewLatencyHistograms()
+	container.SetLatency(latency)
+	reg := router.NewRegistry(r)
+	reg.Disabled = disabled
+	// Login outcomes by country/ASN on /metrics
+	if cfg.MetricsEnabled {
+		var authGeo mailtpl.GeoResolver
+		if cfg.LoginMetricsGeo {
+			authGeo = geo
+		}
+		authMetrics := helpers.NewAuthMetrics(authGeo, cfg.LoginMetricsMaxSeries)
+		authMetrics.Start()
+		container.SetAuthMetrics(authMetrics)
+		reg.OnShutdown("auth metrics", authMetrics.Close)
+	}
+	enabled, err := reg.UseGlobal(globalMiddleware(cfg, logger, rdb, trusted, corsOrigins, latency, reg), cfg.HTTPMiddlewareList())
+	if err != nil {
+		log.Fatalf("invalid HTTP_MIDDLEWARE: %v", err)
+	}
+	logger.Debugf("global middleware: %s", strings.Join(enabled, ", "))
 
 	// Example routes to show client vs real IP
 	r.GET("/ip", func(c *gin.Context) {
@@ -202,54 +388,365 @@ func main() {
 		c.JSON(http.StatusOK, gin.H{"status": "ok"})
 	})
 	// Registry: auto-register modules using container
-	reg := router.NewRegistry(r)
 	router.InitModules(reg)
 	reg.RegisterAll()
+	if *schemaOut != "" {
+		b, err := json.MarshalIndent(reg.Routes(), "", "  ")
+		if err == nil {
+			err = os.WriteFile(*schemaOut, append(b, '\n'), 0o644)
+		}
+		if err != nil {
+			log.Fatalf("schema: %v", err)
+		}
+		return
+	}
+	if *routesOnly {
+		printRoutes(os.Stdout, reg.Routes())
+		return
+	}
+	startup := reg.StartupReport(ctx)
+	container.SetStartup(startup)
+	startup.Log(logger)
+
+	// Embedded queue consumer (single-binary mode)
+	workerCtx, stopWorker := context.WithCancel(context.Background())
+	workerDone := startEmbeddedWorker(workerCtx, cfg, rabbitPub, mgClient, logger)
+	// Stopped after HTTP and the background jobs so they can still enqueue
+	shutdown.RegisterTimeout("embedded worker", helpers.ShutdownConsumers, cfg.DrainTimeout, func(ctx context.Context) error {
+		stopWorker()
+		select {
+		case <-workerDone:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
 
-	srv := &http.Server{Addr: ":" + cfg.Port, Handler: r}
+	serverTLS, err := cfg.ServerTLS()
+	if err != nil {
+		logger.Fatalf("server tls: %v", err)
+	}
+	// The listener may be inherited from the previous process (RESTART_MODE=inherit)
+	ln, inherited, err := helpers.Listen(":"+cfg.Port, cfg.RestartMode == helpers.RestartModeReusePort)
+	if err != nil {
+		logger.Fatalf("listen: %v", err)
+	}
+	srv := &http.Server{Addr: ":" + cfg.Port, Handler: r, TLSConfig: serverTLS}
+	// In-flight requests get up to DRAIN_TIMEOUT
+	shutdown.RegisterTimeout("http server", helpers.ShutdownHTTP, cfg.DrainTimeout, srv.Shutdown)
 	go func() {
-		logger.Infof("server starting on :%s", cfg.Port)
-		if err := srv.ListenAndServe(); err != nil && !errors.Is(http.ErrServerClosed, err) {
+		var err error
+		from := ""
+		if inherited {
+			from = " (inherited socket)"
+		}
+		if serverTLS != nil {
+			logger.Infof("server starting on :%s (tls)%s", cfg.Port, from)
+			err = srv.ServeTLS(ln, "", "")
+		} else {
+			logger.Infof("server starting on :%s%s", cfg.Port, from)
+			err = srv.Serve(ln)
+		}
+		if err != nil && !errors.Is(http.ErrServerClosed, err) {
 			logger.Fatalf("listen: %s\n", err)
 		}
 	}()
+	if helpers.StartedByUpgrade() {
+		go handOver(r, cfg, logger)
+	} else {
+		writePIDFile(cfg.PIDFile, logger)
+	}
 
-	// Graceful shutdown
+	// Graceful shutdown: a signal or POST /internal/drain starts draining; with RESTART_MODE=inherit,
+	// SIGHUP starts a new process on the same socket and this one exits once it is ready
 	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+	signals := []os.Signal{syscall.SIGINT, syscall.SIGTERM, syscall.SIGUSR1}
+	if cfg.RestartMode == helpers.RestartModeInherit {
+		signals = append(signals, syscall.SIGHUP)
+	}
+	signal.Notify(quit, signals...)
+	delay := cfg.DrainDelay
+	upgraded := false
+wait:
+	for {
+		select {
+		case sig := <-quit:
+			if sig == syscall.SIGHUP {
+				logger.Info("received SIGHUP, starting new process")
+				child, err := helpers.Upgrade(ln, cfg.UpgradeTimeout)
+				if err != nil {
+					logger.WithError(err).Error("upgrade failed, still serving")
+					continue
+				}
+				// The socket stays open in the new process, so no drain delay: stop accepting and finish in-flight requests
+				logger.Infof("new process %d is ready, handing over", child.Pid)
+				upgraded, delay = true, 0
+				break wait
+			}
+			logger.Infof("received %s, draining", sig)
+			drain.Start()
+			if sig == syscall.SIGINT {
+				delay = 0 // interactive stop
+			}
+		case <-drain.Done():
+			logger.Info("drain requested via /internal/drain")
+		}
+		break
+	}
+	// Readiness now fails (unless handing over); keep serving until load balancers have stopped routing here
+	srv.SetKeepAlivesEnabled(false)
+	if delay > 0 {
+		time.Sleep(delay)
+	}
 	logger.Info("shutting down server")
 
-	ctxShutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	if err := srv.Shutdown(ctxShutdown); err != nil {
-		logger.Fatalf("server forced to shutdown: %v", err)
+	// HTTP server, background jobs, buffered writers, event bus, email worker, clients, then stores
+	if err := shutdown.Run(context.Background()); err != nil {
+		logger.WithError(err).Warn("shutdown incomplete")
+	}
+	if !upgraded {
+		removePIDFile(cfg.PIDFile)
 	}
 	logger.Info("server exited properly")
 }
 
-func runMigrations(dsn string, migrationsDir string, logger *logrus.Logger) error {
-	// Resolve migrationsDir to an absolute path and verify it exists
-	absDir, err := filepath.Abs(migrationsDir)
+// handOver runs in a process started by helpers.Upgrade: once /readyz passes (checked in-process, as a
+// request on the shared socket could reach the old process) it tells the parent to exit.
+func handOver(h http.Handler, cfg *config.Config, logger *logrus.Logger) {
+	deadline := time.Now().Add(cfg.UpgradeTimeout)
+	for {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		if rec.Code == http.StatusOK {
+			break
+		}
+		if time.Now().After(deadline) {
+			logger.Errorf("not ready after %s (readyz %d); the old process will stop this one", cfg.UpgradeTimeout, rec.Code)
+			return
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+	if err := helpers.NotifyReady(); err != nil {
+		logger.WithError(err).Error("could not signal readiness to the old process")
+		return
+	}
+	writePIDFile(cfg.PIDFile, logger)
+	logger.Info("took over from the old process")
+}
+
+func writePIDFile(path string, logger *logrus.Logger) {
+	if path == "" {
+		return
+	}
+	if err := os.WriteFile(path, []byte(strconv.Itoa(os.Getpid())+"\n"), 0o644); err != nil {
+		logger.WithError(err).Warn("write pid file failed")
+	}
+}
+
+// removePIDFile deletes the pid file unless another process has taken it over
+func removePIDFile(path string) {
+	if path == "" {
+		return
+	}
+	if b, err := os.ReadFile(path); err == nil && strings.TrimSpace(string(b)) == strconv.Itoa(os.Getpid()) {
+		_ = os.Remove(path)
+	}
+}
+
+// startEmbeddedWorker runs the queue consumer in-process when RUN_EMBEDDED_WORKER=true, for the
+// queues WORKER_QUEUES lists. It reuses the publisher's AMQP connection, the Mailgun client from
+// main and the export runner the router registered. The returned channel is closed when the
+// consumer has stopped (immediately if disabled).
+func startEmbeddedWorker(ctx context.Context, cfg *config.Config, pub *helpers.RabbitPublisher, mg *mailer.Mailgun, logger *logrus.Logger) <-chan struct{} {
+	done := make(chan struct{})
+	if !cfg.RunEmbeddedWorker {
+		close(done)
+		return done
+	}
+	if pub == nil {
+		logger.Warn("embedded worker disabled: RabbitMQ unavailable")
+		close(done)
+		return done
+	}
+	deps := worker.Deps{Redis: container.GetRedis(), Exports: container.GetExportRunner()}
+	if cfg.MailSendEnabled && slices.Contains(cfg.WorkerQueueList(), helpers.WorkerQueueEmails) {
+		deps.Email = embeddedEmailConsumer(cfg, mg, logger)
+	}
+	reg, err := worker.NewRegistryFromConfig(cfg, deps)
 	if err != nil {
-		return fmt.Errorf("resolve migrations dir: %w", err)
-	}
-	if _, statErr := os.Stat(absDir); os.IsNotExist(statErr) {
-		// Try relative to the executable directory (useful when running compiled binary)
-		exePath, exeErr := os.Executable()
-		if exeErr == nil {
-			exeDir := filepath.Dir(exePath)
-			alt := filepath.Join(exeDir, migrationsDir)
-			if _, altErr := os.Stat(alt); altErr == nil {
-				absDir = alt
-			} else {
-				logger.Errorf("migrations dir not found: %s (also tried %s)", absDir, alt)
-				return fmt.Errorf("migrations dir not found: %s", absDir)
+		logger.WithError(err).Warn("embedded worker disabled: invalid WORKER_QUEUES")
+		close(done)
+		return done
+	}
+	if len(reg.Queues()) == 0 {
+		close(done)
+		return done
+	}
+	consumer := worker.NewConsumer(pub.Channel, reg)
+	var queues []string
+	for _, q := range reg.Queues() {
+		queues = append(queues, q.Name())
+	}
+	go func() {
+		defer close(done)
+		logger.Infof("embedded worker listening on queues=%s driver=%s", strings.Join(queues, ","), cfg.MailDriver)
+		if err := consumer.Run(ctx); err != nil {
+			logger.WithError(err).Error("embedded worker stopped")
+		}
+	}()
+	return done
+}
+
+// embeddedEmailConsumer sets up the emails queue handler; nil (with a warning) when the mail
+// driver or the templates are unusable
+func embeddedEmailConsumer(cfg *config.Config, mg *mailer.Mailgun, logger *logrus.Logger) *worker.EmailConsumer {
+	var sender mailer.Sender
+	if mg != nil && (cfg.MailDriver == "" || strings.EqualFold(cfg.MailDriver, "mailgun")) {
+		sender = mg
+	} else {
+		s, err := mailer.NewSender(cfg)
+		if err != nil {
+			logger.WithError(err).Warn("embedded email worker disabled: mail driver unavailable")
+			return nil
+		}
+		sender = s
+	}
+	mailtpl.SetInlineCSS(cfg.MailInlineCSS)
+	mailtpl.SetLimits(cfg.MailTemplateTimeout, int64(cfg.MailTemplateMaxBytes))
+	if err := mailtpl.Setup(cfg.MailTemplatesDir); err != nil {
+		logger.WithError(err).Warn("embedded email worker disabled: email templates invalid")
+		return nil
+	}
+	consumer := worker.NewEmailConsumer(sender, container.GetGeo())
+	consumer.Limit = worker.NewRecipientLimit(container.GetRedis(), cfg.EmailRecipientLimitHourly, cfg.EmailRecipientLimitDaily)
+	consumer.Dedup = worker.NewJobDedup(container.GetRedis(), cfg.EmailDedupTTL)
+	consumer.Redis = container.GetRedis()
+	consumer.CorrelationTTL = cfg.CorrelationTTL
+	return consumer
+}
+
+// globalMiddleware lists the middleware HTTP_MIDDLEWARE can enable; see config.DefaultHTTPMiddleware for the default order
+func globalMiddleware(cfg *config.Config, logger *logrus.Logger, rdb *redis.Client, trusted *helpers.TrustedProxies, origins *helpers.CORSOrigins, latency *helpers.LatencyHistograms, reg *router.Registry) router.Pipeline {
+	return router.Pipeline{
+		"timing":     func() gin.HandlerFunc { return middleware.Timing(latency) },
+		"request_id": middleware.RequestIDMiddleware,
+		// meta.degraded from the dependency circuit breakers
+		"degraded": func() gin.HandlerFunc { return middleware.Degraded(container.GetBreakers()) },
+		"real_ip":  func() gin.HandlerFunc { return middleware.RealIP(trusted) },
+		// Request-scoped logger (request_id, route, ip; user_id after Auth) for helpers.FromContext
+		"request_logger": func() gin.HandlerFunc { return middleware.RequestLogger(logger) },
+		// Per-request feature flag overrides from trusted callers (signed X-Feature-Flags)
+		"feature_flags": func() gin.HandlerFunc {
+			if cfg.FeatureFlagsSecret == "" {
+				return nil
 			}
-		} else {
-			logger.Errorf("migrations dir not found: %s", absDir)
-			return fmt.Errorf("migrations dir not found: %s", absDir)
+			return middleware.FeatureFlags(cfg.FeatureFlagsSecret)
+		},
+		"cors": func() gin.HandlerFunc {
+			return cors.New(cors.Config{
+				AllowOriginFunc:  origins.Allow,
+				AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+				AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
+				ExposeHeaders:    []string{"Content-Length", "Deprecation", "Sunset", "Link"},
+				AllowCredentials: true,
+				MaxAge:           12 * time.Hour,
+			})
+		},
+		// Access log only when explicitly turned on; skips metrics and readiness polling
+		"access_log": func() gin.HandlerFunc {
+			if !cfg.HTTPLogEnabled {
+				return nil
+			}
+			return gin.LoggerWithConfig(gin.LoggerConfig{SkipPaths: []string{"/debug/vars", "/api/debug/vars", "/readyz", "/metrics"}})
+		},
+		// Redacted body logging for client integration debugging (opt-in, never in production)
+		"debug_body_log": func() gin.HandlerFunc {
+			routes := cfg.DebugBodyLogRouteList()
+			if (len(routes) == 0 && cfg.DebugBodyLogSecret == "") || !reg.Enabled(config.ModuleDebug) {
+				return nil
+			}
+			if cfg.Env == "production" {
+				logger.Warn("debug body logging is disabled in production")
+				return nil
+			}
+			return middleware.DebugBodyLog(logger, routes, cfg.DebugBodyLogSecret, cfg.DebugBodyLogMaxBytes)
+		},
+		// Mirror sampled read traffic to an alternate upstream and log response diffs
+		"shadow": func() gin.HandlerFunc {
+			if cfg.ShadowUpstream == "" || cfg.ShadowPercent <= 0 {
+				return nil
+			}
+			upstream, err := url.Parse(cfg.ShadowUpstream)
+			if err != nil || upstream.Scheme == "" || upstream.Host == "" {
+				logger.Fatalf("invalid SHADOW_UPSTREAM %q", cfg.ShadowUpstream)
+			}
+			return middleware.Shadow(logger, middleware.ShadowConfig{
+				Upstream:    upstream,
+				Percent:     min(cfg.ShadowPercent, 100),
+				Routes:      cfg.ShadowRouteList(),
+				Ignore:      cfg.ShadowIgnoreFieldList(),
+				Timeout:     cfg.ShadowTimeout,
+				MaxInflight: cfg.ShadowMaxInflight,
+				MaxBytes:    cfg.ShadowMaxBytes,
+			})
+		},
+		"security_headers": func() gin.HandlerFunc { return middleware.SecurityHeaders(cfg.SecurityHSTSMaxAge) },
+		"compression":      middleware.Compress,
+		// Global in-flight cap (before the Redis-backed rate limiter)
+		"inflight": func() gin.HandlerFunc {
+			return middleware.ConcurrencyLimit(cfg.MaxInflightRequests, cfg.InflightQueueWait, time.Second)
+		},
+		// Non-critical routes answer 503 while a dependency they need is degraded (LOAD_SHED_POLICY)
+		"load_shed": func() gin.HandlerFunc {
+			if !cfg.LoadShedEnabled {
+				return nil
+			}
+			return middleware.LoadShed(container.GetDependencies(), cfg.LoadShedRoutes())
+		},
+		"rate_limit": func() gin.HandlerFunc {
+			return middleware.RateLimit(rdb, 300, time.Minute, middleware.KeyByIPAndPath(), middleware.AllowAny(middleware.AllowPrivateIP(), middleware.AllowFromStore(container.GetRateBypass())))
+		},
+		// Routes declare a timeout class; the rest get REQUEST_TIMEOUT
+		"timeout": func() gin.HandlerFunc {
+			classes := map[string]time.Duration{route.TimeoutLong: cfg.RequestTimeoutLong, route.TimeoutNone: 0}
+			return middleware.Timeout(cfg.RequestTimeout, func(c *gin.Context) (time.Duration, bool) {
+				d, ok := classes[reg.TimeoutClass(c.Request.Method, c.FullPath())]
+				return d, ok
+			})
+		},
+	}
+}
+
+// printRoutes writes one line per route: method, path, module, auth, rate limits and middleware chain
+func printRoutes(w io.Writer, routes []route.Info) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "METHOD\tPATH\tMODULE\tAUTH\tRATE LIMITS\tMIDDLEWARE")
+	for _, rt := range routes {
+		limits := strings.Join(rt.RateLimits, "; ")
+		if limits == "" {
+			limits = "-"
 		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", rt.Method, rt.Path, rt.Module, rt.Auth, limits, strings.Join(append(rt.Middleware, rt.Handler), " > "))
+	}
+	_ = tw.Flush()
+}
+
+func runMigrations(dsn string, migrationsDir string, opts migrateOptions, logger *logrus.Logger) error {
+	// Embedded migrations by default; MIGRATIONS_DIR overrides with files on disk
+	var (
+		src     source.Driver
+		srcName string
+		err     error
+	)
+	if migrationsDir == "" {
+		src, err = iofs.New(dbmigrations.Migrations, "migrations")
+		srcName = "embedded db/migrations"
+	} else {
+		srcName = "file://" + filepath.ToSlash(migrationsDir)
+		src, err = source.Open(srcName)
+	}
+	if err != nil {
+		return fmt.Errorf("open migrations source %s: %w", srcName, err)
 	}
 
 	db, err := sql.Open("pgx", dsn)
@@ -261,16 +758,226 @@ func runMigrations(dsn string, migrationsDir string, logger *logrus.Logger) erro
 	if err != nil {
 		return err
 	}
-	srcURL := fmt.Sprintf("file://%s", filepath.ToSlash(absDir))
-	m, err := migrate.NewWithDatabaseInstance(srcURL, "postgres", driver)
+	m, err := migrate.NewWithInstance("migrations", src, "postgres", driver)
 	if err != nil {
 		return err
 	}
-	logger.Infof("running migrations from %s", srcURL)
-	err = m.Up()
+	if opts.DryRun {
+		return logMigrationPlan(m, src, opts.Target, logger)
+	}
+	if err := checkMigrationDirection(m, opts); err != nil {
+		return err
+	}
+	logger.Infof("running migrations from %s", srcName)
+	if opts.Target == nil {
+		err = m.Up()
+	} else if *opts.Target == 0 {
+		logger.Warn("MIGRATE_TARGET_VERSION=0: rolling back all migrations")
+		err = m.Down()
+	} else {
+		logger.Infof("migrating to target version %d", *opts.Target)
+		err = m.Migrate(*opts.Target)
+	}
 	if errors.Is(migrate.ErrNoChange, err) {
 		logger.Info("no migrations to run")
 		return nil
 	}
 	return err
 }
+
+// migrateOptions controls runMigrations: Target nil means latest; DryRun only logs the plan;
+// AllowDown permits a Target below the current version.
+type migrateOptions struct {
+	Target    *uint
+	DryRun    bool
+	AllowDown bool
+}
+
+// checkMigrationDirection refuses a rollback unless explicitly allowed: a target left in the
+// environment would otherwise drop data again on every start.
+func checkMigrationDirection(m *migrate.Migrate, opts migrateOptions) error {
+	if opts.Target == nil || opts.AllowDown {
+		return nil
+	}
+	current, _, err := m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if *opts.Target < current {
+		return fmt.Errorf("MIGRATE_TARGET_VERSION=%d is below the current version %d: set MIGRATE_ALLOW_DOWN=true to roll back (preview with MIGRATE_DRY_RUN=true)", *opts.Target, current)
+	}
+	return nil
+}
+
+func migrateOptionsFromConfig(cfg *config.Config) (migrateOptions, error) {
+	opts := migrateOptions{DryRun: cfg.MigrateDryRun, AllowDown: cfg.MigrateAllowDown}
+	if v := strings.TrimSpace(cfg.MigrateTargetVersion); v != "" {
+		n, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return opts, fmt.Errorf("invalid MIGRATE_TARGET_VERSION %q: %w", v, err)
+		}
+		t := uint(n)
+		opts.Target = &t
+	}
+	return opts, nil
+}
+
+// logMigrationPlan logs the migrations that would be applied (up) or rolled back (down)
+// to reach target (nil = latest) without touching the database schema.
+func logMigrationPlan(m *migrate.Migrate, src source.Driver, target *uint, logger *logrus.Logger) error {
+	current, dirty, err := m.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return err
+	}
+	if dirty {
+		logger.Warnf("database is dirty at version %d; fix it before migrating", current)
+	}
+
+	var versions []uint
+	v, err := src.First()
+	for err == nil {
+		versions = append(versions, v)
+		v, err = src.Next(v)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if len(versions) == 0 {
+		logger.Info("dry run: no migrations found")
+		return nil
+	}
+	dest := versions[len(versions)-1]
+	if target != nil {
+		dest = *target
+	}
+
+	var plan []string
+	if dest >= current {
+		for _, v := range versions {
+			if v > current && v <= dest {
+				plan = append(plan, migrationName(src, v, true))
+			}
+		}
+		logger.Infof("dry run: current version %d, target %d, %d pending up migration(s)", current, dest, len(plan))
+	} else {
+		for i := len(versions) - 1; i >= 0; i-- {
+			if v := versions[i]; v <= current && v > dest {
+				plan = append(plan, migrationName(src, v, false))
+			}
+		}
+		logger.Infof("dry run: current version %d, target %d, %d down migration(s)", current, dest, len(plan))
+	}
+	for _, p := range plan {
+		logger.Infof("dry run: would apply %s", p)
+	}
+	return nil
+}
+
+func migrationName(src source.Driver, v uint, up bool) string {
+	read, dir := src.ReadUp, "up"
+	if !up {
+		read, dir = src.ReadDown, "down"
+	}
+	r, ident, err := read(v)
+	if err != nil {
+		return fmt.Sprintf("%d (%s migration missing)", v, dir)
+	}
+	_ = r.Close()
+	return fmt.Sprintf("%d_%s.%s.sql", v, ident, dir)
+}
+
+// newDependencyMonitor probes the core dependencies (the /readyz checks) plus Elasticsearch and
+// Mailgun when configured; Mailgun is a rate-limited third-party API and is probed less often
+func newDependencyMonitor(cfg *config.Config, pool *pgxpool.Pool, rdb *redis.Client, pub *helpers.RabbitPublisher, es *elasticsearch.Client, mg *mailer.Mailgun, breakers *helpers.Breakers) *helpers.DependencyMonitor {
+	probes := map[string]helpers.DependencyProbe{
+		helpers.DependencyPostgres: func(ctx context.Context) error { return pool.Ping(ctx) },
+		helpers.DependencyRedis:    func(ctx context.Context) error { return rdb.Ping(ctx).Err() },
+	}
+	if pub != nil {
+		probes[helpers.DependencyRabbitMQ] = func(context.Context) error {
+			if pub.IsClosed() {
+				return errors.New("channel closed")
+			}
+			return nil
+		}
+	}
+	if es != nil {
+		probes[helpers.DependencySearch] = func(ctx context.Context) error {
+			res, err := es.Ping(es.Ping.WithContext(ctx))
+			if err != nil {
+				return err
+			}
+			defer func() { _ = res.Body.Close() }()
+			if res.IsError() {
+				return errors.New(res.Status())
+			}
+			return nil
+		}
+	}
+	mon := helpers.NewDependencyMonitor(probes, breakers, cfg.DependencyCheckInterval)
+	if mg != nil {
+		mon.Probes[helpers.DependencyMailgun] = mg.Ping
+		mon.Every[helpers.DependencyMailgun] = cfg.MailgunCheckInterval
+	}
+	return mon
+}
+
+// newSearchService picks the user search backend (SEARCH_BACKEND). auto uses Elasticsearch when a
+// client is configured and otherwise searches nothing; postgres runs ILIKE queries on the users table.
+func newSearchService(cfg *config.Config, es *elasticsearch.Client, pool *pgxpool.Pool, logger *logrus.Logger) repository.SearchService {
+	backend := cfg.SearchBackend
+	if backend == "" || backend == "auto" {
+		backend = repository.SearchNone
+		if es != nil {
+			backend = repository.SearchElasticsearch
+		}
+	}
+	return searchBackend("SEARCH_BACKEND", backend, cfg, es, pool, logger)
+}
+
+// searchBackend builds the named search backend; env names the setting in log messages
+func searchBackend(env, backend string, cfg *config.Config, es *elasticsearch.Client, pool *pgxpool.Pool, logger *logrus.Logger) repository.SearchService {
+	switch backend {
+	case repository.SearchElasticsearch:
+		if es == nil {
+			logger.Warnf("%s=elasticsearch but Elasticsearch is not configured; user search finds nothing", env)
+			return search.Noop{}
+		}
+		return search.NewElasticsearch(es, cfg.ESUsersIndex, cfg.ESUsersWriteAlias)
+	case repository.SearchPostgres:
+		return pginfra.NewUserSearch(pool)
+	case repository.SearchNone:
+		return search.Noop{}
+	}
+	log.Fatalf("invalid %s %q (elasticsearch, postgres or none; SEARCH_BACKEND also takes auto)", env, backend)
+	return nil
+}
+
+// newSessionStore picks the session backend; memory is per-process and meant for tests and single-instance dev.
+// Revocations are broadcast to all replicas; with memory each replica drops its own copy on receipt.
+// SESSION_REPLICAS mirrors session writes into other regions' keyspaces.
+func newSessionStore(cfg *config.Config, rdb *redis.Client, batch *helpers.RedisBatch, inv *helpers.SessionInvalidations, logger *logrus.Logger) repository.SessionStore {
+	local := redisstore.NewSessionStore(rdb)
+	local.Batch = batch
+	var store repository.SessionStore = local
+	if strings.EqualFold(cfg.SessionStore, "memory") {
+		logger.Warn("SESSION_STORE=memory: sessions are per-process and lost on restart")
+		mem := memory.NewSessionStore()
+		inv.OnInvalidate(func(ctx context.Context, i helpers.SessionInvalidation) {
+			_ = mem.Revoke(ctx, i.UserID, i.SessionID)
+		})
+		store = mem
+	}
+	replicas, err := redisstore.RegionReplicas(cfg.SessionReplicaMap(), rdb)
+	if err != nil {
+		log.Fatalf("invalid SESSION_REPLICAS: %v", err)
+	}
+	if len(replicas) > 0 {
+		store = redisstore.NewReplicatingSessionStore(store, replicas, logger)
+		logger.Infof("session writes replicated from region %q to %d other region(s)", cfg.Region, len(replicas))
+	}
+	return redisstore.NewInvalidatingSessionStore(store, inv, logger)
+}
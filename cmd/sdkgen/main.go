@@ -0,0 +1,564 @@
+// Command sdkgen generates a typed API client, TypeScript or Go, from the route schema the server
+// exports, so frontend and service clients stay in step with the handlers:
+//
+//	go run cmd/main.go --schema api-schema.json      # connects like the server, skips migrations
+//	go run cmd/sdkgen -in api-schema.json -lang ts -out web/src/api.ts
+//	go run cmd/sdkgen -in api-schema.json -lang go -package apiclient -out apiclient/client.go
+//
+// The input is the JSON of []route.Info, as written by --schema or returned in data by
+// GET /api/admin/routes. Every /api route becomes a client method named after its handler
+// (ExportHandler.Create -> exportCreate); path params become arguments. Request bodies and
+// response data are typed where the route declares Route.Request/Route.Response and fall back
+// to unknown (json.RawMessage in Go) elsewhere.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/router/route"
+)
+
+var (
+	in      = flag.String("in", "api-schema.json", "route schema (server --schema output or GET /api/admin/routes body)")
+	lang    = flag.String("lang", "ts", "client language: ts or go")
+	out     = flag.String("out", "", "output file (default stdout)")
+	pkgName = flag.String("package", "apiclient", "Go package name (-lang go)")
+	prefix  = flag.String("prefix", "/api/", "only routes under this path prefix")
+)
+
+// operation is one client method
+type operation struct {
+	Name     string // exported Go name; TypeScript lowers the first letter
+	Method   string
+	Path     string
+	Params   []string // path params in order
+	Auth     string
+	Request  *route.Schema
+	Response *route.Schema
+}
+
+func main() {
+	flag.Parse()
+	raw, err := os.ReadFile(*in)
+	if err != nil {
+		log.Fatal(err)
+	}
+	routes, err := readRoutes(raw)
+	if err != nil {
+		log.Fatalf("%s: %v", *in, err)
+	}
+	ops := operations(routes, *prefix)
+	types := namedTypes(ops)
+
+	var src []byte
+	switch *lang {
+	case "ts":
+		src = genTS(ops, types)
+	case "go":
+		src, err = format.Source(genGo(ops, types, *pkgName))
+		if err != nil {
+			log.Fatalf("generated Go does not compile: %v", err)
+		}
+	default:
+		log.Fatalf("unknown -lang %q (ts or go)", *lang)
+	}
+	if *out == "" {
+		_, _ = os.Stdout.Write(src)
+		return
+	}
+	if err := os.WriteFile(*out, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// readRoutes accepts a bare route list or a response envelope carrying it in data
+func readRoutes(raw []byte) ([]route.Info, error) {
+	var routes []route.Info
+	if err := json.Unmarshal(raw, &routes); err == nil {
+		return routes, nil
+	}
+	var env struct {
+		Data []route.Info `json:"data"`
+	}
+	if err := json.Unmarshal(raw, &env); err != nil {
+		return nil, err
+	}
+	return env.Data, nil
+}
+
+var handlerName = regexp.MustCompile(`\(\*(\w+?)(?:Handler)?\)\.(\w+?)(?:-fm)?$`)
+
+func operations(routes []route.Info, prefix string) []operation {
+	var ops []operation
+	used := map[string]bool{}
+	for _, ri := range routes {
+		if ri.Module == "engine" || !strings.HasPrefix(ri.Path, prefix) {
+			continue
+		}
+		op := operation{Method: ri.Method, Path: ri.Path, Auth: ri.Auth, Request: ri.Request, Response: ri.Response}
+		for _, seg := range strings.Split(ri.Path, "/") {
+			if strings.HasPrefix(seg, ":") || strings.HasPrefix(seg, "*") {
+				op.Params = append(op.Params, seg[1:])
+			}
+		}
+		if m := handlerName.FindStringSubmatch(ri.Handler); m != nil {
+			op.Name = m[1] + m[2]
+		}
+		if op.Name == "" || used[op.Name] {
+			op.Name = pathName(ri.Method, strings.TrimPrefix(ri.Path, prefix))
+		}
+		used[op.Name] = true
+		ops = append(ops, op)
+	}
+	sort.Slice(ops, func(i, j int) bool { return ops[i].Name < ops[j].Name })
+	return ops
+}
+
+// pathName names a route from its method and path: POST orgs/:org/email/send -> PostOrgsByOrgEmailSend
+func pathName(method, path string) string {
+	var b strings.Builder
+	b.WriteString(exported(strings.ToLower(method)))
+	for _, seg := range strings.Split(path, "/") {
+		switch {
+		case seg == "":
+		case strings.HasPrefix(seg, ":") || strings.HasPrefix(seg, "*"):
+			b.WriteString("By" + exported(seg[1:]))
+		default:
+			b.WriteString(exported(seg))
+		}
+	}
+	return b.String()
+}
+
+// exported turns a JSON or path name into an exported identifier: request_id -> RequestId
+func exported(s string) string {
+	var b strings.Builder
+	up := true
+	for _, r := range s {
+		if r == '_' || r == '-' || r == '.' || r == ' ' {
+			up = true
+			continue
+		}
+		if up {
+			b.WriteString(strings.ToUpper(string(r)))
+			up = false
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
+
+// namedType is a named object of the schemas; Request is set when it appears in a request body,
+// where fields without binding:"required" may be left out
+type namedType struct {
+	Schema  *route.Schema
+	Request bool
+}
+
+// namedTypes collects the named objects of all schemas, first definition wins, sorted by name
+func namedTypes(ops []operation) []namedType {
+	byName := map[string]*namedType{}
+	var walk func(s *route.Schema, req bool)
+	walk = func(s *route.Schema, req bool) {
+		if s == nil {
+			return
+		}
+		if s.Type == "object" && s.Name != "" && len(s.Fields) > 0 {
+			if nt, ok := byName[exported(s.Name)]; ok {
+				nt.Request = nt.Request || req
+				return
+			}
+			byName[exported(s.Name)] = &namedType{Schema: s, Request: req}
+		}
+		for _, f := range s.Fields {
+			walk(f.Schema, req)
+		}
+		walk(s.Elem, req)
+	}
+	for _, op := range ops {
+		walk(op.Request, true)
+		walk(op.Response, false)
+	}
+	names := make([]string, 0, len(byName))
+	for n := range byName {
+		names = append(names, n)
+	}
+	slices.Sort(names)
+	out := make([]namedType, 0, len(names))
+	for _, n := range names {
+		out = append(out, *byName[n])
+	}
+	return out
+}
+
+// optional reports whether a field may be missing: in requests unless required, in responses
+// when tagged omitempty
+func optional(f route.Field, req bool) bool {
+	if req {
+		return !f.Required
+	}
+	return f.Optional
+}
+
+// authNote is the doc comment suffix naming a route's auth
+func authNote(auth string) string {
+	if auth == "" {
+		return ""
+	}
+	return " (" + auth + ")"
+}
+
+const header = "// Code generated by cmd/sdkgen from the route schema. DO NOT EDIT.\n\n"
+
+// TypeScript
+
+func tsType(s *route.Schema, req bool) string {
+	if s == nil {
+		return "unknown"
+	}
+	t := tsBase(s, req)
+	if s.Nullable {
+		t += " | null"
+	}
+	return t
+}
+
+func tsBase(s *route.Schema, req bool) string {
+	if len(s.Enum) > 0 {
+		quoted := make([]string, len(s.Enum))
+		for i, e := range s.Enum {
+			quoted[i] = fmt.Sprintf("%q", e)
+		}
+		return strings.Join(quoted, " | ")
+	}
+	switch s.Type {
+	case "string":
+		return "string"
+	case "integer", "number":
+		return "number"
+	case "boolean":
+		return "boolean"
+	case "array":
+		t := tsType(s.Elem, req)
+		if strings.Contains(t, " ") {
+			t = "(" + t + ")"
+		}
+		return t + "[]"
+	case "map":
+		return "Record<string, " + tsType(s.Elem, req) + ">"
+	case "object":
+		if s.Name != "" {
+			return exported(s.Name)
+		}
+		return tsObject(s, req)
+	}
+	return "unknown"
+}
+
+func tsObject(s *route.Schema, req bool) string {
+	var b strings.Builder
+	b.WriteString("{ ")
+	for _, f := range s.Fields {
+		opt := ""
+		if optional(f, req) {
+			opt = "?"
+		}
+		fmt.Fprintf(&b, "%s%s: %s; ", f.Name, opt, tsType(f.Schema, req))
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+// tsInterface writes a named object one field per line
+func tsInterface(t namedType) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "export interface %s {\n", exported(t.Schema.Name))
+	for _, f := range t.Schema.Fields {
+		opt := ""
+		if optional(f, t.Request) {
+			opt = "?"
+		}
+		fmt.Fprintf(&b, "  %s%s: %s;\n", f.Name, opt, tsType(f.Schema, t.Request))
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func genTS(ops []operation, types []namedType) []byte {
+	var b bytes.Buffer
+	b.WriteString(header)
+	b.WriteString(`export interface Meta {
+  version: string;
+  request_id: string;
+  timestamp: string;
+  status: number;
+  duration_ms?: number;
+  deprecated?: boolean;
+  degraded?: string[];
+}
+
+export interface Envelope<T> {
+  meta: Meta;
+  data?: T;
+  error?: { message: string; details?: unknown };
+}
+
+export class ApiError extends Error {
+  constructor(public status: number, public body: Envelope<unknown>) {
+    super(body.error?.message ?? "HTTP " + status);
+  }
+}
+`)
+	for _, t := range types {
+		b.WriteString("\n" + tsInterface(t))
+	}
+	b.WriteString(`
+export class ApiClient {
+  constructor(private baseURL: string, private init: RequestInit = { credentials: "include" }) {}
+
+  private async call<T>(method: string, path: string, body?: unknown, query?: Record<string, string>): Promise<T> {
+    const qs = query ? "?" + new URLSearchParams(query).toString() : "";
+    const headers = new Headers(this.init.headers);
+    if (body !== undefined) headers.set("Content-Type", "application/json");
+    const res = await fetch(this.baseURL.replace(/\/$/, "") + path + qs, {
+      ...this.init,
+      method,
+      headers,
+      body: body === undefined ? undefined : JSON.stringify(body),
+    });
+    const env = (await res.json()) as Envelope<T>;
+    if (!res.ok) throw new ApiError(res.status, env);
+    return env.data as T;
+  }
+`)
+	for _, op := range ops {
+		var args []string
+		path := op.Path
+		for _, p := range op.Params {
+			args = append(args, p+": string")
+			path = strings.Replace(path, ":"+p, "${encodeURIComponent("+p+")}", 1)
+			path = strings.Replace(path, "*"+p, "${"+p+"}", 1)
+		}
+		body := "undefined"
+		switch {
+		case op.Request != nil:
+			args, body = append(args, "body: "+tsType(op.Request, true)), "body"
+		case op.Method == "POST" || op.Method == "PUT" || op.Method == "PATCH":
+			args, body = append(args, "body?: unknown"), "body"
+		}
+		args = append(args, "query?: Record<string, string>")
+		fmt.Fprintf(&b, "\n  /** %s %s%s */\n", op.Method, op.Path, authNote(op.Auth))
+		fmt.Fprintf(&b, "  %s(%s): Promise<%s> {\n", lowerFirst(op.Name), strings.Join(args, ", "), tsType(op.Response, false))
+		fmt.Fprintf(&b, "    return this.call(%q, `%s`, %s, query);\n  }\n", op.Method, path, body)
+	}
+	b.WriteString("}\n")
+	return b.Bytes()
+}
+
+// Go
+
+func goType(s *route.Schema, req bool) string {
+	if s == nil {
+		return "json.RawMessage"
+	}
+	t := goBase(s, req)
+	if s.Nullable && !strings.HasPrefix(t, "[]") && !strings.HasPrefix(t, "map[") && t != "any" {
+		t = "*" + t
+	}
+	return t
+}
+
+func goBase(s *route.Schema, req bool) string {
+	switch s.Type {
+	case "string":
+		switch s.Format {
+		case "date-time":
+			return "time.Time"
+		case "byte":
+			return "[]byte"
+		}
+		return "string"
+	case "integer":
+		if s.Format == "duration" {
+			return "time.Duration"
+		}
+		return "int64"
+	case "number":
+		return "float64"
+	case "boolean":
+		return "bool"
+	case "array":
+		return "[]" + goType(s.Elem, req)
+	case "map":
+		return "map[string]" + goType(s.Elem, req)
+	case "object":
+		if s.Name != "" {
+			return exported(s.Name)
+		}
+		return goStruct(s, req)
+	}
+	return "any"
+}
+
+func goStruct(s *route.Schema, req bool) string {
+	var b strings.Builder
+	b.WriteString("struct {\n")
+	for _, f := range s.Fields {
+		tag := f.Name
+		if optional(f, req) {
+			tag += ",omitempty"
+		}
+		fmt.Fprintf(&b, "%s %s `json:%q`\n", exported(f.Name), goType(f.Schema, req), tag)
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+func genGo(ops []operation, types []namedType, pkg string) []byte {
+	var b bytes.Buffer
+	b.WriteString(header)
+	fmt.Fprintf(&b, "package %s\n\n", pkg)
+	b.WriteString(`import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Meta is the meta block of every response
+type Meta struct {
+	Version    string    ` + "`json:\"version\"`" + `
+	RequestID  string    ` + "`json:\"request_id\"`" + `
+	Timestamp  time.Time ` + "`json:\"timestamp\"`" + `
+	Status     int       ` + "`json:\"status\"`" + `
+	DurationMS float64   ` + "`json:\"duration_ms,omitempty\"`" + `
+	Deprecated bool      ` + "`json:\"deprecated,omitempty\"`" + `
+	Degraded   []string  ` + "`json:\"degraded,omitempty\"`" + `
+}
+
+// Error is a non-2xx response
+type Error struct {
+	Status  int
+	Message string
+	Details json.RawMessage
+	Meta    Meta
+}
+
+func (e *Error) Error() string { return fmt.Sprintf("api: %d %s", e.Status, e.Message) }
+
+// Client calls the API at BaseURL; Header is sent with every request (e.g. Authorization)
+type Client struct {
+	BaseURL string
+	HTTP    *http.Client
+	Header  http.Header
+}
+
+func New(baseURL string) *Client {
+	return &Client{BaseURL: strings.TrimRight(baseURL, "/"), HTTP: http.DefaultClient, Header: http.Header{}}
+}
+
+func call[T any](ctx context.Context, c *Client, method, path string, body any, query url.Values) (T, error) {
+	var zero T
+	var rd *bytes.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return zero, err
+		}
+		rd = bytes.NewReader(b)
+	}
+	u := c.BaseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	var req *http.Request
+	var err error
+	if rd != nil {
+		req, err = http.NewRequestWithContext(ctx, method, u, rd)
+	} else {
+		req, err = http.NewRequestWithContext(ctx, method, u, nil)
+	}
+	if err != nil {
+		return zero, err
+	}
+	for k, vs := range c.Header {
+		req.Header[k] = vs
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	res, err := c.HTTP.Do(req)
+	if err != nil {
+		return zero, err
+	}
+	defer func() { _ = res.Body.Close() }()
+	var env struct {
+		Meta  Meta ` + "`json:\"meta\"`" + `
+		Data  T    ` + "`json:\"data\"`" + `
+		Error *struct {
+			Message string          ` + "`json:\"message\"`" + `
+			Details json.RawMessage ` + "`json:\"details\"`" + `
+		} ` + "`json:\"error\"`" + `
+	}
+	if err := json.NewDecoder(res.Body).Decode(&env); err != nil {
+		return zero, fmt.Errorf("api: %d: %w", res.StatusCode, err)
+	}
+	if res.StatusCode >= 300 {
+		e := &Error{Status: res.StatusCode, Meta: env.Meta}
+		if env.Error != nil {
+			e.Message, e.Details = env.Error.Message, env.Error.Details
+		}
+		return zero, e
+	}
+	return env.Data, nil
+}
+`)
+	for _, t := range types {
+		fmt.Fprintf(&b, "\ntype %s %s\n", exported(t.Schema.Name), goStruct(t.Schema, t.Request))
+	}
+	for _, op := range ops {
+		args := []string{"ctx context.Context"}
+		path := fmt.Sprintf("%q", op.Path)
+		for _, p := range op.Params {
+			name := lowerFirst(exported(p))
+			args = append(args, name+" string")
+			path = strings.Replace(path, ":"+p, `"+url.PathEscape(`+name+`)+"`, 1)
+			path = strings.Replace(path, "*"+p, `"+`+name+`+"`, 1)
+		}
+		path = strings.TrimSuffix(strings.TrimPrefix(path, `""+`), `+""`)
+		body := "nil"
+		switch {
+		case op.Request != nil:
+			args, body = append(args, "body "+goType(op.Request, true)), "body"
+		case op.Method == "POST" || op.Method == "PUT" || op.Method == "PATCH":
+			args, body = append(args, "body any"), "body"
+		}
+		args = append(args, "query url.Values")
+		fmt.Fprintf(&b, "\n// %s calls %s %s%s\n", op.Name, op.Method, op.Path, authNote(op.Auth))
+		fmt.Fprintf(&b, "func (c *Client) %s(%s) (%s, error) {\n", op.Name, strings.Join(args, ", "), goType(op.Response, false))
+		fmt.Fprintf(&b, "\treturn call[%s](ctx, c, %q, %s, %s, query)\n}\n", goType(op.Response, false), op.Method, path, body)
+	}
+	return b.Bytes()
+}
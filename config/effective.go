@@ -0,0 +1,63 @@
+package config
+
+import (
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Masked replaces a secret's value in Effective
+const Masked = "******"
+
+// secretField matches the fields whose values never leave the process (passwords, signing and
+// API keys, tokens, DSNs); URLs elsewhere keep their host but lose any password
+var secretField = regexp.MustCompile(`Password|Pass$|Secret|APIKey|LicenseKey|SigningKey|Token$|EncryptionKeys|DSN`)
+
+// Effective returns the resolved configuration by field name, with secrets masked (unset ones
+// stay empty, so it still shows whether they are configured) and durations as strings
+func (c *Config) Effective() map[string]any {
+	out := map[string]any{}
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		val := v.Field(i).Interface()
+		switch x := val.(type) {
+		case string:
+			switch {
+			case x == "":
+			case secretField.MatchString(f.Name):
+				val = Masked
+			default:
+				val = maskURLs(x)
+			}
+		case time.Duration:
+			val = x.String()
+		}
+		out[f.Name] = val
+	}
+	return out
+}
+
+// maskURLs redacts the password of every URL in a comma-separated value (user:xxxxx@host)
+func maskURLs(v string) string {
+	if !strings.Contains(v, "@") {
+		return v
+	}
+	parts := strings.Split(v, ",")
+	for i, p := range parts {
+		u, err := url.Parse(strings.TrimSpace(p))
+		if err != nil || u.User == nil {
+			continue
+		}
+		if _, ok := u.User.Password(); ok {
+			parts[i] = u.Redacted()
+		}
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,9 @@
+// Package db embeds the SQL migrations so the compiled binary carries its schema.
+package db
+
+import "embed"
+
+// Migrations holds db/migrations/*.sql for golang-migrate's iofs source.
+//
+//go:embed migrations/*.sql
+var Migrations embed.FS
@@ -0,0 +1,4 @@
+ALTER TABLE invitations DROP COLUMN IF EXISTS org_role;
+ALTER TABLE invitations DROP COLUMN IF EXISTS org_id;
+DROP TABLE IF EXISTS organization_members;
+DROP TABLE IF EXISTS organizations;
@@ -0,0 +1,8 @@
+-- Per-organization overrides of the default quotas; NULL falls back to the configured default, 0 = unlimited
+CREATE TABLE IF NOT EXISTS organization_limits (
+  org_id UUID PRIMARY KEY REFERENCES organizations(id) ON DELETE CASCADE,
+  rate_per_minute INT,
+  requests_per_day INT,
+  emails_per_day INT,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,13 @@
+-- Hourly API usage rolled up from Redis counters; subject is user:<id> or the internal client (key:<fingerprint>, cn:<name>)
+CREATE TABLE IF NOT EXISTS api_usage (
+  bucket TIMESTAMPTZ NOT NULL,
+  subject TEXT NOT NULL,
+  method TEXT NOT NULL,
+  route TEXT NOT NULL,
+  requests BIGINT NOT NULL DEFAULT 0,
+  bytes_in BIGINT NOT NULL DEFAULT 0,
+  bytes_out BIGINT NOT NULL DEFAULT 0,
+  PRIMARY KEY (bucket, subject, method, route)
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_usage_subject ON api_usage(subject, bucket);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_audit_logs_created;
@@ -0,0 +1,2 @@
+-- Time-range scans for audit log exports
+CREATE INDEX IF NOT EXISTS idx_audit_logs_created ON audit_logs (created_at);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS user_events;
+DROP FUNCTION IF EXISTS user_events_append_only();
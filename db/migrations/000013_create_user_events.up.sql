@@ -0,0 +1,22 @@
+-- Append-only history of the user aggregate: one row per state change, numbered per user.
+-- No foreign key, so a user's history outlives the account.
+CREATE TABLE IF NOT EXISTS user_events (
+  id BIGSERIAL PRIMARY KEY,
+  user_id UUID NOT NULL,
+  seq BIGINT NOT NULL,
+  type TEXT NOT NULL,
+  actor TEXT NOT NULL,
+  changes JSONB NOT NULL DEFAULT '{}'::jsonb,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  UNIQUE (user_id, seq)
+);
+
+CREATE OR REPLACE FUNCTION user_events_append_only() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'user_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER user_events_no_update_delete
+BEFORE UPDATE OR DELETE ON user_events
+FOR EACH ROW EXECUTE FUNCTION user_events_append_only();
@@ -0,0 +1,3 @@
+-- Support filtered user listings: newest-first paging and email prefix search
+CREATE INDEX IF NOT EXISTS idx_users_created ON users (created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_users_email_prefix ON users (email text_pattern_ops);
@@ -0,0 +1,5 @@
+-- Account suspension and bans; either timestamp blocks sign-in and existing sessions
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS suspended_at timestamptz,
+    ADD COLUMN IF NOT EXISTS banned_at timestamptz,
+    ADD COLUMN IF NOT EXISTS suspension_reason text NOT NULL DEFAULT '';
@@ -0,0 +1,7 @@
+-- Lookup key for emails (lowercased, optionally alias-folded by EMAIL_FOLD_*): logins, resets and
+-- invitations match on it, and it is unique so aliases of one mailbox cannot register twice.
+-- The backfill only lowercases; run "adminctl normalize-emails" after enabling a fold.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS normalized_email text;
+UPDATE users SET normalized_email = lower(btrim(email)) WHERE normalized_email IS NULL;
+ALTER TABLE users ALTER COLUMN normalized_email SET NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_normalized_email ON users (normalized_email);
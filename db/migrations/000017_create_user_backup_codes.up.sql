@@ -0,0 +1,8 @@
+-- One-time 2FA recovery codes, stored as SHA-256 hashes; regenerating replaces the whole set
+CREATE TABLE IF NOT EXISTS user_backup_codes (
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  code_hash TEXT NOT NULL,
+  used_at TIMESTAMPTZ,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (user_id, code_hash)
+);
@@ -0,0 +1,5 @@
+-- Idempotency key of an audit entry, assigned when the event happens: the async audit writer
+-- retries batches (and replays ones spilled to Redis) with ON CONFLICT (event_id) DO NOTHING.
+-- Entries written synchronously before this column existed keep NULL.
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS event_id uuid;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_audit_logs_event_id ON audit_logs (event_id);
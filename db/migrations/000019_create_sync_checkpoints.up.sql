@@ -0,0 +1,8 @@
+-- Position of each consumer of the user_events outbox: the highest event id it has applied.
+-- The users search sync advances its row only after Elasticsearch accepted the batch, so a
+-- restart replays everything after it.
+CREATE TABLE IF NOT EXISTS sync_checkpoints (
+  name TEXT PRIMARY KEY,
+  last_id BIGINT NOT NULL DEFAULT 0,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,4 @@
+-- Identifier shared by every step of one auth flow (login, OTP, tokens, emails), so support can
+-- pull an incident's audit entries next to its login and email trail (GET /api/admin/correlations/:id)
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS correlation_id TEXT;
+CREATE INDEX IF NOT EXISTS idx_audit_logs_correlation_id ON audit_logs (correlation_id) WHERE correlation_id IS NOT NULL;
@@ -0,0 +1,22 @@
+-- Background exports (users, audit logs) written to object storage. Workers claim queued jobs with
+-- FOR UPDATE SKIP LOCKED and bump heartbeat_at while running; a running job whose heartbeat went
+-- stale (its instance died) is claimed again and restarted from scratch.
+CREATE TABLE IF NOT EXISTS export_jobs (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  kind TEXT NOT NULL,
+  state TEXT NOT NULL DEFAULT 'queued',
+  params JSONB NOT NULL DEFAULT '{}',
+  total BIGINT NOT NULL DEFAULT 0,
+  processed BIGINT NOT NULL DEFAULT 0,
+  object TEXT NOT NULL DEFAULT '',
+  error TEXT NOT NULL DEFAULT '',
+  attempts INT NOT NULL DEFAULT 0,
+  requested_by UUID REFERENCES users(id) ON DELETE SET NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  started_at TIMESTAMPTZ,
+  heartbeat_at TIMESTAMPTZ,
+  finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_export_jobs_pending ON export_jobs (created_at) WHERE state IN ('queued', 'running');
+CREATE INDEX IF NOT EXISTS idx_export_jobs_requested_by ON export_jobs (requested_by, created_at DESC);
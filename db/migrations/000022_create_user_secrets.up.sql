@@ -0,0 +1,12 @@
+-- Sensitive per-user values (phone, TOTP secret) encrypted by the application with AES-GCM.
+-- ciphertext is "<key id>:<sealed>"; key_id repeats the id so rotation can find rows on old keys.
+CREATE TABLE IF NOT EXISTS user_secrets (
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  name TEXT NOT NULL,
+  ciphertext TEXT NOT NULL,
+  key_id TEXT NOT NULL,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (user_id, name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_secrets_key_id ON user_secrets (key_id);
@@ -0,0 +1,24 @@
+-- Email status events reported by the provider (Mailgun webhooks): delivered, bounced, deferred,
+-- complained, unsubscribed. Events that should reach product services are stored pending; the
+-- forwarder claims due rows with FOR UPDATE SKIP LOCKED, leases them by pushing next_attempt_at
+-- forward, and records the outcome with a backoff until it succeeds or gives up.
+CREATE TABLE IF NOT EXISTS email_events (
+  id BIGSERIAL PRIMARY KEY,
+  provider TEXT NOT NULL,
+  provider_event_id TEXT NOT NULL,
+  event TEXT NOT NULL,
+  recipient TEXT NOT NULL DEFAULT '',
+  message_id TEXT NOT NULL DEFAULT '',
+  reason TEXT NOT NULL DEFAULT '',
+  variables JSONB NOT NULL DEFAULT '{}',
+  occurred_at TIMESTAMPTZ NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  forward_state TEXT NOT NULL DEFAULT 'none',
+  forward_attempts INT NOT NULL DEFAULT 0,
+  next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  forwarded_at TIMESTAMPTZ,
+  last_error TEXT NOT NULL DEFAULT '',
+  UNIQUE (provider, provider_event_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_email_events_forward ON email_events (next_attempt_at) WHERE forward_state = 'pending';
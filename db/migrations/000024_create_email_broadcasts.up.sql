@@ -0,0 +1,26 @@
+-- Admin broadcast emails. A worker claims queued broadcasts with FOR UPDATE SKIP LOCKED and walks
+-- the audience in user id order, enqueuing one email job per recipient and saving last_user_id after
+-- each batch; a running broadcast whose heartbeat went stale (its instance died) is claimed again and
+-- resumes after last_user_id.
+CREATE TABLE IF NOT EXISTS email_broadcasts (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  state TEXT NOT NULL DEFAULT 'queued',
+  audience TEXT NOT NULL,
+  role_name TEXT NOT NULL DEFAULT '',
+  org_id UUID REFERENCES organizations(id) ON DELETE SET NULL,
+  message JSONB NOT NULL,
+  total BIGINT NOT NULL DEFAULT 0,
+  enqueued BIGINT NOT NULL DEFAULT 0,
+  skipped BIGINT NOT NULL DEFAULT 0,
+  failed BIGINT NOT NULL DEFAULT 0,
+  last_user_id UUID,
+  error TEXT NOT NULL DEFAULT '',
+  attempts INT NOT NULL DEFAULT 0,
+  requested_by UUID REFERENCES users(id) ON DELETE SET NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  started_at TIMESTAMPTZ,
+  heartbeat_at TIMESTAMPTZ,
+  finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_email_broadcasts_pending ON email_broadcasts (created_at) WHERE state IN ('queued', 'running');
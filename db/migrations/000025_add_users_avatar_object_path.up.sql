@@ -0,0 +1,8 @@
+-- Object (bucket-relative path) an uploaded avatar is stored as, so signing and cleanup do not have
+-- to parse avatar_url; empty for external avatars. The backfill takes the path out of the public
+-- storage.googleapis.com URLs uploads used to return.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS avatar_object_path TEXT NOT NULL DEFAULT '';
+UPDATE users
+SET avatar_object_path = regexp_replace(avatar_url, '^https://storage\.googleapis\.com/[^/]+/', '')
+WHERE avatar_object_path = ''
+  AND avatar_url ~ '^https://storage\.googleapis\.com/[^/]+/avatars/';
@@ -0,0 +1,25 @@
+-- Public identifier of a user: a ULID (26 Crockford base32 characters, time ordered) the API shows
+-- in place of the internal UUID. The application assigns it on insert; existing users get one
+-- built from their created_at, so the ordering matches signup order.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS external_id TEXT;
+
+CREATE FUNCTION pg_temp.ulid_at(ts TIMESTAMPTZ) RETURNS TEXT LANGUAGE plpgsql VOLATILE AS $$
+DECLARE
+  alphabet CONSTANT TEXT := '0123456789ABCDEFGHJKMNPQRSTVWXYZ';
+  ms BIGINT := floor(extract(epoch FROM ts) * 1000)::BIGINT;
+  ulid TEXT := '';
+BEGIN
+  FOR i IN 0..9 LOOP
+    ulid := ulid || substr(alphabet, 1 + ((ms >> (45 - 5 * i)) & 31)::INT, 1);
+  END LOOP;
+  FOR i IN 1..16 LOOP
+    ulid := ulid || substr(alphabet, 1 + floor(random() * 32)::INT, 1);
+  END LOOP;
+  RETURN ulid;
+END
+$$;
+
+UPDATE users SET external_id = pg_temp.ulid_at(created_at) WHERE external_id IS NULL;
+
+ALTER TABLE users ALTER COLUMN external_id SET NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_external_id ON users (external_id);
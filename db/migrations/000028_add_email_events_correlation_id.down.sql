@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_email_events_correlation;
+ALTER TABLE email_events DROP COLUMN IF EXISTS correlation_id;
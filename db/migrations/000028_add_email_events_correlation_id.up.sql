@@ -0,0 +1,9 @@
+-- Auth flow an email event belongs to, copied out of variables so support can list a flow's
+-- provider outcomes by correlation id; empty for emails sent outside a flow. The backfill takes it
+-- from the variables of events recorded before.
+ALTER TABLE email_events ADD COLUMN IF NOT EXISTS correlation_id TEXT NOT NULL DEFAULT '';
+UPDATE email_events
+SET correlation_id = variables->>'correlation_id'
+WHERE correlation_id = '' AND COALESCE(variables->>'correlation_id', '') <> '';
+
+CREATE INDEX IF NOT EXISTS idx_email_events_correlation ON email_events (correlation_id, occurred_at) WHERE correlation_id <> '';
@@ -0,0 +1,25 @@
+-- name: AddApiUsage :exec
+INSERT INTO api_usage (bucket, subject, method, route, requests, bytes_in, bytes_out)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (bucket, subject, method, route) DO UPDATE
+SET requests = api_usage.requests + EXCLUDED.requests,
+    bytes_in = api_usage.bytes_in + EXCLUDED.bytes_in,
+    bytes_out = api_usage.bytes_out + EXCLUDED.bytes_out;
+
+-- name: SummarizeApiUsageBySubject :many
+SELECT subject, sum(requests)::bigint AS requests, sum(bytes_in)::bigint AS bytes_in, sum(bytes_out)::bigint AS bytes_out
+FROM api_usage
+WHERE bucket >= sqlc.arg('from_time') AND bucket < sqlc.arg('to_time')
+  AND (sqlc.narg('subject')::text IS NULL OR subject = sqlc.narg('subject'))
+GROUP BY subject
+ORDER BY requests DESC
+LIMIT sqlc.arg('row_limit');
+
+-- name: SummarizeApiUsageByRoute :many
+SELECT method, route, sum(requests)::bigint AS requests, sum(bytes_in)::bigint AS bytes_in, sum(bytes_out)::bigint AS bytes_out
+FROM api_usage
+WHERE bucket >= sqlc.arg('from_time') AND bucket < sqlc.arg('to_time')
+  AND (sqlc.narg('subject')::text IS NULL OR subject = sqlc.narg('subject'))
+GROUP BY method, route
+ORDER BY requests DESC
+LIMIT sqlc.arg('row_limit');
@@ -1,4 +1,48 @@
--- name: InsertAuditLog :exec
-INSERT INTO audit_logs (user_id, email, action, ip, user_agent, metadata)
-VALUES ($1, $2, $3, $4, $5, $6);
+-- name: InsertAuditLog :one
+INSERT INTO audit_logs (event_id, user_id, email, action, ip, user_agent, metadata, correlation_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING id, created_at;
 
+-- name: InsertAuditLogBatch :many
+-- Entries whose event_id is already stored are skipped, so a retried batch is not duplicated
+INSERT INTO audit_logs (event_id, user_id, email, action, ip, user_agent, metadata, created_at, correlation_id)
+SELECT * FROM unnest(
+  sqlc.arg('event_ids')::uuid[],
+  sqlc.arg('user_ids')::uuid[],
+  sqlc.arg('emails')::text[],
+  sqlc.arg('actions')::text[],
+  sqlc.arg('ips')::text[],
+  sqlc.arg('user_agents')::text[],
+  sqlc.arg('metadata')::jsonb[],
+  sqlc.arg('created_ats')::timestamptz[],
+  sqlc.arg('correlation_ids')::text[]
+)
+ON CONFLICT (event_id) DO NOTHING
+RETURNING id, event_id, created_at;
+
+-- name: CountAuditLogs :one
+SELECT count(*) FROM audit_logs
+WHERE (sqlc.narg('action')::text IS NULL OR action = sqlc.narg('action'))
+  AND (sqlc.narg('user_id')::uuid IS NULL OR user_id = sqlc.narg('user_id'))
+  AND (sqlc.narg('from_time')::timestamptz IS NULL OR created_at >= sqlc.narg('from_time'))
+  AND (sqlc.narg('to_time')::timestamptz IS NULL OR created_at < sqlc.narg('to_time'))
+  AND (sqlc.narg('correlation_id')::text IS NULL OR correlation_id = sqlc.narg('correlation_id'));
+
+-- name: ListAuditLogsAfter :many
+SELECT id, user_id, email, action, ip, user_agent, metadata, created_at, event_id, correlation_id FROM audit_logs
+WHERE id > sqlc.arg('after_id')
+  AND (sqlc.narg('action')::text IS NULL OR action = sqlc.narg('action'))
+  AND (sqlc.narg('user_id')::uuid IS NULL OR user_id = sqlc.narg('user_id'))
+  AND (sqlc.narg('from_time')::timestamptz IS NULL OR created_at >= sqlc.narg('from_time'))
+  AND (sqlc.narg('to_time')::timestamptz IS NULL OR created_at < sqlc.narg('to_time'))
+  AND (sqlc.narg('correlation_id')::text IS NULL OR correlation_id = sqlc.narg('correlation_id'))
+ORDER BY id
+LIMIT sqlc.arg('row_limit');
+
+-- name: ListLastLoginTimezones :many
+-- Timezone of each user's most recent login that resolved one (recorded by the login handler)
+SELECT DISTINCT ON (user_id) user_id, (metadata->>'timezone')::text AS timezone FROM audit_logs
+WHERE user_id = ANY(sqlc.arg('user_ids')::uuid[])
+  AND action = 'login'
+  AND metadata ? 'timezone'
+ORDER BY user_id, created_at DESC;
@@ -0,0 +1,16 @@
+-- name: DeleteUserBackupCodes :exec
+DELETE FROM user_backup_codes
+WHERE user_id = $1;
+
+-- name: InsertUserBackupCode :exec
+INSERT INTO user_backup_codes (user_id, code_hash)
+VALUES ($1, $2);
+
+-- name: UseUserBackupCode :execrows
+UPDATE user_backup_codes
+SET used_at = now()
+WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL;
+
+-- name: CountUnusedUserBackupCodes :one
+SELECT count(*) FROM user_backup_codes
+WHERE user_id = $1 AND used_at IS NULL;
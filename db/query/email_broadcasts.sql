@@ -0,0 +1,65 @@
+-- name: CreateEmailBroadcast :one
+INSERT INTO email_broadcasts (audience, role_name, org_id, message, requested_by)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, state, audience, role_name, org_id, message, total, enqueued, skipped, failed, last_user_id, error, attempts, requested_by, created_at, started_at, heartbeat_at, finished_at;
+
+-- name: GetEmailBroadcast :one
+SELECT id, state, audience, role_name, org_id, message, total, enqueued, skipped, failed, last_user_id, error, attempts, requested_by, created_at, started_at, heartbeat_at, finished_at
+FROM email_broadcasts
+WHERE id = $1;
+
+-- name: ClaimEmailBroadcast :one
+-- The oldest queued broadcast, or a running one whose worker stopped heartbeating before
+-- stale_before; its counts and last_user_id are kept so it resumes where it stopped
+UPDATE email_broadcasts
+SET state = 'running', started_at = COALESCE(started_at, now()), heartbeat_at = now(), attempts = attempts + 1
+WHERE id = (
+  SELECT id FROM email_broadcasts
+  WHERE state = 'queued' OR (state = 'running' AND heartbeat_at < sqlc.arg(stale_before))
+  ORDER BY created_at
+  FOR UPDATE SKIP LOCKED
+  LIMIT 1
+)
+RETURNING id, state, audience, role_name, org_id, message, total, enqueued, skipped, failed, last_user_id, error, attempts, requested_by, created_at, started_at, heartbeat_at, finished_at;
+
+-- name: UpdateEmailBroadcastProgress :execrows
+-- No row is updated once the broadcast is no longer running (cancelled)
+UPDATE email_broadcasts
+SET total = $2, enqueued = $3, skipped = $4, failed = $5, last_user_id = $6, heartbeat_at = now()
+WHERE id = $1 AND state = 'running';
+
+-- name: FinishEmailBroadcast :exec
+UPDATE email_broadcasts
+SET state = $2, enqueued = $3, skipped = $4, failed = $5, error = $6, finished_at = now()
+WHERE id = $1 AND state = 'running';
+
+-- name: CancelEmailBroadcast :execrows
+UPDATE email_broadcasts
+SET state = 'cancelled', finished_at = now()
+WHERE id = $1 AND state IN ('queued', 'running');
+
+-- name: ListBroadcastRecipients :many
+-- Verified, active users after after_id in id order; role_name and org_id narrow the audience when set
+SELECT u.id, u.email, u.name
+FROM users u
+WHERE u.is_verified AND u.suspended_at IS NULL AND u.banned_at IS NULL
+  AND u.id > sqlc.arg('after_id')
+  AND (sqlc.arg('role_name')::text = '' OR EXISTS (
+    SELECT 1 FROM user_roles ur JOIN roles r ON r.id = ur.role_id
+    WHERE ur.user_id = u.id AND r.name = sqlc.arg('role_name')::text))
+  AND (sqlc.narg('org_id')::uuid IS NULL OR EXISTS (
+    SELECT 1 FROM organization_members m
+    WHERE m.org_id = sqlc.narg('org_id')::uuid AND m.user_id = u.id))
+ORDER BY u.id
+LIMIT sqlc.arg('row_limit');
+
+-- name: CountBroadcastRecipients :one
+SELECT count(*)
+FROM users u
+WHERE u.is_verified AND u.suspended_at IS NULL AND u.banned_at IS NULL
+  AND (sqlc.arg('role_name')::text = '' OR EXISTS (
+    SELECT 1 FROM user_roles ur JOIN roles r ON r.id = ur.role_id
+    WHERE ur.user_id = u.id AND r.name = sqlc.arg('role_name')::text))
+  AND (sqlc.narg('org_id')::uuid IS NULL OR EXISTS (
+    SELECT 1 FROM organization_members m
+    WHERE m.org_id = sqlc.narg('org_id')::uuid AND m.user_id = u.id));
@@ -0,0 +1,37 @@
+-- name: InsertEmailEvent :execrows
+-- A provider event seen before (webhook retries, replays) is ignored
+INSERT INTO email_events (provider, provider_event_id, event, recipient, message_id, reason, variables, occurred_at, forward_state, correlation_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+ON CONFLICT (provider, provider_event_id) DO NOTHING;
+
+-- name: ClaimEmailEvents :many
+-- Pending events due for forwarding, leased until lease_until so a batch whose forwarder died is
+-- claimed again afterwards
+UPDATE email_events
+SET forward_attempts = forward_attempts + 1, next_attempt_at = sqlc.arg(lease_until)
+WHERE id IN (
+  SELECT id FROM email_events
+  WHERE forward_state = 'pending' AND next_attempt_at <= now()
+  ORDER BY next_attempt_at
+  FOR UPDATE SKIP LOCKED
+  LIMIT sqlc.arg(batch)
+)
+RETURNING id, provider, provider_event_id, event, recipient, message_id, reason, variables, occurred_at, created_at, forward_state, forward_attempts, next_attempt_at, forwarded_at, last_error, correlation_id;
+
+-- name: MarkEmailEventForwarded :exec
+UPDATE email_events
+SET forward_state = 'forwarded', forwarded_at = now(), last_error = ''
+WHERE id = $1;
+
+-- name: FailEmailEventForward :exec
+UPDATE email_events
+SET forward_state = $2, next_attempt_at = $3, last_error = $4
+WHERE id = $1;
+
+-- name: ListEmailEventsByCorrelation :many
+-- Provider outcomes of the emails of one auth flow, oldest first
+SELECT id, provider, provider_event_id, event, recipient, message_id, reason, variables, occurred_at, created_at, forward_state, forward_attempts, next_attempt_at, forwarded_at, last_error, correlation_id
+FROM email_events
+WHERE correlation_id = $1
+ORDER BY occurred_at, id
+LIMIT $2;
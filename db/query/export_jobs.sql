@@ -0,0 +1,32 @@
+-- name: CreateExportJob :one
+INSERT INTO export_jobs (kind, params, requested_by)
+VALUES ($1, $2, $3)
+RETURNING id, kind, state, params, total, processed, object, error, attempts, requested_by, created_at, started_at, heartbeat_at, finished_at;
+
+-- name: GetExportJob :one
+SELECT id, kind, state, params, total, processed, object, error, attempts, requested_by, created_at, started_at, heartbeat_at, finished_at
+FROM export_jobs
+WHERE id = $1;
+
+-- name: ClaimExportJob :one
+-- The oldest queued job, or a running one whose worker stopped heartbeating before stale_before
+UPDATE export_jobs
+SET state = 'running', started_at = now(), heartbeat_at = now(), total = 0, processed = 0, attempts = attempts + 1
+WHERE id = (
+  SELECT id FROM export_jobs
+  WHERE state = 'queued' OR (state = 'running' AND heartbeat_at < sqlc.arg(stale_before))
+  ORDER BY created_at
+  FOR UPDATE SKIP LOCKED
+  LIMIT 1
+)
+RETURNING id, kind, state, params, total, processed, object, error, attempts, requested_by, created_at, started_at, heartbeat_at, finished_at;
+
+-- name: UpdateExportProgress :exec
+UPDATE export_jobs
+SET total = $2, processed = $3, heartbeat_at = now()
+WHERE id = $1 AND state = 'running';
+
+-- name: FinishExportJob :exec
+UPDATE export_jobs
+SET state = $2, processed = $3, object = $4, error = $5, finished_at = now()
+WHERE id = $1;
@@ -0,0 +1,19 @@
+-- name: CreateIdentity :one
+INSERT INTO identities (user_id, provider, provider_user_id, email)
+VALUES ($1, $2, $3, $4)
+RETURNING id, user_id, provider, provider_user_id, email, created_at;
+
+-- name: GetIdentityByProvider :one
+SELECT id, user_id, provider, provider_user_id, email, created_at
+FROM identities
+WHERE provider = $1 AND provider_user_id = $2;
+
+-- name: ListUserIdentities :many
+SELECT id, user_id, provider, provider_user_id, email, created_at
+FROM identities
+WHERE user_id = $1
+ORDER BY provider ASC;
+
+-- name: DeleteUserIdentity :execrows
+DELETE FROM identities
+WHERE user_id = $1 AND provider = $2;
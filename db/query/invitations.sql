@@ -0,0 +1,24 @@
+-- name: CreateInvitation :one
+INSERT INTO invitations (email, role, token_hash, invited_by, expires_at, org_id, org_role)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, email, role, token_hash, invited_by, expires_at, accepted_at, revoked_at, created_at, org_id, org_role;
+
+-- name: GetInvitationByTokenHash :one
+SELECT id, email, role, token_hash, invited_by, expires_at, accepted_at, revoked_at, created_at, org_id, org_role
+FROM invitations
+WHERE token_hash = $1;
+
+-- name: ListInvitations :many
+SELECT id, email, role, token_hash, invited_by, expires_at, accepted_at, revoked_at, created_at, org_id, org_role
+FROM invitations
+ORDER BY created_at DESC;
+
+-- name: AcceptInvitation :execrows
+UPDATE invitations
+SET accepted_at = now()
+WHERE id = $1 AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > now();
+
+-- name: RevokeInvitation :execrows
+UPDATE invitations
+SET revoked_at = now()
+WHERE id = $1 AND accepted_at IS NULL AND revoked_at IS NULL;
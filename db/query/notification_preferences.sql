@@ -0,0 +1,9 @@
+-- name: ListNotificationPreferences :many
+SELECT category, enabled FROM notification_preferences
+WHERE user_id = $1;
+
+-- name: UpsertNotificationPreference :exec
+INSERT INTO notification_preferences (user_id, category, enabled)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id, category) DO UPDATE
+SET enabled = EXCLUDED.enabled, updated_at = now();
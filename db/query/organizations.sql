@@ -0,0 +1,66 @@
+-- name: CreateOrganization :one
+INSERT INTO organizations (name, slug, created_by)
+VALUES ($1, $2, $3)
+RETURNING id, name, slug, created_by, created_at, updated_at;
+
+-- name: GetOrganization :one
+SELECT id, name, slug, created_by, created_at, updated_at
+FROM organizations
+WHERE id = $1;
+
+-- name: GetOrganizationBySlug :one
+SELECT id, name, slug, created_by, created_at, updated_at
+FROM organizations
+WHERE slug = $1;
+
+-- name: ListUserOrganizations :many
+SELECT o.id, o.name, o.slug, o.created_by, o.created_at, o.updated_at, m.role
+FROM organizations o
+JOIN organization_members m ON m.org_id = o.id
+WHERE m.user_id = $1
+ORDER BY o.name ASC;
+
+-- name: AddOrganizationMember :execrows
+INSERT INTO organization_members (org_id, user_id, role)
+VALUES ($1, $2, $3)
+ON CONFLICT (org_id, user_id) DO NOTHING;
+
+-- name: GetOrganizationMember :one
+SELECT org_id, user_id, role, created_at, updated_at
+FROM organization_members
+WHERE org_id = $1 AND user_id = $2;
+
+-- name: ListOrganizationMembers :many
+SELECT m.org_id, m.user_id, u.email, u.name, m.role, m.created_at
+FROM organization_members m
+JOIN users u ON u.id = m.user_id
+WHERE m.org_id = $1
+ORDER BY m.created_at ASC;
+
+-- name: UpdateOrganizationMemberRole :execrows
+UPDATE organization_members
+SET role = $3, updated_at = now()
+WHERE org_id = $1 AND user_id = $2;
+
+-- name: RemoveOrganizationMember :execrows
+DELETE FROM organization_members
+WHERE org_id = $1 AND user_id = $2;
+
+-- name: CountOrganizationOwners :one
+SELECT count(*)
+FROM organization_members
+WHERE org_id = $1 AND role = 'owner';
+
+-- name: GetOrganizationLimits :one
+SELECT org_id, rate_per_minute, requests_per_day, emails_per_day, updated_at
+FROM organization_limits
+WHERE org_id = $1;
+
+-- name: UpsertOrganizationLimits :exec
+INSERT INTO organization_limits (org_id, rate_per_minute, requests_per_day, emails_per_day)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (org_id) DO UPDATE
+SET rate_per_minute = EXCLUDED.rate_per_minute,
+    requests_per_day = EXCLUDED.requests_per_day,
+    emails_per_day = EXCLUDED.emails_per_day,
+    updated_at = now();
@@ -0,0 +1,39 @@
+-- name: CreatePermission :one
+INSERT INTO permissions (name)
+VALUES ($1)
+ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name, updated_at = now()
+RETURNING id, name, created_at, updated_at;
+
+-- name: GetPermissionByName :one
+SELECT id, name, created_at, updated_at
+FROM permissions
+WHERE name = $1;
+
+-- name: ListPermissions :many
+SELECT id, name, created_at, updated_at
+FROM permissions
+ORDER BY name ASC;
+
+-- name: AttachPermissionToRole :execrows
+INSERT INTO role_permissions (role_id, permission_id)
+VALUES ($1, $2)
+ON CONFLICT (role_id, permission_id) DO NOTHING;
+
+-- name: DetachPermissionFromRole :execrows
+DELETE FROM role_permissions
+WHERE role_id = $1 AND permission_id = $2;
+
+-- name: GetRolePermissions :many
+SELECT p.id, p.name, p.created_at, p.updated_at
+FROM permissions p
+JOIN role_permissions rp ON rp.permission_id = p.id
+WHERE rp.role_id = $1
+ORDER BY p.name ASC;
+
+-- name: GetUserPermissions :many
+SELECT DISTINCT p.id, p.name, p.created_at, p.updated_at
+FROM permissions p
+JOIN role_permissions rp ON rp.permission_id = p.id
+JOIN user_roles ur ON ur.role_id = rp.role_id
+WHERE ur.user_id = $1
+ORDER BY p.name ASC;
@@ -0,0 +1,30 @@
+-- name: CreatePersonalAccessToken :one
+INSERT INTO personal_access_tokens (id, user_id, name, token_prefix, token_hash, scope, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, user_id, name, token_prefix, token_hash, scope, expires_at, last_used_at, last_used_ip, revoked_at, created_at;
+
+-- name: GetPersonalAccessTokenByHash :one
+SELECT id, user_id, name, token_prefix, token_hash, scope, expires_at, last_used_at, last_used_ip, revoked_at, created_at
+FROM personal_access_tokens
+WHERE token_hash = $1;
+
+-- name: ListPersonalAccessTokens :many
+SELECT id, user_id, name, token_prefix, token_hash, scope, expires_at, last_used_at, last_used_ip, revoked_at, created_at
+FROM personal_access_tokens
+WHERE user_id = $1
+ORDER BY created_at DESC;
+
+-- name: CountActivePersonalAccessTokens :one
+SELECT count(*) FROM personal_access_tokens
+WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > now();
+
+-- name: RevokePersonalAccessToken :execrows
+UPDATE personal_access_tokens
+SET revoked_at = now()
+WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL;
+
+-- name: TouchPersonalAccessToken :exec
+-- Records a use at most once a minute, so busy scripts do not write on every request
+UPDATE personal_access_tokens
+SET last_used_at = now(), last_used_ip = $2
+WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < now() - interval '1 minute');
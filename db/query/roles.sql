@@ -19,10 +19,39 @@ INSERT INTO user_roles (user_id, role_id)
 VALUES ($1, $2)
 ON CONFLICT (user_id, role_id) DO NOTHING;
 
+-- name: AssignRoleToUsers :many
+INSERT INTO user_roles (user_id, role_id)
+SELECT unnest(@user_ids::uuid[]), @role_id::uuid
+ON CONFLICT (user_id, role_id) DO NOTHING
+RETURNING user_id;
+
+-- name: CountUsersWithRole :one
+SELECT count(*)
+FROM user_roles ur
+JOIN roles r ON r.id = ur.role_id
+WHERE r.name = $1;
+
 -- name: RevokeRoleFromUser :execrows
 DELETE FROM user_roles
 WHERE user_id = $1 AND role_id = $2;
 
+-- name: RevokeRoleFromUsers :many
+DELETE FROM user_roles
+WHERE role_id = @role_id AND user_id = ANY(@user_ids::uuid[])
+RETURNING user_id;
+
+-- name: RevokeRoleFromUsersExcept :many
+-- Everyone holding the role except user_ids loses it (group sync)
+DELETE FROM user_roles
+WHERE role_id = @role_id AND NOT (user_id = ANY(@user_ids::uuid[]))
+RETURNING user_id;
+
+-- name: LockRole :one
+-- Serializes membership changes of one role
+SELECT id FROM roles
+WHERE id = $1
+FOR UPDATE;
+
 -- name: GetUserRoles :many
 SELECT r.id, r.name, r.created_at, r.updated_at
 FROM roles r
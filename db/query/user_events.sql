@@ -0,0 +1,34 @@
+-- name: InsertUserEvent :one
+-- Callers hold the users row lock (GetUserForUpdate), so seq is gap-free per user
+INSERT INTO user_events (user_id, seq, type, actor, changes)
+VALUES ($1, COALESCE((SELECT max(seq) FROM user_events WHERE user_id = $1), 0) + 1, $2, $3, $4)
+RETURNING id, seq, created_at;
+
+-- name: ListUserEvents :many
+SELECT id, user_id, seq, type, actor, changes, created_at
+FROM user_events
+WHERE user_id = $1 AND seq > $2
+ORDER BY seq
+LIMIT $3;
+
+-- name: ListUserEventsSince :many
+SELECT id, user_id, seq, type, actor, changes, created_at
+FROM user_events
+WHERE user_id = $1 AND created_at >= $2
+ORDER BY seq
+LIMIT $3;
+
+-- name: ListUserEventsAfter :many
+SELECT id, user_id, seq, type, actor, changes, created_at
+FROM user_events
+WHERE id > $1
+ORDER BY id
+LIMIT $2;
+
+-- name: GetSyncCheckpoint :one
+SELECT last_id FROM sync_checkpoints WHERE name = $1;
+
+-- name: SetSyncCheckpoint :exec
+INSERT INTO sync_checkpoints (name, last_id, updated_at)
+VALUES ($1, $2, now())
+ON CONFLICT (name) DO UPDATE SET last_id = EXCLUDED.last_id, updated_at = now();
@@ -0,0 +1,26 @@
+-- name: UpsertUserSecret :exec
+INSERT INTO user_secrets (user_id, name, ciphertext, key_id)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (user_id, name) DO UPDATE
+SET ciphertext = EXCLUDED.ciphertext, key_id = EXCLUDED.key_id, updated_at = now();
+
+-- name: GetUserSecret :one
+SELECT user_id, name, ciphertext, key_id, updated_at FROM user_secrets
+WHERE user_id = $1 AND name = $2;
+
+-- name: DeleteUserSecret :execrows
+DELETE FROM user_secrets
+WHERE user_id = $1 AND name = $2;
+
+-- name: ListUserSecretsNotOnKey :many
+-- Rows written with another key than key_id, in primary key order after (after_user, after_name)
+SELECT user_id, name, ciphertext, key_id, updated_at FROM user_secrets
+WHERE key_id <> sqlc.arg('key_id') AND (user_id, name) > (sqlc.arg('after_user')::uuid, sqlc.arg('after_name')::text)
+ORDER BY user_id, name
+LIMIT sqlc.arg('max_rows');
+
+-- name: ReplaceUserSecretCiphertext :execrows
+-- Swaps in a re-encrypted value unless the row changed since it was read
+UPDATE user_secrets
+SET ciphertext = sqlc.arg('new_ciphertext'), key_id = sqlc.arg('key_id')
+WHERE user_id = sqlc.arg('user_id') AND name = sqlc.arg('name') AND ciphertext = sqlc.arg('old_ciphertext');
@@ -1,17 +1,22 @@
 -- name: CreateUser :one
-INSERT INTO users (email, password, name, avatar_url)
-VALUES ($1, $2, $3, $4)
-RETURNING id, email, password, name, avatar_url, is_verified, created_at, updated_at;
+INSERT INTO users (email, password, name, avatar_url, avatar_object_path, normalized_email, external_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, email, password, name, avatar_url, avatar_object_path, is_verified, suspended_at, banned_at, suspension_reason, created_at, updated_at, external_id;
 
 -- name: GetUserByID :one
-SELECT id, email, password, name, avatar_url, is_verified, created_at, updated_at
+SELECT id, email, password, name, avatar_url, avatar_object_path, is_verified, suspended_at, banned_at, suspension_reason, created_at, updated_at, external_id
 FROM users
 WHERE id = $1;
 
+-- name: GetUserByExternalID :one
+SELECT id, email, password, name, avatar_url, avatar_object_path, is_verified, suspended_at, banned_at, suspension_reason, created_at, updated_at, external_id
+FROM users
+WHERE external_id = $1;
+
 -- name: GetUserByEmail :one
-SELECT id, email, password, name, avatar_url, is_verified, created_at, updated_at
+SELECT id, email, password, name, avatar_url, avatar_object_path, is_verified, suspended_at, banned_at, suspension_reason, created_at, updated_at, external_id
 FROM users
-WHERE email = $1;
+WHERE normalized_email = $1;
 
 -- name: UpdateUser :execrows
 UPDATE users
@@ -19,14 +24,15 @@ SET email = $2,
     password = $3,
     name = $4,
     avatar_url = $5,
+    avatar_object_path = $6,
+    normalized_email = $7,
     updated_at = now()
 WHERE id = $1;
 
--- name: UpdateUserPassword :execrows
+-- name: SetUserNormalizedEmail :execrows
 UPDATE users
-SET password = $2,
-    updated_at = now()
-WHERE id = $1;
+SET normalized_email = $2
+WHERE id = $1 AND normalized_email <> $2;
 
 -- name: SetUserVerified :execrows
 UPDATE users
@@ -34,7 +40,62 @@ SET is_verified = true,
     updated_at = now()
 WHERE id = $1;
 
+-- name: SetUserStatus :execrows
+UPDATE users
+SET suspended_at = $2,
+    banned_at = $3,
+    suspension_reason = $4,
+    updated_at = now()
+WHERE id = $1;
+
 -- name: GetUserIsVerified :one
 SELECT is_verified
 FROM users
 WHERE id = $1;
+
+-- name: ListUsersAfter :many
+SELECT id, email, name, avatar_url, is_verified, created_at, updated_at
+FROM users
+WHERE id > $1
+ORDER BY id
+LIMIT $2;
+
+-- name: GetUserForUpdate :one
+SELECT id, email, password, name, avatar_url, avatar_object_path, is_verified, suspended_at, banned_at, suspension_reason, created_at, updated_at
+FROM users
+WHERE id = $1
+FOR UPDATE;
+
+-- name: CountUsers :one
+SELECT count(*) FROM users
+WHERE (sqlc.narg('email_prefix')::text IS NULL OR email LIKE sqlc.narg('email_prefix') || '%')
+  AND (sqlc.narg('verified')::boolean IS NULL OR is_verified = sqlc.narg('verified'))
+  AND (sqlc.narg('created_from')::timestamptz IS NULL OR created_at >= sqlc.narg('created_from'))
+  AND (sqlc.narg('created_to')::timestamptz IS NULL OR created_at < sqlc.narg('created_to'));
+
+-- name: ListUsers :many
+SELECT id, email, name, avatar_url, is_verified, created_at, updated_at
+FROM users
+WHERE (sqlc.narg('email_prefix')::text IS NULL OR email LIKE sqlc.narg('email_prefix') || '%')
+  AND (sqlc.narg('verified')::boolean IS NULL OR is_verified = sqlc.narg('verified'))
+  AND (sqlc.narg('created_from')::timestamptz IS NULL OR created_at >= sqlc.narg('created_from'))
+  AND (sqlc.narg('created_to')::timestamptz IS NULL OR created_at < sqlc.narg('created_to'))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('row_limit') OFFSET sqlc.arg('row_offset');
+
+-- name: ExistingUserIDs :many
+SELECT id FROM users
+WHERE id = ANY(@ids::uuid[]);
+
+-- name: SearchUsers :many
+-- pattern is an ILIKE pattern; email matches rank first, as the Elasticsearch query boosts email
+SELECT id, email, name, avatar_url, created_at, updated_at
+FROM users
+WHERE email ILIKE sqlc.arg('pattern') OR name ILIKE sqlc.arg('pattern')
+ORDER BY (email ILIKE sqlc.arg('pattern')) DESC, created_at DESC, id DESC
+LIMIT sqlc.arg('row_limit') OFFSET sqlc.arg('row_offset');
+
+-- name: CountSearchUsers :one
+SELECT count(*)
+FROM users
+WHERE email ILIKE sqlc.arg('pattern') OR name ILIKE sqlc.arg('pattern');
@@ -0,0 +1,138 @@
+package application
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	repo "github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+)
+
+// AccountStatusService suspends and bans accounts. Blocking an account stores its status in
+// Postgres, marks it in the session store so outstanding tokens stop working at once, ends its
+// session and forgets its trusted devices; lifting the last block clears the marker.
+type AccountStatusService struct {
+	Users    repo.UserRepository
+	Sessions repo.SessionStore
+	Redis    *redis.Client
+	Logger   *logrus.Logger
+}
+
+func NewAccountStatusService(users repo.UserRepository, sessions repo.SessionStore, rdb *redis.Client, logger *logrus.Logger) *AccountStatusService {
+	return &AccountStatusService{Users: users, Sessions: sessions, Redis: rdb, Logger: logger}
+}
+
+// Suspend blocks the account until Unsuspend; suspending again only updates the reason.
+// The bool reports whether the effective status (entity.User.AccountError) changed.
+func (s *AccountStatusService) Suspend(ctx context.Context, userID, reason string) (*entity.User, bool, error) {
+	return s.update(ctx, userID, func(u *entity.User, now time.Time) {
+		if u.SuspendedAt == nil {
+			u.SuspendedAt = &now
+		}
+		u.SuspensionReason = reason
+	})
+}
+
+// Unsuspend lifts a suspension; a ban stays in place
+func (s *AccountStatusService) Unsuspend(ctx context.Context, userID string) (*entity.User, bool, error) {
+	return s.update(ctx, userID, func(u *entity.User, _ time.Time) {
+		u.SuspendedAt = nil
+		if u.BannedAt == nil {
+			u.SuspensionReason = ""
+		}
+	})
+}
+
+// Ban blocks the account until Unban; it outranks a suspension
+func (s *AccountStatusService) Ban(ctx context.Context, userID, reason string) (*entity.User, bool, error) {
+	return s.update(ctx, userID, func(u *entity.User, now time.Time) {
+		if u.BannedAt == nil {
+			u.BannedAt = &now
+		}
+		u.SuspensionReason = reason
+	})
+}
+
+// Unban lifts a ban; a suspension stays in place
+func (s *AccountStatusService) Unban(ctx context.Context, userID string) (*entity.User, bool, error) {
+	return s.update(ctx, userID, func(u *entity.User, _ time.Time) {
+		u.BannedAt = nil
+		if u.SuspendedAt == nil {
+			u.SuspensionReason = ""
+		}
+	})
+}
+
+// ResolveID maps an admin-supplied user ID, internal or public (ULID), to the internal one;
+// ErrUserNotFound for an unknown public ID
+func (s *AccountStatusService) ResolveID(id string) (string, error) {
+	return lookupUserID(s.Users, id)
+}
+
+func (s *AccountStatusService) update(ctx context.Context, userID string, apply func(u *entity.User, now time.Time)) (*entity.User, bool, error) {
+	u, err := s.Users.GetByID(userID)
+	if err != nil || u == nil {
+		return nil, false, notFound(err, ErrUserNotFound)
+	}
+	before := u.AccountError()
+	apply(u, time.Now().UTC())
+	if err := s.Users.SetStatus(ctx, u.ID, u.SuspendedAt, u.BannedAt, u.SuspensionReason); err != nil {
+		return nil, false, err
+	}
+	after := u.AccountError()
+	if s.Sessions == nil {
+		return u, before != after, nil
+	}
+	// The marker is what rejects tokens already handed out, so failing to store it fails the call
+	if err := s.Sessions.Block(ctx, u.ID, blockStatus(after)); err != nil {
+		return nil, false, err
+	}
+	if after != nil && before == nil {
+		log := helpers.FromContext(ctx).WithField("user_id", u.ID)
+		if err := s.Sessions.Revoke(ctx, u.ID, ""); err != nil {
+			log.WithError(err).Warn("revoke sessions of blocked account failed")
+		}
+		if s.Redis != nil {
+			if err := helpers.ForgetTrustedDevices(ctx, s.Redis, u.ID); err != nil {
+				log.WithError(err).Warn("forget trusted devices of blocked account failed")
+			}
+		}
+	}
+	return u, before != after, nil
+}
+
+// RevokeSessions signs the user out everywhere for incident response: it ends every session (which
+// invalidates their refresh tokens, bound to the session) and forgets trusted devices and any
+// pending login OTP, without changing the account status. Unlike blocking, failures are returned.
+func (s *AccountStatusService) RevokeSessions(ctx context.Context, userID string) (*entity.User, error) {
+	u, err := s.Users.GetByID(userID)
+	if err != nil || u == nil {
+		return nil, notFound(err, ErrUserNotFound)
+	}
+	if s.Sessions != nil {
+		if err := s.Sessions.Revoke(ctx, u.ID, ""); err != nil {
+			return nil, err
+		}
+	}
+	if s.Redis != nil {
+		if err := helpers.ForgetTrustedDevices(ctx, s.Redis, u.ID); err != nil {
+			return nil, repo.Wrap(repo.ErrUnavailable, err)
+		}
+	}
+	return u, nil
+}
+
+// blockStatus is the SessionStore.Block status for an account error
+func blockStatus(err error) string {
+	switch err {
+	case entity.ErrAccountBanned:
+		return repo.BlockBanned
+	case entity.ErrAccountSuspended:
+		return repo.BlockSuspended
+	}
+	return ""
+}
@@ -0,0 +1,123 @@
+package application
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	repo "github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
+)
+
+var ErrExportTooLarge = errors.New("export exceeds the row cap")
+
+const auditExportBatch = 1000
+
+var auditCSVHeader = []string{"id", "created_at", "action", "user_id", "email", "ip", "user_agent", "metadata", "correlation_id"}
+
+// AuditExportService exports audit logs as CSV, streamed directly up to MaxRows; larger ranges
+// go through ExportService as a background job.
+type AuditExportService struct {
+	Repo    repo.AuditRepository
+	MaxRows int64
+}
+
+func NewAuditExportService(r repo.AuditRepository, maxRows int64) *AuditExportService {
+	return &AuditExportService{Repo: r, MaxRows: maxRows}
+}
+
+// Check counts the matching rows and returns ErrExportTooLarge above the cap.
+func (s *AuditExportService) Check(f entity.AuditFilter) (int64, error) {
+	n, err := s.Repo.Count(f)
+	if err != nil {
+		return 0, err
+	}
+	if s.MaxRows > 0 && n > s.MaxRows {
+		return n, ErrExportTooLarge
+	}
+	return n, nil
+}
+
+// WriteCSV writes the matching entries in id order, calling progress (if set) with the rows
+// written so far after each batch.
+func (s *AuditExportService) WriteCSV(ctx context.Context, w io.Writer, f entity.AuditFilter, progress func(rows int64)) (int64, error) {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(auditCSVHeader); err != nil {
+		return 0, err
+	}
+	return s.Each(ctx, f, func(a entity.AuditLog) error {
+		return cw.Write(auditCSVRecord(a))
+	}, func(rows int64) error {
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			return err
+		}
+		if progress != nil {
+			progress(rows)
+		}
+		return nil
+	})
+}
+
+// Each calls fn with the matching entries in id order, a batch at a time, and batchDone (if set)
+// with the rows so far after each batch; an error from either stops it.
+func (s *AuditExportService) Each(ctx context.Context, f entity.AuditFilter, fn func(entity.AuditLog) error, batchDone func(rows int64) error) (int64, error) {
+	var rows int64
+	var after int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return rows, err
+		}
+		batch, err := s.Repo.ListAfter(f, after, auditExportBatch)
+		if err != nil {
+			return rows, err
+		}
+		for _, a := range batch {
+			if err := fn(a); err != nil {
+				return rows, err
+			}
+		}
+		rows += int64(len(batch))
+		if batchDone != nil {
+			if err := batchDone(rows); err != nil {
+				return rows, err
+			}
+		}
+		if len(batch) < auditExportBatch {
+			return rows, nil
+		}
+		after = batch[len(batch)-1].ID
+	}
+}
+
+func auditCSVRecord(a entity.AuditLog) []string {
+	md := ""
+	if len(a.Metadata) > 0 {
+		b, _ := json.Marshal(a.Metadata)
+		md = string(b)
+	}
+	return []string{
+		strconv.FormatInt(a.ID, 10),
+		a.CreatedAt.UTC().Format(time.RFC3339),
+		csvSafe(a.Action),
+		a.UserID,
+		csvSafe(a.Email),
+		csvSafe(a.IP),
+		csvSafe(a.UserAgent),
+		csvSafe(md),
+		csvSafe(a.CorrelationID),
+	}
+}
+
+// csvSafe neutralizes spreadsheet formulas in user-controlled cells
+func csvSafe(v string) string {
+	if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+		return "'" + v
+	}
+	return v
+}
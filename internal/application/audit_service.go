@@ -0,0 +1,233 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v8"
+	"github.com/sirupsen/logrus"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	repo "github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+)
+
+// auditIndexBody maps audit entries for free-text search; metadata is kept as-is but searched
+// through metadata_text (its flattened "key value" pairs) so arbitrary keys don't grow the mapping.
+var auditIndexBody = map[string]any{
+	"mappings": map[string]any{
+		"properties": map[string]any{
+			"id":             map[string]any{"type": "long"},
+			"user_id":        map[string]any{"type": "keyword"},
+			"email":          map[string]any{"type": "text", "fields": map[string]any{"keyword": map[string]any{"type": "keyword"}}},
+			"action":         map[string]any{"type": "text", "fields": map[string]any{"keyword": map[string]any{"type": "keyword"}}},
+			"ip":             map[string]any{"type": "keyword"},
+			"user_agent":     map[string]any{"type": "text"},
+			"metadata":       map[string]any{"type": "object", "enabled": false},
+			"metadata_text":  map[string]any{"type": "text"},
+			"created_at":     map[string]any{"type": "date"},
+			"correlation_id": map[string]any{"type": "keyword"},
+		},
+	},
+}
+
+// AuditSearchQuery filters an audit log search; zero values are ignored.
+type AuditSearchQuery struct {
+	Q      string // free text across action, email, ip, user agent and metadata
+	Action string
+	UserID string
+	From   time.Time
+	To     time.Time
+	Size   int
+}
+
+// AuditService records audit log entries in Postgres and indexes them into Elasticsearch
+// asynchronously through the event bus (see IndexEntry).
+type AuditService struct {
+	Repo   repo.AuditRepository
+	Bus    *helpers.EventBus
+	ES     *elasticsearch.Client
+	Index  string
+	Logger *logrus.Logger
+	// Writer, when set, makes Record asynchronous (AUDIT_ASYNC)
+	Writer *AuditWriter
+}
+
+func NewAuditService(r repo.AuditRepository, bus *helpers.EventBus, es *elasticsearch.Client, index string, logger *logrus.Logger) *AuditService {
+	return &AuditService{Repo: r, Bus: bus, ES: es, Index: index, Logger: logger}
+}
+
+// Record stores the entry and publishes it for indexing; the correlation id of ctx is kept with it. With a Writer it only stamps the entry
+// (event id, time) and hands it over; the insert happens in a later batch.
+func (s *AuditService) Record(ctx context.Context, a entity.AuditLog) error {
+	if a.EventID == "" {
+		a.EventID = helpers.NewUUIDv7()
+	}
+	if a.CorrelationID == "" {
+		a.CorrelationID = helpers.CorrelationIDFrom(ctx)
+	}
+	if s.Writer != nil {
+		if a.CreatedAt.IsZero() {
+			a.CreatedAt = time.Now().UTC()
+		}
+		s.Writer.Enqueue(ctx, a)
+		return nil
+	}
+	if err := s.Repo.Insert(&a); err != nil {
+		return err
+	}
+	s.Bus.Publish(helpers.TopicAuditLogged, a)
+	return nil
+}
+
+// EnsureIndex creates the audit index when missing.
+func (s *AuditService) EnsureIndex(ctx context.Context) error {
+	if s.ES == nil || s.Index == "" {
+		return ErrSearchNotConfigured
+	}
+	ok, err := helpers.ESIndexExists(ctx, s.ES, s.Index)
+	if err != nil || ok {
+		return err
+	}
+	return helpers.ESCreateIndex(ctx, s.ES, s.Index, auditIndexBody)
+}
+
+// IndexEntry is the TopicAuditLogged subscriber; the Postgres id is the document id so
+// redelivery is idempotent.
+func (s *AuditService) IndexEntry(ctx context.Context, payload any) {
+	a, ok := payload.(entity.AuditLog)
+	if !ok || s.ES == nil || s.Index == "" {
+		return
+	}
+	c, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	failed, err := helpers.ESBulk(c, s.ES, s.Index, "index", []helpers.ESBulkDoc{{ID: strconv.FormatInt(a.ID, 10), Doc: auditDoc(a)}})
+	if (err != nil || failed > 0) && s.Logger != nil {
+		s.Logger.WithError(err).WithField("audit_id", a.ID).Warn("audit log not indexed")
+	}
+}
+
+func auditDoc(a entity.AuditLog) map[string]any {
+	return map[string]any{
+		"id":             a.ID,
+		"user_id":        a.UserID,
+		"email":          a.Email,
+		"action":         a.Action,
+		"ip":             a.IP,
+		"user_agent":     a.UserAgent,
+		"metadata":       a.Metadata,
+		"metadata_text":  metadataText(a.Metadata),
+		"created_at":     a.CreatedAt.Format(time.RFC3339Nano),
+		"correlation_id": a.CorrelationID,
+	}
+}
+
+// metadataText flattens metadata into "key value" pairs (nested keys joined by dots)
+func metadataText(md map[string]any) string {
+	var parts []string
+	var walk func(prefix string, v any)
+	walk = func(prefix string, v any) {
+		switch t := v.(type) {
+		case map[string]any:
+			keys := make([]string, 0, len(t))
+			for k := range t {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				key := k
+				if prefix != "" {
+					key = prefix + "." + k
+				}
+				walk(key, t[k])
+			}
+		case []any:
+			for _, e := range t {
+				walk(prefix, e)
+			}
+		case nil:
+		default:
+			parts = append(parts, prefix+" "+fmt.Sprint(t))
+		}
+	}
+	walk("", md)
+	return strings.Join(parts, "\n")
+}
+
+// Search runs a free-text search over indexed audit entries, newest first among equal scores.
+func (s *AuditService) Search(ctx context.Context, q AuditSearchQuery) ([]map[string]any, int64, error) {
+	if s.ES == nil || s.Index == "" {
+		return nil, 0, ErrSearchNotConfigured
+	}
+	if q.Size <= 0 || q.Size > 100 {
+		q.Size = 20
+	}
+	var filter []map[string]any
+	if q.Action != "" {
+		filter = append(filter, map[string]any{"term": map[string]any{"action.keyword": q.Action}})
+	}
+	if q.UserID != "" {
+		filter = append(filter, map[string]any{"term": map[string]any{"user_id": q.UserID}})
+	}
+	if !q.From.IsZero() || !q.To.IsZero() {
+		rng := map[string]any{}
+		if !q.From.IsZero() {
+			rng["gte"] = q.From.Format(time.RFC3339Nano)
+		}
+		if !q.To.IsZero() {
+			rng["lt"] = q.To.Format(time.RFC3339Nano)
+		}
+		filter = append(filter, map[string]any{"range": map[string]any{"created_at": rng}})
+	}
+	boolQ := map[string]any{"filter": filter}
+	if strings.TrimSpace(q.Q) != "" {
+		boolQ["must"] = map[string]any{"simple_query_string": map[string]any{
+			"query":            q.Q,
+			"fields":           []string{"action^3", "email^2", "ip^2", "metadata_text", "user_agent"},
+			"default_operator": "and",
+			"lenient":          true,
+		}}
+	}
+	body := map[string]any{
+		"query":            map[string]any{"bool": boolQ},
+		"size":             q.Size,
+		"sort":             []any{"_score", map[string]any{"created_at": "desc"}},
+		"track_total_hits": true,
+	}
+	b, _ := json.Marshal(body)
+
+	c, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+	res, err := s.ES.Search(s.ES.Search.WithContext(c), s.ES.Search.WithIndex(s.Index), s.ES.Search.WithBody(strings.NewReader(string(b))))
+	if err != nil {
+		return nil, 0, err
+	}
+	defer func() { _ = res.Body.Close() }()
+	if res.IsError() {
+		return nil, 0, fmt.Errorf("audit search: %s", res.Status())
+	}
+	var parsed struct {
+		Hits struct {
+			Total struct {
+				Value int64 `json:"value"`
+			} `json:"total"`
+			Hits []struct {
+				Source map[string]any `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&parsed); err != nil {
+		return nil, 0, err
+	}
+	out := make([]map[string]any, 0, len(parsed.Hits.Hits))
+	for _, h := range parsed.Hits.Hits {
+		delete(h.Source, "metadata_text")
+		out = append(out, h.Source)
+	}
+	return out, parsed.Hits.Total.Value, nil
+}
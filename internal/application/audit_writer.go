@@ -0,0 +1,239 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	repo "github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/keyspace"
+)
+
+// Redis list holding audit entries that could not be buffered or written, replayed by Run
+func keyAuditSpill() string     { return keyspace.Key("audit:spill") }
+func keyAuditSpillLock() string { return keyspace.Key("audit:spill:lock") }
+
+// AuditWriter takes audit entries off the request path: Enqueue buffers them in a bounded
+// channel and Run writes them in multi-row batches. Entries that do not fit the buffer, or whose
+// batch fails, spill to a Redis list and are replayed later; every entry carries an EventID, so
+// replays and retries never store it twice. Only when Redis is unavailable too is an entry lost
+// (counted as dropped and logged).
+type AuditWriter struct {
+	Repo          repo.AuditRepository
+	Redis         *redis.Client
+	Bus           *helpers.EventBus
+	Logger        *logrus.Logger
+	BatchSize     int
+	FlushInterval time.Duration
+
+	queue   chan entity.AuditLog
+	closed  atomic.Bool
+	stop    chan struct{}
+	done    chan struct{}
+	runOnce sync.Once
+
+	enqueued, written, duplicates, spilled, replayed, dropped, failedBatches atomic.Int64
+}
+
+func NewAuditWriter(r repo.AuditRepository, rdb *redis.Client, bus *helpers.EventBus, logger *logrus.Logger, buffer, batchSize int, flushInterval time.Duration) *AuditWriter {
+	if buffer < 1 {
+		buffer = 1024
+	}
+	if batchSize < 1 {
+		batchSize = 100
+	}
+	if flushInterval <= 0 {
+		flushInterval = time.Second
+	}
+	return &AuditWriter{
+		Repo: r, Redis: rdb, Bus: bus, Logger: logger, BatchSize: batchSize, FlushInterval: flushInterval,
+		queue: make(chan entity.AuditLog, buffer), stop: make(chan struct{}), done: make(chan struct{}),
+	}
+}
+
+// Enqueue buffers a (it must have an EventID) without blocking. When the buffer is full or the
+// writer is closed, the entry spills to Redis instead.
+func (w *AuditWriter) Enqueue(ctx context.Context, a entity.AuditLog) {
+	w.enqueued.Add(1)
+	if !w.closed.Load() {
+		select {
+		case w.queue <- a:
+			return
+		default:
+		}
+	}
+	w.spill(ctx, []entity.AuditLog{a})
+}
+
+// Start writes buffered entries in the background until Close, flushing every BatchSize entries
+// or FlushInterval, and replays spilled entries while the buffer has room.
+func (w *AuditWriter) Start() {
+	w.runOnce.Do(func() { go w.run() })
+}
+
+func (w *AuditWriter) run() {
+	defer close(w.done)
+	ticker := time.NewTicker(w.FlushInterval)
+	defer ticker.Stop()
+	batch := make([]entity.AuditLog, 0, w.BatchSize)
+	flush := func() {
+		if len(batch) > 0 {
+			w.write(batch)
+			batch = batch[:0]
+		}
+	}
+	for {
+		select {
+		case a := <-w.queue:
+			batch = append(batch, a)
+			if len(batch) >= w.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+			if len(w.queue) < cap(w.queue)/2 {
+				w.replay()
+			}
+		case <-w.stop:
+			for {
+				select {
+				case a := <-w.queue:
+					batch = append(batch, a)
+					if len(batch) >= w.BatchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// Close stops buffering (later entries spill to Redis), writes what is buffered and waits for it
+// until ctx is done. Call it after the HTTP server has stopped and before the event bus closes.
+func (w *AuditWriter) Close(ctx context.Context) {
+	if w == nil || !w.closed.CompareAndSwap(false, true) {
+		return
+	}
+	close(w.stop)
+	w.runOnce.Do(func() { close(w.done) }) // never started
+	select {
+	case <-w.done:
+	case <-ctx.Done():
+		w.Logger.WithField("buffered", len(w.queue)).Warn("audit writer did not flush in time")
+	}
+}
+
+// write stores one batch and publishes the stored entries for indexing; a failed batch spills
+func (w *AuditWriter) write(batch []entity.AuditLog) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stored, err := w.Repo.InsertBatch(ctx, batch)
+	if err != nil {
+		w.failedBatches.Add(1)
+		w.Logger.WithError(err).WithField("entries", len(batch)).Warn("audit batch not written, spilling to redis")
+		w.spill(ctx, batch)
+		return
+	}
+	w.written.Add(int64(len(stored)))
+	w.duplicates.Add(int64(len(batch) - len(stored)))
+	for _, a := range stored {
+		w.Bus.Publish(helpers.TopicAuditLogged, a)
+	}
+}
+
+// spill appends entries to the Redis list; without Redis they are dropped
+func (w *AuditWriter) spill(ctx context.Context, entries []entity.AuditLog) {
+	vals := make([]any, 0, len(entries))
+	for _, a := range entries {
+		raw, err := json.Marshal(a)
+		if err != nil {
+			continue
+		}
+		vals = append(vals, raw)
+	}
+	var err error
+	if w.Redis == nil {
+		err = repo.ErrUnavailable
+	} else {
+		err = w.Redis.RPush(context.WithoutCancel(ctx), keyAuditSpill(), vals...).Err()
+	}
+	if err != nil {
+		w.dropped.Add(int64(len(entries)))
+		ids := make([]string, 0, len(entries))
+		for _, a := range entries {
+			ids = append(ids, a.EventID+" "+a.Action)
+		}
+		w.Logger.WithError(err).WithField("entries", ids).Error("audit entries dropped")
+		return
+	}
+	w.spilled.Add(int64(len(entries)))
+}
+
+// replay moves up to BatchSize spilled entries back into Postgres. A short lock keeps instances
+// from trimming each other's range; the list is only trimmed once the batch is stored.
+func (w *AuditWriter) replay() {
+	if w.Redis == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ok, err := w.Redis.SetNX(ctx, keyAuditSpillLock(), "1", 30*time.Second).Result()
+	if err != nil || !ok {
+		return
+	}
+	defer w.Redis.Del(context.WithoutCancel(ctx), keyAuditSpillLock())
+	raws, err := w.Redis.LRange(ctx, keyAuditSpill(), 0, int64(w.BatchSize)-1).Result()
+	if err != nil || len(raws) == 0 {
+		return
+	}
+	batch := make([]entity.AuditLog, 0, len(raws))
+	for _, raw := range raws {
+		var a entity.AuditLog
+		if json.Unmarshal([]byte(raw), &a) == nil && a.EventID != "" {
+			batch = append(batch, a)
+		}
+	}
+	stored, err := w.Repo.InsertBatch(ctx, batch)
+	if err != nil {
+		w.Logger.WithError(err).WithField("entries", len(batch)).Debug("spilled audit entries not replayed yet")
+		return
+	}
+	if err := w.Redis.LTrim(ctx, keyAuditSpill(), int64(len(raws)), -1).Err(); err != nil {
+		w.Logger.WithError(err).Warn("spilled audit entries written but not trimmed; they will be skipped as duplicates")
+	}
+	w.replayed.Add(int64(len(stored)))
+	w.duplicates.Add(int64(len(batch) - len(stored)))
+	for _, a := range stored {
+		w.Bus.Publish(helpers.TopicAuditLogged, a)
+	}
+}
+
+// WriteMetrics appends the writer counters and buffer depth in Prometheus text format. nil-safe.
+func (w *AuditWriter) WriteMetrics(b *strings.Builder) {
+	if w == nil {
+		return
+	}
+	counter := func(name, help string, v int64) {
+		fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s counter\n%s %d\n", name, help, name, name, v)
+	}
+	counter("audit_writer_enqueued_total", "Audit entries handed to the async writer.", w.enqueued.Load())
+	counter("audit_writer_written_total", "Audit entries stored from the buffer.", w.written.Load())
+	counter("audit_writer_replayed_total", "Spilled audit entries stored on replay.", w.replayed.Load())
+	counter("audit_writer_duplicates_total", "Audit entries skipped because their event id was already stored.", w.duplicates.Load())
+	counter("audit_writer_spilled_total", "Audit entries spilled to Redis (buffer full, writer closed or batch failed).", w.spilled.Load())
+	counter("audit_writer_dropped_total", "Audit entries lost because Redis was unavailable too.", w.dropped.Load())
+	counter("audit_writer_failed_batches_total", "Audit batches whose insert failed.", w.failedBatches.Load())
+	fmt.Fprintf(b, "# HELP audit_writer_buffered Audit entries waiting in the buffer.\n# TYPE audit_writer_buffered gauge\naudit_writer_buffered %d\n", len(w.queue))
+}
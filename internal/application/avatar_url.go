@@ -0,0 +1,50 @@
+package application
+
+import (
+	"context"
+	"time"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/keyspace"
+)
+
+func keyAvatarURL(objectPath string) string { return keyspace.Regional("avatar:signed:" + objectPath) }
+
+// AvatarURL is the avatar URL to hand to clients. Without AvatarStorage it is the stored URL; with
+// it (private buckets) it is a signed URL valid for AvatarURLTTL, reused from Redis until a fifth
+// of its lifetime is left so clients never receive one about to expire. A failed signing falls
+// back to the stored URL, which is useless for a private object but keeps the profile readable.
+// The object is AvatarObjectPath; rows without one fall back to parsing the URL.
+func (s *Service) AvatarURL(ctx context.Context, u *entity.User) string {
+	if s.AvatarStorage == nil || u.AvatarURL == "" {
+		return u.AvatarURL
+	}
+	objectPath, ok := u.AvatarObjectPath, u.AvatarObjectPath != ""
+	if !ok {
+		objectPath, ok = s.AvatarStorage.ObjectPath(u.AvatarURL)
+	}
+	if !ok {
+		return u.AvatarURL // external avatar (e.g. from an identity provider)
+	}
+	ttl := s.AvatarURLTTL
+	if ttl <= 0 {
+		ttl = 15 * time.Minute
+	}
+	if s.Redis != nil {
+		if url, err := s.Redis.Get(ctx, keyAvatarURL(objectPath)).Result(); err == nil {
+			return url
+		}
+	}
+	url, err := s.AvatarStorage.SignedURL(ctx, objectPath, ttl)
+	if err != nil {
+		helpers.FromContext(ctx).WithError(err).WithField("user_id", u.ID).Warn("sign avatar url failed")
+		return u.AvatarURL
+	}
+	if s.Redis != nil {
+		if err := s.Redis.Set(ctx, keyAvatarURL(objectPath), url, ttl-ttl/5).Err(); err != nil {
+			helpers.FromContext(ctx).WithError(err).Debug("cache signed avatar url failed")
+		}
+	}
+	return url
+}
@@ -0,0 +1,83 @@
+package application
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+
+	"github.com/sirupsen/logrus"
+
+	repo "github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+)
+
+var ErrBackupCodeInvalid = errors.New("backup code invalid or already used")
+
+// BackupCodeService issues and redeems one-time recovery codes, accepted in place of the login OTP
+// when the user cannot receive it. Only salted SHA-256 hashes are stored; the plaintext codes are
+// shown once, when generated.
+type BackupCodeService struct {
+	Repo      repo.BackupCodeRepository
+	Logger    *logrus.Logger
+	Count     int // codes per set
+	WarnBelow int // Low reports true once fewer remain
+}
+
+func NewBackupCodeService(r repo.BackupCodeRepository, logger *logrus.Logger, count, warnBelow int) *BackupCodeService {
+	if count < 1 {
+		count = 10
+	}
+	return &BackupCodeService{Repo: r, Logger: logger, Count: count, WarnBelow: warnBelow}
+}
+
+// BackupCodeStatus is what the user sees about their codes (never the codes themselves)
+type BackupCodeStatus struct {
+	Remaining int  `json:"remaining"`
+	Low       bool `json:"low"` // regenerate soon
+}
+
+// backupCodeHash salts with the user id, so equal codes of different users hash differently
+func backupCodeHash(userID, code string) string {
+	sum := sha256.Sum256([]byte(userID + ":" + helpers.NormalizeBackupCode(code)))
+	return hex.EncodeToString(sum[:])
+}
+
+// Generate replaces the user's codes with a fresh set and returns it; earlier codes stop working
+func (s *BackupCodeService) Generate(ctx context.Context, userID string) ([]string, error) {
+	codes := make([]string, 0, s.Count)
+	hashes := make([]string, 0, s.Count)
+	for len(codes) < s.Count {
+		code, err := helpers.GenBackupCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, backupCodeHash(userID, code))
+	}
+	if err := s.Repo.Replace(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Redeem spends code and returns the status afterwards; ErrBackupCodeInvalid when it does not match
+// an unused code
+func (s *BackupCodeService) Redeem(ctx context.Context, userID, code string) (BackupCodeStatus, error) {
+	ok, err := s.Repo.Use(ctx, userID, backupCodeHash(userID, code))
+	if err != nil {
+		return BackupCodeStatus{}, err
+	}
+	if !ok {
+		return BackupCodeStatus{}, ErrBackupCodeInvalid
+	}
+	return s.Status(ctx, userID)
+}
+
+func (s *BackupCodeService) Status(ctx context.Context, userID string) (BackupCodeStatus, error) {
+	n, err := s.Repo.Remaining(ctx, userID)
+	if err != nil {
+		return BackupCodeStatus{}, err
+	}
+	return BackupCodeStatus{Remaining: n, Low: n < s.WarnBelow}, nil
+}
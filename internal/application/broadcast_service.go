@@ -0,0 +1,379 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	repo "github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/mailer"
+	tpl "github.com/oksasatya/go-ddd-clean-architecture/pkg/mailer/templates"
+)
+
+var (
+	ErrBroadcastUnavailable = errors.New("email broadcasts not configured")
+	ErrBroadcastNotFound    = errors.New("broadcast not found")
+	ErrBroadcastAudience    = errors.New("invalid broadcast audience")
+	ErrBroadcastFinished    = errors.New("broadcast already finished")
+
+	// errBroadcastStopped ends a run whose broadcast was cancelled meanwhile
+	errBroadcastStopped = errors.New("broadcast no longer running")
+)
+
+const broadcastMaxAttempts = 5
+
+// BroadcastMessage is the email a broadcast sends: a universal template type with its data, or a
+// raw subject with text and/or html. Template data gets each recipient's Name and Email and their
+// announcements unsubscribe link.
+type BroadcastMessage struct {
+	Template string         `json:"template,omitempty"`
+	Data     map[string]any `json:"data,omitempty"`
+	Subject  string         `json:"subject,omitempty"`
+	Text     string         `json:"text,omitempty"`
+	HTML     string         `json:"html,omitempty"`
+	Locale   string         `json:"locale,omitempty"`
+	From     string         `json:"from,omitempty"`
+	ReplyTo  string         `json:"reply_to,omitempty"`
+	Tags     []string       `json:"tags,omitempty"`
+}
+
+// BroadcastService sends an admin email to an audience (every verified user, a role's holders or
+// an organization's members; suspended and banned users are left out). Create stores a queued
+// broadcast; the worker (Start) claims broadcasts one at a time on any instance, walks the audience
+// in batches of Batch users and enqueues one email job per recipient, at most Rate per second so
+// the email queue and provider are not flooded. Users who opted out of announcements are skipped.
+//
+// Progress is saved after every batch. A broadcast whose instance died, or whose enqueueing failed,
+// stays running until its heartbeat is StaleAfter old and is then resumed after the last saved
+// user, up to broadcastMaxAttempts times; jobs carry a per-recipient dedup key, so users of a batch
+// cut short are not emailed twice.
+type BroadcastService struct {
+	Repo       repo.EmailBroadcastRepository
+	Roles      repo.RoleRepository
+	Orgs       repo.OrganizationRepository
+	Prefs      *NotificationPreferenceService
+	Pub        EmailPublisher
+	Logger     *logrus.Logger
+	Batch      int           // recipients per batch
+	Rate       int           // emails enqueued per second; 0 = unthrottled
+	Interval   time.Duration // how often an idle worker looks for queued broadcasts
+	StaleAfter time.Duration // a running broadcast without progress for this long is claimed again
+	Senders    []string      // from overrides allowed by mailer.SenderAllowed (MAIL_FROM_ALLOWLIST)
+
+	closed  atomic.Bool
+	stop    chan struct{}
+	done    chan struct{}
+	runOnce sync.Once
+}
+
+func NewBroadcastService(r repo.EmailBroadcastRepository, roles repo.RoleRepository, orgs repo.OrganizationRepository, prefs *NotificationPreferenceService, pub EmailPublisher, logger *logrus.Logger, batch, rate int, interval time.Duration) *BroadcastService {
+	if batch <= 0 {
+		batch = 500
+	}
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	return &BroadcastService{
+		Repo: r, Roles: roles, Orgs: orgs, Prefs: prefs, Pub: pub, Logger: logger,
+		Batch: batch, Rate: rate, Interval: interval, StaleAfter: 5 * time.Minute,
+		stop: make(chan struct{}), done: make(chan struct{}),
+	}
+}
+
+// CanRun reports whether broadcasts can be sent (they need the email queue)
+func (s *BroadcastService) CanRun() bool { return s != nil && s.Pub != nil }
+
+// Create validates the audience and message and queues the broadcast
+func (s *BroadcastService) Create(ctx context.Context, audience entity.BroadcastAudience, msg BroadcastMessage, requestedBy string) (*entity.EmailBroadcast, error) {
+	if !s.CanRun() {
+		return nil, ErrBroadcastUnavailable
+	}
+	audience, err := s.checkAudience(audience)
+	if err != nil {
+		return nil, err
+	}
+	if msg.Template != "" {
+		if typ, _ := msg.Data["Type"].(string); tpl.IsSecurity(typ) {
+			return nil, fmt.Errorf("%w: security email %q cannot be broadcast", mailer.ErrInvalidJob, typ)
+		}
+	}
+	if msg.From != "" && !mailer.SenderAllowed(msg.From, s.Senders) {
+		return nil, fmt.Errorf("%w: from %q is not an allowed sender", mailer.ErrInvalidJob, msg.From)
+	}
+	// Validate the job a recipient would get, so a bad message fails here rather than per user
+	job := broadcastJob("", msg, entity.BroadcastRecipient{ID: "preview", Email: "recipient@example.com"}, "")
+	if err := job.Upgrade(); err != nil {
+		return nil, err
+	}
+	if err := job.Validate(); err != nil {
+		return nil, err
+	}
+	raw, err := json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	b := &entity.EmailBroadcast{Audience: audience, Message: raw, RequestedBy: requestedBy}
+	if err := s.Repo.Create(ctx, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// checkAudience makes sure the role or organization exists and drops fields the type does not use
+func (s *BroadcastService) checkAudience(a entity.BroadcastAudience) (entity.BroadcastAudience, error) {
+	switch a.Type {
+	case entity.BroadcastVerified:
+		return entity.BroadcastAudience{Type: a.Type}, nil
+	case entity.BroadcastRole:
+		if a.Role == "" {
+			return a, fmt.Errorf("%w: role is required", ErrBroadcastAudience)
+		}
+		if _, err := s.Roles.GetRoleByName(a.Role); err != nil {
+			return a, notFound(err, fmt.Errorf("%w: role %q not found", ErrBroadcastAudience, a.Role))
+		}
+		return entity.BroadcastAudience{Type: a.Type, Role: a.Role}, nil
+	case entity.BroadcastOrg:
+		if a.OrgID == "" {
+			return a, fmt.Errorf("%w: org_id is required", ErrBroadcastAudience)
+		}
+		org, err := s.Orgs.GetByID(a.OrgID)
+		if err != nil {
+			return a, notFound(err, fmt.Errorf("%w: organization %q not found", ErrBroadcastAudience, a.OrgID))
+		}
+		return entity.BroadcastAudience{Type: a.Type, OrgID: org.ID}, nil
+	}
+	return a, fmt.Errorf("%w: type must be verified, role or org", ErrBroadcastAudience)
+}
+
+// Get returns the broadcast with its progress
+func (s *BroadcastService) Get(ctx context.Context, id string) (*entity.EmailBroadcast, error) {
+	b, err := s.Repo.Get(ctx, id)
+	if err != nil {
+		return nil, notFound(err, ErrBroadcastNotFound)
+	}
+	return b, nil
+}
+
+// Cancel stops a queued or running broadcast; emails already enqueued are still sent
+func (s *BroadcastService) Cancel(ctx context.Context, id string) (*entity.EmailBroadcast, error) {
+	ok, err := s.Repo.Cancel(ctx, id)
+	if err != nil {
+		return nil, notFound(err, ErrBroadcastNotFound)
+	}
+	b, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return b, ErrBroadcastFinished
+	}
+	return b, nil
+}
+
+// Start runs the worker until Close: it drains the queue, then looks again every Interval
+func (s *BroadcastService) Start() {
+	s.runOnce.Do(func() { go s.run() })
+}
+
+func (s *BroadcastService) run() {
+	defer close(s.done)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-s.stop
+		cancel()
+	}()
+	for {
+		ran, err := s.RunNext(ctx)
+		if err != nil && !errors.Is(err, context.Canceled) {
+			s.Logger.WithError(err).Warn("broadcast worker failed; will retry")
+		}
+		if ran && err == nil {
+			continue
+		}
+		select {
+		case <-s.stop:
+			return
+		case <-time.After(s.Interval):
+		}
+	}
+}
+
+// Close stops the worker and waits for it until ctx is done. A broadcast cut short stays running
+// until its heartbeat goes stale, then another instance resumes it.
+func (s *BroadcastService) Close(ctx context.Context) {
+	if s == nil || !s.closed.CompareAndSwap(false, true) {
+		return
+	}
+	close(s.stop)
+	s.runOnce.Do(func() { close(s.done) }) // never started
+	select {
+	case <-s.done:
+	case <-ctx.Done():
+	}
+}
+
+// RunNext claims one broadcast and sends it; false when the queue is empty. When enqueueing fails
+// the broadcast is left running, to be resumed once stale.
+func (s *BroadcastService) RunNext(ctx context.Context) (bool, error) {
+	b, err := s.Repo.Claim(ctx, time.Now().Add(-s.StaleAfter))
+	if errors.Is(err, repo.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	log := s.Logger.WithFields(logrus.Fields{"broadcast": b.ID, "audience": b.Audience.Type, "attempt": b.Attempts})
+	if b.Attempts > broadcastMaxAttempts {
+		b.State, b.Error = entity.BroadcastFailed, fmt.Sprintf("gave up after %d attempts", broadcastMaxAttempts)
+		log.Error("broadcast abandoned")
+		return true, s.Repo.Finish(context.WithoutCancel(ctx), b)
+	}
+
+	err = s.send(ctx, b)
+	switch {
+	case errors.Is(err, errBroadcastStopped):
+		log.WithField("enqueued", b.Enqueued).Info("broadcast cancelled")
+		return true, nil
+	case errors.Is(err, context.Canceled) && ctx.Err() != nil:
+		return true, err
+	case errors.Is(err, errBroadcastEnqueue):
+		log.WithError(err).Warn("broadcast interrupted; it resumes once stale")
+		return true, nil
+	case err != nil:
+		b.State, b.Error = entity.BroadcastFailed, err.Error()
+		log.WithError(err).Error("broadcast failed")
+	default:
+		b.State = entity.BroadcastDone
+		log.WithFields(logrus.Fields{"enqueued": b.Enqueued, "skipped": b.Skipped, "failed": b.Failed}).Info("broadcast done")
+	}
+	return true, s.Repo.Finish(context.WithoutCancel(ctx), b)
+}
+
+// errBroadcastEnqueue wraps a publish failure; the broadcast is resumed rather than failed
+var errBroadcastEnqueue = errors.New("email job not enqueued")
+
+// send counts the audience on the first attempt, then enqueues the remaining recipients batch by
+// batch, saving progress after each
+func (s *BroadcastService) send(ctx context.Context, b *entity.EmailBroadcast) error {
+	var msg BroadcastMessage
+	if err := json.Unmarshal(b.Message, &msg); err != nil {
+		return err
+	}
+	if b.LastUserID == "" {
+		n, err := s.Repo.CountRecipients(ctx, b.Audience)
+		if err != nil {
+			return err
+		}
+		b.Total = n
+	}
+	if err := s.progress(ctx, b); err != nil {
+		return err
+	}
+	var pace time.Duration
+	if s.Rate > 0 {
+		pace = time.Second / time.Duration(s.Rate)
+	}
+	next := time.Now()
+	for {
+		users, err := s.Repo.Recipients(ctx, b.Audience, b.LastUserID, s.Batch)
+		if err != nil {
+			return err
+		}
+		for _, u := range users {
+			if !s.Prefs.AllowedCategory(ctx, u.ID, NotifyAnnouncements) {
+				b.Skipped++
+				b.LastUserID = u.ID
+				continue
+			}
+			if wait := time.Until(next); wait > 0 {
+				select {
+				case <-ctx.Done():
+					_ = s.progress(context.WithoutCancel(ctx), b)
+					return ctx.Err()
+				case <-time.After(wait):
+				}
+			}
+			if now := time.Now(); now.After(next) {
+				next = now
+			}
+			next = next.Add(pace)
+
+			job := broadcastJob(b.ID, msg, u, s.Prefs.CategoryUnsubscribeLink(u.ID, NotifyAnnouncements))
+			err := job.Upgrade()
+			if err == nil {
+				err = job.Validate()
+			}
+			if err != nil {
+				// this recipient only (say, an address the mailer rejects)
+				b.Failed++
+				b.LastUserID = u.ID
+				s.Logger.WithError(err).WithFields(logrus.Fields{"broadcast": b.ID, "user_id": u.ID}).Warn("broadcast email not valid for recipient")
+				continue
+			}
+			if err := s.Pub.PublishEmail(ctx, job); err != nil {
+				_ = s.progress(context.WithoutCancel(ctx), b)
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				return fmt.Errorf("%w: %v", errBroadcastEnqueue, err)
+			}
+			b.Enqueued++
+			b.LastUserID = u.ID
+		}
+		if err := s.progress(ctx, b); err != nil {
+			return err
+		}
+		if len(users) < s.Batch {
+			return nil
+		}
+	}
+}
+
+// progress saves b's counts and position; errBroadcastStopped once it was cancelled
+func (s *BroadcastService) progress(ctx context.Context, b *entity.EmailBroadcast) error {
+	ok, err := s.Repo.Progress(ctx, b)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errBroadcastStopped
+	}
+	return nil
+}
+
+// broadcastJob is msg addressed to u; its dedup key keeps a resumed broadcast from emailing u twice
+func broadcastJob(broadcastID string, msg BroadcastMessage, u entity.BroadcastRecipient, unsubscribeURL string) mailer.EmailJob {
+	job := mailer.EmailJob{
+		To: u.Email, Subject: msg.Subject, Text: msg.Text, HTML: msg.HTML, Locale: msg.Locale,
+		DedupKey: "broadcast:" + broadcastID + ":" + u.ID,
+		Envelope: mailer.Envelope{
+			From: msg.From, ReplyTo: msg.ReplyTo, Tags: msg.Tags,
+			Variables: map[string]string{"user_id": u.ID, "broadcast_id": broadcastID},
+		},
+		JobMeta: mailer.JobMeta{UserID: u.ID},
+	}
+	if msg.Template != "" {
+		data := maps.Clone(msg.Data)
+		if data == nil {
+			data = map[string]any{}
+		}
+		if _, ok := data["Name"]; !ok {
+			data["Name"] = u.Name
+		}
+		data["Email"], data["RecipientEmail"] = u.Email, u.Email
+		if unsubscribeURL != "" {
+			data["UnsubscribeURL"] = unsubscribeURL
+		}
+		job.Template, job.Data = msg.Template, data
+	}
+	return job
+}
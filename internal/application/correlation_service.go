@@ -0,0 +1,59 @@
+package application
+
+import (
+	"context"
+	"errors"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	repo "github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+)
+
+var ErrCorrelationNotFound = errors.New("correlation not found")
+
+// correlationAuditLimit bounds the audit entries and the email events returned for one flow
+const correlationAuditLimit = 500
+
+// Correlation is everything recorded for one auth flow: its audit entries and the provider outcomes
+// of its emails (Postgres), and its login and email trail (Redis, kept for CORRELATION_TTL)
+type Correlation struct {
+	ID          string
+	AuditLogs   []entity.AuditLog
+	EmailEvents []*entity.EmailEvent
+	Trail       []helpers.CorrelationEvent
+}
+
+// CorrelationService reconstructs auth flows from their correlation id for support
+type CorrelationService struct {
+	Audit  repo.AuditRepository
+	Emails repo.EmailEventRepository // optional
+	Redis  *redis.Client
+}
+
+func NewCorrelationService(audit repo.AuditRepository, emails repo.EmailEventRepository, rdb *redis.Client) *CorrelationService {
+	return &CorrelationService{Audit: audit, Emails: emails, Redis: rdb}
+}
+
+// Lookup returns the flow id, or ErrCorrelationNotFound when nothing carries it
+func (s *CorrelationService) Lookup(ctx context.Context, id string) (*Correlation, error) {
+	logs, err := s.Audit.ListAfter(entity.AuditFilter{CorrelationID: id}, 0, correlationAuditLimit)
+	if err != nil {
+		return nil, err
+	}
+	var emails []*entity.EmailEvent
+	if s.Emails != nil {
+		if emails, err = s.Emails.ListByCorrelation(ctx, id, correlationAuditLimit); err != nil {
+			return nil, err
+		}
+	}
+	trail, err := helpers.GetCorrelation(ctx, s.Redis, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(logs) == 0 && len(emails) == 0 && len(trail) == 0 {
+		return nil, ErrCorrelationNotFound
+	}
+	return &Correlation{ID: id, AuditLogs: logs, EmailEvents: emails, Trail: trail}, nil
+}
@@ -0,0 +1,385 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/config"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	repo "github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/keyspace"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/mailer"
+	tpl "github.com/oksasatya/go-ddd-clean-architecture/pkg/mailer/templates"
+)
+
+func keyDigestLock() string { return keyspace.Key("digest:lock") }
+func keyDigestSent(uid, week string) string {
+	return keyspace.Key("digest:sent:" + uid + ":" + week)
+}
+
+const (
+	digestPeriod     = 7 * 24 * time.Hour
+	digestUserBatch  = 500
+	digestAuditPage  = 500
+	digestMaxLogins  = 5  // listed in the email; the rest are only counted
+	digestMaxEntries = 20 // per section
+	digestSentTTL    = 8 * 24 * time.Hour
+	digestLockTTL    = time.Hour // when passes are triggered externally (Interval 0)
+	digestTimeLayout = "Mon 02 Jan, 15:04"
+)
+
+// digestSecurityActions are the audit actions listed under security activity
+var digestSecurityActions = map[string]string{
+	"sessions_revoked":       "Signed out of all sessions",
+	"backup_codes_generated": "New backup codes generated",
+	"phone_changed":          "Phone number changed",
+	"phone_removed":          "Phone number removed",
+	"suspicious_login_email": "Sign-in from a new location flagged",
+}
+
+// EmailPublisher enqueues email jobs (helpers.RabbitPublisher)
+type EmailPublisher interface {
+	PublishEmail(ctx context.Context, job mailer.EmailJob) error
+}
+
+// DigestReport is what one pass did
+type DigestReport struct {
+	Checked int `json:"checked"` // users looked at
+	Sent    int `json:"sent"`
+	Empty   int `json:"empty"` // due but nothing happened during the week
+	Failed  int `json:"failed"`
+}
+
+// DigestService emails each verified, active user a weekly summary of their sign-ins, profile
+// changes and security events, built from the audit log and the user history. A user is due once
+// Weekday/Hour has passed in their timezone: the one resolved for their latest login, or
+// DefaultLocation. Only one instance runs a pass at a time, a per-user, per-week Redis marker
+// keeps a user from getting the week twice, and the activity_digest notification preference is
+// honoured. An instance that is down for a user's whole send day skips that week.
+type DigestService struct {
+	Users  repo.UserRepository
+	Audit  repo.AuditRepository
+	Events repo.UserEventRepository
+	Prefs  *NotificationPreferenceService
+	Pub    EmailPublisher
+	Redis  *redis.Client
+	Cfg    *config.Config
+	Logger *logrus.Logger
+
+	Weekday         time.Weekday
+	Hour            int
+	DefaultLocation *time.Location
+	Interval        time.Duration
+
+	locMu sync.Mutex
+	locs  map[string]*time.Location // nil value: unknown zone
+
+	stop    chan struct{}
+	done    chan struct{}
+	runOnce sync.Once
+	closed  atomic.Bool
+}
+
+func NewDigestService(users repo.UserRepository, audit repo.AuditRepository, events repo.UserEventRepository, prefs *NotificationPreferenceService, pub EmailPublisher, rdb *redis.Client, cfg *config.Config, logger *logrus.Logger) *DigestService {
+	loc, err := time.LoadLocation(cfg.DigestTimezone)
+	if err != nil {
+		logger.WithError(err).WithField("timezone", cfg.DigestTimezone).Warn("digest timezone unknown, using UTC")
+		loc = time.UTC
+	}
+	return &DigestService{
+		Users: users, Audit: audit, Events: events, Prefs: prefs, Pub: pub, Redis: rdb, Cfg: cfg, Logger: logger,
+		Weekday: cfg.DigestWeekday(), Hour: cfg.DigestHour, DefaultLocation: loc, Interval: cfg.DigestCheckInterval,
+		locs: map[string]*time.Location{},
+		stop: make(chan struct{}), done: make(chan struct{}),
+	}
+}
+
+// Start checks for due users every Interval until Close; with Interval 0 passes only run when
+// triggered (RunOnce)
+func (s *DigestService) Start() {
+	if s.Interval <= 0 {
+		return
+	}
+	s.runOnce.Do(func() { go s.run() })
+}
+
+func (s *DigestService) run() {
+	defer close(s.done)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-s.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	t := time.NewTicker(s.Interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			rep, err := s.RunOnce(ctx, time.Now())
+			if err != nil && ctx.Err() == nil {
+				s.Logger.WithError(err).Warn("activity digest pass failed; will retry")
+			}
+			if rep != nil && rep.Sent+rep.Failed > 0 {
+				s.Logger.WithFields(logrus.Fields{"checked": rep.Checked, "sent": rep.Sent, "empty": rep.Empty, "failed": rep.Failed}).Info("activity digests enqueued")
+			}
+		}
+	}
+}
+
+// Close stops the loop, cancelling a pass in progress
+func (s *DigestService) Close(ctx context.Context) {
+	if !s.closed.CompareAndSwap(false, true) {
+		return
+	}
+	close(s.stop)
+	s.runOnce.Do(func() { close(s.done) }) // never started
+	select {
+	case <-s.done:
+	case <-ctx.Done():
+	}
+}
+
+// RunOnce sends the digests due at now. It returns (nil, nil) when another instance holds the lock.
+func (s *DigestService) RunOnce(ctx context.Context, now time.Time) (*DigestReport, error) {
+	lockTTL := s.Interval
+	if lockTTL <= 0 {
+		lockTTL = digestLockTTL
+	}
+	ok, err := s.Redis.SetNX(ctx, keyDigestLock(), "1", lockTTL).Result()
+	if err != nil || !ok {
+		return nil, err
+	}
+	defer func() { _ = s.Redis.Del(context.Background(), keyDigestLock()).Err() }()
+
+	rep := &DigestReport{}
+	after := ""
+	for {
+		if err := ctx.Err(); err != nil {
+			return rep, err
+		}
+		users, err := s.Users.ListAfter(after, digestUserBatch)
+		if err != nil {
+			return rep, err
+		}
+		if err := s.runBatch(ctx, users, now, rep); err != nil {
+			return rep, err
+		}
+		if len(users) < digestUserBatch {
+			return rep, nil
+		}
+		after = users[len(users)-1].ID
+	}
+}
+
+func (s *DigestService) runBatch(ctx context.Context, users []entity.User, now time.Time, rep *DigestReport) error {
+	ids := make([]string, 0, len(users))
+	for _, u := range users {
+		if u.IsVerified && u.AccountError() == nil {
+			ids = append(ids, u.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	zones, err := s.Audit.LastLoginTimezones(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for i := range users {
+		u := &users[i]
+		if !u.IsVerified || u.AccountError() != nil {
+			continue
+		}
+		rep.Checked++
+		loc := s.location(zones[u.ID])
+		local := now.In(loc)
+		if local.Weekday() != s.Weekday || local.Hour() < s.Hour {
+			continue
+		}
+		year, week := local.ISOWeek()
+		sentKey := keyDigestSent(u.ID, fmt.Sprintf("%d-W%02d", year, week))
+		if n, err := s.Redis.Exists(ctx, sentKey).Result(); err != nil {
+			return err
+		} else if n > 0 {
+			continue
+		}
+		if !s.Prefs.Allowed(ctx, u.ID, tpl.ActivityDigest) {
+			continue
+		}
+		if ok, err := s.Redis.SetNX(ctx, sentKey, "1", digestSentTTL).Result(); err != nil {
+			return err
+		} else if !ok {
+			continue
+		}
+		sent, err := s.send(ctx, u, loc, now, sentKey)
+		switch {
+		case err != nil:
+			rep.Failed++
+			// let the next pass retry the user
+			_ = s.Redis.Del(context.Background(), sentKey).Err()
+			s.Logger.WithError(err).WithField("user_id", u.ID).Warn("activity digest not sent")
+		case sent:
+			rep.Sent++
+		default:
+			rep.Empty++
+		}
+	}
+	return nil
+}
+
+// location loads an IANA zone once; unknown or empty zones fall back to DefaultLocation
+func (s *DigestService) location(name string) *time.Location {
+	if name == "" {
+		return s.DefaultLocation
+	}
+	s.locMu.Lock()
+	defer s.locMu.Unlock()
+	loc, ok := s.locs[name]
+	if !ok {
+		loc, _ = time.LoadLocation(name)
+		s.locs[name] = loc
+	}
+	if loc == nil {
+		return s.DefaultLocation
+	}
+	return loc
+}
+
+// send builds the user's digest for the week before now and enqueues it; false when there was
+// nothing to report
+func (s *DigestService) send(ctx context.Context, u *entity.User, loc *time.Location, now time.Time, dedupKey string) (bool, error) {
+	from := now.Add(-digestPeriod)
+	logins, loginCount, security, err := s.auditActivity(ctx, u.ID, loc, from, now)
+	if err != nil {
+		return false, err
+	}
+	changes, passwordChanges, err := s.profileActivity(ctx, u.ID, loc, from)
+	if err != nil {
+		return false, err
+	}
+	security = append(security, passwordChanges...)
+	if loginCount == 0 && len(changes) == 0 && len(security) == 0 {
+		return false, nil
+	}
+	slices.SortStableFunc(security, func(a, b digestItem) int { return a.at.Compare(b.at) })
+
+	period := from.In(loc).Format("02 January") + " - " + now.In(loc).Format("02 January 2006")
+	data := tpl.NewActivityDigestData(s.Cfg, u.Name, u.Email, period, loc.String(), loginCount,
+		digestEntries(logins), digestEntries(changes), digestEntries(security),
+		tpl.WithUnsubscribeURL(s.Prefs.UnsubscribeLink(u.ID, tpl.ActivityDigest)))
+	job := mailer.EmailJob{
+		To: u.Email, Template: tpl.Universal, Data: tpl.ToMap(data), DedupKey: dedupKey,
+		Envelope: mailer.Envelope{Variables: map[string]string{"user_id": u.ID}},
+		JobMeta:  mailer.JobMeta{UserID: u.ID},
+	}
+	if err := s.Pub.PublishEmail(ctx, job); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// digestItem is a DigestEntry before formatting, kept with its time for ordering
+type digestItem struct {
+	at    time.Time
+	entry tpl.DigestEntry
+}
+
+func digestEntries(items []digestItem) []tpl.DigestEntry {
+	if len(items) > digestMaxEntries {
+		items = items[len(items)-digestMaxEntries:]
+	}
+	out := make([]tpl.DigestEntry, 0, len(items))
+	for _, it := range items {
+		out = append(out, it.entry)
+	}
+	return out
+}
+
+// auditActivity reads the user's audit entries in [from, to): the latest logins (newest first),
+// the login total and the security actions
+func (s *DigestService) auditActivity(ctx context.Context, userID string, loc *time.Location, from, to time.Time) ([]digestItem, int, []digestItem, error) {
+	var logins, security []digestItem
+	count := 0
+	f := entity.AuditFilter{UserID: userID, From: from, To: to}
+	var afterID int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, 0, nil, err
+		}
+		page, err := s.Audit.ListAfter(f, afterID, digestAuditPage)
+		if err != nil {
+			return nil, 0, nil, err
+		}
+		for _, a := range page {
+			at := a.CreatedAt.In(loc)
+			if a.Action == "login" {
+				count++
+				detail, _ := a.Metadata["location"].(string)
+				if detail == "" {
+					detail = a.IP
+				}
+				method, _ := a.Metadata["method"].(string)
+				logins = append(logins, digestItem{a.CreatedAt, tpl.DigestEntry{Time: at.Format(digestTimeLayout), Text: strings.ReplaceAll(method, "_", " "), Detail: detail}})
+				if len(logins) > digestMaxLogins {
+					logins = logins[1:]
+				}
+				continue
+			}
+			if text, ok := digestSecurityActions[a.Action]; ok {
+				security = append(security, digestItem{a.CreatedAt, tpl.DigestEntry{Time: at.Format(digestTimeLayout), Text: text, Detail: a.IP}})
+			}
+		}
+		if len(page) < digestAuditPage {
+			break
+		}
+		afterID = page[len(page)-1].ID
+	}
+	slices.Reverse(logins)
+	return logins, count, security, nil
+}
+
+// profileActivity reads the user's history since from: profile edits and verification, and
+// password changes (reported with the security activity)
+func (s *DigestService) profileActivity(ctx context.Context, userID string, loc *time.Location, from time.Time) ([]digestItem, []digestItem, error) {
+	events, err := s.Events.ListByUserSince(ctx, userID, from, digestAuditPage)
+	if err != nil {
+		return nil, nil, err
+	}
+	var changes, passwords []digestItem
+	for _, ev := range events {
+		at := ev.CreatedAt.In(loc).Format(digestTimeLayout)
+		switch ev.Type {
+		case entity.UserEventUpdated:
+			fields := make([]string, 0, len(ev.Changes))
+			for f := range ev.Changes {
+				if f != "password" {
+					fields = append(fields, strings.ReplaceAll(f, "_", " "))
+				}
+			}
+			if len(fields) == 0 {
+				continue
+			}
+			slices.Sort(fields)
+			changes = append(changes, digestItem{ev.CreatedAt, tpl.DigestEntry{Time: at, Text: "Profile updated", Detail: strings.Join(fields, ", ")}})
+		case entity.UserEventVerified:
+			changes = append(changes, digestItem{ev.CreatedAt, tpl.DigestEntry{Time: at, Text: "Email verified"}})
+		case entity.UserEventPasswordChanged:
+			passwords = append(passwords, digestItem{ev.CreatedAt, tpl.DigestEntry{Time: at, Text: "Password changed"}})
+		}
+	}
+	return changes, passwords, nil
+}
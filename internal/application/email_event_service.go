@@ -0,0 +1,218 @@
+package application
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	repo "github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+)
+
+const (
+	emailEventBatch      = 50
+	emailEventBaseDelay  = 30 * time.Second
+	emailEventMaxDelay   = time.Hour
+	emailEventErrorBytes = 512
+)
+
+// EmailEventPayload is the JSON body POSTed to EMAIL_EVENTS_WEBHOOK_URL for each event
+type EmailEventPayload struct {
+	ID         int64             `json:"id"`
+	Provider   string            `json:"provider"`
+	Event      string            `json:"event"` // delivered, bounced, deferred, complained, unsubscribed
+	Recipient  string            `json:"recipient"`
+	MessageID  string            `json:"message_id,omitempty"`
+	Reason     string            `json:"reason,omitempty"`
+	Variables  map[string]string `json:"variables,omitempty"` // as sent: user_id, request_id, correlation_id, custom
+	OccurredAt time.Time         `json:"occurred_at"`
+	Attempt    int               `json:"attempt"`
+}
+
+// EmailEventService records provider email events and forwards them to product services. Record
+// stores each event once (by provider event id), pending when a webhook URL is configured; the
+// forwarder (Start) claims due events on any instance and POSTs them as signed JSON. A non-2xx
+// answer or network error is retried with exponential backoff (30s doubling, at most an hour)
+// until MaxAttempts, after which the event is marked failed.
+//
+// Requests carry X-Email-Event-Id and X-Email-Event-Signature: "t=<unix>,v1=<hex>", where v1 is
+// HMAC-SHA256 of "<t>.<body>" with Secret; receivers should reject stale t and dedupe by id.
+type EmailEventService struct {
+	Repo        repo.EmailEventRepository
+	Client      *http.Client
+	URL         string
+	Secret      string
+	MaxAttempts int
+	Interval    time.Duration // how often an idle forwarder looks for due events
+	Logger      *logrus.Logger
+
+	closed  atomic.Bool
+	stop    chan struct{}
+	done    chan struct{}
+	runOnce sync.Once
+}
+
+func NewEmailEventService(r repo.EmailEventRepository, url, secret string, maxAttempts int, timeout, interval time.Duration, logger *logrus.Logger) *EmailEventService {
+	if maxAttempts <= 0 {
+		maxAttempts = 10
+	}
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	return &EmailEventService{
+		Repo: r, Client: &http.Client{Timeout: timeout}, URL: url, Secret: secret,
+		MaxAttempts: maxAttempts, Interval: interval, Logger: logger,
+		stop: make(chan struct{}), done: make(chan struct{}),
+	}
+}
+
+// Forwarding reports whether events are forwarded to a webhook
+func (s *EmailEventService) Forwarding() bool { return s != nil && s.URL != "" }
+
+// Record stores e; false when the provider event was already recorded
+func (s *EmailEventService) Record(ctx context.Context, e *entity.EmailEvent) (bool, error) {
+	if e.CorrelationID == "" {
+		e.CorrelationID = e.Variables["correlation_id"]
+	}
+	e.ForwardState = entity.EmailForwardNone
+	if s.Forwarding() {
+		e.ForwardState = entity.EmailForwardPending
+	}
+	return s.Repo.Record(ctx, e)
+}
+
+// Start runs the forwarder until Close: it drains due events, then looks again every Interval
+func (s *EmailEventService) Start() {
+	s.runOnce.Do(func() { go s.run() })
+}
+
+func (s *EmailEventService) run() {
+	defer close(s.done)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-s.stop
+		cancel()
+	}()
+	for {
+		n, err := s.ForwardBatch(ctx)
+		if err != nil && !errors.Is(err, context.Canceled) {
+			s.Logger.WithError(err).Warn("email event forwarder failed; will retry")
+		}
+		if n == emailEventBatch && err == nil {
+			continue
+		}
+		select {
+		case <-s.stop:
+			return
+		case <-time.After(s.Interval):
+		}
+	}
+}
+
+// Close stops the forwarder and waits for it until ctx is done. Events claimed but not yet sent
+// are retried once their lease runs out.
+func (s *EmailEventService) Close(ctx context.Context) {
+	if s == nil || !s.closed.CompareAndSwap(false, true) {
+		return
+	}
+	close(s.stop)
+	s.runOnce.Do(func() { close(s.done) }) // never started
+	select {
+	case <-s.done:
+	case <-ctx.Done():
+	}
+}
+
+// ForwardBatch claims up to one batch of due events and posts each; it returns how many it claimed
+func (s *EmailEventService) ForwardBatch(ctx context.Context) (int, error) {
+	// The lease outlasts a batch of requests that all time out
+	lease := time.Now().Add(emailEventBatch*s.Client.Timeout + time.Minute)
+	events, err := s.Repo.ClaimForward(ctx, emailEventBatch, lease)
+	if err != nil {
+		return 0, err
+	}
+	for _, e := range events {
+		if ctx.Err() != nil {
+			return len(events), ctx.Err()
+		}
+		log := s.Logger.WithFields(logrus.Fields{"email_event": e.ID, "event": e.Event, "attempt": e.ForwardAttempts})
+		if err := s.post(ctx, e); err != nil {
+			if errors.Is(err, context.Canceled) && ctx.Err() != nil {
+				return len(events), err
+			}
+			final := e.ForwardAttempts >= s.MaxAttempts
+			if final {
+				log.WithError(err).Error("email event not forwarded; giving up")
+			} else {
+				log.WithError(err).Warn("email event not forwarded; will retry")
+			}
+			if ferr := s.Repo.ForwardFailed(context.WithoutCancel(ctx), e.ID, time.Now().Add(emailEventBackoff(e.ForwardAttempts)), err.Error(), final); ferr != nil {
+				log.WithError(ferr).Warn("email event retry not saved")
+			}
+			continue
+		}
+		if err := s.Repo.Forwarded(context.WithoutCancel(ctx), e.ID); err != nil {
+			log.WithError(err).Warn("email event forwarded but not marked; it will be sent again")
+		}
+	}
+	return len(events), nil
+}
+
+// emailEventBackoff is the wait after the given failed attempt: 30s, 1m, 2m, ... capped at an hour
+func emailEventBackoff(attempt int) time.Duration {
+	d := emailEventBaseDelay
+	for i := 1; i < attempt && d < emailEventMaxDelay; i++ {
+		d *= 2
+	}
+	return min(d, emailEventMaxDelay)
+}
+
+func (s *EmailEventService) post(ctx context.Context, e *entity.EmailEvent) error {
+	body, err := json.Marshal(EmailEventPayload{
+		ID: e.ID, Provider: e.Provider, Event: e.Event, Recipient: e.Recipient, MessageID: e.MessageID,
+		Reason: e.Reason, Variables: e.Variables, OccurredAt: e.OccurredAt, Attempt: e.ForwardAttempts,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Email-Event-Id", strconv.FormatInt(e.ID, 10))
+	if s.Secret != "" {
+		req.Header.Set("X-Email-Event-Signature", SignEmailEvent(s.Secret, time.Now(), body))
+	}
+	res, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, emailEventErrorBytes))
+		return fmt.Errorf("webhook answered %d: %s", res.StatusCode, bytes.TrimSpace(msg))
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+	return nil
+}
+
+// SignEmailEvent is the X-Email-Event-Signature value for body sent at t
+func SignEmailEvent(secret string, t time.Time, body []byte) string {
+	return helpers.SignWebhook(secret, t, body)
+}
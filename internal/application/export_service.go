@@ -0,0 +1,298 @@
+package application
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/sirupsen/logrus"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	repo "github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
+)
+
+var (
+	ErrExportUnavailable = errors.New("async export not configured")
+	ErrExportJobNotFound = errors.New("export job not found")
+	ErrExportKind        = errors.New("unknown export kind")
+)
+
+const (
+	exportBatch       = 1000
+	exportURLTTL      = time.Hour
+	exportTimeout     = time.Hour
+	exportMaxAttempts = 3
+)
+
+// ExportKinds are the kinds ExportService can run
+var ExportKinds = []string{entity.ExportUsers, entity.ExportAudit}
+
+var userCSVHeader = []string{"id", "email", "name", "verified", "created_at", "updated_at", "suspended_at", "banned_at"}
+
+// ExportService runs large exports (users, audit logs) as background jobs. Request stores a queued
+// row in export_jobs; the worker (Start) claims jobs one at a time on any instance, writes the CSV
+// to GCS and records its progress as it goes, and Job returns the state with a short-lived signed
+// download URL once done. A job whose instance died stops heartbeating and is restarted elsewhere
+// after StaleAfter, up to exportMaxAttempts times.
+type ExportService struct {
+	Jobs       repo.ExportJobRepository
+	Users      repo.UserRepository
+	Audit      *AuditExportService
+	GCS        *storage.Client
+	Bucket     string
+	Logger     *logrus.Logger
+	Interval   time.Duration // how often an idle worker looks for queued jobs
+	StaleAfter time.Duration // a running job without progress for this long is claimed again
+	// Notify announces a queued job on the exports queue so a queue worker starts it at once
+	// instead of at the next poll; optional, failures only delay the job
+	Notify func(ctx context.Context, jobID string) error
+
+	closed  atomic.Bool
+	stop    chan struct{}
+	done    chan struct{}
+	runOnce sync.Once
+}
+
+func NewExportService(jobs repo.ExportJobRepository, users repo.UserRepository, audit *AuditExportService, gcs *storage.Client, bucket string, logger *logrus.Logger, interval time.Duration) *ExportService {
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	return &ExportService{
+		Jobs: jobs, Users: users, Audit: audit, GCS: gcs, Bucket: bucket, Logger: logger,
+		Interval: interval, StaleAfter: 5 * time.Minute,
+		stop: make(chan struct{}), done: make(chan struct{}),
+	}
+}
+
+// CanRun reports whether background exports are available (they need a GCS bucket)
+func (s *ExportService) CanRun() bool { return s != nil && s.GCS != nil && s.Bucket != "" }
+
+// Request queues an export of kind; params is its filter (entity.AuditFilter for audit, none for users)
+func (s *ExportService) Request(ctx context.Context, kind string, params any, requestedBy string) (*entity.ExportJob, error) {
+	if !s.CanRun() {
+		return nil, ErrExportUnavailable
+	}
+	if !slices.Contains(ExportKinds, kind) || (kind == entity.ExportAudit && s.Audit == nil) {
+		return nil, ErrExportKind
+	}
+	job := &entity.ExportJob{Kind: kind, RequestedBy: requestedBy}
+	if params != nil {
+		b, err := json.Marshal(params)
+		if err != nil {
+			return nil, err
+		}
+		job.Params = b
+	}
+	if err := s.Jobs.Create(ctx, job); err != nil {
+		return nil, err
+	}
+	if s.Notify != nil {
+		if err := s.Notify(ctx, job.ID); err != nil {
+			s.Logger.WithError(err).WithField("job", job.ID).Warn("export queue notify failed; job waits for the next poll")
+		}
+	}
+	return job, nil
+}
+
+// Job returns the export job and, once done, a signed download URL valid for an hour
+func (s *ExportService) Job(ctx context.Context, id string) (*entity.ExportJob, string, error) {
+	job, err := s.Jobs.Get(ctx, id)
+	if errors.Is(err, repo.ErrNotFound) {
+		return nil, "", ErrExportJobNotFound
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	if job.State != entity.ExportDone || !s.CanRun() {
+		return job, "", nil
+	}
+	u, err := s.GCS.Bucket(s.Bucket).SignedURL(job.Object, &storage.SignedURLOptions{
+		Method:  "GET",
+		Expires: time.Now().Add(exportURLTTL),
+		Scheme:  storage.SigningSchemeV4,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return job, u, nil
+}
+
+// Start runs the worker until Close: it drains the queue, then looks again every Interval
+func (s *ExportService) Start() {
+	s.runOnce.Do(func() { go s.run() })
+}
+
+func (s *ExportService) run() {
+	defer close(s.done)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-s.stop
+		cancel()
+	}()
+	for {
+		ran, err := s.RunNext(ctx)
+		if err != nil && !errors.Is(err, context.Canceled) {
+			s.Logger.WithError(err).Warn("export worker failed; will retry")
+		}
+		if ran && err == nil {
+			continue
+		}
+		select {
+		case <-s.stop:
+			return
+		case <-time.After(s.Interval):
+		}
+	}
+}
+
+// Close stops the worker and waits for it until ctx is done. A job cut short stays running
+// until its heartbeat goes stale, then another instance restarts it.
+func (s *ExportService) Close(ctx context.Context) {
+	if s == nil || !s.closed.CompareAndSwap(false, true) {
+		return
+	}
+	close(s.stop)
+	s.runOnce.Do(func() { close(s.done) }) // never started
+	select {
+	case <-s.done:
+	case <-ctx.Done():
+	}
+}
+
+// RunNext claims and runs one job; false when the queue is empty
+func (s *ExportService) RunNext(ctx context.Context) (bool, error) {
+	job, err := s.Jobs.Claim(ctx, time.Now().Add(-s.StaleAfter))
+	if errors.Is(err, repo.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	log := s.Logger.WithFields(logrus.Fields{"job": job.ID, "kind": job.Kind, "attempt": job.Attempts})
+	if job.Attempts > exportMaxAttempts {
+		job.State, job.Error = entity.ExportFailed, fmt.Sprintf("gave up after %d attempts", exportMaxAttempts)
+		log.Error("export abandoned")
+		return true, s.Jobs.Finish(context.WithoutCancel(ctx), job)
+	}
+
+	jctx, cancel := context.WithTimeout(ctx, exportTimeout)
+	defer cancel()
+	object := "exports/" + job.Kind + "/" + job.CreatedAt.UTC().Format("20060102") + "/" + job.ID + ".csv"
+	wc := s.GCS.Bucket(s.Bucket).Object(object).NewWriter(jctx)
+	wc.ContentType = "text/csv"
+	rows, err := s.write(jctx, wc, job)
+	if cerr := wc.Close(); err == nil {
+		err = cerr
+	}
+	if errors.Is(err, context.Canceled) && ctx.Err() != nil {
+		// shutting down: leave the job running so it is picked up again once stale
+		return true, err
+	}
+	job.Processed = rows
+	if err != nil {
+		job.State, job.Error = entity.ExportFailed, err.Error()
+		log.WithError(err).Error("export failed")
+	} else {
+		job.State, job.Object = entity.ExportDone, object
+		log.WithField("rows", rows).Info("export done")
+	}
+	return true, s.Jobs.Finish(context.WithoutCancel(ctx), job)
+}
+
+// write counts the job's rows, then writes them as CSV to w, recording progress after each batch
+func (s *ExportService) write(ctx context.Context, w io.Writer, job *entity.ExportJob) (int64, error) {
+	var total int64
+	progress := func(rows int64) {
+		if err := s.Jobs.Progress(ctx, job.ID, total, rows); err != nil {
+			s.Logger.WithError(err).WithField("job", job.ID).Warn("export progress not saved")
+		}
+	}
+	switch job.Kind {
+	case entity.ExportAudit:
+		var f entity.AuditFilter
+		if len(job.Params) > 0 {
+			if err := json.Unmarshal(job.Params, &f); err != nil {
+				return 0, err
+			}
+		}
+		n, err := s.Audit.Repo.Count(f)
+		if err != nil {
+			return 0, err
+		}
+		total = n
+		progress(0)
+		return s.Audit.WriteCSV(ctx, w, f, progress)
+	case entity.ExportUsers:
+		n, err := s.Users.Count(ctx, entity.UserFilter{})
+		if err != nil {
+			return 0, err
+		}
+		total = n
+		progress(0)
+		return s.writeUsersCSV(ctx, w, progress)
+	}
+	return 0, ErrExportKind
+}
+
+// writeUsersCSV writes every user in id order; passwords are never loaded
+func (s *ExportService) writeUsersCSV(ctx context.Context, w io.Writer, progress func(rows int64)) (int64, error) {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(userCSVHeader); err != nil {
+		return 0, err
+	}
+	var rows int64
+	after := ""
+	for {
+		if err := ctx.Err(); err != nil {
+			return rows, err
+		}
+		batch, err := s.Users.ListAfter(after, exportBatch)
+		if err != nil {
+			return rows, err
+		}
+		for _, u := range batch {
+			if err := cw.Write(userCSVRecord(u)); err != nil {
+				return rows, err
+			}
+		}
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			return rows, err
+		}
+		rows += int64(len(batch))
+		progress(rows)
+		if len(batch) < exportBatch {
+			return rows, nil
+		}
+		after = batch[len(batch)-1].ID
+	}
+}
+
+func userCSVRecord(u entity.User) []string {
+	ts := func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.UTC().Format(time.RFC3339)
+	}
+	return []string{
+		u.ID,
+		csvSafe(u.Email),
+		csvSafe(u.Name),
+		strconv.FormatBool(u.IsVerified),
+		u.CreatedAt.UTC().Format(time.RFC3339),
+		u.UpdatedAt.UTC().Format(time.RFC3339),
+		ts(u.SuspendedAt),
+		ts(u.BannedAt),
+	}
+}
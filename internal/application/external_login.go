@@ -0,0 +1,69 @@
+package application
+
+import (
+	"context"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	repo "github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+)
+
+// provisionByEmail finds a user by email for an external (OIDC/SAML) login, creating one with an
+// unusable password on first login. verified marks the email verified when the IdP asserts it.
+func provisionByEmail(ctx context.Context, users repo.UserRepository, logger *logrus.Logger, email, name string, verified bool) (*entity.User, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	u, err := users.GetByEmail(email)
+	if err != nil || u == nil {
+		hash, hErr := unusablePasswordHash()
+		if hErr != nil {
+			return nil, hErr
+		}
+		if name == "" {
+			name = email
+		}
+		u = &entity.User{Email: email, Password: hash, Name: name}
+		if cErr := users.Create(ctx, u); cErr != nil {
+			return nil, cErr
+		}
+		if logger != nil {
+			logger.WithField("user_id", u.ID).Info("provisioned user on first external login")
+		}
+	}
+	if verified && !u.IsVerified {
+		if vErr := users.SetVerified(ctx, u.ID); vErr == nil {
+			u.IsVerified = true
+		}
+	}
+	return u, nil
+}
+
+// grantMappedRoles grants the roles mapped from IdP groups; existing roles are kept.
+func grantMappedRoles(roles repo.RoleRepository, logger *logrus.Logger, userID string, groups []string, mapping map[string][]string) {
+	for _, g := range groups {
+		for _, roleName := range mapping[g] {
+			r, err := roles.GetRoleByName(roleName)
+			if err != nil || r == nil {
+				if logger != nil {
+					logger.WithField("role", roleName).Warn("mapped role does not exist")
+				}
+				continue
+			}
+			if err := roles.AssignRole(userID, r.ID); err != nil && logger != nil {
+				logger.WithError(err).WithField("role", roleName).Warn("assign mapped role failed")
+			}
+		}
+	}
+}
+
+// unusablePasswordHash hashes a random secret nobody knows, for accounts that sign in without a
+// password (IdP or email-code login)
+func unusablePasswordHash() (string, error) {
+	secret, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+	return helpers.HashPassword(secret)
+}
@@ -0,0 +1,127 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	repo "github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+)
+
+// Provider names stored in identities.provider
+const (
+	ProviderOIDC = "oidc"
+	ProviderSAML = "saml"
+)
+
+var (
+	ErrIdentityConflict        = errors.New("an account with this email already exists; sign in and link this provider")
+	ErrIdentityLinkedElsewhere = errors.New("identity is linked to another account")
+	ErrIdentityAlreadyLinked   = errors.New("a different identity from this provider is already linked")
+	ErrIdentityNotFound        = errors.New("identity not found")
+	ErrIdentityNoSubject       = errors.New("external identity has no subject")
+)
+
+// ExternalIdentity is the subject asserted by an IdP at login
+type ExternalIdentity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	Name          string
+	EmailVerified bool
+}
+
+// IdentityService maps IdP subjects to local users. A login whose email belongs to an existing
+// local account is refused unless AutoLink is on and the IdP asserts the email as verified.
+type IdentityService struct {
+	Identities repo.IdentityRepository
+	Users      repo.UserRepository
+	Logger     *logrus.Logger
+	AutoLink   bool
+}
+
+func NewIdentityService(identities repo.IdentityRepository, users repo.UserRepository, logger *logrus.Logger, autoLink bool) *IdentityService {
+	return &IdentityService{Identities: identities, Users: users, Logger: logger, AutoLink: autoLink}
+}
+
+// Resolve returns the user linked to ext, linking or provisioning one on first login.
+func (s *IdentityService) Resolve(ctx context.Context, ext ExternalIdentity) (*entity.User, error) {
+	if ext.Subject == "" {
+		return nil, ErrIdentityNoSubject
+	}
+	if id, err := s.Identities.GetByProvider(ext.Provider, ext.Subject); err == nil && id != nil {
+		return s.Users.GetByID(id.UserID)
+	}
+
+	email := strings.ToLower(strings.TrimSpace(ext.Email))
+	if u, err := s.Users.GetByEmail(email); err == nil && u != nil {
+		if !s.AutoLink || !ext.EmailVerified {
+			return nil, ErrIdentityConflict
+		}
+		if err := s.create(u.ID, ext); err != nil {
+			return nil, err
+		}
+		if s.Logger != nil {
+			helpers.FromContext(ctx).WithFields(logrus.Fields{"user_id": u.ID, "provider": ext.Provider}).Info("auto-linked identity by verified email")
+		}
+		return u, nil
+	}
+
+	// no session yet: the identity provider is the actor for the provisioning writes
+	ctx = repo.WithActor(ctx, "idp:"+ext.Provider)
+	u, err := provisionByEmail(ctx, s.Users, s.Logger, email, ext.Name, ext.EmailVerified)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.create(u.ID, ext); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// Link attaches ext to an already signed-in user.
+func (s *IdentityService) Link(ctx context.Context, userID string, ext ExternalIdentity) error {
+	if ext.Subject == "" {
+		return ErrIdentityNoSubject
+	}
+	if id, err := s.Identities.GetByProvider(ext.Provider, ext.Subject); err == nil && id != nil {
+		if id.UserID != userID {
+			return ErrIdentityLinkedElsewhere
+		}
+		return nil
+	}
+	linked, err := s.Identities.ListByUser(userID)
+	if err != nil {
+		return err
+	}
+	for _, id := range linked {
+		if id.Provider == ext.Provider {
+			return ErrIdentityAlreadyLinked
+		}
+	}
+	return s.create(userID, ext)
+}
+
+func (s *IdentityService) List(ctx context.Context, userID string) ([]entity.Identity, error) {
+	return s.Identities.ListByUser(userID)
+}
+
+func (s *IdentityService) Unlink(ctx context.Context, userID, provider string) error {
+	if err := s.Identities.Delete(userID, strings.ToLower(strings.TrimSpace(provider))); err != nil {
+		return ErrIdentityNotFound
+	}
+	return nil
+}
+
+func (s *IdentityService) create(userID string, ext ExternalIdentity) error {
+	return s.Identities.Create(&entity.Identity{
+		UserID:         userID,
+		Provider:       ext.Provider,
+		ProviderUserID: ext.Subject,
+		Email:          strings.ToLower(strings.TrimSpace(ext.Email)),
+	})
+}
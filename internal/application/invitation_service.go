@@ -0,0 +1,171 @@
+package application
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	repo "github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+)
+
+var (
+	ErrInvitationInvalid    = errors.New("invitation invalid or expired")
+	ErrInvitationNotFound   = errors.New("invitation not found")
+	ErrInvitationEmailTaken = errors.New("an account with this email already exists")
+)
+
+// Invitation states derived from the accepted/revoked/expiry columns
+const (
+	InvitationPending  = "pending"
+	InvitationAccepted = "accepted"
+	InvitationRevoked  = "revoked"
+	InvitationExpired  = "expired"
+)
+
+// InvitationStatus reports the state of inv at now.
+func InvitationStatus(inv entity.Invitation, now time.Time) string {
+	switch {
+	case inv.AcceptedAt != nil:
+		return InvitationAccepted
+	case inv.RevokedAt != nil:
+		return InvitationRevoked
+	case !now.Before(inv.ExpiresAt):
+		return InvitationExpired
+	default:
+		return InvitationPending
+	}
+}
+
+// InvitationService issues single-use invitations and creates accounts from them.
+// Only a SHA-256 of the token is stored; the raw token exists in the invite email only.
+type InvitationService struct {
+	Repo   repo.InvitationRepository
+	Users  repo.UserRepository
+	Roles  repo.RoleRepository
+	Orgs   repo.OrganizationRepository
+	Logger *logrus.Logger
+	TTL    time.Duration
+	Pwned  *helpers.PwnedPasswords // nil = no breached password check
+}
+
+func NewInvitationService(invitations repo.InvitationRepository, users repo.UserRepository, roles repo.RoleRepository, orgs repo.OrganizationRepository, logger *logrus.Logger, ttl time.Duration) *InvitationService {
+	return &InvitationService{Repo: invitations, Users: users, Roles: roles, Orgs: orgs, Logger: logger, TTL: ttl}
+}
+
+func hashInvitationToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Invite creates an invitation for email with role and returns it with the raw token.
+func (s *InvitationService) Invite(ctx context.Context, invitedBy, email, role string) (*entity.Invitation, string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if u, err := s.Users.GetByEmail(email); err == nil && u != nil {
+		return nil, "", ErrInvitationEmailTaken
+	}
+	rn, err := normalizeName(role)
+	if err != nil {
+		return nil, "", err
+	}
+	if r, err := s.Roles.GetRoleByName(rn); err != nil || r == nil {
+		return nil, "", notFound(err, ErrRoleNotFound)
+	}
+	return s.issue(&entity.Invitation{Email: email, Role: rn, InvitedBy: invitedBy})
+}
+
+// InviteToOrg invites email to sign up and join orgID with orgRole (no global role).
+func (s *InvitationService) InviteToOrg(ctx context.Context, invitedBy, email, orgID, orgRole string) (*entity.Invitation, string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if u, err := s.Users.GetByEmail(email); err == nil && u != nil {
+		return nil, "", ErrInvitationEmailTaken
+	}
+	return s.issue(&entity.Invitation{Email: email, OrgID: orgID, OrgRole: orgRole, InvitedBy: invitedBy})
+}
+
+func (s *InvitationService) issue(inv *entity.Invitation) (*entity.Invitation, string, error) {
+	token, err := randomToken(32)
+	if err != nil {
+		return nil, "", err
+	}
+	inv.ExpiresAt = time.Now().Add(s.TTL)
+	if err := s.Repo.Create(inv, hashInvitationToken(token)); err != nil {
+		return nil, "", err
+	}
+	return inv, token, nil
+}
+
+func (s *InvitationService) List(ctx context.Context) ([]entity.Invitation, error) {
+	return s.Repo.List()
+}
+
+func (s *InvitationService) Revoke(ctx context.Context, id string) error {
+	if err := s.Repo.Revoke(id); err != nil {
+		return ErrInvitationNotFound
+	}
+	return nil
+}
+
+// Accept consumes the invitation and creates the account with the invited role and membership.
+// The email counts as verified since the token was delivered to it. An empty password creates
+// a passwordless account that signs in with emailed codes.
+func (s *InvitationService) Accept(ctx context.Context, token, name, password string) (*entity.User, error) {
+	inv, err := s.Repo.GetByTokenHash(hashInvitationToken(strings.TrimSpace(token)))
+	if err != nil || inv == nil || InvitationStatus(*inv, time.Now()) != InvitationPending {
+		return nil, ErrInvitationInvalid
+	}
+	if u, err := s.Users.GetByEmail(inv.Email); err == nil && u != nil {
+		return nil, ErrInvitationEmailTaken
+	}
+	if err := s.Pwned.Check(ctx, password); err != nil {
+		return nil, err
+	}
+	var hash string
+	if password == "" {
+		hash, err = unusablePasswordHash()
+	} else {
+		hash, err = helpers.HashPassword(password)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Claim first so concurrent accepts cannot create two accounts
+	if err := s.Repo.MarkAccepted(inv.ID); err != nil {
+		return nil, ErrInvitationInvalid
+	}
+	if strings.TrimSpace(name) == "" {
+		name = inv.Email
+	}
+	u := &entity.User{Email: inv.Email, Password: hash, Name: strings.TrimSpace(name)}
+	ctx = repo.WithActor(ctx, "invitation:"+inv.ID)
+	if err := s.Users.Create(ctx, u); err != nil {
+		return nil, err
+	}
+	if err := s.Users.SetVerified(ctx, u.ID); err == nil {
+		u.IsVerified = true
+	}
+	if inv.Role != "" {
+		if r, err := s.Roles.GetRoleByName(inv.Role); err == nil && r != nil {
+			if err := s.Roles.AssignRole(u.ID, r.ID); err != nil && s.Logger != nil {
+				helpers.FromContext(ctx).WithError(err).WithField("role", inv.Role).Warn("assign invited role failed")
+			}
+		} else if s.Logger != nil {
+			helpers.FromContext(ctx).WithField("role", inv.Role).Warn("invited role no longer exists")
+		}
+	}
+	if inv.OrgID != "" {
+		if err := s.Orgs.AddMember(inv.OrgID, u.ID, inv.OrgRole); err != nil && s.Logger != nil {
+			helpers.FromContext(ctx).WithError(err).WithField("org_id", inv.OrgID).Warn("add invited member failed")
+		}
+	}
+	if s.Logger != nil {
+		helpers.FromContext(ctx).WithFields(logrus.Fields{"user_id": u.ID, "invitation_id": inv.ID}).Info("account created from invitation")
+	}
+	return u, nil
+}
@@ -0,0 +1,122 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/keyspace"
+)
+
+var ErrJobNotFound = errors.New("job not found")
+
+// keyJobRuns is the Redis hash of each job's last run (job name -> JSON JobRun)
+func keyJobRuns() string { return keyspace.Key("jobs:runs") }
+
+// Job is a maintenance task an external scheduler can trigger. Run returns what it did; a nil
+// result without an error means it was skipped because another instance holds its lock.
+type Job struct {
+	Name        string
+	Description string
+	Run         func(ctx context.Context) (any, error)
+}
+
+// JobRun is the outcome of one triggered run
+type JobRun struct {
+	Job        string    `json:"job"`
+	Ran        bool      `json:"ran"` // false: already running elsewhere
+	Result     any       `json:"result,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	StartedAt  time.Time `json:"started_at"`
+	DurationMS int64     `json:"duration_ms"`
+}
+
+// JobInfo is a registered job with its last run, if any
+type JobInfo struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	LastRun     *JobRun `json:"last_run,omitempty"`
+}
+
+// JobService runs maintenance jobs on request, so Cloud Scheduler, Cloud Tasks or cron can drive
+// them without a resident scheduler. A run is detached from the request that triggered it and
+// bounded by Timeout instead, so a client that gives up does not cancel it half way; each job's own
+// lock keeps concurrent triggers from running it twice. The last run of each job is kept in Redis.
+type JobService struct {
+	Redis   *redis.Client
+	Logger  *logrus.Logger
+	Timeout time.Duration
+
+	jobs map[string]Job
+}
+
+func NewJobService(rdb *redis.Client, logger *logrus.Logger, timeout time.Duration) *JobService {
+	if timeout <= 0 {
+		timeout = 30 * time.Minute
+	}
+	return &JobService{Redis: rdb, Logger: logger, Timeout: timeout, jobs: map[string]Job{}}
+}
+
+// Register adds job, replacing one with the same name
+func (s *JobService) Register(job Job) {
+	s.jobs[job.Name] = job
+}
+
+// List returns the registered jobs sorted by name, with their last run when Redis has one
+func (s *JobService) List(ctx context.Context) ([]JobInfo, error) {
+	var runs map[string]string
+	if s.Redis != nil {
+		var err error
+		if runs, err = s.Redis.HGetAll(ctx, keyJobRuns()).Result(); err != nil {
+			return nil, err
+		}
+	}
+	out := make([]JobInfo, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		info := JobInfo{Name: j.Name, Description: j.Description}
+		if raw, ok := runs[j.Name]; ok {
+			var r JobRun
+			if json.Unmarshal([]byte(raw), &r) == nil {
+				info.LastRun = &r
+			}
+		}
+		out = append(out, info)
+	}
+	sort.Slice(out, func(i, k int) bool { return out[i].Name < out[k].Name })
+	return out, nil
+}
+
+// Run runs the job called name to completion and records it. The error is the job's own.
+func (s *JobService) Run(ctx context.Context, name string) (*JobRun, error) {
+	job, ok := s.jobs[name]
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), s.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	res, err := job.Run(ctx)
+	run := &JobRun{Job: name, Ran: res != nil || err != nil, Result: res, StartedAt: start.UTC(), DurationMS: time.Since(start).Milliseconds()}
+	log := helpers.FromContext(ctx).WithFields(logrus.Fields{"job": name, "ran": run.Ran, "duration_ms": run.DurationMS})
+	if err != nil {
+		run.Error = err.Error()
+		log.WithError(err).Error("job failed")
+	} else {
+		log.Info("job finished")
+	}
+	if s.Redis != nil && run.Ran {
+		if data, merr := json.Marshal(run); merr == nil {
+			if rerr := s.Redis.HSet(context.WithoutCancel(ctx), keyJobRuns(), name, data).Err(); rerr != nil {
+				log.WithError(rerr).Warn("job run not recorded")
+			}
+		}
+	}
+	return run, err
+}
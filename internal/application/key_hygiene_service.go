@@ -0,0 +1,234 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+
+	repo "github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/keyspace"
+)
+
+func keyHygieneLock() string { return keyspace.Key("hygiene:lock") }
+
+const (
+	hygieneReasonOrphaned = "orphaned" // the user no longer exists
+	hygieneReasonNoTTL    = "no_ttl"   // stored without an expiry, so it would never go away
+)
+
+// hygieneKind is one family of per-user keys the sweep inspects
+type hygieneKind struct {
+	name    string
+	pattern string
+	userID  func(key string) string
+	// expires is set for keys that are always written with a TTL; one without is stale
+	expires bool
+}
+
+// hygieneKinds is built per sweep: patterns depend on the keyspace prefix set at startup
+func hygieneKinds() []hygieneKind {
+	return []hygieneKind{
+		{name: "session", pattern: helpers.KeySession("*"), userID: func(k string) string {
+			return strings.TrimPrefix(k, helpers.KeySession(""))
+		}},
+		{name: "trusted_device", pattern: helpers.KeyTrustedDevice("*", "*"), expires: true, userID: func(k string) string {
+			uid, _, _ := strings.Cut(strings.TrimPrefix(k, strings.TrimSuffix(helpers.KeyTrustedDevice("", ""), ":")), ":")
+			return uid
+		}},
+		{name: "login_otp", pattern: helpers.KeyLoginOTP("*"), expires: true, userID: func(k string) string {
+			return strings.TrimPrefix(k, helpers.KeyLoginOTP(""))
+		}},
+		{name: "account_block", pattern: helpers.KeyAccountBlocked("*"), userID: func(k string) string {
+			return strings.TrimPrefix(k, helpers.KeyAccountBlocked(""))
+		}},
+	}
+}
+
+// HygieneReport is what one sweep found and removed
+type HygieneReport struct {
+	Scanned   map[string]int            `json:"scanned"`   // by kind
+	Reclaimed map[string]map[string]int `json:"reclaimed"` // by kind, then reason
+	Duration  time.Duration             `json:"-"`
+}
+
+// KeyHygieneService sweeps Redis for per-user keys the normal flows leave behind: sessions,
+// trusted devices and login OTPs of deleted users, and trusted devices or OTPs stored without
+// an expiry. Only one instance sweeps at a time; users are checked in batches against Postgres
+// and a failed lookup stops the sweep rather than deleting anything.
+type KeyHygieneService struct {
+	Users  repo.UserRepository
+	Redis  *redis.Client
+	Logger *logrus.Logger
+	Batch  int // keys per SCAN page and user lookup
+
+	mu        sync.Mutex
+	reclaimed map[[2]string]int64 // {kind, reason}
+	runs      int64
+	failures  int64
+	lastRun   time.Time
+}
+
+func NewKeyHygieneService(users repo.UserRepository, rdb *redis.Client, logger *logrus.Logger) *KeyHygieneService {
+	return &KeyHygieneService{Users: users, Redis: rdb, Logger: logger, Batch: 200, reclaimed: map[[2]string]int64{}}
+}
+
+// Sweep runs one pass over every key kind. It returns (nil, nil) when another instance holds the lock.
+func (s *KeyHygieneService) Sweep(ctx context.Context, lockTTL time.Duration) (*HygieneReport, error) {
+	ok, err := s.Redis.SetNX(ctx, keyHygieneLock(), "1", lockTTL).Result()
+	if err != nil || !ok {
+		return nil, err
+	}
+	defer func() { _ = s.Redis.Del(context.Background(), keyHygieneLock()).Err() }()
+
+	start := time.Now()
+	rep := &HygieneReport{Scanned: map[string]int{}, Reclaimed: map[string]map[string]int{}}
+	var sweepErr error
+	for _, kind := range hygieneKinds() {
+		rep.Reclaimed[kind.name] = map[string]int{}
+		if err := s.sweepKind(ctx, kind, rep); err != nil {
+			sweepErr = fmt.Errorf("%s: %w", kind.name, err)
+			break
+		}
+	}
+	rep.Duration = time.Since(start)
+	s.record(rep, sweepErr)
+	return rep, sweepErr
+}
+
+func (s *KeyHygieneService) sweepKind(ctx context.Context, kind hygieneKind, rep *HygieneReport) error {
+	iter := s.Redis.Scan(ctx, 0, kind.pattern, int64(s.Batch)).Iterator()
+	batch := make([]string, 0, s.Batch)
+	for iter.Next(ctx) {
+		batch = append(batch, iter.Val())
+		if len(batch) >= s.Batch {
+			if err := s.sweepBatch(ctx, kind, batch, rep); err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	if len(batch) == 0 {
+		return nil
+	}
+	return s.sweepBatch(ctx, kind, batch, rep)
+}
+
+func (s *KeyHygieneService) sweepBatch(ctx context.Context, kind hygieneKind, keys []string, rep *HygieneReport) error {
+	rep.Scanned[kind.name] += len(keys)
+	ids := make([]string, 0, len(keys))
+	for _, k := range keys {
+		ids = append(ids, kind.userID(k))
+	}
+	existing, err := s.Users.ExistingIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+	var ttls []*redis.DurationCmd
+	if kind.expires {
+		pipe := s.Redis.Pipeline()
+		for _, k := range keys {
+			ttls = append(ttls, pipe.TTL(ctx, k))
+		}
+		if _, err := pipe.Exec(ctx); err != nil {
+			return err
+		}
+	}
+	var del []string
+	reasons := map[string]int{}
+	for i, k := range keys {
+		switch {
+		case !existing[ids[i]]:
+			reasons[hygieneReasonOrphaned]++
+		case ttls != nil && ttls[i].Val() == -1:
+			// -1 = no expiry; -2 means the key expired since the scan
+			reasons[hygieneReasonNoTTL]++
+		default:
+			continue
+		}
+		del = append(del, k)
+	}
+	if len(del) == 0 {
+		return nil
+	}
+	if err := s.Redis.Del(ctx, del...).Err(); err != nil {
+		return err
+	}
+	for reason, n := range reasons {
+		rep.Reclaimed[kind.name][reason] += n
+	}
+	return nil
+}
+
+func (s *KeyHygieneService) record(rep *HygieneReport, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.runs++
+	s.lastRun = time.Now()
+	if err != nil {
+		s.failures++
+	}
+	for kind, reasons := range rep.Reclaimed {
+		for reason, n := range reasons {
+			s.reclaimed[[2]string{kind, reason}] += int64(n)
+		}
+	}
+}
+
+// Run sweeps every interval until ctx is cancelled; the lock outlives a sweep by at most the interval.
+func (s *KeyHygieneService) Run(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			rep, err := s.Sweep(ctx, interval)
+			if err != nil && s.Logger != nil {
+				s.Logger.WithError(err).Warn("redis key hygiene sweep failed; will retry")
+			}
+			if rep != nil && s.Logger != nil {
+				s.Logger.WithFields(logrus.Fields{
+					"scanned":     rep.Scanned,
+					"reclaimed":   rep.Reclaimed,
+					"duration_ms": rep.Duration.Milliseconds(),
+				}).Info("redis key hygiene sweep done")
+			}
+		}
+	}
+}
+
+// WriteMetrics appends the sweep counters in Prometheus text format. nil-safe.
+func (s *KeyHygieneService) WriteMetrics(b *strings.Builder) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := make([][2]string, 0, len(s.reclaimed))
+	for k := range s.reclaimed {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i][0] < keys[j][0] || keys[i][0] == keys[j][0] && keys[i][1] < keys[j][1]
+	})
+	b.WriteString("# HELP redis_key_hygiene_reclaimed_total Stale Redis keys deleted by the hygiene sweep, by kind and reason.\n# TYPE redis_key_hygiene_reclaimed_total counter\n")
+	for _, k := range keys {
+		fmt.Fprintf(b, "redis_key_hygiene_reclaimed_total{kind=%q,reason=%q} %d\n", k[0], k[1], s.reclaimed[k])
+	}
+	fmt.Fprintf(b, "# HELP redis_key_hygiene_runs_total Hygiene sweeps run by this instance.\n# TYPE redis_key_hygiene_runs_total counter\nredis_key_hygiene_runs_total %d\n", s.runs)
+	fmt.Fprintf(b, "# HELP redis_key_hygiene_failures_total Hygiene sweeps that stopped on an error.\n# TYPE redis_key_hygiene_failures_total counter\nredis_key_hygiene_failures_total %d\n", s.failures)
+	if !s.lastRun.IsZero() {
+		fmt.Fprintf(b, "# HELP redis_key_hygiene_last_run_timestamp_seconds When this instance last swept.\n# TYPE redis_key_hygiene_last_run_timestamp_seconds gauge\nredis_key_hygiene_last_run_timestamp_seconds %d\n", s.lastRun.Unix())
+	}
+}
@@ -0,0 +1,133 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/keyspace"
+	tpl "github.com/oksasatya/go-ddd-clean-architecture/pkg/mailer/templates"
+)
+
+const loginPendingTTL = 10 * time.Minute // matches the login OTP lifetime
+
+func keyLoginHistory(uid string) string { return keyspace.Key("user:login:history:" + uid) }
+func keyLoginPending(uid string) string { return keyspace.Key("user:login:pending:" + uid) }
+
+// LoginAssessment is the outcome of comparing a login's location with the user's recent history.
+type LoginAssessment struct {
+	IP         string  `json:"ip"`
+	Geo        tpl.Geo `json:"geo"`
+	NewCountry bool    `json:"new_country"`
+	NewNetwork bool    `json:"new_network"`
+}
+
+// Suspicious reports a login from a country or network not seen recently
+func (a LoginAssessment) Suspicious() bool { return a.NewCountry || a.NewNetwork }
+
+// LoginAnomalyService remembers the countries and networks (ASN) each user logged in from during
+// History and flags logins from new ones; alert emails get their revoke link from SessionRevokeService.
+// Lookups fail open: without geo data or history a login is never suspicious.
+type LoginAnomalyService struct {
+	Redis   *redis.Client
+	Geo     tpl.GeoResolver
+	Logger  *logrus.Logger
+	History time.Duration
+}
+
+func NewLoginAnomalyService(rdb *redis.Client, geo tpl.GeoResolver, logger *logrus.Logger, history time.Duration) *LoginAnomalyService {
+	return &LoginAnomalyService{Redis: rdb, Geo: geo, Logger: logger, History: history}
+}
+
+// Assess resolves ip and compares it with the user's recent logins; nil-safe.
+func (s *LoginAnomalyService) Assess(ctx context.Context, userID, ip string) LoginAssessment {
+	a := LoginAssessment{IP: ip}
+	if s == nil || s.Redis == nil || s.Geo == nil || strings.TrimSpace(ip) == "" {
+		return a
+	}
+	c, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+	g, err := s.Geo.Lookup(c, ip)
+	if err != nil {
+		return a
+	}
+	a.Geo = g
+	known, err := s.recent(ctx, userID)
+	if err != nil || len(known) == 0 {
+		return a
+	}
+	a.NewCountry = g.CountryCode != "" && !known["cc:"+g.CountryCode]
+	a.NewNetwork = g.ASN != "" && !known["asn:"+g.ASN]
+	return a
+}
+
+// recent returns the history entries seen within History, pruning older ones
+func (s *LoginAnomalyService) recent(ctx context.Context, userID string) (map[string]bool, error) {
+	all, err := s.Redis.HGetAll(ctx, keyLoginHistory(userID)).Result()
+	if err != nil {
+		return nil, err
+	}
+	cutoff := time.Now().Add(-s.History).Unix()
+	out := make(map[string]bool, len(all))
+	var stale []string
+	for field, v := range all {
+		if ts, _ := strconv.ParseInt(v, 10, 64); ts >= cutoff {
+			out[field] = true
+		} else {
+			stale = append(stale, field)
+		}
+	}
+	if len(stale) > 0 {
+		_ = s.Redis.HDel(ctx, keyLoginHistory(userID), stale...).Err()
+	}
+	return out, nil
+}
+
+// Record adds a successful login's country and network to the user's history.
+func (s *LoginAnomalyService) Record(ctx context.Context, userID string, a LoginAssessment) {
+	if s == nil || s.Redis == nil || (a.Geo.CountryCode == "" && a.Geo.ASN == "") {
+		return
+	}
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	fields := map[string]any{}
+	if a.Geo.CountryCode != "" {
+		fields["cc:"+a.Geo.CountryCode] = now
+	}
+	if a.Geo.ASN != "" {
+		fields["asn:"+a.Geo.ASN] = now
+	}
+	pipe := s.Redis.TxPipeline()
+	pipe.HSet(ctx, keyLoginHistory(userID), fields)
+	pipe.Expire(ctx, keyLoginHistory(userID), s.History)
+	if _, err := pipe.Exec(ctx); err != nil && s.Logger != nil {
+		helpers.FromContext(ctx).WithError(err).WithField("user_id", userID).Warn("login history not recorded")
+	}
+}
+
+// Remember keeps the assessment of a login waiting for its OTP so the confirm step can use it.
+func (s *LoginAnomalyService) Remember(ctx context.Context, userID string, a LoginAssessment) {
+	if s == nil || s.Redis == nil {
+		return
+	}
+	b, _ := json.Marshal(a)
+	_ = s.Redis.Set(ctx, keyLoginPending(userID), b, loginPendingTTL).Err()
+}
+
+// Pending returns and clears the assessment stored by Remember.
+func (s *LoginAnomalyService) Pending(ctx context.Context, userID string) (LoginAssessment, bool) {
+	var a LoginAssessment
+	if s == nil || s.Redis == nil {
+		return a, false
+	}
+	raw, err := s.Redis.GetDel(ctx, keyLoginPending(userID)).Bytes()
+	if err != nil || json.Unmarshal(raw, &a) != nil {
+		return a, false
+	}
+	return a, true
+}
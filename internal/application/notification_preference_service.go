@@ -0,0 +1,151 @@
+package application
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	repo "github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+	tpl "github.com/oksasatya/go-ddd-clean-architecture/pkg/mailer/templates"
+)
+
+var (
+	ErrUnknownNotificationCategory = errors.New("unknown notification category")
+	ErrUnsubscribeTokenInvalid     = errors.New("unsubscribe token invalid")
+)
+
+// Email categories a user may opt out of. Security emails (verification, password reset and change,
+// login OTP and new-login alerts) have no category and are always sent.
+const (
+	NotifyAccountUpdates = "account_updates"
+	NotifyActivityDigest = "activity_digest"
+	NotifyAnnouncements  = "announcements" // admin broadcasts
+)
+
+var NotificationCategories = []string{NotifyAccountUpdates, NotifyActivityDigest, NotifyAnnouncements}
+
+// NotificationCategory maps an email template type to its opt-out category ("" = security, always sent)
+func NotificationCategory(emailType string) string {
+	switch emailType {
+	case tpl.ProfileUpdated:
+		return NotifyAccountUpdates
+	case tpl.ActivityDigest:
+		return NotifyActivityDigest
+	}
+	return ""
+}
+
+// NotificationPreferenceService decides whether non-security emails may be sent and issues
+// per-user unsubscribe links (HMAC-signed, no expiry so old emails keep working).
+type NotificationPreferenceService struct {
+	Repo           repo.NotificationPreferenceRepository
+	Logger         *logrus.Logger
+	UnsubscribeURL string
+	Secret         string
+}
+
+func NewNotificationPreferenceService(r repo.NotificationPreferenceRepository, logger *logrus.Logger, unsubscribeURL, secret string) *NotificationPreferenceService {
+	return &NotificationPreferenceService{Repo: r, Logger: logger, UnsubscribeURL: unsubscribeURL, Secret: secret}
+}
+
+// Preferences returns every category with its current state
+func (s *NotificationPreferenceService) Preferences(ctx context.Context, userID string) (map[string]bool, error) {
+	stored, err := s.Repo.List(userID)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]bool, len(NotificationCategories))
+	for _, c := range NotificationCategories {
+		enabled, ok := stored[c]
+		out[c] = !ok || enabled
+	}
+	return out, nil
+}
+
+func (s *NotificationPreferenceService) Set(ctx context.Context, userID, category string, enabled bool) error {
+	if !slices.Contains(NotificationCategories, category) {
+		return ErrUnknownNotificationCategory
+	}
+	return s.Repo.Set(userID, category, enabled)
+}
+
+// Allowed reports whether an email of the given template type may be sent to the user.
+// Security emails are always allowed; on lookup errors non-security emails are skipped.
+func (s *NotificationPreferenceService) Allowed(ctx context.Context, userID, emailType string) bool {
+	return s.AllowedCategory(ctx, userID, NotificationCategory(emailType))
+}
+
+// AllowedCategory reports whether the user receives emails of category ("" = security, always sent)
+func (s *NotificationPreferenceService) AllowedCategory(ctx context.Context, userID, category string) bool {
+	if s == nil || category == "" {
+		return true
+	}
+	prefs, err := s.Preferences(ctx, userID)
+	if err != nil {
+		if s.Logger != nil {
+			helpers.FromContext(ctx).WithError(err).WithField("user_id", userID).Warn("notification preferences unavailable, skipping email")
+		}
+		return false
+	}
+	return prefs[category]
+}
+
+func (s *NotificationPreferenceService) unsubscribeMAC(payload string) string {
+	m := hmac.New(sha256.New, []byte(s.Secret))
+	m.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(m.Sum(nil))
+}
+
+// UnsubscribeLink returns UnsubscribeURL with a signed token for the user and the category of emailType.
+// Without a secret (or for security emails) the configured URL is returned unchanged.
+func (s *NotificationPreferenceService) UnsubscribeLink(userID, emailType string) string {
+	return s.CategoryUnsubscribeLink(userID, NotificationCategory(emailType))
+}
+
+// CategoryUnsubscribeLink is UnsubscribeLink for emails of category
+func (s *NotificationPreferenceService) CategoryUnsubscribeLink(userID, category string) string {
+	if s == nil {
+		return ""
+	}
+	if s.Secret == "" || s.UnsubscribeURL == "" || category == "" {
+		return s.UnsubscribeURL
+	}
+	payload := userID + ":" + category
+	token := base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + s.unsubscribeMAC(payload)
+	sep := "?"
+	if strings.Contains(s.UnsubscribeURL, "?") {
+		sep = "&"
+	}
+	return s.UnsubscribeURL + sep + "token=" + url.QueryEscape(token)
+}
+
+// Unsubscribe verifies a token from UnsubscribeLink and disables that category; it returns the category.
+func (s *NotificationPreferenceService) Unsubscribe(ctx context.Context, token string) (string, error) {
+	if s.Secret == "" {
+		return "", ErrUnsubscribeTokenInvalid
+	}
+	enc, mac, ok := strings.Cut(token, ".")
+	if !ok {
+		return "", ErrUnsubscribeTokenInvalid
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(enc)
+	if err != nil || !hmac.Equal([]byte(mac), []byte(s.unsubscribeMAC(string(raw)))) {
+		return "", ErrUnsubscribeTokenInvalid
+	}
+	userID, category, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return "", ErrUnsubscribeTokenInvalid
+	}
+	if err := s.Set(ctx, userID, category, false); err != nil {
+		return "", err
+	}
+	return category, nil
+}
@@ -0,0 +1,117 @@
+package application
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	repo "github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/keyspace"
+)
+
+var (
+	ErrOIDCStateInvalid = errors.New("oidc state invalid or expired")
+	ErrOIDCNoEmail      = errors.New("oidc id token has no email")
+)
+
+const oidcStateTTL = 10 * time.Minute
+
+func keyOIDCState(state string) string { return keyspace.Key("oidc:state:" + state) }
+
+type oidcPending struct {
+	Nonce      string `json:"nonce"`
+	Verifier   string `json:"verifier"`
+	LinkUserID string `json:"link_user_id,omitempty"`
+}
+
+// OIDCService implements login through an external OpenID Connect provider.
+// Users are resolved through their linked identity (see IdentityService); IdP groups grant local roles.
+type OIDCService struct {
+	Provider    *helpers.OIDCProvider
+	Identities  *IdentityService
+	Roles       repo.RoleRepository
+	Redis       *redis.Client
+	Logger      *logrus.Logger
+	GroupsClaim string
+	GroupRoles  map[string][]string
+}
+
+func NewOIDCService(provider *helpers.OIDCProvider, identities *IdentityService, roles repo.RoleRepository, rdb *redis.Client, logger *logrus.Logger, groupsClaim string, groupRoles map[string][]string) *OIDCService {
+	return &OIDCService{Provider: provider, Identities: identities, Roles: roles, Redis: rdb, Logger: logger, GroupsClaim: groupsClaim, GroupRoles: groupRoles}
+}
+
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Start creates state, nonce and PKCE verifier (kept in Redis) and returns the IdP authorization URL.
+// A non-empty linkUserID makes the callback link the identity to that user instead of logging in.
+func (s *OIDCService) Start(ctx context.Context, linkUserID string) (string, error) {
+	state, err := randomToken(24)
+	if err != nil {
+		return "", err
+	}
+	nonce, err := randomToken(24)
+	if err != nil {
+		return "", err
+	}
+	verifier, challenge, err := helpers.NewPKCE()
+	if err != nil {
+		return "", err
+	}
+	b, _ := json.Marshal(oidcPending{Nonce: nonce, Verifier: verifier, LinkUserID: linkUserID})
+	if err := s.Redis.Set(ctx, keyOIDCState(state), b, oidcStateTTL).Err(); err != nil {
+		return "", err
+	}
+	return s.Provider.AuthCodeURL(ctx, state, nonce, challenge)
+}
+
+// Callback consumes the state (single use) and redeems the code. For a login it returns the local
+// user; for a link flow started by a signed-in user it links the identity and returns linked=true.
+func (s *OIDCService) Callback(ctx context.Context, state, code string) (u *entity.User, linked bool, err error) {
+	if state == "" || code == "" {
+		return nil, false, ErrOIDCStateInvalid
+	}
+	raw, err := s.Redis.GetDel(ctx, keyOIDCState(state)).Result()
+	if err != nil {
+		return nil, false, ErrOIDCStateInvalid
+	}
+	var pending oidcPending
+	if err := json.Unmarshal([]byte(raw), &pending); err != nil {
+		return nil, false, ErrOIDCStateInvalid
+	}
+	claims, err := s.Provider.Exchange(ctx, code, pending.Verifier, pending.Nonce)
+	if err != nil {
+		return nil, false, err
+	}
+	ext := ExternalIdentity{Provider: ProviderOIDC, Subject: claims.Subject, Email: claims.Email, Name: claims.Name, EmailVerified: claims.EmailVerified}
+	if pending.LinkUserID != "" {
+		if err := s.Identities.Link(ctx, pending.LinkUserID, ext); err != nil {
+			return nil, false, err
+		}
+		u, err := s.Identities.Users.GetByID(pending.LinkUserID)
+		return u, true, err
+	}
+	if strings.TrimSpace(claims.Email) == "" {
+		return nil, false, ErrOIDCNoEmail
+	}
+	u, err = s.Identities.Resolve(ctx, ext)
+	if err != nil {
+		return nil, false, err
+	}
+	grantMappedRoles(s.Roles, s.Logger, u.ID, helpers.OIDCGroups(claims.Raw, s.GroupsClaim), s.GroupRoles)
+	return u, false, nil
+}
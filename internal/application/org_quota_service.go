@@ -0,0 +1,162 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	repo "github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/keyspace"
+)
+
+// OrgQuotaDefaults are the limits applied when an organization has no override (0 = unlimited)
+type OrgQuotaDefaults struct {
+	RatePerMinute  int
+	RequestsPerDay int
+	EmailsPerDay   int
+}
+
+// OrgUsage is the current consumption of each organization quota
+type OrgUsage struct {
+	Rate     helpers.QuotaResult
+	Requests helpers.QuotaResult
+	Emails   helpers.QuotaResult
+}
+
+// OrgQuotaService enforces per-organization request rate, daily request and daily email quotas
+// with Redis counters. Redis errors fail open like the rate limit middleware.
+type OrgQuotaService struct {
+	Orgs     repo.OrganizationRepository
+	Redis    *redis.Client
+	Logger   *logrus.Logger
+	Defaults OrgQuotaDefaults
+}
+
+func NewOrgQuotaService(orgs repo.OrganizationRepository, rdb *redis.Client, logger *logrus.Logger, defaults OrgQuotaDefaults) *OrgQuotaService {
+	return &OrgQuotaService{Orgs: orgs, Redis: rdb, Logger: logger, Defaults: defaults}
+}
+
+func keyOrgRate(orgID string) string { return keyspace.Key("quota:org:" + orgID + ":rate") }
+func keyOrgDaily(orgID, kind string, day time.Time) string {
+	return keyspace.Key(fmt.Sprintf("quota:org:%s:%s:%s", orgID, kind, day.Format("20060102")))
+}
+
+// untilMidnight is the remaining part of the current UTC day (the daily quota window)
+func untilMidnight(now time.Time) time.Duration {
+	d := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC).Sub(now)
+	if d < time.Second {
+		d = time.Second
+	}
+	return d
+}
+
+func pick(override *int, def int) int64 {
+	if override != nil {
+		return int64(*override)
+	}
+	return int64(def)
+}
+
+// Limits returns the effective limits of orgID (overrides on top of the defaults).
+func (s *OrgQuotaService) Limits(ctx context.Context, orgID string) OrgQuotaDefaults {
+	l := &entity.OrgLimits{}
+	if s.Orgs != nil {
+		if got, err := s.Orgs.GetLimits(orgID); err == nil && got != nil {
+			l = got
+		} else if err != nil && s.Logger != nil {
+			helpers.FromContext(ctx).WithError(err).WithField("org_id", orgID).Warn("load org limits failed; using defaults")
+		}
+	}
+	return OrgQuotaDefaults{
+		RatePerMinute:  int(pick(l.RatePerMinute, s.Defaults.RatePerMinute)),
+		RequestsPerDay: int(pick(l.RequestsPerDay, s.Defaults.RequestsPerDay)),
+		EmailsPerDay:   int(pick(l.EmailsPerDay, s.Defaults.EmailsPerDay)),
+	}
+}
+
+// SetLimits stores per-organization overrides.
+func (s *OrgQuotaService) SetLimits(ctx context.Context, orgID string, limits entity.OrgLimits) error {
+	if o, err := s.Orgs.GetByID(orgID); err != nil || o == nil {
+		return notFound(err, ErrOrgNotFound)
+	}
+	return s.Orgs.SetLimits(orgID, limits)
+}
+
+// ConsumeRequest counts one API request for orgID. It returns helpers.ErrRateLimited when the
+// per-minute rate is exceeded and helpers.ErrQuotaExceeded when the daily quota is used up;
+// the result describes the daily quota (or the rate window when rate limited).
+func (s *OrgQuotaService) ConsumeRequest(ctx context.Context, orgID string) (helpers.QuotaResult, error) {
+	limits := s.Limits(ctx, orgID)
+	if s.Redis == nil {
+		return helpers.QuotaResult{}, nil
+	}
+	if limits.RatePerMinute > 0 {
+		rate, err := helpers.ConsumeQuota(ctx, s.Redis, keyOrgRate(orgID), 1, int64(limits.RatePerMinute), time.Minute)
+		if err == nil && rate.Exceeded() {
+			return rate, helpers.ErrRateLimited
+		}
+	}
+	if limits.RequestsPerDay <= 0 {
+		return helpers.QuotaResult{}, nil
+	}
+	now := time.Now().UTC()
+	res, err := helpers.ConsumeQuota(ctx, s.Redis, keyOrgDaily(orgID, "requests", now), 1, int64(limits.RequestsPerDay), untilMidnight(now))
+	if err != nil {
+		return helpers.QuotaResult{}, nil
+	}
+	if res.Exceeded() {
+		return res, helpers.ErrQuotaExceeded
+	}
+	return res, nil
+}
+
+// ConsumeEmails counts n outgoing emails for orgID; helpers.ErrQuotaExceeded when over the daily limit.
+func (s *OrgQuotaService) ConsumeEmails(ctx context.Context, orgID string, n int) (helpers.QuotaResult, error) {
+	limits := s.Limits(ctx, orgID)
+	if s.Redis == nil || limits.EmailsPerDay <= 0 {
+		return helpers.QuotaResult{}, nil
+	}
+	now := time.Now().UTC()
+	key := keyOrgDaily(orgID, "emails", now)
+	res, err := helpers.ConsumeQuota(ctx, s.Redis, key, int64(n), int64(limits.EmailsPerDay), untilMidnight(now))
+	if err != nil {
+		return helpers.QuotaResult{}, nil
+	}
+	if res.Exceeded() {
+		// Refused sends do not count against the quota
+		_ = s.Redis.DecrBy(ctx, key, int64(n)).Err()
+		res.Used -= int64(n)
+		return res, helpers.ErrQuotaExceeded
+	}
+	return res, nil
+}
+
+// Usage reports the current counters of every quota without consuming.
+func (s *OrgQuotaService) Usage(ctx context.Context, orgID string) (OrgUsage, error) {
+	limits := s.Limits(ctx, orgID)
+	u := OrgUsage{
+		Rate:     helpers.QuotaResult{Limit: int64(limits.RatePerMinute)},
+		Requests: helpers.QuotaResult{Limit: int64(limits.RequestsPerDay)},
+		Emails:   helpers.QuotaResult{Limit: int64(limits.EmailsPerDay)},
+	}
+	if s.Redis == nil {
+		return u, nil
+	}
+	now := time.Now().UTC()
+	var err error
+	if u.Rate, err = helpers.PeekQuota(ctx, s.Redis, keyOrgRate(orgID), u.Rate.Limit); err != nil {
+		return u, err
+	}
+	if u.Requests, err = helpers.PeekQuota(ctx, s.Redis, keyOrgDaily(orgID, "requests", now), u.Requests.Limit); err != nil {
+		return u, err
+	}
+	if u.Emails, err = helpers.PeekQuota(ctx, s.Redis, keyOrgDaily(orgID, "emails", now), u.Emails.Limit); err != nil {
+		return u, err
+	}
+	return u, nil
+}
@@ -0,0 +1,225 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	repo "github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
+)
+
+var (
+	ErrOrgNotFound       = errors.New("organization not found")
+	ErrOrgSlugTaken      = errors.New("organization slug already taken")
+	ErrOrgInvalid        = errors.New("invalid organization name or slug")
+	ErrOrgInvalidRole    = errors.New("invalid organization role")
+	ErrOrgForbidden      = errors.New("insufficient organization role")
+	ErrOrgMemberNotFound = errors.New("organization member not found")
+	ErrOrgAlreadyMember  = errors.New("user is already a member")
+	ErrOrgLastOwner      = errors.New("organization must keep at least one owner")
+)
+
+// Organization member roles, lowest to highest
+const (
+	OrgRoleMember = "member"
+	OrgRoleAdmin  = "admin"
+	OrgRoleOwner  = "owner"
+)
+
+var (
+	slugRe      = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,62}$`)
+	slugSepRe   = regexp.MustCompile(`[^a-z0-9]+`)
+	orgRoleRank = map[string]int{OrgRoleMember: 1, OrgRoleAdmin: 2, OrgRoleOwner: 3}
+)
+
+// OrgRoleAtLeast reports whether role is minRole or higher.
+func OrgRoleAtLeast(role, minRole string) bool {
+	r, ok := orgRoleRank[role]
+	return ok && r >= orgRoleRank[minRole]
+}
+
+func normalizeOrgRole(role string) (string, error) {
+	r := strings.ToLower(strings.TrimSpace(role))
+	if _, ok := orgRoleRank[r]; !ok {
+		return "", ErrOrgInvalidRole
+	}
+	return r, nil
+}
+
+func slugify(s string) string {
+	return strings.Trim(slugSepRe.ReplaceAllString(strings.ToLower(strings.TrimSpace(s)), "-"), "-")
+}
+
+// OrganizationService manages organizations (teams) and membership. Resources that belong to an
+// organization should carry its ID and be served behind the org route guard (route.Route.OrgRole).
+type OrganizationService struct {
+	Repo        repo.OrganizationRepository
+	Users       repo.UserRepository
+	Invitations *InvitationService
+	Quotas      *OrgQuotaService // optional; invitation emails count against the org email quota
+	Logger      *logrus.Logger
+}
+
+func NewOrganizationService(orgs repo.OrganizationRepository, users repo.UserRepository, invitations *InvitationService, quotas *OrgQuotaService, logger *logrus.Logger) *OrganizationService {
+	return &OrganizationService{Repo: orgs, Users: users, Invitations: invitations, Quotas: quotas, Logger: logger}
+}
+
+// Create makes a new organization owned by userID; slug defaults to one derived from name.
+func (s *OrganizationService) Create(ctx context.Context, userID, name, slug string) (*entity.Organization, error) {
+	name = strings.TrimSpace(name)
+	if slug == "" {
+		slug = name
+	}
+	slug = slugify(slug)
+	if name == "" || len(name) > 100 || !slugRe.MatchString(slug) {
+		return nil, ErrOrgInvalid
+	}
+	if o, err := s.Repo.GetBySlug(slug); err == nil && o != nil {
+		return nil, ErrOrgSlugTaken
+	}
+	org := &entity.Organization{Name: name, Slug: slug}
+	if err := s.Repo.CreateWithOwner(org, userID); err != nil {
+		return nil, err
+	}
+	return org, nil
+}
+
+func (s *OrganizationService) ListForUser(ctx context.Context, userID string) ([]entity.OrgMembership, error) {
+	return s.Repo.ListByUser(userID)
+}
+
+func (s *OrganizationService) Get(ctx context.Context, orgID string) (*entity.Organization, error) {
+	o, err := s.Repo.GetByID(orgID)
+	if err != nil || o == nil {
+		return nil, notFound(err, ErrOrgNotFound)
+	}
+	return o, nil
+}
+
+// MemberRole returns the user's role in the organization, or "" when not a member.
+func (s *OrganizationService) MemberRole(ctx context.Context, orgID, userID string) (string, error) {
+	m, err := s.Repo.GetMember(orgID, userID)
+	if err != nil || m == nil {
+		return "", notFound(err, nil)
+	}
+	return m.Role, nil
+}
+
+func (s *OrganizationService) ListMembers(ctx context.Context, orgID string) ([]entity.OrgMember, error) {
+	return s.Repo.ListMembers(orgID)
+}
+
+// actorRole returns the acting user's role, requiring at least minRole.
+func (s *OrganizationService) actorRole(orgID, actorID, minRole string) (string, error) {
+	m, err := s.Repo.GetMember(orgID, actorID)
+	if err != nil || m == nil || !OrgRoleAtLeast(m.Role, minRole) {
+		return "", ErrOrgForbidden
+	}
+	return m.Role, nil
+}
+
+// InviteMember adds an existing user to the organization, or invites the email to sign up.
+// Exactly one of the returned member and invitation is non-nil; token is set with the invitation.
+func (s *OrganizationService) InviteMember(ctx context.Context, actorID, orgID, email, role string) (*entity.OrgMember, *entity.Invitation, string, error) {
+	r, err := normalizeOrgRole(role)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	actor, err := s.actorRole(orgID, actorID, OrgRoleAdmin)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if r == OrgRoleOwner && actor != OrgRoleOwner {
+		return nil, nil, "", ErrOrgForbidden
+	}
+	email = strings.ToLower(strings.TrimSpace(email))
+	u, err := s.Users.GetByEmail(email)
+	if err != nil || u == nil {
+		if err := notFound(err, nil); err != nil {
+			return nil, nil, "", err
+		}
+		if s.Quotas != nil {
+			if _, err := s.Quotas.ConsumeEmails(ctx, orgID, 1); err != nil {
+				return nil, nil, "", err
+			}
+		}
+		inv, token, err := s.Invitations.InviteToOrg(ctx, actorID, email, orgID, r)
+		return nil, inv, token, err
+	}
+	if m, err := s.Repo.GetMember(orgID, u.ID); err == nil && m != nil {
+		return nil, nil, "", ErrOrgAlreadyMember
+	}
+	if err := s.Repo.AddMember(orgID, u.ID, r); err != nil {
+		return nil, nil, "", err
+	}
+	return &entity.OrgMember{OrgID: orgID, UserID: u.ID, Email: u.Email, Name: u.Name, Role: r}, nil, "", nil
+}
+
+// SetMemberRole changes a member's role; only owners grant or take away ownership.
+func (s *OrganizationService) SetMemberRole(ctx context.Context, actorID, orgID, userID, role string) error {
+	r, err := normalizeOrgRole(role)
+	if err != nil {
+		return err
+	}
+	actor, err := s.actorRole(orgID, actorID, OrgRoleAdmin)
+	if err != nil {
+		return err
+	}
+	target, err := s.Repo.GetMember(orgID, userID)
+	if err != nil || target == nil {
+		return notFound(err, ErrOrgMemberNotFound)
+	}
+	if (r == OrgRoleOwner || target.Role == OrgRoleOwner) && actor != OrgRoleOwner {
+		return ErrOrgForbidden
+	}
+	if target.Role == OrgRoleOwner && r != OrgRoleOwner {
+		if err := s.keepOwner(orgID); err != nil {
+			return err
+		}
+	}
+	if err := s.Repo.UpdateMemberRole(orgID, userID, r); err != nil {
+		return ErrOrgMemberNotFound
+	}
+	return nil
+}
+
+// RemoveMember removes userID; members may remove themselves, admins others, and only owners remove owners.
+func (s *OrganizationService) RemoveMember(ctx context.Context, actorID, orgID, userID string) error {
+	target, err := s.Repo.GetMember(orgID, userID)
+	if err != nil || target == nil {
+		return notFound(err, ErrOrgMemberNotFound)
+	}
+	if actorID != userID {
+		minRole := OrgRoleAdmin
+		if target.Role == OrgRoleOwner {
+			minRole = OrgRoleOwner
+		}
+		if _, err := s.actorRole(orgID, actorID, minRole); err != nil {
+			return err
+		}
+	}
+	if target.Role == OrgRoleOwner {
+		if err := s.keepOwner(orgID); err != nil {
+			return err
+		}
+	}
+	if err := s.Repo.RemoveMember(orgID, userID); err != nil {
+		return ErrOrgMemberNotFound
+	}
+	return nil
+}
+
+func (s *OrganizationService) keepOwner(orgID string) error {
+	n, err := s.Repo.CountOwners(orgID)
+	if err != nil {
+		return err
+	}
+	if n <= 1 {
+		return ErrOrgLastOwner
+	}
+	return nil
+}
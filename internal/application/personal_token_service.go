@@ -0,0 +1,150 @@
+package application
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	repo "github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+)
+
+var (
+	ErrPersonalTokenNotFound = errors.New("personal access token not found")
+	ErrPersonalTokenLimit    = errors.New("personal access token limit reached")
+	ErrPersonalTokenTTL      = errors.New("personal access token lifetime exceeds the maximum")
+)
+
+// personalTokenShown is how much of a token (after its prefix) is kept in clear to tell tokens apart
+const personalTokenShown = 6
+
+// Personal access token states derived from the revoked/expiry columns
+const (
+	PersonalTokenActive  = "active"
+	PersonalTokenRevoked = "revoked"
+	PersonalTokenExpired = "expired"
+)
+
+// PersonalTokenStatus reports the state of t at now.
+func PersonalTokenStatus(t entity.PersonalToken, now time.Time) string {
+	switch {
+	case t.RevokedAt != nil:
+		return PersonalTokenRevoked
+	case !now.Before(t.ExpiresAt):
+		return PersonalTokenExpired
+	default:
+		return PersonalTokenActive
+	}
+}
+
+// PersonalTokenService manages the personal access tokens users create for scripts and resolves
+// them for the auth middleware. Tokens are "pat_" plus 32 random bytes; only their SHA-256 is
+// stored, so a token is shown once, when created. Every token expires (after DefaultTTL unless the
+// user asks for less, MaxTTL at most) and a user has at most MaxPerUser active ones.
+type PersonalTokenService struct {
+	Repo       repo.PersonalTokenRepository
+	Users      repo.UserRepository
+	Logger     *logrus.Logger
+	DefaultTTL time.Duration
+	MaxTTL     time.Duration
+	MaxPerUser int
+}
+
+func NewPersonalTokenService(tokens repo.PersonalTokenRepository, users repo.UserRepository, logger *logrus.Logger, defaultTTL, maxTTL time.Duration, maxPerUser int) *PersonalTokenService {
+	if maxTTL <= 0 {
+		maxTTL = 365 * 24 * time.Hour
+	}
+	if defaultTTL <= 0 || defaultTTL > maxTTL {
+		defaultTTL = min(90*24*time.Hour, maxTTL)
+	}
+	return &PersonalTokenService{Repo: tokens, Users: users, Logger: logger, DefaultTTL: defaultTTL, MaxTTL: maxTTL, MaxPerUser: maxPerUser}
+}
+
+func hashPersonalToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Create issues a token named name for userID with scopes (already checked against the caller's)
+// that expires after ttl (DefaultTTL when 0), and returns it with the raw token.
+func (s *PersonalTokenService) Create(ctx context.Context, userID, name string, scopes []string, ttl time.Duration) (*entity.PersonalToken, string, error) {
+	if ttl <= 0 {
+		ttl = s.DefaultTTL
+	}
+	if ttl > s.MaxTTL {
+		return nil, "", ErrPersonalTokenTTL
+	}
+	if s.MaxPerUser > 0 {
+		n, err := s.Repo.CountActive(ctx, userID)
+		if err != nil {
+			return nil, "", err
+		}
+		if n >= s.MaxPerUser {
+			return nil, "", ErrPersonalTokenLimit
+		}
+	}
+	secret, err := randomToken(32)
+	if err != nil {
+		return nil, "", err
+	}
+	token := entity.PersonalTokenPrefix + secret
+	t := &entity.PersonalToken{
+		UserID:    userID,
+		Name:      strings.TrimSpace(name),
+		Prefix:    token[:len(entity.PersonalTokenPrefix)+personalTokenShown],
+		Scopes:    scopes,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	if err := s.Repo.Create(ctx, t, hashPersonalToken(token)); err != nil {
+		return nil, "", err
+	}
+	return t, token, nil
+}
+
+// List returns the user's tokens, newest first, including revoked and expired ones
+func (s *PersonalTokenService) List(ctx context.Context, userID string) ([]entity.PersonalToken, error) {
+	return s.Repo.ListByUser(ctx, userID)
+}
+
+// Revoke revokes one of the user's tokens; ErrPersonalTokenNotFound when it has no such active token
+func (s *PersonalTokenService) Revoke(ctx context.Context, userID, id string) error {
+	err := s.Repo.Revoke(ctx, userID, id)
+	if errors.Is(err, repo.ErrNotFound) {
+		return ErrPersonalTokenNotFound
+	}
+	return err
+}
+
+// Authenticate resolves a bearer token for the auth middleware and records its use from ip.
+// A failure to record the use is logged, not returned.
+func (s *PersonalTokenService) Authenticate(ctx context.Context, token, ip string) (*entity.PersonalToken, *entity.User, error) {
+	t, err := s.Repo.GetByHash(ctx, hashPersonalToken(token))
+	if err != nil {
+		return nil, nil, err
+	}
+	if !t.Active(time.Now()) {
+		return nil, nil, repo.ErrNotFound
+	}
+	u, err := s.Users.GetByID(t.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if u == nil {
+		return nil, nil, repo.ErrNotFound
+	}
+	if err := u.AccountError(); err != nil {
+		return nil, nil, err
+	}
+	if err := s.Repo.MarkUsed(ctx, t.ID, ip); err != nil {
+		helpers.FromContext(ctx).WithError(err).WithField("token_id", t.ID).Warn("personal access token use not recorded")
+	}
+	return t, u, nil
+}
+
+var _ repo.TokenAuthenticator = (*PersonalTokenService)(nil)
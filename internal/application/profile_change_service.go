@@ -0,0 +1,204 @@
+package application
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	repo "github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/keyspace"
+)
+
+var (
+	ErrProfileChangeInvalid     = errors.New("profile change token invalid or expired")
+	ErrProfileChangeUnsupported = errors.New("field cannot be changed this way")
+	ErrProfileChangeNoop        = errors.New("new value equals the current one")
+	ErrProfileChangeEmailTaken  = errors.New("an account with this email already exists")
+	ErrProfileChangeNotFound    = errors.New("no pending change for this field")
+)
+
+// Sensitive profile fields whose changes wait for confirmation. The user model has no phone
+// number yet; a phone field would be added here with its own delivery channel.
+const ProfileFieldEmail = "email"
+
+var SensitiveProfileFields = []string{ProfileFieldEmail}
+
+func keyProfileChangeToken(hash string) string { return keyspace.Key("profile:change:token:" + hash) }
+func keyProfileChanges(uid string) string      { return keyspace.Key("profile:change:pending:" + uid) }
+
+// PendingProfileChange is a requested change of a sensitive field, applied once the emailed
+// token is confirmed
+type PendingProfileChange struct {
+	Field       string    `json:"field"`
+	Value       string    `json:"value"`
+	RequestedAt time.Time `json:"requested_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	TokenHash   string    `json:"token_hash,omitempty"`
+}
+
+// ProfileChangeService keeps changes to sensitive fields pending until confirmed through a token
+// sent to the new address, so a hijacked session cannot silently take over the account's email.
+// Pending changes live in Redis (one per field, a new request replaces the old one); tokens are
+// stored only as SHA-256 hashes.
+type ProfileChangeService struct {
+	Profiles *Service
+	Redis    *redis.Client
+	Logger   *logrus.Logger
+	TTL      time.Duration
+}
+
+func NewProfileChangeService(profiles *Service, rdb *redis.Client, logger *logrus.Logger, ttl time.Duration) *ProfileChangeService {
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+	return &ProfileChangeService{Profiles: profiles, Redis: rdb, Logger: logger, TTL: ttl}
+}
+
+func profileChangeHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Request records a pending change of field to value and returns it with the confirmation token
+func (s *ProfileChangeService) Request(ctx context.Context, userID, field, value string) (PendingProfileChange, string, error) {
+	if !slices.Contains(SensitiveProfileFields, field) {
+		return PendingProfileChange{}, "", ErrProfileChangeUnsupported
+	}
+	u, err := s.Profiles.Repo.GetByID(userID)
+	if err != nil || u == nil {
+		return PendingProfileChange{}, "", notFound(err, ErrUserNotFound)
+	}
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == u.Email {
+		return PendingProfileChange{}, "", ErrProfileChangeNoop
+	}
+	if err := s.emailAvailable(u.ID, value); err != nil {
+		return PendingProfileChange{}, "", err
+	}
+	tok, err := randomToken(32)
+	if err != nil {
+		return PendingProfileChange{}, "", err
+	}
+	now := time.Now().UTC()
+	pc := PendingProfileChange{Field: field, Value: value, RequestedAt: now, ExpiresAt: now.Add(s.TTL), TokenHash: profileChangeHash(tok)}
+	raw, err := json.Marshal(pc)
+	if err != nil {
+		return PendingProfileChange{}, "", err
+	}
+	prev, _ := s.pending(ctx, userID)
+	pipe := s.Redis.TxPipeline()
+	for _, p := range prev {
+		if p.Field == field {
+			pipe.Del(ctx, keyProfileChangeToken(p.TokenHash)) // the older link stops working
+		}
+	}
+	pipe.Set(ctx, keyProfileChangeToken(pc.TokenHash), userID+":"+field, s.TTL)
+	pipe.HSet(ctx, keyProfileChanges(userID), field, raw)
+	pipe.Expire(ctx, keyProfileChanges(userID), s.TTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return PendingProfileChange{}, "", err
+	}
+	pc.TokenHash = ""
+	return pc, tok, nil
+}
+
+// Pending lists the user's unexpired pending changes, oldest first
+func (s *ProfileChangeService) Pending(ctx context.Context, userID string) ([]PendingProfileChange, error) {
+	out, err := s.pending(ctx, userID)
+	for i := range out {
+		out[i].TokenHash = ""
+	}
+	return out, err
+}
+
+func (s *ProfileChangeService) pending(ctx context.Context, userID string) ([]PendingProfileChange, error) {
+	raw, err := s.Redis.HGetAll(ctx, keyProfileChanges(userID)).Result()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	out := make([]PendingProfileChange, 0, len(raw))
+	for _, v := range raw {
+		var pc PendingProfileChange
+		if json.Unmarshal([]byte(v), &pc) == nil && pc.ExpiresAt.After(now) {
+			out = append(out, pc)
+		}
+	}
+	slices.SortFunc(out, func(a, b PendingProfileChange) int { return a.RequestedAt.Compare(b.RequestedAt) })
+	return out, nil
+}
+
+// Cancel drops the pending change of field; its token stops working
+func (s *ProfileChangeService) Cancel(ctx context.Context, userID, field string) error {
+	pcs, err := s.pending(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, pc := range pcs {
+		if pc.Field == field {
+			pipe := s.Redis.TxPipeline()
+			pipe.Del(ctx, keyProfileChangeToken(pc.TokenHash))
+			pipe.HDel(ctx, keyProfileChanges(userID), field)
+			_, err := pipe.Exec(ctx)
+			return err
+		}
+	}
+	return ErrProfileChangeNotFound
+}
+
+// Confirm consumes token and applies its change. It returns the updated user and the value the
+// field had before, so the caller can notify the old address.
+func (s *ProfileChangeService) Confirm(ctx context.Context, token string) (*entity.User, string, PendingProfileChange, error) {
+	if strings.TrimSpace(token) == "" {
+		return nil, "", PendingProfileChange{}, ErrProfileChangeInvalid
+	}
+	hash := profileChangeHash(token)
+	ref, err := s.Redis.GetDel(ctx, keyProfileChangeToken(hash)).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, "", PendingProfileChange{}, ErrProfileChangeInvalid
+	}
+	if err != nil {
+		return nil, "", PendingProfileChange{}, err
+	}
+	userID, field, _ := strings.Cut(ref, ":")
+	raw, err := s.Redis.HGet(ctx, keyProfileChanges(userID), field).Result()
+	var pc PendingProfileChange
+	if err != nil || json.Unmarshal([]byte(raw), &pc) != nil || pc.TokenHash != hash || !pc.ExpiresAt.After(time.Now()) {
+		return nil, "", PendingProfileChange{}, ErrProfileChangeInvalid
+	}
+	_ = s.Redis.HDel(ctx, keyProfileChanges(userID), field).Err()
+	pc.TokenHash = ""
+
+	u, err := s.Profiles.Repo.GetByID(userID)
+	if err != nil || u == nil {
+		return nil, "", pc, notFound(err, ErrUserNotFound)
+	}
+	// The address may have been claimed by someone else while the change was pending
+	if err := s.emailAvailable(u.ID, pc.Value); err != nil {
+		return nil, "", pc, err
+	}
+	old := u.Email
+	u, err = s.Profiles.ChangeEmail(ctx, u, pc.Value)
+	return u, old, pc, err
+}
+
+// emailAvailable rejects an address another account already uses (the user's own aliases pass)
+func (s *ProfileChangeService) emailAvailable(userID, email string) error {
+	other, err := s.Profiles.Repo.GetByEmail(email)
+	if errors.Is(err, repo.ErrNotFound) || (err == nil && (other == nil || other.ID == userID)) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return ErrProfileChangeEmailTaken
+}
@@ -0,0 +1,89 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/keyspace"
+)
+
+const (
+	refreshLockTTL      = 10 * time.Second // longest a refresh may hold its session's lock
+	refreshPollInterval = 50 * time.Millisecond
+)
+
+func keyRefreshLock(sid string) string   { return keyspace.Regional("session:refresh:lock:" + sid) }
+func keyRefreshResult(sid string) string { return keyspace.Regional("session:refresh:result:" + sid) }
+
+// refreshResult is what a refresh leaves for concurrent refreshes presenting the same token
+type refreshResult struct {
+	Pair      TokenPair `json:"pair"`
+	SessionID string    `json:"sid"`
+}
+
+// refreshShared lets one refresh per session rotate it. Tabs sharing a refresh token tend to
+// refresh together; without this the first rotates the session and the rest get 401 because their
+// token's session id is gone. The winner holds a Redis lock while rotating and then leaves the new
+// pair for RefreshGrace; the others wait for it (up to refreshLockTTL) and receive the same pair.
+// The pair is only handed out while its session exists and matches the caller's device binding.
+// When Redis fails the refresh goes ahead uncoordinated.
+func (s *Service) refreshShared(ctx context.Context, claims *helpers.Claims) (TokenPair, string, error) {
+	deadline := time.Now().Add(refreshLockTTL)
+	for {
+		if pair, ok := s.rotatedPair(ctx, claims); ok {
+			return pair, claims.UserID, nil
+		}
+		won, err := s.Redis.SetNX(ctx, keyRefreshLock(claims.SessionID), "1", refreshLockTTL).Result()
+		if err != nil {
+			helpers.FromContext(ctx).WithError(err).Warn("refresh lock unavailable; refreshing without it")
+			pair, _, err := s.rotate(ctx, claims)
+			return pair, claims.UserID, err
+		}
+		if won {
+			break
+		}
+		if time.Now().After(deadline) {
+			return TokenPair{}, "", ErrInvalidCredentials
+		}
+		select {
+		case <-ctx.Done():
+			return TokenPair{}, "", ctx.Err()
+		case <-time.After(refreshPollInterval):
+		}
+	}
+	defer s.Redis.Del(context.WithoutCancel(ctx), keyRefreshLock(claims.SessionID))
+	// A refresh that finished between the check and the lock left its pair behind
+	if pair, ok := s.rotatedPair(ctx, claims); ok {
+		return pair, claims.UserID, nil
+	}
+	pair, sid, err := s.rotate(ctx, claims)
+	if err != nil {
+		return TokenPair{}, "", err
+	}
+	if b, err := json.Marshal(refreshResult{Pair: pair, SessionID: sid}); err == nil {
+		if err := s.Redis.Set(ctx, keyRefreshResult(claims.SessionID), b, s.RefreshGrace).Err(); err != nil {
+			helpers.FromContext(ctx).WithError(err).Warn("rotated pair not shared with concurrent refreshes")
+		}
+	}
+	return pair, claims.UserID, nil
+}
+
+// rotatedPair returns the pair the session of claims was just rotated to, if that session is
+// still live and bound to the caller's device
+func (s *Service) rotatedPair(ctx context.Context, claims *helpers.Claims) (TokenPair, bool) {
+	b, err := s.Redis.Get(ctx, keyRefreshResult(claims.SessionID)).Bytes()
+	if err != nil {
+		return TokenPair{}, false
+	}
+	var r refreshResult
+	if json.Unmarshal(b, &r) != nil {
+		return TokenPair{}, false
+	}
+	sess, err := s.Sessions.Get(ctx, claims.UserID, r.SessionID)
+	if err != nil || !s.bindingMatches(ctx, sess) {
+		return TokenPair{}, false
+	}
+	return r.Pair, true
+}
@@ -0,0 +1,137 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+)
+
+// MaxRoleMembers caps the members of one bulk change or sync; emails are resolved one at a time
+const MaxRoleMembers = 1000
+
+var ErrTooManyMembers = errors.New("too many members")
+
+// RoleMembershipChange reports what a bulk change or a sync did to a role's members
+type RoleMembershipChange struct {
+	Role    string
+	Added   []string // user ids granted the role
+	Removed []string // user ids that lost it
+	Unknown []string // members, as given, that match no user
+}
+
+// resolveMembers maps user ids and emails to distinct user ids; members matching no user are
+// returned as unknown
+func (s *RoleService) resolveMembers(ctx context.Context, members []string) ([]string, []string, error) {
+	if len(members) > MaxRoleMembers {
+		return nil, nil, ErrTooManyMembers
+	}
+	ids, unknown := []string{}, []string{}
+	seen := map[string]bool{}
+	var candidates []string
+	for _, m := range members {
+		m = strings.TrimSpace(m)
+		if m == "" {
+			continue
+		}
+		if id, err := uuid.Parse(m); err == nil {
+			candidates = append(candidates, id.String())
+			continue
+		}
+		u, err := s.Users.GetByEmail(m)
+		if err != nil || u == nil {
+			if nerr := notFound(err, ErrUserNotFound); !errors.Is(nerr, ErrUserNotFound) {
+				return nil, nil, nerr
+			}
+			unknown = append(unknown, m)
+			continue
+		}
+		candidates = append(candidates, u.ID)
+	}
+	existing, err := s.Users.ExistingIDs(ctx, candidates)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, id := range candidates {
+		switch {
+		case seen[id]:
+		case existing[id]:
+			ids = append(ids, id)
+		default:
+			unknown = append(unknown, id)
+		}
+		seen[id] = true
+	}
+	return ids, unknown, nil
+}
+
+// BulkAssignRole grants the role to every member (user id or email) or, when any member matches
+// no user, to none: the change then lists them as Unknown and the error is ErrUserNotFound
+func (s *RoleService) BulkAssignRole(ctx context.Context, roleName string, members []string) (RoleMembershipChange, error) {
+	return s.bulk(ctx, roleName, members, "assign", s.Repo.AssignRoleBulk)
+}
+
+// BulkRevokeRole takes the role from every member, all or nothing like BulkAssignRole
+func (s *RoleService) BulkRevokeRole(ctx context.Context, roleName string, members []string) (RoleMembershipChange, error) {
+	return s.bulk(ctx, roleName, members, "revoke", s.Repo.RevokeRoleBulk)
+}
+
+func (s *RoleService) bulk(ctx context.Context, roleName string, members []string, op string, apply func(context.Context, string, []string) ([]string, error)) (RoleMembershipChange, error) {
+	r, err := s.roleByName(roleName)
+	if err != nil {
+		return RoleMembershipChange{}, err
+	}
+	change := RoleMembershipChange{Role: r.Name, Added: []string{}, Removed: []string{}}
+	ids, unknown, err := s.resolveMembers(ctx, members)
+	if err != nil {
+		return change, err
+	}
+	if change.Unknown = unknown; len(unknown) > 0 {
+		return change, ErrUserNotFound
+	}
+	changed, err := apply(ctx, r.ID, ids)
+	if err != nil {
+		return change, notFound(err, ErrRoleNotFound)
+	}
+	if op == "assign" {
+		change.Added = changed
+	} else {
+		change.Removed = changed
+	}
+	s.logMembership(ctx, op, change)
+	return change, nil
+}
+
+// SyncRole reconciles the role's members with an external group list (user ids or emails, e.g.
+// from an IdP): members gain the role, everyone else holding it loses it, in one transaction.
+// Members that match no user are skipped and reported as Unknown; an empty list empties the role.
+func (s *RoleService) SyncRole(ctx context.Context, roleName string, members []string) (RoleMembershipChange, error) {
+	r, err := s.roleByName(roleName)
+	if err != nil {
+		return RoleMembershipChange{}, err
+	}
+	ids, unknown, err := s.resolveMembers(ctx, members)
+	if err != nil {
+		return RoleMembershipChange{}, err
+	}
+	added, removed, err := s.Repo.SyncRoleMembers(ctx, r.ID, ids)
+	if err != nil {
+		return RoleMembershipChange{}, notFound(err, ErrRoleNotFound)
+	}
+	change := RoleMembershipChange{Role: r.Name, Added: added, Removed: removed, Unknown: unknown}
+	s.logMembership(ctx, "sync", change)
+	return change, nil
+}
+
+func (s *RoleService) logMembership(ctx context.Context, op string, c RoleMembershipChange) {
+	if s.Logger == nil {
+		return
+	}
+	helpers.FromContext(ctx).WithFields(logrus.Fields{
+		"role": c.Role, "op": op, "added": len(c.Added), "removed": len(c.Removed), "unknown": len(c.Unknown),
+	}).Info("role membership changed")
+}
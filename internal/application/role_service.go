@@ -0,0 +1,197 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	repo "github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
+)
+
+var (
+	ErrRoleNotFound       = errors.New("role not found")
+	ErrPermissionNotFound = errors.New("permission not found")
+	ErrInvalidName        = errors.New("invalid name")
+)
+
+// names are lowercase identifiers; permissions may use "resource:action" form
+var nameRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_.:-]{0,63}$`)
+
+type RoleService struct {
+	Repo   repo.RoleRepository
+	Users  repo.UserRepository
+	Logger *logrus.Logger
+}
+
+func NewRoleService(roles repo.RoleRepository, users repo.UserRepository, logger *logrus.Logger) *RoleService {
+	return &RoleService{Repo: roles, Users: users, Logger: logger}
+}
+
+// RoleWithPermissions is a role together with the permissions attached to it.
+type RoleWithPermissions struct {
+	entity.Role
+	Permissions []entity.Permission
+}
+
+// EffectivePermissions lists a user's roles and the union of their permissions.
+type EffectivePermissions struct {
+	UserID      string
+	Roles       []entity.Role
+	Permissions []entity.Permission
+}
+
+func normalizeName(name string) (string, error) {
+	n := strings.ToLower(strings.TrimSpace(name))
+	if !nameRe.MatchString(n) {
+		return "", ErrInvalidName
+	}
+	return n, nil
+}
+
+func (s *RoleService) CreateRole(ctx context.Context, name string) (*entity.Role, error) {
+	n, err := normalizeName(name)
+	if err != nil {
+		return nil, err
+	}
+	return s.Repo.CreateRole(n)
+}
+
+func (s *RoleService) ListRoles(ctx context.Context) ([]RoleWithPermissions, error) {
+	roles, err := s.Repo.ListRoles()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]RoleWithPermissions, 0, len(roles))
+	for _, r := range roles {
+		perms, err := s.Repo.GetRolePermissions(r.ID)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, RoleWithPermissions{Role: r, Permissions: perms})
+	}
+	return out, nil
+}
+
+func (s *RoleService) ListPermissions(ctx context.Context) ([]entity.Permission, error) {
+	return s.Repo.ListPermissions()
+}
+
+func (s *RoleService) roleByName(name string) (*entity.Role, error) {
+	n, err := normalizeName(name)
+	if err != nil {
+		return nil, err
+	}
+	r, err := s.Repo.GetRoleByName(n)
+	if err != nil || r == nil {
+		return nil, notFound(err, ErrRoleNotFound)
+	}
+	return r, nil
+}
+
+// AttachPermission attaches a permission to a role, creating the permission if needed.
+func (s *RoleService) AttachPermission(ctx context.Context, roleName, permission string) (*entity.Permission, error) {
+	r, err := s.roleByName(roleName)
+	if err != nil {
+		return nil, err
+	}
+	pn, err := normalizeName(permission)
+	if err != nil {
+		return nil, err
+	}
+	p, err := s.Repo.CreatePermission(pn)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Repo.AttachPermission(r.ID, p.ID); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (s *RoleService) DetachPermission(ctx context.Context, roleName, permission string) error {
+	r, err := s.roleByName(roleName)
+	if err != nil {
+		return err
+	}
+	pn, err := normalizeName(permission)
+	if err != nil {
+		return err
+	}
+	p, err := s.Repo.GetPermissionByName(pn)
+	if err != nil || p == nil {
+		return notFound(err, ErrPermissionNotFound)
+	}
+	if err := s.Repo.DetachPermission(r.ID, p.ID); err != nil {
+		return ErrPermissionNotFound
+	}
+	return nil
+}
+
+func (s *RoleService) AssignRole(ctx context.Context, userID, roleName string) error {
+	if u, err := s.Users.GetByID(userID); err != nil || u == nil {
+		return notFound(err, ErrUserNotFound)
+	}
+	r, err := s.roleByName(roleName)
+	if err != nil {
+		return err
+	}
+	return s.Repo.AssignRole(userID, r.ID)
+}
+
+func (s *RoleService) RevokeRole(ctx context.Context, userID, roleName string) error {
+	r, err := s.roleByName(roleName)
+	if err != nil {
+		return err
+	}
+	if err := s.Repo.RevokeRole(userID, r.ID); err != nil {
+		return ErrRoleNotFound
+	}
+	return nil
+}
+
+func (s *RoleService) UserPermissions(ctx context.Context, userID string) (*EffectivePermissions, error) {
+	if u, err := s.Users.GetByID(userID); err != nil || u == nil {
+		return nil, notFound(err, ErrUserNotFound)
+	}
+	roles, err := s.Repo.GetUserRoles(userID)
+	if err != nil {
+		return nil, err
+	}
+	perms, err := s.Repo.GetUserPermissions(userID)
+	if err != nil {
+		return nil, err
+	}
+	return &EffectivePermissions{UserID: userID, Roles: roles, Permissions: perms}, nil
+}
+
+// HasRole reports whether the user holds the named role (case-insensitive).
+func (s *RoleService) HasRole(ctx context.Context, userID, role string) (bool, error) {
+	roles, err := s.Repo.GetUserRoles(userID)
+	if err != nil {
+		return false, err
+	}
+	for _, r := range roles {
+		if strings.EqualFold(r.Name, role) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// HasPermission reports whether any of the user's roles grants the permission.
+func (s *RoleService) HasPermission(ctx context.Context, userID, permission string) (bool, error) {
+	perms, err := s.Repo.GetUserPermissions(userID)
+	if err != nil {
+		return false, err
+	}
+	for _, p := range perms {
+		if strings.EqualFold(p.Name, permission) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
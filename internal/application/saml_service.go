@@ -0,0 +1,131 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	repo "github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/keyspace"
+)
+
+var (
+	ErrSAMLStateInvalid = errors.New("saml relay state invalid or expired")
+	ErrSAMLReplay       = errors.New("saml assertion already used")
+	ErrSAMLNoEmail      = errors.New("saml assertion has no email")
+)
+
+const samlRequestTTL = 10 * time.Minute
+
+func keySAMLRelay(relay string) string  { return keyspace.Key("saml:relay:" + relay) }
+func keySAMLAssertion(id string) string { return keyspace.Key("saml:assertion:" + id) }
+
+type samlPending struct {
+	RequestID  string `json:"request_id"`
+	LinkUserID string `json:"link_user_id,omitempty"`
+}
+
+// SAMLAttributeMap names the assertion attributes mapped onto the user entity
+type SAMLAttributeMap struct {
+	Email  string
+	Name   string
+	Groups string
+}
+
+// SAMLService implements SSO through a SAML 2.0 IdP, resolving users through their linked
+// identity (NameID) like OIDCService and granting roles from IdP groups.
+type SAMLService struct {
+	Provider          *helpers.SAMLProvider
+	Identities        *IdentityService
+	Roles             repo.RoleRepository
+	Redis             *redis.Client
+	Logger            *logrus.Logger
+	Attrs             SAMLAttributeMap
+	GroupRoles        map[string][]string
+	AllowIDPInitiated bool
+}
+
+func NewSAMLService(provider *helpers.SAMLProvider, identities *IdentityService, roles repo.RoleRepository, rdb *redis.Client, logger *logrus.Logger, attrs SAMLAttributeMap, groupRoles map[string][]string, allowIDPInitiated bool) *SAMLService {
+	return &SAMLService{Provider: provider, Identities: identities, Roles: roles, Redis: rdb, Logger: logger, Attrs: attrs, GroupRoles: groupRoles, AllowIDPInitiated: allowIDPInitiated}
+}
+
+// Start issues an AuthnRequest, remembering its ID under a random RelayState.
+// A non-empty linkUserID makes the ACS link the identity to that user instead of logging in.
+func (s *SAMLService) Start(ctx context.Context, linkUserID string) (string, error) {
+	relay, err := randomToken(24)
+	if err != nil {
+		return "", err
+	}
+	u, reqID, err := s.Provider.AuthnRequestURL(ctx, relay)
+	if err != nil {
+		return "", err
+	}
+	b, _ := json.Marshal(samlPending{RequestID: reqID, LinkUserID: linkUserID})
+	if err := s.Redis.Set(ctx, keySAMLRelay(relay), b, samlRequestTTL).Err(); err != nil {
+		return "", err
+	}
+	return u, nil
+}
+
+// ACS validates the posted SAMLResponse. For a login it returns the local user; for a link
+// flow started by a signed-in user it links the NameID and returns linked=true.
+func (s *SAMLService) ACS(ctx context.Context, samlResponse, relayState string) (u *entity.User, linked bool, err error) {
+	var pending samlPending
+	if relayState != "" {
+		if raw, err := s.Redis.GetDel(ctx, keySAMLRelay(relayState)).Result(); err == nil {
+			_ = json.Unmarshal([]byte(raw), &pending)
+		}
+	}
+	var requestIDs []string
+	if pending.RequestID != "" {
+		requestIDs = []string{pending.RequestID}
+	}
+	if len(requestIDs) == 0 && !s.AllowIDPInitiated {
+		return nil, false, ErrSAMLStateInvalid
+	}
+	a, err := s.Provider.ParseResponse(ctx, samlResponse, requestIDs)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// One-time use until the assertion expires
+	ttl := time.Until(a.NotOnOrAfter)
+	if ttl < time.Minute {
+		ttl = time.Minute
+	}
+	if ok, err := s.Redis.SetNX(ctx, keySAMLAssertion(a.ID), 1, ttl).Result(); err != nil {
+		return nil, false, err
+	} else if !ok {
+		return nil, false, ErrSAMLReplay
+	}
+
+	email := a.Attr(s.Attrs.Email)
+	if email == "" && strings.Contains(a.NameID, "@") {
+		email = a.NameID
+	}
+	// The IdP is authoritative for its users' addresses
+	ext := ExternalIdentity{Provider: ProviderSAML, Subject: a.NameID, Email: email, Name: a.Attr(s.Attrs.Name), EmailVerified: true}
+	if pending.LinkUserID != "" {
+		if err := s.Identities.Link(ctx, pending.LinkUserID, ext); err != nil {
+			return nil, false, err
+		}
+		u, err := s.Identities.Users.GetByID(pending.LinkUserID)
+		return u, true, err
+	}
+	if email == "" {
+		return nil, false, ErrSAMLNoEmail
+	}
+	u, err = s.Identities.Resolve(ctx, ext)
+	if err != nil {
+		return nil, false, err
+	}
+	grantMappedRoles(s.Roles, s.Logger, u.ID, a.Attributes[s.Attrs.Groups], s.GroupRoles)
+	return u, false, nil
+}
@@ -0,0 +1,105 @@
+package application
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+)
+
+// SessionClient describes the client a session is issued to
+type SessionClient struct {
+	IP        string
+	UserAgent string
+	Location  string
+	DeviceID  string
+}
+
+type sessionClientKey struct{}
+
+// WithSessionClient returns ctx carrying cl for IssueTokens to record on the new session
+func WithSessionClient(ctx context.Context, cl SessionClient) context.Context {
+	return context.WithValue(ctx, sessionClientKey{}, cl)
+}
+
+func sessionClientFrom(ctx context.Context) SessionClient {
+	cl, _ := ctx.Value(sessionClientKey{}).(SessionClient)
+	return cl
+}
+
+// ListSessions returns the user's active sessions.
+func (s *Service) ListSessions(ctx context.Context, userID string) ([]entity.Session, error) {
+	if s.Sessions == nil {
+		return []entity.Session{}, nil
+	}
+	return s.Sessions.ListByUser(ctx, userID)
+}
+
+func newDeviceID() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// SessionBinding is the hash a session is bound to: the client family of userAgent (see
+// helpers.UAFamily, so browser updates keep it) and the device id
+func SessionBinding(userAgent, deviceID string) string {
+	sum := sha256.Sum256([]byte(helpers.UAFamily(userAgent) + "\x00" + deviceID))
+	return hex.EncodeToString(sum[:])
+}
+
+// bindingMatches checks the client presenting a refresh (on ctx) against the session's binding.
+// A mismatch is logged and recorded as a refresh_binding_mismatch audit event, and rejected in
+// enforce mode. Sessions issued before binding adopt the presented client, provided
+// the device id they carry matches.
+func (s *Service) bindingMatches(ctx context.Context, sess *entity.Session) bool {
+	if s.DeviceBinding == "" || s.DeviceBinding == DeviceBindingOff {
+		return true
+	}
+	cl := sessionClientFrom(ctx)
+	presented := SessionBinding(cl.UserAgent, cl.DeviceID)
+	sameDevice := subtle.ConstantTimeCompare([]byte(cl.DeviceID), []byte(sess.DeviceID)) == 1
+	if sess.Binding == "" {
+		if sess.DeviceID == "" || sameDevice {
+			sess.DeviceID, sess.Binding = cl.DeviceID, presented
+			return true
+		}
+	} else if subtle.ConstantTimeCompare([]byte(presented), []byte(sess.Binding)) == 1 {
+		return true
+	}
+
+	reason := "device_id"
+	if sameDevice {
+		reason = "client_family"
+	}
+	enforce := s.DeviceBinding == DeviceBindingEnforce
+	meta := map[string]any{
+		"session_id":       sess.ID,
+		"reason":           reason,
+		"rejected":         enforce,
+		"device_presented": cl.DeviceID != "",
+		"expected_client":  helpers.UAFamily(sess.UserAgent),
+		"presented_client": helpers.UAFamily(cl.UserAgent),
+	}
+	if s.Logger != nil {
+		helpers.FromContext(ctx).WithFields(logrus.Fields(meta)).WithField("user_id", sess.UserID).Warn("refresh token presented from another device")
+	}
+	if s.Audit != nil {
+		if err := s.Audit.Record(ctx, entity.AuditLog{
+			UserID: sess.UserID, Email: sess.Email, Action: "refresh_binding_mismatch",
+			IP: cl.IP, UserAgent: cl.UserAgent, Metadata: meta,
+		}); err != nil && s.Logger != nil {
+			helpers.FromContext(ctx).WithError(err).Warn("audit log not recorded")
+		}
+	}
+	return !enforce
+}
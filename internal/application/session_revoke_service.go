@@ -0,0 +1,199 @@
+package application
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+
+	repo "github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/keyspace"
+)
+
+var ErrRevokeTokenInvalid = errors.New("revoke token invalid or expired")
+
+// Security emails that carry a revoke link; recorded with the revocation
+const (
+	RevokeFromSuspiciousLogin = "suspicious_login"
+	RevokeFromPasswordChanged = "password_changed"
+)
+
+func keyRevokeToken(t string) string  { return keyspace.Key("session:revoke:token:" + t) }
+func keyRevokeUsed(mac string) string { return keyspace.Key("session:revoke:used:" + mac) }
+
+// SessionRevocation is what a redeemed revoke link ended
+type SessionRevocation struct {
+	UserID   string
+	DeviceID string // the sessions of this device; empty = every session
+	Source   string // email the link came from
+}
+
+// SessionRevokeService issues the one-click "this wasn't me" links of security emails and redeems
+// them without a login. With Secret set, a link carries an HMAC-signed token naming the user, the
+// device whose sessions to end (or all of them) and an expiry TTL after issue, so nothing is stored
+// until it is used; each token works once. A device-scoped link ends the sessions of that device
+// and stops trusting it, so the person behind it is signed out even after refreshing; an
+// account-wide one ends every session and forgets all trusted devices.
+//
+// Without a secret, links fall back to random tokens kept in Redis for TTL, which are always
+// account-wide.
+type SessionRevokeService struct {
+	Sessions repo.SessionStore
+	Redis    *redis.Client
+	Logger   *logrus.Logger
+	URL      string // page the link opens (SESSION_REVOKE_URL); it POSTs the token back. Empty = no links
+	Secret   string
+	TTL      time.Duration
+}
+
+func NewSessionRevokeService(sessions repo.SessionStore, rdb *redis.Client, logger *logrus.Logger, url, secret string, ttl time.Duration) *SessionRevokeService {
+	if ttl <= 0 {
+		ttl = 7 * 24 * time.Hour
+	}
+	return &SessionRevokeService{Sessions: sessions, Redis: rdb, Logger: logger, URL: url, Secret: secret, TTL: ttl}
+}
+
+// Link returns URL?token=... ending the sessions of deviceID (all of them when empty), or "" when
+// links are not configured or the token could not be issued; nil-safe
+func (s *SessionRevokeService) Link(ctx context.Context, userID, deviceID, source string) string {
+	if s == nil || s.URL == "" {
+		return ""
+	}
+	tok, err := s.Token(ctx, userID, deviceID, source, time.Now())
+	if err != nil {
+		helpers.FromContext(ctx).WithError(err).WithField("user_id", userID).Warn("session revoke token not issued")
+		return ""
+	}
+	sep := "?"
+	if strings.Contains(s.URL, "?") {
+		sep = "&"
+	}
+	return s.URL + sep + "token=" + url.QueryEscape(tok)
+}
+
+// Token issues a revoke token valid for TTL from now: signed with Secret, otherwise a random
+// account-wide token stored in Redis
+func (s *SessionRevokeService) Token(ctx context.Context, userID, deviceID, source string, now time.Time) (string, error) {
+	if s.Secret != "" {
+		payload := strings.Join([]string{userID, deviceID, source, strconv.FormatInt(now.Add(s.TTL).Unix(), 10)}, ":")
+		return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + s.mac(payload), nil
+	}
+	if s.Redis == nil {
+		return "", errors.New("session revoke links need SESSION_REVOKE_SECRET or redis")
+	}
+	tok, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+	if err := s.Redis.Set(ctx, keyRevokeToken(tok), userID, s.TTL).Err(); err != nil {
+		return "", err
+	}
+	return tok, nil
+}
+
+func (s *SessionRevokeService) mac(payload string) string {
+	m := hmac.New(sha256.New, []byte(s.Secret))
+	m.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(m.Sum(nil))
+}
+
+// Revoke redeems token: it ends the sessions it names and forgets the matching trusted devices
+func (s *SessionRevokeService) Revoke(ctx context.Context, token string) (SessionRevocation, error) {
+	if s == nil {
+		return SessionRevocation{}, ErrRevokeTokenInvalid
+	}
+	r, err := s.redeem(ctx, strings.TrimSpace(token))
+	if err != nil {
+		return r, err
+	}
+	if r.DeviceID == "" {
+		if s.Sessions != nil {
+			if err := s.Sessions.Revoke(ctx, r.UserID, ""); err != nil {
+				return r, err
+			}
+		}
+		if s.Redis == nil {
+			return r, nil
+		}
+		return r, helpers.ForgetTrustedDevices(ctx, s.Redis, r.UserID)
+	}
+	if s.Sessions != nil {
+		sessions, err := s.Sessions.ListByUser(ctx, r.UserID)
+		if err != nil {
+			return r, err
+		}
+		for _, sess := range sessions {
+			if sess.DeviceID != r.DeviceID {
+				continue
+			}
+			if err := s.Sessions.Revoke(ctx, r.UserID, sess.ID); err != nil {
+				return r, err
+			}
+		}
+	}
+	if s.Redis == nil {
+		return r, nil
+	}
+	return r, s.Redis.Del(ctx, helpers.KeyTrustedDevice(r.UserID, r.DeviceID)).Err()
+}
+
+// redeem checks token and marks it used
+func (s *SessionRevokeService) redeem(ctx context.Context, token string) (SessionRevocation, error) {
+	if token == "" {
+		return SessionRevocation{}, ErrRevokeTokenInvalid
+	}
+	enc, mac, signed := strings.Cut(token, ".")
+	if !signed {
+		// Random tokens (no secret, or issued before one was set)
+		if s.Redis == nil {
+			return SessionRevocation{}, ErrRevokeTokenInvalid
+		}
+		uid, err := s.Redis.GetDel(ctx, keyRevokeToken(token)).Result()
+		if errors.Is(err, redis.Nil) || (err == nil && uid == "") {
+			return SessionRevocation{}, ErrRevokeTokenInvalid
+		}
+		if err != nil {
+			return SessionRevocation{}, err
+		}
+		return SessionRevocation{UserID: uid, Source: RevokeFromSuspiciousLogin}, nil
+	}
+
+	if s.Secret == "" {
+		return SessionRevocation{}, ErrRevokeTokenInvalid
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(enc)
+	if err != nil || !hmac.Equal([]byte(mac), []byte(s.mac(string(raw)))) {
+		return SessionRevocation{}, ErrRevokeTokenInvalid
+	}
+	parts := strings.Split(string(raw), ":")
+	if len(parts) != 4 || parts[0] == "" {
+		return SessionRevocation{}, ErrRevokeTokenInvalid
+	}
+	exp, err := strconv.ParseInt(parts[3], 10, 64)
+	if err != nil {
+		return SessionRevocation{}, ErrRevokeTokenInvalid
+	}
+	left := time.Until(time.Unix(exp, 0))
+	if left <= 0 {
+		return SessionRevocation{}, ErrRevokeTokenInvalid
+	}
+	if s.Redis != nil {
+		fresh, err := s.Redis.SetNX(ctx, keyRevokeUsed(mac), "1", left).Result()
+		if err != nil {
+			return SessionRevocation{}, err
+		}
+		if !fresh {
+			return SessionRevocation{}, ErrRevokeTokenInvalid
+		}
+	}
+	return SessionRevocation{UserID: parts[0], DeviceID: parts[1], Source: parts[2]}, nil
+}
@@ -0,0 +1,175 @@
+package application
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	repo "github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/keyspace"
+)
+
+var (
+	ErrSetupCompleted    = errors.New("setup already completed")
+	ErrSetupTokenInvalid = errors.New("setup token invalid or expired")
+	ErrSetupInProgress   = errors.New("setup already in progress")
+	ErrSetupEmailTaken   = errors.New("an account with this email already exists")
+)
+
+const (
+	// SetupAdminRole is the role the bootstrap account receives
+	SetupAdminRole = "admin"
+
+	setupTokenTTL = 24 * time.Hour
+)
+
+// keySetupToken holds the sha256 of the generated token, shared by all instances
+func keySetupToken() string { return keyspace.Key("setup:admin:token") }
+func keySetupLock() string  { return keyspace.Key("setup:admin:lock") }
+
+// SetupService creates the first admin account. While no user holds the admin role, POST
+// /api/setup/admin accepts a one-time setup token: SETUP_TOKEN when configured, otherwise one
+// generated at startup, stored hashed in Redis and printed to the logs of the instance that made it.
+type SetupService struct {
+	Users  repo.UserRepository
+	Roles  repo.RoleRepository
+	Redis  *redis.Client
+	Logger *logrus.Logger
+	Token  string                  // SETUP_TOKEN; empty = generate one
+	Pwned  *helpers.PwnedPasswords // nil = no breached password check
+}
+
+func NewSetupService(users repo.UserRepository, roles repo.RoleRepository, rdb *redis.Client, logger *logrus.Logger, token string) *SetupService {
+	return &SetupService{Users: users, Roles: roles, Redis: rdb, Logger: logger, Token: strings.TrimSpace(token)}
+}
+
+// Required reports whether no admin exists yet
+func (s *SetupService) Required(ctx context.Context) (bool, error) {
+	n, err := s.Roles.CountUsersWithRole(SetupAdminRole)
+	if err != nil {
+		return false, err
+	}
+	return n == 0, nil
+}
+
+// Prepare runs at startup. When setup is required and SETUP_TOKEN is unset it issues a token
+// valid for 24h; only the first instance to start issues (and logs) it, a restart after expiry
+// issues a new one.
+func (s *SetupService) Prepare(ctx context.Context) error {
+	required, err := s.Required(ctx)
+	if err != nil || !required {
+		return err
+	}
+	if s.Token != "" {
+		s.Logger.Warn("no admin account exists: create it with POST /api/setup/admin and SETUP_TOKEN")
+		return nil
+	}
+	token, err := randomToken(32)
+	if err != nil {
+		return err
+	}
+	ok, err := s.Redis.SetNX(ctx, keySetupToken(), hashInvitationToken(token), setupTokenTTL).Result()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		s.Logger.Warn("no admin account exists: a setup token was already issued (see the logs of the instance that issued it)")
+		return nil
+	}
+	s.Logger.WithFields(logrus.Fields{
+		"setup_token": token,
+		"expires_in":  setupTokenTTL.String(),
+	}).Warn("no admin account exists: create it with POST /api/setup/admin and this one-time setup token")
+	return nil
+}
+
+// validToken checks token against SETUP_TOKEN or the issued token's hash
+func (s *SetupService) validToken(ctx context.Context, token string) (bool, error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return false, nil
+	}
+	want := s.Token
+	if want != "" {
+		want = hashInvitationToken(want)
+	} else {
+		stored, err := s.Redis.Get(ctx, keySetupToken()).Result()
+		if errors.Is(err, redis.Nil) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		want = stored
+	}
+	return subtle.ConstantTimeCompare([]byte(hashInvitationToken(token)), []byte(want)) == 1, nil
+}
+
+// CreateAdmin creates a verified account with the admin role and retires the setup token.
+// It fails with ErrSetupCompleted once any admin exists.
+func (s *SetupService) CreateAdmin(ctx context.Context, token, email, name, password string) (*entity.User, error) {
+	required, err := s.Required(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !required {
+		return nil, ErrSetupCompleted
+	}
+	ok, err := s.validToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrSetupTokenInvalid
+	}
+	// One setup at a time; the admin check is repeated under the lock
+	locked, err := s.Redis.SetNX(ctx, keySetupLock(), "1", time.Minute).Result()
+	if err != nil {
+		return nil, err
+	}
+	if !locked {
+		return nil, ErrSetupInProgress
+	}
+	defer func() { _ = s.Redis.Del(context.Background(), keySetupLock()).Err() }()
+	if required, err = s.Required(ctx); err != nil {
+		return nil, err
+	} else if !required {
+		return nil, ErrSetupCompleted
+	}
+
+	email = strings.ToLower(strings.TrimSpace(email))
+	if u, err := s.Users.GetByEmail(email); err == nil && u != nil {
+		return nil, ErrSetupEmailTaken
+	}
+	if err := s.Pwned.Check(ctx, password); err != nil {
+		return nil, err
+	}
+	hash, err := helpers.HashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(name) == "" {
+		name = email
+	}
+	role, err := s.Roles.CreateRole(SetupAdminRole) // upsert
+	if err != nil {
+		return nil, err
+	}
+	// User, verification and role commit together: a failure must not leave an account that
+	// blocks a retry with ErrSetupEmailTaken while setup stays open
+	u := &entity.User{Email: email, Password: hash, Name: strings.TrimSpace(name), IsVerified: true}
+	ctx = repo.WithActor(ctx, "setup")
+	if err := s.Users.CreateWithRoles(ctx, u, role.ID); err != nil {
+		return nil, err
+	}
+	_ = s.Redis.Del(ctx, keySetupToken()).Err()
+	helpers.FromContext(ctx).WithField("user_id", u.ID).Info("initial admin account created; setup is closed")
+	return u, nil
+}
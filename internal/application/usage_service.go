@@ -0,0 +1,161 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	repo "github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/keyspace"
+)
+
+var ErrUsageInvalidQuery = errors.New("invalid usage query")
+
+const (
+	UsageGroupSubject = "subject"
+	UsageGroupRoute   = "route"
+)
+
+func keyUsagePending() string      { return keyspace.Key("usage:pending") }
+func keyUsageRollupPrefix() string { return keyspace.Key("usage:rollup:") }
+
+// UsageService meters API requests into Redis hashes and periodically rolls them up into
+// hourly Postgres buckets. A failed rollup keeps its Redis snapshot and is retried on the next run.
+type UsageService struct {
+	Repo   repo.UsageRepository
+	Redis  *redis.Client
+	Logger *logrus.Logger
+}
+
+func NewUsageService(usage repo.UsageRepository, rdb *redis.Client, logger *logrus.Logger) *UsageService {
+	return &UsageService{Repo: usage, Redis: rdb, Logger: logger}
+}
+
+// usage hash fields are "<hour unix>\t<subject>\t<method>\t<route>\t<counter>" with counter r (requests), i or o (bytes)
+func usageField(hour int64, subject, method, route, counter string) string {
+	return strconv.FormatInt(hour, 10) + "\t" + subject + "\t" + method + "\t" + route + "\t" + counter
+}
+
+// Record counts one request. Errors are logged and otherwise ignored so metering never fails a request.
+func (s *UsageService) Record(ctx context.Context, subject, method, route string, bytesIn, bytesOut int64) {
+	hour := time.Now().UTC().Truncate(time.Hour).Unix()
+	_, err := s.Redis.Pipelined(ctx, func(p redis.Pipeliner) error {
+		p.HIncrBy(ctx, keyUsagePending(), usageField(hour, subject, method, route, "r"), 1)
+		if bytesIn > 0 {
+			p.HIncrBy(ctx, keyUsagePending(), usageField(hour, subject, method, route, "i"), bytesIn)
+		}
+		if bytesOut > 0 {
+			p.HIncrBy(ctx, keyUsagePending(), usageField(hour, subject, method, route, "o"), bytesOut)
+		}
+		return nil
+	})
+	if err != nil && s.Logger != nil {
+		helpers.FromContext(ctx).WithError(err).Debug("usage metering failed")
+	}
+}
+
+// Rollup moves the pending counters into Postgres. The pending hash is renamed first so requests
+// recorded meanwhile start a new hash and several instances never roll up the same counters.
+func (s *UsageService) Rollup(ctx context.Context) error {
+	// Snapshots left behind by an earlier failed rollup
+	var keys []string
+	iter := s.Redis.Scan(ctx, 0, keyUsageRollupPrefix()+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	snapshot := keyUsageRollupPrefix() + strconv.FormatInt(time.Now().UnixNano(), 10)
+	if err := s.Redis.Rename(ctx, keyUsagePending(), snapshot).Err(); err == nil {
+		keys = append(keys, snapshot)
+	} else if !strings.Contains(err.Error(), "no such key") {
+		return err
+	}
+	for _, key := range keys {
+		if err := s.flush(ctx, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *UsageService) flush(ctx context.Context, key string) error {
+	fields, err := s.Redis.HGetAll(ctx, key).Result()
+	if err != nil {
+		return err
+	}
+	byKey := map[string]*entity.UsageRecord{}
+	for field, raw := range fields {
+		parts := strings.Split(field, "\t")
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if len(parts) != 5 || err != nil {
+			continue
+		}
+		hour, err := strconv.ParseInt(parts[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		id := strings.Join(parts[:4], "\t")
+		rec, ok := byKey[id]
+		if !ok {
+			rec = &entity.UsageRecord{Bucket: time.Unix(hour, 0).UTC(), Subject: parts[1], Method: parts[2], Route: parts[3]}
+			byKey[id] = rec
+		}
+		switch parts[4] {
+		case "r":
+			rec.Requests += n
+		case "i":
+			rec.BytesIn += n
+		case "o":
+			rec.BytesOut += n
+		}
+	}
+	if len(byKey) > 0 {
+		records := make([]entity.UsageRecord, 0, len(byKey))
+		for _, rec := range byKey {
+			records = append(records, *rec)
+		}
+		if err := s.Repo.Add(records); err != nil {
+			return err
+		}
+	}
+	return s.Redis.Del(ctx, key).Err()
+}
+
+// Run rolls usage up every interval until ctx is cancelled.
+func (s *UsageService) Run(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if err := s.Rollup(ctx); err != nil && s.Logger != nil {
+				s.Logger.WithError(err).Warn("usage rollup failed; will retry")
+			}
+		}
+	}
+}
+
+// Report summarizes rolled-up usage in [from, to), grouped by subject or route, optionally for one subject.
+func (s *UsageService) Report(ctx context.Context, from, to time.Time, subject, groupBy string, limit int) ([]entity.UsageSummary, error) {
+	if !from.Before(to) || limit <= 0 {
+		return nil, ErrUsageInvalidQuery
+	}
+	switch groupBy {
+	case UsageGroupSubject, "":
+		return s.Repo.SummaryBySubject(from, to, subject, limit)
+	case UsageGroupRoute:
+		return s.Repo.SummaryByRoute(from, to, subject, limit)
+	}
+	return nil, ErrUsageInvalidQuery
+}
@@ -0,0 +1,107 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	repo "github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
+)
+
+// replayBatch is how many events Replay reads per query
+const replayBatch = 500
+
+// UserSnapshot is a user's state rebuilt from its history; Seq is the last event applied
+type UserSnapshot struct {
+	ID        string    `json:"id"`
+	Email     string    `json:"email"`
+	Name      string    `json:"name"`
+	AvatarURL string    `json:"avatar_url"`
+	Verified  bool      `json:"verified"`
+	Suspended bool      `json:"suspended"`
+	Banned    bool      `json:"banned"`
+	Seq       int64     `json:"seq"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// UserHistoryService reads the append-only user history and replays it into past states.
+type UserHistoryService struct {
+	Events repo.UserEventRepository
+	Users  repo.UserRepository // resolves public IDs (ULIDs); nil = internal IDs only
+}
+
+func NewUserHistoryService(events repo.UserEventRepository) *UserHistoryService {
+	return &UserHistoryService{Events: events}
+}
+
+// History returns up to limit events with seq > afterSeq; none for an unknown user
+func (s *UserHistoryService) History(ctx context.Context, userID string, afterSeq int64, limit int) ([]entity.UserEvent, error) {
+	userID, err := lookupUserID(s.Users, userID)
+	if errors.Is(err, ErrUserNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.Events.ListByUser(ctx, userID, afterSeq, limit)
+}
+
+// Replay folds the user's events up to and including untilSeq (0 = all) into a snapshot.
+// It returns ErrUserNotFound when the user has no history.
+func (s *UserHistoryService) Replay(ctx context.Context, userID string, untilSeq int64) (*UserSnapshot, error) {
+	userID, err := lookupUserID(s.Users, userID)
+	if err != nil {
+		return nil, err
+	}
+	snap := &UserSnapshot{ID: userID}
+	var after int64
+	for {
+		batch, err := s.Events.ListByUser(ctx, userID, after, replayBatch)
+		if err != nil {
+			return nil, err
+		}
+		for _, ev := range batch {
+			if untilSeq > 0 && ev.Seq > untilSeq {
+				return finishReplay(snap)
+			}
+			applyUserEvent(snap, ev)
+		}
+		if len(batch) < replayBatch {
+			return finishReplay(snap)
+		}
+		after = batch[len(batch)-1].Seq
+	}
+}
+
+func finishReplay(snap *UserSnapshot) (*UserSnapshot, error) {
+	if snap.Seq == 0 {
+		return nil, ErrUserNotFound
+	}
+	return snap, nil
+}
+
+func applyUserEvent(snap *UserSnapshot, ev entity.UserEvent) {
+	if ev.Type == entity.UserEventCreated {
+		snap.CreatedAt = ev.CreatedAt
+	}
+	for field, ch := range ev.Changes {
+		switch field {
+		case "email":
+			snap.Email, _ = ch.After.(string)
+		case "name":
+			snap.Name, _ = ch.After.(string)
+		case "avatar_url":
+			snap.AvatarURL, _ = ch.After.(string)
+		case "is_verified":
+			snap.Verified, _ = ch.After.(bool)
+		case "suspended":
+			snap.Suspended, _ = ch.After.(bool)
+		case "banned":
+			snap.Banned, _ = ch.After.(bool)
+		}
+	}
+	snap.Seq = ev.Seq
+	snap.UpdatedAt = ev.CreatedAt
+}
@@ -0,0 +1,313 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v8"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	repo "github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/keyspace"
+)
+
+var (
+	ErrSearchNotConfigured = errors.New("elasticsearch not configured")
+	ErrReindexRunning      = errors.New("reindex already running")
+	ErrReindexFailures     = errors.New("reindex had failed documents")
+)
+
+const (
+	reindexBatch   = 500
+	reindexLockTTL = time.Hour
+)
+
+// usersIndexBody is the settings/mappings of a new users index
+var usersIndexBody = map[string]any{
+	"mappings": map[string]any{
+		"properties": map[string]any{
+			"id":         map[string]any{"type": "keyword"},
+			"email":      map[string]any{"type": "text", "fields": map[string]any{"keyword": map[string]any{"type": "keyword"}}},
+			"name":       map[string]any{"type": "text"},
+			"avatar_url": map[string]any{"type": "keyword", "index": false},
+			"created_at": map[string]any{"type": "date"},
+			"updated_at": map[string]any{"type": "date"},
+		},
+	},
+}
+
+// ReindexStatus describes the last (or running) reindex
+type ReindexStatus struct {
+	State      string     `json:"state"` // running, done, failed
+	Index      string     `json:"index,omitempty"`
+	Previous   []string   `json:"previous,omitempty"`
+	Indexed    int        `json:"indexed"`
+	Failed     int        `json:"failed"`
+	Error      string     `json:"error,omitempty"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// UserIndexService manages the users search index behind a read alias (searches) and a write alias
+// (profile updates). Reindex builds a fresh timestamped index from Postgres and swaps the aliases,
+// so mapping changes need no downtime.
+type UserIndexService struct {
+	Users      repo.UserRepository
+	ES         *elasticsearch.Client
+	Redis      *redis.Client
+	Logger     *logrus.Logger
+	ReadAlias  string
+	WriteAlias string
+}
+
+func NewUserIndexService(users repo.UserRepository, es *elasticsearch.Client, rdb *redis.Client, logger *logrus.Logger, readAlias, writeAlias string) *UserIndexService {
+	return &UserIndexService{Users: users, ES: es, Redis: rdb, Logger: logger, ReadAlias: readAlias, WriteAlias: writeAlias}
+}
+
+func (s *UserIndexService) keyLock() string {
+	return keyspace.Key("es:reindex:" + s.ReadAlias + ":lock")
+}
+func (s *UserIndexService) keyStatus() string {
+	return keyspace.Key("es:reindex:" + s.ReadAlias + ":status")
+}
+
+func (s *UserIndexService) newIndexName() string {
+	return s.ReadAlias + "_" + time.Now().UTC().Format("20060102150405")
+}
+
+// EnsureAliases sets up the aliases: an existing concrete index named like the read alias (pre-alias
+// deployments) gets the write alias; with nothing in place a first timestamped index is created.
+func (s *UserIndexService) EnsureAliases(ctx context.Context) error {
+	if s.ES == nil || s.ReadAlias == "" {
+		return ErrSearchNotConfigured
+	}
+	writeTargets, err := helpers.ESAliasIndices(ctx, s.ES, s.WriteAlias)
+	if err != nil || len(writeTargets) > 0 {
+		return err
+	}
+	readTargets, err := helpers.ESAliasIndices(ctx, s.ES, s.ReadAlias)
+	if err != nil {
+		return err
+	}
+	target := ""
+	if len(readTargets) > 0 {
+		target = readTargets[0]
+	} else if exists, err := helpers.ESIndexExists(ctx, s.ES, s.ReadAlias); err != nil {
+		return err
+	} else if exists {
+		target = s.ReadAlias
+	}
+	if target != "" {
+		return helpers.ESUpdateAliases(ctx, s.ES, []map[string]any{
+			{"add": map[string]any{"index": target, "alias": s.WriteAlias, "is_write_index": true}},
+		})
+	}
+	body := map[string]any{
+		"mappings": usersIndexBody["mappings"],
+		"aliases": map[string]any{
+			s.ReadAlias:  map[string]any{},
+			s.WriteAlias: map[string]any{"is_write_index": true},
+		},
+	}
+	return helpers.ESCreateIndex(ctx, s.ES, s.newIndexName(), body)
+}
+
+// Status returns the last reindex status (nil when none has run)
+func (s *UserIndexService) Status(ctx context.Context) (*ReindexStatus, error) {
+	raw, err := s.Redis.Get(ctx, s.keyStatus()).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var st ReindexStatus
+	if err := json.Unmarshal([]byte(raw), &st); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+func (s *UserIndexService) saveStatus(ctx context.Context, st *ReindexStatus) {
+	b, _ := json.Marshal(st)
+	if err := s.Redis.Set(ctx, s.keyStatus(), b, 7*24*time.Hour).Err(); err != nil && s.Logger != nil {
+		s.Logger.WithError(err).Warn("save reindex status failed")
+	}
+}
+
+// Reindex rebuilds the users index without downtime:
+//  1. create a new timestamped index
+//  2. point the write alias at it, so live updates land in the new index
+//  3. bulk-load every user from Postgres with op "create" (documents written live are newer and kept)
+//  4. atomically move the read alias to the new index
+//  5. delete the old index
+//
+// On failure before step 4 the write alias is moved back and the new index deleted.
+func (s *UserIndexService) Reindex(ctx context.Context) (*ReindexStatus, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	return s.run(ctx)
+}
+
+// StartReindex runs Reindex in the background; it fails fast when one is already running.
+func (s *UserIndexService) StartReindex(ctx context.Context) error {
+	if err := s.lock(ctx); err != nil {
+		return err
+	}
+	go func() {
+		if _, err := s.run(context.Background()); err != nil && s.Logger != nil {
+			s.Logger.WithError(err).Error("users reindex failed")
+		}
+	}()
+	return nil
+}
+
+func (s *UserIndexService) lock(ctx context.Context) error {
+	if s.ES == nil || s.ReadAlias == "" {
+		return ErrSearchNotConfigured
+	}
+	ok, err := s.Redis.SetNX(ctx, s.keyLock(), "1", reindexLockTTL).Result()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrReindexRunning
+	}
+	return nil
+}
+
+// run performs the reindex while holding the lock and releases it
+func (s *UserIndexService) run(ctx context.Context) (*ReindexStatus, error) {
+	defer func() { _ = s.Redis.Del(context.Background(), s.keyLock()).Err() }()
+
+	st := &ReindexStatus{State: "running", StartedAt: time.Now().UTC()}
+	s.saveStatus(ctx, st)
+	err := s.reindex(ctx, st)
+	now := time.Now().UTC()
+	st.FinishedAt = &now
+	st.State = "done"
+	if err != nil {
+		st.State, st.Error = "failed", err.Error()
+	}
+	s.saveStatus(context.Background(), st)
+	return st, err
+}
+
+func (s *UserIndexService) reindex(ctx context.Context, st *ReindexStatus) error {
+	if err := s.EnsureAliases(ctx); err != nil {
+		return err
+	}
+	oldRead, err := helpers.ESAliasIndices(ctx, s.ES, s.ReadAlias)
+	if err != nil {
+		return err
+	}
+	// A concrete index named like the read alias must be removed in the same request that creates the alias
+	legacy := false
+	if len(oldRead) == 0 {
+		if exists, err := helpers.ESIndexExists(ctx, s.ES, s.ReadAlias); err != nil {
+			return err
+		} else if exists {
+			legacy, oldRead = true, []string{s.ReadAlias}
+		}
+	}
+	oldWrite, err := helpers.ESAliasIndices(ctx, s.ES, s.WriteAlias)
+	if err != nil {
+		return err
+	}
+	st.Index, st.Previous = s.newIndexName(), oldRead
+	if err := helpers.ESCreateIndex(ctx, s.ES, st.Index, usersIndexBody); err != nil {
+		return err
+	}
+
+	if err := helpers.ESUpdateAliases(ctx, s.ES, moveAlias(s.WriteAlias, oldWrite, st.Index)); err != nil {
+		_ = helpers.ESDeleteIndices(context.Background(), s.ES, st.Index)
+		return err
+	}
+	if err := s.load(ctx, st); err != nil {
+		// Roll back: live writes made meanwhile only reached the new index and are lost from the old one
+		rb := context.Background()
+		if rbErr := helpers.ESUpdateAliases(rb, s.ES, moveAlias(s.WriteAlias, []string{st.Index}, firstOr(oldWrite, ""))); rbErr != nil && s.Logger != nil {
+			s.Logger.WithError(rbErr).Error("reindex rollback of write alias failed")
+		}
+		_ = helpers.ESDeleteIndices(rb, s.ES, st.Index)
+		return err
+	}
+
+	var actions []map[string]any
+	if legacy {
+		actions = append(actions, map[string]any{"remove_index": map[string]any{"index": s.ReadAlias}})
+	} else {
+		for _, idx := range oldRead {
+			actions = append(actions, map[string]any{"remove": map[string]any{"index": idx, "alias": s.ReadAlias}})
+		}
+	}
+	actions = append(actions, map[string]any{"add": map[string]any{"index": st.Index, "alias": s.ReadAlias}})
+	if err := helpers.ESUpdateAliases(ctx, s.ES, actions); err != nil {
+		return err
+	}
+	if !legacy {
+		if err := helpers.ESDeleteIndices(ctx, s.ES, oldRead...); err != nil && s.Logger != nil {
+			s.Logger.WithError(err).WithField("indices", oldRead).Warn("delete old users index failed")
+		}
+	}
+	if s.Logger != nil {
+		s.Logger.WithFields(logrus.Fields{"index": st.Index, "indexed": st.Indexed, "previous": oldRead}).Info("users index rebuilt")
+	}
+	return nil
+}
+
+// load bulk-indexes every user into the new index
+func (s *UserIndexService) load(ctx context.Context, st *ReindexStatus) error {
+	after := ""
+	for {
+		users, err := s.Users.ListAfter(after, reindexBatch)
+		if err != nil {
+			return err
+		}
+		if len(users) == 0 {
+			break
+		}
+		docs := make([]helpers.ESBulkDoc, 0, len(users))
+		for i := range users {
+			docs = append(docs, helpers.ESBulkDoc{ID: users[i].ID, Doc: entity.SummaryOf(&users[i])})
+		}
+		failed, err := helpers.ESBulk(ctx, s.ES, st.Index, "create", docs)
+		if err != nil {
+			return err
+		}
+		st.Indexed += len(docs) - failed
+		st.Failed += failed
+		after = users[len(users)-1].ID
+	}
+	if st.Failed > 0 {
+		return ErrReindexFailures
+	}
+	return nil
+}
+
+// moveAlias moves a write alias from the from indices to to (only removes when to is empty)
+func moveAlias(alias string, from []string, to string) []map[string]any {
+	var actions []map[string]any
+	for _, idx := range from {
+		if idx != to {
+			actions = append(actions, map[string]any{"remove": map[string]any{"index": idx, "alias": alias}})
+		}
+	}
+	if to != "" {
+		actions = append(actions, map[string]any{"add": map[string]any{"index": to, "alias": alias, "is_write_index": true}})
+	}
+	return actions
+}
+
+func firstOr(v []string, def string) string {
+	if len(v) > 0 {
+		return v[0]
+	}
+	return def
+}
@@ -0,0 +1,250 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	repo "github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/keyspace"
+)
+
+const (
+	// userIndexCheckpoint names the users search sync in sync_checkpoints
+	userIndexCheckpoint = "users_search"
+	userIndexLockTTL    = 30 * time.Second
+)
+
+func keyUserIndexLock() string { return keyspace.Key("es:sync:users:lock") }
+
+var ErrUserIndexFailures = errors.New("users index sync had failed documents")
+
+// UserIndexSync keeps the users search index in step with Postgres by consuming the user_events
+// outbox: every user write appends an event in its own transaction, so nothing committed can be
+// missed. Each batch re-reads the affected users and bulk-indexes their current rows through the
+// write alias; the checkpoint only advances once Elasticsearch accepted all of them, so an outage
+// or a restart replays from the last applied event. Indexing the current row is idempotent, which
+// makes replays and duplicate deliveries harmless. One instance syncs at a time (Redis lock).
+//
+// Requests never wait on Elasticsearch: they only append the event, and repeated updates of one
+// user within a batch are indexed once. While a batch keeps failing the sync backs off, doubling
+// its wait up to MaxBackoff, so an outage is not hammered; the outbox holds the backlog meanwhile.
+//
+// Event ids come from a sequence and are assigned at insert, so a transaction can commit a lower
+// id after a higher one was read. A hole in the ids stops the batch until it fills or is older
+// than GapWait (rolled back transactions leave holes for good).
+type UserIndexSync struct {
+	Events     repo.UserEventRepository
+	Users      repo.UserRepository
+	Index      *UserIndexService // aliases are ensured before the first write
+	Search     repo.SearchService
+	Redis      *redis.Client
+	Logger     *logrus.Logger
+	Batch      int
+	Interval   time.Duration
+	MaxBackoff time.Duration // longest wait between failing runs
+	GapWait    time.Duration
+
+	owner   string
+	aliases atomic.Bool
+	closed  atomic.Bool
+	stop    chan struct{}
+	done    chan struct{}
+	runOnce sync.Once
+
+	applied, indexed, failures atomic.Int64
+	checkpoint, lastSync       atomic.Int64
+}
+
+func NewUserIndexSync(events repo.UserEventRepository, users repo.UserRepository, idx *UserIndexService, search repo.SearchService, rdb *redis.Client, logger *logrus.Logger, interval time.Duration) *UserIndexSync {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	return &UserIndexSync{
+		Events: events, Users: users, Index: idx, Search: search, Redis: rdb, Logger: logger,
+		Batch: reindexBatch, Interval: interval, MaxBackoff: time.Minute, GapWait: time.Minute,
+		owner: uuid.NewString(), stop: make(chan struct{}), done: make(chan struct{}),
+	}
+}
+
+// Start replays everything after the checkpoint, then follows new events every Interval until Close
+func (s *UserIndexSync) Start() {
+	s.runOnce.Do(func() { go s.run() })
+}
+
+func (s *UserIndexSync) run() {
+	defer close(s.done)
+	wait := s.Interval
+	for {
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			select {
+			case <-s.stop:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+		if n, err := s.Sync(ctx); err != nil && !errors.Is(err, context.Canceled) {
+			s.failures.Add(1)
+			wait = min(wait*2, max(s.MaxBackoff, s.Interval))
+			s.Logger.WithError(err).WithFields(logrus.Fields{"checkpoint": s.checkpoint.Load(), "retry_in": wait}).Warn("users index sync failed; will retry")
+		} else {
+			wait = s.Interval
+			if n > 0 {
+				s.Logger.WithFields(logrus.Fields{"events": n, "checkpoint": s.checkpoint.Load()}).Debug("users index synced")
+			}
+		}
+		cancel()
+		select {
+		case <-s.stop:
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// Close stops the sync and waits for the running batch until ctx is done; the checkpoint keeps
+// what was applied, so the next start continues from there.
+func (s *UserIndexSync) Close(ctx context.Context) {
+	if s == nil || !s.closed.CompareAndSwap(false, true) {
+		return
+	}
+	close(s.stop)
+	s.runOnce.Do(func() { close(s.done) }) // never started
+	select {
+	case <-s.done:
+	case <-ctx.Done():
+	}
+	_ = s.Redis.Eval(context.Background(), `if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("DEL", KEYS[1]) end return 0`, []string{keyUserIndexLock()}, s.owner).Err()
+}
+
+// Sync applies every available event after the checkpoint and returns how many it applied. It
+// returns (0, nil) when another instance holds the lock.
+func (s *UserIndexSync) Sync(ctx context.Context) (int, error) {
+	if ok, err := s.lock(ctx); err != nil || !ok {
+		return 0, err
+	}
+	if !s.aliases.Load() {
+		if err := s.Index.EnsureAliases(ctx); err != nil {
+			return 0, fmt.Errorf("ensure aliases: %w", err)
+		}
+		s.aliases.Store(true)
+	}
+	last, err := s.Events.Checkpoint(ctx, userIndexCheckpoint)
+	if err != nil {
+		return 0, err
+	}
+	s.checkpoint.Store(last)
+	applied := 0
+	for {
+		events, err := s.Events.ListAfter(ctx, last, s.Batch)
+		if err != nil {
+			return applied, err
+		}
+		ready := s.ready(events, last)
+		if len(ready) == 0 {
+			break
+		}
+		if err := s.apply(ctx, ready); err != nil {
+			return applied, err
+		}
+		last = ready[len(ready)-1].ID
+		if err := s.Events.SetCheckpoint(ctx, userIndexCheckpoint, last); err != nil {
+			return applied, err
+		}
+		s.checkpoint.Store(last)
+		s.applied.Add(int64(len(ready)))
+		applied += len(ready)
+		if len(ready) < len(events) || len(events) < s.Batch {
+			break
+		}
+		if ok, err := s.lock(ctx); err != nil || !ok {
+			return applied, err
+		}
+	}
+	s.lastSync.Store(time.Now().Unix())
+	return applied, nil
+}
+
+// ready returns the leading events that can be applied: it stops before a hole in the ids that
+// is younger than GapWait, since the missing event may still be committed.
+func (s *UserIndexSync) ready(events []entity.UserEvent, last int64) []entity.UserEvent {
+	for i, ev := range events {
+		if ev.ID != last+1 && time.Since(ev.CreatedAt) < s.GapWait {
+			return events[:i]
+		}
+		last = ev.ID
+	}
+	return events
+}
+
+// apply indexes the current rows of the users the events touched
+func (s *UserIndexSync) apply(ctx context.Context, events []entity.UserEvent) error {
+	seen := make(map[string]bool, len(events))
+	users := make([]entity.User, 0, len(events))
+	for _, ev := range events {
+		if seen[ev.UserID] {
+			continue
+		}
+		seen[ev.UserID] = true
+		u, err := s.Users.GetByID(ev.UserID)
+		if errors.Is(err, repo.ErrNotFound) {
+			continue // users are not hard-deleted; nothing to index
+		}
+		if err != nil {
+			return err
+		}
+		users = append(users, *u)
+	}
+	failed, err := s.Search.IndexUsers(ctx, users)
+	if err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%w: %d of %d", ErrUserIndexFailures, failed, len(users))
+	}
+	s.indexed.Add(int64(len(users)))
+	return nil
+}
+
+// lock takes or renews the sync lock; false means another instance holds it
+func (s *UserIndexSync) lock(ctx context.Context) (bool, error) {
+	ok, err := s.Redis.SetNX(ctx, keyUserIndexLock(), s.owner, userIndexLockTTL).Result()
+	if err != nil || ok {
+		return ok, err
+	}
+	holder, err := s.Redis.Get(ctx, keyUserIndexLock()).Result()
+	if errors.Is(err, redis.Nil) {
+		return false, nil // released meanwhile; taken again next cycle
+	}
+	if err != nil || holder != s.owner {
+		return false, err
+	}
+	return true, s.Redis.Expire(ctx, keyUserIndexLock(), userIndexLockTTL).Err()
+}
+
+// WriteMetrics appends the sync counters and checkpoint in Prometheus text format. nil-safe.
+func (s *UserIndexSync) WriteMetrics(b *strings.Builder) {
+	if s == nil {
+		return
+	}
+	counter := func(name, help string, v int64) {
+		fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s counter\n%s %d\n", name, help, name, name, v)
+	}
+	counter("users_index_sync_events_total", "user_events applied to the users search index.", s.applied.Load())
+	counter("users_index_sync_documents_total", "User documents written to the users search index.", s.indexed.Load())
+	counter("users_index_sync_failures_total", "Users index sync runs that failed and will be retried.", s.failures.Load())
+	fmt.Fprintf(b, "# HELP users_index_sync_checkpoint Last user_events id applied to the users search index.\n# TYPE users_index_sync_checkpoint gauge\nusers_index_sync_checkpoint %d\n", s.checkpoint.Load())
+	if ts := s.lastSync.Load(); ts > 0 {
+		fmt.Fprintf(b, "# HELP users_index_sync_last_success_timestamp_seconds When this instance last caught up.\n# TYPE users_index_sync_last_success_timestamp_seconds gauge\nusers_index_sync_last_success_timestamp_seconds %d\n", ts)
+	}
+}
@@ -0,0 +1,114 @@
+package application
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	repo "github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/keyspace"
+)
+
+func keySearchQuota(userID string, day time.Time) string {
+	return keyspace.Key("quota:search:user:" + userID + ":" + day.Format("20060102"))
+}
+
+// keySearchCache hashes the normalized request and the backend answering it, so equal queries
+// share one entry across users and canary results never stand in for the primary backend's
+func keySearchCache(q UserSearchQuery, backend string) string {
+	b, _ := json.Marshal(struct {
+		Q       string   `json:"q"`
+		From    int      `json:"from"`
+		Size    int      `json:"size"`
+		Fields  []string `json:"fields"`
+		Backend string   `json:"backend,omitempty"`
+	}{q.Q, q.From, q.Size, q.Fields, backend})
+	sum := sha256.Sum256(b)
+	return keyspace.Regional("cache:search:users:" + hex.EncodeToString(sum[:]))
+}
+
+// Elasticsearch refuses to page past this many hits (index.max_result_window)
+const userSearchMaxWindow = 10000
+
+// The search types live in the domain so every SearchService implementation shares them
+type (
+	UserSearchQuery = entity.UserSearchQuery
+	UserSummary     = entity.UserSummary
+	SearchResult    = entity.UserSearchResult
+)
+
+// ConsumeSearchQuota counts one search against the user's daily quota (reset at UTC midnight).
+// It returns helpers.ErrQuotaExceeded once the quota is used up; Redis errors fail open.
+func (s *Service) ConsumeSearchQuota(ctx context.Context, userID string) (helpers.QuotaResult, error) {
+	if s.Redis == nil || s.SearchDailyQuota <= 0 || userID == "" {
+		return helpers.QuotaResult{}, nil
+	}
+	now := time.Now().UTC()
+	res, err := helpers.ConsumeQuota(ctx, s.Redis, keySearchQuota(userID, now), 1, int64(s.SearchDailyQuota), untilMidnight(now))
+	if err != nil {
+		if s.Logger != nil {
+			helpers.FromContext(ctx).WithError(err).Warn("search quota unavailable")
+		}
+		return helpers.QuotaResult{}, nil
+	}
+	if res.Exceeded() {
+		return res, helpers.ErrQuotaExceeded
+	}
+	return res, nil
+}
+
+// SearchUsers matches q.Q against email and name through the configured SearchService (or
+// SearchCanary while the search_canary flag is on) and returns the page q selects. Identical queries within SearchCacheTTL are answered from Redis; cached reports whether
+// this one was.
+func (s *Service) SearchUsers(ctx context.Context, q UserSearchQuery) (res SearchResult, cached bool, err error) {
+	if q.Size <= 0 || q.Size > 50 {
+		q.Size = 10
+	}
+	q.From = min(max(q.From, 0), userSearchMaxWindow-q.Size)
+	q.Q = strings.TrimSpace(q.Q)
+	q.Fields = slices.Sorted(slices.Values(q.Fields))
+	empty := SearchResult{Items: []UserSummary{}, From: q.From, Size: q.Size}
+	search, backend := s.Search, ""
+	if s.SearchCanary != nil && s.Features.Enabled(ctx, helpers.FeatureSearchCanary) {
+		search, backend = s.SearchCanary, "canary:"+s.SearchCanary.Backend()
+	}
+	if search == nil {
+		return empty, false, nil
+	}
+	if s.Redis == nil || s.SearchCacheTTL <= 0 {
+		res, err = s.searchUsersGuarded(ctx, search, q)
+		return res, false, err
+	}
+	key := keySearchCache(q, backend)
+	if b, gerr := s.Redis.Get(ctx, key).Bytes(); gerr == nil && json.Unmarshal(b, &res) == nil {
+		return res, true, nil
+	}
+	if res, err = s.searchUsersGuarded(ctx, search, q); err != nil {
+		return empty, false, err
+	}
+	if b, merr := json.Marshal(res); merr == nil {
+		_ = s.Redis.Set(ctx, key, b, s.SearchCacheTTL).Err()
+	}
+	return res, false, nil
+}
+
+// searchUsersGuarded calls the primary search backend through SearchBreaker. While the breaker is
+// open it returns no results instead of an error (cached results are still served by SearchUsers);
+// the response meta then lists "search" as degraded. The canary backend is called directly.
+func (s *Service) searchUsersGuarded(ctx context.Context, search repo.SearchService, q UserSearchQuery) (SearchResult, error) {
+	if search != s.Search {
+		return search.SearchUsers(ctx, q)
+	}
+	if !s.SearchBreaker.Allow() {
+		return SearchResult{Items: []UserSummary{}, From: q.From, Size: q.Size}, nil
+	}
+	res, err := s.Search.SearchUsers(ctx, q)
+	s.SearchBreaker.Record(err)
+	return res, err
+}
@@ -0,0 +1,136 @@
+package application
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	repo "github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+)
+
+var (
+	ErrUserSecretNotFound = errors.New("no value stored")
+	ErrUserSecretsOff     = errors.New("field encryption not configured")
+)
+
+const reencryptBatch = 500
+
+// UserSecretService keeps sensitive per-user values (phone number, TOTP secret) encrypted at rest
+// in user_secrets. Values are sealed with the current FIELD_ENCRYPTION_KEY_ID and bound to the
+// user and name, so Postgres (and its backups) only ever hold ciphertext. Rotate re-encrypts
+// values still on older keys.
+type UserSecretService struct {
+	Repo   repo.UserSecretRepository
+	Cipher *helpers.FieldCipher
+	Logger *logrus.Logger
+}
+
+func NewUserSecretService(r repo.UserSecretRepository, fc *helpers.FieldCipher, logger *logrus.Logger) *UserSecretService {
+	return &UserSecretService{Repo: r, Cipher: fc, Logger: logger}
+}
+
+// Enabled reports whether encryption keys are configured
+func (s *UserSecretService) Enabled() bool { return s != nil && s.Cipher != nil }
+
+func userSecretAAD(userID, name string) string { return userID + ":" + name }
+
+// Set encrypts value and stores it as the user's name
+func (s *UserSecretService) Set(ctx context.Context, userID, name, value string) error {
+	if !s.Enabled() {
+		return ErrUserSecretsOff
+	}
+	ct, err := s.Cipher.Encrypt(value, userSecretAAD(userID, name))
+	if err != nil {
+		return err
+	}
+	return s.Repo.Put(ctx, entity.UserSecret{UserID: userID, Name: name, Ciphertext: ct, KeyID: s.Cipher.KeyID()})
+}
+
+// Get returns the user's decrypted value; ErrUserSecretNotFound when none is stored
+func (s *UserSecretService) Get(ctx context.Context, userID, name string) (string, error) {
+	if !s.Enabled() {
+		return "", ErrUserSecretsOff
+	}
+	sec, err := s.Repo.Get(ctx, userID, name)
+	if err != nil {
+		return "", notFound(err, ErrUserSecretNotFound)
+	}
+	return s.Cipher.Decrypt(sec.Ciphertext, userSecretAAD(userID, name))
+}
+
+// Delete removes the user's value; ErrUserSecretNotFound when none was stored
+func (s *UserSecretService) Delete(ctx context.Context, userID, name string) error {
+	if !s.Enabled() {
+		return ErrUserSecretsOff
+	}
+	ok, err := s.Repo.Delete(ctx, userID, name)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrUserSecretNotFound
+	}
+	return nil
+}
+
+// RotateResult counts what Rotate did
+type RotateResult struct {
+	Reencrypted int `json:"reencrypted"`
+	Changed     int `json:"changed"` // rewritten by a user meanwhile, already on the current key
+	Failed      int `json:"failed"`  // key no longer configured or ciphertext damaged; left as is
+}
+
+// Rotate re-encrypts every value not yet on the current key. It is safe to run while the server
+// is up: a value written meanwhile is left alone. Values that fail to decrypt are logged and
+// skipped; keep their key configured until they have been dealt with.
+func (s *UserSecretService) Rotate(ctx context.Context) (RotateResult, error) {
+	var res RotateResult
+	if !s.Enabled() {
+		return res, ErrUserSecretsOff
+	}
+	current := s.Cipher.KeyID()
+	afterUser, afterName := "", ""
+	for {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		batch, err := s.Repo.ListNotOnKey(ctx, current, afterUser, afterName, reencryptBatch)
+		if err != nil {
+			return res, err
+		}
+		for _, sec := range batch {
+			ok, err := s.reencrypt(ctx, sec, current)
+			switch {
+			case err != nil:
+				res.Failed++
+				s.Logger.WithError(err).WithFields(logrus.Fields{"user_id": sec.UserID, "name": sec.Name, "key_id": sec.KeyID}).Warn("user secret not re-encrypted")
+			case ok:
+				res.Reencrypted++
+			default:
+				res.Changed++
+			}
+		}
+		if len(batch) < reencryptBatch {
+			return res, nil
+		}
+		last := batch[len(batch)-1]
+		afterUser, afterName = last.UserID, last.Name
+	}
+}
+
+// reencrypt moves one value onto the current key; false when it changed since it was listed
+func (s *UserSecretService) reencrypt(ctx context.Context, sec entity.UserSecret, current string) (bool, error) {
+	aad := userSecretAAD(sec.UserID, sec.Name)
+	plain, err := s.Cipher.Decrypt(sec.Ciphertext, aad)
+	if err != nil {
+		return false, err
+	}
+	ct, err := s.Cipher.Encrypt(plain, aad)
+	if err != nil {
+		return false, err
+	}
+	return s.Repo.Reencrypt(ctx, sec, ct, current)
+}
@@ -2,7 +2,6 @@ package application
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"io"
 	"path/filepath"
@@ -10,8 +9,6 @@ import (
 	"time"
 
 	"cloud.google.com/go/storage"
-	"github.com/elastic/go-elasticsearch/v8"
-	"github.com/elastic/go-elasticsearch/v8/esapi"
 	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 	"github.com/sirupsen/logrus"
@@ -27,15 +24,79 @@ var (
 	ErrEmailNotVerified   = errors.New("email not verified")
 )
 
+// notFound is the error for a failed lookup: sentinel when the row is missing, but a repository
+// failure of another kind (unavailable, conflict, internal) is passed through so handlers do not
+// report an outage as a 404. Unclassified errors, such as a malformed id, count as missing.
+func notFound(err, sentinel error) error {
+	var re *repo.Error
+	if errors.As(err, &re) && !errors.Is(err, repo.ErrNotFound) {
+		return err
+	}
+	return sentinel
+}
+
+// lookupUserID returns the internal ID of the user id names: a public ULID (entity.User.ExternalID)
+// is looked up, anything else is taken to be an internal ID already
+func lookupUserID(users repo.UserRepository, id string) (string, error) {
+	if users == nil || !helpers.IsULID(id) {
+		return id, nil
+	}
+	u, err := users.GetByExternalID(id)
+	if err != nil || u == nil {
+		return "", notFound(err, ErrUserNotFound)
+	}
+	return u.ID, nil
+}
+
+// Email verification policies applied to password login
+const (
+	VerifyPolicyOff   = "off"
+	VerifyPolicyWarn  = "warn"
+	VerifyPolicyBlock = "block"
+)
+
+// Device binding modes for refresh: a refresh token must come with the device id of its session
+const (
+	DeviceBindingOff     = "off"
+	DeviceBindingLog     = "log"
+	DeviceBindingEnforce = "enforce"
+)
+
 type Service struct {
-	Repo         repo.UserRepository
-	JWT          *helpers.JWTManager
-	GCS          *storage.Client
-	GCSBucket    string
-	Redis        *redis.Client
-	Logger       *logrus.Logger
-	ES           *elasticsearch.Client
-	ESUsersIndex string
+	Repo      repo.UserRepository
+	JWT       *helpers.JWTManager
+	GCS       *storage.Client
+	GCSBucket string
+	Sessions  repo.SessionStore
+	Logger    *logrus.Logger
+	// Search answers SearchUsers (Elasticsearch, Postgres or nothing; see SEARCH_BACKEND); nil finds nothing
+	Search       repo.SearchService
+	VerifyPolicy string
+	Policy       helpers.SessionPolicy
+
+	// User search protection (see user_search.go); set after construction
+	Redis            *redis.Client
+	SearchDailyQuota int
+	SearchCacheTTL   time.Duration
+	// RefreshGrace is how long a rotated pair is handed to concurrent refreshes of the same token
+	// (REFRESH_GRACE_PERIOD; 0 = no coordination); set after construction
+	RefreshGrace time.Duration
+	// SearchBreaker stops calling Elasticsearch while it keeps failing; searches then return no
+	// results and the response meta lists "search" as degraded
+	SearchBreaker *helpers.Breaker
+	// SearchCanary replaces Search while Features has helpers.FeatureSearchCanary on (FEATURE_FLAGS
+	// or a request override); nil = no canary
+	SearchCanary repo.SearchService
+	Features     *helpers.Features
+
+	// DeviceBinding is one of the DeviceBinding* modes; set after construction
+	DeviceBinding string
+	// Audit records binding mismatches on refresh as security events; set after construction
+	Audit *AuditService
+
+	// AvatarStorage, when set, makes AvatarURL sign avatar URLs (private buckets); set after construction
+	AvatarStorage repo.Storage
+	AvatarURLTTL  time.Duration
 }
 
 type TokenPair struct {
@@ -43,26 +104,28 @@ type TokenPair struct {
 	AccessTokenExpiry  time.Time
 	RefreshToken       string
 	RefreshTokenExpiry time.Time
+	DeviceID           string // device the session is bound to; presented again on refresh
 }
 
-func sessionKey(userID string) string {
-	return "user:session:" + userID
-}
-
-func nowRFC3339() string {
-	return time.Now().UTC().Format(time.RFC3339Nano)
+// sessionTTL is the configured session TTL (24h when unset)
+func (s *Service) sessionTTL() time.Duration {
+	if s.Policy.TTL > 0 {
+		return s.Policy.TTL
+	}
+	return 24 * time.Hour
 }
 
-func NewService(repo repo.UserRepository, jwt *helpers.JWTManager, gcs *storage.Client, gcsBucket string, rdb *redis.Client, logger *logrus.Logger, es *elasticsearch.Client, esUsersIndex string) *Service {
+func NewService(repo repo.UserRepository, jwt *helpers.JWTManager, gcs *storage.Client, gcsBucket string, sessions repo.SessionStore, logger *logrus.Logger, search repo.SearchService, verifyPolicy string, policy helpers.SessionPolicy) *Service {
 	return &Service{
 		Repo:         repo,
 		JWT:          jwt,
 		GCS:          gcs,
 		GCSBucket:    gcsBucket,
-		Redis:        rdb,
+		Sessions:     sessions,
 		Logger:       logger,
-		ES:           es,
-		ESUsersIndex: esUsersIndex,
+		Search:       search,
+		VerifyPolicy: verifyPolicy,
+		Policy:       policy,
 	}
 }
 
@@ -73,56 +136,75 @@ type LoginResponse struct {
 }
 
 // Authenticate validates email/password and returns the user without issuing tokens.
+// A suspended or banned account gets entity.ErrAccountSuspended or entity.ErrAccountBanned.
+// Under the block policy an unverified user gets ErrEmailNotVerified; the user is still
+// returned so the caller can offer a verification resend.
 func (s *Service) Authenticate(ctx context.Context, email, password string) (*entity.User, error) {
 	u, err := s.Repo.GetByEmail(email)
 	if err != nil || u == nil {
-		return nil, ErrInvalidCredentials
+		return nil, notFound(err, ErrInvalidCredentials)
 	}
 	if !helpers.CompareHashAndPassword(u.Password, password) {
 		return nil, ErrInvalidCredentials
 	}
-	// Do not block on email verification here; email verification is triggered after login via protected endpoint.
+	if err := u.AccountError(); err != nil {
+		return nil, err
+	}
+	if !u.IsVerified {
+		switch s.VerifyPolicy {
+		case VerifyPolicyBlock:
+			return u, ErrEmailNotVerified
+		case VerifyPolicyWarn:
+			if s.Logger != nil {
+				helpers.FromContext(ctx).WithField("user_id", u.ID).Warn("login with unverified email")
+			}
+		}
+	}
 	return u, nil
 }
 
-// IssueTokens generates access/refresh tokens and records a session in Redis.
+// IssueTokens generates access/refresh tokens and records a session in the session store.
+// The session keeps the client set on ctx with WithSessionClient. A suspended or banned user
+// gets the account error instead.
 func (s *Service) IssueTokens(ctx context.Context, u *entity.User) (TokenPair, error) {
+	if err := u.AccountError(); err != nil {
+		return TokenPair{}, err
+	}
 	sid := uuid.NewString()
 	access, aexp, err := s.JWT.GenerateAccessToken(u.ID, sid)
 	if err != nil {
 		if s.Logger != nil {
-			s.Logger.WithError(err).WithField("user_id", u.ID).Error("generate access token failed")
+			helpers.FromContext(ctx).WithError(err).WithField("user_id", u.ID).Error("generate access token failed")
 		}
 		return TokenPair{}, err
 	}
 	refresh, rexp, err := s.JWT.GenerateRefreshToken(u.ID, sid)
 	if err != nil {
 		if s.Logger != nil {
-			s.Logger.WithError(err).WithField("user_id", u.ID).Error("generate refresh token failed")
+			helpers.FromContext(ctx).WithError(err).WithField("user_id", u.ID).Error("generate refresh token failed")
 		}
 		return TokenPair{}, err
 	}
 
-	if s.Redis != nil {
-		fields := map[string]any{
-			"user_id":    u.ID,
-			"email":      u.Email,
-			"name":       u.Name,
-			"avatar_url": u.AvatarURL,
-			"sid":        sid,
-			"logged_in":  true,
-			"created_at": nowRFC3339(),
+	// Every session is bound to a device; clients without a device_id get a fresh one
+	cl := sessionClientFrom(ctx)
+	if cl.DeviceID == "" {
+		if cl.DeviceID, err = newDeviceID(); err != nil {
+			return TokenPair{}, err
+		}
+	}
+	if s.Sessions != nil {
+		sess := &entity.Session{
+			ID: sid, UserID: u.ID, Email: u.Email, Name: u.Name, AvatarURL: u.AvatarURL,
+			IP: cl.IP, UserAgent: cl.UserAgent, Location: cl.Location, DeviceID: cl.DeviceID,
+			Binding: SessionBinding(cl.UserAgent, cl.DeviceID),
 		}
-		key := sessionKey(u.ID)
-		pipe := s.Redis.Pipeline()
-		pipe.HSet(ctx, key, fields)
-		pipe.Expire(ctx, key, 24*time.Hour)
-		if _, rErr := pipe.Exec(ctx); rErr != nil && s.Logger != nil {
-			s.Logger.WithError(rErr).WithField("key", key).Warn("redis pipeline failed")
+		if sErr := s.Sessions.Create(ctx, sess, s.sessionTTL()); sErr != nil && s.Logger != nil {
+			helpers.FromContext(ctx).WithError(sErr).WithField("user_id", u.ID).Warn("session create failed")
 		}
 	}
 
-	return TokenPair{AccessToken: access, AccessTokenExpiry: aexp, RefreshToken: refresh, RefreshTokenExpiry: rexp}, nil
+	return TokenPair{AccessToken: access, AccessTokenExpiry: aexp, RefreshToken: refresh, RefreshTokenExpiry: rexp, DeviceID: cl.DeviceID}, nil
 }
 
 func (s *Service) Login(ctx context.Context, email, password string) (*LoginResponse, TokenPair, error) {
@@ -142,27 +224,56 @@ func (s *Service) Login(ctx context.Context, email, password string) (*LoginResp
 func (s *Service) GetUserByEmail(ctx context.Context, email string) (*entity.User, error) {
 	u, err := s.Repo.GetByEmail(email)
 	if err != nil || u == nil {
-		return nil, ErrUserNotFound
+		return nil, notFound(err, ErrUserNotFound)
 	}
 	return u, nil
 }
 
+// Refresh rotates the session behind refreshToken and returns the new pair and the user id. With
+// Redis and RefreshGracePeriod, concurrent refreshes of one session are serialized and the losers
+// get the pair the winner rotated to (see refreshShared).
 func (s *Service) Refresh(ctx context.Context, refreshToken string) (TokenPair, string, error) {
 	claims, err := s.JWT.ParseRefreshToken(refreshToken)
 	if err != nil {
 		return TokenPair{}, "", ErrInvalidCredentials
 	}
+	if s.Redis != nil && s.RefreshGrace > 0 && s.Sessions != nil {
+		return s.refreshShared(ctx, claims)
+	}
+	pair, _, err := s.rotate(ctx, claims)
+	if err != nil {
+		return TokenPair{}, "", err
+	}
+	return pair, claims.UserID, nil
+}
+
+// rotate checks the session of claims and replaces it (and its tokens) with a new session id,
+// which it returns along with the pair
+func (s *Service) rotate(ctx context.Context, claims *helpers.Claims) (TokenPair, string, error) {
 	u, err := s.Repo.GetByID(claims.UserID)
 	if err != nil || u == nil {
-		return TokenPair{}, "", ErrInvalidCredentials
+		return TokenPair{}, "", notFound(err, ErrInvalidCredentials)
+	}
+	if err := u.AccountError(); err != nil {
+		return TokenPair{}, "", err
 	}
 	// Validate current session id matches the token's sid
-	if s.Redis != nil {
-		key := sessionKey(u.ID)
-		data, rErr := s.Redis.HGetAll(ctx, key).Result()
-		if rErr != nil || len(data) == 0 || data["sid"] != claims.SessionID {
+	ttl := s.sessionTTL()
+	var sess *entity.Session
+	if s.Sessions != nil {
+		if sess, err = s.Sessions.Get(ctx, u.ID, claims.SessionID); err != nil {
 			return TokenPair{}, "", ErrInvalidCredentials
 		}
+		if !s.bindingMatches(ctx, sess) {
+			return TokenPair{}, "", ErrInvalidCredentials
+		}
+		// A refresh never extends a sliding session past its absolute lifetime
+		if s.Policy.Sliding {
+			var ok bool
+			if ttl, ok = s.Policy.NextTTL(sess.CreatedAt, time.Now()); !ok {
+				return TokenPair{}, "", ErrInvalidCredentials
+			}
+		}
 	}
 	// Rotate session id and tokens
 	sid := uuid.NewString()
@@ -174,23 +285,23 @@ func (s *Service) Refresh(ctx context.Context, refreshToken string) (TokenPair,
 	if err != nil {
 		return TokenPair{}, "", err
 	}
-	if s.Redis != nil {
-		key := sessionKey(u.ID)
-		pipe := s.Redis.Pipeline()
-		pipe.HSet(ctx, key, map[string]any{
-			"sid":        sid,
-			"updated_at": nowRFC3339(),
-		})
-		pipe.Expire(ctx, key, 24*time.Hour)
-		_, _ = pipe.Exec(ctx)
-	}
-	return TokenPair{AccessToken: access, AccessTokenExpiry: aexp, RefreshToken: refresh, RefreshTokenExpiry: rexp}, u.ID, nil
+	if sess != nil {
+		// Same login (CreatedAt kept), new sid: the old tokens stop working
+		sess.ID, sess.UpdatedAt = sid, time.Now().UTC()
+		sess.Email, sess.Name, sess.AvatarURL = u.Email, u.Name, u.AvatarURL
+		_ = s.Sessions.Create(ctx, sess, ttl)
+	}
+	pair := TokenPair{AccessToken: access, AccessTokenExpiry: aexp, RefreshToken: refresh, RefreshTokenExpiry: rexp}
+	if sess != nil {
+		pair.DeviceID = sess.DeviceID
+	}
+	return pair, sid, nil
 }
 
 func (s *Service) GetProfile(userID string) (*entity.User, error) {
 	u, err := s.Repo.GetByID(userID)
 	if err != nil || u == nil {
-		return nil, ErrUserNotFound
+		return nil, notFound(err, ErrUserNotFound)
 	}
 	return u, nil
 }
@@ -204,37 +315,33 @@ type UpdateProfileInput struct {
 func (s *Service) UpdateProfile(ctx context.Context, userID string, in UpdateProfileInput) (*entity.User, error) {
 	u, err := s.Repo.GetByID(userID)
 	if err != nil || u == nil {
-		return nil, ErrUserNotFound
+		return nil, notFound(err, ErrUserNotFound)
 	}
 	if in.Name != "" {
 		u.Name = in.Name
 	}
-	if in.AvatarURL != "" {
-		u.AvatarURL = in.AvatarURL
+	if in.AvatarURL != "" && in.AvatarURL != u.AvatarURL {
+		u.SetAvatar(in.AvatarURL, "")
 	}
-	if err := s.Repo.Update(u); err != nil {
+	if err := s.Repo.Update(ctx, u); err != nil {
 		return nil, err
 	}
 
-	if s.Redis != nil {
-		key := sessionKey(u.ID)
-		pipe := s.Redis.Pipeline()
-		pipe.HSet(ctx, key, map[string]any{
-			"name":       u.Name,
-			"avatar_url": u.AvatarURL,
-			"updated_at": nowRFC3339(),
-		})
+	s.refreshSessionProfile(ctx, u)
+	return u, nil
+}
 
-		if ttl, tErr := s.Redis.TTL(ctx, key).Result(); tErr == nil && ttl > 0 {
-			pipe.Expire(ctx, key, ttl)
-		}
-		if _, pErr := pipe.Exec(ctx); pErr != nil && s.Logger != nil {
-			s.Logger.WithError(pErr).WithField("key", key).Warn("redis pipeline failed")
+// ChangeEmail stores a confirmed new address (see ProfileChangeService); the new address proved
+// reachable, so the verified flag stands
+func (s *Service) ChangeEmail(ctx context.Context, u *entity.User, email string) (*entity.User, error) {
+	u.Email = email
+	if err := s.Repo.Update(ctx, u); err != nil {
+		if errors.Is(err, repo.ErrConflict) {
+			return nil, ErrProfileChangeEmailTaken
 		}
+		return nil, err
 	}
-
-	// Index latest profile to Elasticsearch
-	_ = s.indexUser(ctx, u)
+	s.refreshSessionProfile(ctx, u)
 	return u, nil
 }
 
@@ -242,119 +349,60 @@ func (s *Service) UpdateProfile(ctx context.Context, userID string, in UpdatePro
 func (s *Service) UploadAvatar(ctx context.Context, userID string, r io.Reader, filename, contentType string) (string, error) {
 	u, err := s.Repo.GetByID(userID)
 	if err != nil || u == nil {
-		return "", ErrUserNotFound
+		return "", notFound(err, ErrUserNotFound)
 	}
-	url, err := s.uploadImageToGCS(ctx, userID, r, filename, contentType)
+	url, objectPath, err := s.uploadImageToGCS(ctx, userID, r, filename, contentType)
 	if err != nil {
 		return "", err
 	}
-	u.AvatarURL = url
-	if err := s.Repo.Update(u); err != nil {
+	u.SetAvatar(url, objectPath)
+	if err := s.Repo.Update(ctx, u); err != nil {
 		return "", err
 	}
-	// cache meta in redis (optional)
-	if s.Redis != nil {
-		key := "user:session:" + u.ID
-		s.Redis.HSet(ctx, key, map[string]any{
-			"avatar_url": u.AvatarURL,
-			"updated_at": nowRFC3339(),
-		})
-	}
-	// Re-index
-	_ = s.indexUser(ctx, u)
+	s.refreshSessionProfile(ctx, u)
 	return url, nil
 }
 
-func (s *Service) uploadImageToGCS(ctx context.Context, userID string, r io.Reader, filename, contentType string) (string, error) {
-	if s.GCS == nil || s.GCSBucket == "" {
-		return "", errors.New("gcs not configured")
+// refreshSessionProfile updates the profile fields cached in the user's sessions, keeping their expiry
+func (s *Service) refreshSessionProfile(ctx context.Context, u *entity.User) {
+	if s.Sessions == nil {
+		return
 	}
-	id := uuid.NewString()
-	ext := strings.ToLower(filepath.Ext(filename))
-	objectPath := filepath.ToSlash(filepath.Join("avatars", userID, id+ext))
-	return helpers.UploadImageToGCS(ctx, s.GCS, s.GCSBucket, objectPath, contentType, r)
-}
-
-func (s *Service) indexUser(ctx context.Context, u *entity.User) error {
-	if s.ES == nil || s.ESUsersIndex == "" {
-		return nil
-	}
-	doc := map[string]any{
-		"id":         u.ID,
-		"email":      u.Email,
-		"name":       u.Name,
-		"avatar_url": u.AvatarURL,
-		"created_at": u.CreatedAt.Format(time.RFC3339Nano),
-		"updated_at": u.UpdatedAt.Format(time.RFC3339Nano),
-	}
-	b, _ := json.Marshal(doc)
-	req := esapi.IndexRequest{Index: s.ESUsersIndex, DocumentID: u.ID, Body: strings.NewReader(string(b)), Refresh: "false"}
-	c, cancel := context.WithTimeout(ctx, 3*time.Second)
-	defer cancel()
-	res, err := req.Do(c, s.ES)
+	sessions, err := s.Sessions.ListByUser(ctx, u.ID)
 	if err != nil {
 		if s.Logger != nil {
-			s.Logger.WithError(err).WithField("user_id", u.ID).Warn("es index failed")
+			helpers.FromContext(ctx).WithError(err).WithField("user_id", u.ID).Warn("session lookup failed")
+		}
+		return
+	}
+	for _, sess := range sessions {
+		ttl := s.sessionTTL()
+		if !sess.ExpiresAt.IsZero() {
+			if ttl = time.Until(sess.ExpiresAt); ttl <= 0 {
+				continue
+			}
+		}
+		sess.Email, sess.Name, sess.AvatarURL, sess.UpdatedAt = u.Email, u.Name, u.AvatarURL, time.Now().UTC()
+		if err := s.Sessions.Create(ctx, &sess, ttl); err != nil && s.Logger != nil {
+			helpers.FromContext(ctx).WithError(err).WithField("user_id", u.ID).Warn("session update failed")
 		}
-		return err
-	}
-	defer func() { _ = res.Body.Close() }()
-	if res.IsError() && s.Logger != nil {
-		s.Logger.WithField("status", res.Status()).WithField("user_id", u.ID).Warn("es index response error")
 	}
-	return nil
 }
 
-// SearchUsers performs a simple multi_match search on email and name.
-func (s *Service) SearchUsers(ctx context.Context, q string, size int) ([]map[string]any, error) {
-	if s.ES == nil || s.ESUsersIndex == "" {
-		return []map[string]any{}, nil
-	}
-	if size <= 0 || size > 50 {
-		size = 10
-	}
-	query := map[string]any{
-		"query": map[string]any{
-			"multi_match": map[string]any{
-				"query":  q,
-				"fields": []string{"email^2", "name"},
-			},
-		},
-		"size": size,
+// uploadImageToGCS stores the image as avatars/<user>/<uuid><ext> and returns its public URL and object path
+func (s *Service) uploadImageToGCS(ctx context.Context, userID string, r io.Reader, filename, contentType string) (string, string, error) {
+	if s.GCS == nil || s.GCSBucket == "" {
+		return "", "", errors.New("gcs not configured")
 	}
-	b, _ := json.Marshal(query)
-
-	c, cancel := context.WithTimeout(ctx, 3*time.Second)
-	defer cancel()
-
-	res, err := s.ES.Search(s.ES.Search.WithContext(c), s.ES.Search.WithIndex(s.ESUsersIndex), s.ES.Search.WithBody(strings.NewReader(string(b))))
-
+	id := uuid.NewString()
+	ext := strings.ToLower(filepath.Ext(filename))
+	objectPath := filepath.ToSlash(filepath.Join("avatars", userID, id+ext))
+	url, err := helpers.UploadImageToGCS(ctx, s.GCS, s.GCSBucket, objectPath, contentType, r)
 	if err != nil {
-		return nil, err
+		return "", "", err
 	}
-
-	defer func() {
-		_ = res.Body.Close()
-	}()
-
-	var parsed struct {
-		Hits struct {
-			Hits []struct {
-				ID     string         `json:"_id"`
-				Source map[string]any `json:"_source"`
-			} `json:"hits"`
-		} `json:"hits"`
-	}
-
-	if err := json.NewDecoder(res.Body).Decode(&parsed); err != nil {
-		return nil, err
-	}
-
-	out := make([]map[string]any, 0, len(parsed.Hits.Hits))
-
-	for _, h := range parsed.Hits.Hits {
-		out = append(out, h.Source)
-	}
-
-	return out, nil
+	return url, objectPath, nil
 }
+
+// UserSearchFields are the document fields of the users index a search can select
+var UserSearchFields = []string{"id", "email", "name", "avatar_url", "created_at", "updated_at"}
@@ -8,8 +8,11 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/oksasatya/go-ddd-clean-architecture/config"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/worker"
 	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
 	"github.com/oksasatya/go-ddd-clean-architecture/pkg/mailer"
+	mailtpl "github.com/oksasatya/go-ddd-clean-architecture/pkg/mailer/templates"
 )
 
 // app-level container to share constructed components across packages
@@ -27,6 +30,26 @@ var (
 	mailgunClient *mailer.Mailgun
 	rabbitPub     *helpers.RabbitPublisher
 	esClient      *elasticsearch.Client
+	searchService repository.SearchService
+	searchCanary  repository.SearchService
+	features      *helpers.Features
+	geoResolver   mailtpl.GeoResolver
+	drainState    *helpers.DrainState
+	eventBus      *helpers.EventBus
+	sessionStore  repository.SessionStore
+	tokenAuth     repository.TokenAuthenticator
+	exportRunner  worker.ExportRunner
+	redisBatch    *helpers.RedisBatch
+	latency       *helpers.LatencyHistograms
+	authMetrics   *helpers.AuthMetrics
+	userEventPub  *helpers.RabbitPublisher
+	invalidations *helpers.SessionInvalidations
+	corsOrigins   *helpers.CORSOrigins
+	rateBypass    *helpers.RateBypass
+	breakers      *helpers.Breakers
+	dependencies  *helpers.DependencyMonitor
+	shutdown      *helpers.ShutdownRegistry
+	startup       *helpers.StartupReport
 )
 
 func SetConfig(c *config.Config)   { cfg = c }
@@ -53,3 +76,81 @@ func SetRabbitPub(p *helpers.RabbitPublisher) { rabbitPub = p }
 func GetRabbitPub() *helpers.RabbitPublisher  { return rabbitPub }
 func SetES(c *elasticsearch.Client)           { esClient = c }
 func GetES() *elasticsearch.Client            { return esClient }
+
+// SetSearch sets the user search backend chosen by SEARCH_BACKEND (nil = search finds nothing)
+func SetSearch(s repository.SearchService) { searchService = s }
+func GetSearch() repository.SearchService  { return searchService }
+
+// SetSearchCanary sets the SEARCH_CANARY_BACKEND search, used while the search_canary flag is on
+func SetSearchCanary(s repository.SearchService) { searchCanary = s }
+func GetSearchCanary() repository.SearchService  { return searchCanary }
+
+// SetFeatures holds the feature flag defaults (FEATURE_FLAGS); requests may override them
+func SetFeatures(f *helpers.Features) { features = f }
+func GetFeatures() *helpers.Features  { return features }
+
+func SetGeo(g mailtpl.GeoResolver) { geoResolver = g }
+func GetGeo() mailtpl.GeoResolver {
+	if geoResolver != nil {
+		return geoResolver
+	}
+	return mailtpl.NoopResolver{}
+}
+
+func SetDrain(d *helpers.DrainState) { drainState = d }
+func GetDrain() *helpers.DrainState  { return drainState }
+
+func SetEventBus(b *helpers.EventBus) { eventBus = b }
+func GetEventBus() *helpers.EventBus  { return eventBus }
+
+func SetSessionStore(s repository.SessionStore) { sessionStore = s }
+func GetSessionStore() repository.SessionStore  { return sessionStore }
+
+// Personal access tokens for the auth middleware; nil = not accepted
+func SetTokenAuthenticator(a repository.TokenAuthenticator) { tokenAuth = a }
+func GetTokenAuthenticator() repository.TokenAuthenticator  { return tokenAuth }
+
+// Batched Redis reads (session listings, trusted-device checks) with their pipeline metrics
+func SetRedisBatch(b *helpers.RedisBatch) { redisBatch = b }
+func GetRedisBatch() *helpers.RedisBatch  { return redisBatch }
+
+// Background export runner for the exports queue of the embedded worker; nil = exports not configured
+func SetExportRunner(r worker.ExportRunner) { exportRunner = r }
+func GetExportRunner() worker.ExportRunner  { return exportRunner }
+
+func SetSessionInvalidations(s *helpers.SessionInvalidations) { invalidations = s }
+func GetSessionInvalidations() *helpers.SessionInvalidations  { return invalidations }
+
+// SetBreakers holds the circuit breakers of optional dependencies (search, email, geo)
+func SetBreakers(b *helpers.Breakers) { breakers = b }
+func GetBreakers() *helpers.Breakers  { return breakers }
+
+// SetDependencies holds the background dependency probes used for load shedding (nil = off)
+func SetDependencies(m *helpers.DependencyMonitor) { dependencies = m }
+func GetDependencies() *helpers.DependencyMonitor  { return dependencies }
+
+func SetCORSOrigins(o *helpers.CORSOrigins) { corsOrigins = o }
+func GetCORSOrigins() *helpers.CORSOrigins  { return corsOrigins }
+
+// SetRateBypass sets the runtime rate limit allowlist consulted by the Registry's limiters
+func SetRateBypass(b *helpers.RateBypass) { rateBypass = b }
+func GetRateBypass() *helpers.RateBypass  { return rateBypass }
+
+func SetLatency(l *helpers.LatencyHistograms) { latency = l }
+func GetLatency() *helpers.LatencyHistograms  { return latency }
+
+// SetAuthMetrics sets the login outcome counters (nil = off, with METRICS_ENABLED=false)
+func SetAuthMetrics(m *helpers.AuthMetrics) { authMetrics = m }
+func GetAuthMetrics() *helpers.AuthMetrics  { return authMetrics }
+
+// SetUserEventPub sets the publisher for the user events exchange (nil = events off)
+func SetUserEventPub(p *helpers.RabbitPublisher) { userEventPub = p }
+func GetUserEventPub() *helpers.RabbitPublisher  { return userEventPub }
+
+// SetShutdown sets the registry components register their Close with; main runs it on shutdown
+func SetShutdown(s *helpers.ShutdownRegistry) { shutdown = s }
+func GetShutdown() *helpers.ShutdownRegistry  { return shutdown }
+
+// SetStartup sets the report main logs once the routes are registered (GET /api/admin/config)
+func SetStartup(s *helpers.StartupReport) { startup = s }
+func GetStartup() *helpers.StartupReport  { return startup }
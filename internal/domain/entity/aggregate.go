@@ -0,0 +1,24 @@
+package entity
+
+// DomainEvent is something that happened to an aggregate, named like "user.email_changed"
+type DomainEvent interface {
+	EventName() string
+}
+
+// AggregateRoot collects domain events raised by an aggregate's methods until the repository
+// that saves it pulls and dispatches them after commit. Embed it by value.
+type AggregateRoot struct {
+	events []DomainEvent
+}
+
+// RecordEvent queues e for dispatch once the aggregate is saved
+func (a *AggregateRoot) RecordEvent(e DomainEvent) {
+	a.events = append(a.events, e)
+}
+
+// PullEvents returns the queued events and clears them
+func (a *AggregateRoot) PullEvents() []DomainEvent {
+	evs := a.events
+	a.events = nil
+	return evs
+}
@@ -0,0 +1,29 @@
+package entity
+
+import "time"
+
+// AuditLog is one security-relevant action (verification, password reset, ...)
+type AuditLog struct {
+	ID        int64
+	UserID    string
+	Email     string
+	Action    string
+	IP        string
+	UserAgent string
+	Metadata  map[string]any
+	CreatedAt time.Time
+	// EventID identifies the entry before it is stored, so retried writes are not duplicated
+	EventID string
+	// CorrelationID ties the entry to the other steps of its auth flow (helpers.WithCorrelationID)
+	CorrelationID string
+}
+
+// AuditFilter narrows audit log listings; zero values match everything
+type AuditFilter struct {
+	Action string
+	UserID string
+	From   time.Time
+	To     time.Time
+	// CorrelationID matches the entries of one auth flow
+	CorrelationID string
+}
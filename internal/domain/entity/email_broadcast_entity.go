@@ -0,0 +1,57 @@
+package entity
+
+import "time"
+
+// Broadcast audiences; every audience is limited to verified, active users
+const (
+	BroadcastVerified = "verified" // all of them
+	BroadcastRole     = "role"     // holders of a role
+	BroadcastOrg      = "org"      // members of an organization
+)
+
+// Broadcast states
+const (
+	BroadcastQueued    = "queued"
+	BroadcastRunning   = "running"
+	BroadcastDone      = "done"
+	BroadcastFailed    = "failed"
+	BroadcastCancelled = "cancelled"
+)
+
+// BroadcastAudience selects the recipients of a broadcast; Role and OrgID apply to their audience
+type BroadcastAudience struct {
+	Type  string
+	Role  string
+	OrgID string
+}
+
+// EmailBroadcast is an admin email sent to an audience by a background worker. Message holds the
+// email as JSON; Total is counted when a worker starts it, and LastUserID is how far it got (the
+// audience is walked in user id order), so a broadcast cut short resumes after it.
+type EmailBroadcast struct {
+	ID          string
+	State       string
+	Audience    BroadcastAudience
+	Message     []byte
+	Total       int64
+	Enqueued    int64
+	Skipped     int64 // opted out of announcements
+	Failed      int64
+	LastUserID  string
+	Error       string
+	Attempts    int
+	RequestedBy string
+	CreatedAt   time.Time
+	StartedAt   *time.Time
+	FinishedAt  *time.Time
+}
+
+// Processed is how many recipients the broadcast has handled
+func (b *EmailBroadcast) Processed() int64 { return b.Enqueued + b.Skipped + b.Failed }
+
+// BroadcastRecipient is a user a broadcast is sent to
+type BroadcastRecipient struct {
+	ID    string
+	Email string
+	Name  string
+}
@@ -0,0 +1,40 @@
+package entity
+
+import "time"
+
+// Email status events, normalized from the provider's event names
+const (
+	EmailDelivered    = "delivered"
+	EmailBounced      = "bounced"  // permanent failure: the address does not accept mail
+	EmailDeferred     = "deferred" // temporary failure; the provider keeps retrying
+	EmailComplained   = "complained"
+	EmailUnsubscribed = "unsubscribed"
+)
+
+// Forwarding states of an email event
+const (
+	EmailForwardNone      = "none" // forwarding was off when the event arrived
+	EmailForwardPending   = "pending"
+	EmailForwardForwarded = "forwarded"
+	EmailForwardFailed    = "failed" // gave up after the maximum attempts
+)
+
+// EmailEvent is a delivery outcome reported by the email provider. Variables are the custom
+// variables the email was sent with (user_id, request_id, correlation_id and whatever the
+// producing service added), which let the service match the event to what it sent.
+type EmailEvent struct {
+	ID              int64
+	Provider        string
+	ProviderEventID string
+	Event           string
+	Recipient       string
+	MessageID       string
+	Reason          string // bounce or complaint detail from the provider
+	Variables       map[string]string
+	CorrelationID   string // auth flow of the email, from Variables["correlation_id"]; "" outside a flow
+	OccurredAt      time.Time
+	CreatedAt       time.Time
+	ForwardState    string
+	ForwardAttempts int
+	LastError       string
+}
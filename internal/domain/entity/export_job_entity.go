@@ -0,0 +1,35 @@
+package entity
+
+import "time"
+
+// Export kinds
+const (
+	ExportUsers = "users"
+	ExportAudit = "audit"
+)
+
+// Export job states
+const (
+	ExportQueued  = "queued"
+	ExportRunning = "running"
+	ExportDone    = "done"
+	ExportFailed  = "failed"
+)
+
+// ExportJob is a background export written to object storage. Params hold the kind's filter as
+// JSON; Total is counted when a worker starts the job and Processed grows as batches are written.
+type ExportJob struct {
+	ID          string
+	Kind        string
+	State       string
+	Params      []byte
+	Total       int64
+	Processed   int64
+	Object      string // object path, set once done
+	Error       string
+	Attempts    int
+	RequestedBy string
+	CreatedAt   time.Time
+	StartedAt   *time.Time
+	FinishedAt  *time.Time
+}
@@ -0,0 +1,13 @@
+package entity
+
+import "time"
+
+// Identity links an external login (OIDC/SAML subject) to a local user
+type Identity struct {
+	ID             string
+	UserID         string
+	Provider       string
+	ProviderUserID string
+	Email          string
+	CreatedAt      time.Time
+}
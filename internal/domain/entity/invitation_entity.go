@@ -0,0 +1,17 @@
+package entity
+
+import "time"
+
+// Invitation is a single-use invite to create an account with a role and/or organization membership
+type Invitation struct {
+	ID         string
+	Email      string
+	Role       string
+	OrgID      string
+	OrgRole    string
+	InvitedBy  string
+	ExpiresAt  time.Time
+	AcceptedAt *time.Time
+	RevokedAt  *time.Time
+	CreatedAt  time.Time
+}
@@ -0,0 +1,36 @@
+package entity
+
+import "time"
+
+// Organization groups users into a team; resources can be scoped by OrgID
+type Organization struct {
+	ID        string
+	Name      string
+	Slug      string
+	CreatedBy string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// OrgMember is a user's membership and role (owner, admin, member) in an organization
+type OrgMember struct {
+	OrgID     string
+	UserID    string
+	Email     string
+	Name      string
+	Role      string
+	CreatedAt time.Time
+}
+
+// OrgLimits overrides the default quotas for one organization; nil uses the default, 0 is unlimited
+type OrgLimits struct {
+	RatePerMinute  *int
+	RequestsPerDay *int
+	EmailsPerDay   *int
+}
+
+// OrgMembership is an organization as seen by one of its members
+type OrgMembership struct {
+	Organization
+	Role string
+}
@@ -0,0 +1,7 @@
+package entity
+
+// Page selects a window of an ordered listing
+type Page struct {
+	Limit  int
+	Offset int
+}
@@ -0,0 +1,12 @@
+package entity
+
+import "time"
+
+// Permission represents a named capability (e.g. "users:read")
+// Many-to-many with Role via role_permissions
+type Permission struct {
+	ID        string
+	Name      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
@@ -0,0 +1,26 @@
+package entity
+
+import "time"
+
+// PersonalTokenPrefix starts every personal access token, so the auth middleware can tell them from JWTs
+const PersonalTokenPrefix = "pat_"
+
+// PersonalToken is a personal access token a user created for scripting against the API. The token
+// itself is shown once at creation; only its SHA-256 is stored, with Prefix to tell tokens apart.
+type PersonalToken struct {
+	ID         string
+	UserID     string
+	Name       string
+	Prefix     string
+	Scopes     []string
+	ExpiresAt  time.Time
+	LastUsedAt *time.Time
+	LastUsedIP string
+	RevokedAt  *time.Time
+	CreatedAt  time.Time
+}
+
+// Active reports whether the token can still authenticate at now
+func (t PersonalToken) Active(now time.Time) bool {
+	return t.RevokedAt == nil && now.Before(t.ExpiresAt)
+}
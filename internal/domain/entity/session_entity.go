@@ -0,0 +1,22 @@
+package entity
+
+import "time"
+
+// Session is a signed-in user's session; access and refresh tokens carry its ID (sid).
+// Profile fields are cached so authenticated requests need no database lookup.
+type Session struct {
+	ID        string
+	UserID    string
+	Email     string
+	Name      string
+	AvatarURL string
+	// Client the session was issued to, so users can recognize their devices
+	IP        string
+	UserAgent string
+	Location  string    // "City, Region, Country" when geo lookup succeeded
+	DeviceID  string    // trusted-device cookie at login, empty when none
+	Binding   string    // hash of the client family and device id; a refresh must present the same
+	CreatedAt time.Time // login time; bounds sliding expiration
+	UpdatedAt time.Time
+	ExpiresAt time.Time // zero when the store keeps no expiry
+}
@@ -0,0 +1,24 @@
+package entity
+
+import "time"
+
+// UsageRecord is the API usage of one subject on one route within an hourly bucket
+type UsageRecord struct {
+	Bucket   time.Time
+	Subject  string
+	Method   string
+	Route    string
+	Requests int64
+	BytesIn  int64
+	BytesOut int64
+}
+
+// UsageSummary aggregates usage over a period, grouped by subject or by route (the other fields are empty)
+type UsageSummary struct {
+	Subject  string
+	Method   string
+	Route    string
+	Requests int64
+	BytesIn  int64
+	BytesOut int64
+}
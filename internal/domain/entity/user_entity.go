@@ -1,15 +1,24 @@
 package entity
 
 import (
+	"errors"
 	"time"
 )
 
+var (
+	ErrAccountSuspended = errors.New("account suspended")
+	ErrAccountBanned    = errors.New("account banned")
+)
+
 // User is the aggregate root for user domain
 // Passwords are stored as bcrypt hashes in Password field
 //
 // In a real-world app, prefer value objects for Email, etc.
 type User struct {
+	AggregateRoot
+
 	ID         string
+	ExternalID string // public ULID shown by the API in place of ID; assigned on create
 	Email      string
 	Password   string
 	Name       string
@@ -17,4 +26,71 @@ type User struct {
 	IsVerified bool
 	CreatedAt  time.Time
 	UpdatedAt  time.Time
+
+	// AvatarObjectPath is the stored object behind AvatarURL (e.g. avatars/<id>/<uuid>.png); empty
+	// for external avatars. AvatarURL is derived from it on upload; set both through SetAvatar.
+	AvatarObjectPath string
+
+	// Account status; a ban outranks a suspension
+	SuspendedAt      *time.Time
+	BannedAt         *time.Time
+	SuspensionReason string
+}
+
+// SetAvatar points the avatar at url, stored as objectPath ("" for an external URL)
+func (u *User) SetAvatar(url, objectPath string) {
+	u.AvatarURL, u.AvatarObjectPath = url, objectPath
+}
+
+// AccountError is ErrAccountBanned or ErrAccountSuspended while the account is blocked, nil otherwise
+func (u *User) AccountError() error {
+	switch {
+	case u.BannedAt != nil:
+		return ErrAccountBanned
+	case u.SuspendedAt != nil:
+		return ErrAccountSuspended
+	}
+	return nil
+}
+
+// EmailChanged is raised by User.ChangeEmail
+type EmailChanged struct {
+	UserID   string
+	OldEmail string
+	NewEmail string
+	At       time.Time
+}
+
+func (EmailChanged) EventName() string { return "user.email_changed" }
+
+// PasswordChanged is raised by User.ChangePassword; it never carries the hash
+type PasswordChanged struct {
+	UserID string
+	At     time.Time
+}
+
+func (PasswordChanged) EventName() string { return "user.password_changed" }
+
+// ChangeEmail sets a new email and records EmailChanged; the same address is a no-op
+func (u *User) ChangeEmail(email string) {
+	if email == u.Email {
+		return
+	}
+	old := u.Email
+	u.Email = email
+	u.RecordEvent(EmailChanged{UserID: u.ID, OldEmail: old, NewEmail: email, At: time.Now()})
+}
+
+// ChangePassword sets a new bcrypt hash and records PasswordChanged
+func (u *User) ChangePassword(hash string) {
+	u.Password = hash
+	u.RecordEvent(PasswordChanged{UserID: u.ID, At: time.Now()})
+}
+
+// UserFilter narrows user listings; zero values match everything
+type UserFilter struct {
+	EmailPrefix string
+	Verified    *bool
+	CreatedFrom time.Time // inclusive
+	CreatedTo   time.Time // exclusive
 }
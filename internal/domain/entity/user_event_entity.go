@@ -0,0 +1,29 @@
+package entity
+
+import "time"
+
+// User history event types; replaying them in Seq order rebuilds the user's state
+const (
+	UserEventCreated         = "created"
+	UserEventUpdated         = "updated"
+	UserEventPasswordChanged = "password_changed"
+	UserEventVerified        = "verified"
+	UserEventStatusChanged   = "status_changed"
+)
+
+// FieldChange is one field's value before and after a change; secrets are recorded as "[redacted]"
+type FieldChange struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// UserEvent is one append-only entry in a user's history
+type UserEvent struct {
+	ID        int64
+	UserID    string
+	Seq       int64
+	Type      string
+	Actor     string
+	Changes   map[string]FieldChange
+	CreatedAt time.Time
+}
@@ -0,0 +1,62 @@
+package entity
+
+import "time"
+
+// UserSearchQuery selects a page of user search results; Fields limits the returned fields
+type UserSearchQuery struct {
+	Q      string
+	From   int
+	Size   int // 1-50, default 10
+	Fields []string
+}
+
+// UserSummary is a user as search returns it, and the document the users index holds. With a
+// fields selection, unselected fields are left empty and omitted from JSON.
+type UserSummary struct {
+	ID        string     `json:"id,omitempty"`
+	Email     string     `json:"email,omitempty"`
+	Name      string     `json:"name,omitempty"`
+	AvatarURL string     `json:"avatar_url,omitempty"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// SummaryOf is the search document of u
+func SummaryOf(u *User) UserSummary {
+	created, updated := u.CreatedAt, u.UpdatedAt
+	return UserSummary{ID: u.ID, Email: u.Email, Name: u.Name, AvatarURL: u.AvatarURL, CreatedAt: &created, UpdatedAt: &updated}
+}
+
+// Select keeps only the named fields (all of them when fields is empty)
+func (s UserSummary) Select(fields []string) UserSummary {
+	if len(fields) == 0 {
+		return s
+	}
+	var out UserSummary
+	for _, f := range fields {
+		switch f {
+		case "id":
+			out.ID = s.ID
+		case "email":
+			out.Email = s.Email
+		case "name":
+			out.Name = s.Name
+		case "avatar_url":
+			out.AvatarURL = s.AvatarURL
+		case "created_at":
+			out.CreatedAt = s.CreatedAt
+		case "updated_at":
+			out.UpdatedAt = s.UpdatedAt
+		}
+	}
+	return out
+}
+
+// UserSearchResult is one page of search hits with the total match count and the engine's time
+type UserSearchResult struct {
+	Items  []UserSummary `json:"items"`
+	Total  int64         `json:"total"`
+	From   int           `json:"from"`
+	Size   int           `json:"size"`
+	TookMs int           `json:"took_ms"`
+}
@@ -0,0 +1,18 @@
+package entity
+
+import "time"
+
+// Names of encrypted per-user values
+const (
+	SecretPhone      = "phone"
+	SecretTOTPSecret = "totp_secret"
+)
+
+// UserSecret is an encrypted per-user value as stored; only the application decrypts it
+type UserSecret struct {
+	UserID     string
+	Name       string
+	Ciphertext string
+	KeyID      string
+	UpdatedAt  time.Time
+}
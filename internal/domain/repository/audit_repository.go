@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+)
+
+// AuditRepository defines persistence for audit log entries.
+type AuditRepository interface {
+	// Insert stores the entry and sets its ID and CreatedAt
+	Insert(a *entity.AuditLog) error
+	// InsertBatch stores entries in one statement and returns the ones written, with ID and
+	// CreatedAt set; entries whose EventID is already stored are skipped
+	InsertBatch(ctx context.Context, entries []entity.AuditLog) ([]entity.AuditLog, error)
+	Count(f entity.AuditFilter) (int64, error)
+	// ListAfter returns up to limit entries with id > afterID in id order (keyset pagination)
+	ListAfter(f entity.AuditFilter, afterID int64, limit int) ([]entity.AuditLog, error)
+	// LastLoginTimezones maps each of userIDs to the IANA timezone of its latest login that
+	// resolved one; users without one are left out
+	LastLoginTimezones(ctx context.Context, userIDs []string) (map[string]string, error)
+}
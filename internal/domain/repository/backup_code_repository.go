@@ -0,0 +1,13 @@
+package repository
+
+import "context"
+
+// BackupCodeRepository stores hashed one-time 2FA recovery codes.
+type BackupCodeRepository interface {
+	// Replace drops the user's codes and stores hashes as the new unused set, atomically
+	Replace(ctx context.Context, userID string, hashes []string) error
+	// Use marks an unused code spent; false when no unused code has that hash
+	Use(ctx context.Context, userID, hash string) (bool, error)
+	// Remaining counts the user's unused codes
+	Remaining(ctx context.Context, userID string) (int, error)
+}
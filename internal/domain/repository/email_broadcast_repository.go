@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+)
+
+// EmailBroadcastRepository defines persistence for admin broadcast emails and their audiences.
+type EmailBroadcastRepository interface {
+	// Create queues b and sets its ID, State and CreatedAt
+	Create(ctx context.Context, b *entity.EmailBroadcast) error
+	Get(ctx context.Context, id string) (*entity.EmailBroadcast, error)
+	// Claim marks the oldest queued broadcast running and returns it, or a running one whose worker
+	// has not reported progress since staleBefore; ErrNotFound when there is none
+	Claim(ctx context.Context, staleBefore time.Time) (*entity.EmailBroadcast, error)
+	// Progress records the counts and LastUserID of b, which also serves as its heartbeat; false
+	// when b is no longer running (cancelled)
+	Progress(ctx context.Context, b *entity.EmailBroadcast) (bool, error)
+	// Finish stores the final state (done or failed), counts and error of a running b
+	Finish(ctx context.Context, b *entity.EmailBroadcast) error
+	// Cancel stops a queued or running broadcast; false when it had already finished
+	Cancel(ctx context.Context, id string) (bool, error)
+
+	// Recipients returns up to limit users of the audience after afterID, in id order
+	Recipients(ctx context.Context, a entity.BroadcastAudience, afterID string, limit int) ([]entity.BroadcastRecipient, error)
+	CountRecipients(ctx context.Context, a entity.BroadcastAudience) (int64, error)
+}
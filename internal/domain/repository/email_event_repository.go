@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+)
+
+// EmailEventRepository defines persistence for provider email events and their forwarding state.
+type EmailEventRepository interface {
+	// Record stores e; false when the provider event was recorded before
+	Record(ctx context.Context, e *entity.EmailEvent) (bool, error)
+	// ListByCorrelation returns up to limit events of one auth flow, oldest first
+	ListByCorrelation(ctx context.Context, correlationID string, limit int) ([]*entity.EmailEvent, error)
+	// ClaimForward returns up to limit pending events that are due, counting an attempt for each
+	// and leasing them until leaseUntil
+	ClaimForward(ctx context.Context, limit int, leaseUntil time.Time) ([]*entity.EmailEvent, error)
+	// Forwarded marks the event delivered to the webhook
+	Forwarded(ctx context.Context, id int64) error
+	// ForwardFailed records a failed attempt: retried at next, or given up when final
+	ForwardFailed(ctx context.Context, id int64, next time.Time, lastErr string, final bool) error
+}
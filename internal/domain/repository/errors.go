@@ -0,0 +1,50 @@
+package repository
+
+import "errors"
+
+// Error kinds infrastructure implementations map driver errors to, so services and handlers
+// branch on errors.Is(err, ErrNotFound) instead of pgx, pgconn or redis types.
+var (
+	ErrNotFound    = errors.New("not found")
+	ErrConflict    = errors.New("conflict")    // unique or foreign key violation, lost update
+	ErrUnavailable = errors.New("unavailable") // connection refused, timeout, pool closed
+	ErrInternal    = errors.New("internal error")
+)
+
+// Error is an infrastructure error classified as Kind; Err keeps the driver error for logs
+type Error struct {
+	Kind error
+	Err  error
+}
+
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return e.Kind.Error()
+	}
+	return e.Kind.Error() + ": " + e.Err.Error()
+}
+
+// Unwrap exposes both the kind and the driver error to errors.Is and errors.As
+func (e *Error) Unwrap() []error { return []error{e.Kind, e.Err} }
+
+// Wrap classifies err as kind; nil stays nil and an already classified error is kept as is
+func Wrap(kind, err error) error {
+	if err == nil {
+		return nil
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return err
+	}
+	return &Error{Kind: kind, Err: err}
+}
+
+// KindOf returns the kind err was classified as, or ErrInternal
+func KindOf(err error) error {
+	for _, k := range []error{ErrNotFound, ErrConflict, ErrUnavailable} {
+		if errors.Is(err, k) {
+			return k
+		}
+	}
+	return ErrInternal
+}
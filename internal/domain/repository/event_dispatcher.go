@@ -0,0 +1,11 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+)
+
+// EventDispatcher delivers domain events pulled from a saved aggregate. Repositories call it only
+// after the transaction commits, so handlers never see changes that were rolled back.
+type EventDispatcher func(ctx context.Context, events []entity.DomainEvent)
@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+)
+
+// ExportJobRepository defines persistence for background export jobs.
+type ExportJobRepository interface {
+	// Create queues j and sets its ID, State and CreatedAt
+	Create(ctx context.Context, j *entity.ExportJob) error
+	Get(ctx context.Context, id string) (*entity.ExportJob, error)
+	// Claim marks the oldest queued job running and returns it, or a running job whose worker has
+	// not reported progress since staleBefore; ErrNotFound when there is none
+	Claim(ctx context.Context, staleBefore time.Time) (*entity.ExportJob, error)
+	// Progress records how far a running job got, which also serves as its heartbeat
+	Progress(ctx context.Context, id string, total, processed int64) error
+	// Finish stores the final state (done or failed), object and error of j
+	Finish(ctx context.Context, j *entity.ExportJob) error
+}
@@ -0,0 +1,11 @@
+package repository
+
+import "github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+
+// IdentityRepository defines persistence for external identities linked to users.
+type IdentityRepository interface {
+	Create(i *entity.Identity) error
+	GetByProvider(provider, providerUserID string) (*entity.Identity, error)
+	ListByUser(userID string) ([]entity.Identity, error)
+	Delete(userID, provider string) error
+}
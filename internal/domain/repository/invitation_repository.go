@@ -0,0 +1,12 @@
+package repository
+
+import "github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+
+// InvitationRepository defines persistence for invitations; tokens are looked up by hash.
+type InvitationRepository interface {
+	Create(inv *entity.Invitation, tokenHash string) error
+	GetByTokenHash(tokenHash string) (*entity.Invitation, error)
+	List() ([]entity.Invitation, error)
+	MarkAccepted(id string) error
+	Revoke(id string) error
+}
@@ -0,0 +1,8 @@
+package repository
+
+// NotificationPreferenceRepository defines persistence for per-user email category opt-outs.
+type NotificationPreferenceRepository interface {
+	// List returns the stored preferences (category -> enabled); missing categories are enabled
+	List(userID string) (map[string]bool, error)
+	Set(userID, category string, enabled bool) error
+}
@@ -0,0 +1,21 @@
+package repository
+
+import "github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+
+// OrganizationRepository defines persistence for organizations and their members.
+type OrganizationRepository interface {
+	CreateWithOwner(org *entity.Organization, ownerID string) error
+	GetByID(id string) (*entity.Organization, error)
+	GetBySlug(slug string) (*entity.Organization, error)
+	ListByUser(userID string) ([]entity.OrgMembership, error)
+
+	AddMember(orgID, userID, role string) error
+	GetMember(orgID, userID string) (*entity.OrgMember, error)
+	ListMembers(orgID string) ([]entity.OrgMember, error)
+	UpdateMemberRole(orgID, userID, role string) error
+	RemoveMember(orgID, userID string) error
+	CountOwners(orgID string) (int64, error)
+
+	GetLimits(orgID string) (*entity.OrgLimits, error)
+	SetLimits(orgID string, limits entity.OrgLimits) error
+}
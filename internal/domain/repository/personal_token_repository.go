@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+)
+
+// PersonalTokenRepository persists personal access tokens; tokens are looked up by hash.
+type PersonalTokenRepository interface {
+	// Create stores t under tokenHash and fills in its ID and CreatedAt
+	Create(ctx context.Context, t *entity.PersonalToken, tokenHash string) error
+	// GetByHash returns the token, whatever its state; ErrNotFound when there is none
+	GetByHash(ctx context.Context, tokenHash string) (*entity.PersonalToken, error)
+	// ListByUser returns the user's tokens, newest first, including revoked and expired ones
+	ListByUser(ctx context.Context, userID string) ([]entity.PersonalToken, error)
+	// CountActive counts the user's tokens that are neither revoked nor expired
+	CountActive(ctx context.Context, userID string) (int, error)
+	// Revoke revokes one of the user's tokens; ErrNotFound when the user has no such active token
+	Revoke(ctx context.Context, userID, id string) error
+	// MarkUsed records a use from ip (stored at most once a minute)
+	MarkUsed(ctx context.Context, id, ip string) error
+}
+
+// TokenAuthenticator resolves a personal access token presented as a bearer token. The auth
+// middleware takes the application's implementation; nil means such tokens are not accepted.
+type TokenAuthenticator interface {
+	// Authenticate returns the token and its owner. An unknown, revoked or expired token fails
+	// with ErrNotFound, a blocked owner with entity.ErrAccountSuspended or entity.ErrAccountBanned.
+	Authenticate(ctx context.Context, token, ip string) (*entity.PersonalToken, *entity.User, error)
+}
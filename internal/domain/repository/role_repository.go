@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+)
+
+// RoleRepository defines the interface for roles, permissions and their assignments.
+type RoleRepository interface {
+	CreateRole(name string) (*entity.Role, error)
+	GetRoleByName(name string) (*entity.Role, error)
+	ListRoles() ([]entity.Role, error)
+	AssignRole(userID, roleID string) error
+	RevokeRole(userID, roleID string) error
+	GetUserRoles(userID string) ([]entity.Role, error)
+	CountUsersWithRole(name string) (int64, error)
+	// AssignRoleBulk grants the role to every user in one transaction and returns those that lacked it
+	AssignRoleBulk(ctx context.Context, roleID string, userIDs []string) ([]string, error)
+	// RevokeRoleBulk takes the role from every user in one transaction and returns those that had it
+	RevokeRoleBulk(ctx context.Context, roleID string, userIDs []string) ([]string, error)
+	// SyncRoleMembers makes userIDs exactly the role's members in one transaction
+	SyncRoleMembers(ctx context.Context, roleID string, userIDs []string) (added, removed []string, err error)
+
+	CreatePermission(name string) (*entity.Permission, error)
+	GetPermissionByName(name string) (*entity.Permission, error)
+	ListPermissions() ([]entity.Permission, error)
+	AttachPermission(roleID, permissionID string) error
+	DetachPermission(roleID, permissionID string) error
+	GetRolePermissions(roleID string) ([]entity.Permission, error)
+	GetUserPermissions(userID string) ([]entity.Permission, error)
+}
@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+)
+
+// Search backends (SEARCH_BACKEND)
+const (
+	SearchElasticsearch = "elasticsearch"
+	SearchPostgres      = "postgres"
+	SearchNone          = "none"
+)
+
+// SearchService finds users and keeps their search documents current. The container holds one
+// implementation (Elasticsearch or no-op in infrastructure/search, Postgres with the other
+// repositories), so the application never talks to a
+// search engine directly and deployments without Elasticsearch need nothing extra.
+type SearchService interface {
+	// Backend is one of the Search* names
+	Backend() string
+	// SearchUsers matches q.Q against email and name and returns the page q selects
+	SearchUsers(ctx context.Context, q entity.UserSearchQuery) (entity.UserSearchResult, error)
+	// IndexUsers writes the current documents of users and returns how many were rejected;
+	// backends that query Postgres directly have nothing to write
+	IndexUsers(ctx context.Context, users []entity.User) (failed int, err error)
+}
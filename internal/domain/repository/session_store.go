@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+)
+
+var (
+	ErrSessionNotFound = errors.New("session not found")
+	ErrSessionMismatch = errors.New("session replaced or revoked")
+)
+
+// SessionStore persists sessions. A user has at most one session: Create replaces the current one,
+// which signs out other devices (the refresh flow rotates the session the same way).
+type SessionStore interface {
+	// Create stores s as the user's session for ttl (<= 0 means no expiry)
+	Create(ctx context.Context, s *entity.Session, ttl time.Duration) error
+	// Get returns the session when sessionID is the user's current one; ErrSessionNotFound or ErrSessionMismatch otherwise
+	Get(ctx context.Context, userID, sessionID string) (*entity.Session, error)
+	// Touch records activity and, when ttl > 0, moves expiry to now+ttl
+	Touch(ctx context.Context, userID, sessionID string, ttl time.Duration) error
+	// Revoke ends the given session, or every session of the user when sessionID is empty
+	Revoke(ctx context.Context, userID, sessionID string) error
+	ListByUser(ctx context.Context, userID string) ([]entity.Session, error)
+	// ListByUsers returns the sessions of several users at once (batched where the store allows);
+	// users without a session are left out of the map
+	ListByUsers(ctx context.Context, userIDs []string) (map[string][]entity.Session, error)
+	// Block makes Get fail with entity.ErrAccountSuspended or entity.ErrAccountBanned for status
+	// "suspended" or "banned"; "" lifts the block
+	Block(ctx context.Context, userID, status string) error
+}
+
+// Account block statuses accepted by SessionStore.Block
+const (
+	BlockSuspended = "suspended"
+	BlockBanned    = "banned"
+)
+
+// BlockError maps a block status to its entity error; unknown or empty statuses are not blocked
+func BlockError(status string) error {
+	switch status {
+	case BlockBanned:
+		return entity.ErrAccountBanned
+	case BlockSuspended:
+		return entity.ErrAccountSuspended
+	}
+	return nil
+}
@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// Storage is the object store uploads (avatars) live in. With a private bucket the stored URLs
+// cannot be fetched directly, so readers get a short-lived signed URL instead.
+type Storage interface {
+	// SignedURL returns a GET URL for objectPath that stops working after ttl
+	SignedURL(ctx context.Context, objectPath string, ttl time.Duration) (string, error)
+	// ObjectPath returns the object a stored URL points to; false for URLs outside the store
+	ObjectPath(url string) (string, bool)
+}
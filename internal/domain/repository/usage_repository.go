@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+)
+
+// UsageRepository defines persistence for rolled-up API usage.
+type UsageRepository interface {
+	// Add accumulates the records (adding to existing buckets) in one transaction
+	Add(records []entity.UsageRecord) error
+	SummaryBySubject(from, to time.Time, subject string, limit int) ([]entity.UsageSummary, error)
+	SummaryByRoute(from, to time.Time, subject string, limit int) ([]entity.UsageSummary, error)
+}
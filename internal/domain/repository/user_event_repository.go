@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+)
+
+// UserEventRepository reads the append-only user history; events are written by UserRepository
+// in the same transaction as the change they describe.
+type UserEventRepository interface {
+	// ListByUser returns up to limit events with seq > afterSeq in seq order
+	ListByUser(ctx context.Context, userID string, afterSeq int64, limit int) ([]entity.UserEvent, error)
+	// ListByUserSince returns up to limit of the user's events created at or after since, in seq order
+	ListByUserSince(ctx context.Context, userID string, since time.Time, limit int) ([]entity.UserEvent, error)
+	// ListAfter returns up to limit events of any user with id > afterID in id order; it is the
+	// outbox consumers read (ids are assigned at insert, so a lower id may commit later)
+	ListAfter(ctx context.Context, afterID int64, limit int) ([]entity.UserEvent, error)
+	// Checkpoint returns the last event id consumer name applied (0 when it never ran)
+	Checkpoint(ctx context.Context, name string) (int64, error)
+	// SetCheckpoint records that consumer name applied every event up to lastID
+	SetCheckpoint(ctx context.Context, name string, lastID int64) error
+}
+
+type actorKey struct{}
+
+// SystemActor is recorded when no actor was attached to the context
+const SystemActor = "system"
+
+// WithActor attaches who is making a change (e.g. "user:<id>", "adminctl:root") to ctx
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFrom returns the actor attached by WithActor, or SystemActor
+func ActorFrom(ctx context.Context) string {
+	if a, ok := ctx.Value(actorKey{}).(string); ok && a != "" {
+		return a
+	}
+	return SystemActor
+}
@@ -1,14 +1,33 @@
 package repository
 
-import "github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+import (
+	"context"
+	"time"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+)
 
 // UserRepository defines the interface for user-related database operations.
+// Writes take a ctx carrying the actor (see WithActor) and append to the user's history;
+// Create and Update dispatch the user's pending domain events after commit.
 type UserRepository interface {
-	Create(u *entity.User) error
+	Create(ctx context.Context, u *entity.User) error
+	// CreateWithRoles creates u (verified when u.IsVerified) and grants roleIDs atomically
+	CreateWithRoles(ctx context.Context, u *entity.User, roleIDs ...string) error
 	GetByID(id string) (*entity.User, error)
+	// GetByExternalID finds a user by the public ULID (entity.User.ExternalID)
+	GetByExternalID(id string) (*entity.User, error)
 	GetByEmail(email string) (*entity.User, error)
-	Update(u *entity.User) error
-	UpdatePassword(userID string, passwordHash string) error
+	Update(ctx context.Context, u *entity.User) error
 	IsVerified(userID string) (bool, error)
-	SetVerified(userID string) error
+	SetVerified(ctx context.Context, userID string) error
+	// SetStatus stores the account status; nil timestamps clear the suspension or ban
+	SetStatus(ctx context.Context, userID string, suspendedAt, bannedAt *time.Time, reason string) error
+	// List returns users matching f, newest first; passwords are not loaded
+	List(ctx context.Context, f entity.UserFilter, page entity.Page) ([]entity.User, error)
+	Count(ctx context.Context, f entity.UserFilter) (int64, error)
+	// ListAfter pages through all users ordered by id (afterID "" starts at the beginning); passwords are not loaded
+	ListAfter(afterID string, limit int) ([]entity.User, error)
+	// ExistingIDs returns the subset of ids that belong to a user; malformed ids are never existing
+	ExistingIDs(ctx context.Context, ids []string) (map[string]bool, error)
 }
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+)
+
+// UserSecretRepository stores encrypted per-user values; it never sees plaintext.
+type UserSecretRepository interface {
+	// Put stores or replaces the user's value named s.Name
+	Put(ctx context.Context, s entity.UserSecret) error
+	// Get returns the value; ErrNotFound when the user has none
+	Get(ctx context.Context, userID, name string) (*entity.UserSecret, error)
+	// Delete removes the value; false when there was none
+	Delete(ctx context.Context, userID, name string) (bool, error)
+	// ListNotOnKey pages through values written with another key than keyID, in (user, name)
+	// order after the given position ("" starts at the beginning)
+	ListNotOnKey(ctx context.Context, keyID, afterUser, afterName string, limit int) ([]entity.UserSecret, error)
+	// Reencrypt swaps s's ciphertext for newCiphertext under keyID unless the value changed since
+	// s was read; false when it did
+	Reencrypt(ctx context.Context, s entity.UserSecret, newCiphertext, keyID string) (bool, error)
+}
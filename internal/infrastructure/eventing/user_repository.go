@@ -0,0 +1,94 @@
+// Package eventing decorates repositories so writes emit lifecycle events, whichever code path made them.
+package eventing
+
+import (
+	"context"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/events"
+)
+
+// UserRepository emits user.created, user.verified and user.updated after successful writes.
+// Emit must not block for long: it runs on the caller's goroutine.
+type UserRepository struct {
+	repository.UserRepository
+	Emit func(events.UserEvent)
+}
+
+func NewUserRepository(inner repository.UserRepository, emit func(events.UserEvent)) *UserRepository {
+	return &UserRepository{UserRepository: inner, Emit: emit}
+}
+
+func (r *UserRepository) Create(ctx context.Context, u *entity.User) error {
+	if err := r.UserRepository.Create(ctx, u); err != nil {
+		return err
+	}
+	r.Emit(events.NewUserEvent(events.UserCreated, view(u)))
+	return nil
+}
+
+// CreateWithRoles emits user.created, and user.verified for an account created verified
+func (r *UserRepository) CreateWithRoles(ctx context.Context, u *entity.User, roleIDs ...string) error {
+	if err := r.UserRepository.CreateWithRoles(ctx, u, roleIDs...); err != nil {
+		return err
+	}
+	r.Emit(events.NewUserEvent(events.UserCreated, view(u)))
+	if u.IsVerified {
+		r.Emit(events.NewUserEvent(events.UserVerified, view(u)))
+	}
+	return nil
+}
+
+// SetVerified emits only on the first verification
+func (r *UserRepository) SetVerified(ctx context.Context, userID string) error {
+	before, _ := r.UserRepository.GetByID(userID)
+	if err := r.UserRepository.SetVerified(ctx, userID); err != nil {
+		return err
+	}
+	if before == nil || !before.IsVerified {
+		r.emitFor(events.UserVerified, userID)
+	}
+	return nil
+}
+
+// Update reads the stored row first so the event can name the fields that changed
+func (r *UserRepository) Update(ctx context.Context, u *entity.User) error {
+	before, _ := r.UserRepository.GetByID(u.ID)
+	if err := r.UserRepository.Update(ctx, u); err != nil {
+		return err
+	}
+	var changes []string
+	if before != nil {
+		if before.Name != u.Name {
+			changes = append(changes, "name")
+		}
+		if before.AvatarURL != u.AvatarURL {
+			changes = append(changes, "avatar_url")
+		}
+		if before.Email != u.Email {
+			changes = append(changes, "email")
+		}
+		if before.Password != u.Password {
+			changes = append(changes, "password")
+		}
+		if len(changes) == 0 {
+			return nil
+		}
+	}
+	r.emitFor(events.UserUpdated, u.ID, changes...)
+	return nil
+}
+
+// emitFor reloads the user so the event carries the stored state
+func (r *UserRepository) emitFor(typ, userID string, changes ...string) {
+	u, err := r.UserRepository.GetByID(userID)
+	if err != nil || u == nil {
+		u = &entity.User{ID: userID}
+	}
+	r.Emit(events.NewUserEvent(typ, view(u), changes...))
+}
+
+func view(u *entity.User) events.User {
+	return events.User{ID: u.ID, ExternalID: u.ExternalID, Email: u.Email, Name: u.Name, AvatarURL: u.AvatarURL, Verified: u.IsVerified, CreatedAt: u.CreatedAt, UpdatedAt: u.UpdatedAt}
+}
@@ -0,0 +1,42 @@
+package gcsstore
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/storage"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+)
+
+// Storage signs V4 URLs for objects in one GCS bucket; the client's credentials must be able to sign
+// (a service account key, or ADC with iam.serviceAccounts.signBlob)
+type Storage struct {
+	client *storage.Client
+	bucket string
+}
+
+func NewStorage(client *storage.Client, bucket string) *Storage {
+	return &Storage{client: client, bucket: bucket}
+}
+
+func (s *Storage) SignedURL(_ context.Context, objectPath string, ttl time.Duration) (string, error) {
+	return s.client.Bucket(s.bucket).SignedURL(objectPath, &storage.SignedURLOptions{
+		Method:  "GET",
+		Expires: time.Now().Add(ttl),
+		Scheme:  storage.SigningSchemeV4,
+	})
+}
+
+// ObjectPath accepts the public URLs helpers.UploadObject returns for this bucket
+func (s *Storage) ObjectPath(url string) (string, bool) {
+	prefix := helpers.PublicURL(s.bucket, "")
+	if !strings.HasPrefix(url, prefix) || len(url) == len(prefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(url, prefix), true
+}
+
+var _ repository.Storage = (*Storage)(nil)
@@ -0,0 +1,125 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
+)
+
+// SessionStore is an in-process SessionStore for tests and single-instance development.
+// Sessions are lost on restart and not shared between replicas.
+type SessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]entity.Session // by user id
+	blocked  map[string]string         // block status by user id
+	now      func() time.Time
+}
+
+func NewSessionStore() *SessionStore {
+	return &SessionStore{sessions: map[string]entity.Session{}, blocked: map[string]string{}, now: time.Now}
+}
+
+// current returns the user's unexpired session; callers hold mu
+func (s *SessionStore) current(userID string) (entity.Session, bool) {
+	sess, ok := s.sessions[userID]
+	if ok && !sess.ExpiresAt.IsZero() && !s.now().Before(sess.ExpiresAt) {
+		delete(s.sessions, userID)
+		return entity.Session{}, false
+	}
+	return sess, ok
+}
+
+func (s *SessionStore) Create(ctx context.Context, sess *entity.Session, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sess.CreatedAt.IsZero() {
+		sess.CreatedAt = s.now().UTC()
+	}
+	stored := *sess
+	stored.ExpiresAt = time.Time{}
+	if ttl > 0 {
+		stored.ExpiresAt = s.now().Add(ttl)
+	}
+	s.sessions[sess.UserID] = stored
+	return nil
+}
+
+func (s *SessionStore) Get(ctx context.Context, userID, sessionID string) (*entity.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := repository.BlockError(s.blocked[userID]); err != nil {
+		return nil, err
+	}
+	sess, ok := s.current(userID)
+	if !ok {
+		return nil, repository.ErrSessionNotFound
+	}
+	if sess.ID != sessionID {
+		return nil, repository.ErrSessionMismatch
+	}
+	return &sess, nil
+}
+
+func (s *SessionStore) Touch(ctx context.Context, userID, sessionID string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, ok := s.current(userID)
+	if !ok {
+		return repository.ErrSessionNotFound
+	}
+	if sess.ID != sessionID {
+		return repository.ErrSessionMismatch
+	}
+	sess.UpdatedAt = s.now().UTC()
+	if ttl > 0 {
+		sess.ExpiresAt = s.now().Add(ttl)
+	}
+	s.sessions[userID] = sess
+	return nil
+}
+
+func (s *SessionStore) Revoke(ctx context.Context, userID, sessionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sess, ok := s.current(userID); ok && (sessionID == "" || sess.ID == sessionID) {
+		delete(s.sessions, userID)
+	}
+	return nil
+}
+
+func (s *SessionStore) ListByUser(ctx context.Context, userID string) ([]entity.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sess, ok := s.current(userID); ok {
+		return []entity.Session{sess}, nil
+	}
+	return []entity.Session{}, nil
+}
+
+func (s *SessionStore) ListByUsers(ctx context.Context, userIDs []string) (map[string][]entity.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[string][]entity.Session, len(userIDs))
+	for _, uid := range userIDs {
+		if sess, ok := s.current(uid); ok {
+			out[uid] = []entity.Session{sess}
+		}
+	}
+	return out, nil
+}
+
+func (s *SessionStore) Block(ctx context.Context, userID, status string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if status == "" {
+		delete(s.blocked, userID)
+	} else {
+		s.blocked[userID] = status
+	}
+	return nil
+}
+
+var _ repository.SessionStore = (*SessionStore)(nil)
@@ -0,0 +1,188 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/infrastructure/postgres/pgstore"
+)
+
+type AuditRepository struct {
+	queries *pgstore.Queries
+}
+
+func NewAuditRepository(pool *pgxpool.Pool) *AuditRepository {
+	return &AuditRepository{queries: newQueries(pool)}
+}
+
+func optText(s string) pgtype.Text { return pgtype.Text{String: s, Valid: s != ""} }
+
+func (r *AuditRepository) Insert(a *entity.AuditLog) error {
+	var uid pgtype.UUID
+	if a.UserID != "" {
+		// best effort: a malformed id is stored as NULL rather than dropping the entry
+		uid, _ = toPGUUID(a.UserID)
+	}
+	md, err := json.Marshal(a.Metadata)
+	if err != nil {
+		return err
+	}
+	var eventID pgtype.UUID
+	if a.EventID != "" {
+		eventID, _ = toPGUUID(a.EventID)
+	}
+	row, err := r.queries.InsertAuditLog(context.Background(), pgstore.InsertAuditLogParams{
+		EventID:       eventID,
+		UserID:        uid,
+		Email:         optText(a.Email),
+		Action:        a.Action,
+		Ip:            optText(a.IP),
+		UserAgent:     optText(a.UserAgent),
+		Metadata:      md,
+		CorrelationID: optText(a.CorrelationID),
+	})
+	if err != nil {
+		return err
+	}
+	a.ID = row.ID
+	a.CreatedAt = timeOf(row.CreatedAt)
+	return nil
+}
+
+func (r *AuditRepository) InsertBatch(ctx context.Context, entries []entity.AuditLog) ([]entity.AuditLog, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	arg := pgstore.InsertAuditLogBatchParams{}
+	byEvent := make(map[string]int, len(entries))
+	for i, a := range entries {
+		eventID, err := toPGUUID(a.EventID)
+		if err != nil {
+			return nil, repository.Wrap(repository.ErrInternal, err)
+		}
+		var uid pgtype.UUID
+		if a.UserID != "" {
+			uid, _ = toPGUUID(a.UserID)
+		}
+		md, err := json.Marshal(a.Metadata)
+		if err != nil {
+			return nil, err
+		}
+		byEvent[uuidString(eventID)] = i
+		arg.EventIds = append(arg.EventIds, eventID)
+		arg.UserIds = append(arg.UserIds, uid)
+		arg.Emails = append(arg.Emails, optText(a.Email))
+		arg.Actions = append(arg.Actions, a.Action)
+		arg.Ips = append(arg.Ips, optText(a.IP))
+		arg.UserAgents = append(arg.UserAgents, optText(a.UserAgent))
+		arg.Metadata = append(arg.Metadata, md)
+		at := a.CreatedAt
+		if at.IsZero() {
+			at = time.Now()
+		}
+		arg.CreatedAts = append(arg.CreatedAts, pgtype.Timestamptz{Time: at, Valid: true})
+		arg.CorrelationIds = append(arg.CorrelationIds, optText(a.CorrelationID))
+	}
+	rows, err := r.queries.InsertAuditLogBatch(ctx, arg)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]entity.AuditLog, 0, len(rows))
+	for _, row := range rows {
+		a := entries[byEvent[uuidString(row.EventID)]]
+		a.ID, a.CreatedAt = row.ID, timeOf(row.CreatedAt)
+		out = append(out, a)
+	}
+	return out, nil
+}
+
+// auditFilterArgs converts f to nullable query arguments; a malformed user id matches nothing
+func auditFilterArgs(f entity.AuditFilter) (action pgtype.Text, uid pgtype.UUID, from, to pgtype.Timestamptz, corr pgtype.Text, err error) {
+	action = optText(f.Action)
+	if f.UserID != "" {
+		if uid, err = toPGUUID(f.UserID); err != nil {
+			return
+		}
+	}
+	from = pgtype.Timestamptz{Time: f.From, Valid: !f.From.IsZero()}
+	to = pgtype.Timestamptz{Time: f.To, Valid: !f.To.IsZero()}
+	corr = optText(f.CorrelationID)
+	return
+}
+
+func (r *AuditRepository) Count(f entity.AuditFilter) (int64, error) {
+	action, uid, from, to, corr, err := auditFilterArgs(f)
+	if err != nil {
+		return 0, nil
+	}
+	return r.queries.CountAuditLogs(context.Background(), pgstore.CountAuditLogsParams{Action: action, UserID: uid, FromTime: from, ToTime: to, CorrelationID: corr})
+}
+
+func (r *AuditRepository) LastLoginTimezones(ctx context.Context, userIDs []string) (map[string]string, error) {
+	ids := make([]pgtype.UUID, 0, len(userIDs))
+	for _, id := range userIDs {
+		if uid, err := toPGUUID(id); err == nil {
+			ids = append(ids, uid)
+		}
+	}
+	out := map[string]string{}
+	if len(ids) == 0 {
+		return out, nil
+	}
+	rows, err := r.queries.ListLastLoginTimezones(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if row.Timezone != "" {
+			out[uuidString(row.UserID)] = row.Timezone
+		}
+	}
+	return out, nil
+}
+
+func (r *AuditRepository) ListAfter(f entity.AuditFilter, afterID int64, limit int) ([]entity.AuditLog, error) {
+	action, uid, from, to, corr, err := auditFilterArgs(f)
+	if err != nil {
+		return nil, nil
+	}
+	rows, err := r.queries.ListAuditLogsAfter(context.Background(), pgstore.ListAuditLogsAfterParams{
+		AfterID:       afterID,
+		Action:        action,
+		UserID:        uid,
+		FromTime:      from,
+		ToTime:        to,
+		CorrelationID: corr,
+		RowLimit:      int32(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]entity.AuditLog, 0, len(rows))
+	for _, row := range rows {
+		a := entity.AuditLog{
+			ID:            row.ID,
+			UserID:        uuidString(row.UserID),
+			Email:         row.Email.String,
+			Action:        row.Action,
+			IP:            row.Ip.String,
+			UserAgent:     row.UserAgent.String,
+			CreatedAt:     timeOf(row.CreatedAt),
+			EventID:       uuidString(row.EventID),
+			CorrelationID: row.CorrelationID.String,
+		}
+		if len(row.Metadata) > 0 {
+			_ = json.Unmarshal(row.Metadata, &a.Metadata)
+		}
+		out = append(out, a)
+	}
+	return out, nil
+}
+
+var _ repository.AuditRepository = (*AuditRepository)(nil)
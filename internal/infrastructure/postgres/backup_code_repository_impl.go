@@ -0,0 +1,61 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/infrastructure/postgres/pgstore"
+)
+
+type BackupCodeRepository struct {
+	pool    *pgxpool.Pool
+	queries *pgstore.Queries
+}
+
+func NewBackupCodeRepository(pool *pgxpool.Pool) *BackupCodeRepository {
+	return &BackupCodeRepository{pool: pool, queries: newQueries(pool)}
+}
+
+func (r *BackupCodeRepository) Replace(ctx context.Context, userID string, hashes []string) error {
+	uid, err := toPGUUID(userID)
+	if err != nil {
+		return errNotFound
+	}
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return classify(err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+	q := newQueries(tx)
+	if err := q.DeleteUserBackupCodes(ctx, uid); err != nil {
+		return err
+	}
+	for _, h := range hashes {
+		if err := q.InsertUserBackupCode(ctx, pgstore.InsertUserBackupCodeParams{UserID: uid, CodeHash: h}); err != nil {
+			return err
+		}
+	}
+	return classify(tx.Commit(ctx))
+}
+
+func (r *BackupCodeRepository) Use(ctx context.Context, userID, hash string) (bool, error) {
+	uid, err := toPGUUID(userID)
+	if err != nil {
+		return false, nil
+	}
+	n, err := r.queries.UseUserBackupCode(ctx, pgstore.UseUserBackupCodeParams{UserID: uid, CodeHash: hash})
+	return n == 1, err
+}
+
+func (r *BackupCodeRepository) Remaining(ctx context.Context, userID string) (int, error) {
+	uid, err := toPGUUID(userID)
+	if err != nil {
+		return 0, errNotFound
+	}
+	n, err := r.queries.CountUnusedUserBackupCodes(ctx, uid)
+	return int(n), err
+}
+
+var _ repository.BackupCodeRepository = (*BackupCodeRepository)(nil)
@@ -0,0 +1,157 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/infrastructure/postgres/pgstore"
+)
+
+type EmailBroadcastRepository struct {
+	pool    *pgxpool.Pool
+	queries *pgstore.Queries
+}
+
+func NewEmailBroadcastRepository(pool *pgxpool.Pool) *EmailBroadcastRepository {
+	return &EmailBroadcastRepository{pool: pool, queries: newQueries(pool)}
+}
+
+func mapEmailBroadcast(b pgstore.EmailBroadcast) *entity.EmailBroadcast {
+	return &entity.EmailBroadcast{
+		ID:          uuidString(b.ID),
+		State:       b.State,
+		Audience:    entity.BroadcastAudience{Type: b.Audience, Role: b.RoleName, OrgID: uuidString(b.OrgID)},
+		Message:     b.Message,
+		Total:       b.Total,
+		Enqueued:    b.Enqueued,
+		Skipped:     b.Skipped,
+		Failed:      b.Failed,
+		LastUserID:  uuidString(b.LastUserID),
+		Error:       b.Error,
+		Attempts:    int(b.Attempts),
+		RequestedBy: uuidString(b.RequestedBy),
+		CreatedAt:   timeOf(b.CreatedAt),
+		StartedAt:   timePtr(b.StartedAt),
+		FinishedAt:  timePtr(b.FinishedAt),
+	}
+}
+
+// optionalUUID is NULL for an empty id
+func optionalUUID(id string) (pgtype.UUID, error) {
+	if id == "" {
+		return pgtype.UUID{}, nil
+	}
+	return toPGUUID(id)
+}
+
+func (r *EmailBroadcastRepository) Create(ctx context.Context, b *entity.EmailBroadcast) error {
+	orgID, err := optionalUUID(b.Audience.OrgID)
+	if err != nil {
+		return err
+	}
+	requestedBy, err := optionalUUID(b.RequestedBy)
+	if err != nil {
+		return err
+	}
+	row, err := r.queries.CreateEmailBroadcast(ctx, pgstore.CreateEmailBroadcastParams{
+		Audience: b.Audience.Type, RoleName: b.Audience.Role, OrgID: orgID, Message: b.Message, RequestedBy: requestedBy,
+	})
+	if err != nil {
+		return err
+	}
+	*b = *mapEmailBroadcast(row)
+	return nil
+}
+
+func (r *EmailBroadcastRepository) Get(ctx context.Context, id string) (*entity.EmailBroadcast, error) {
+	pgID, err := toPGUUID(id)
+	if err != nil {
+		return nil, errNotFound
+	}
+	row, err := r.queries.GetEmailBroadcast(ctx, pgID)
+	if err != nil {
+		return nil, err
+	}
+	return mapEmailBroadcast(row), nil
+}
+
+func (r *EmailBroadcastRepository) Claim(ctx context.Context, staleBefore time.Time) (*entity.EmailBroadcast, error) {
+	row, err := r.queries.ClaimEmailBroadcast(ctx, pgtype.Timestamptz{Time: staleBefore, Valid: true})
+	if err != nil {
+		return nil, err
+	}
+	return mapEmailBroadcast(row), nil
+}
+
+func (r *EmailBroadcastRepository) Progress(ctx context.Context, b *entity.EmailBroadcast) (bool, error) {
+	pgID, err := toPGUUID(b.ID)
+	if err != nil {
+		return false, err
+	}
+	last, err := optionalUUID(b.LastUserID)
+	if err != nil {
+		return false, err
+	}
+	n, err := r.queries.UpdateEmailBroadcastProgress(ctx, pgstore.UpdateEmailBroadcastProgressParams{
+		ID: pgID, Total: b.Total, Enqueued: b.Enqueued, Skipped: b.Skipped, Failed: b.Failed, LastUserID: last,
+	})
+	return n > 0, err
+}
+
+func (r *EmailBroadcastRepository) Finish(ctx context.Context, b *entity.EmailBroadcast) error {
+	pgID, err := toPGUUID(b.ID)
+	if err != nil {
+		return err
+	}
+	return r.queries.FinishEmailBroadcast(ctx, pgstore.FinishEmailBroadcastParams{
+		ID: pgID, State: b.State, Enqueued: b.Enqueued, Skipped: b.Skipped, Failed: b.Failed, Error: b.Error,
+	})
+}
+
+func (r *EmailBroadcastRepository) Cancel(ctx context.Context, id string) (bool, error) {
+	pgID, err := toPGUUID(id)
+	if err != nil {
+		return false, errNotFound
+	}
+	n, err := r.queries.CancelEmailBroadcast(ctx, pgID)
+	return n > 0, err
+}
+
+func (r *EmailBroadcastRepository) Recipients(ctx context.Context, a entity.BroadcastAudience, afterID string, limit int) ([]entity.BroadcastRecipient, error) {
+	orgID, err := optionalUUID(a.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	after := pgtype.UUID{Valid: true} // zero UUID sorts first
+	if afterID != "" {
+		if after, err = toPGUUID(afterID); err != nil {
+			return nil, err
+		}
+	}
+	rows, err := r.queries.ListBroadcastRecipients(ctx, pgstore.ListBroadcastRecipientsParams{
+		AfterID: after, RoleName: a.Role, OrgID: orgID, RowLimit: int32(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]entity.BroadcastRecipient, 0, len(rows))
+	for _, u := range rows {
+		out = append(out, entity.BroadcastRecipient{ID: uuidString(u.ID), Email: u.Email, Name: u.Name})
+	}
+	return out, nil
+}
+
+func (r *EmailBroadcastRepository) CountRecipients(ctx context.Context, a entity.BroadcastAudience) (int64, error) {
+	orgID, err := optionalUUID(a.OrgID)
+	if err != nil {
+		return 0, err
+	}
+	return r.queries.CountBroadcastRecipients(ctx, pgstore.CountBroadcastRecipientsParams{RoleName: a.Role, OrgID: orgID})
+}
+
+var _ repository.EmailBroadcastRepository = (*EmailBroadcastRepository)(nil)
@@ -0,0 +1,110 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/infrastructure/postgres/pgstore"
+)
+
+type EmailEventRepository struct {
+	pool    *pgxpool.Pool
+	queries *pgstore.Queries
+}
+
+func NewEmailEventRepository(pool *pgxpool.Pool) *EmailEventRepository {
+	return &EmailEventRepository{pool: pool, queries: newQueries(pool)}
+}
+
+func mapEmailEvent(e pgstore.EmailEvent) *entity.EmailEvent {
+	var vars map[string]string
+	_ = json.Unmarshal(e.Variables, &vars)
+	return &entity.EmailEvent{
+		ID:              e.ID,
+		Provider:        e.Provider,
+		ProviderEventID: e.ProviderEventID,
+		Event:           e.Event,
+		Recipient:       e.Recipient,
+		MessageID:       e.MessageID,
+		Reason:          e.Reason,
+		Variables:       vars,
+		CorrelationID:   e.CorrelationID,
+		OccurredAt:      timeOf(e.OccurredAt),
+		CreatedAt:       timeOf(e.CreatedAt),
+		ForwardState:    e.ForwardState,
+		ForwardAttempts: int(e.ForwardAttempts),
+		LastError:       e.LastError,
+	}
+}
+
+func (r *EmailEventRepository) Record(ctx context.Context, e *entity.EmailEvent) (bool, error) {
+	vars := []byte("{}")
+	if len(e.Variables) > 0 {
+		b, err := json.Marshal(e.Variables)
+		if err != nil {
+			return false, err
+		}
+		vars = b
+	}
+	state := e.ForwardState
+	if state == "" {
+		state = entity.EmailForwardNone
+	}
+	n, err := r.queries.InsertEmailEvent(ctx, pgstore.InsertEmailEventParams{
+		Provider:        e.Provider,
+		ProviderEventID: e.ProviderEventID,
+		Event:           e.Event,
+		Recipient:       e.Recipient,
+		MessageID:       e.MessageID,
+		Reason:          e.Reason,
+		Variables:       vars,
+		OccurredAt:      pgtype.Timestamptz{Time: e.OccurredAt, Valid: true},
+		ForwardState:    state,
+		CorrelationID:   e.CorrelationID,
+	})
+	return n > 0, err
+}
+
+func (r *EmailEventRepository) ListByCorrelation(ctx context.Context, correlationID string, limit int) ([]*entity.EmailEvent, error) {
+	rows, err := r.queries.ListEmailEventsByCorrelation(ctx, pgstore.ListEmailEventsByCorrelationParams{CorrelationID: correlationID, Limit: int32(limit)})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*entity.EmailEvent, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, mapEmailEvent(row))
+	}
+	return out, nil
+}
+
+func (r *EmailEventRepository) ClaimForward(ctx context.Context, limit int, leaseUntil time.Time) ([]*entity.EmailEvent, error) {
+	rows, err := r.queries.ClaimEmailEvents(ctx, pgstore.ClaimEmailEventsParams{LeaseUntil: pgtype.Timestamptz{Time: leaseUntil, Valid: true}, Batch: int32(limit)})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*entity.EmailEvent, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, mapEmailEvent(row))
+	}
+	return out, nil
+}
+
+func (r *EmailEventRepository) Forwarded(ctx context.Context, id int64) error {
+	return r.queries.MarkEmailEventForwarded(ctx, id)
+}
+
+func (r *EmailEventRepository) ForwardFailed(ctx context.Context, id int64, next time.Time, lastErr string, final bool) error {
+	state := entity.EmailForwardPending
+	if final {
+		state = entity.EmailForwardFailed
+	}
+	return r.queries.FailEmailEventForward(ctx, pgstore.FailEmailEventForwardParams{ID: id, ForwardState: state, NextAttemptAt: pgtype.Timestamptz{Time: next, Valid: true}, LastError: lastErr})
+}
+
+var _ repository.EmailEventRepository = (*EmailEventRepository)(nil)
@@ -0,0 +1,83 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/infrastructure/postgres/pgstore"
+)
+
+// classify maps a pgx error to a repository error kind; the driver error stays wrapped for logs
+func classify(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		return repository.Wrap(repository.ErrNotFound, err)
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code[:2] {
+		case "23": // integrity constraint violation
+			if pgErr.Code == "23505" || pgErr.Code == "23503" {
+				return repository.Wrap(repository.ErrConflict, err)
+			}
+		case "40": // serialization failure, deadlock
+			return repository.Wrap(repository.ErrConflict, err)
+		case "08", "53", "57": // connection, insufficient resources, operator intervention
+			return repository.Wrap(repository.ErrUnavailable, err)
+		}
+		return repository.Wrap(repository.ErrInternal, err)
+	}
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || pgconn.Timeout(err) || errors.As(err, &netErr) {
+		return repository.Wrap(repository.ErrUnavailable, err)
+	}
+	var connErr *pgconn.ConnectError
+	if errors.As(err, &connErr) {
+		return repository.Wrap(repository.ErrUnavailable, err)
+	}
+	if errors.Is(err, context.Canceled) {
+		return err
+	}
+	return repository.Wrap(repository.ErrInternal, err)
+}
+
+// newQueries returns sqlc queries whose errors are classified, for the pool or a transaction
+func newQueries(db pgstore.DBTX) *pgstore.Queries {
+	return pgstore.New(classifyingDB{db})
+}
+
+// classifyingDB classifies every error sqlc sees, including row scans
+type classifyingDB struct{ db pgstore.DBTX }
+
+func (d classifyingDB) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	tag, err := d.db.Exec(ctx, sql, args...)
+	return tag, classify(err)
+}
+
+func (d classifyingDB) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	rows, err := d.db.Query(ctx, sql, args...)
+	if err != nil {
+		return rows, classify(err)
+	}
+	return classifyingRows{rows}, nil
+}
+
+func (d classifyingDB) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	return classifyingRow{d.db.QueryRow(ctx, sql, args...)}
+}
+
+type classifyingRow struct{ row pgx.Row }
+
+func (r classifyingRow) Scan(dest ...any) error { return classify(r.row.Scan(dest...)) }
+
+type classifyingRows struct{ pgx.Rows }
+
+func (r classifyingRows) Scan(dest ...any) error { return classify(r.Rows.Scan(dest...)) }
+func (r classifyingRows) Err() error             { return classify(r.Rows.Err()) }
@@ -0,0 +1,99 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/infrastructure/postgres/pgstore"
+)
+
+type ExportJobRepository struct {
+	pool    *pgxpool.Pool
+	queries *pgstore.Queries
+}
+
+func NewExportJobRepository(pool *pgxpool.Pool) *ExportJobRepository {
+	return &ExportJobRepository{pool: pool, queries: newQueries(pool)}
+}
+
+func mapExportJob(j pgstore.ExportJob) *entity.ExportJob {
+	return &entity.ExportJob{
+		ID:          uuidString(j.ID),
+		Kind:        j.Kind,
+		State:       j.State,
+		Params:      j.Params,
+		Total:       j.Total,
+		Processed:   j.Processed,
+		Object:      j.Object,
+		Error:       j.Error,
+		Attempts:    int(j.Attempts),
+		RequestedBy: uuidString(j.RequestedBy),
+		CreatedAt:   timeOf(j.CreatedAt),
+		StartedAt:   timePtr(j.StartedAt),
+		FinishedAt:  timePtr(j.FinishedAt),
+	}
+}
+
+func (r *ExportJobRepository) Create(ctx context.Context, j *entity.ExportJob) error {
+	var requestedBy pgtype.UUID
+	if j.RequestedBy != "" {
+		id, err := toPGUUID(j.RequestedBy)
+		if err != nil {
+			return err
+		}
+		requestedBy = id
+	}
+	params := j.Params
+	if len(params) == 0 {
+		params = []byte("{}")
+	}
+	row, err := r.queries.CreateExportJob(ctx, pgstore.CreateExportJobParams{Kind: j.Kind, Params: params, RequestedBy: requestedBy})
+	if err != nil {
+		return err
+	}
+	*j = *mapExportJob(row)
+	return nil
+}
+
+func (r *ExportJobRepository) Get(ctx context.Context, id string) (*entity.ExportJob, error) {
+	pgID, err := toPGUUID(id)
+	if err != nil {
+		return nil, errNotFound
+	}
+	row, err := r.queries.GetExportJob(ctx, pgID)
+	if err != nil {
+		return nil, err
+	}
+	return mapExportJob(row), nil
+}
+
+func (r *ExportJobRepository) Claim(ctx context.Context, staleBefore time.Time) (*entity.ExportJob, error) {
+	row, err := r.queries.ClaimExportJob(ctx, pgtype.Timestamptz{Time: staleBefore, Valid: true})
+	if err != nil {
+		return nil, err
+	}
+	return mapExportJob(row), nil
+}
+
+func (r *ExportJobRepository) Progress(ctx context.Context, id string, total, processed int64) error {
+	pgID, err := toPGUUID(id)
+	if err != nil {
+		return err
+	}
+	return r.queries.UpdateExportProgress(ctx, pgstore.UpdateExportProgressParams{ID: pgID, Total: total, Processed: processed})
+}
+
+func (r *ExportJobRepository) Finish(ctx context.Context, j *entity.ExportJob) error {
+	pgID, err := toPGUUID(j.ID)
+	if err != nil {
+		return err
+	}
+	return r.queries.FinishExportJob(ctx, pgstore.FinishExportJobParams{ID: pgID, State: j.State, Processed: j.Processed, Object: j.Object, Error: j.Error})
+}
+
+var _ repository.ExportJobRepository = (*ExportJobRepository)(nil)
@@ -0,0 +1,96 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/infrastructure/postgres/pgstore"
+)
+
+type IdentityRepository struct {
+	pool    *pgxpool.Pool
+	queries *pgstore.Queries
+}
+
+func NewIdentityRepository(pool *pgxpool.Pool) *IdentityRepository {
+	return &IdentityRepository{pool: pool, queries: newQueries(pool)}
+}
+
+func mapIdentity(i pgstore.Identity) entity.Identity {
+	return entity.Identity{
+		ID:             uuidString(i.ID),
+		UserID:         uuidString(i.UserID),
+		Provider:       i.Provider,
+		ProviderUserID: i.ProviderUserID,
+		Email:          i.Email,
+		CreatedAt:      timeOf(i.CreatedAt),
+	}
+}
+
+func (r *IdentityRepository) Create(i *entity.Identity) error {
+	uid, err := toPGUUID(i.UserID)
+	if err != nil {
+		return err
+	}
+	row, err := r.queries.CreateIdentity(context.Background(), pgstore.CreateIdentityParams{
+		UserID:         uid,
+		Provider:       i.Provider,
+		ProviderUserID: i.ProviderUserID,
+		Email:          i.Email,
+	})
+	if err != nil {
+		return err
+	}
+	*i = mapIdentity(row)
+	return nil
+}
+
+func (r *IdentityRepository) GetByProvider(provider, providerUserID string) (*entity.Identity, error) {
+	row, err := r.queries.GetIdentityByProvider(context.Background(), pgstore.GetIdentityByProviderParams{Provider: provider, ProviderUserID: providerUserID})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errNotFound
+		}
+		return nil, err
+	}
+	i := mapIdentity(row)
+	return &i, nil
+}
+
+func (r *IdentityRepository) ListByUser(userID string) ([]entity.Identity, error) {
+	uid, err := toPGUUID(userID)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.queries.ListUserIdentities(context.Background(), uid)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]entity.Identity, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, mapIdentity(row))
+	}
+	return out, nil
+}
+
+func (r *IdentityRepository) Delete(userID, provider string) error {
+	uid, err := toPGUUID(userID)
+	if err != nil {
+		return err
+	}
+	rows, err := r.queries.DeleteUserIdentity(context.Background(), pgstore.DeleteUserIdentityParams{UserID: uid, Provider: provider})
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errNotFound
+	}
+	return nil
+}
+
+var _ repository.IdentityRepository = (*IdentityRepository)(nil)
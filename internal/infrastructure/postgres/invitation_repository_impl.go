@@ -0,0 +1,137 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/infrastructure/postgres/pgstore"
+)
+
+type InvitationRepository struct {
+	pool    *pgxpool.Pool
+	queries *pgstore.Queries
+}
+
+func NewInvitationRepository(pool *pgxpool.Pool) *InvitationRepository {
+	return &InvitationRepository{pool: pool, queries: newQueries(pool)}
+}
+
+func timePtr(ts pgtype.Timestamptz) *time.Time {
+	if !ts.Valid {
+		return nil
+	}
+	t := ts.Time
+	return &t
+}
+
+func mapInvitation(i pgstore.Invitation) entity.Invitation {
+	return entity.Invitation{
+		ID:         uuidString(i.ID),
+		Email:      i.Email,
+		Role:       i.Role,
+		OrgID:      uuidString(i.OrgID),
+		OrgRole:    i.OrgRole,
+		InvitedBy:  uuidString(i.InvitedBy),
+		ExpiresAt:  timeOf(i.ExpiresAt),
+		AcceptedAt: timePtr(i.AcceptedAt),
+		RevokedAt:  timePtr(i.RevokedAt),
+		CreatedAt:  timeOf(i.CreatedAt),
+	}
+}
+
+func (r *InvitationRepository) Create(inv *entity.Invitation, tokenHash string) error {
+	var invitedBy pgtype.UUID
+	if inv.InvitedBy != "" {
+		id, err := toPGUUID(inv.InvitedBy)
+		if err != nil {
+			return err
+		}
+		invitedBy = id
+	}
+	var orgID pgtype.UUID
+	if inv.OrgID != "" {
+		id, err := toPGUUID(inv.OrgID)
+		if err != nil {
+			return err
+		}
+		orgID = id
+	}
+	row, err := r.queries.CreateInvitation(context.Background(), pgstore.CreateInvitationParams{
+		Email:     inv.Email,
+		Role:      inv.Role,
+		TokenHash: tokenHash,
+		InvitedBy: invitedBy,
+		ExpiresAt: pgtype.Timestamptz{Time: inv.ExpiresAt, Valid: true},
+		OrgID:     orgID,
+		OrgRole:   inv.OrgRole,
+	})
+	if err != nil {
+		return err
+	}
+	*inv = mapInvitation(row)
+	return nil
+}
+
+func (r *InvitationRepository) GetByTokenHash(tokenHash string) (*entity.Invitation, error) {
+	row, err := r.queries.GetInvitationByTokenHash(context.Background(), tokenHash)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errNotFound
+		}
+		return nil, err
+	}
+	inv := mapInvitation(row)
+	return &inv, nil
+}
+
+func (r *InvitationRepository) List() ([]entity.Invitation, error) {
+	rows, err := r.queries.ListInvitations(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	out := make([]entity.Invitation, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, mapInvitation(row))
+	}
+	return out, nil
+}
+
+// MarkAccepted claims a pending, unexpired invitation; errNotFound if it is no longer usable.
+func (r *InvitationRepository) MarkAccepted(id string) error {
+	pgID, err := toPGUUID(id)
+	if err != nil {
+		return err
+	}
+	n, err := r.queries.AcceptInvitation(context.Background(), pgID)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return errNotFound
+	}
+	return nil
+}
+
+func (r *InvitationRepository) Revoke(id string) error {
+	pgID, err := toPGUUID(id)
+	if err != nil {
+		return err
+	}
+	n, err := r.queries.RevokeInvitation(context.Background(), pgID)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return errNotFound
+	}
+	return nil
+}
+
+var _ repository.InvitationRepository = (*InvitationRepository)(nil)
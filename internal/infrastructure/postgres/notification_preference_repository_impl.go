@@ -0,0 +1,44 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/infrastructure/postgres/pgstore"
+)
+
+type NotificationPreferenceRepository struct {
+	queries *pgstore.Queries
+}
+
+func NewNotificationPreferenceRepository(pool *pgxpool.Pool) *NotificationPreferenceRepository {
+	return &NotificationPreferenceRepository{queries: newQueries(pool)}
+}
+
+func (r *NotificationPreferenceRepository) List(userID string) (map[string]bool, error) {
+	uid, err := toPGUUID(userID)
+	if err != nil {
+		return nil, errNotFound
+	}
+	rows, err := r.queries.ListNotificationPreferences(context.Background(), uid)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]bool, len(rows))
+	for _, row := range rows {
+		out[row.Category] = row.Enabled
+	}
+	return out, nil
+}
+
+func (r *NotificationPreferenceRepository) Set(userID, category string, enabled bool) error {
+	uid, err := toPGUUID(userID)
+	if err != nil {
+		return errNotFound
+	}
+	return r.queries.UpsertNotificationPreference(context.Background(), pgstore.UpsertNotificationPreferenceParams{UserID: uid, Category: category, Enabled: enabled})
+}
+
+var _ repository.NotificationPreferenceRepository = (*NotificationPreferenceRepository)(nil)
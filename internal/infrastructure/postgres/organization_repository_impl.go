@@ -0,0 +1,273 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/infrastructure/postgres/pgstore"
+)
+
+// errAlreadyMember is returned by AddMember when the user already belongs to the organization
+var errAlreadyMember = errors.New("already a member")
+
+type OrganizationRepository struct {
+	pool    *pgxpool.Pool
+	queries *pgstore.Queries
+}
+
+func NewOrganizationRepository(pool *pgxpool.Pool) *OrganizationRepository {
+	return &OrganizationRepository{pool: pool, queries: newQueries(pool)}
+}
+
+func mapOrganization(o pgstore.Organization) entity.Organization {
+	return entity.Organization{
+		ID:        uuidString(o.ID),
+		Name:      o.Name,
+		Slug:      o.Slug,
+		CreatedBy: uuidString(o.CreatedBy),
+		CreatedAt: timeOf(o.CreatedAt),
+		UpdatedAt: timeOf(o.UpdatedAt),
+	}
+}
+
+func orgMemberIDs(orgID, userID string) (pgtype.UUID, pgtype.UUID, error) {
+	oid, err := toPGUUID(orgID)
+	if err != nil {
+		return oid, pgtype.UUID{}, err
+	}
+	uid, err := toPGUUID(userID)
+	return oid, uid, err
+}
+
+// CreateWithOwner inserts the organization and its first owner in one transaction.
+func (r *OrganizationRepository) CreateWithOwner(org *entity.Organization, ownerID string) error {
+	ctx := context.Background()
+	uid, err := toPGUUID(ownerID)
+	if err != nil {
+		return err
+	}
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return classify(err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+	q := newQueries(tx)
+	row, err := q.CreateOrganization(ctx, pgstore.CreateOrganizationParams{Name: org.Name, Slug: org.Slug, CreatedBy: uid})
+	if err != nil {
+		return err
+	}
+	if _, err := q.AddOrganizationMember(ctx, pgstore.AddOrganizationMemberParams{OrgID: row.ID, UserID: uid, Role: "owner"}); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return classify(err)
+	}
+	*org = mapOrganization(row)
+	return nil
+}
+
+func (r *OrganizationRepository) GetByID(id string) (*entity.Organization, error) {
+	pgID, err := toPGUUID(id)
+	if err != nil {
+		return nil, errNotFound
+	}
+	row, err := r.queries.GetOrganization(context.Background(), pgID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errNotFound
+		}
+		return nil, err
+	}
+	o := mapOrganization(row)
+	return &o, nil
+}
+
+func (r *OrganizationRepository) GetBySlug(slug string) (*entity.Organization, error) {
+	row, err := r.queries.GetOrganizationBySlug(context.Background(), slug)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errNotFound
+		}
+		return nil, err
+	}
+	o := mapOrganization(row)
+	return &o, nil
+}
+
+func (r *OrganizationRepository) ListByUser(userID string) ([]entity.OrgMembership, error) {
+	uid, err := toPGUUID(userID)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.queries.ListUserOrganizations(context.Background(), uid)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]entity.OrgMembership, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, entity.OrgMembership{
+			Organization: entity.Organization{
+				ID:        uuidString(row.ID),
+				Name:      row.Name,
+				Slug:      row.Slug,
+				CreatedBy: uuidString(row.CreatedBy),
+				CreatedAt: timeOf(row.CreatedAt),
+				UpdatedAt: timeOf(row.UpdatedAt),
+			},
+			Role: row.Role,
+		})
+	}
+	return out, nil
+}
+
+func (r *OrganizationRepository) AddMember(orgID, userID, role string) error {
+	oid, uid, err := orgMemberIDs(orgID, userID)
+	if err != nil {
+		return err
+	}
+	n, err := r.queries.AddOrganizationMember(context.Background(), pgstore.AddOrganizationMemberParams{OrgID: oid, UserID: uid, Role: role})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return errAlreadyMember
+	}
+	return nil
+}
+
+func (r *OrganizationRepository) GetMember(orgID, userID string) (*entity.OrgMember, error) {
+	oid, uid, err := orgMemberIDs(orgID, userID)
+	if err != nil {
+		return nil, errNotFound
+	}
+	row, err := r.queries.GetOrganizationMember(context.Background(), pgstore.GetOrganizationMemberParams{OrgID: oid, UserID: uid})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errNotFound
+		}
+		return nil, err
+	}
+	return &entity.OrgMember{
+		OrgID:     uuidString(row.OrgID),
+		UserID:    uuidString(row.UserID),
+		Role:      row.Role,
+		CreatedAt: timeOf(row.CreatedAt),
+	}, nil
+}
+
+func (r *OrganizationRepository) ListMembers(orgID string) ([]entity.OrgMember, error) {
+	oid, err := toPGUUID(orgID)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.queries.ListOrganizationMembers(context.Background(), oid)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]entity.OrgMember, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, entity.OrgMember{
+			OrgID:     uuidString(row.OrgID),
+			UserID:    uuidString(row.UserID),
+			Email:     row.Email,
+			Name:      row.Name,
+			Role:      row.Role,
+			CreatedAt: timeOf(row.CreatedAt),
+		})
+	}
+	return out, nil
+}
+
+func (r *OrganizationRepository) UpdateMemberRole(orgID, userID, role string) error {
+	oid, uid, err := orgMemberIDs(orgID, userID)
+	if err != nil {
+		return err
+	}
+	n, err := r.queries.UpdateOrganizationMemberRole(context.Background(), pgstore.UpdateOrganizationMemberRoleParams{OrgID: oid, UserID: uid, Role: role})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return errNotFound
+	}
+	return nil
+}
+
+func (r *OrganizationRepository) RemoveMember(orgID, userID string) error {
+	oid, uid, err := orgMemberIDs(orgID, userID)
+	if err != nil {
+		return err
+	}
+	n, err := r.queries.RemoveOrganizationMember(context.Background(), pgstore.RemoveOrganizationMemberParams{OrgID: oid, UserID: uid})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return errNotFound
+	}
+	return nil
+}
+
+func (r *OrganizationRepository) CountOwners(orgID string) (int64, error) {
+	oid, err := toPGUUID(orgID)
+	if err != nil {
+		return 0, err
+	}
+	return r.queries.CountOrganizationOwners(context.Background(), oid)
+}
+
+func intOf(v pgtype.Int4) *int {
+	if !v.Valid {
+		return nil
+	}
+	n := int(v.Int32)
+	return &n
+}
+
+func int4Of(v *int) pgtype.Int4 {
+	if v == nil {
+		return pgtype.Int4{}
+	}
+	return pgtype.Int4{Int32: int32(*v), Valid: true}
+}
+
+// GetLimits returns the organization's quota overrides (all nil when none are set).
+func (r *OrganizationRepository) GetLimits(orgID string) (*entity.OrgLimits, error) {
+	oid, err := toPGUUID(orgID)
+	if err != nil {
+		return nil, err
+	}
+	row, err := r.queries.GetOrganizationLimits(context.Background(), oid)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return &entity.OrgLimits{}, nil
+		}
+		return nil, err
+	}
+	return &entity.OrgLimits{
+		RatePerMinute:  intOf(row.RatePerMinute),
+		RequestsPerDay: intOf(row.RequestsPerDay),
+		EmailsPerDay:   intOf(row.EmailsPerDay),
+	}, nil
+}
+
+func (r *OrganizationRepository) SetLimits(orgID string, limits entity.OrgLimits) error {
+	oid, err := toPGUUID(orgID)
+	if err != nil {
+		return err
+	}
+	return r.queries.UpsertOrganizationLimits(context.Background(), pgstore.UpsertOrganizationLimitsParams{
+		OrgID:          oid,
+		RatePerMinute:  int4Of(limits.RatePerMinute),
+		RequestsPerDay: int4Of(limits.RequestsPerDay),
+		EmailsPerDay:   int4Of(limits.EmailsPerDay),
+	})
+}
+
+var _ repository.OrganizationRepository = (*OrganizationRepository)(nil)
@@ -0,0 +1,126 @@
+package postgres
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/infrastructure/postgres/pgstore"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+)
+
+type PersonalTokenRepository struct {
+	queries *pgstore.Queries
+}
+
+func NewPersonalTokenRepository(pool *pgxpool.Pool) *PersonalTokenRepository {
+	return &PersonalTokenRepository{queries: newQueries(pool)}
+}
+
+func toPersonalToken(t pgstore.PersonalAccessToken) entity.PersonalToken {
+	return entity.PersonalToken{
+		ID:         uuidString(t.ID),
+		UserID:     uuidString(t.UserID),
+		Name:       t.Name,
+		Prefix:     t.TokenPrefix,
+		Scopes:     strings.Fields(t.Scope),
+		ExpiresAt:  timeOf(t.ExpiresAt),
+		LastUsedAt: timePtr(t.LastUsedAt),
+		LastUsedIP: t.LastUsedIp,
+		RevokedAt:  timePtr(t.RevokedAt),
+		CreatedAt:  timeOf(t.CreatedAt),
+	}
+}
+
+// Create assigns a UUIDv7, so IDs sort by creation time
+func (r *PersonalTokenRepository) Create(ctx context.Context, t *entity.PersonalToken, tokenHash string) error {
+	uid, err := toPGUUID(t.UserID)
+	if err != nil {
+		return errNotFound
+	}
+	id, err := toPGUUID(helpers.NewUUIDv7())
+	if err != nil {
+		return err
+	}
+	row, err := r.queries.CreatePersonalAccessToken(ctx, pgstore.CreatePersonalAccessTokenParams{
+		ID:          id,
+		UserID:      uid,
+		Name:        t.Name,
+		TokenPrefix: t.Prefix,
+		TokenHash:   tokenHash,
+		Scope:       strings.Join(t.Scopes, " "),
+		ExpiresAt:   pgtype.Timestamptz{Time: t.ExpiresAt, Valid: true},
+	})
+	if err != nil {
+		return classify(err)
+	}
+	*t = toPersonalToken(row)
+	return nil
+}
+
+func (r *PersonalTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*entity.PersonalToken, error) {
+	row, err := r.queries.GetPersonalAccessTokenByHash(ctx, tokenHash)
+	if err != nil {
+		return nil, classify(err)
+	}
+	t := toPersonalToken(row)
+	return &t, nil
+}
+
+func (r *PersonalTokenRepository) ListByUser(ctx context.Context, userID string) ([]entity.PersonalToken, error) {
+	uid, err := toPGUUID(userID)
+	if err != nil {
+		return nil, nil
+	}
+	rows, err := r.queries.ListPersonalAccessTokens(ctx, uid)
+	if err != nil {
+		return nil, classify(err)
+	}
+	out := make([]entity.PersonalToken, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, toPersonalToken(row))
+	}
+	return out, nil
+}
+
+func (r *PersonalTokenRepository) CountActive(ctx context.Context, userID string) (int, error) {
+	uid, err := toPGUUID(userID)
+	if err != nil {
+		return 0, nil
+	}
+	n, err := r.queries.CountActivePersonalAccessTokens(ctx, uid)
+	return int(n), classify(err)
+}
+
+func (r *PersonalTokenRepository) Revoke(ctx context.Context, userID, id string) error {
+	uid, err := toPGUUID(userID)
+	if err != nil {
+		return errNotFound
+	}
+	pgID, err := toPGUUID(id)
+	if err != nil {
+		return errNotFound
+	}
+	n, err := r.queries.RevokePersonalAccessToken(ctx, pgstore.RevokePersonalAccessTokenParams{ID: pgID, UserID: uid})
+	if err != nil {
+		return classify(err)
+	}
+	if n == 0 {
+		return errNotFound
+	}
+	return nil
+}
+
+func (r *PersonalTokenRepository) MarkUsed(ctx context.Context, id, ip string) error {
+	pgID, err := toPGUUID(id)
+	if err != nil {
+		return errNotFound
+	}
+	return classify(r.queries.TouchPersonalAccessToken(ctx, pgstore.TouchPersonalAccessTokenParams{ID: pgID, LastUsedIp: ip}))
+}
+
+var _ repository.PersonalTokenRepository = (*PersonalTokenRepository)(nil)
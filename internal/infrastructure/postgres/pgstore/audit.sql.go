@@ -11,28 +11,231 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
-const insertAuditLog = `-- name: InsertAuditLog :exec
-INSERT INTO audit_logs (user_id, email, action, ip, user_agent, metadata)
-VALUES ($1, $2, $3, $4, $5, $6)
+const countAuditLogs = `-- name: CountAuditLogs :one
+SELECT count(*) FROM audit_logs
+WHERE ($1::text IS NULL OR action = $1)
+  AND ($2::uuid IS NULL OR user_id = $2)
+  AND ($3::timestamptz IS NULL OR created_at >= $3)
+  AND ($4::timestamptz IS NULL OR created_at < $4)
+  AND ($5::text IS NULL OR correlation_id = $5)
+`
+
+type CountAuditLogsParams struct {
+	Action        pgtype.Text        `json:"action"`
+	UserID        pgtype.UUID        `json:"user_id"`
+	FromTime      pgtype.Timestamptz `json:"from_time"`
+	ToTime        pgtype.Timestamptz `json:"to_time"`
+	CorrelationID pgtype.Text        `json:"correlation_id"`
+}
+
+func (q *Queries) CountAuditLogs(ctx context.Context, arg CountAuditLogsParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countAuditLogs,
+		arg.Action,
+		arg.UserID,
+		arg.FromTime,
+		arg.ToTime,
+		arg.CorrelationID,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const insertAuditLog = `-- name: InsertAuditLog :one
+INSERT INTO audit_logs (event_id, user_id, email, action, ip, user_agent, metadata, correlation_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING id, created_at
 `
 
 type InsertAuditLogParams struct {
-	UserID    pgtype.UUID `json:"user_id"`
-	Email     pgtype.Text `json:"email"`
-	Action    string      `json:"action"`
-	Ip        pgtype.Text `json:"ip"`
-	UserAgent pgtype.Text `json:"user_agent"`
-	Metadata  []byte      `json:"metadata"`
+	EventID       pgtype.UUID `json:"event_id"`
+	UserID        pgtype.UUID `json:"user_id"`
+	Email         pgtype.Text `json:"email"`
+	Action        string      `json:"action"`
+	Ip            pgtype.Text `json:"ip"`
+	UserAgent     pgtype.Text `json:"user_agent"`
+	Metadata      []byte      `json:"metadata"`
+	CorrelationID pgtype.Text `json:"correlation_id"`
 }
 
-func (q *Queries) InsertAuditLog(ctx context.Context, arg InsertAuditLogParams) error {
-	_, err := q.db.Exec(ctx, insertAuditLog,
+type InsertAuditLogRow struct {
+	ID        int64              `json:"id"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+func (q *Queries) InsertAuditLog(ctx context.Context, arg InsertAuditLogParams) (InsertAuditLogRow, error) {
+	row := q.db.QueryRow(ctx, insertAuditLog,
+		arg.EventID,
 		arg.UserID,
 		arg.Email,
 		arg.Action,
 		arg.Ip,
 		arg.UserAgent,
 		arg.Metadata,
+		arg.CorrelationID,
 	)
-	return err
+	var i InsertAuditLogRow
+	err := row.Scan(&i.ID, &i.CreatedAt)
+	return i, err
+}
+
+const insertAuditLogBatch = `-- name: InsertAuditLogBatch :many
+INSERT INTO audit_logs (event_id, user_id, email, action, ip, user_agent, metadata, created_at, correlation_id)
+SELECT * FROM unnest(
+  $1::uuid[],
+  $2::uuid[],
+  $3::text[],
+  $4::text[],
+  $5::text[],
+  $6::text[],
+  $7::jsonb[],
+  $8::timestamptz[],
+  $9::text[]
+)
+ON CONFLICT (event_id) DO NOTHING
+RETURNING id, event_id, created_at
+`
+
+type InsertAuditLogBatchParams struct {
+	EventIds       []pgtype.UUID        `json:"event_ids"`
+	UserIds        []pgtype.UUID        `json:"user_ids"`
+	Emails         []pgtype.Text        `json:"emails"`
+	Actions        []string             `json:"actions"`
+	Ips            []pgtype.Text        `json:"ips"`
+	UserAgents     []pgtype.Text        `json:"user_agents"`
+	Metadata       [][]byte             `json:"metadata"`
+	CreatedAts     []pgtype.Timestamptz `json:"created_ats"`
+	CorrelationIds []pgtype.Text        `json:"correlation_ids"`
+}
+
+type InsertAuditLogBatchRow struct {
+	ID        int64              `json:"id"`
+	EventID   pgtype.UUID        `json:"event_id"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+// Entries whose event_id is already stored are skipped, so a retried batch is not duplicated
+func (q *Queries) InsertAuditLogBatch(ctx context.Context, arg InsertAuditLogBatchParams) ([]InsertAuditLogBatchRow, error) {
+	rows, err := q.db.Query(ctx, insertAuditLogBatch,
+		arg.EventIds,
+		arg.UserIds,
+		arg.Emails,
+		arg.Actions,
+		arg.Ips,
+		arg.UserAgents,
+		arg.Metadata,
+		arg.CreatedAts,
+		arg.CorrelationIds,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []InsertAuditLogBatchRow
+	for rows.Next() {
+		var i InsertAuditLogBatchRow
+		if err := rows.Scan(&i.ID, &i.EventID, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAuditLogsAfter = `-- name: ListAuditLogsAfter :many
+SELECT id, user_id, email, action, ip, user_agent, metadata, created_at, event_id, correlation_id FROM audit_logs
+WHERE id > $1
+  AND ($2::text IS NULL OR action = $2)
+  AND ($3::uuid IS NULL OR user_id = $3)
+  AND ($4::timestamptz IS NULL OR created_at >= $4)
+  AND ($5::timestamptz IS NULL OR created_at < $5)
+  AND ($6::text IS NULL OR correlation_id = $6)
+ORDER BY id
+LIMIT $7
+`
+
+type ListAuditLogsAfterParams struct {
+	AfterID       int64              `json:"after_id"`
+	Action        pgtype.Text        `json:"action"`
+	UserID        pgtype.UUID        `json:"user_id"`
+	FromTime      pgtype.Timestamptz `json:"from_time"`
+	ToTime        pgtype.Timestamptz `json:"to_time"`
+	CorrelationID pgtype.Text        `json:"correlation_id"`
+	RowLimit      int32              `json:"row_limit"`
+}
+
+func (q *Queries) ListAuditLogsAfter(ctx context.Context, arg ListAuditLogsAfterParams) ([]AuditLog, error) {
+	rows, err := q.db.Query(ctx, listAuditLogsAfter,
+		arg.AfterID,
+		arg.Action,
+		arg.UserID,
+		arg.FromTime,
+		arg.ToTime,
+		arg.CorrelationID,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AuditLog
+	for rows.Next() {
+		var i AuditLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Email,
+			&i.Action,
+			&i.Ip,
+			&i.UserAgent,
+			&i.Metadata,
+			&i.CreatedAt,
+			&i.EventID,
+			&i.CorrelationID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listLastLoginTimezones = `-- name: ListLastLoginTimezones :many
+SELECT DISTINCT ON (user_id) user_id, (metadata->>'timezone')::text AS timezone FROM audit_logs
+WHERE user_id = ANY($1::uuid[])
+  AND action = 'login'
+  AND metadata ? 'timezone'
+ORDER BY user_id, created_at DESC
+`
+
+type ListLastLoginTimezonesRow struct {
+	UserID   pgtype.UUID `json:"user_id"`
+	Timezone string      `json:"timezone"`
+}
+
+// Timezone of each user's most recent login that resolved one (recorded by the login handler)
+func (q *Queries) ListLastLoginTimezones(ctx context.Context, userIds []pgtype.UUID) ([]ListLastLoginTimezonesRow, error) {
+	rows, err := q.db.Query(ctx, listLastLoginTimezones, userIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListLastLoginTimezonesRow
+	for rows.Next() {
+		var i ListLastLoginTimezonesRow
+		if err := rows.Scan(&i.UserID, &i.Timezone); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
@@ -8,15 +8,160 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type ApiUsage struct {
+	Bucket   pgtype.Timestamptz `json:"bucket"`
+	Subject  string             `json:"subject"`
+	Method   string             `json:"method"`
+	Route    string             `json:"route"`
+	Requests int64              `json:"requests"`
+	BytesIn  int64              `json:"bytes_in"`
+	BytesOut int64              `json:"bytes_out"`
+}
+
 type AuditLog struct {
-	ID        int64              `json:"id"`
+	ID            int64              `json:"id"`
+	UserID        pgtype.UUID        `json:"user_id"`
+	Email         pgtype.Text        `json:"email"`
+	Action        string             `json:"action"`
+	Ip            pgtype.Text        `json:"ip"`
+	UserAgent     pgtype.Text        `json:"user_agent"`
+	Metadata      []byte             `json:"metadata"`
+	CreatedAt     pgtype.Timestamptz `json:"created_at"`
+	EventID       pgtype.UUID        `json:"event_id"`
+	CorrelationID pgtype.Text        `json:"correlation_id"`
+}
+
+type EmailBroadcast struct {
+	ID          pgtype.UUID        `json:"id"`
+	State       string             `json:"state"`
+	Audience    string             `json:"audience"`
+	RoleName    string             `json:"role_name"`
+	OrgID       pgtype.UUID        `json:"org_id"`
+	Message     []byte             `json:"message"`
+	Total       int64              `json:"total"`
+	Enqueued    int64              `json:"enqueued"`
+	Skipped     int64              `json:"skipped"`
+	Failed      int64              `json:"failed"`
+	LastUserID  pgtype.UUID        `json:"last_user_id"`
+	Error       string             `json:"error"`
+	Attempts    int32              `json:"attempts"`
+	RequestedBy pgtype.UUID        `json:"requested_by"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	StartedAt   pgtype.Timestamptz `json:"started_at"`
+	HeartbeatAt pgtype.Timestamptz `json:"heartbeat_at"`
+	FinishedAt  pgtype.Timestamptz `json:"finished_at"`
+}
+
+type EmailEvent struct {
+	ID              int64              `json:"id"`
+	Provider        string             `json:"provider"`
+	ProviderEventID string             `json:"provider_event_id"`
+	Event           string             `json:"event"`
+	Recipient       string             `json:"recipient"`
+	MessageID       string             `json:"message_id"`
+	Reason          string             `json:"reason"`
+	Variables       []byte             `json:"variables"`
+	OccurredAt      pgtype.Timestamptz `json:"occurred_at"`
+	CreatedAt       pgtype.Timestamptz `json:"created_at"`
+	ForwardState    string             `json:"forward_state"`
+	ForwardAttempts int32              `json:"forward_attempts"`
+	NextAttemptAt   pgtype.Timestamptz `json:"next_attempt_at"`
+	ForwardedAt     pgtype.Timestamptz `json:"forwarded_at"`
+	LastError       string             `json:"last_error"`
+	CorrelationID   string             `json:"correlation_id"`
+}
+
+type ExportJob struct {
+	ID          pgtype.UUID        `json:"id"`
+	Kind        string             `json:"kind"`
+	State       string             `json:"state"`
+	Params      []byte             `json:"params"`
+	Total       int64              `json:"total"`
+	Processed   int64              `json:"processed"`
+	Object      string             `json:"object"`
+	Error       string             `json:"error"`
+	Attempts    int32              `json:"attempts"`
+	RequestedBy pgtype.UUID        `json:"requested_by"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	StartedAt   pgtype.Timestamptz `json:"started_at"`
+	HeartbeatAt pgtype.Timestamptz `json:"heartbeat_at"`
+	FinishedAt  pgtype.Timestamptz `json:"finished_at"`
+}
+
+type Identity struct {
+	ID             pgtype.UUID        `json:"id"`
+	UserID         pgtype.UUID        `json:"user_id"`
+	Provider       string             `json:"provider"`
+	ProviderUserID string             `json:"provider_user_id"`
+	Email          string             `json:"email"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+}
+
+type Invitation struct {
+	ID         pgtype.UUID        `json:"id"`
+	Email      string             `json:"email"`
+	Role       string             `json:"role"`
+	TokenHash  string             `json:"token_hash"`
+	InvitedBy  pgtype.UUID        `json:"invited_by"`
+	ExpiresAt  pgtype.Timestamptz `json:"expires_at"`
+	AcceptedAt pgtype.Timestamptz `json:"accepted_at"`
+	RevokedAt  pgtype.Timestamptz `json:"revoked_at"`
+	CreatedAt  pgtype.Timestamptz `json:"created_at"`
+	OrgID      pgtype.UUID        `json:"org_id"`
+	OrgRole    string             `json:"org_role"`
+}
+
+type NotificationPreference struct {
+	UserID    pgtype.UUID        `json:"user_id"`
+	Category  string             `json:"category"`
+	Enabled   bool               `json:"enabled"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+}
+
+type Organization struct {
+	ID        pgtype.UUID        `json:"id"`
+	Name      string             `json:"name"`
+	Slug      string             `json:"slug"`
+	CreatedBy pgtype.UUID        `json:"created_by"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+}
+
+type OrganizationLimit struct {
+	OrgID          pgtype.UUID        `json:"org_id"`
+	RatePerMinute  pgtype.Int4        `json:"rate_per_minute"`
+	RequestsPerDay pgtype.Int4        `json:"requests_per_day"`
+	EmailsPerDay   pgtype.Int4        `json:"emails_per_day"`
+	UpdatedAt      pgtype.Timestamptz `json:"updated_at"`
+}
+
+type OrganizationMember struct {
+	OrgID     pgtype.UUID        `json:"org_id"`
 	UserID    pgtype.UUID        `json:"user_id"`
-	Email     pgtype.Text        `json:"email"`
-	Action    string             `json:"action"`
-	Ip        pgtype.Text        `json:"ip"`
-	UserAgent pgtype.Text        `json:"user_agent"`
-	Metadata  []byte             `json:"metadata"`
+	Role      string             `json:"role"`
 	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+}
+
+type PersonalAccessToken struct {
+	ID          pgtype.UUID        `json:"id"`
+	UserID      pgtype.UUID        `json:"user_id"`
+	Name        string             `json:"name"`
+	TokenPrefix string             `json:"token_prefix"`
+	TokenHash   string             `json:"token_hash"`
+	Scope       string             `json:"scope"`
+	ExpiresAt   pgtype.Timestamptz `json:"expires_at"`
+	LastUsedAt  pgtype.Timestamptz `json:"last_used_at"`
+	LastUsedIp  string             `json:"last_used_ip"`
+	RevokedAt   pgtype.Timestamptz `json:"revoked_at"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+}
+
+type Permission struct {
+	ID        pgtype.UUID        `json:"id"`
+	Name      string             `json:"name"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
 }
 
 type Role struct {
@@ -26,15 +171,50 @@ type Role struct {
 	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
 }
 
+type RolePermission struct {
+	RoleID       pgtype.UUID        `json:"role_id"`
+	PermissionID pgtype.UUID        `json:"permission_id"`
+	CreatedAt    pgtype.Timestamptz `json:"created_at"`
+}
+
+type SyncCheckpoint struct {
+	Name      string             `json:"name"`
+	LastID    int64              `json:"last_id"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+}
+
 type User struct {
-	ID         pgtype.UUID        `json:"id"`
-	Email      string             `json:"email"`
-	Password   string             `json:"password"`
-	Name       string             `json:"name"`
-	AvatarUrl  string             `json:"avatar_url"`
-	CreatedAt  pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt  pgtype.Timestamptz `json:"updated_at"`
-	IsVerified bool               `json:"is_verified"`
+	ID               pgtype.UUID        `json:"id"`
+	Email            string             `json:"email"`
+	Password         string             `json:"password"`
+	Name             string             `json:"name"`
+	AvatarUrl        string             `json:"avatar_url"`
+	CreatedAt        pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt        pgtype.Timestamptz `json:"updated_at"`
+	IsVerified       bool               `json:"is_verified"`
+	SuspendedAt      pgtype.Timestamptz `json:"suspended_at"`
+	BannedAt         pgtype.Timestamptz `json:"banned_at"`
+	SuspensionReason string             `json:"suspension_reason"`
+	NormalizedEmail  string             `json:"normalized_email"`
+	AvatarObjectPath string             `json:"avatar_object_path"`
+	ExternalID       string             `json:"external_id"`
+}
+
+type UserBackupCode struct {
+	UserID    pgtype.UUID        `json:"user_id"`
+	CodeHash  string             `json:"code_hash"`
+	UsedAt    pgtype.Timestamptz `json:"used_at"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+type UserEvent struct {
+	ID        int64              `json:"id"`
+	UserID    pgtype.UUID        `json:"user_id"`
+	Seq       int64              `json:"seq"`
+	Type      string             `json:"type"`
+	Actor     string             `json:"actor"`
+	Changes   []byte             `json:"changes"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
 }
 
 type UserRole struct {
@@ -42,3 +222,11 @@ type UserRole struct {
 	RoleID    pgtype.UUID        `json:"role_id"`
 	CreatedAt pgtype.Timestamptz `json:"created_at"`
 }
+
+type UserSecret struct {
+	UserID     pgtype.UUID        `json:"user_id"`
+	Name       string             `json:"name"`
+	Ciphertext string             `json:"ciphertext"`
+	KeyID      string             `json:"key_id"`
+	UpdatedAt  pgtype.Timestamptz `json:"updated_at"`
+}
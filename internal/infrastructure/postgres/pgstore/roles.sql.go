@@ -30,6 +30,52 @@ func (q *Queries) AssignRoleToUser(ctx context.Context, arg AssignRoleToUserPara
 	return result.RowsAffected(), nil
 }
 
+const assignRoleToUsers = `-- name: AssignRoleToUsers :many
+INSERT INTO user_roles (user_id, role_id)
+SELECT unnest($1::uuid[]), $2::uuid
+ON CONFLICT (user_id, role_id) DO NOTHING
+RETURNING user_id
+`
+
+type AssignRoleToUsersParams struct {
+	UserIds []pgtype.UUID `json:"user_ids"`
+	RoleID  pgtype.UUID   `json:"role_id"`
+}
+
+func (q *Queries) AssignRoleToUsers(ctx context.Context, arg AssignRoleToUsersParams) ([]pgtype.UUID, error) {
+	rows, err := q.db.Query(ctx, assignRoleToUsers, arg.UserIds, arg.RoleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []pgtype.UUID
+	for rows.Next() {
+		var user_id pgtype.UUID
+		if err := rows.Scan(&user_id); err != nil {
+			return nil, err
+		}
+		items = append(items, user_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countUsersWithRole = `-- name: CountUsersWithRole :one
+SELECT count(*)
+FROM user_roles ur
+JOIN roles r ON r.id = ur.role_id
+WHERE r.name = $1
+`
+
+func (q *Queries) CountUsersWithRole(ctx context.Context, name string) (int64, error) {
+	row := q.db.QueryRow(ctx, countUsersWithRole, name)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createRole = `-- name: CreateRole :one
 INSERT INTO roles (name)
 VALUES ($1)
@@ -131,6 +177,19 @@ func (q *Queries) ListRoles(ctx context.Context) ([]Role, error) {
 	return items, nil
 }
 
+const lockRole = `-- name: LockRole :one
+SELECT id FROM roles
+WHERE id = $1
+FOR UPDATE
+`
+
+// Serializes membership changes of one role
+func (q *Queries) LockRole(ctx context.Context, id pgtype.UUID) (pgtype.UUID, error) {
+	row := q.db.QueryRow(ctx, lockRole, id)
+	err := row.Scan(&id)
+	return id, err
+}
+
 const revokeRoleFromUser = `-- name: RevokeRoleFromUser :execrows
 DELETE FROM user_roles
 WHERE user_id = $1 AND role_id = $2
@@ -148,3 +207,66 @@ func (q *Queries) RevokeRoleFromUser(ctx context.Context, arg RevokeRoleFromUser
 	}
 	return result.RowsAffected(), nil
 }
+
+const revokeRoleFromUsers = `-- name: RevokeRoleFromUsers :many
+DELETE FROM user_roles
+WHERE role_id = $1 AND user_id = ANY($2::uuid[])
+RETURNING user_id
+`
+
+type RevokeRoleFromUsersParams struct {
+	RoleID  pgtype.UUID   `json:"role_id"`
+	UserIds []pgtype.UUID `json:"user_ids"`
+}
+
+func (q *Queries) RevokeRoleFromUsers(ctx context.Context, arg RevokeRoleFromUsersParams) ([]pgtype.UUID, error) {
+	rows, err := q.db.Query(ctx, revokeRoleFromUsers, arg.RoleID, arg.UserIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []pgtype.UUID
+	for rows.Next() {
+		var user_id pgtype.UUID
+		if err := rows.Scan(&user_id); err != nil {
+			return nil, err
+		}
+		items = append(items, user_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const revokeRoleFromUsersExcept = `-- name: RevokeRoleFromUsersExcept :many
+DELETE FROM user_roles
+WHERE role_id = $1 AND NOT (user_id = ANY($2::uuid[]))
+RETURNING user_id
+`
+
+type RevokeRoleFromUsersExceptParams struct {
+	RoleID  pgtype.UUID   `json:"role_id"`
+	UserIds []pgtype.UUID `json:"user_ids"`
+}
+
+// Everyone holding the role except user_ids loses it (group sync)
+func (q *Queries) RevokeRoleFromUsersExcept(ctx context.Context, arg RevokeRoleFromUsersExceptParams) ([]pgtype.UUID, error) {
+	rows, err := q.db.Query(ctx, revokeRoleFromUsersExcept, arg.RoleID, arg.UserIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []pgtype.UUID
+	for rows.Next() {
+		var user_id pgtype.UUID
+		if err := rows.Scan(&user_id); err != nil {
+			return nil, err
+		}
+		items = append(items, user_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -11,28 +11,76 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const countSearchUsers = `-- name: CountSearchUsers :one
+SELECT count(*)
+FROM users
+WHERE email ILIKE $1 OR name ILIKE $1
+`
+
+func (q *Queries) CountSearchUsers(ctx context.Context, pattern string) (int64, error) {
+	row := q.db.QueryRow(ctx, countSearchUsers, pattern)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countUsers = `-- name: CountUsers :one
+SELECT count(*) FROM users
+WHERE ($1::text IS NULL OR email LIKE $1 || '%')
+  AND ($2::boolean IS NULL OR is_verified = $2)
+  AND ($3::timestamptz IS NULL OR created_at >= $3)
+  AND ($4::timestamptz IS NULL OR created_at < $4)
+`
+
+type CountUsersParams struct {
+	EmailPrefix pgtype.Text        `json:"email_prefix"`
+	Verified    pgtype.Bool        `json:"verified"`
+	CreatedFrom pgtype.Timestamptz `json:"created_from"`
+	CreatedTo   pgtype.Timestamptz `json:"created_to"`
+}
+
+func (q *Queries) CountUsers(ctx context.Context, arg CountUsersParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countUsers,
+		arg.EmailPrefix,
+		arg.Verified,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createUser = `-- name: CreateUser :one
-INSERT INTO users (email, password, name, avatar_url)
-VALUES ($1, $2, $3, $4)
-RETURNING id, email, password, name, avatar_url, is_verified, created_at, updated_at
+INSERT INTO users (email, password, name, avatar_url, avatar_object_path, normalized_email, external_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, email, password, name, avatar_url, avatar_object_path, is_verified, suspended_at, banned_at, suspension_reason, created_at, updated_at, external_id
 `
 
 type CreateUserParams struct {
-	Email     string `json:"email"`
-	Password  string `json:"password"`
-	Name      string `json:"name"`
-	AvatarUrl string `json:"avatar_url"`
+	Email            string `json:"email"`
+	Password         string `json:"password"`
+	Name             string `json:"name"`
+	AvatarUrl        string `json:"avatar_url"`
+	AvatarObjectPath string `json:"avatar_object_path"`
+	NormalizedEmail  string `json:"normalized_email"`
+	ExternalID       string `json:"external_id"`
 }
 
 type CreateUserRow struct {
-	ID         pgtype.UUID        `json:"id"`
-	Email      string             `json:"email"`
-	Password   string             `json:"password"`
-	Name       string             `json:"name"`
-	AvatarUrl  string             `json:"avatar_url"`
-	IsVerified bool               `json:"is_verified"`
-	CreatedAt  pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt  pgtype.Timestamptz `json:"updated_at"`
+	ID               pgtype.UUID        `json:"id"`
+	Email            string             `json:"email"`
+	Password         string             `json:"password"`
+	Name             string             `json:"name"`
+	AvatarUrl        string             `json:"avatar_url"`
+	AvatarObjectPath string             `json:"avatar_object_path"`
+	IsVerified       bool               `json:"is_verified"`
+	SuspendedAt      pgtype.Timestamptz `json:"suspended_at"`
+	BannedAt         pgtype.Timestamptz `json:"banned_at"`
+	SuspensionReason string             `json:"suspension_reason"`
+	CreatedAt        pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt        pgtype.Timestamptz `json:"updated_at"`
+	ExternalID       string             `json:"external_id"`
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (CreateUserRow, error) {
@@ -41,6 +89,9 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (CreateU
 		arg.Password,
 		arg.Name,
 		arg.AvatarUrl,
+		arg.AvatarObjectPath,
+		arg.NormalizedEmail,
+		arg.ExternalID,
 	)
 	var i CreateUserRow
 	err := row.Scan(
@@ -49,32 +100,67 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (CreateU
 		&i.Password,
 		&i.Name,
 		&i.AvatarUrl,
+		&i.AvatarObjectPath,
 		&i.IsVerified,
+		&i.SuspendedAt,
+		&i.BannedAt,
+		&i.SuspensionReason,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ExternalID,
 	)
 	return i, err
 }
 
+const existingUserIDs = `-- name: ExistingUserIDs :many
+SELECT id FROM users
+WHERE id = ANY($1::uuid[])
+`
+
+func (q *Queries) ExistingUserIDs(ctx context.Context, ids []pgtype.UUID) ([]pgtype.UUID, error) {
+	rows, err := q.db.Query(ctx, existingUserIDs, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []pgtype.UUID
+	for rows.Next() {
+		var id pgtype.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, email, password, name, avatar_url, is_verified, created_at, updated_at
+SELECT id, email, password, name, avatar_url, avatar_object_path, is_verified, suspended_at, banned_at, suspension_reason, created_at, updated_at, external_id
 FROM users
-WHERE email = $1
+WHERE normalized_email = $1
 `
 
 type GetUserByEmailRow struct {
-	ID         pgtype.UUID        `json:"id"`
-	Email      string             `json:"email"`
-	Password   string             `json:"password"`
-	Name       string             `json:"name"`
-	AvatarUrl  string             `json:"avatar_url"`
-	IsVerified bool               `json:"is_verified"`
-	CreatedAt  pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt  pgtype.Timestamptz `json:"updated_at"`
-}
-
-func (q *Queries) GetUserByEmail(ctx context.Context, email string) (GetUserByEmailRow, error) {
-	row := q.db.QueryRow(ctx, getUserByEmail, email)
+	ID               pgtype.UUID        `json:"id"`
+	Email            string             `json:"email"`
+	Password         string             `json:"password"`
+	Name             string             `json:"name"`
+	AvatarUrl        string             `json:"avatar_url"`
+	AvatarObjectPath string             `json:"avatar_object_path"`
+	IsVerified       bool               `json:"is_verified"`
+	SuspendedAt      pgtype.Timestamptz `json:"suspended_at"`
+	BannedAt         pgtype.Timestamptz `json:"banned_at"`
+	SuspensionReason string             `json:"suspension_reason"`
+	CreatedAt        pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt        pgtype.Timestamptz `json:"updated_at"`
+	ExternalID       string             `json:"external_id"`
+}
+
+func (q *Queries) GetUserByEmail(ctx context.Context, normalizedEmail string) (GetUserByEmailRow, error) {
+	row := q.db.QueryRow(ctx, getUserByEmail, normalizedEmail)
 	var i GetUserByEmailRow
 	err := row.Scan(
 		&i.ID,
@@ -82,28 +168,81 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (GetUserByEm
 		&i.Password,
 		&i.Name,
 		&i.AvatarUrl,
+		&i.AvatarObjectPath,
 		&i.IsVerified,
+		&i.SuspendedAt,
+		&i.BannedAt,
+		&i.SuspensionReason,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ExternalID,
+	)
+	return i, err
+}
+
+const getUserByExternalID = `-- name: GetUserByExternalID :one
+SELECT id, email, password, name, avatar_url, avatar_object_path, is_verified, suspended_at, banned_at, suspension_reason, created_at, updated_at, external_id
+FROM users
+WHERE external_id = $1
+`
+
+type GetUserByExternalIDRow struct {
+	ID               pgtype.UUID        `json:"id"`
+	Email            string             `json:"email"`
+	Password         string             `json:"password"`
+	Name             string             `json:"name"`
+	AvatarUrl        string             `json:"avatar_url"`
+	AvatarObjectPath string             `json:"avatar_object_path"`
+	IsVerified       bool               `json:"is_verified"`
+	SuspendedAt      pgtype.Timestamptz `json:"suspended_at"`
+	BannedAt         pgtype.Timestamptz `json:"banned_at"`
+	SuspensionReason string             `json:"suspension_reason"`
+	CreatedAt        pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt        pgtype.Timestamptz `json:"updated_at"`
+	ExternalID       string             `json:"external_id"`
+}
+
+func (q *Queries) GetUserByExternalID(ctx context.Context, externalID string) (GetUserByExternalIDRow, error) {
+	row := q.db.QueryRow(ctx, getUserByExternalID, externalID)
+	var i GetUserByExternalIDRow
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.Password,
+		&i.Name,
+		&i.AvatarUrl,
+		&i.AvatarObjectPath,
+		&i.IsVerified,
+		&i.SuspendedAt,
+		&i.BannedAt,
+		&i.SuspensionReason,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ExternalID,
 	)
 	return i, err
 }
 
 const getUserByID = `-- name: GetUserByID :one
-SELECT id, email, password, name, avatar_url, is_verified, created_at, updated_at
+SELECT id, email, password, name, avatar_url, avatar_object_path, is_verified, suspended_at, banned_at, suspension_reason, created_at, updated_at, external_id
 FROM users
 WHERE id = $1
 `
 
 type GetUserByIDRow struct {
-	ID         pgtype.UUID        `json:"id"`
-	Email      string             `json:"email"`
-	Password   string             `json:"password"`
-	Name       string             `json:"name"`
-	AvatarUrl  string             `json:"avatar_url"`
-	IsVerified bool               `json:"is_verified"`
-	CreatedAt  pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt  pgtype.Timestamptz `json:"updated_at"`
+	ID               pgtype.UUID        `json:"id"`
+	Email            string             `json:"email"`
+	Password         string             `json:"password"`
+	Name             string             `json:"name"`
+	AvatarUrl        string             `json:"avatar_url"`
+	AvatarObjectPath string             `json:"avatar_object_path"`
+	IsVerified       bool               `json:"is_verified"`
+	SuspendedAt      pgtype.Timestamptz `json:"suspended_at"`
+	BannedAt         pgtype.Timestamptz `json:"banned_at"`
+	SuspensionReason string             `json:"suspension_reason"`
+	CreatedAt        pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt        pgtype.Timestamptz `json:"updated_at"`
+	ExternalID       string             `json:"external_id"`
 }
 
 func (q *Queries) GetUserByID(ctx context.Context, id pgtype.UUID) (GetUserByIDRow, error) {
@@ -115,7 +254,54 @@ func (q *Queries) GetUserByID(ctx context.Context, id pgtype.UUID) (GetUserByIDR
 		&i.Password,
 		&i.Name,
 		&i.AvatarUrl,
+		&i.AvatarObjectPath,
+		&i.IsVerified,
+		&i.SuspendedAt,
+		&i.BannedAt,
+		&i.SuspensionReason,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ExternalID,
+	)
+	return i, err
+}
+
+const getUserForUpdate = `-- name: GetUserForUpdate :one
+SELECT id, email, password, name, avatar_url, avatar_object_path, is_verified, suspended_at, banned_at, suspension_reason, created_at, updated_at
+FROM users
+WHERE id = $1
+FOR UPDATE
+`
+
+type GetUserForUpdateRow struct {
+	ID               pgtype.UUID        `json:"id"`
+	Email            string             `json:"email"`
+	Password         string             `json:"password"`
+	Name             string             `json:"name"`
+	AvatarUrl        string             `json:"avatar_url"`
+	AvatarObjectPath string             `json:"avatar_object_path"`
+	IsVerified       bool               `json:"is_verified"`
+	SuspendedAt      pgtype.Timestamptz `json:"suspended_at"`
+	BannedAt         pgtype.Timestamptz `json:"banned_at"`
+	SuspensionReason string             `json:"suspension_reason"`
+	CreatedAt        pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt        pgtype.Timestamptz `json:"updated_at"`
+}
+
+func (q *Queries) GetUserForUpdate(ctx context.Context, id pgtype.UUID) (GetUserForUpdateRow, error) {
+	row := q.db.QueryRow(ctx, getUserForUpdate, id)
+	var i GetUserForUpdateRow
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.Password,
+		&i.Name,
+		&i.AvatarUrl,
+		&i.AvatarObjectPath,
 		&i.IsVerified,
+		&i.SuspendedAt,
+		&i.BannedAt,
+		&i.SuspensionReason,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 	)
@@ -135,6 +321,227 @@ func (q *Queries) GetUserIsVerified(ctx context.Context, id pgtype.UUID) (bool,
 	return is_verified, err
 }
 
+const listUsers = `-- name: ListUsers :many
+SELECT id, email, name, avatar_url, is_verified, created_at, updated_at
+FROM users
+WHERE ($1::text IS NULL OR email LIKE $1 || '%')
+  AND ($2::boolean IS NULL OR is_verified = $2)
+  AND ($3::timestamptz IS NULL OR created_at >= $3)
+  AND ($4::timestamptz IS NULL OR created_at < $4)
+ORDER BY created_at DESC, id DESC
+LIMIT $5 OFFSET $6
+`
+
+type ListUsersParams struct {
+	EmailPrefix pgtype.Text        `json:"email_prefix"`
+	Verified    pgtype.Bool        `json:"verified"`
+	CreatedFrom pgtype.Timestamptz `json:"created_from"`
+	CreatedTo   pgtype.Timestamptz `json:"created_to"`
+	RowLimit    int32              `json:"row_limit"`
+	RowOffset   int32              `json:"row_offset"`
+}
+
+type ListUsersRow struct {
+	ID               pgtype.UUID        `json:"id"`
+	Email            string             `json:"email"`
+	Name             string             `json:"name"`
+	AvatarUrl        string             `json:"avatar_url"`
+	IsVerified       bool               `json:"is_verified"`
+	SuspendedAt      pgtype.Timestamptz `json:"suspended_at"`
+	BannedAt         pgtype.Timestamptz `json:"banned_at"`
+	SuspensionReason string             `json:"suspension_reason"`
+	CreatedAt        pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt        pgtype.Timestamptz `json:"updated_at"`
+}
+
+func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]ListUsersRow, error) {
+	rows, err := q.db.Query(ctx, listUsers,
+		arg.EmailPrefix,
+		arg.Verified,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.RowLimit,
+		arg.RowOffset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUsersRow
+	for rows.Next() {
+		var i ListUsersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Email,
+			&i.Name,
+			&i.AvatarUrl,
+			&i.IsVerified,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsersAfter = `-- name: ListUsersAfter :many
+SELECT id, email, name, avatar_url, is_verified, created_at, updated_at
+FROM users
+WHERE id > $1
+ORDER BY id
+LIMIT $2
+`
+
+type ListUsersAfterParams struct {
+	ID    pgtype.UUID `json:"id"`
+	Limit int32       `json:"limit"`
+}
+
+type ListUsersAfterRow struct {
+	ID               pgtype.UUID        `json:"id"`
+	Email            string             `json:"email"`
+	Name             string             `json:"name"`
+	AvatarUrl        string             `json:"avatar_url"`
+	IsVerified       bool               `json:"is_verified"`
+	SuspendedAt      pgtype.Timestamptz `json:"suspended_at"`
+	BannedAt         pgtype.Timestamptz `json:"banned_at"`
+	SuspensionReason string             `json:"suspension_reason"`
+	CreatedAt        pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt        pgtype.Timestamptz `json:"updated_at"`
+}
+
+func (q *Queries) ListUsersAfter(ctx context.Context, arg ListUsersAfterParams) ([]ListUsersAfterRow, error) {
+	rows, err := q.db.Query(ctx, listUsersAfter, arg.ID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUsersAfterRow
+	for rows.Next() {
+		var i ListUsersAfterRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Email,
+			&i.Name,
+			&i.AvatarUrl,
+			&i.IsVerified,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchUsers = `-- name: SearchUsers :many
+SELECT id, email, name, avatar_url, created_at, updated_at
+FROM users
+WHERE email ILIKE $1 OR name ILIKE $1
+ORDER BY (email ILIKE $1) DESC, created_at DESC, id DESC
+LIMIT $2 OFFSET $3
+`
+
+type SearchUsersParams struct {
+	Pattern   string `json:"pattern"`
+	RowLimit  int32  `json:"row_limit"`
+	RowOffset int32  `json:"row_offset"`
+}
+
+type SearchUsersRow struct {
+	ID        pgtype.UUID        `json:"id"`
+	Email     string             `json:"email"`
+	Name      string             `json:"name"`
+	AvatarUrl string             `json:"avatar_url"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+}
+
+// pattern is an ILIKE pattern; email matches rank first, as the Elasticsearch query boosts email
+func (q *Queries) SearchUsers(ctx context.Context, arg SearchUsersParams) ([]SearchUsersRow, error) {
+	rows, err := q.db.Query(ctx, searchUsers, arg.Pattern, arg.RowLimit, arg.RowOffset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchUsersRow
+	for rows.Next() {
+		var i SearchUsersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Email,
+			&i.Name,
+			&i.AvatarUrl,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setUserNormalizedEmail = `-- name: SetUserNormalizedEmail :execrows
+UPDATE users
+SET normalized_email = $2
+WHERE id = $1 AND normalized_email <> $2
+`
+
+type SetUserNormalizedEmailParams struct {
+	ID              pgtype.UUID `json:"id"`
+	NormalizedEmail string      `json:"normalized_email"`
+}
+
+func (q *Queries) SetUserNormalizedEmail(ctx context.Context, arg SetUserNormalizedEmailParams) (int64, error) {
+	result, err := q.db.Exec(ctx, setUserNormalizedEmail, arg.ID, arg.NormalizedEmail)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const setUserStatus = `-- name: SetUserStatus :execrows
+UPDATE users
+SET suspended_at = $2,
+    banned_at = $3,
+    suspension_reason = $4,
+    updated_at = now()
+WHERE id = $1
+`
+
+type SetUserStatusParams struct {
+	ID               pgtype.UUID        `json:"id"`
+	SuspendedAt      pgtype.Timestamptz `json:"suspended_at"`
+	BannedAt         pgtype.Timestamptz `json:"banned_at"`
+	SuspensionReason string             `json:"suspension_reason"`
+}
+
+func (q *Queries) SetUserStatus(ctx context.Context, arg SetUserStatusParams) (int64, error) {
+	result, err := q.db.Exec(ctx, setUserStatus,
+		arg.ID,
+		arg.SuspendedAt,
+		arg.BannedAt,
+		arg.SuspensionReason,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const setUserVerified = `-- name: SetUserVerified :execrows
 UPDATE users
 SET is_verified = true,
@@ -156,16 +563,20 @@ SET email = $2,
     password = $3,
     name = $4,
     avatar_url = $5,
+    avatar_object_path = $6,
+    normalized_email = $7,
     updated_at = now()
 WHERE id = $1
 `
 
 type UpdateUserParams struct {
-	ID        pgtype.UUID `json:"id"`
-	Email     string      `json:"email"`
-	Password  string      `json:"password"`
-	Name      string      `json:"name"`
-	AvatarUrl string      `json:"avatar_url"`
+	ID               pgtype.UUID `json:"id"`
+	Email            string      `json:"email"`
+	Password         string      `json:"password"`
+	Name             string      `json:"name"`
+	AvatarUrl        string      `json:"avatar_url"`
+	AvatarObjectPath string      `json:"avatar_object_path"`
+	NormalizedEmail  string      `json:"normalized_email"`
 }
 
 func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (int64, error) {
@@ -175,29 +586,11 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (int64,
 		arg.Password,
 		arg.Name,
 		arg.AvatarUrl,
+		arg.AvatarObjectPath,
+		arg.NormalizedEmail,
 	)
 	if err != nil {
 		return 0, err
 	}
 	return result.RowsAffected(), nil
 }
-
-const updateUserPassword = `-- name: UpdateUserPassword :execrows
-UPDATE users
-SET password = $2,
-    updated_at = now()
-WHERE id = $1
-`
-
-type UpdateUserPasswordParams struct {
-	ID       pgtype.UUID `json:"id"`
-	Password string      `json:"password"`
-}
-
-func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (int64, error) {
-	result, err := q.db.Exec(ctx, updateUserPassword, arg.ID, arg.Password)
-	if err != nil {
-		return 0, err
-	}
-	return result.RowsAffected(), nil
-}
@@ -2,12 +2,18 @@ package postgres
 
 import (
 	"context"
+	"net/url"
+	"strings"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-func NewPool(ctx context.Context, dsn string, maxConns, minConns int32, maxConnLife time.Duration) (*pgxpool.Pool, error) {
+// NewPool creates a pgx pool. With pgbouncer=true the pool is made safe for
+// PgBouncer transaction pooling: no server-side prepared statements or
+// statement/description caches, and idle connections are recycled quickly.
+func NewPool(ctx context.Context, dsn string, maxConns, minConns int32, maxConnLife time.Duration, pgbouncer bool) (*pgxpool.Pool, error) {
 	cfg, err := pgxpool.ParseConfig(dsn)
 	if err != nil {
 		return nil, err
@@ -15,6 +21,14 @@ func NewPool(ctx context.Context, dsn string, maxConns, minConns int32, maxConnL
 	cfg.MaxConns = maxConns
 	cfg.MinConns = minConns
 	cfg.MaxConnLifetime = maxConnLife
+	if pgbouncer {
+		cfg.ConnConfig.DefaultQueryExecMode = pgx.QueryExecModeSimpleProtocol
+		cfg.ConnConfig.StatementCacheCapacity = 0
+		cfg.ConnConfig.DescriptionCacheCapacity = 0
+		// PgBouncer owns the server connections; keep client-side idle conns short-lived
+		cfg.MaxConnIdleTime = 30 * time.Second
+		cfg.MinConns = 0
+	}
 	pool, err := pgxpool.NewWithConfig(ctx, cfg)
 	if err != nil {
 		return nil, err
@@ -27,3 +41,19 @@ func NewPool(ctx context.Context, dsn string, maxConns, minConns int32, maxConnL
 	}
 	return pool, nil
 }
+
+// StdlibDSN returns the DSN for database/sql (pgx stdlib) connections such as
+// migrations and the seeder. With pgbouncer=true it forces the simple protocol.
+func StdlibDSN(dsn string, pgbouncer bool) string {
+	if !pgbouncer {
+		return dsn
+	}
+	if u, err := url.Parse(dsn); err == nil && (u.Scheme == "postgres" || u.Scheme == "postgresql") {
+		q := u.Query()
+		q.Set("default_query_exec_mode", "simple_protocol")
+		u.RawQuery = q.Encode()
+		return u.String()
+	}
+	// keyword/value DSN
+	return strings.TrimSpace(dsn) + " default_query_exec_mode=simple_protocol"
+}
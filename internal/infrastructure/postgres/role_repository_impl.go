@@ -0,0 +1,338 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/infrastructure/postgres/pgstore"
+)
+
+type RoleRepository struct {
+	pool    *pgxpool.Pool
+	queries *pgstore.Queries
+}
+
+func NewRoleRepository(pool *pgxpool.Pool) *RoleRepository {
+	return &RoleRepository{pool: pool, queries: newQueries(pool)}
+}
+
+// toPGUUID parses a string id into a valid pgtype.UUID
+func toPGUUID(id string) (pgtype.UUID, error) {
+	var pgID pgtype.UUID
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return pgID, err
+	}
+	pgID.Bytes = parsed
+	pgID.Valid = true
+	return pgID, nil
+}
+
+func uuidString(id pgtype.UUID) string {
+	if !id.Valid {
+		return ""
+	}
+	return uuid.UUID(id.Bytes).String()
+}
+
+func timeOf(ts pgtype.Timestamptz) time.Time {
+	if !ts.Valid {
+		return time.Time{}
+	}
+	return ts.Time
+}
+
+func mapRole(r pgstore.Role) entity.Role {
+	return entity.Role{
+		ID:        uuidString(r.ID),
+		Name:      r.Name,
+		CreatedAt: timeOf(r.CreatedAt),
+		UpdatedAt: timeOf(r.UpdatedAt),
+	}
+}
+
+func mapPermission(p pgstore.Permission) entity.Permission {
+	return entity.Permission{
+		ID:        uuidString(p.ID),
+		Name:      p.Name,
+		CreatedAt: timeOf(p.CreatedAt),
+		UpdatedAt: timeOf(p.UpdatedAt),
+	}
+}
+
+func (r *RoleRepository) CreateRole(name string) (*entity.Role, error) {
+	row, err := r.queries.CreateRole(context.Background(), name)
+	if err != nil {
+		return nil, err
+	}
+	role := mapRole(row)
+	return &role, nil
+}
+
+func (r *RoleRepository) GetRoleByName(name string) (*entity.Role, error) {
+	row, err := r.queries.GetRoleByName(context.Background(), name)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errNotFound
+		}
+		return nil, err
+	}
+	role := mapRole(row)
+	return &role, nil
+}
+
+func (r *RoleRepository) ListRoles() ([]entity.Role, error) {
+	rows, err := r.queries.ListRoles(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	out := make([]entity.Role, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, mapRole(row))
+	}
+	return out, nil
+}
+
+func (r *RoleRepository) AssignRole(userID, roleID string) error {
+	uid, err := toPGUUID(userID)
+	if err != nil {
+		return err
+	}
+	rid, err := toPGUUID(roleID)
+	if err != nil {
+		return err
+	}
+	_, err = r.queries.AssignRoleToUser(context.Background(), pgstore.AssignRoleToUserParams{UserID: uid, RoleID: rid})
+	return err
+}
+
+func (r *RoleRepository) RevokeRole(userID, roleID string) error {
+	uid, err := toPGUUID(userID)
+	if err != nil {
+		return err
+	}
+	rid, err := toPGUUID(roleID)
+	if err != nil {
+		return err
+	}
+	rows, err := r.queries.RevokeRoleFromUser(context.Background(), pgstore.RevokeRoleFromUserParams{UserID: uid, RoleID: rid})
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errNotFound
+	}
+	return nil
+}
+
+func (r *RoleRepository) GetUserRoles(userID string) ([]entity.Role, error) {
+	uid, err := toPGUUID(userID)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.queries.GetUserRoles(context.Background(), uid)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]entity.Role, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, mapRole(row))
+	}
+	return out, nil
+}
+
+func (r *RoleRepository) CountUsersWithRole(name string) (int64, error) {
+	return r.queries.CountUsersWithRole(context.Background(), name)
+}
+
+// roleMembers parses the role and user ids for the bulk membership queries
+func roleMembers(roleID string, userIDs []string) (pgtype.UUID, []pgtype.UUID, error) {
+	rid, err := toPGUUID(roleID)
+	if err != nil {
+		return rid, nil, err
+	}
+	uids := make([]pgtype.UUID, 0, len(userIDs))
+	for _, id := range userIDs {
+		uid, err := toPGUUID(id)
+		if err != nil {
+			return rid, nil, err
+		}
+		uids = append(uids, uid)
+	}
+	return rid, uids, nil
+}
+
+func uuidStrings(ids []pgtype.UUID) []string {
+	out := make([]string, 0, len(ids))
+	for _, id := range ids {
+		out = append(out, uuidString(id))
+	}
+	return out
+}
+
+// membership runs fn in a transaction holding the role's row lock, so concurrent bulk changes
+// and syncs of one role apply one after another
+func (r *RoleRepository) membership(ctx context.Context, roleID string, userIDs []string, fn func(q *pgstore.Queries, rid pgtype.UUID, uids []pgtype.UUID) error) error {
+	rid, uids, err := roleMembers(roleID, userIDs)
+	if err != nil {
+		return err
+	}
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return classify(err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+	q := newQueries(tx)
+	if _, err := q.LockRole(ctx, rid); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errNotFound
+		}
+		return err
+	}
+	if err := fn(q, rid, uids); err != nil {
+		return err
+	}
+	return classify(tx.Commit(ctx))
+}
+
+func (r *RoleRepository) AssignRoleBulk(ctx context.Context, roleID string, userIDs []string) ([]string, error) {
+	var added []string
+	err := r.membership(ctx, roleID, userIDs, func(q *pgstore.Queries, rid pgtype.UUID, uids []pgtype.UUID) error {
+		rows, err := q.AssignRoleToUsers(ctx, pgstore.AssignRoleToUsersParams{UserIds: uids, RoleID: rid})
+		added = uuidStrings(rows)
+		return err
+	})
+	return added, err
+}
+
+func (r *RoleRepository) RevokeRoleBulk(ctx context.Context, roleID string, userIDs []string) ([]string, error) {
+	var removed []string
+	err := r.membership(ctx, roleID, userIDs, func(q *pgstore.Queries, rid pgtype.UUID, uids []pgtype.UUID) error {
+		rows, err := q.RevokeRoleFromUsers(ctx, pgstore.RevokeRoleFromUsersParams{RoleID: rid, UserIds: uids})
+		removed = uuidStrings(rows)
+		return err
+	})
+	return removed, err
+}
+
+func (r *RoleRepository) SyncRoleMembers(ctx context.Context, roleID string, userIDs []string) ([]string, []string, error) {
+	var added, removed []string
+	err := r.membership(ctx, roleID, userIDs, func(q *pgstore.Queries, rid pgtype.UUID, uids []pgtype.UUID) error {
+		rows, err := q.RevokeRoleFromUsersExcept(ctx, pgstore.RevokeRoleFromUsersExceptParams{RoleID: rid, UserIds: uids})
+		if err != nil {
+			return err
+		}
+		removed = uuidStrings(rows)
+		rows, err = q.AssignRoleToUsers(ctx, pgstore.AssignRoleToUsersParams{UserIds: uids, RoleID: rid})
+		added = uuidStrings(rows)
+		return err
+	})
+	return added, removed, err
+}
+
+func (r *RoleRepository) CreatePermission(name string) (*entity.Permission, error) {
+	row, err := r.queries.CreatePermission(context.Background(), name)
+	if err != nil {
+		return nil, err
+	}
+	p := mapPermission(row)
+	return &p, nil
+}
+
+func (r *RoleRepository) GetPermissionByName(name string) (*entity.Permission, error) {
+	row, err := r.queries.GetPermissionByName(context.Background(), name)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errNotFound
+		}
+		return nil, err
+	}
+	p := mapPermission(row)
+	return &p, nil
+}
+
+func (r *RoleRepository) ListPermissions() ([]entity.Permission, error) {
+	rows, err := r.queries.ListPermissions(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	out := make([]entity.Permission, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, mapPermission(row))
+	}
+	return out, nil
+}
+
+func (r *RoleRepository) AttachPermission(roleID, permissionID string) error {
+	rid, err := toPGUUID(roleID)
+	if err != nil {
+		return err
+	}
+	pid, err := toPGUUID(permissionID)
+	if err != nil {
+		return err
+	}
+	_, err = r.queries.AttachPermissionToRole(context.Background(), pgstore.AttachPermissionToRoleParams{RoleID: rid, PermissionID: pid})
+	return err
+}
+
+func (r *RoleRepository) DetachPermission(roleID, permissionID string) error {
+	rid, err := toPGUUID(roleID)
+	if err != nil {
+		return err
+	}
+	pid, err := toPGUUID(permissionID)
+	if err != nil {
+		return err
+	}
+	rows, err := r.queries.DetachPermissionFromRole(context.Background(), pgstore.DetachPermissionFromRoleParams{RoleID: rid, PermissionID: pid})
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errNotFound
+	}
+	return nil
+}
+
+func (r *RoleRepository) GetRolePermissions(roleID string) ([]entity.Permission, error) {
+	rid, err := toPGUUID(roleID)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.queries.GetRolePermissions(context.Background(), rid)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]entity.Permission, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, mapPermission(row))
+	}
+	return out, nil
+}
+
+func (r *RoleRepository) GetUserPermissions(userID string) ([]entity.Permission, error) {
+	uid, err := toPGUUID(userID)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.queries.GetUserPermissions(context.Background(), uid)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]entity.Permission, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, mapPermission(row))
+	}
+	return out, nil
+}
+
+var _ repository.RoleRepository = (*RoleRepository)(nil)
@@ -0,0 +1,80 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/infrastructure/postgres/pgstore"
+)
+
+type UsageRepository struct {
+	pool    *pgxpool.Pool
+	queries *pgstore.Queries
+}
+
+func NewUsageRepository(pool *pgxpool.Pool) *UsageRepository {
+	return &UsageRepository{pool: pool, queries: newQueries(pool)}
+}
+
+func (r *UsageRepository) Add(records []entity.UsageRecord) error {
+	ctx := context.Background()
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return classify(err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+	q := newQueries(tx)
+	for _, rec := range records {
+		if err := q.AddApiUsage(ctx, pgstore.AddApiUsageParams{
+			Bucket:   pgtype.Timestamptz{Time: rec.Bucket, Valid: true},
+			Subject:  rec.Subject,
+			Method:   rec.Method,
+			Route:    rec.Route,
+			Requests: rec.Requests,
+			BytesIn:  rec.BytesIn,
+			BytesOut: rec.BytesOut,
+		}); err != nil {
+			return err
+		}
+	}
+	return classify(tx.Commit(ctx))
+}
+
+func usageRange(from, to time.Time, subject string) (pgtype.Timestamptz, pgtype.Timestamptz, pgtype.Text) {
+	return pgtype.Timestamptz{Time: from, Valid: true},
+		pgtype.Timestamptz{Time: to, Valid: true},
+		pgtype.Text{String: subject, Valid: subject != ""}
+}
+
+func (r *UsageRepository) SummaryBySubject(from, to time.Time, subject string, limit int) ([]entity.UsageSummary, error) {
+	f, t, s := usageRange(from, to, subject)
+	rows, err := r.queries.SummarizeApiUsageBySubject(context.Background(), pgstore.SummarizeApiUsageBySubjectParams{FromTime: f, ToTime: t, Subject: s, RowLimit: int32(limit)})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]entity.UsageSummary, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, entity.UsageSummary{Subject: row.Subject, Requests: row.Requests, BytesIn: row.BytesIn, BytesOut: row.BytesOut})
+	}
+	return out, nil
+}
+
+func (r *UsageRepository) SummaryByRoute(from, to time.Time, subject string, limit int) ([]entity.UsageSummary, error) {
+	f, t, s := usageRange(from, to, subject)
+	rows, err := r.queries.SummarizeApiUsageByRoute(context.Background(), pgstore.SummarizeApiUsageByRouteParams{FromTime: f, ToTime: t, Subject: s, RowLimit: int32(limit)})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]entity.UsageSummary, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, entity.UsageSummary{Method: row.Method, Route: row.Route, Requests: row.Requests, BytesIn: row.BytesIn, BytesOut: row.BytesOut})
+	}
+	return out, nil
+}
+
+var _ repository.UsageRepository = (*UsageRepository)(nil)
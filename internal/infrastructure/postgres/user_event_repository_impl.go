@@ -0,0 +1,89 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/infrastructure/postgres/pgstore"
+)
+
+type UserEventRepository struct {
+	queries *pgstore.Queries
+}
+
+func NewUserEventRepository(pool *pgxpool.Pool) *UserEventRepository {
+	return &UserEventRepository{queries: newQueries(pool)}
+}
+
+func (r *UserEventRepository) ListByUser(ctx context.Context, userID string, afterSeq int64, limit int) ([]entity.UserEvent, error) {
+	uid, err := toPGUUID(userID)
+	if err != nil {
+		return nil, nil // a malformed id has no history
+	}
+	rows, err := r.queries.ListUserEvents(ctx, pgstore.ListUserEventsParams{UserID: uid, Seq: afterSeq, Limit: int32(limit)})
+	if err != nil {
+		return nil, err
+	}
+	return mapUserEvents(rows), nil
+}
+
+func (r *UserEventRepository) ListByUserSince(ctx context.Context, userID string, since time.Time, limit int) ([]entity.UserEvent, error) {
+	uid, err := toPGUUID(userID)
+	if err != nil {
+		return nil, nil
+	}
+	rows, err := r.queries.ListUserEventsSince(ctx, pgstore.ListUserEventsSinceParams{UserID: uid, CreatedAt: pgtype.Timestamptz{Time: since, Valid: true}, Limit: int32(limit)})
+	if err != nil {
+		return nil, err
+	}
+	return mapUserEvents(rows), nil
+}
+
+func (r *UserEventRepository) ListAfter(ctx context.Context, afterID int64, limit int) ([]entity.UserEvent, error) {
+	rows, err := r.queries.ListUserEventsAfter(ctx, pgstore.ListUserEventsAfterParams{ID: afterID, Limit: int32(limit)})
+	if err != nil {
+		return nil, err
+	}
+	return mapUserEvents(rows), nil
+}
+
+func (r *UserEventRepository) Checkpoint(ctx context.Context, name string) (int64, error) {
+	id, err := r.queries.GetSyncCheckpoint(ctx, name)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	return id, err
+}
+
+func (r *UserEventRepository) SetCheckpoint(ctx context.Context, name string, lastID int64) error {
+	return r.queries.SetSyncCheckpoint(ctx, pgstore.SetSyncCheckpointParams{Name: name, LastID: lastID})
+}
+
+func mapUserEvents(rows []pgstore.UserEvent) []entity.UserEvent {
+	out := make([]entity.UserEvent, 0, len(rows))
+	for _, row := range rows {
+		ev := entity.UserEvent{
+			ID:        row.ID,
+			UserID:    uuidString(row.UserID),
+			Seq:       row.Seq,
+			Type:      row.Type,
+			Actor:     row.Actor,
+			CreatedAt: timeOf(row.CreatedAt),
+		}
+		if len(row.Changes) > 0 {
+			_ = json.Unmarshal(row.Changes, &ev.Changes)
+		}
+		out = append(out, ev)
+	}
+	return out
+}
+
+var _ repository.UserEventRepository = (*UserEventRepository)(nil)
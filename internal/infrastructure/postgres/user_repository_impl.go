@@ -2,7 +2,9 @@ package postgres
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,19 +15,21 @@ import (
 	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
 	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
 	"github.com/oksasatya/go-ddd-clean-architecture/internal/infrastructure/postgres/pgstore"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
 )
 
-var (
-	errNotFound = errors.New("not found")
-)
+// errNotFound is returned for missing rows the queries report without pgx.ErrNoRows
+var errNotFound = repository.ErrNotFound
 
 type UserRepository struct {
 	pool    *pgxpool.Pool
 	queries *pgstore.Queries
+	// Dispatch receives the user's domain events after a successful Create or Update; nil drops them
+	Dispatch repository.EventDispatcher
 }
 
 func NewUserRepository(pool *pgxpool.Pool) *UserRepository {
-	return &UserRepository{pool: pool, queries: pgstore.New(pool)}
+	return &UserRepository{pool: pool, queries: newQueries(pool)}
 }
 
 // map helpers for sqlc rows
@@ -44,6 +48,7 @@ func mapCreateRow(u pgstore.CreateUserRow) *entity.User {
 	}
 	return &entity.User{
 		ID:         idStr,
+		ExternalID: u.ExternalID,
 		Email:      u.Email,
 		Password:   u.Password,
 		Name:       u.Name,
@@ -68,14 +73,19 @@ func mapGetByIDRow(u pgstore.GetUserByIDRow) *entity.User {
 		updatedAt = u.UpdatedAt.Time
 	}
 	return &entity.User{
-		ID:         idStr,
-		Email:      u.Email,
-		Password:   u.Password,
-		Name:       u.Name,
-		AvatarURL:  u.AvatarUrl,
-		IsVerified: u.IsVerified,
-		CreatedAt:  createdAt,
-		UpdatedAt:  updatedAt,
+		ID:               idStr,
+		ExternalID:       u.ExternalID,
+		Email:            u.Email,
+		Password:         u.Password,
+		Name:             u.Name,
+		AvatarURL:        u.AvatarUrl,
+		AvatarObjectPath: u.AvatarObjectPath,
+		IsVerified:       u.IsVerified,
+		CreatedAt:        createdAt,
+		UpdatedAt:        updatedAt,
+		SuspendedAt:      optTime(u.SuspendedAt),
+		BannedAt:         optTime(u.BannedAt),
+		SuspensionReason: u.SuspensionReason,
 	}
 }
 
@@ -93,32 +103,115 @@ func mapGetByEmailRow(u pgstore.GetUserByEmailRow) *entity.User {
 		updatedAt = u.UpdatedAt.Time
 	}
 	return &entity.User{
-		ID:         idStr,
-		Email:      u.Email,
-		Password:   u.Password,
-		Name:       u.Name,
-		AvatarURL:  u.AvatarUrl,
-		IsVerified: u.IsVerified,
-		CreatedAt:  createdAt,
-		UpdatedAt:  updatedAt,
+		ID:               idStr,
+		ExternalID:       u.ExternalID,
+		Email:            u.Email,
+		Password:         u.Password,
+		Name:             u.Name,
+		AvatarURL:        u.AvatarUrl,
+		AvatarObjectPath: u.AvatarObjectPath,
+		IsVerified:       u.IsVerified,
+		CreatedAt:        createdAt,
+		UpdatedAt:        updatedAt,
+		SuspendedAt:      optTime(u.SuspendedAt),
+		BannedAt:         optTime(u.BannedAt),
+		SuspensionReason: u.SuspensionReason,
 	}
 }
 
-func (r *UserRepository) Create(u *entity.User) error {
-	ctx := context.Background()
-	created, err := r.queries.CreateUser(ctx, pgstore.CreateUserParams{
-		Email:     u.Email,
-		Password:  u.Password,
-		Name:      u.Name,
-		AvatarUrl: u.AvatarURL,
+func (r *UserRepository) Create(ctx context.Context, u *entity.User) error {
+	err := r.inTx(ctx, func(q *pgstore.Queries) error {
+		if u.ExternalID == "" {
+			u.ExternalID = helpers.NewULID()
+		}
+		created, err := q.CreateUser(ctx, pgstore.CreateUserParams{
+			Email:            u.Email,
+			Password:         u.Password,
+			Name:             u.Name,
+			AvatarUrl:        u.AvatarURL,
+			AvatarObjectPath: u.AvatarObjectPath,
+			NormalizedEmail:  helpers.NormalizeEmail(u.Email),
+			ExternalID:       u.ExternalID,
+		})
+		if err != nil {
+			return err
+		}
+		mapped := mapCreateRow(created)
+		u.ID = mapped.ID
+		u.CreatedAt = mapped.CreatedAt
+		u.UpdatedAt = mapped.UpdatedAt
+		return appendUserEvent(ctx, q, created.ID, entity.UserEventCreated, map[string]entity.FieldChange{
+			"email":       {After: created.Email},
+			"name":        {After: created.Name},
+			"avatar_url":  {After: created.AvatarUrl},
+			"is_verified": {After: created.IsVerified},
+		})
+	})
+	if err != nil {
+		return err
+	}
+	r.dispatch(ctx, u)
+	return nil
+}
+
+// CreateWithRoles creates u, verified when u.IsVerified, and grants roleIDs in one transaction
+func (r *UserRepository) CreateWithRoles(ctx context.Context, u *entity.User, roleIDs ...string) error {
+	rids := make([]pgtype.UUID, 0, len(roleIDs))
+	for _, id := range roleIDs {
+		rid, err := toPGUUID(id)
+		if err != nil {
+			return err
+		}
+		rids = append(rids, rid)
+	}
+	err := r.inTx(ctx, func(q *pgstore.Queries) error {
+		if u.ExternalID == "" {
+			u.ExternalID = helpers.NewULID()
+		}
+		created, err := q.CreateUser(ctx, pgstore.CreateUserParams{
+			Email:            u.Email,
+			Password:         u.Password,
+			Name:             u.Name,
+			AvatarUrl:        u.AvatarURL,
+			AvatarObjectPath: u.AvatarObjectPath,
+			NormalizedEmail:  helpers.NormalizeEmail(u.Email),
+			ExternalID:       u.ExternalID,
+		})
+		if err != nil {
+			return err
+		}
+		if err := appendUserEvent(ctx, q, created.ID, entity.UserEventCreated, map[string]entity.FieldChange{
+			"email":       {After: created.Email},
+			"name":        {After: created.Name},
+			"avatar_url":  {After: created.AvatarUrl},
+			"is_verified": {After: created.IsVerified},
+		}); err != nil {
+			return err
+		}
+		if u.IsVerified && !created.IsVerified {
+			if _, err := q.SetUserVerified(ctx, created.ID); err != nil {
+				return err
+			}
+			if err := appendUserEvent(ctx, q, created.ID, entity.UserEventVerified, map[string]entity.FieldChange{"is_verified": {Before: false, After: true}}); err != nil {
+				return err
+			}
+		}
+		for _, rid := range rids {
+			if _, err := q.AssignRoleToUser(ctx, pgstore.AssignRoleToUserParams{UserID: created.ID, RoleID: rid}); err != nil {
+				return err
+			}
+		}
+		mapped := mapCreateRow(created)
+		u.ID = mapped.ID
+		u.CreatedAt = mapped.CreatedAt
+		u.UpdatedAt = mapped.UpdatedAt
+		u.IsVerified = u.IsVerified || created.IsVerified
+		return nil
 	})
 	if err != nil {
 		return err
 	}
-	mapped := mapCreateRow(created)
-	u.ID = mapped.ID
-	u.CreatedAt = mapped.CreatedAt
-	u.UpdatedAt = mapped.UpdatedAt
+	r.dispatch(ctx, u)
 	return nil
 }
 
@@ -141,9 +234,26 @@ func (r *UserRepository) GetByID(id string) (*entity.User, error) {
 	return mapGetByIDRow(row), nil
 }
 
+// GetByExternalID accepts the ULID in either case; a malformed one is simply not found
+func (r *UserRepository) GetByExternalID(id string) (*entity.User, error) {
+	canonical, _, err := helpers.ParseULID(id)
+	if err != nil {
+		return nil, errNotFound
+	}
+	row, err := r.queries.GetUserByExternalID(context.Background(), canonical)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errNotFound
+		}
+		return nil, err
+	}
+	return mapGetByIDRow(pgstore.GetUserByIDRow(row)), nil
+}
+
+// GetByEmail matches on the normalized address, so any alias the email policy folds finds the user
 func (r *UserRepository) GetByEmail(email string) (*entity.User, error) {
 	ctx := context.Background()
-	row, err := r.queries.GetUserByEmail(ctx, email)
+	row, err := r.queries.GetUserByEmail(ctx, helpers.NormalizeEmail(email))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, errNotFound
@@ -153,52 +263,83 @@ func (r *UserRepository) GetByEmail(email string) (*entity.User, error) {
 	return mapGetByEmailRow(row), nil
 }
 
-func (r *UserRepository) Update(u *entity.User) error {
-	ctx := context.Background()
-	parsed, err := uuid.Parse(u.ID)
+// Update records only the fields that actually changed; a no-op update leaves no event.
+// A change to the password alone is recorded as password_changed.
+func (r *UserRepository) Update(ctx context.Context, u *entity.User) error {
+	pgID, err := toPGUUID(u.ID)
 	if err != nil {
 		return err
 	}
-	var pgID pgtype.UUID
-	pgID.Bytes = parsed
-	pgID.Valid = true
-	rows, err := r.queries.UpdateUser(ctx, pgstore.UpdateUserParams{
-		ID:        pgID,
-		Email:     u.Email,
-		Password:  u.Password,
-		Name:      u.Name,
-		AvatarUrl: u.AvatarURL,
+	err = r.inTx(ctx, func(q *pgstore.Queries) error {
+		before, err := lockUser(ctx, q, pgID)
+		if err != nil {
+			return err
+		}
+		if _, err := q.UpdateUser(ctx, pgstore.UpdateUserParams{
+			ID:               pgID,
+			Email:            u.Email,
+			Password:         u.Password,
+			Name:             u.Name,
+			AvatarUrl:        u.AvatarURL,
+			AvatarObjectPath: u.AvatarObjectPath,
+			NormalizedEmail:  helpers.NormalizeEmail(u.Email),
+		}); err != nil {
+			return err
+		}
+		u.UpdatedAt = time.Now()
+		changes := map[string]entity.FieldChange{}
+		diffField(changes, "email", before.Email, u.Email)
+		diffField(changes, "name", before.Name, u.Name)
+		diffField(changes, "avatar_url", before.AvatarUrl, u.AvatarURL)
+		if before.Password != u.Password {
+			changes["password"] = redacted
+		}
+		if len(changes) == 0 {
+			return nil
+		}
+		typ := entity.UserEventUpdated
+		if _, ok := changes["password"]; ok && len(changes) == 1 {
+			typ = entity.UserEventPasswordChanged
+		}
+		return appendUserEvent(ctx, q, pgID, typ, changes)
 	})
 	if err != nil {
 		return err
 	}
-	if rows == 0 {
-		return errNotFound
-	}
-	u.UpdatedAt = time.Now()
+	r.dispatch(ctx, u)
 	return nil
 }
 
-func (r *UserRepository) UpdatePassword(userID string, passwordHash string) error {
-	ctx := context.Background()
-	parsed, err := uuid.Parse(userID)
-	if err != nil {
-		return err
-	}
-	var pgID pgtype.UUID
-	pgID.Bytes = parsed
-	pgID.Valid = true
-	rows, err := r.queries.UpdateUserPassword(ctx, pgstore.UpdateUserPasswordParams{
-		ID:       pgID,
-		Password: passwordHash,
-	})
-	if err != nil {
-		return err
-	}
-	if rows == 0 {
-		return errNotFound
+// NormalizeEmails recomputes normalized_email for every user under the current email policy, for
+// after a fold was enabled. Users whose new key belongs to another account keep their old key
+// and are returned as conflicts (ids) for an operator to merge or rename.
+func (r *UserRepository) NormalizeEmails(ctx context.Context) (updated int, conflicts []string, err error) {
+	after := ""
+	for {
+		page, err := r.ListAfter(after, 500)
+		if err != nil {
+			return updated, conflicts, err
+		}
+		for _, u := range page {
+			pgID, err := toPGUUID(u.ID)
+			if err != nil {
+				return updated, conflicts, err
+			}
+			n, err := r.queries.SetUserNormalizedEmail(ctx, pgstore.SetUserNormalizedEmailParams{ID: pgID, NormalizedEmail: helpers.NormalizeEmail(u.Email)})
+			switch {
+			case errors.Is(err, repository.ErrConflict):
+				conflicts = append(conflicts, u.ID)
+			case err != nil:
+				return updated, conflicts, err
+			default:
+				updated += int(n)
+			}
+		}
+		if len(page) < 500 {
+			return updated, conflicts, nil
+		}
+		after = page[len(page)-1].ID
 	}
-	return nil
 }
 
 func (r *UserRepository) IsVerified(userID string) (bool, error) {
@@ -220,23 +361,223 @@ func (r *UserRepository) IsVerified(userID string) (bool, error) {
 	return v, nil
 }
 
-func (r *UserRepository) SetVerified(userID string) error {
-	ctx := context.Background()
-	parsed, err := uuid.Parse(userID)
+// SetVerified records an event only on the first verification
+func (r *UserRepository) SetVerified(ctx context.Context, userID string) error {
+	pgID, err := toPGUUID(userID)
 	if err != nil {
 		return err
 	}
-	var id pgtype.UUID
-	id.Bytes = parsed
-	id.Valid = true
-	rows, err := r.queries.SetUserVerified(ctx, id)
+	return r.inTx(ctx, func(q *pgstore.Queries) error {
+		before, err := lockUser(ctx, q, pgID)
+		if err != nil {
+			return err
+		}
+		if _, err := q.SetUserVerified(ctx, pgID); err != nil {
+			return err
+		}
+		if before.IsVerified {
+			return nil
+		}
+		return appendUserEvent(ctx, q, pgID, entity.UserEventVerified, map[string]entity.FieldChange{"is_verified": {Before: false, After: true}})
+	})
+}
+
+// SetStatus records a status_changed event when the suspension, ban or reason changes
+func (r *UserRepository) SetStatus(ctx context.Context, userID string, suspendedAt, bannedAt *time.Time, reason string) error {
+	pgID, err := toPGUUID(userID)
 	if err != nil {
 		return err
 	}
-	if rows == 0 {
-		return errNotFound
+	return r.inTx(ctx, func(q *pgstore.Queries) error {
+		before, err := lockUser(ctx, q, pgID)
+		if err != nil {
+			return err
+		}
+		if _, err := q.SetUserStatus(ctx, pgstore.SetUserStatusParams{
+			ID:               pgID,
+			SuspendedAt:      pgTime(suspendedAt),
+			BannedAt:         pgTime(bannedAt),
+			SuspensionReason: reason,
+		}); err != nil {
+			return err
+		}
+		changes := map[string]entity.FieldChange{}
+		if before.SuspendedAt.Valid != (suspendedAt != nil) {
+			changes["suspended"] = entity.FieldChange{Before: before.SuspendedAt.Valid, After: suspendedAt != nil}
+		}
+		if before.BannedAt.Valid != (bannedAt != nil) {
+			changes["banned"] = entity.FieldChange{Before: before.BannedAt.Valid, After: bannedAt != nil}
+		}
+		diffField(changes, "suspension_reason", before.SuspensionReason, reason)
+		if len(changes) == 0 {
+			return nil
+		}
+		return appendUserEvent(ctx, q, pgID, entity.UserEventStatusChanged, changes)
+	})
+}
+
+func (r *UserRepository) ListAfter(afterID string, limit int) ([]entity.User, error) {
+	after := pgtype.UUID{Valid: true} // zero UUID sorts first
+	if afterID != "" {
+		parsed, err := uuid.Parse(afterID)
+		if err != nil {
+			return nil, err
+		}
+		after.Bytes = parsed
 	}
-	return nil
+	rows, err := r.queries.ListUsersAfter(context.Background(), pgstore.ListUsersAfterParams{ID: after, Limit: int32(limit)})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]entity.User, 0, len(rows))
+	for _, u := range rows {
+		out = append(out, entity.User{
+			ID:         uuidString(u.ID),
+			Email:      u.Email,
+			Name:       u.Name,
+			AvatarURL:  u.AvatarUrl,
+			IsVerified: u.IsVerified,
+			CreatedAt:  timeOf(u.CreatedAt),
+			UpdatedAt:  timeOf(u.UpdatedAt),
+		})
+	}
+	return out, nil
+}
+
+// userFilterArgs converts f to nullable query arguments; LIKE wildcards in the prefix match literally
+func userFilterArgs(f entity.UserFilter) pgstore.CountUsersParams {
+	arg := pgstore.CountUsersParams{
+		CreatedFrom: pgtype.Timestamptz{Time: f.CreatedFrom, Valid: !f.CreatedFrom.IsZero()},
+		CreatedTo:   pgtype.Timestamptz{Time: f.CreatedTo, Valid: !f.CreatedTo.IsZero()},
+	}
+	if f.EmailPrefix != "" {
+		arg.EmailPrefix = pgtype.Text{String: likeEscaper.Replace(strings.ToLower(f.EmailPrefix)), Valid: true}
+	}
+	if f.Verified != nil {
+		arg.Verified = pgtype.Bool{Bool: *f.Verified, Valid: true}
+	}
+	return arg
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+func (r *UserRepository) Count(ctx context.Context, f entity.UserFilter) (int64, error) {
+	return r.queries.CountUsers(ctx, userFilterArgs(f))
+}
+
+func (r *UserRepository) List(ctx context.Context, f entity.UserFilter, page entity.Page) ([]entity.User, error) {
+	arg := userFilterArgs(f)
+	rows, err := r.queries.ListUsers(ctx, pgstore.ListUsersParams{
+		EmailPrefix: arg.EmailPrefix,
+		Verified:    arg.Verified,
+		CreatedFrom: arg.CreatedFrom,
+		CreatedTo:   arg.CreatedTo,
+		RowLimit:    int32(page.Limit),
+		RowOffset:   int32(max(page.Offset, 0)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]entity.User, 0, len(rows))
+	for _, u := range rows {
+		out = append(out, entity.User{
+			ID:         uuidString(u.ID),
+			Email:      u.Email,
+			Name:       u.Name,
+			AvatarURL:  u.AvatarUrl,
+			IsVerified: u.IsVerified,
+			CreatedAt:  timeOf(u.CreatedAt),
+			UpdatedAt:  timeOf(u.UpdatedAt),
+		})
+	}
+	return out, nil
+}
+
+// inTx is the unit of work for user writes: the change and its history event commit together
+func (r *UserRepository) inTx(ctx context.Context, fn func(q *pgstore.Queries) error) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return classify(err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+	if err := fn(newQueries(tx)); err != nil {
+		return err
+	}
+	return classify(tx.Commit(ctx))
+}
+
+// dispatch hands u's pending domain events to Dispatch; they are pulled even without one
+func (r *UserRepository) dispatch(ctx context.Context, u *entity.User) {
+	evs := u.PullEvents()
+	if r.Dispatch != nil && len(evs) > 0 {
+		r.Dispatch(ctx, evs)
+	}
+}
+
+// lockUser reads the current row under FOR UPDATE, serializing writers so history seqs stay ordered
+func lockUser(ctx context.Context, q *pgstore.Queries, id pgtype.UUID) (pgstore.GetUserForUpdateRow, error) {
+	row, err := q.GetUserForUpdate(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return row, errNotFound
+	}
+	return row, err
+}
+
+func optTime(t pgtype.Timestamptz) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+func pgTime(t *time.Time) pgtype.Timestamptz {
+	if t == nil {
+		return pgtype.Timestamptz{}
+	}
+	return pgtype.Timestamptz{Time: *t, Valid: true}
+}
+
+var redacted = entity.FieldChange{Before: "[redacted]", After: "[redacted]"}
+
+func diffField(changes map[string]entity.FieldChange, field, before, after string) {
+	if before != after {
+		changes[field] = entity.FieldChange{Before: before, After: after}
+	}
+}
+
+func appendUserEvent(ctx context.Context, q *pgstore.Queries, userID pgtype.UUID, typ string, changes map[string]entity.FieldChange) error {
+	b, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+	_, err = q.InsertUserEvent(ctx, pgstore.InsertUserEventParams{
+		UserID:  userID,
+		Type:    typ,
+		Actor:   repository.ActorFrom(ctx),
+		Changes: b,
+	})
+	return err
 }
 
 var _ repository.UserRepository = (*UserRepository)(nil)
+
+func (r *UserRepository) ExistingIDs(ctx context.Context, ids []string) (map[string]bool, error) {
+	pgIDs := make([]pgtype.UUID, 0, len(ids))
+	for _, id := range ids {
+		if pgID, err := toPGUUID(id); err == nil {
+			pgIDs = append(pgIDs, pgID)
+		}
+	}
+	out := make(map[string]bool, len(pgIDs))
+	if len(pgIDs) == 0 {
+		return out, nil
+	}
+	rows, err := r.queries.ExistingUserIDs(ctx, pgIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range rows {
+		out[uuidString(id)] = true
+	}
+	return out, nil
+}
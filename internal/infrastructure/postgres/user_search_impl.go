@@ -0,0 +1,54 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/infrastructure/postgres/pgstore"
+)
+
+// UserSearch answers user searches straight from the users table with a case-insensitive
+// substring match on email and name. It needs no index to maintain, but every search scans the
+// table, so it suits deployments with modest user counts and no Elasticsearch.
+type UserSearch struct {
+	queries *pgstore.Queries
+}
+
+func NewUserSearch(pool *pgxpool.Pool) *UserSearch {
+	return &UserSearch{queries: newQueries(pool)}
+}
+
+func (s *UserSearch) Backend() string { return repository.SearchPostgres }
+
+func (s *UserSearch) SearchUsers(ctx context.Context, q entity.UserSearchQuery) (entity.UserSearchResult, error) {
+	start := time.Now()
+	out := entity.UserSearchResult{Items: []entity.UserSummary{}, From: q.From, Size: q.Size}
+	pattern := "%" + likeEscaper.Replace(q.Q) + "%"
+	total, err := s.queries.CountSearchUsers(ctx, pattern)
+	if err != nil {
+		return out, err
+	}
+	out.Total = total
+	if total > int64(q.From) {
+		rows, err := s.queries.SearchUsers(ctx, pgstore.SearchUsersParams{Pattern: pattern, RowLimit: int32(q.Size), RowOffset: int32(q.From)})
+		if err != nil {
+			return out, err
+		}
+		for _, r := range rows {
+			created, updated := timeOf(r.CreatedAt), timeOf(r.UpdatedAt)
+			u := entity.UserSummary{ID: uuidString(r.ID), Email: r.Email, Name: r.Name, AvatarURL: r.AvatarUrl, CreatedAt: &created, UpdatedAt: &updated}
+			out.Items = append(out.Items, u.Select(q.Fields))
+		}
+	}
+	out.TookMs = int(time.Since(start).Milliseconds())
+	return out, nil
+}
+
+// IndexUsers has nothing to do: searches read the users table itself
+func (s *UserSearch) IndexUsers(ctx context.Context, users []entity.User) (int, error) { return 0, nil }
+
+var _ repository.SearchService = (*UserSearch)(nil)
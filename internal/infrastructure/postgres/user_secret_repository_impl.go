@@ -0,0 +1,96 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/infrastructure/postgres/pgstore"
+)
+
+type UserSecretRepository struct {
+	queries *pgstore.Queries
+}
+
+func NewUserSecretRepository(pool *pgxpool.Pool) *UserSecretRepository {
+	return &UserSecretRepository{queries: newQueries(pool)}
+}
+
+func toUserSecret(s pgstore.UserSecret) entity.UserSecret {
+	return entity.UserSecret{
+		UserID:     uuidString(s.UserID),
+		Name:       s.Name,
+		Ciphertext: s.Ciphertext,
+		KeyID:      s.KeyID,
+		UpdatedAt:  timeOf(s.UpdatedAt),
+	}
+}
+
+func (r *UserSecretRepository) Put(ctx context.Context, s entity.UserSecret) error {
+	uid, err := toPGUUID(s.UserID)
+	if err != nil {
+		return errNotFound
+	}
+	return classify(r.queries.UpsertUserSecret(ctx, pgstore.UpsertUserSecretParams{
+		UserID: uid, Name: s.Name, Ciphertext: s.Ciphertext, KeyID: s.KeyID,
+	}))
+}
+
+func (r *UserSecretRepository) Get(ctx context.Context, userID, name string) (*entity.UserSecret, error) {
+	uid, err := toPGUUID(userID)
+	if err != nil {
+		return nil, errNotFound
+	}
+	row, err := r.queries.GetUserSecret(ctx, pgstore.GetUserSecretParams{UserID: uid, Name: name})
+	if err != nil {
+		return nil, classify(err)
+	}
+	s := toUserSecret(row)
+	return &s, nil
+}
+
+func (r *UserSecretRepository) Delete(ctx context.Context, userID, name string) (bool, error) {
+	uid, err := toPGUUID(userID)
+	if err != nil {
+		return false, nil
+	}
+	n, err := r.queries.DeleteUserSecret(ctx, pgstore.DeleteUserSecretParams{UserID: uid, Name: name})
+	return n > 0, classify(err)
+}
+
+func (r *UserSecretRepository) ListNotOnKey(ctx context.Context, keyID, afterUser, afterName string, limit int) ([]entity.UserSecret, error) {
+	after := pgtype.UUID{Valid: true} // the nil UUID sorts first
+	if afterUser != "" {
+		var err error
+		if after, err = toPGUUID(afterUser); err != nil {
+			return nil, err
+		}
+	}
+	rows, err := r.queries.ListUserSecretsNotOnKey(ctx, pgstore.ListUserSecretsNotOnKeyParams{
+		KeyID: keyID, AfterUser: after, AfterName: afterName, MaxRows: int32(limit),
+	})
+	if err != nil {
+		return nil, classify(err)
+	}
+	out := make([]entity.UserSecret, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, toUserSecret(row))
+	}
+	return out, nil
+}
+
+func (r *UserSecretRepository) Reencrypt(ctx context.Context, s entity.UserSecret, newCiphertext, keyID string) (bool, error) {
+	uid, err := toPGUUID(s.UserID)
+	if err != nil {
+		return false, nil
+	}
+	n, err := r.queries.ReplaceUserSecretCiphertext(ctx, pgstore.ReplaceUserSecretCiphertextParams{
+		NewCiphertext: newCiphertext, KeyID: keyID, UserID: uid, Name: s.Name, OldCiphertext: s.Ciphertext,
+	})
+	return n == 1, classify(err)
+}
+
+var _ repository.UserSecretRepository = (*UserSecretRepository)(nil)
@@ -0,0 +1,35 @@
+package redisstore
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+)
+
+// InvalidatingSessionStore announces every successful Revoke on the session invalidation channel so
+// all replicas purge their local state for the user, whichever flow revoked (logout-all, reset, admin).
+type InvalidatingSessionStore struct {
+	repository.SessionStore
+	Invalidations *helpers.SessionInvalidations
+	Logger        *logrus.Logger
+}
+
+func NewInvalidatingSessionStore(inner repository.SessionStore, inv *helpers.SessionInvalidations, logger *logrus.Logger) *InvalidatingSessionStore {
+	return &InvalidatingSessionStore{SessionStore: inner, Invalidations: inv, Logger: logger}
+}
+
+// Revoke publishes after the store revoked; a failed publish is logged, the revocation itself stands
+func (s *InvalidatingSessionStore) Revoke(ctx context.Context, userID, sessionID string) error {
+	if err := s.SessionStore.Revoke(ctx, userID, sessionID); err != nil {
+		return err
+	}
+	if err := s.Invalidations.Publish(ctx, helpers.SessionInvalidation{UserID: userID, SessionID: sessionID}); err != nil && s.Logger != nil {
+		s.Logger.WithError(err).WithField("user_id", userID).Warn("session invalidation not published")
+	}
+	return nil
+}
+
+var _ repository.SessionStore = (*InvalidatingSessionStore)(nil)
@@ -0,0 +1,95 @@
+package redisstore
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
+)
+
+// replicaTimeout bounds each write to a remote region, so a slow region cannot stall logins here
+const replicaTimeout = 2 * time.Second
+
+// ReplicatingSessionStore mirrors session writes into other regions for active-active
+// deployments: after the local store accepted a Create, Revoke or Block, the same call goes to
+// every replica (a hook per region; NewRegionSessionStore on that region's Redis, or any
+// SessionStore that forwards elsewhere). Replication is best effort: a failed replica is logged
+// and the local result stands. Touch is not replicated; a replica's copy keeps the TTL it was
+// created with, so sliding sessions only slide in the region serving them.
+type ReplicatingSessionStore struct {
+	repository.SessionStore
+	Replicas map[string]repository.SessionStore // by region
+	Logger   *logrus.Logger
+}
+
+func NewReplicatingSessionStore(inner repository.SessionStore, replicas map[string]repository.SessionStore, logger *logrus.Logger) *ReplicatingSessionStore {
+	return &ReplicatingSessionStore{SessionStore: inner, Replicas: replicas, Logger: logger}
+}
+
+func (s *ReplicatingSessionStore) Create(ctx context.Context, sess *entity.Session, ttl time.Duration) error {
+	if err := s.SessionStore.Create(ctx, sess, ttl); err != nil {
+		return err
+	}
+	s.replicate(ctx, "create", sess.UserID, func(ctx context.Context, r repository.SessionStore) error {
+		return r.Create(ctx, sess, ttl)
+	})
+	return nil
+}
+
+func (s *ReplicatingSessionStore) Revoke(ctx context.Context, userID, sessionID string) error {
+	if err := s.SessionStore.Revoke(ctx, userID, sessionID); err != nil {
+		return err
+	}
+	s.replicate(ctx, "revoke", userID, func(ctx context.Context, r repository.SessionStore) error {
+		return r.Revoke(ctx, userID, sessionID)
+	})
+	return nil
+}
+
+func (s *ReplicatingSessionStore) Block(ctx context.Context, userID, status string) error {
+	if err := s.SessionStore.Block(ctx, userID, status); err != nil {
+		return err
+	}
+	s.replicate(ctx, "block", userID, func(ctx context.Context, r repository.SessionStore) error {
+		return r.Block(ctx, userID, status)
+	})
+	return nil
+}
+
+// replicate runs op on every replica with its own timeout, detached from the request's
+// cancellation so a client hanging up does not leave regions out of step
+func (s *ReplicatingSessionStore) replicate(ctx context.Context, op, userID string, fn func(context.Context, repository.SessionStore) error) {
+	for region, r := range s.Replicas {
+		rctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), replicaTimeout)
+		err := fn(rctx, r)
+		cancel()
+		if err != nil && s.Logger != nil {
+			s.Logger.WithError(err).WithFields(logrus.Fields{"region": region, "op": op, "user_id": userID}).Warn("session replication failed")
+		}
+	}
+}
+
+// RegionReplicas builds a replica per region from region -> Redis URL (SESSION_REPLICAS): a store
+// on that region's Redis, or on local (keys differ by region prefix) when the URL is empty
+func RegionReplicas(urls map[string]string, local *redis.Client) (map[string]repository.SessionStore, error) {
+	out := make(map[string]repository.SessionStore, len(urls))
+	for region, url := range urls {
+		client := local
+		if url != "" {
+			opts, err := redis.ParseURL(url)
+			if err != nil {
+				return nil, fmt.Errorf("region %s: %w", region, err)
+			}
+			client = redis.NewClient(opts)
+		}
+		out[region] = NewRegionSessionStore(client, region)
+	}
+	return out, nil
+}
+
+var _ repository.SessionStore = (*ReplicatingSessionStore)(nil)
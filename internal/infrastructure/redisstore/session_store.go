@@ -0,0 +1,214 @@
+package redisstore
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/keyspace"
+)
+
+// SessionStore keeps each user's session in the Redis hash [<region>:]user:session:<user id>
+type SessionStore struct {
+	rdb    *redis.Client
+	region string
+	// Batch pipelines ListByUsers; replace it to record its pipelines in shared metrics
+	Batch *helpers.RedisBatch
+}
+
+// NewSessionStore stores sessions under the local region's keys (REGION)
+func NewSessionStore(rdb *redis.Client) *SessionStore {
+	return &SessionStore{rdb: rdb, region: keyspace.Region(), Batch: helpers.NewRedisBatch(rdb, nil)}
+}
+
+// NewRegionSessionStore stores sessions under another region's keys, for session replication
+func NewRegionSessionStore(rdb *redis.Client, region string) *SessionStore {
+	return &SessionStore{rdb: rdb, region: region, Batch: helpers.NewRedisBatch(rdb, nil)}
+}
+
+func (s *SessionStore) keySession(uid string) string { return helpers.KeySessionIn(s.region, uid) }
+func (s *SessionStore) keyBlocked(uid string) string {
+	return helpers.KeyAccountBlockedIn(s.region, uid)
+}
+
+func rfc3339(t time.Time) string { return t.UTC().Format(time.RFC3339Nano) }
+
+func (s *SessionStore) Create(ctx context.Context, sess *entity.Session, ttl time.Duration) error {
+	key := s.keySession(sess.UserID)
+	if sess.CreatedAt.IsZero() {
+		sess.CreatedAt = time.Now().UTC()
+	}
+	fields := map[string]any{
+		"user_id":    sess.UserID,
+		"email":      sess.Email,
+		"name":       sess.Name,
+		"avatar_url": sess.AvatarURL,
+		"sid":        sess.ID,
+		"logged_in":  true,
+		"created_at": rfc3339(sess.CreatedAt),
+		"ip":         sess.IP,
+		"user_agent": sess.UserAgent,
+		"location":   sess.Location,
+		"device_id":  sess.DeviceID,
+		"binding":    sess.Binding,
+	}
+	if !sess.UpdatedAt.IsZero() {
+		fields["updated_at"] = rfc3339(sess.UpdatedAt)
+	}
+	_, err := s.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, key)
+		pipe.HSet(ctx, key, fields)
+		if ttl > 0 {
+			pipe.Expire(ctx, key, ttl)
+		}
+		return nil
+	})
+	return unavailable(err)
+}
+
+// unavailable classifies a Redis failure other than a missing key
+func unavailable(err error) error {
+	if err == nil || errors.Is(err, redis.Nil) {
+		return err
+	}
+	return repository.Wrap(repository.ErrUnavailable, err)
+}
+
+// load reads the session together with the user's block status
+func (s *SessionStore) load(ctx context.Context, userID string) (*entity.Session, string, error) {
+	key := s.keySession(userID)
+	pipe := s.rdb.Pipeline()
+	all := pipe.HGetAll(ctx, key)
+	ttl := pipe.TTL(ctx, key)
+	blocked := pipe.Get(ctx, s.keyBlocked(userID))
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, "", unavailable(err)
+	}
+	sess := sessionFromHash(all.Val(), ttl.Val())
+	if sess == nil {
+		return nil, blocked.Val(), repository.ErrSessionNotFound
+	}
+	return sess, blocked.Val(), nil
+}
+
+// sessionFromHash decodes a session hash with its remaining TTL; nil when there is no session
+func sessionFromHash(data map[string]string, ttl time.Duration) *entity.Session {
+	if len(data) == 0 || data["sid"] == "" {
+		return nil
+	}
+	sess := &entity.Session{
+		ID:        data["sid"],
+		UserID:    data["user_id"],
+		Email:     data["email"],
+		Name:      data["name"],
+		AvatarURL: data["avatar_url"],
+		IP:        data["ip"],
+		UserAgent: data["user_agent"],
+		Location:  data["location"],
+		DeviceID:  data["device_id"],
+		Binding:   data["binding"],
+	}
+	sess.CreatedAt, _ = time.Parse(time.RFC3339Nano, data["created_at"])
+	sess.UpdatedAt, _ = time.Parse(time.RFC3339Nano, data["updated_at"])
+	if ttl > 0 {
+		sess.ExpiresAt = time.Now().Add(ttl)
+	}
+	return sess
+}
+
+func (s *SessionStore) Get(ctx context.Context, userID, sessionID string) (*entity.Session, error) {
+	sess, blocked, err := s.load(ctx, userID)
+	if err := repository.BlockError(blocked); err != nil {
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+	if sess.ID != sessionID {
+		return nil, repository.ErrSessionMismatch
+	}
+	return sess, nil
+}
+
+func (s *SessionStore) Touch(ctx context.Context, userID, sessionID string, ttl time.Duration) error {
+	key := s.keySession(userID)
+	sid, err := s.rdb.HGet(ctx, key, "sid").Result()
+	if errors.Is(err, redis.Nil) {
+		return repository.ErrSessionNotFound
+	}
+	if err != nil {
+		return unavailable(err)
+	}
+	if sid != sessionID {
+		return repository.ErrSessionMismatch
+	}
+	pipe := s.rdb.Pipeline()
+	pipe.HSet(ctx, key, "updated_at", rfc3339(time.Now()))
+	if ttl > 0 {
+		pipe.Expire(ctx, key, ttl)
+	}
+	_, err = pipe.Exec(ctx)
+	return unavailable(err)
+}
+
+func (s *SessionStore) Revoke(ctx context.Context, userID, sessionID string) error {
+	key := s.keySession(userID)
+	if sessionID == "" {
+		return unavailable(s.rdb.Del(ctx, key).Err())
+	}
+	sid, err := s.rdb.HGet(ctx, key, "sid").Result()
+	if errors.Is(err, redis.Nil) {
+		return nil
+	}
+	if err != nil || sid != sessionID {
+		return unavailable(err)
+	}
+	return unavailable(s.rdb.Del(ctx, key).Err())
+}
+
+func (s *SessionStore) ListByUser(ctx context.Context, userID string) ([]entity.Session, error) {
+	sess, _, err := s.load(ctx, userID)
+	if errors.Is(err, repository.ErrSessionNotFound) {
+		return []entity.Session{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []entity.Session{*sess}, nil
+}
+
+// ListByUsers reads every user's session hash and TTL in pipelined batches
+func (s *SessionStore) ListByUsers(ctx context.Context, userIDs []string) (map[string][]entity.Session, error) {
+	all := make([]*redis.MapStringStringCmd, len(userIDs))
+	ttls := make([]*redis.DurationCmd, len(userIDs))
+	err := s.Batch.Pipeline(ctx, "session_list", len(userIDs), func(pipe redis.Pipeliner, i int) {
+		key := s.keySession(userIDs[i])
+		all[i] = pipe.HGetAll(ctx, key)
+		ttls[i] = pipe.TTL(ctx, key)
+	})
+	if err != nil {
+		return nil, unavailable(err)
+	}
+	out := make(map[string][]entity.Session, len(userIDs))
+	for i, uid := range userIDs {
+		if sess := sessionFromHash(all[i].Val(), ttls[i].Val()); sess != nil {
+			out[uid] = []entity.Session{*sess}
+		}
+	}
+	return out, nil
+}
+
+// Block stores the marker without expiry; it lives until the account is reinstated
+func (s *SessionStore) Block(ctx context.Context, userID, status string) error {
+	if status == "" {
+		return unavailable(s.rdb.Del(ctx, s.keyBlocked(userID)).Err())
+	}
+	return unavailable(s.rdb.Set(ctx, s.keyBlocked(userID), status, 0).Err())
+}
+
+var _ repository.SessionStore = (*SessionStore)(nil)
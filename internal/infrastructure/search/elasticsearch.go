@@ -0,0 +1,109 @@
+// Package search holds the Elasticsearch and no-op SearchService implementations; the Postgres
+// one lives with the other Postgres repositories.
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v8"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+)
+
+// Elasticsearch searches the users index through its read alias and writes documents through
+// its write alias (the read alias when none is configured), so a reindex can swap indices
+// underneath (see UserIndexService)
+type Elasticsearch struct {
+	ES         *elasticsearch.Client
+	ReadAlias  string
+	WriteAlias string
+	Timeout    time.Duration // per search; 3s when zero
+}
+
+func NewElasticsearch(es *elasticsearch.Client, readAlias, writeAlias string) *Elasticsearch {
+	return &Elasticsearch{ES: es, ReadAlias: readAlias, WriteAlias: writeAlias, Timeout: 3 * time.Second}
+}
+
+func (s *Elasticsearch) Backend() string { return repository.SearchElasticsearch }
+
+// SearchUsers runs a multi_match search on email and name with an exact total; a non-empty
+// fields list limits the returned source fields (ES _source filtering).
+func (s *Elasticsearch) SearchUsers(ctx context.Context, q entity.UserSearchQuery) (entity.UserSearchResult, error) {
+	query := map[string]any{
+		"query": map[string]any{
+			"multi_match": map[string]any{
+				"query":  q.Q,
+				"fields": []string{"email^2", "name"},
+			},
+		},
+		"from":             q.From,
+		"size":             q.Size,
+		"track_total_hits": true,
+	}
+	if len(q.Fields) > 0 {
+		query["_source"] = q.Fields
+	}
+	b, _ := json.Marshal(query)
+
+	timeout := s.Timeout
+	if timeout <= 0 {
+		timeout = 3 * time.Second
+	}
+	c, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	res, err := s.ES.Search(s.ES.Search.WithContext(c), s.ES.Search.WithIndex(s.ReadAlias), s.ES.Search.WithBody(strings.NewReader(string(b))))
+	if err != nil {
+		return entity.UserSearchResult{}, err
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+
+	var parsed struct {
+		Took int `json:"took"`
+		Hits struct {
+			Total struct {
+				Value int64 `json:"value"`
+			} `json:"total"`
+			Hits []struct {
+				ID     string             `json:"_id"`
+				Source entity.UserSummary `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&parsed); err != nil {
+		return entity.UserSearchResult{}, err
+	}
+
+	out := entity.UserSearchResult{Items: make([]entity.UserSummary, 0, len(parsed.Hits.Hits)), Total: parsed.Hits.Total.Value, From: q.From, Size: q.Size, TookMs: parsed.Took}
+	for _, h := range parsed.Hits.Hits {
+		u := h.Source
+		if u.ID == "" && (len(q.Fields) == 0 || slices.Contains(q.Fields, "id")) {
+			u.ID = h.ID
+		}
+		out.Items = append(out.Items, u)
+	}
+	return out, nil
+}
+
+// IndexUsers overwrites the users' documents through the write alias
+func (s *Elasticsearch) IndexUsers(ctx context.Context, users []entity.User) (int, error) {
+	docs := make([]helpers.ESBulkDoc, 0, len(users))
+	for i := range users {
+		docs = append(docs, helpers.ESBulkDoc{ID: users[i].ID, Doc: entity.SummaryOf(&users[i])})
+	}
+	target := s.WriteAlias
+	if target == "" {
+		target = s.ReadAlias
+	}
+	return helpers.ESBulk(ctx, s.ES, target, "index", docs)
+}
+
+var _ repository.SearchService = (*Elasticsearch)(nil)
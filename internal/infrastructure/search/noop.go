@@ -0,0 +1,22 @@
+package search
+
+import (
+	"context"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
+)
+
+// Noop is the SearchService of deployments without search (SEARCH_BACKEND=none): every search
+// finds nothing and indexing is dropped
+type Noop struct{}
+
+func (Noop) Backend() string { return repository.SearchNone }
+
+func (Noop) SearchUsers(ctx context.Context, q entity.UserSearchQuery) (entity.UserSearchResult, error) {
+	return entity.UserSearchResult{Items: []entity.UserSummary{}, From: q.From, Size: q.Size}, nil
+}
+
+func (Noop) IndexUsers(ctx context.Context, users []entity.User) (int, error) { return 0, nil }
+
+var _ repository.SearchService = Noop{}
@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/config"
+	userapp "github.com/oksasatya/go-ddd-clean-architecture/internal/application"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/mailer"
+	tpl "github.com/oksasatya/go-ddd-clean-architecture/pkg/mailer/templates"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/response"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/validation"
+)
+
+// AccountStatusHandler serves the admin suspend/ban endpoints and notifies the affected user
+type AccountStatusHandler struct {
+	Svc    *userapp.AccountStatusService
+	Audit  *userapp.AuditService // optional
+	Pub    *helpers.RabbitPublisher
+	Cfg    *config.Config
+	Logger *logrus.Logger
+}
+
+func NewAccountStatusHandler(svc *userapp.AccountStatusService, audit *userapp.AuditService, pub *helpers.RabbitPublisher, cfg *config.Config, logger *logrus.Logger) *AccountStatusHandler {
+	return &AccountStatusHandler{Svc: svc, Audit: audit, Pub: pub, Cfg: cfg, Logger: logger}
+}
+
+type accountStatusRequest struct {
+	Reason string `json:"reason" binding:"max=500"`
+}
+
+// Suspend POST /api/admin/users/:id/suspend {reason}
+func (h *AccountStatusHandler) Suspend(c *gin.Context) {
+	var req accountStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) { // the body is optional
+		response.Error[any](c, http.StatusBadRequest, "invalid payload", validation.Details(err))
+		return
+	}
+	reason := strings.TrimSpace(req.Reason)
+	h.apply(c, "account_suspended", reason, func(ctx context.Context, id string) (*entity.User, bool, error) {
+		return h.Svc.Suspend(ctx, id, reason)
+	})
+}
+
+// Unsuspend POST /api/admin/users/:id/unsuspend
+func (h *AccountStatusHandler) Unsuspend(c *gin.Context) {
+	h.apply(c, "account_unsuspended", "", h.Svc.Unsuspend)
+}
+
+// Ban POST /api/admin/users/:id/ban {reason}
+func (h *AccountStatusHandler) Ban(c *gin.Context) {
+	var req accountStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) { // the body is optional
+		response.Error[any](c, http.StatusBadRequest, "invalid payload", validation.Details(err))
+		return
+	}
+	reason := strings.TrimSpace(req.Reason)
+	h.apply(c, "account_banned", reason, func(ctx context.Context, id string) (*entity.User, bool, error) {
+		return h.Svc.Ban(ctx, id, reason)
+	})
+}
+
+// Unban POST /api/admin/users/:id/unban
+func (h *AccountStatusHandler) Unban(c *gin.Context) {
+	h.apply(c, "account_unbanned", "", h.Svc.Unban)
+}
+
+// RevokeSessions POST /api/admin/users/:id/revoke-sessions ends every session of the user, which
+// invalidates their refresh tokens, and forgets their trusted devices. The call is audited.
+func (h *AccountStatusHandler) RevokeSessions(c *gin.Context) {
+	id, ok := h.userParam(c)
+	if !ok {
+		return
+	}
+	u, err := h.Svc.RevokeSessions(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, userapp.ErrUserNotFound) {
+			response.Error[any](c, http.StatusNotFound, "user not found", nil)
+			return
+		}
+		serverError(c, h.Logger, err, "failed to revoke sessions")
+		return
+	}
+	if h.Audit != nil {
+		if err := h.Audit.Record(c.Request.Context(), entity.AuditLog{
+			UserID:    u.ID,
+			Email:     u.Email,
+			Action:    "sessions_revoked",
+			IP:        clientIP(c),
+			UserAgent: c.GetHeader("User-Agent"),
+			Metadata:  map[string]any{"actor": c.GetString("userID")},
+		}); err != nil {
+			h.Logger.WithError(err).Warn("audit log not recorded")
+		}
+	}
+	response.Success[any](c, http.StatusOK, map[string]any{"id": u.ID, "revoked": true}, "sessions revoked", nil)
+}
+
+// userParam is the internal ID of the user :id names, by internal or public (ULID) ID; it answers
+// 404 itself for an unknown public ID
+func (h *AccountStatusHandler) userParam(c *gin.Context) (string, bool) {
+	id, err := h.Svc.ResolveID(c.Param("id"))
+	if errors.Is(err, userapp.ErrUserNotFound) {
+		response.Error[any](c, http.StatusNotFound, "user not found", nil)
+		return "", false
+	}
+	if err != nil {
+		serverError(c, h.Logger, err, "failed to load user")
+		return "", false
+	}
+	return id, true
+}
+
+// apply runs one status change on :id, audits it and emails the user when their status changed
+func (h *AccountStatusHandler) apply(c *gin.Context, action, reason string, fn func(ctx context.Context, id string) (*entity.User, bool, error)) {
+	id, ok := h.userParam(c)
+	if !ok {
+		return
+	}
+	if id == c.GetString("userID") {
+		response.Error[any](c, http.StatusConflict, "you cannot change the status of your own account", nil)
+		return
+	}
+	u, changed, err := fn(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, userapp.ErrUserNotFound) {
+			response.Error[any](c, http.StatusNotFound, "user not found", nil)
+			return
+		}
+		serverError(c, h.Logger, err, "failed to update account status")
+		return
+	}
+	if h.Audit != nil {
+		if err := h.Audit.Record(c.Request.Context(), entity.AuditLog{
+			UserID:    u.ID,
+			Email:     u.Email,
+			Action:    action,
+			IP:        clientIP(c),
+			UserAgent: c.GetHeader("User-Agent"),
+			Metadata:  map[string]any{"actor": c.GetString("userID"), "reason": reason, "changed": changed},
+		}); err != nil {
+			h.Logger.WithError(err).Warn("audit log not recorded")
+		}
+	}
+	if changed {
+		h.notify(c, u)
+	}
+	response.Success[any](c, http.StatusOK, accountStatusView(u), "account status updated", nil)
+}
+
+// notify emails the user their new status: suspended, banned or reinstated
+func (h *AccountStatusHandler) notify(c *gin.Context, u *entity.User) {
+	if h.Pub == nil || h.Cfg == nil || !h.Cfg.MailSendEnabled {
+		return
+	}
+	typ := tpl.AccountReinstated
+	switch u.AccountError() {
+	case entity.ErrAccountBanned:
+		typ = tpl.AccountBanned
+	case entity.ErrAccountSuspended:
+		typ = tpl.AccountSuspended
+	}
+	data := tpl.NewAccountStatusData(h.Cfg, typ, u.Name, u.Email, u.SuspensionReason, tpl.WithTime(time.Now()))
+	job := mailer.EmailJob{To: u.Email, Template: "universal", Data: tpl.ToMap(data), Envelope: mailer.Envelope{Variables: emailVariables(c, u.ID)}, JobMeta: emailJobMeta(c, c.GetString("userID"))}
+	if err := h.Pub.PublishEmail(c, job); err != nil {
+		h.Logger.WithError(err).WithField("user_id", u.ID).Warn("enqueue account status email failed")
+	}
+}
+
+func accountStatusView(u *entity.User) map[string]any {
+	status := "active"
+	switch u.AccountError() {
+	case entity.ErrAccountBanned:
+		status = "banned"
+	case entity.ErrAccountSuspended:
+		status = "suspended"
+	}
+	return map[string]any{
+		"user_id":      u.ID,
+		"status":       status,
+		"suspended_at": u.SuspendedAt,
+		"banned_at":    u.BannedAt,
+		"reason":       u.SuspensionReason,
+	}
+}
@@ -0,0 +1,29 @@
+package handlers
+
+// Request bodies and response data of the declared routes, exported for route metadata
+// (route.Route.Request/Response): GET /api/admin/routes and the server's --schema dump describe
+// them by reflection, and cmd/sdkgen generates typed clients from that.
+type (
+	AccountStatusRequest       = accountStatusRequest
+	AcceptInvitationRequest    = acceptInvitationRequest
+	AssignRoleRequest          = assignRoleRequest
+	AttachPermissionRequest    = attachPermissionRequest
+	BroadcastRequest           = broadcastRequest
+	CreateExportRequest        = createExportRequest
+	CreateInvitationRequest    = createInvitationRequest
+	CreateOrgRequest           = createOrgRequest
+	CreatePersonalTokenRequest = createPersonalTokenRequest
+	CreateRoleRequest          = createRoleRequest
+	InviteMemberRequest        = inviteMemberRequest
+	RoleMembersRequest         = roleMembersRequest
+	SendEmailRequest           = sendEmailRequest
+	SetMemberRoleRequest       = setMemberRoleRequest
+	SetOrgLimitsRequest        = setOrgLimitsRequest
+	SetPhoneRequest            = setPhoneRequest
+	SetupAdminRequest          = setupAdminRequest
+	SyncRoleRequest            = syncRoleRequest
+
+	PersonalTokenView = personalTokenView
+	PhoneView         = phoneView
+	StatusPage        = statusPage
+)
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	userapp "github.com/oksasatya/go-ddd-clean-architecture/internal/application"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/response"
+)
+
+type AuditHandler struct {
+	Svc     *userapp.AuditService
+	Exports *userapp.AuditExportService
+	Jobs    *userapp.ExportService // background exports (optional)
+	Logger  *logrus.Logger
+}
+
+func NewAuditHandler(svc *userapp.AuditService, export *userapp.AuditExportService, jobs *userapp.ExportService, logger *logrus.Logger) *AuditHandler {
+	return &AuditHandler{Svc: svc, Exports: export, Jobs: jobs, Logger: logger}
+}
+
+// auditFilter reads action, user_id, correlation_id, from and to; it writes a 400 and reports false when invalid
+func auditFilter(c *gin.Context) (entity.AuditFilter, bool) {
+	f := entity.AuditFilter{Action: c.Query("action"), UserID: c.Query("user_id"), CorrelationID: c.Query("correlation_id")}
+	var err error
+	if f.From, err = parseUsageTime(c.Query("from"), time.Time{}); err != nil {
+		response.Error[any](c, http.StatusBadRequest, "invalid from", nil)
+		return f, false
+	}
+	if f.To, err = parseUsageTime(c.Query("to"), time.Time{}); err != nil {
+		response.Error[any](c, http.StatusBadRequest, "invalid to", nil)
+		return f, false
+	}
+	return f, true
+}
+
+// wantsNDJSON reports whether the client asked for a streamed NDJSON response (format=ndjson or Accept)
+func wantsNDJSON(c *gin.Context) bool {
+	return c.Query("format") == "ndjson" || strings.Contains(c.GetHeader("Accept"), response.NDJSONContentType)
+}
+
+// Search GET /api/admin/audit-logs/search
+// Query: q (free text over action, email, ip, user agent, metadata), action, user_id, from, to, size (max 100).
+func (h *AuditHandler) Search(c *gin.Context) {
+	f, ok := auditFilter(c)
+	if !ok {
+		return
+	}
+	q := userapp.AuditSearchQuery{Q: c.Query("q"), Action: f.Action, UserID: f.UserID, From: f.From, To: f.To}
+	if v := c.Query("size"); v != "" {
+		var err error
+		if q.Size, err = strconv.Atoi(v); err != nil || q.Size <= 0 {
+			response.Error[any](c, http.StatusBadRequest, "invalid size", nil)
+			return
+		}
+	}
+	items, total, err := h.Svc.Search(c.Request.Context(), q)
+	if err != nil {
+		if errors.Is(err, userapp.ErrSearchNotConfigured) {
+			response.Error[any](c, http.StatusServiceUnavailable, "search not configured", nil)
+			return
+		}
+		serverError(c, h.Logger, err, "failed to search audit logs")
+		return
+	}
+	response.Success[any](c, http.StatusOK, map[string]any{"total": total, "items": items}, "ok", nil)
+}
+
+// Export GET /api/admin/audit-logs/export
+// Streams matching entries as CSV (chunked), or as NDJSON ending in a trailer record with format=ndjson
+// or Accept: application/x-ndjson. Ranges above the row cap, or async=true, become a background
+// export job (see ExportHandler); poll GET /api/admin/audit-logs/export/:id for progress and the download URL.
+func (h *AuditHandler) Export(c *gin.Context) {
+	f, ok := auditFilter(c)
+	if !ok {
+		return
+	}
+	if c.Query("async") == "true" {
+		h.startExportJob(c, f)
+		return
+	}
+	rows, err := h.Exports.Check(f)
+	if errors.Is(err, userapp.ErrExportTooLarge) {
+		if h.Jobs.CanRun() {
+			h.startExportJob(c, f)
+			return
+		}
+		response.Error[any](c, http.StatusRequestEntityTooLarge, "export too large, narrow the filters", map[string]any{"rows": rows, "max_rows": h.Exports.MaxRows})
+		return
+	}
+	if err != nil {
+		serverError(c, h.Logger, err, "failed to export audit logs")
+		return
+	}
+
+	if wantsNDJSON(c) {
+		c.Header("X-Export-Rows", strconv.FormatInt(rows, 10))
+		st := response.NewStream(c)
+		_, err := h.Exports.Each(c.Request.Context(), f, func(a entity.AuditLog) error {
+			return st.Write(newAuditLogView(a))
+		}, nil)
+		if err != nil {
+			h.Logger.WithError(err).Error("audit export aborted")
+		}
+		st.Close(err, "audit export aborted")
+		return
+	}
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="audit-logs-`+time.Now().UTC().Format("20060102T150405Z")+`.csv"`)
+	c.Header("X-Export-Rows", strconv.FormatInt(rows, 10))
+	c.Status(http.StatusOK)
+	if _, err := h.Exports.WriteCSV(c.Request.Context(), c.Writer, f, func(int64) { c.Writer.Flush() }); err != nil {
+		// headers are already sent; the truncated body is all the client gets
+		h.Logger.WithError(err).Error("audit export aborted")
+	}
+}
+
+func (h *AuditHandler) startExportJob(c *gin.Context, f entity.AuditFilter) {
+	startExport(c, h.Jobs, h.Logger, entity.ExportAudit, f)
+}
+
+// ExportJob GET /api/admin/audit-logs/export/:id
+func (h *AuditHandler) ExportJob(c *gin.Context) {
+	exportJob(c, h.Jobs, h.Logger)
+}
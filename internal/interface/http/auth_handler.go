@@ -2,47 +2,70 @@ package handlers
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
-	"encoding/json"
+	"encoding/hex"
+	"errors"
+	"io"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/redis/go-redis/v9"
 	"github.com/sirupsen/logrus"
 
 	"github.com/oksasatya/go-ddd-clean-architecture/config"
+	userapp "github.com/oksasatya/go-ddd-clean-architecture/internal/application"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
 	repo "github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
 	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/keyspace"
 	"github.com/oksasatya/go-ddd-clean-architecture/pkg/mailer"
 	tpl "github.com/oksasatya/go-ddd-clean-architecture/pkg/mailer/templates"
 	"github.com/oksasatya/go-ddd-clean-architecture/pkg/response"
 	"github.com/oksasatya/go-ddd-clean-architecture/pkg/validation"
-
-	// added for sqlc-based audit logging
-	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgtype"
-	"github.com/oksasatya/go-ddd-clean-architecture/internal/infrastructure/postgres/pgstore"
 )
 
 type AuthHandler struct {
-	Repo   repo.UserRepository
-	RDB    *redis.Client
-	Logger *logrus.Logger
-	Cfg    *config.Config
-	Pub    *helpers.RabbitPublisher
-	DB     *pgxpool.Pool
+	Repo     repo.UserRepository
+	RDB      *redis.Client
+	Sessions repo.SessionStore
+	Logger   *logrus.Logger
+	Cfg      *config.Config
+	Pub      *helpers.RabbitPublisher
+	Audit    *userapp.AuditService
+	Geo      tpl.GeoResolver
+	Revokes  *userapp.SessionRevokeService
+	Pwned    *helpers.PwnedPasswords // nil = no breached password check
+	Links    *helpers.DeepLinks      // nil = verify/reset links carry the raw token
 }
 
-func NewAuthHandler(repo repo.UserRepository, rdb *redis.Client, logger *logrus.Logger, cfg *config.Config, pub *helpers.RabbitPublisher, db *pgxpool.Pool) *AuthHandler {
-	return &AuthHandler{Repo: repo, RDB: rdb, Logger: logger, Cfg: cfg, Pub: pub, DB: db}
+func NewAuthHandler(repo repo.UserRepository, rdb *redis.Client, sessions repo.SessionStore, logger *logrus.Logger, cfg *config.Config, pub *helpers.RabbitPublisher, audit *userapp.AuditService, geo tpl.GeoResolver, revokes *userapp.SessionRevokeService) *AuthHandler {
+	return &AuthHandler{Repo: repo, RDB: rdb, Sessions: sessions, Logger: logger, Cfg: cfg, Pub: pub, Audit: audit, Geo: geo, Revokes: revokes}
 }
 
 // Key helpers
-func keyVerifyToken(t string) string { return "email:verify:token:" + t }
-func keyResetToken(t string) string  { return "pwd:reset:token:" + t }
-func keyVerified(uid string) string  { return "user:verified:" + uid }
+// Verify and reset tokens are only stored as their SHA-256, so a Redis dump cannot be replayed as links
+func keyVerifyToken(t string) string { return keyVerifyTokenHash(hashToken(t)) }
+func keyResetToken(t string) string  { return keyspace.Key("pwd:reset:token:" + hashToken(t)) }
+func keyVerified(uid string) string  { return keyspace.Key("user:verified:" + uid) }
+
+func keyVerifyTokenHash(h string) string { return keyspace.Key("email:verify:token:" + h) }
+
+// keyVerifyCurrent holds the hash of the user's outstanding verification token, so issuing a new one revokes it
+func keyVerifyCurrent(uid string) string  { return keyspace.Key("email:verify:user:" + uid) }
+func keyVerifyCooldown(uid string) string { return keyspace.Key("email:verify:cooldown:" + uid) }
+
+func hashToken(t string) string {
+	sum := sha256.Sum256([]byte(t))
+	return hex.EncodeToString(sum[:])
+}
+
+// exposeLinks reports whether issued links may be echoed in responses (development only)
+func (h *AuthHandler) exposeLinks() bool {
+	return h.Cfg != nil && h.Cfg.Env == "development"
+}
 
 func clientIP(c *gin.Context) string {
 	if ip := c.GetString("real_ip"); ip != "" {
@@ -59,56 +82,128 @@ func (h *AuthHandler) genToken(n int) (string, error) {
 	return base64.RawURLEncoding.EncodeToString(b), nil
 }
 
-func (h *AuthHandler) audit(c *gin.Context, userID string, email string, action string, metadata map[string]any) {
-	if h.DB == nil {
-		return
+// redirectAllowed validates the optional redirect a verify/reset link should carry; it writes the
+// 400 when the target is not on DEEP_LINK_REDIRECT_ORIGINS (or links are not signed)
+func (h *AuthHandler) redirectAllowed(c *gin.Context, redirect string) bool {
+	if redirect == "" || h.Links.AllowRedirect(redirect) {
+		return true
 	}
-	md, _ := json.Marshal(metadata)
-	ip := clientIP(c)
-	ua := c.GetHeader("User-Agent")
-
-	q := pgstore.New(h.DB)
+	response.Error[any](c, http.StatusBadRequest, "redirect not allowed", map[string]string{"redirect": "must be on an allowed origin"})
+	return false
+}
 
-	var uid pgtype.UUID
-	if userID != "" {
-		if parsed, err := uuid.Parse(userID); err == nil {
-			uid.Bytes = parsed
-			uid.Valid = true
+// redeemLink turns the token a front end posts back into the link it came from. Signed links are
+// checked for signature, purpose and expiry; raw tokens pass unless links are signed and
+// DEEP_LINK_ACCEPT_UNSIGNED is off. The Redis token inside is still what proves ownership.
+func (h *AuthHandler) redeemLink(token, purpose string) (helpers.DeepLink, error) {
+	if h.Links == nil || !helpers.IsSignedLink(token) {
+		if h.Links != nil && h.Cfg != nil && !h.Cfg.DeepLinkAcceptUnsigned {
+			return helpers.DeepLink{}, helpers.ErrDeepLinkInvalid
 		}
+		return helpers.DeepLink{Purpose: purpose, Token: token}, nil
 	}
-	var emailTxt pgtype.Text
-	if email != "" {
-		emailTxt.String = email
-		emailTxt.Valid = true
+	return h.Links.Verify(token, purpose, time.Now())
+}
+
+// linkRejected answers a token redeemLink refused; it reports whether err was set
+func linkRejected(c *gin.Context, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, helpers.ErrDeepLinkExpired):
+		response.Error[any](c, http.StatusBadRequest, "link expired", nil)
+	default:
+		response.Error[any](c, http.StatusBadRequest, "invalid or expired token", nil)
 	}
-	var ipTxt pgtype.Text
-	if ip != "" {
-		ipTxt.String = ip
-		ipTxt.Valid = true
+	return true
+}
+
+// withRedirect adds the link's redirect target to a confirm response
+func withRedirect(body gin.H, l helpers.DeepLink) gin.H {
+	if l.Redirect != "" {
+		body["redirect"] = l.Redirect
 	}
-	var uaTxt pgtype.Text
-	if ua != "" {
-		uaTxt.String = ua
-		uaTxt.Valid = true
+	return body
+}
+
+func (h *AuthHandler) audit(c *gin.Context, userID string, email string, action string, metadata map[string]any) {
+	if h.Audit == nil {
+		return
 	}
-	_ = q.InsertAuditLog(c, pgstore.InsertAuditLogParams{
-		UserID:    uid,
-		Email:     emailTxt,
+	if err := h.Audit.Record(c.Request.Context(), entity.AuditLog{
+		UserID:    userID,
+		Email:     email,
 		Action:    action,
-		Ip:        ipTxt,
-		UserAgent: uaTxt,
-		Metadata:  md,
-	})
+		IP:        clientIP(c),
+		UserAgent: c.GetHeader("User-Agent"),
+		Metadata:  metadata,
+	}); err != nil {
+		h.Logger.WithError(err).WithField("action", action).Warn("audit log not recorded")
+	}
 }
 
-// VerifyInit POST /api/auth/verify/init (auth required)
-// Returns a verification link that embeds the token in the front-end URL
+// VerifyInit POST /api/auth/verify/init {redirect?} (auth required)
+// Emails a verification link that embeds the token in the front-end URL; the link is only echoed in development
 func (h *AuthHandler) VerifyInit(c *gin.Context) {
 	uid := c.GetString("userID")
 	if uid == "" {
 		response.Error[any](c, http.StatusUnauthorized, "unauthorized", nil)
 		return
 	}
+	var req struct {
+		Redirect string `json:"redirect"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		response.Error[any](c, http.StatusBadRequest, "invalid payload", validation.Details(err))
+		return
+	}
+	if !h.redirectAllowed(c, req.Redirect) {
+		return
+	}
+	h.sendVerification(c, uid, req.Redirect)
+}
+
+// VerifyResend POST /api/auth/verify/resend {token?, redirect?}
+// Issues a fresh verification link, revoking the previous one, at most once per VERIFY_RESEND_COOLDOWN
+// per user (429 with Retry-After otherwise). Callers are a signed-in user, or hold the one-time token
+// from a login blocked by LOGIN_EMAIL_VERIFICATION=block (consumed only when an email is sent).
+func (h *AuthHandler) VerifyResend(c *gin.Context) {
+	var req struct {
+		Token    string `json:"token"`
+		Redirect string `json:"redirect"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		response.Error[any](c, http.StatusBadRequest, "invalid payload", validation.Details(err))
+		return
+	}
+	if !h.redirectAllowed(c, req.Redirect) {
+		return
+	}
+	if h.RDB == nil {
+		response.Error[any](c, http.StatusServiceUnavailable, "verification unavailable", nil)
+		return
+	}
+	uid := c.GetString("userID")
+	if uid == "" {
+		if req.Token == "" {
+			response.Error[any](c, http.StatusUnauthorized, "sign in or provide the resend token", nil)
+			return
+		}
+		var err error
+		if uid, err = h.RDB.Get(c, helpers.KeyVerifyResend(req.Token)).Result(); err != nil || uid == "" {
+			response.Error[any](c, http.StatusBadRequest, "invalid or expired token", nil)
+			return
+		}
+	}
+	if h.sendVerification(c, uid, req.Redirect) && req.Token != "" {
+		h.RDB.Del(c, helpers.KeyVerifyResend(req.Token))
+	}
+}
+
+// sendVerification issues a verification token for uid and enqueues the email; it reports whether a
+// new token was issued (false when already verified, cooling down or failed, with the response written).
+// redirect (already validated) travels in the signed link.
+func (h *AuthHandler) sendVerification(c *gin.Context, uid, redirect string) bool {
 	// If already verified in DB or Redis, return idempotent OK
 	if ok, err := h.Repo.IsVerified(uid); err == nil && ok {
 		if h.RDB != nil {
@@ -116,26 +211,41 @@ func (h *AuthHandler) VerifyInit(c *gin.Context) {
 		}
 		h.audit(c, uid, "", "verify_init_already", nil)
 		response.Success(c, http.StatusOK, gin.H{"already_verified": true}, "already verified", nil)
-		return
+		return false
 	}
 	if h.RDB != nil {
 		if v, _ := h.RDB.Get(c, keyVerified(uid)).Result(); v == "1" {
 			h.audit(c, uid, "", "verify_init_already", map[string]any{"source": "redis"})
 			response.Success(c, http.StatusOK, gin.H{"already_verified": true}, "already verified", nil)
-			return
+			return false
+		}
+		// One email per cooldown window, whichever endpoint asked
+		if cd := h.verifyCooldown(); cd > 0 {
+			if ok, err := h.RDB.SetNX(c, keyVerifyCooldown(uid), "1", cd).Result(); err == nil && !ok {
+				wait := h.RDB.PTTL(c, keyVerifyCooldown(uid)).Val()
+				retry := max(int((wait+time.Second-1)/time.Second), 1)
+				c.Header("Retry-After", strconv.Itoa(retry))
+				response.Error[any](c, http.StatusTooManyRequests, "verification email sent recently", map[string]any{"retry_in": retry, "retry_after_seconds": retry, "attempts_remaining": 0})
+				return false
+			}
 		}
 	}
 	// Create token and store mapping -> uid
 	tok, err := h.genToken(32)
 	if err != nil {
 		response.Error[any](c, http.StatusInternalServerError, "token generation failed", nil)
-		return
+		return false
 	}
 	if h.RDB != nil {
+		// Revoke the previous link: only the newest one verifies
+		if prev, err := h.RDB.GetSet(c, keyVerifyCurrent(uid), hashToken(tok)).Result(); err == nil && prev != "" {
+			h.RDB.Del(c, keyVerifyTokenHash(prev))
+		}
+		h.RDB.Expire(c, keyVerifyCurrent(uid), 24*time.Hour)
 		h.RDB.Set(c, keyVerifyToken(tok), uid, 24*time.Hour)
 	}
-	link := h.Cfg.VerifyEmailURL + "?token=" + tok
-	h.audit(c, uid, "", "verify_init_issue", map[string]any{"link": link})
+	link := h.Links.Link(h.Cfg.VerifyEmailURL, helpers.DeepLink{Purpose: helpers.LinkVerifyEmail, Token: tok, Expires: time.Now().Add(24 * time.Hour).Unix(), Redirect: redirect})
+	h.audit(c, uid, "", "verify_init_issue", nil)
 
 	// enqueue verify email
 	if h.Pub != nil && h.Cfg != nil && h.Cfg.MailSendEnabled {
@@ -143,7 +253,6 @@ func (h *AuthHandler) VerifyInit(c *gin.Context) {
 		if u != nil {
 			ip := clientIP(c)
 			ua := c.GetHeader("User-Agent")
-			resolver := tpl.IPAPIResolver{}
 			data := tpl.NewVerifyEmailData(
 				h.Cfg,
 				u.Name,
@@ -153,50 +262,73 @@ func (h *AuthHandler) VerifyInit(c *gin.Context) {
 				tpl.WithExpiresIn(24*time.Hour),
 				tpl.WithIP(ip),
 				tpl.WithUserAgent(ua),
-				tpl.WithGeoFromIP(c.Request.Context(), resolver, ip),
+				tpl.WithGeoFromIP(c.Request.Context(), h.Geo, ip),
 			)
-			job := mailer.EmailJob{To: u.Email, Template: "universal", Data: data}
-			_ = h.Pub.PublishJSON(c, job)
+			job := mailer.EmailJob{To: u.Email, Locale: emailLocale(c), Template: "universal", Data: tpl.ToMap(data), Envelope: mailer.Envelope{Variables: emailVariables(c, u.ID)}, JobMeta: emailJobMeta(c, u.ID)}
+			_ = h.Pub.PublishEmail(c, job)
 		}
 	}
 
-	response.Success(c, http.StatusOK, gin.H{"verify_link": link}, "verification link", nil)
+	if h.exposeLinks() {
+		response.Success(c, http.StatusOK, gin.H{"verify_link": link}, "verification link", nil)
+		return true
+	}
+	response.Success(c, http.StatusOK, gin.H{"sent": true}, "verification email sent", nil)
+	return true
+}
+
+func (h *AuthHandler) verifyCooldown() time.Duration {
+	if h.Cfg == nil {
+		return 0
+	}
+	return h.Cfg.VerifyResendCooldown
 }
 
 // VerifyConfirm POST /api/auth/verify/confirm {token}
+// token is the link's token parameter: a signed link (DEEP_LINK_SECRET) or a raw token
 func (h *AuthHandler) VerifyConfirm(c *gin.Context) {
 	var req struct {
 		Token string `json:"token" binding:"required"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.Error[any](c, http.StatusBadRequest, "invalid payload", validation.ToDetails(err))
+		response.Error[any](c, http.StatusBadRequest, "invalid payload", validation.Details(err))
 		return
 	}
 	if h.RDB == nil {
 		response.Error[any](c, http.StatusInternalServerError, "verification unavailable", nil)
 		return
 	}
-	uid, err := h.RDB.Get(c, keyVerifyToken(req.Token)).Result()
+	link, err := h.redeemLink(req.Token, helpers.LinkVerifyEmail)
+	if linkRejected(c, err) {
+		return
+	}
+	// GETDEL makes the token single-use even under concurrent confirms
+	uid, err := h.RDB.GetDel(c, keyVerifyToken(link.Token)).Result()
 	if err != nil || uid == "" {
 		response.Error[any](c, http.StatusBadRequest, "invalid or expired token", nil)
 		return
 	}
 	// Mark verified in DB and cache
-	_ = h.Repo.SetVerified(uid)
+	// the token proves the caller owns the account
+	_ = h.Repo.SetVerified(repo.WithActor(c.Request.Context(), "user:"+uid), uid)
 	h.RDB.Set(c, keyVerified(uid), "1", 0)
-	h.RDB.Del(c, keyVerifyToken(req.Token))
+	h.RDB.Del(c, keyVerifyCurrent(uid))
 	h.audit(c, uid, "", "verify_confirm", map[string]any{"token": "redacted"})
-	response.Success[any](c, http.StatusOK, gin.H{"verified": true}, "email verified", nil)
+	response.Success[any](c, http.StatusOK, withRedirect(gin.H{"verified": true}, link), "email verified", nil)
 }
 
-// ResetInit - POST /api/auth/reset/init {email}
-// Returns a reset link that embeds the token in the front-end URL
+// ResetInit - POST /api/auth/reset/init {email, redirect?}
+// Emails a reset link that embeds the token in the front-end URL; the link is only echoed in development
 func (h *AuthHandler) ResetInit(c *gin.Context) {
 	var req struct {
-		Email string `json:"email" binding:"required,email"`
+		Email    string `json:"email" binding:"required,email"`
+		Redirect string `json:"redirect"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.Error[any](c, http.StatusBadRequest, "invalid payload", validation.ToDetails(err))
+		response.Error[any](c, http.StatusBadRequest, "invalid payload", validation.Details(err))
+		return
+	}
+	if !h.redirectAllowed(c, req.Redirect) {
 		return
 	}
 	// Always return OK to avoid enumeration
@@ -209,12 +341,11 @@ func (h *AuthHandler) ResetInit(c *gin.Context) {
 			return
 		}
 		h.RDB.Set(c, keyResetToken(tok), u.ID, 30*time.Minute)
-		link = h.Cfg.ResetPasswordURL + "?token=" + tok
+		link = h.Links.Link(h.Cfg.ResetPasswordURL, helpers.DeepLink{Purpose: helpers.LinkResetPassword, Token: tok, Expires: time.Now().Add(30 * time.Minute).Unix(), Redirect: req.Redirect})
 		// enqueue email
 		if h.Pub != nil && h.Cfg != nil && h.Cfg.MailSendEnabled {
 			ip := clientIP(c)
 			ua := c.GetHeader("User-Agent")
-			resolver := tpl.IPAPIResolver{}
 			data := tpl.NewForgotPasswordData(
 				h.Cfg,
 				u.Name,
@@ -225,17 +356,82 @@ func (h *AuthHandler) ResetInit(c *gin.Context) {
 				tpl.WithExpiresIn(30*time.Minute),
 				tpl.WithIP(ip),
 				tpl.WithUserAgent(ua),
-				tpl.WithGeoFromIP(c.Request.Context(), resolver, ip),
+				tpl.WithGeoFromIP(c.Request.Context(), h.Geo, ip),
 			)
-			job := mailer.EmailJob{To: u.Email, Template: "universal", Data: data}
-			_ = h.Pub.PublishJSON(c, job)
+			job := mailer.EmailJob{To: u.Email, Locale: emailLocale(c), Template: "universal", Data: tpl.ToMap(data), Envelope: mailer.Envelope{Variables: emailVariables(c, u.ID)}, JobMeta: emailJobMeta(c, u.ID)}
+			_ = h.Pub.PublishEmail(c, job)
 		}
-		h.audit(c, u.ID, u.Email, "reset_init_issue", map[string]any{"link": link})
+		h.audit(c, u.ID, u.Email, "reset_init_issue", nil)
 	} else {
 		// log attempted reset with unknown email
 		h.audit(c, "", req.Email, "reset_init_unknown", nil)
 	}
-	response.Success(c, http.StatusOK, gin.H{"reset_link": link}, "reset link", nil)
+	if h.exposeLinks() {
+		response.Success(c, http.StatusOK, gin.H{"reset_link": link}, "reset link", nil)
+		return
+	}
+	response.Success(c, http.StatusOK, gin.H{"sent": true}, "if the account exists, a reset link was sent", nil)
+}
+
+// sendPasswordChanged enqueues the password-changed confirmation (best effort), with a one-click
+// link that ends every session in case the change was not the user's
+func (h *AuthHandler) sendPasswordChanged(c *gin.Context, uid string) {
+	if h.Pub == nil || h.Cfg == nil || !h.Cfg.MailSendEnabled {
+		return
+	}
+	u, err := h.Repo.GetByID(uid)
+	if err != nil || u == nil {
+		return
+	}
+	ip := clientIP(c)
+	opts := []tpl.Option{
+		tpl.WithTime(time.Now()),
+		tpl.WithIP(ip),
+		tpl.WithUserAgent(c.GetHeader("User-Agent")),
+		tpl.WithGeoFromIP(c.Request.Context(), h.Geo, ip),
+	}
+	if link := h.Revokes.Link(c.Request.Context(), uid, "", userapp.RevokeFromPasswordChanged); link != "" {
+		opts = append(opts, tpl.WithRevokeURL(link))
+	}
+	data := tpl.NewPasswordChangedData(h.Cfg, u.Name, u.Email, opts...)
+	if err := h.Pub.PublishEmail(c, mailer.EmailJob{To: u.Email, Locale: emailLocale(c), Template: "universal", Data: tpl.ToMap(data), Envelope: mailer.Envelope{Variables: emailVariables(c, uid)}, JobMeta: emailJobMeta(c, uid)}); err != nil {
+		h.Logger.WithError(err).WithField("user_id", uid).Warn("enqueue password changed email failed")
+	}
+}
+
+// ResetValidate - GET /api/auth/reset/validate?token=...
+// Reports whether a reset token can still be confirmed and for how long, without consuming it,
+// so reset pages can show an expiry message before the user types a new password
+func (h *AuthHandler) ResetValidate(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		response.Error[any](c, http.StatusBadRequest, "token is required", nil)
+		return
+	}
+	if h.RDB == nil {
+		response.Error[any](c, http.StatusServiceUnavailable, "reset unavailable", nil)
+		return
+	}
+	link, err := h.redeemLink(token, helpers.LinkResetPassword)
+	if err != nil {
+		response.Success[any](c, http.StatusOK, gin.H{"valid": false, "reason": err.Error()}, "invalid or expired token", nil)
+		return
+	}
+	ttl, err := h.RDB.PTTL(c.Request.Context(), keyResetToken(link.Token)).Result()
+	if err != nil {
+		serverError(c, h.Logger, repo.Wrap(repo.ErrUnavailable, err), "reset unavailable")
+		return
+	}
+	// PTTL is -2 for a missing key; reset tokens are always stored with an expiry
+	if ttl <= 0 {
+		response.Success[any](c, http.StatusOK, gin.H{"valid": false}, "invalid or expired token", nil)
+		return
+	}
+	response.Success[any](c, http.StatusOK, withRedirect(gin.H{
+		"valid":      true,
+		"expires_at": time.Now().Add(ttl).UTC().Truncate(time.Second),
+		"expires_in": int(ttl.Seconds()),
+	}, link), "token valid", nil)
 }
 
 // POST /api/auth/reset/confirm {token, new_password}
@@ -245,14 +441,23 @@ func (h *AuthHandler) ResetConfirm(c *gin.Context) {
 		NewPassword string `json:"new_password" binding:"required,pwd"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.Error[any](c, http.StatusBadRequest, "invalid payload", validation.ToDetails(err))
+		response.Error[any](c, http.StatusBadRequest, "invalid payload", validation.Details(err))
 		return
 	}
 	if h.RDB == nil {
 		response.Error[any](c, http.StatusInternalServerError, "reset unavailable", nil)
 		return
 	}
-	uid, err := h.RDB.Get(c, keyResetToken(req.Token)).Result()
+	// Checked before the token is consumed, so the same link works with a different password
+	if err := h.Pwned.Check(c.Request.Context(), req.NewPassword); passwordRejected(c, "new_password", err) {
+		return
+	}
+	link, err := h.redeemLink(req.Token, helpers.LinkResetPassword)
+	if linkRejected(c, err) {
+		return
+	}
+	// Consumed up front so two concurrent confirms cannot both succeed; a failed update needs a new link
+	uid, err := h.RDB.GetDel(c, keyResetToken(link.Token)).Result()
 	if err != nil || uid == "" {
 		response.Error[any](c, http.StatusBadRequest, "invalid or expired token", nil)
 		return
@@ -262,11 +467,61 @@ func (h *AuthHandler) ResetConfirm(c *gin.Context) {
 		response.Error[any](c, http.StatusInternalServerError, "hash fail", nil)
 		return
 	}
-	if err := h.Repo.UpdatePassword(uid, hash); err != nil {
-		response.Error[any](c, http.StatusInternalServerError, "update fail", nil)
+	u, err := h.Repo.GetByID(uid)
+	if err != nil || u == nil {
+		response.Error[any](c, http.StatusBadRequest, "invalid or expired token", nil)
+		return
+	}
+	u.ChangePassword(hash)
+	if err := h.Repo.Update(repo.WithActor(c.Request.Context(), "user:"+uid), u); err != nil {
+		serverError(c, h.Logger, err, "update fail")
+		return
+	}
+	// The old password may be compromised: end every session and forget trusted devices
+	revoked := true
+	if h.Sessions != nil {
+		if err := h.Sessions.Revoke(c.Request.Context(), uid, ""); err != nil {
+			revoked = false
+			h.Logger.WithError(err).WithField("user_id", uid).Error("revoke sessions after reset failed")
+		}
+	}
+	if err := helpers.ForgetTrustedDevices(c.Request.Context(), h.RDB, uid); err != nil {
+		revoked = false
+		h.Logger.WithError(err).WithField("user_id", uid).Error("forget trusted devices after reset failed")
+	}
+	h.audit(c, uid, "", "reset_confirm", map[string]any{"token": "redacted", "sessions_revoked": revoked})
+	h.sendPasswordChanged(c, uid)
+	response.Success[any](c, http.StatusOK, withRedirect(gin.H{"reset": true}, link), "password updated", nil)
+}
+
+// SessionRevoke - POST /api/auth/sessions/revoke {token}: the "this wasn't me" link from a security
+// email; no login needed. A new-location login link ends the sessions of the device that logged in
+// and stops trusting it; a password-changed link ends every session and forgets all trusted devices.
+func (h *AuthHandler) SessionRevoke(c *gin.Context) {
+	var req struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error[any](c, http.StatusBadRequest, "invalid payload", validation.Details(err))
+		return
+	}
+	r, err := h.Revokes.Revoke(c.Request.Context(), req.Token)
+	if errors.Is(err, userapp.ErrRevokeTokenInvalid) {
+		response.Error[any](c, http.StatusBadRequest, "invalid or expired token", nil)
 		return
 	}
-	h.RDB.Del(c, keyResetToken(req.Token))
-	h.audit(c, uid, "", "reset_confirm", map[string]any{"token": "redacted"})
-	response.Success[any](c, http.StatusOK, gin.H{"reset": true}, "password updated", nil)
+	if err != nil {
+		serverError(c, h.Logger, err, "revoke failed")
+		return
+	}
+	scope := "all"
+	if r.DeviceID != "" {
+		scope = "device"
+	}
+	meta := map[string]any{"source": r.Source + "_email", "scope": scope}
+	if r.DeviceID != "" {
+		meta["device_id"] = r.DeviceID
+	}
+	h.audit(c, r.UserID, "", "sessions_revoked", meta)
+	response.Success[any](c, http.StatusOK, gin.H{"revoked": true, "scope": scope}, "sessions revoked", nil)
 }
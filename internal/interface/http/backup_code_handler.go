@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	userapp "github.com/oksasatya/go-ddd-clean-architecture/internal/application"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/response"
+)
+
+// BackupCodeHandler lets users see how many recovery codes they have left and generate a new set
+type BackupCodeHandler struct {
+	Svc    *userapp.BackupCodeService
+	Audit  *userapp.AuditService // optional
+	Logger *logrus.Logger
+}
+
+func NewBackupCodeHandler(svc *userapp.BackupCodeService, audit *userapp.AuditService, logger *logrus.Logger) *BackupCodeHandler {
+	return &BackupCodeHandler{Svc: svc, Audit: audit, Logger: logger}
+}
+
+// Status GET /api/auth/backup-codes
+func (h *BackupCodeHandler) Status(c *gin.Context) {
+	st, err := h.Svc.Status(c.Request.Context(), c.GetString("userID"))
+	if err != nil {
+		serverError(c, h.Logger, err, "failed to load backup codes")
+		return
+	}
+	response.Success[any](c, http.StatusOK, st, "ok", nil)
+}
+
+// Regenerate POST /api/auth/backup-codes returns a new set of codes, shown only this once; the
+// previous codes stop working
+func (h *BackupCodeHandler) Regenerate(c *gin.Context) {
+	uid := c.GetString("userID")
+	codes, err := h.Svc.Generate(c.Request.Context(), uid)
+	if err != nil {
+		serverError(c, h.Logger, err, "failed to generate backup codes")
+		return
+	}
+	if h.Audit != nil {
+		if err := h.Audit.Record(c.Request.Context(), entity.AuditLog{
+			UserID:    uid,
+			Action:    "backup_codes_generated",
+			IP:        clientIP(c),
+			UserAgent: c.GetHeader("User-Agent"),
+			Metadata:  map[string]any{"count": len(codes)},
+		}); err != nil {
+			h.Logger.WithError(err).Warn("audit log not recorded")
+		}
+	}
+	c.Header("Cache-Control", "no-store")
+	response.Success[any](c, http.StatusOK, map[string]any{"codes": codes, "remaining": len(codes), "low": false}, "backup codes generated", nil)
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	userapp "github.com/oksasatya/go-ddd-clean-architecture/internal/application"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/mailer"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/response"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/validation"
+)
+
+type BroadcastHandler struct {
+	Broadcasts *userapp.BroadcastService
+	Logger     *logrus.Logger
+}
+
+func NewBroadcastHandler(b *userapp.BroadcastService, logger *logrus.Logger) *BroadcastHandler {
+	return &BroadcastHandler{Broadcasts: b, Logger: logger}
+}
+
+type broadcastAudienceRequest struct {
+	Type  string `json:"type" binding:"required,oneof=verified role org"`
+	Role  string `json:"role"`   // role name, for type role
+	OrgID string `json:"org_id"` // organization id, for type org
+}
+
+type broadcastRequest struct {
+	Audience broadcastAudienceRequest `json:"audience"`
+	Template string                   `json:"template"` // universal (with data.Type), or leave empty and set subject with text/html
+	Data     map[string]any           `json:"data"`
+	Subject  string                   `json:"subject"`
+	Text     string                   `json:"text"`
+	HTML     string                   `json:"html"`
+	Locale   string                   `json:"locale"`
+	From     string                   `json:"from"`
+	ReplyTo  string                   `json:"reply_to"`
+	Tags     []string                 `json:"tags"`
+}
+
+// broadcastView is the polling view of a broadcast; progress is processed/total (0 until counted)
+func broadcastView(b *entity.EmailBroadcast) map[string]any {
+	progress := 0.0
+	if b.Total > 0 {
+		progress = min(float64(b.Processed())/float64(b.Total), 1)
+	} else if b.State == entity.BroadcastDone {
+		progress = 1
+	}
+	audience := map[string]any{"type": b.Audience.Type}
+	if b.Audience.Role != "" {
+		audience["role"] = b.Audience.Role
+	}
+	if b.Audience.OrgID != "" {
+		audience["org_id"] = b.Audience.OrgID
+	}
+	out := map[string]any{
+		"id":         b.ID,
+		"state":      b.State,
+		"audience":   audience,
+		"total":      b.Total,
+		"enqueued":   b.Enqueued,
+		"skipped":    b.Skipped,
+		"failed":     b.Failed,
+		"progress":   progress,
+		"created_at": b.CreatedAt,
+	}
+	if b.RequestedBy != "" {
+		out["requested_by"] = b.RequestedBy
+	}
+	if b.StartedAt != nil {
+		out["started_at"] = b.StartedAt
+	}
+	if b.FinishedAt != nil {
+		out["finished_at"] = b.FinishedAt
+	}
+	if b.Error != "" {
+		out["error"] = b.Error
+	}
+	return out
+}
+
+// Create POST /api/admin/emails/broadcast
+// Body: {"audience": {"type": "verified"|"role"|"org", "role": "...", "org_id": "..."}, then the
+// email as for /api/email/send (template and data, or subject with text/html). Answers 202 with the
+// queued broadcast; poll GET /api/admin/emails/broadcast/:id for its progress.
+func (h *BroadcastHandler) Create(c *gin.Context) {
+	var req broadcastRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error[any](c, http.StatusBadRequest, "invalid payload", validation.Details(err))
+		return
+	}
+	audience := entity.BroadcastAudience{Type: req.Audience.Type, Role: req.Audience.Role, OrgID: req.Audience.OrgID}
+	msg := userapp.BroadcastMessage{
+		Template: req.Template, Data: req.Data, Subject: req.Subject, Text: req.Text, HTML: req.HTML,
+		Locale: req.Locale, From: req.From, ReplyTo: req.ReplyTo, Tags: req.Tags,
+	}
+	b, err := h.Broadcasts.Create(c.Request.Context(), audience, msg, c.GetString("userID"))
+	switch {
+	case errors.Is(err, userapp.ErrBroadcastUnavailable):
+		response.Error[any](c, http.StatusServiceUnavailable, "email broadcasts not configured", nil)
+	case errors.Is(err, userapp.ErrBroadcastAudience), errors.Is(err, mailer.ErrInvalidJob):
+		response.Error[any](c, http.StatusBadRequest, err.Error(), nil)
+	case err != nil:
+		serverError(c, h.Logger, err, "failed to queue broadcast")
+	default:
+		response.Success[any](c, http.StatusAccepted, broadcastView(b), "broadcast queued", nil)
+	}
+}
+
+// Get GET /api/admin/emails/broadcast/:id
+// State is queued, running, done, failed or cancelled; progress is (enqueued+skipped+failed)/total.
+func (h *BroadcastHandler) Get(c *gin.Context) {
+	b, err := h.Broadcasts.Get(c.Request.Context(), c.Param("id"))
+	if errors.Is(err, userapp.ErrBroadcastNotFound) {
+		response.Error[any](c, http.StatusNotFound, "broadcast not found", nil)
+		return
+	}
+	if err != nil {
+		serverError(c, h.Logger, err, "failed to load broadcast")
+		return
+	}
+	response.Success[any](c, http.StatusOK, broadcastView(b), "ok", nil)
+}
+
+// Cancel POST /api/admin/emails/broadcast/:id/cancel
+// Stops a queued or running broadcast (emails already enqueued are still sent); 409 once finished.
+func (h *BroadcastHandler) Cancel(c *gin.Context) {
+	b, err := h.Broadcasts.Cancel(c.Request.Context(), c.Param("id"))
+	switch {
+	case errors.Is(err, userapp.ErrBroadcastNotFound):
+		response.Error[any](c, http.StatusNotFound, "broadcast not found", nil)
+	case errors.Is(err, userapp.ErrBroadcastFinished):
+		response.Error[any](c, http.StatusConflict, "broadcast already finished", broadcastView(b))
+	case err != nil:
+		serverError(c, h.Logger, err, "failed to cancel broadcast")
+	default:
+		response.Success[any](c, http.StatusOK, broadcastView(b), "broadcast cancelled", nil)
+	}
+}
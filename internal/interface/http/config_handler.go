@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/response"
+)
+
+// ConfigHandler serves the startup report: effective configuration (secrets masked), modules,
+// route count, build and dependency versions (admin)
+type ConfigHandler struct {
+	Report func() *helpers.StartupReport // usually container.GetStartup
+}
+
+func NewConfigHandler(report func() *helpers.StartupReport) *ConfigHandler {
+	return &ConfigHandler{Report: report}
+}
+
+func (h *ConfigHandler) Get(c *gin.Context) {
+	rep := h.Report()
+	if rep == nil {
+		response.Error[any](c, http.StatusServiceUnavailable, "startup not complete", nil)
+		return
+	}
+	response.Success[any](c, http.StatusOK, rep, "ok", nil)
+}
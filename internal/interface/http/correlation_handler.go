@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	userapp "github.com/oksasatya/go-ddd-clean-architecture/internal/application"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/httpx"
+)
+
+type CorrelationHandler struct {
+	Svc    *userapp.CorrelationService
+	Logger *logrus.Logger
+}
+
+func NewCorrelationHandler(svc *userapp.CorrelationService, logger *logrus.Logger) *CorrelationHandler {
+	return &CorrelationHandler{Svc: svc, Logger: logger}
+}
+
+type auditLogView struct {
+	ID            int64          `json:"id"`
+	Action        string         `json:"action"`
+	UserID        string         `json:"user_id,omitempty"`
+	Email         string         `json:"email,omitempty"`
+	IP            string         `json:"ip,omitempty"`
+	UserAgent     string         `json:"user_agent,omitempty"`
+	Metadata      map[string]any `json:"metadata,omitempty"`
+	CreatedAt     time.Time      `json:"created_at"`
+	CorrelationID string         `json:"correlation_id,omitempty"`
+}
+
+func newAuditLogView(a entity.AuditLog) auditLogView {
+	return auditLogView{ID: a.ID, Action: a.Action, UserID: a.UserID, Email: a.Email, IP: a.IP, UserAgent: a.UserAgent, Metadata: a.Metadata, CreatedAt: a.CreatedAt, CorrelationID: a.CorrelationID}
+}
+
+type emailEventView struct {
+	ID         int64     `json:"id"`
+	Event      string    `json:"event"`
+	Recipient  string    `json:"recipient"`
+	MessageID  string    `json:"message_id,omitempty"`
+	Reason     string    `json:"reason,omitempty"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// Get GET /api/admin/correlations/:id
+// Returns the audit entries of one auth flow with the provider outcomes of its emails (delivered,
+// bounced, ...) and its login and email trail, each oldest first.
+// Written against httpx, so it is mounted through route.Route.Func.
+func (h *CorrelationHandler) Get(r *httpx.Request) httpx.Response {
+	corr, err := h.Svc.Lookup(r.Context(), r.Param("id"))
+	if errors.Is(err, userapp.ErrCorrelationNotFound) {
+		return httpx.Error(http.StatusNotFound, "correlation not found", nil)
+	}
+	if err != nil {
+		return errorResponse(h.Logger, err, "failed to load correlation")
+	}
+	logs := make([]auditLogView, 0, len(corr.AuditLogs))
+	for _, a := range corr.AuditLogs {
+		logs = append(logs, newAuditLogView(a))
+	}
+	emails := make([]emailEventView, 0, len(corr.EmailEvents))
+	for _, e := range corr.EmailEvents {
+		emails = append(emails, emailEventView{ID: e.ID, Event: e.Event, Recipient: e.Recipient, MessageID: e.MessageID, Reason: e.Reason, OccurredAt: e.OccurredAt})
+	}
+	return httpx.OK(map[string]any{"correlation_id": corr.ID, "audit_logs": logs, "email_events": emails, "trail": corr.Trail})
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/response"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/validation"
+)
+
+type CORSAdminHandler struct {
+	Origins *helpers.CORSOrigins
+	Logger  *logrus.Logger
+}
+
+func NewCORSAdminHandler(origins *helpers.CORSOrigins, logger *logrus.Logger) *CORSAdminHandler {
+	return &CORSAdminHandler{Origins: origins, Logger: logger}
+}
+
+// List returns the static (CORS_ALLOWED_ORIGINS) and Redis-managed origin patterns.
+func (h *CORSAdminHandler) List(c *gin.Context) {
+	dynamic, err := h.Origins.Dynamic(c.Request.Context())
+	if err != nil {
+		serverError(c, h.Logger, err, "failed to list origins")
+		return
+	}
+	response.Success[any](c, http.StatusOK, map[string]any{"static": h.Origins.Static(), "managed": dynamic}, "ok", nil)
+}
+
+// Add allows an origin pattern {origin} on every replica (this one at once, others within CORS_REFRESH_INTERVAL).
+func (h *CORSAdminHandler) Add(c *gin.Context) {
+	var req struct {
+		Origin string `json:"origin" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error[any](c, http.StatusBadRequest, "invalid payload", validation.Details(err))
+		return
+	}
+	origin, err := h.Origins.Add(c.Request.Context(), req.Origin)
+	switch {
+	case errors.Is(err, helpers.ErrInvalidOrigin):
+		response.Error[any](c, http.StatusBadRequest, err.Error(), nil)
+	case err != nil:
+		serverError(c, h.Logger, err, "failed to add origin")
+	default:
+		h.Logger.WithFields(logrus.Fields{"origin": origin, "by": c.GetString("userID")}).Info("cors origin added")
+		response.Success[any](c, http.StatusCreated, map[string]any{"origin": origin}, "origin added", nil)
+	}
+}
+
+// Remove drops the Redis-managed pattern ?origin=; static origins can only change through config.
+func (h *CORSAdminHandler) Remove(c *gin.Context) {
+	origin := c.Query("origin")
+	if origin == "" {
+		response.Error[any](c, http.StatusBadRequest, "origin is required", nil)
+		return
+	}
+	removed, err := h.Origins.Remove(c.Request.Context(), origin)
+	if err != nil {
+		serverError(c, h.Logger, err, "failed to remove origin")
+		return
+	}
+	if !removed {
+		response.Error[any](c, http.StatusNotFound, "origin not managed", nil)
+		return
+	}
+	h.Logger.WithFields(logrus.Fields{"origin": origin, "by": c.GetString("userID")}).Info("cors origin removed")
+	response.Success[any](c, http.StatusOK, map[string]any{"origin": origin}, "origin removed", nil)
+}
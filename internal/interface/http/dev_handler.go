@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/config"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/mailer"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/response"
+)
+
+// DevHandler exposes development-only helpers (never mounted in production).
+type DevHandler struct {
+	Cfg *config.Config
+}
+
+func NewDevHandler(cfg *config.Config) *DevHandler {
+	return &DevHandler{Cfg: cfg}
+}
+
+// ListEmails GET /api/dev/emails?limit=N lists emails captured by the file mail driver.
+func (h *DevHandler) ListEmails(c *gin.Context) {
+	limit := 50
+	if s := c.Query("limit"); s != "" {
+		if v, err := strconv.Atoi(s); err == nil && v > 0 && v <= 500 {
+			limit = v
+		}
+	}
+	emails, err := mailer.ListCaptured(h.Cfg.MailCaptureDir, limit)
+	if err != nil {
+		response.Error[any](c, http.StatusInternalServerError, "failed to list captured emails", err.Error())
+		return
+	}
+	response.Success[any](c, http.StatusOK, emails, "captured emails", nil)
+}
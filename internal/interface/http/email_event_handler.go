@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	userapp "github.com/oksasatya/go-ddd-clean-architecture/internal/application"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/mailer"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/response"
+)
+
+const (
+	mailgunWebhookMaxAge   = 15 * time.Minute
+	mailgunWebhookMaxBytes = 256 << 10
+)
+
+type EmailEventHandler struct {
+	Events     *userapp.EmailEventService
+	SigningKey string // MAILGUN_WEBHOOK_SIGNING_KEY
+	Logger     *logrus.Logger
+}
+
+func NewEmailEventHandler(events *userapp.EmailEventService, signingKey string, logger *logrus.Logger) *EmailEventHandler {
+	return &EmailEventHandler{Events: events, SigningKey: signingKey, Logger: logger}
+}
+
+// Mailgun receives a Mailgun webhook. Delivered, failed (bounced/deferred), complained and
+// unsubscribed events are recorded once each; other events are acknowledged and dropped. Errors
+// answer non-2xx so Mailgun retries.
+func (h *EmailEventHandler) Mailgun(c *gin.Context) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, mailgunWebhookMaxBytes))
+	if err != nil {
+		response.Error[any](c, http.StatusBadRequest, "invalid payload", nil)
+		return
+	}
+	ev, err := mailer.ParseMailgunWebhook(body, h.SigningKey, mailgunWebhookMaxAge)
+	switch {
+	case errors.Is(err, mailer.ErrWebhookSignature), errors.Is(err, mailer.ErrWebhookStale):
+		response.Error[any](c, http.StatusUnauthorized, err.Error(), nil)
+		return
+	case err != nil:
+		response.Error[any](c, http.StatusBadRequest, "invalid payload", nil)
+		return
+	}
+	if ev.Status == "" || ev.ID == "" {
+		response.Success[any](c, http.StatusOK, map[string]any{"recorded": false}, "event ignored", nil)
+		return
+	}
+	recorded, err := h.Events.Record(c.Request.Context(), &entity.EmailEvent{
+		Provider: "mailgun", ProviderEventID: ev.ID, Event: ev.Status, Recipient: ev.Recipient,
+		MessageID: ev.MessageID, Reason: ev.Reason, Variables: ev.Variables, OccurredAt: ev.OccurredAt,
+	})
+	if err != nil {
+		serverError(c, h.Logger, err, "failed to record email event")
+		return
+	}
+	response.Success[any](c, http.StatusOK, map[string]any{"recorded": recorded}, "event received", nil)
+}
@@ -1,14 +1,18 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 
 	"github.com/oksasatya/go-ddd-clean-architecture/config"
+	userapp "github.com/oksasatya/go-ddd-clean-architecture/internal/application"
 	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
 	"github.com/oksasatya/go-ddd-clean-architecture/pkg/mailer"
+	tpl "github.com/oksasatya/go-ddd-clean-architecture/pkg/mailer/templates"
 	"github.com/oksasatya/go-ddd-clean-architecture/pkg/response"
 	"github.com/oksasatya/go-ddd-clean-architecture/pkg/validation"
 )
@@ -17,44 +21,126 @@ type EmailHandler struct {
 	Pub    *helpers.RabbitPublisher
 	Logger *logrus.Logger
 	Cfg    *config.Config
+	Quotas *userapp.OrgQuotaService // org email quota for the org-scoped route (optional)
+	// Roles lets admins override from with a MAIL_FROM_ALLOWLIST sender; nil rejects every override
+	Roles *userapp.RoleService
 }
 
-func NewEmailHandler(pub *helpers.RabbitPublisher, logger *logrus.Logger, cfg *config.Config) *EmailHandler {
-	return &EmailHandler{Pub: pub, Logger: logger, Cfg: cfg}
+func NewEmailHandler(pub *helpers.RabbitPublisher, logger *logrus.Logger, cfg *config.Config, quotas *userapp.OrgQuotaService) *EmailHandler {
+	return &EmailHandler{Pub: pub, Logger: logger, Cfg: cfg, Quotas: quotas}
 }
 
 type sendEmailRequest struct {
 	To       string         `json:"to" binding:"required,email"`
-	Template string         `json:"template"` // optional: login_notification, verify_email, forgot_password, profile_updated
+	Template string         `json:"template"` // optional: universal (with data.Type) or a legacy type name; security types are rejected
 	Data     map[string]any `json:"data"`     // optional template data
 	Subject  string         `json:"subject"`  // required if no template
 	Text     string         `json:"text"`     // optional if html provided
 	HTML     string         `json:"html"`     // optional if text provided
+	Locale   string         `json:"locale"`   // optional: subject locale for templates (e.g. "en", "id")
+	From     string         `json:"from"`     // optional: sender override, admins only and within MAIL_FROM_ALLOWLIST
+	ReplyTo  string         `json:"reply_to"` // optional
+	CC       []string       `json:"cc"`       // optional; at most mailer.MaxCopies with bcc
+	BCC      []string       `json:"bcc"`      // optional
+
+	// Mailgun options (optional): tags, tracking overrides, custom variables
+	Tags        []string          `json:"tags"`
+	TrackClicks *bool             `json:"track_clicks"`
+	TrackOpens  *bool             `json:"track_opens"`
+	Variables   map[string]string `json:"variables"` // user_id, request_id and correlation_id are reserved
+
+	// DedupKey makes retries idempotent: jobs with the same key (per caller) are sent once
+	DedupKey string `json:"dedup_key"`
+}
+
+// reservedEmailVariables are set by emailVariables; callers cannot override them, webhooks,
+// email_events and the signed forwards attribute emails by them
+var reservedEmailVariables = map[string]bool{"user_id": true, "request_id": true, "correlation_id": true}
+
+// emailVariables are the Mailgun custom variables every enqueued email carries, so webhook
+// events can be joined back to the user and the request that triggered them.
+func emailVariables(c *gin.Context, userID string) map[string]string {
+	vars := map[string]string{}
+	if userID != "" {
+		vars["user_id"] = userID
+	}
+	if rid := c.GetString("request_id"); rid != "" {
+		vars["request_id"] = rid
+	}
+	if cid := helpers.CorrelationIDFrom(c.Request.Context()); cid != "" {
+		vars["correlation_id"] = cid
+	}
+	return vars
+}
+
+// emailJobMeta records the producing request (and auth flow) on the job for worker logs and metrics
+func emailJobMeta(c *gin.Context, userID string) mailer.JobMeta {
+	return mailer.JobMeta{RequestID: c.GetString("request_id"), UserID: userID, CorrelationID: helpers.CorrelationIDFrom(c.Request.Context())}
+}
+
+// emailLocale is the user's preferred subject locale: the primary subtag of the first
+// Accept-Language entry ("id-ID;q=0.9, en" -> "id"). Users have no stored locale, so
+// producers pass the locale of the request that triggered the email.
+func emailLocale(c *gin.Context) string {
+	tag, _, _ := strings.Cut(c.GetHeader("Accept-Language"), ",")
+	tag, _, _ = strings.Cut(tag, ";")
+	tag, _, _ = strings.Cut(strings.TrimSpace(tag), "-")
+	if tag == "*" {
+		return ""
+	}
+	return strings.ToLower(tag)
+}
+
+// allowFrom lets an admin send from a MAIL_FROM_ALLOWLIST sender; anything else would spoof our
+// own Mailgun domain. It answers the request when it reports false.
+func (h *EmailHandler) allowFrom(c *gin.Context, from string) bool {
+	if h.Roles == nil {
+		response.Error[any](c, http.StatusForbidden, "from override is not allowed", nil)
+		return false
+	}
+	ok, err := h.Roles.HasRole(c.Request.Context(), c.GetString("userID"), "admin")
+	if err != nil {
+		serverError(c, h.Logger, err, "authorization unavailable")
+		return false
+	}
+	if !ok {
+		response.Error[any](c, http.StatusForbidden, "from override requires admin", nil)
+		return false
+	}
+	if !mailer.SenderAllowed(from, h.Cfg.MailFromAllowList()) {
+		response.Error[any](c, http.StatusBadRequest, "from is not an allowed sender", map[string]any{"field": "from"})
+		return false
+	}
+	return true
 }
 
 // Send enqueues an email job to RabbitMQ.
 func (h *EmailHandler) Send(c *gin.Context) {
 	var req sendEmailRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.Error[any](c, http.StatusBadRequest, "invalid payload", validation.ToDetails(err))
+		response.Error[any](c, http.StatusBadRequest, "invalid payload", validation.Details(err))
+		return
+	}
+	if req.From != "" && !h.allowFrom(c, req.From) {
 		return
 	}
 
-	// Validate: either template provided, or raw subject with at least text/html
-	if req.Template == "" {
-		if req.Subject == "" || (req.Text == "" && req.HTML == "") {
-			response.Error[any](c, http.StatusBadRequest, "either template or subject with text/html is required", nil)
+	job := mailer.EmailJob{To: req.To, Locale: req.Locale, Envelope: mailer.Envelope{
+		From: req.From, ReplyTo: req.ReplyTo, CC: req.CC, BCC: req.BCC,
+		Tags: req.Tags, TrackClicks: req.TrackClicks, TrackOpens: req.TrackOpens,
+		Variables: emailVariables(c, c.GetString("userID")),
+	}, JobMeta: emailJobMeta(c, c.GetString("userID"))}
+	for k, v := range req.Variables {
+		if reservedEmailVariables[strings.ToLower(strings.TrimSpace(k))] {
+			response.Error[any](c, http.StatusBadRequest, "variable "+k+" is reserved", map[string]any{"field": "variables." + k})
 			return
 		}
+		job.Variables[k] = v
 	}
-
-	// If sending disabled, short-circuit
-	if h.Cfg != nil && !h.Cfg.MailSendEnabled {
-		response.Success[any](c, http.StatusAccepted, map[string]any{"enqueued": false, "disabled": true}, "email sending disabled", nil)
-		return
+	if req.DedupKey != "" {
+		// Scoped to the caller so one client cannot suppress another's emails
+		job.DedupKey = "api:" + c.GetString("userID") + ":" + req.DedupKey
 	}
-
-	job := mailer.EmailJob{To: req.To}
 	if req.Template != "" {
 		job.Template = req.Template
 		job.Data = req.Data
@@ -63,7 +149,37 @@ func (h *EmailHandler) Send(c *gin.Context) {
 		job.Text = req.Text
 		job.HTML = req.HTML
 	}
-	if err := h.Pub.PublishJSON(c.Request.Context(), job); err != nil {
+	// Legacy template names are accepted and upgraded; then reject what the worker could not
+	// render: unknown template, missing data, bad address
+	err := job.Upgrade()
+	if err == nil {
+		err = job.Validate()
+	}
+	if err != nil {
+		response.Error[any](c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	// Security emails bypass opt-outs and the per-recipient limit, so only the server sends them
+	if typ, _ := job.Data["Type"].(string); job.Template != "" && tpl.IsSecurity(typ) {
+		response.Error[any](c, http.StatusBadRequest, "security email "+typ+" cannot be sent through the API", map[string]any{"field": "data.Type"})
+		return
+	}
+
+	// If sending disabled, short-circuit
+	if h.Cfg != nil && !h.Cfg.MailSendEnabled {
+		response.Success[any](c, http.StatusAccepted, map[string]any{"enqueued": false, "disabled": true}, "email sending disabled", nil)
+		return
+	}
+
+	// Sends on behalf of an organization count against its daily email quota, one per recipient
+	if orgID := c.GetString("orgID"); orgID != "" && h.Quotas != nil {
+		if res, err := h.Quotas.ConsumeEmails(c.Request.Context(), orgID, 1+len(job.CC)+len(job.BCC)); errors.Is(err, helpers.ErrQuotaExceeded) {
+			response.Error[any](c, http.StatusPaymentRequired, "organization email quota exceeded", map[string]any{"quota": "emails", "limit": res.Limit, "reset_in": int(res.Reset.Seconds())})
+			return
+		}
+	}
+
+	if err := h.Pub.PublishEmail(c.Request.Context(), job); err != nil {
 		if h.Logger != nil {
 			h.Logger.WithError(err).Warn("failed to publish email job")
 		}
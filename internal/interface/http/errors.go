@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	repo "github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/httpx"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/response"
+)
+
+// errorStatus is the HTTP status for an error of the repository error kinds
+func errorStatus(err error) int {
+	switch repo.KindOf(err) {
+	case repo.ErrNotFound:
+		return http.StatusNotFound
+	case repo.ErrConflict:
+		return http.StatusConflict
+	case repo.ErrUnavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// serverError responds to an error no handler case matched, using its kind's status. msg is
+// the response message and log line; driver details only reach the log. Unavailable sets
+// Retry-After so clients back off instead of treating it as a bug.
+func serverError(c *gin.Context, logger *logrus.Logger, err error, msg string) {
+	res := errorResponse(logger, err, msg)
+	for k := range res.Header {
+		c.Header(k, res.Header.Get(k))
+	}
+	response.ErrorCode[any](c, res.Status, res.Err.Code, res.Err.Message, res.Err.Details)
+}
+
+// errorResponse is serverError for httpx handlers
+func errorResponse(logger *logrus.Logger, err error, msg string) httpx.Response {
+	status := errorStatus(err)
+	if logger != nil && status >= http.StatusInternalServerError {
+		logger.WithError(err).WithField("kind", repo.KindOf(err).Error()).Error(msg)
+	}
+	var details any
+	if status != http.StatusInternalServerError {
+		details = map[string]any{"kind": repo.KindOf(err).Error()}
+	}
+	res := httpx.Error(status, msg, details)
+	if errors.Is(err, repo.ErrUnavailable) {
+		res = res.WithHeader("Retry-After", "5")
+	}
+	return res
+}
+
+// passwordRejected answers a failed breached-password check: 400 with a detail for field when the
+// password is breached, 503 when the check could not run. It reports whether err was either.
+func passwordRejected(c *gin.Context, field string, err error) bool {
+	var pwned *helpers.PwnedPasswordError
+	switch {
+	case errors.As(err, &pwned):
+		response.Error[any](c, http.StatusBadRequest, "password found in a data breach", map[string]string{field: pwned.Error()})
+	case errors.Is(err, helpers.ErrPwnedUnavailable):
+		c.Header("Retry-After", "5")
+		response.Error[any](c, http.StatusServiceUnavailable, "password check unavailable", nil)
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	userapp "github.com/oksasatya/go-ddd-clean-architecture/internal/application"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/response"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/validation"
+)
+
+type ExportHandler struct {
+	Jobs   *userapp.ExportService
+	Logger *logrus.Logger
+}
+
+func NewExportHandler(jobs *userapp.ExportService, logger *logrus.Logger) *ExportHandler {
+	return &ExportHandler{Jobs: jobs, Logger: logger}
+}
+
+// exportJobView is the polling view of a job; progress is processed/total (0 until counted)
+func exportJobView(j *entity.ExportJob, url string) map[string]any {
+	progress := 0.0
+	if j.Total > 0 {
+		progress = float64(j.Processed) / float64(j.Total)
+	} else if j.State == entity.ExportDone {
+		progress = 1
+	}
+	out := map[string]any{
+		"id":         j.ID,
+		"kind":       j.Kind,
+		"state":      j.State,
+		"total":      j.Total,
+		"processed":  j.Processed,
+		"progress":   progress,
+		"created_at": j.CreatedAt,
+	}
+	if j.RequestedBy != "" {
+		out["requested_by"] = j.RequestedBy
+	}
+	if j.StartedAt != nil {
+		out["started_at"] = j.StartedAt
+	}
+	if j.FinishedAt != nil {
+		out["finished_at"] = j.FinishedAt
+	}
+	if j.Error != "" {
+		out["error"] = j.Error
+	}
+	if url != "" {
+		out["url"] = url
+	}
+	return out
+}
+
+// startExport queues a background export and answers 202 with the job
+func startExport(c *gin.Context, jobs *userapp.ExportService, logger *logrus.Logger, kind string, params any) {
+	job, err := jobs.Request(c.Request.Context(), kind, params, c.GetString("userID"))
+	if errors.Is(err, userapp.ErrExportUnavailable) {
+		response.Error[any](c, http.StatusServiceUnavailable, "async export not configured", nil)
+		return
+	}
+	if err != nil {
+		serverError(c, logger, err, "failed to start export")
+		return
+	}
+	response.Success[any](c, http.StatusAccepted, exportJobView(job, ""), "export queued", nil)
+}
+
+// exportJob answers the job named by the :id param
+func exportJob(c *gin.Context, jobs *userapp.ExportService, logger *logrus.Logger) {
+	if !jobs.CanRun() {
+		response.Error[any](c, http.StatusNotFound, "export job not found", nil)
+		return
+	}
+	job, url, err := jobs.Job(c.Request.Context(), c.Param("id"))
+	if errors.Is(err, userapp.ErrExportJobNotFound) {
+		response.Error[any](c, http.StatusNotFound, "export job not found", nil)
+		return
+	}
+	if err != nil {
+		serverError(c, logger, err, "failed to load export job")
+		return
+	}
+	response.Success[any](c, http.StatusOK, exportJobView(job, url), "ok", nil)
+}
+
+type createExportRequest struct {
+	Kind string `json:"kind" binding:"required,oneof=users audit"`
+	// Filter applies to audit exports: action, user_id, correlation_id, from, to (RFC 3339 or YYYY-MM-DD)
+	Filter struct {
+		Action        string `json:"action"`
+		UserID        string `json:"user_id"`
+		CorrelationID string `json:"correlation_id"`
+		From          string `json:"from"`
+		To            string `json:"to"`
+	} `json:"filter"`
+}
+
+// Create POST /api/admin/exports
+// Body: {"kind": "users"} or {"kind": "audit", "filter": {...}}. Answers 202 with the queued job;
+// poll GET /api/admin/exports/:id until state is done and download from its url.
+func (h *ExportHandler) Create(c *gin.Context) {
+	var req createExportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error[any](c, http.StatusBadRequest, "invalid payload", validation.Details(err))
+		return
+	}
+	var params any
+	if req.Kind == entity.ExportAudit {
+		f := entity.AuditFilter{Action: req.Filter.Action, UserID: req.Filter.UserID, CorrelationID: req.Filter.CorrelationID}
+		var err error
+		if f.From, err = parseUsageTime(req.Filter.From, time.Time{}); err != nil {
+			response.Error[any](c, http.StatusBadRequest, "invalid from", nil)
+			return
+		}
+		if f.To, err = parseUsageTime(req.Filter.To, time.Time{}); err != nil {
+			response.Error[any](c, http.StatusBadRequest, "invalid to", nil)
+			return
+		}
+		params = f
+	}
+	startExport(c, h.Jobs, h.Logger, req.Kind, params)
+}
+
+// Get GET /api/admin/exports/:id
+// State is queued, running, done or failed; progress is processed/total. Done jobs carry a signed
+// download url valid for an hour (fetch the job again for a fresh one).
+func (h *ExportHandler) Get(c *gin.Context) {
+	exportJob(c, h.Jobs, h.Logger)
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	userapp "github.com/oksasatya/go-ddd-clean-architecture/internal/application"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/response"
+)
+
+// completeExternalLogin finishes an IdP (OIDC/SAML) login: applies the admin-only login policy,
+// starts a session and either redirects to postLoginRedirect or returns the login payload.
+func completeExternalLogin(c *gin.Context, svc *userapp.Service, roles *userapp.RoleService, cookies *helpers.Manager, postLoginRedirect string, u *entity.User) {
+	// Same policy as password login: only admins may proceed
+	if ok, aerr := roles.HasRole(c.Request.Context(), u.ID, "admin"); aerr != nil {
+		serverError(c, nil, aerr, "login unavailable")
+		return
+	} else if !ok {
+		response.Error[any](c, http.StatusForbidden, "forbidden", nil)
+		return
+	}
+
+	deviceID, _ := cookies.Get(c, helpers.CookieDeviceID)
+	ctx := userapp.WithSessionClient(c.Request.Context(), userapp.SessionClient{IP: clientIP(c), UserAgent: c.GetHeader("User-Agent"), DeviceID: deviceID})
+	pair, err := svc.IssueTokens(ctx, u)
+	if accountBlocked(c, err) {
+		return
+	}
+	if err != nil {
+		serverError(c, nil, err, "login failed")
+		return
+	}
+	cookies.SetPair(c, pair.AccessToken, pair.AccessTokenExpiry, pair.RefreshToken, pair.RefreshTokenExpiry)
+	setDeviceCookie(c, cookies, pair)
+	if postLoginRedirect != "" {
+		c.Redirect(http.StatusFound, postLoginRedirect)
+		return
+	}
+	response.Success[any](c, http.StatusOK, map[string]any{
+		"user_id": u.ID,
+		"email":   u.Email,
+		"name":    u.Name,
+	}, "login successful", map[string]any{"access_expires_at": pair.AccessTokenExpiry, "refresh_expires_at": pair.RefreshTokenExpiry})
+}
+
+// completeLink answers a finished link flow; the current session is kept as is.
+func completeLink(c *gin.Context, postLoginRedirect, provider string, u *entity.User) {
+	if postLoginRedirect != "" {
+		c.Redirect(http.StatusFound, postLoginRedirect)
+		return
+	}
+	response.Success[any](c, http.StatusOK, map[string]any{"user_id": u.ID, "provider": provider}, "provider linked", nil)
+}
+
+// identityError maps account-linking errors; it reports false for errors it does not handle.
+func identityError(c *gin.Context, provider string, err error) bool {
+	switch {
+	case errors.Is(err, userapp.ErrIdentityConflict):
+		response.Error[any](c, http.StatusConflict, err.Error(), map[string]any{"provider": provider, "action": "link"})
+	case errors.Is(err, userapp.ErrIdentityLinkedElsewhere), errors.Is(err, userapp.ErrIdentityAlreadyLinked):
+		response.Error[any](c, http.StatusConflict, err.Error(), map[string]any{"provider": provider})
+	case errors.Is(err, userapp.ErrIdentityNoSubject):
+		response.Error[any](c, http.StatusForbidden, err.Error(), nil)
+	default:
+		return false
+	}
+	return true
+}
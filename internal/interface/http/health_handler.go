@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+
+	userapp "github.com/oksasatya/go-ddd-clean-architecture/internal/application"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+)
+
+// HealthHandler serves readiness and pool metrics for Postgres, Redis and RabbitMQ, plus email
+// queue depth so a stuck consumer shows up in alerting.
+type HealthHandler struct {
+	DB      *pgxpool.Pool
+	RDB     *redis.Client
+	Pub     *helpers.RabbitPublisher
+	Drain   *helpers.DrainState
+	Queues  *helpers.QueueProbe        // optional
+	Latency *helpers.LatencyHistograms // optional per-route request durations
+	Hygiene *userapp.KeyHygieneService // optional Redis key sweep counters
+	Audit   *userapp.AuditWriter       // optional async audit writer counters
+	Search  *userapp.UserIndexSync     // optional users index sync counters
+	Deps    *helpers.DependencyMonitor // optional dependency health and shed requests
+	Auth    *helpers.AuthMetrics       // optional login outcomes by country/ASN
+	Batches *helpers.RedisBatchMetrics // optional batched Redis pipeline sizes and latencies
+}
+
+func NewHealthHandler(db *pgxpool.Pool, rdb *redis.Client, pub *helpers.RabbitPublisher, drain *helpers.DrainState, queues *helpers.QueueProbe, latency *helpers.LatencyHistograms) *HealthHandler {
+	return &HealthHandler{DB: db, RDB: rdb, Pub: pub, Drain: drain, Queues: queues, Latency: latency}
+}
+
+// PoolStats is a snapshot of connection pool saturation per dependency.
+type PoolStats struct {
+	Postgres map[string]any `json:"postgres,omitempty"`
+	Redis    map[string]any `json:"redis,omitempty"`
+	RabbitMQ map[string]any `json:"rabbitmq,omitempty"`
+}
+
+func (h *HealthHandler) poolStats() PoolStats {
+	var ps PoolStats
+	if h.DB != nil {
+		s := h.DB.Stat()
+		ps.Postgres = map[string]any{
+			"total_conns":            s.TotalConns(),
+			"idle_conns":             s.IdleConns(),
+			"acquired_conns":         s.AcquiredConns(),
+			"constructing_conns":     s.ConstructingConns(),
+			"max_conns":              s.MaxConns(),
+			"acquire_count":          s.AcquireCount(),
+			"empty_acquire_count":    s.EmptyAcquireCount(),
+			"canceled_acquire_count": s.CanceledAcquireCount(),
+			"acquire_duration_ms":    s.AcquireDuration().Milliseconds(),
+		}
+	}
+	if h.RDB != nil {
+		s := h.RDB.PoolStats()
+		ps.Redis = map[string]any{
+			"hits":        s.Hits,
+			"misses":      s.Misses,
+			"timeouts":    s.Timeouts,
+			"total_conns": s.TotalConns,
+			"idle_conns":  s.IdleConns,
+			"stale_conns": s.StaleConns,
+		}
+	}
+	if h.Pub != nil {
+		ps.RabbitMQ = map[string]any{"channel_open": !h.Pub.IsClosed()}
+	}
+	return ps
+}
+
+// Readyz GET /readyz: pings dependencies and reports pool stats; 503 when any check fails or while draining.
+// Queue stats are informational: a stuck email consumer does not make the API unready.
+func (h *HealthHandler) Readyz(c *gin.Context) {
+	if h.Drain.Draining() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "draining"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+
+	checks := map[string]string{}
+	ready := true
+	if h.DB != nil {
+		if err := h.DB.Ping(ctx); err != nil {
+			checks["postgres"] = err.Error()
+			ready = false
+		} else {
+			checks["postgres"] = "ok"
+		}
+	}
+	if h.RDB != nil {
+		if err := h.RDB.Ping(ctx).Err(); err != nil {
+			checks["redis"] = err.Error()
+			ready = false
+		} else {
+			checks["redis"] = "ok"
+		}
+	}
+	if h.Pub != nil {
+		if h.Pub.IsClosed() {
+			checks["rabbitmq"] = "channel closed"
+			ready = false
+		} else {
+			checks["rabbitmq"] = "ok"
+		}
+	}
+
+	status, code := "ok", http.StatusOK
+	if !ready {
+		status, code = "unavailable", http.StatusServiceUnavailable
+	}
+	body := gin.H{"status": status, "checks": checks, "pools": h.poolStats()}
+	if queues := h.Queues.Stats(ctx); len(queues) > 0 {
+		body["queues"] = queues
+	}
+	c.JSON(code, body)
+}
+
+// StartDrain POST /internal/drain: fails readiness and begins the graceful shutdown (see cmd/main.go).
+func (h *HealthHandler) StartDrain(c *gin.Context) {
+	if h.Drain == nil {
+		c.JSON(http.StatusNotImplemented, gin.H{"status": "unsupported"})
+		return
+	}
+	started := h.Drain.Start()
+	c.JSON(http.StatusAccepted, gin.H{"status": "draining", "started": started})
+}
+
+// Metrics GET /metrics: pool stats, queue depth and request latency in Prometheus text exposition format.
+func (h *HealthHandler) Metrics(c *gin.Context) {
+	var b strings.Builder
+	gauge := func(name, help string, v any) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s gauge\n%s %v\n", name, help, name, name, v)
+	}
+	if h.DB != nil {
+		s := h.DB.Stat()
+		gauge("db_pool_total_conns", "Total connections in the Postgres pool.", s.TotalConns())
+		gauge("db_pool_idle_conns", "Idle connections in the Postgres pool.", s.IdleConns())
+		gauge("db_pool_acquired_conns", "Connections currently acquired from the Postgres pool.", s.AcquiredConns())
+		gauge("db_pool_constructing_conns", "Connections being established.", s.ConstructingConns())
+		gauge("db_pool_max_conns", "Maximum size of the Postgres pool.", s.MaxConns())
+		gauge("db_pool_acquire_count", "Cumulative successful acquires.", s.AcquireCount())
+		gauge("db_pool_empty_acquire_count", "Cumulative acquires that waited for a connection.", s.EmptyAcquireCount())
+		gauge("db_pool_canceled_acquire_count", "Cumulative acquires canceled by context.", s.CanceledAcquireCount())
+		gauge("db_pool_acquire_duration_seconds", "Cumulative time spent acquiring connections.", s.AcquireDuration().Seconds())
+	}
+	if h.RDB != nil {
+		s := h.RDB.PoolStats()
+		gauge("redis_pool_hits", "Times a free connection was found in the pool.", s.Hits)
+		gauge("redis_pool_misses", "Times a free connection was not found in the pool.", s.Misses)
+		gauge("redis_pool_timeouts", "Times a wait for a connection timed out.", s.Timeouts)
+		gauge("redis_pool_total_conns", "Total connections in the Redis pool.", s.TotalConns)
+		gauge("redis_pool_idle_conns", "Idle connections in the Redis pool.", s.IdleConns)
+		gauge("redis_pool_stale_conns", "Stale connections removed from the Redis pool.", s.StaleConns)
+	}
+	if h.Pub != nil {
+		open := 0
+		if !h.Pub.IsClosed() {
+			open = 1
+		}
+		gauge("rabbitmq_channel_open", "1 when the publisher channel is open.", open)
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+	helpers.WriteQueueMetrics(&b, h.Queues.Stats(ctx))
+	h.Latency.WriteMetrics(&b)
+	h.Hygiene.WriteMetrics(&b)
+	h.Audit.WriteMetrics(&b)
+	h.Search.WriteMetrics(&b)
+	h.Deps.WriteMetrics(&b)
+	h.Auth.WriteMetrics(&b)
+	h.Batches.WriteMetrics(&b)
+	c.Data(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", []byte(b.String()))
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	userapp "github.com/oksasatya/go-ddd-clean-architecture/internal/application"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/response"
+)
+
+type IdentityHandler struct {
+	Svc    *userapp.IdentityService
+	Logger *logrus.Logger
+}
+
+func NewIdentityHandler(svc *userapp.IdentityService, logger *logrus.Logger) *IdentityHandler {
+	return &IdentityHandler{Svc: svc, Logger: logger}
+}
+
+// List returns the external identities linked to the current user.
+func (h *IdentityHandler) List(c *gin.Context) {
+	ids, err := h.Svc.List(c.Request.Context(), c.GetString("userID"))
+	if err != nil {
+		serverError(c, h.Logger, err, "failed to list identities")
+		return
+	}
+	out := make([]map[string]any, 0, len(ids))
+	for _, id := range ids {
+		out = append(out, map[string]any{"provider": id.Provider, "email": id.Email, "linked_at": id.CreatedAt})
+	}
+	response.Success[any](c, http.StatusOK, out, "ok", nil)
+}
+
+// Unlink removes the identity of the given provider from the current user.
+func (h *IdentityHandler) Unlink(c *gin.Context) {
+	provider := c.Param("provider")
+	if err := h.Svc.Unlink(c.Request.Context(), c.GetString("userID"), provider); err != nil {
+		if errors.Is(err, userapp.ErrIdentityNotFound) {
+			response.Error[any](c, http.StatusNotFound, err.Error(), nil)
+			return
+		}
+		serverError(c, h.Logger, err, "failed to unlink provider")
+		return
+	}
+	response.Success[any](c, http.StatusOK, map[string]any{"provider": provider}, "provider unlinked", nil)
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/response"
+)
+
+type IntrospectHandler struct {
+	JWT      *helpers.JWTManager
+	Sessions repository.SessionStore
+	Logger   *logrus.Logger
+}
+
+func NewIntrospectHandler(jwt *helpers.JWTManager, sessions repository.SessionStore, logger *logrus.Logger) *IntrospectHandler {
+	return &IntrospectHandler{JWT: jwt, Sessions: sessions, Logger: logger}
+}
+
+type introspectRequest struct {
+	Token         string `json:"token" form:"token"`
+	TokenTypeHint string `json:"token_type_hint" form:"token_type_hint"`
+}
+
+// Introspect validates an access token RFC 7662-style: the token is active only when its
+// signature and expiry are valid and its session is still current. Inactive tokens
+// yield {"active": false} with no further detail.
+func (h *IntrospectHandler) Introspect(c *gin.Context) {
+	var req introspectRequest
+	if err := c.ShouldBind(&req); err != nil || strings.TrimSpace(req.Token) == "" {
+		response.Error[any](c, http.StatusBadRequest, "token is required", nil)
+		return
+	}
+	inactive := map[string]any{"active": false}
+
+	claims, err := h.JWT.ParseAccessTokenAnyAudience(strings.TrimSpace(req.Token))
+	if err != nil {
+		response.Success[any](c, http.StatusOK, inactive, "ok", nil)
+		return
+	}
+
+	sessionActive := false
+	if h.Sessions != nil {
+		_, sErr := h.Sessions.Get(c.Request.Context(), claims.UserID, claims.SessionID)
+		// a suspended or banned account's tokens are simply inactive
+		inactiveErr := errors.Is(sErr, repository.ErrSessionNotFound) || errors.Is(sErr, repository.ErrSessionMismatch) ||
+			errors.Is(sErr, entity.ErrAccountSuspended) || errors.Is(sErr, entity.ErrAccountBanned)
+		if sErr != nil && !inactiveErr {
+			if h.Logger != nil {
+				h.Logger.WithError(sErr).Warn("introspect: session lookup failed")
+			}
+			response.Error[any](c, http.StatusServiceUnavailable, "session store unavailable", nil)
+			return
+		}
+		sessionActive = sErr == nil
+	}
+	if !sessionActive {
+		response.Success[any](c, http.StatusOK, inactive, "ok", nil)
+		return
+	}
+
+	out := map[string]any{
+		"active":         true,
+		"token_type":     "access_token",
+		"sub":            claims.UserID,
+		"sid":            claims.SessionID,
+		"scope":          strings.Join(claims.Scopes(), " "),
+		"session_active": sessionActive,
+	}
+	if len(claims.Custom) > 0 {
+		out["ext"] = claims.Custom
+	}
+	if len(claims.Audience) > 0 {
+		out["aud"] = []string(claims.Audience)
+	}
+	if claims.ExpiresAt != nil {
+		out["exp"] = claims.ExpiresAt.Unix()
+	}
+	if claims.IssuedAt != nil {
+		out["iat"] = claims.IssuedAt.Unix()
+	}
+	response.Success[any](c, http.StatusOK, out, "ok", nil)
+}
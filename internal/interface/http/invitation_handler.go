@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/config"
+	userapp "github.com/oksasatya/go-ddd-clean-architecture/internal/application"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/mailer"
+	tpl "github.com/oksasatya/go-ddd-clean-architecture/pkg/mailer/templates"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/response"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/validation"
+)
+
+type InvitationHandler struct {
+	Svc    *userapp.InvitationService
+	Pub    *helpers.RabbitPublisher
+	Cfg    *config.Config
+	Logger *logrus.Logger
+}
+
+func NewInvitationHandler(svc *userapp.InvitationService, pub *helpers.RabbitPublisher, cfg *config.Config, logger *logrus.Logger) *InvitationHandler {
+	return &InvitationHandler{Svc: svc, Pub: pub, Cfg: cfg, Logger: logger}
+}
+
+type createInvitationRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	Role  string `json:"role" binding:"required"`
+}
+
+type acceptInvitationRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Name     string `json:"name"`
+	Password string `json:"password" binding:"omitempty,pwd"` // optional with LOGIN_CODE_ENABLED
+}
+
+// sendInvitation enqueues the invite email (when mail is enabled) and returns the accept link.
+func sendInvitation(c *gin.Context, pub *helpers.RabbitPublisher, cfg *config.Config, logger *logrus.Logger, inv *entity.Invitation, token string) string {
+	link := cfg.InviteAcceptURL + "?token=" + token
+	if pub != nil && cfg.MailSendEnabled {
+		data := tpl.NewInvitationData(cfg, inv.Email, link, inv.Role, tpl.WithTime(time.Now()), tpl.WithExpiresAt(inv.ExpiresAt))
+		vars := emailVariables(c, "")
+		vars["invitation_id"] = inv.ID
+		job := mailer.EmailJob{To: inv.Email, Template: "universal", Data: tpl.ToMap(data), Envelope: mailer.Envelope{Variables: vars}, JobMeta: emailJobMeta(c, c.GetString("userID"))}
+		if err := pub.PublishEmail(c, job); err != nil && logger != nil {
+			logger.WithError(err).WithField("invitation_id", inv.ID).Warn("enqueue invitation email failed")
+		}
+	}
+	return link
+}
+
+func invitationView(inv entity.Invitation) map[string]any {
+	return map[string]any{
+		"id":          inv.ID,
+		"email":       inv.Email,
+		"role":        inv.Role,
+		"org_id":      inv.OrgID,
+		"org_role":    inv.OrgRole,
+		"status":      userapp.InvitationStatus(inv, time.Now()),
+		"invited_by":  inv.InvitedBy,
+		"expires_at":  inv.ExpiresAt,
+		"accepted_at": inv.AcceptedAt,
+		"revoked_at":  inv.RevokedAt,
+		"created_at":  inv.CreatedAt,
+	}
+}
+
+// Create issues an invitation and emails the accept link (admin).
+func (h *InvitationHandler) Create(c *gin.Context) {
+	var req createInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error[any](c, http.StatusBadRequest, "invalid payload", validation.Details(err))
+		return
+	}
+	inv, token, err := h.Svc.Invite(c.Request.Context(), c.GetString("userID"), req.Email, req.Role)
+	if err != nil {
+		switch {
+		case errors.Is(err, userapp.ErrInvitationEmailTaken):
+			response.Error[any](c, http.StatusConflict, err.Error(), nil)
+		case errors.Is(err, userapp.ErrRoleNotFound), errors.Is(err, userapp.ErrInvalidName):
+			response.Error[any](c, http.StatusBadRequest, err.Error(), nil)
+		default:
+			serverError(c, h.Logger, err, "failed to create invitation")
+		}
+		return
+	}
+
+	out := invitationView(*inv)
+	out["invite_link"] = sendInvitation(c, h.Pub, h.Cfg, h.Logger, inv, token)
+	response.Success[any](c, http.StatusCreated, out, "invitation created", nil)
+}
+
+// List returns all invitations, newest first (admin).
+func (h *InvitationHandler) List(c *gin.Context) {
+	invs, err := h.Svc.List(c.Request.Context())
+	if err != nil {
+		serverError(c, h.Logger, err, "failed to list invitations")
+		return
+	}
+	out := make([]map[string]any, 0, len(invs))
+	for _, inv := range invs {
+		out = append(out, invitationView(inv))
+	}
+	response.Success[any](c, http.StatusOK, out, "ok", nil)
+}
+
+// Revoke cancels a pending invitation (admin).
+func (h *InvitationHandler) Revoke(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		response.Error[any](c, http.StatusBadRequest, "invalid invitation id", nil)
+		return
+	}
+	if err := h.Svc.Revoke(c.Request.Context(), id); err != nil {
+		response.Error[any](c, http.StatusNotFound, "pending invitation not found", nil)
+		return
+	}
+	response.Success[any](c, http.StatusOK, map[string]any{"id": id}, "invitation revoked", nil)
+}
+
+// Accept creates the invited account (public; the token is the credential).
+func (h *InvitationHandler) Accept(c *gin.Context) {
+	var req acceptInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error[any](c, http.StatusBadRequest, "invalid payload", validation.Details(err))
+		return
+	}
+	if req.Password == "" && (h.Cfg == nil || !h.Cfg.LoginCodeEnabled) {
+		response.Error[any](c, http.StatusBadRequest, "password is required", nil)
+		return
+	}
+	u, err := h.Svc.Accept(c.Request.Context(), req.Token, req.Name, req.Password)
+	if err != nil {
+		switch {
+		case passwordRejected(c, "password", err):
+		case errors.Is(err, userapp.ErrInvitationInvalid):
+			response.Error[any](c, http.StatusBadRequest, err.Error(), nil)
+		case errors.Is(err, userapp.ErrInvitationEmailTaken):
+			response.Error[any](c, http.StatusConflict, err.Error(), nil)
+		default:
+			serverError(c, h.Logger, err, "failed to accept invitation")
+		}
+		return
+	}
+	response.Success[any](c, http.StatusCreated, map[string]any{"user_id": u.ID, "email": u.Email, "name": u.Name}, "account created", nil)
+}
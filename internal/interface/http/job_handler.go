@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	userapp "github.com/oksasatya/go-ddd-clean-architecture/internal/application"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/response"
+)
+
+type JobHandler struct {
+	Jobs   *userapp.JobService
+	Logger *logrus.Logger
+}
+
+func NewJobHandler(jobs *userapp.JobService, logger *logrus.Logger) *JobHandler {
+	return &JobHandler{Jobs: jobs, Logger: logger}
+}
+
+// List GET /internal/jobs: the jobs a scheduler can trigger, with their last run
+func (h *JobHandler) List(c *gin.Context) {
+	jobs, err := h.Jobs.List(c.Request.Context())
+	if err != nil {
+		serverError(c, h.Logger, err, "failed to list jobs")
+		return
+	}
+	response.Success[any](c, http.StatusOK, jobs, "ok", nil)
+}
+
+// Run POST /internal/jobs/:name runs the job and answers once it is done: 200 with its result (ran
+// false when another instance is already running it), or a 5xx when it fails, so the scheduler retries.
+func (h *JobHandler) Run(c *gin.Context) {
+	run, err := h.Jobs.Run(c.Request.Context(), c.Param("name"))
+	switch {
+	case errors.Is(err, userapp.ErrJobNotFound):
+		response.Error[any](c, http.StatusNotFound, "job not found", nil)
+	case err != nil:
+		serverError(c, h.Logger, err, "job "+run.Job+" failed")
+	default:
+		response.Success[any](c, http.StatusOK, run, "ok", nil)
+	}
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+	tpl "github.com/oksasatya/go-ddd-clean-architecture/pkg/mailer/templates"
+)
+
+// loginFlowTTL carries a login's correlation id to the OTP confirm step; matches the OTP lifetime
+const loginFlowTTL = 10 * time.Minute
+
+// startLoginFlow puts the login's correlation id on the request context (audit entries and email
+// jobs pick it up from there) and returns it in the X-Correlation-ID header. An empty id starts a
+// new flow.
+func (h *UserHandler) startLoginFlow(c *gin.Context, id string) {
+	if id == "" {
+		id = helpers.NewCorrelationID()
+	}
+	c.Request = c.Request.WithContext(helpers.WithCorrelationID(c.Request.Context(), id))
+	c.Header(helpers.HeaderCorrelationID, id)
+}
+
+// resumeLoginFlow continues the flow the password or code request step started for u (a new one
+// when it expired) and forgets it, so a later login starts afresh
+func (h *UserHandler) resumeLoginFlow(c *gin.Context, u *entity.User) {
+	id := ""
+	if h.RDB != nil {
+		id, _ = h.RDB.GetDel(c, helpers.KeyLoginCorrelation(u.ID)).Result()
+	}
+	h.startLoginFlow(c, id)
+}
+
+// holdLoginFlow keeps the flow id for the OTP confirm step of u
+func (h *UserHandler) holdLoginFlow(c *gin.Context, u *entity.User) {
+	if id := helpers.CorrelationIDFrom(c.Request.Context()); id != "" && h.RDB != nil {
+		_ = h.RDB.Set(c, helpers.KeyLoginCorrelation(u.ID), id, loginFlowTTL).Err()
+	}
+}
+
+// loginStep adds a step to the flow's login trail; best effort
+func (h *UserHandler) loginStep(c *gin.Context, userID, event string, fields map[string]any) {
+	if h.Cfg == nil {
+		return
+	}
+	if fields == nil {
+		fields = map[string]any{}
+	}
+	fields["ip"] = clientIP(c)
+	ctx := c.Request.Context()
+	ev := helpers.CorrelationEvent{Source: helpers.CorrelationLogin, Event: event, UserID: userID, Fields: fields}
+	if err := helpers.RecordCorrelation(ctx, h.RDB, helpers.CorrelationIDFrom(ctx), h.Cfg.CorrelationTTL, ev); err != nil {
+		helpers.FromContext(ctx).WithError(err).WithField("event", event).Warn("login trail not recorded")
+	}
+}
+
+// auditLogin records a successful login (method: trusted_device, otp or backup_code)
+func (h *UserHandler) auditLogin(c *gin.Context, u *entity.User, method string, g tpl.Geo) {
+	if h.Audit == nil {
+		return
+	}
+	meta := map[string]any{"method": method}
+	// location and timezone feed the activity digest, which has no other record of where users are
+	if loc := tpl.FormatGeo(g); loc != "" {
+		meta["location"] = loc
+	}
+	if g.Timezone != "" {
+		meta["timezone"] = g.Timezone
+	}
+	if err := h.Audit.Record(c.Request.Context(), entity.AuditLog{
+		UserID:    u.ID,
+		Email:     u.Email,
+		Action:    "login",
+		IP:        clientIP(c),
+		UserAgent: c.GetHeader("User-Agent"),
+		Metadata:  meta,
+	}); err != nil {
+		helpers.FromContext(c).WithError(err).Warn("audit log not recorded")
+	}
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	userapp "github.com/oksasatya/go-ddd-clean-architecture/internal/application"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/response"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/validation"
+)
+
+type NotificationHandler struct {
+	Svc    *userapp.NotificationPreferenceService
+	Logger *logrus.Logger
+}
+
+func NewNotificationHandler(svc *userapp.NotificationPreferenceService, logger *logrus.Logger) *NotificationHandler {
+	return &NotificationHandler{Svc: svc, Logger: logger}
+}
+
+// Preferences GET /api/notifications/preferences
+func (h *NotificationHandler) Preferences(c *gin.Context) {
+	prefs, err := h.Svc.Preferences(c.Request.Context(), c.GetString("userID"))
+	if err != nil {
+		serverError(c, h.Logger, err, "failed to load preferences")
+		return
+	}
+	response.Success[any](c, http.StatusOK, prefs, "ok", nil)
+}
+
+// UpdatePreferences PUT /api/notifications/preferences {"account_updates": false}
+func (h *NotificationHandler) UpdatePreferences(c *gin.Context) {
+	var req map[string]bool
+	if err := c.ShouldBindJSON(&req); err != nil || len(req) == 0 {
+		response.Error[any](c, http.StatusBadRequest, "invalid payload", map[string]any{"categories": userapp.NotificationCategories})
+		return
+	}
+	uid := c.GetString("userID")
+	for category, enabled := range req {
+		if err := h.Svc.Set(c.Request.Context(), uid, category, enabled); err != nil {
+			if errors.Is(err, userapp.ErrUnknownNotificationCategory) {
+				response.Error[any](c, http.StatusBadRequest, "unknown category", map[string]any{"category": category, "categories": userapp.NotificationCategories})
+				return
+			}
+			serverError(c, h.Logger, err, "failed to update preferences")
+			return
+		}
+	}
+	h.Preferences(c)
+}
+
+// Unsubscribe POST /api/notifications/unsubscribe {token} (public; token from the email unsubscribe link)
+func (h *NotificationHandler) Unsubscribe(c *gin.Context) {
+	var req struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error[any](c, http.StatusBadRequest, "invalid payload", validation.Details(err))
+		return
+	}
+	category, err := h.Svc.Unsubscribe(c.Request.Context(), req.Token)
+	if err != nil {
+		if errors.Is(err, userapp.ErrUnsubscribeTokenInvalid) || errors.Is(err, userapp.ErrUnknownNotificationCategory) {
+			response.Error[any](c, http.StatusBadRequest, "invalid unsubscribe token", nil)
+			return
+		}
+		serverError(c, h.Logger, err, "failed to unsubscribe")
+		return
+	}
+	response.Success[any](c, http.StatusOK, map[string]any{"category": category, "enabled": false}, "unsubscribed", nil)
+}
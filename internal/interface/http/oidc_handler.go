@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	userapp "github.com/oksasatya/go-ddd-clean-architecture/internal/application"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/response"
+)
+
+type OIDCHandler struct {
+	OIDC              *userapp.OIDCService
+	Svc               *userapp.Service
+	Roles             *userapp.RoleService
+	Cookies           *helpers.Manager
+	Logger            *logrus.Logger
+	PostLoginRedirect string
+}
+
+func NewOIDCHandler(oidc *userapp.OIDCService, svc *userapp.Service, roles *userapp.RoleService, cookies *helpers.Manager, logger *logrus.Logger, postLoginRedirect string) *OIDCHandler {
+	return &OIDCHandler{OIDC: oidc, Svc: svc, Roles: roles, Cookies: cookies, Logger: logger, PostLoginRedirect: postLoginRedirect}
+}
+
+// Login redirects the browser to the IdP authorization endpoint.
+func (h *OIDCHandler) Login(c *gin.Context) {
+	h.start(c, "")
+}
+
+// Link starts the flow that links the IdP identity to the signed-in user.
+func (h *OIDCHandler) Link(c *gin.Context) {
+	h.start(c, c.GetString("userID"))
+}
+
+func (h *OIDCHandler) start(c *gin.Context, linkUserID string) {
+	u, err := h.OIDC.Start(c.Request.Context(), linkUserID)
+	if err != nil {
+		if h.Logger != nil {
+			h.Logger.WithError(err).Warn("oidc: start failed")
+		}
+		response.Error[any](c, http.StatusBadGateway, "identity provider unavailable", nil)
+		return
+	}
+	c.Redirect(http.StatusFound, u)
+}
+
+// Callback completes the authorization code flow and starts a local session.
+func (h *OIDCHandler) Callback(c *gin.Context) {
+	if e := c.Query("error"); e != "" {
+		response.Error[any](c, http.StatusUnauthorized, "identity provider denied login", map[string]any{"error": e})
+		return
+	}
+	u, linked, err := h.OIDC.Callback(c.Request.Context(), c.Query("state"), c.Query("code"))
+	if err != nil {
+		if identityError(c, userapp.ProviderOIDC, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, userapp.ErrOIDCStateInvalid):
+			response.Error[any](c, http.StatusBadRequest, err.Error(), nil)
+		case errors.Is(err, userapp.ErrOIDCNoEmail):
+			response.Error[any](c, http.StatusForbidden, err.Error(), nil)
+		default:
+			if h.Logger != nil {
+				h.Logger.WithError(err).Warn("oidc: callback failed")
+			}
+			response.Error[any](c, http.StatusUnauthorized, "oidc login failed", nil)
+		}
+		return
+	}
+	if linked {
+		completeLink(c, h.PostLoginRedirect, userapp.ProviderOIDC, u)
+		return
+	}
+	completeExternalLogin(c, h.Svc, h.Roles, h.Cookies, h.PostLoginRedirect, u)
+}
@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/config"
+	userapp "github.com/oksasatya/go-ddd-clean-architecture/internal/application"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/response"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/validation"
+)
+
+type OrgHandler struct {
+	Svc    *userapp.OrganizationService
+	Quotas *userapp.OrgQuotaService
+	Pub    *helpers.RabbitPublisher
+	Cfg    *config.Config
+	Logger *logrus.Logger
+}
+
+func NewOrgHandler(svc *userapp.OrganizationService, quotas *userapp.OrgQuotaService, pub *helpers.RabbitPublisher, cfg *config.Config, logger *logrus.Logger) *OrgHandler {
+	return &OrgHandler{Svc: svc, Quotas: quotas, Pub: pub, Cfg: cfg, Logger: logger}
+}
+
+type createOrgRequest struct {
+	Name string `json:"name" binding:"required"`
+	Slug string `json:"slug"`
+}
+
+type inviteMemberRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	Role  string `json:"role" binding:"required"`
+}
+
+type setMemberRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// Limits are pointers so an omitted field (null) falls back to the default
+type setOrgLimitsRequest struct {
+	RatePerMinute  *int `json:"rate_per_minute" binding:"omitempty,min=0"`
+	RequestsPerDay *int `json:"requests_per_day" binding:"omitempty,min=0"`
+	EmailsPerDay   *int `json:"emails_per_day" binding:"omitempty,min=0"`
+}
+
+func quotaView(r helpers.QuotaResult) map[string]any {
+	return map[string]any{"limit": r.Limit, "used": r.Used, "remaining": r.Remaining(), "reset_in": int(r.Reset.Seconds())}
+}
+
+func orgView(o entity.Organization) map[string]any {
+	return map[string]any{"id": o.ID, "name": o.Name, "slug": o.Slug, "created_by": o.CreatedBy, "created_at": o.CreatedAt}
+}
+
+func memberView(m entity.OrgMember) map[string]any {
+	return map[string]any{"user_id": m.UserID, "email": m.Email, "name": m.Name, "role": m.Role, "joined_at": m.CreatedAt}
+}
+
+// orgError maps organization service errors to HTTP responses.
+func (h *OrgHandler) orgError(c *gin.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, userapp.ErrOrgInvalid), errors.Is(err, userapp.ErrOrgInvalidRole):
+		response.Error[any](c, http.StatusBadRequest, err.Error(), nil)
+	case errors.Is(err, userapp.ErrOrgForbidden):
+		response.Error[any](c, http.StatusForbidden, err.Error(), nil)
+	case errors.Is(err, userapp.ErrOrgNotFound), errors.Is(err, userapp.ErrOrgMemberNotFound):
+		response.Error[any](c, http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, helpers.ErrQuotaExceeded):
+		response.Error[any](c, http.StatusPaymentRequired, "organization email quota exceeded", map[string]any{"quota": "emails"})
+	case errors.Is(err, userapp.ErrOrgSlugTaken), errors.Is(err, userapp.ErrOrgAlreadyMember),
+		errors.Is(err, userapp.ErrOrgLastOwner), errors.Is(err, userapp.ErrInvitationEmailTaken):
+		response.Error[any](c, http.StatusConflict, err.Error(), nil)
+	default:
+		serverError(c, h.Logger, err, msg)
+	}
+}
+
+// Create makes a new organization owned by the current user.
+func (h *OrgHandler) Create(c *gin.Context) {
+	var req createOrgRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error[any](c, http.StatusBadRequest, "invalid payload", validation.Details(err))
+		return
+	}
+	org, err := h.Svc.Create(c.Request.Context(), c.GetString("userID"), req.Name, req.Slug)
+	if err != nil {
+		h.orgError(c, err, "failed to create organization")
+		return
+	}
+	out := orgView(*org)
+	out["role"] = userapp.OrgRoleOwner
+	response.Success[any](c, http.StatusCreated, out, "organization created", nil)
+}
+
+// List returns the organizations the current user belongs to.
+func (h *OrgHandler) List(c *gin.Context) {
+	orgs, err := h.Svc.ListForUser(c.Request.Context(), c.GetString("userID"))
+	if err != nil {
+		h.orgError(c, err, "failed to list organizations")
+		return
+	}
+	out := make([]map[string]any, 0, len(orgs))
+	for _, o := range orgs {
+		v := orgView(o.Organization)
+		v["role"] = o.Role
+		out = append(out, v)
+	}
+	response.Success[any](c, http.StatusOK, out, "ok", nil)
+}
+
+// Get returns the organization in the path (members only).
+func (h *OrgHandler) Get(c *gin.Context) {
+	org, err := h.Svc.Get(c.Request.Context(), c.GetString("orgID"))
+	if err != nil {
+		h.orgError(c, err, "failed to get organization")
+		return
+	}
+	out := orgView(*org)
+	out["role"] = c.GetString("orgRole")
+	response.Success[any](c, http.StatusOK, out, "ok", nil)
+}
+
+// ListMembers returns the organization's members (members only).
+func (h *OrgHandler) ListMembers(c *gin.Context) {
+	members, err := h.Svc.ListMembers(c.Request.Context(), c.GetString("orgID"))
+	if err != nil {
+		h.orgError(c, err, "failed to list members")
+		return
+	}
+	out := make([]map[string]any, 0, len(members))
+	for _, m := range members {
+		out = append(out, memberView(m))
+	}
+	response.Success[any](c, http.StatusOK, out, "ok", nil)
+}
+
+// InviteMember adds an existing user directly or emails an invitation to sign up (org admins).
+func (h *OrgHandler) InviteMember(c *gin.Context) {
+	var req inviteMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error[any](c, http.StatusBadRequest, "invalid payload", validation.Details(err))
+		return
+	}
+	member, inv, token, err := h.Svc.InviteMember(c.Request.Context(), c.GetString("userID"), c.GetString("orgID"), req.Email, req.Role)
+	if err != nil {
+		h.orgError(c, err, "failed to invite member")
+		return
+	}
+	if inv != nil {
+		out := invitationView(*inv)
+		out["invite_link"] = sendInvitation(c, h.Pub, h.Cfg, h.Logger, inv, token)
+		response.Success[any](c, http.StatusAccepted, out, "invitation sent", nil)
+		return
+	}
+	response.Success[any](c, http.StatusCreated, memberView(*member), "member added", nil)
+}
+
+// SetMemberRole changes a member's role (org admins; ownership changes need an owner).
+func (h *OrgHandler) SetMemberRole(c *gin.Context) {
+	userID := c.Param("user")
+	if _, err := uuid.Parse(userID); err != nil {
+		response.Error[any](c, http.StatusBadRequest, "invalid user id", nil)
+		return
+	}
+	var req setMemberRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error[any](c, http.StatusBadRequest, "invalid payload", validation.Details(err))
+		return
+	}
+	if err := h.Svc.SetMemberRole(c.Request.Context(), c.GetString("userID"), c.GetString("orgID"), userID, req.Role); err != nil {
+		h.orgError(c, err, "failed to update member role")
+		return
+	}
+	response.Success[any](c, http.StatusOK, map[string]any{"user_id": userID, "role": req.Role}, "member role updated", nil)
+}
+
+// RemoveMember removes a member, or lets a member leave when the path user is themselves.
+func (h *OrgHandler) RemoveMember(c *gin.Context) {
+	userID := c.Param("user")
+	if _, err := uuid.Parse(userID); err != nil {
+		response.Error[any](c, http.StatusBadRequest, "invalid user id", nil)
+		return
+	}
+	if err := h.Svc.RemoveMember(c.Request.Context(), c.GetString("userID"), c.GetString("orgID"), userID); err != nil {
+		h.orgError(c, err, "failed to remove member")
+		return
+	}
+	response.Success[any](c, http.StatusOK, map[string]any{"user_id": userID}, "member removed", nil)
+}
+
+// Usage reports the organization's quota consumption (members only).
+func (h *OrgHandler) Usage(c *gin.Context) {
+	u, err := h.Quotas.Usage(c.Request.Context(), c.GetString("orgID"))
+	if err != nil {
+		h.orgError(c, err, "usage unavailable")
+		return
+	}
+	response.Success[any](c, http.StatusOK, map[string]any{
+		"rate_per_minute":  quotaView(u.Rate),
+		"requests_per_day": quotaView(u.Requests),
+		"emails_per_day":   quotaView(u.Emails),
+	}, "ok", nil)
+}
+
+// SetLimits overrides an organization's quotas (global admin).
+func (h *OrgHandler) SetLimits(c *gin.Context) {
+	orgID := c.Param("org")
+	if _, err := uuid.Parse(orgID); err != nil {
+		response.Error[any](c, http.StatusBadRequest, "invalid organization id", nil)
+		return
+	}
+	var req setOrgLimitsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error[any](c, http.StatusBadRequest, "invalid payload", validation.Details(err))
+		return
+	}
+	limits := entity.OrgLimits{RatePerMinute: req.RatePerMinute, RequestsPerDay: req.RequestsPerDay, EmailsPerDay: req.EmailsPerDay}
+	if err := h.Quotas.SetLimits(c.Request.Context(), orgID, limits); err != nil {
+		h.orgError(c, err, "failed to update limits")
+		return
+	}
+	eff := h.Quotas.Limits(c.Request.Context(), orgID)
+	response.Success[any](c, http.StatusOK, map[string]any{
+		"rate_per_minute":  eff.RatePerMinute,
+		"requests_per_day": eff.RequestsPerDay,
+		"emails_per_day":   eff.EmailsPerDay,
+	}, "limits updated", nil)
+}
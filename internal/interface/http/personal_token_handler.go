@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	userapp "github.com/oksasatya/go-ddd-clean-architecture/internal/application"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/response"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/validation"
+)
+
+// PersonalTokenHandler lets users create, list and revoke the personal access tokens they use for scripts
+type PersonalTokenHandler struct {
+	Svc    *userapp.PersonalTokenService
+	Audit  *userapp.AuditService // optional
+	Logger *logrus.Logger
+}
+
+func NewPersonalTokenHandler(svc *userapp.PersonalTokenService, audit *userapp.AuditService, logger *logrus.Logger) *PersonalTokenHandler {
+	return &PersonalTokenHandler{Svc: svc, Audit: audit, Logger: logger}
+}
+
+type createPersonalTokenRequest struct {
+	Name          string   `json:"name" binding:"required,max=100"`
+	Scopes        []string `json:"scopes" binding:"required,min=1"`
+	ExpiresInDays int      `json:"expires_in_days" binding:"omitempty,min=1"` // optional; PERSONAL_TOKEN_TTL by default
+}
+
+type personalTokenView struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	Status     string     `json:"status"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	LastUsedIP string     `json:"last_used_ip,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func newPersonalTokenView(t entity.PersonalToken) personalTokenView {
+	return personalTokenView{
+		ID:         t.ID,
+		Name:       t.Name,
+		Prefix:     t.Prefix,
+		Scopes:     t.Scopes,
+		Status:     userapp.PersonalTokenStatus(t, time.Now()),
+		ExpiresAt:  t.ExpiresAt,
+		LastUsedAt: t.LastUsedAt,
+		LastUsedIP: t.LastUsedIP,
+		RevokedAt:  t.RevokedAt,
+		CreatedAt:  t.CreatedAt,
+	}
+}
+
+// List GET /api/auth/personal-tokens
+func (h *PersonalTokenHandler) List(c *gin.Context) {
+	tokens, err := h.Svc.List(c.Request.Context(), c.GetString("userID"))
+	if err != nil {
+		serverError(c, h.Logger, err, "failed to list personal access tokens")
+		return
+	}
+	out := make([]personalTokenView, 0, len(tokens))
+	for _, t := range tokens {
+		out = append(out, newPersonalTokenView(t))
+	}
+	response.Success[any](c, http.StatusOK, out, "ok", nil)
+}
+
+// Create POST /api/auth/personal-tokens returns the new token, shown only this once. Its scopes
+// must be a subset of the caller's, and it cannot be done with a personal access token.
+func (h *PersonalTokenHandler) Create(c *gin.Context) {
+	if c.GetString("personalTokenID") != "" {
+		response.Error[any](c, http.StatusForbidden, "personal access tokens cannot create tokens", nil)
+		return
+	}
+	var req createPersonalTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error[any](c, http.StatusBadRequest, "invalid payload", validation.Details(err))
+		return
+	}
+	scopes, err := helpers.ReduceScopes(c.GetStringSlice("scopes"), req.Scopes)
+	if err != nil {
+		response.Error[any](c, http.StatusForbidden, "requested scopes exceed the current token", nil)
+		return
+	}
+	uid := c.GetString("userID")
+	t, token, err := h.Svc.Create(c.Request.Context(), uid, req.Name, scopes, time.Duration(req.ExpiresInDays)*24*time.Hour)
+	switch {
+	case errors.Is(err, userapp.ErrPersonalTokenTTL):
+		response.Error[any](c, http.StatusBadRequest, err.Error(), map[string]any{"max_days": int(h.Svc.MaxTTL.Hours() / 24)})
+		return
+	case errors.Is(err, userapp.ErrPersonalTokenLimit):
+		response.Error[any](c, http.StatusConflict, err.Error(), map[string]any{"max": h.Svc.MaxPerUser})
+		return
+	case err != nil:
+		serverError(c, h.Logger, err, "failed to create personal access token")
+		return
+	}
+	h.audit(c, uid, "personal_token_created", map[string]any{"token_id": t.ID, "name": t.Name, "scopes": t.Scopes, "expires_at": t.ExpiresAt})
+	c.Header("Cache-Control", "no-store")
+	response.Success[any](c, http.StatusCreated, map[string]any{
+		"token":      token,
+		"token_type": "Bearer",
+		"details":    newPersonalTokenView(*t),
+	}, "personal access token created", nil)
+}
+
+// Revoke DELETE /api/auth/personal-tokens/:id; the token stops working at once
+func (h *PersonalTokenHandler) Revoke(c *gin.Context) {
+	uid, id := c.GetString("userID"), c.Param("id")
+	err := h.Svc.Revoke(c.Request.Context(), uid, id)
+	if errors.Is(err, userapp.ErrPersonalTokenNotFound) {
+		response.Error[any](c, http.StatusNotFound, err.Error(), nil)
+		return
+	}
+	if err != nil {
+		serverError(c, h.Logger, err, "failed to revoke personal access token")
+		return
+	}
+	h.audit(c, uid, "personal_token_revoked", map[string]any{"token_id": id})
+	response.Success[any](c, http.StatusOK, map[string]any{"revoked": true}, "personal access token revoked", nil)
+}
+
+func (h *PersonalTokenHandler) audit(c *gin.Context, uid, action string, meta map[string]any) {
+	if h.Audit == nil {
+		return
+	}
+	if err := h.Audit.Record(c.Request.Context(), entity.AuditLog{
+		UserID:    uid,
+		Action:    action,
+		IP:        clientIP(c),
+		UserAgent: c.GetHeader("User-Agent"),
+		Metadata:  meta,
+	}); err != nil {
+		h.Logger.WithError(err).Warn("audit log not recorded")
+	}
+}
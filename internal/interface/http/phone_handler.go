@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	userapp "github.com/oksasatya/go-ddd-clean-architecture/internal/application"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/response"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/validation"
+)
+
+// PhoneHandler manages the signed-in user's phone number, stored encrypted in user_secrets
+type PhoneHandler struct {
+	Secrets *userapp.UserSecretService
+	Audit   *userapp.AuditService // optional
+	Logger  *logrus.Logger
+}
+
+func NewPhoneHandler(secrets *userapp.UserSecretService, audit *userapp.AuditService, logger *logrus.Logger) *PhoneHandler {
+	return &PhoneHandler{Secrets: secrets, Audit: audit, Logger: logger}
+}
+
+type phoneView struct {
+	Phone string `json:"phone"`
+}
+
+type setPhoneRequest struct {
+	Phone string `json:"phone" binding:"required,phone"`
+}
+
+func (h *PhoneHandler) record(c *gin.Context, action string) {
+	if h.Audit == nil {
+		return
+	}
+	if err := h.Audit.Record(c.Request.Context(), entity.AuditLog{
+		UserID:    c.GetString("userID"),
+		Action:    action,
+		IP:        clientIP(c),
+		UserAgent: c.GetHeader("User-Agent"),
+	}); err != nil {
+		h.Logger.WithError(err).Warn("audit log not recorded")
+	}
+}
+
+// Get GET /api/profile/phone
+func (h *PhoneHandler) Get(c *gin.Context) {
+	phone, err := h.Secrets.Get(c.Request.Context(), c.GetString("userID"), entity.SecretPhone)
+	if errors.Is(err, userapp.ErrUserSecretNotFound) {
+		response.Error[any](c, http.StatusNotFound, "no phone number", nil)
+		return
+	}
+	if err != nil {
+		serverError(c, h.Logger, err, "failed to load phone number")
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	response.Success[any](c, http.StatusOK, phoneView{Phone: phone}, "ok", nil)
+}
+
+// Put PUT /api/profile/phone
+// Body: {"phone": "+628123456789"} (E.164). The number is encrypted before it reaches Postgres.
+func (h *PhoneHandler) Put(c *gin.Context) {
+	var req setPhoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error[any](c, http.StatusBadRequest, "invalid payload", validation.Details(err))
+		return
+	}
+	if err := h.Secrets.Set(c.Request.Context(), c.GetString("userID"), entity.SecretPhone, req.Phone); err != nil {
+		serverError(c, h.Logger, err, "failed to save phone number")
+		return
+	}
+	h.record(c, "phone_changed")
+	response.Success[any](c, http.StatusOK, phoneView{Phone: req.Phone}, "phone number saved", nil)
+}
+
+// Delete DELETE /api/profile/phone
+func (h *PhoneHandler) Delete(c *gin.Context) {
+	err := h.Secrets.Delete(c.Request.Context(), c.GetString("userID"), entity.SecretPhone)
+	if errors.Is(err, userapp.ErrUserSecretNotFound) {
+		response.Error[any](c, http.StatusNotFound, "no phone number", nil)
+		return
+	}
+	if err != nil {
+		serverError(c, h.Logger, err, "failed to remove phone number")
+		return
+	}
+	h.record(c, "phone_removed")
+	response.Success[any](c, http.StatusOK, nil, "phone number removed", nil)
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/config"
+	userapp "github.com/oksasatya/go-ddd-clean-architecture/internal/application"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/mailer"
+	tpl "github.com/oksasatya/go-ddd-clean-architecture/pkg/mailer/templates"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/response"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/validation"
+)
+
+// ProfileChangeHandler serves pending changes of sensitive profile fields (PROFILE_CHANGE_APPROVAL).
+// PUT /api/profile creates them through request; the emailed link confirms them.
+type ProfileChangeHandler struct {
+	Svc    *userapp.ProfileChangeService
+	Audit  *userapp.AuditService // optional
+	Pub    *helpers.RabbitPublisher
+	Cfg    *config.Config
+	Logger *logrus.Logger
+}
+
+func NewProfileChangeHandler(svc *userapp.ProfileChangeService, audit *userapp.AuditService, pub *helpers.RabbitPublisher, cfg *config.Config, logger *logrus.Logger) *ProfileChangeHandler {
+	return &ProfileChangeHandler{Svc: svc, Audit: audit, Pub: pub, Cfg: cfg, Logger: logger}
+}
+
+// request starts a change of field for u and emails the confirmation link to the new address. It
+// reports whether the caller may go on; on false the response has been written.
+func (h *ProfileChangeHandler) request(c *gin.Context, u *entity.User, field, value string) (*userapp.PendingProfileChange, bool) {
+	pc, tok, err := h.Svc.Request(c.Request.Context(), u.ID, field, value)
+	switch {
+	case errors.Is(err, userapp.ErrProfileChangeNoop):
+		return nil, true
+	case errors.Is(err, userapp.ErrProfileChangeEmailTaken):
+		response.Error[any](c, http.StatusConflict, "email already in use", map[string]string{field: err.Error()})
+		return nil, false
+	case err != nil:
+		serverError(c, h.Logger, err, "failed to request profile change")
+		return nil, false
+	}
+	if h.Pub != nil && h.Cfg != nil && h.Cfg.MailSendEnabled {
+		link := h.Cfg.ProfileChangeConfirmURL + "?token=" + url.QueryEscape(tok)
+		data := tpl.NewConfirmEmailChangeData(h.Cfg, u.Name, pc.Value, link, tpl.WithTime(time.Now()), tpl.WithExpiresIn(time.Until(pc.ExpiresAt)))
+		job := mailer.EmailJob{To: pc.Value, Locale: emailLocale(c), Template: "universal", Data: tpl.ToMap(data), Envelope: mailer.Envelope{Variables: emailVariables(c, u.ID)}, JobMeta: emailJobMeta(c, u.ID)}
+		if err := h.Pub.PublishEmail(c, job); err != nil {
+			h.Logger.WithError(err).WithField("user_id", u.ID).Warn("enqueue email change confirmation failed")
+		}
+	}
+	h.audit(c, u.ID, u.Email, "profile_change_requested", map[string]any{"field": field})
+	return &pc, true
+}
+
+// Pending GET /api/profile/pending-changes
+func (h *ProfileChangeHandler) Pending(c *gin.Context) {
+	pcs, err := h.Svc.Pending(c.Request.Context(), c.GetString("userID"))
+	if err != nil {
+		serverError(c, h.Logger, err, "failed to load pending changes")
+		return
+	}
+	response.Success[any](c, http.StatusOK, pcs, "ok", nil)
+}
+
+// Cancel DELETE /api/profile/pending-changes/:field
+func (h *ProfileChangeHandler) Cancel(c *gin.Context) {
+	err := h.Svc.Cancel(c.Request.Context(), c.GetString("userID"), strings.ToLower(c.Param("field")))
+	if errors.Is(err, userapp.ErrProfileChangeNotFound) {
+		response.Error[any](c, http.StatusNotFound, "no pending change for this field", nil)
+		return
+	}
+	if err != nil {
+		serverError(c, h.Logger, err, "failed to cancel pending change")
+		return
+	}
+	response.Success[any](c, http.StatusOK, map[string]any{"cancelled": true}, "pending change cancelled", nil)
+}
+
+// Confirm POST /api/profile/changes/confirm {token} (public; token from the emailed link). The old
+// address is told about the change, so an unwanted one can be reported.
+func (h *ProfileChangeHandler) Confirm(c *gin.Context) {
+	var req struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error[any](c, http.StatusBadRequest, "invalid payload", validation.Details(err))
+		return
+	}
+	u, old, pc, err := h.Svc.Confirm(c.Request.Context(), req.Token)
+	switch {
+	case errors.Is(err, userapp.ErrProfileChangeInvalid), errors.Is(err, userapp.ErrUserNotFound):
+		response.Error[any](c, http.StatusBadRequest, "invalid or expired token", nil)
+		return
+	case errors.Is(err, userapp.ErrProfileChangeEmailTaken):
+		response.Error[any](c, http.StatusConflict, "email already in use", nil)
+		return
+	case err != nil:
+		serverError(c, h.Logger, err, "failed to apply profile change")
+		return
+	}
+	h.audit(c, u.ID, u.Email, "profile_change_confirmed", map[string]any{"field": pc.Field, "old": old, "new": pc.Value})
+	h.notifyOld(c, u, old, pc)
+	response.Success[any](c, http.StatusOK, map[string]any{"id": u.ID, "email": u.Email, "field": pc.Field}, "profile change applied", nil)
+}
+
+// notifyOld tells the previous address that the account's email changed (security email, always sent)
+func (h *ProfileChangeHandler) notifyOld(c *gin.Context, u *entity.User, old string, pc userapp.PendingProfileChange) {
+	if h.Pub == nil || h.Cfg == nil || !h.Cfg.MailSendEnabled || old == "" {
+		return
+	}
+	data := tpl.NewProfileUpdatedData(h.Cfg, u.Name, old, map[string]string{pc.Field: pc.Value}, tpl.WithTime(time.Now()))
+	job := mailer.EmailJob{To: old, Locale: emailLocale(c), Template: "universal", Data: tpl.ToMap(data), Envelope: mailer.Envelope{Variables: emailVariables(c, u.ID)}, JobMeta: emailJobMeta(c, u.ID)}
+	if err := h.Pub.PublishEmail(c, job); err != nil {
+		h.Logger.WithError(err).WithField("user_id", u.ID).Warn("enqueue email change notice failed")
+	}
+}
+
+func (h *ProfileChangeHandler) audit(c *gin.Context, userID, email, action string, metadata map[string]any) {
+	if h.Audit == nil {
+		return
+	}
+	if err := h.Audit.Record(c.Request.Context(), entity.AuditLog{
+		UserID:    userID,
+		Email:     email,
+		Action:    action,
+		IP:        clientIP(c),
+		UserAgent: c.GetHeader("User-Agent"),
+		Metadata:  metadata,
+	}); err != nil {
+		h.Logger.WithError(err).WithField("action", action).Warn("audit log not recorded")
+	}
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/response"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/validation"
+)
+
+type RateBypassHandler struct {
+	Bypass *helpers.RateBypass
+	Logger *logrus.Logger
+}
+
+func NewRateBypassHandler(bypass *helpers.RateBypass, logger *logrus.Logger) *RateBypassHandler {
+	return &RateBypassHandler{Bypass: bypass, Logger: logger}
+}
+
+// AddRateBypassRequest exempts an IP, CIDR, user ID or API key from rate limits
+type AddRateBypassRequest struct {
+	Kind       string `json:"kind" binding:"required,oneof=ip cidr user api_key"`
+	Value      string `json:"value" binding:"required"`
+	Note       string `json:"note" binding:"max=200"`
+	TTLSeconds int    `json:"ttl_seconds" binding:"min=0"` // 0 = until removed
+}
+
+// List returns the active bypass entries; API keys appear as their SHA-256
+func (h *RateBypassHandler) List(c *gin.Context) {
+	entries, err := h.Bypass.List(c.Request.Context())
+	if err != nil {
+		serverError(c, h.Logger, err, "failed to list rate limit bypass entries")
+		return
+	}
+	response.Success[any](c, http.StatusOK, entries, "ok", nil)
+}
+
+// Add stores an entry on every replica (this one at once, others within RATE_BYPASS_REFRESH_INTERVAL)
+func (h *RateBypassHandler) Add(c *gin.Context) {
+	var req AddRateBypassRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error[any](c, http.StatusBadRequest, "invalid payload", validation.Details(err))
+		return
+	}
+	entry, err := h.Bypass.Add(c.Request.Context(), helpers.RateBypassEntry{Kind: req.Kind, Value: req.Value, Note: req.Note, AddedBy: c.GetString("userID")}, time.Duration(req.TTLSeconds)*time.Second)
+	switch {
+	case errors.Is(err, helpers.ErrInvalidBypass):
+		response.Error[any](c, http.StatusBadRequest, err.Error(), nil)
+	case err != nil:
+		serverError(c, h.Logger, err, "failed to add rate limit bypass entry")
+	default:
+		h.Logger.WithFields(logrus.Fields{"kind": entry.Kind, "value": entry.Value, "by": entry.AddedBy}).Info("rate limit bypass added")
+		response.Success[any](c, http.StatusCreated, entry, "bypass added", nil)
+	}
+}
+
+// Remove drops the entry ?kind=&value= (for api_key the key itself or its listed hash)
+func (h *RateBypassHandler) Remove(c *gin.Context) {
+	kind, value := c.Query("kind"), c.Query("value")
+	if kind == "" || value == "" {
+		response.Error[any](c, http.StatusBadRequest, "kind and value are required", nil)
+		return
+	}
+	removed, err := h.Bypass.Remove(c.Request.Context(), kind, value)
+	switch {
+	case errors.Is(err, helpers.ErrInvalidBypass):
+		response.Error[any](c, http.StatusBadRequest, err.Error(), nil)
+	case err != nil:
+		serverError(c, h.Logger, err, "failed to remove rate limit bypass entry")
+	case !removed:
+		response.Error[any](c, http.StatusNotFound, "bypass entry not found", nil)
+	default:
+		h.Logger.WithFields(logrus.Fields{"kind": kind, "by": c.GetString("userID")}).Info("rate limit bypass removed")
+		response.Success[any](c, http.StatusOK, map[string]any{"kind": kind}, "bypass removed", nil)
+	}
+}
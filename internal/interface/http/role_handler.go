@@ -0,0 +1,238 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	userapp "github.com/oksasatya/go-ddd-clean-architecture/internal/application"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/response"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/validation"
+)
+
+type RoleHandler struct {
+	Svc    *userapp.RoleService
+	Logger *logrus.Logger
+}
+
+func NewRoleHandler(svc *userapp.RoleService, logger *logrus.Logger) *RoleHandler {
+	return &RoleHandler{Svc: svc, Logger: logger}
+}
+
+type createRoleRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type attachPermissionRequest struct {
+	Permission string `json:"permission" binding:"required"`
+}
+
+type assignRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// roleMembersRequest lists users by id or email
+type roleMembersRequest struct {
+	Members []string `json:"members" binding:"required,min=1,max=1000"`
+}
+
+// syncRoleRequest is the complete member list of the role; an empty list removes everyone
+type syncRoleRequest struct {
+	Members []string `json:"members" binding:"required,max=1000"`
+}
+
+func permissionNames(perms []entity.Permission) []string {
+	out := make([]string, 0, len(perms))
+	for _, p := range perms {
+		out = append(out, p.Name)
+	}
+	return out
+}
+
+func roleNames(roles []entity.Role) []string {
+	out := make([]string, 0, len(roles))
+	for _, r := range roles {
+		out = append(out, r.Name)
+	}
+	return out
+}
+
+// roleError maps role service errors to HTTP responses.
+func (h *RoleHandler) roleError(c *gin.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, userapp.ErrInvalidName), errors.Is(err, userapp.ErrTooManyMembers):
+		response.Error[any](c, http.StatusBadRequest, err.Error(), nil)
+	case errors.Is(err, userapp.ErrRoleNotFound), errors.Is(err, userapp.ErrPermissionNotFound), errors.Is(err, userapp.ErrUserNotFound):
+		response.Error[any](c, http.StatusNotFound, err.Error(), nil)
+	default:
+		serverError(c, h.Logger, err, msg)
+	}
+}
+
+// ListRoles returns all roles with their attached permissions.
+func (h *RoleHandler) ListRoles(c *gin.Context) {
+	roles, err := h.Svc.ListRoles(c.Request.Context())
+	if err != nil {
+		h.roleError(c, err, "failed to list roles")
+		return
+	}
+	out := make([]map[string]any, 0, len(roles))
+	for _, r := range roles {
+		out = append(out, map[string]any{"id": r.ID, "name": r.Name, "permissions": permissionNames(r.Permissions)})
+	}
+	response.Success[any](c, http.StatusOK, out, "ok", nil)
+}
+
+// CreateRole creates a role (idempotent on name).
+func (h *RoleHandler) CreateRole(c *gin.Context) {
+	var req createRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error[any](c, http.StatusBadRequest, "invalid payload", validation.Details(err))
+		return
+	}
+	role, err := h.Svc.CreateRole(c.Request.Context(), req.Name)
+	if err != nil {
+		h.roleError(c, err, "failed to create role")
+		return
+	}
+	response.Success[any](c, http.StatusCreated, map[string]any{"id": role.ID, "name": role.Name}, "role created", nil)
+}
+
+// ListPermissions returns every known permission.
+func (h *RoleHandler) ListPermissions(c *gin.Context) {
+	perms, err := h.Svc.ListPermissions(c.Request.Context())
+	if err != nil {
+		h.roleError(c, err, "failed to list permissions")
+		return
+	}
+	response.Success[any](c, http.StatusOK, permissionNames(perms), "ok", nil)
+}
+
+// AttachPermission grants a permission to the role in the path.
+func (h *RoleHandler) AttachPermission(c *gin.Context) {
+	var req attachPermissionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error[any](c, http.StatusBadRequest, "invalid payload", validation.Details(err))
+		return
+	}
+	p, err := h.Svc.AttachPermission(c.Request.Context(), c.Param("role"), req.Permission)
+	if err != nil {
+		h.roleError(c, err, "failed to attach permission")
+		return
+	}
+	response.Success[any](c, http.StatusOK, map[string]any{"role": c.Param("role"), "permission": p.Name}, "permission attached", nil)
+}
+
+// DetachPermission removes a permission from the role in the path.
+func (h *RoleHandler) DetachPermission(c *gin.Context) {
+	if err := h.Svc.DetachPermission(c.Request.Context(), c.Param("role"), c.Param("permission")); err != nil {
+		h.roleError(c, err, "failed to detach permission")
+		return
+	}
+	response.Success[any](c, http.StatusOK, map[string]any{"role": c.Param("role"), "permission": c.Param("permission")}, "permission detached", nil)
+}
+
+// AssignRole grants a role to the user in the path.
+func (h *RoleHandler) AssignRole(c *gin.Context) {
+	userID := c.Param("id")
+	if _, err := uuid.Parse(userID); err != nil {
+		response.Error[any](c, http.StatusBadRequest, "invalid user id", nil)
+		return
+	}
+	var req assignRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error[any](c, http.StatusBadRequest, "invalid payload", validation.Details(err))
+		return
+	}
+	if err := h.Svc.AssignRole(c.Request.Context(), userID, req.Role); err != nil {
+		h.roleError(c, err, "failed to assign role")
+		return
+	}
+	response.Success[any](c, http.StatusOK, map[string]any{"user_id": userID, "role": req.Role}, "role assigned", nil)
+}
+
+// RevokeRole removes a role from the user in the path.
+func (h *RoleHandler) RevokeRole(c *gin.Context) {
+	userID := c.Param("id")
+	if _, err := uuid.Parse(userID); err != nil {
+		response.Error[any](c, http.StatusBadRequest, "invalid user id", nil)
+		return
+	}
+	if err := h.Svc.RevokeRole(c.Request.Context(), userID, c.Param("role")); err != nil {
+		h.roleError(c, err, "failed to revoke role")
+		return
+	}
+	response.Success[any](c, http.StatusOK, map[string]any{"user_id": userID, "role": c.Param("role")}, "role revoked", nil)
+}
+
+// UserPermissions lists a user's roles and effective permissions.
+func (h *RoleHandler) UserPermissions(c *gin.Context) {
+	userID := c.Param("id")
+	if _, err := uuid.Parse(userID); err != nil {
+		response.Error[any](c, http.StatusBadRequest, "invalid user id", nil)
+		return
+	}
+	eff, err := h.Svc.UserPermissions(c.Request.Context(), userID)
+	if err != nil {
+		h.roleError(c, err, "failed to load permissions")
+		return
+	}
+	response.Success[any](c, http.StatusOK, map[string]any{
+		"user_id":     eff.UserID,
+		"roles":       roleNames(eff.Roles),
+		"permissions": permissionNames(eff.Permissions),
+	}, "ok", nil)
+}
+
+func membershipView(ch userapp.RoleMembershipChange) map[string]any {
+	return map[string]any{"role": ch.Role, "added": ch.Added, "removed": ch.Removed, "unknown": ch.Unknown}
+}
+
+// AssignRoleBulk grants the role in the path to every listed user, or to none when any is unknown
+func (h *RoleHandler) AssignRoleBulk(c *gin.Context) {
+	h.bulkMembers(c, h.Svc.BulkAssignRole, "role assigned")
+}
+
+// RevokeRoleBulk takes the role in the path from every listed user, or from none when any is unknown
+func (h *RoleHandler) RevokeRoleBulk(c *gin.Context) {
+	h.bulkMembers(c, h.Svc.BulkRevokeRole, "role revoked")
+}
+
+func (h *RoleHandler) bulkMembers(c *gin.Context, apply func(context.Context, string, []string) (userapp.RoleMembershipChange, error), msg string) {
+	var req roleMembersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error[any](c, http.StatusBadRequest, "invalid payload", validation.Details(err))
+		return
+	}
+	ch, err := apply(c.Request.Context(), c.Param("role"), req.Members)
+	if errors.Is(err, userapp.ErrUserNotFound) {
+		response.Error[any](c, http.StatusNotFound, "unknown users; nothing changed", map[string]any{"unknown": ch.Unknown})
+		return
+	}
+	if err != nil {
+		h.roleError(c, err, "failed to update role members")
+		return
+	}
+	response.Success[any](c, http.StatusOK, membershipView(ch), msg, nil)
+}
+
+// SyncRole makes the listed users (e.g. an IdP group) exactly the members of the role in the path
+// and reports who was added and removed; unknown users are skipped and listed
+func (h *RoleHandler) SyncRole(c *gin.Context) {
+	var req syncRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error[any](c, http.StatusBadRequest, "invalid payload", validation.Details(err))
+		return
+	}
+	ch, err := h.Svc.SyncRole(c.Request.Context(), c.Param("role"), req.Members)
+	if err != nil {
+		h.roleError(c, err, "failed to sync role members")
+		return
+	}
+	response.Success[any](c, http.StatusOK, membershipView(ch), "role synced", nil)
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/router/route"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/response"
+)
+
+// RoutesHandler lists the mounted routes with their guards (admin)
+type RoutesHandler struct {
+	List func() []route.Info // usually Registry.Routes
+}
+
+func NewRoutesHandler(list func() []route.Info) *RoutesHandler {
+	return &RoutesHandler{List: list}
+}
+
+// Routes returns all routes, optionally filtered by ?module= and ?path= (prefix).
+func (h *RoutesHandler) Routes(c *gin.Context) {
+	module, prefix := c.Query("module"), c.Query("path")
+	out := make([]route.Info, 0)
+	for _, info := range h.List() {
+		if module != "" && !strings.EqualFold(info.Module, module) {
+			continue
+		}
+		if prefix != "" && !strings.HasPrefix(info.Path, prefix) {
+			continue
+		}
+		out = append(out, info)
+	}
+	response.Success[any](c, http.StatusOK, out, "ok", nil)
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	userapp "github.com/oksasatya/go-ddd-clean-architecture/internal/application"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/response"
+)
+
+type SAMLHandler struct {
+	SAML              *userapp.SAMLService
+	Svc               *userapp.Service
+	Roles             *userapp.RoleService
+	Cookies           *helpers.Manager
+	Logger            *logrus.Logger
+	PostLoginRedirect string
+}
+
+func NewSAMLHandler(saml *userapp.SAMLService, svc *userapp.Service, roles *userapp.RoleService, cookies *helpers.Manager, logger *logrus.Logger, postLoginRedirect string) *SAMLHandler {
+	return &SAMLHandler{SAML: saml, Svc: svc, Roles: roles, Cookies: cookies, Logger: logger, PostLoginRedirect: postLoginRedirect}
+}
+
+// Metadata serves the SP metadata document for registration with the IdP.
+func (h *SAMLHandler) Metadata(c *gin.Context) {
+	c.Data(http.StatusOK, "application/samlmetadata+xml", h.SAML.Provider.Metadata())
+}
+
+// Login redirects the browser to the IdP with an AuthnRequest.
+func (h *SAMLHandler) Login(c *gin.Context) {
+	h.start(c, "")
+}
+
+// Link starts the flow that links the IdP NameID to the signed-in user.
+func (h *SAMLHandler) Link(c *gin.Context) {
+	h.start(c, c.GetString("userID"))
+}
+
+func (h *SAMLHandler) start(c *gin.Context, linkUserID string) {
+	u, err := h.SAML.Start(c.Request.Context(), linkUserID)
+	if err != nil {
+		if h.Logger != nil {
+			h.Logger.WithError(err).Warn("saml: start failed")
+		}
+		response.Error[any](c, http.StatusBadGateway, "identity provider unavailable", nil)
+		return
+	}
+	c.Redirect(http.StatusFound, u)
+}
+
+// ACS is the assertion consumer service (HTTP-POST binding).
+func (h *SAMLHandler) ACS(c *gin.Context) {
+	samlResponse := c.PostForm("SAMLResponse")
+	if samlResponse == "" {
+		response.Error[any](c, http.StatusBadRequest, "SAMLResponse is required", nil)
+		return
+	}
+	u, linked, err := h.SAML.ACS(c.Request.Context(), samlResponse, c.PostForm("RelayState"))
+	if err != nil {
+		if identityError(c, userapp.ProviderSAML, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, userapp.ErrSAMLStateInvalid), errors.Is(err, userapp.ErrSAMLReplay):
+			response.Error[any](c, http.StatusBadRequest, err.Error(), nil)
+		case errors.Is(err, userapp.ErrSAMLNoEmail), errors.Is(err, helpers.ErrSAMLNotSuccess):
+			response.Error[any](c, http.StatusForbidden, err.Error(), nil)
+		default:
+			if h.Logger != nil {
+				h.Logger.WithError(err).Warn("saml: acs failed")
+			}
+			response.Error[any](c, http.StatusUnauthorized, "saml login failed", nil)
+		}
+		return
+	}
+	if linked {
+		completeLink(c, h.PostLoginRedirect, userapp.ProviderSAML, u)
+		return
+	}
+	completeExternalLogin(c, h.Svc, h.Roles, h.Cookies, h.PostLoginRedirect, u)
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	userapp "github.com/oksasatya/go-ddd-clean-architecture/internal/application"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/response"
+)
+
+type SearchAdminHandler struct {
+	Index  *userapp.UserIndexService
+	Logger *logrus.Logger
+}
+
+func NewSearchAdminHandler(index *userapp.UserIndexService, logger *logrus.Logger) *SearchAdminHandler {
+	return &SearchAdminHandler{Index: index, Logger: logger}
+}
+
+// Reindex starts a zero-downtime rebuild of the users index (runs in the background).
+func (h *SearchAdminHandler) Reindex(c *gin.Context) {
+	err := h.Index.StartReindex(c.Request.Context())
+	switch {
+	case errors.Is(err, userapp.ErrReindexRunning):
+		response.Error[any](c, http.StatusConflict, "reindex already running", nil)
+	case errors.Is(err, userapp.ErrSearchNotConfigured):
+		response.Error[any](c, http.StatusServiceUnavailable, "search not configured", nil)
+	case err != nil:
+		serverError(c, h.Logger, err, "failed to start reindex")
+	default:
+		response.Success[any](c, http.StatusAccepted, map[string]any{"state": "running"}, "reindex started", nil)
+	}
+}
+
+// ReindexStatus returns the state of the last users reindex.
+func (h *SearchAdminHandler) ReindexStatus(c *gin.Context) {
+	st, err := h.Index.Status(c.Request.Context())
+	if err != nil {
+		serverError(c, h.Logger, err, "failed to load reindex status")
+		return
+	}
+	if st == nil {
+		response.Error[any](c, http.StatusNotFound, "no reindex has run", nil)
+		return
+	}
+	response.Success[any](c, http.StatusOK, st, "ok", nil)
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	userapp "github.com/oksasatya/go-ddd-clean-architecture/internal/application"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/response"
+)
+
+type SessionListHandler struct {
+	Svc    *userapp.SessionListService
+	Logger *logrus.Logger
+}
+
+func NewSessionListHandler(svc *userapp.SessionListService, logger *logrus.Logger) *SessionListHandler {
+	return &SessionListHandler{Svc: svc, Logger: logger}
+}
+
+// List GET /api/admin/sessions?user_ids=<id>,<id>
+// Lists the active sessions of up to 100 users (internal or public IDs, comma separated or
+// repeated) with each session's trusted-device status, read from Redis in a few batched round trips.
+func (h *SessionListHandler) List(c *gin.Context) {
+	var ids []string
+	for _, v := range c.QueryArray("user_ids") {
+		for _, id := range strings.Split(v, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				ids = append(ids, id)
+			}
+		}
+	}
+	if len(ids) == 0 {
+		response.Error[any](c, http.StatusBadRequest, "user_ids is required", nil)
+		return
+	}
+	list, err := h.Svc.List(c.Request.Context(), ids)
+	if errors.Is(err, userapp.ErrSessionListTooMany) {
+		response.Error[any](c, http.StatusBadRequest, "too many user_ids", gin.H{"max": userapp.SessionListMaxUsers})
+		return
+	}
+	if err != nil {
+		serverError(c, h.Logger, err, "failed to list sessions")
+		return
+	}
+	out := make([]gin.H, 0, len(list))
+	for _, us := range list {
+		items := make([]map[string]any, 0, len(us.Sessions))
+		for i, sess := range us.Sessions {
+			items = append(items, sessionItem(sess, "", us.Trusted[i]))
+		}
+		out = append(out, gin.H{"user_id": us.UserID, "sessions": items})
+	}
+	response.Success[any](c, http.StatusOK, out, "ok", nil)
+}
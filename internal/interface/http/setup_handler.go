@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	userapp "github.com/oksasatya/go-ddd-clean-architecture/internal/application"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/response"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/validation"
+)
+
+// SetupHandler serves the first-run admin bootstrap
+type SetupHandler struct {
+	Svc    *userapp.SetupService
+	Audit  *userapp.AuditService // optional
+	Logger *logrus.Logger
+}
+
+func NewSetupHandler(svc *userapp.SetupService, audit *userapp.AuditService, logger *logrus.Logger) *SetupHandler {
+	return &SetupHandler{Svc: svc, Audit: audit, Logger: logger}
+}
+
+type setupAdminRequest struct {
+	SetupToken string `json:"setup_token"` // or the X-Setup-Token header
+	Email      string `json:"email" binding:"required,email"`
+	Name       string `json:"name"`
+	Password   string `json:"password" binding:"required,pwd"`
+}
+
+// Status GET /api/setup/admin reports whether the initial admin still has to be created.
+func (h *SetupHandler) Status(c *gin.Context) {
+	required, err := h.Svc.Required(c.Request.Context())
+	if err != nil {
+		serverError(c, h.Logger, err, "failed to check setup status")
+		return
+	}
+	response.Success[any](c, http.StatusOK, map[string]any{"required": required}, "ok", nil)
+}
+
+// CreateAdmin POST /api/setup/admin creates the initial admin with the one-time setup token.
+func (h *SetupHandler) CreateAdmin(c *gin.Context) {
+	var req setupAdminRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error[any](c, http.StatusBadRequest, "invalid payload", validation.Details(err))
+		return
+	}
+	if req.SetupToken == "" {
+		req.SetupToken = c.GetHeader("X-Setup-Token")
+	}
+	u, err := h.Svc.CreateAdmin(c.Request.Context(), req.SetupToken, req.Email, req.Name, req.Password)
+	if err != nil {
+		switch {
+		case passwordRejected(c, "password", err):
+		case errors.Is(err, userapp.ErrSetupCompleted):
+			response.Error[any](c, http.StatusGone, err.Error(), nil)
+		case errors.Is(err, userapp.ErrSetupTokenInvalid):
+			h.Logger.WithField("ip", clientIP(c)).Warn("admin setup attempted with an invalid token")
+			response.Error[any](c, http.StatusUnauthorized, err.Error(), nil)
+		case errors.Is(err, userapp.ErrSetupInProgress), errors.Is(err, userapp.ErrSetupEmailTaken):
+			response.Error[any](c, http.StatusConflict, err.Error(), nil)
+		default:
+			serverError(c, h.Logger, err, "failed to create admin")
+		}
+		return
+	}
+	if h.Audit != nil {
+		if err := h.Audit.Record(c.Request.Context(), entity.AuditLog{
+			UserID:    u.ID,
+			Email:     u.Email,
+			Action:    "setup_admin_created",
+			IP:        clientIP(c),
+			UserAgent: c.GetHeader("User-Agent"),
+		}); err != nil {
+			h.Logger.WithError(err).Warn("audit log not recorded")
+		}
+	}
+	response.Success[any](c, http.StatusCreated, map[string]any{"user_id": u.ID, "email": u.Email, "name": u.Name}, "admin created", nil)
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/httpx"
+)
+
+// StatusHandler serves the public status page data from the background dependency probes
+type StatusHandler struct {
+	Deps *helpers.DependencyMonitor
+}
+
+func NewStatusHandler(deps *helpers.DependencyMonitor) *StatusHandler {
+	return &StatusHandler{Deps: deps}
+}
+
+// statusPage is the body of GET /api/status
+type statusPage struct {
+	Status       string                     `json:"status"` // ok or degraded
+	Dependencies []helpers.DependencyStatus `json:"dependencies"`
+	GeneratedAt  time.Time                  `json:"generated_at"`
+}
+
+// Get GET /api/status (public)
+// Up/down and p50/p95 probe latency per dependency, as of the last background check; no
+// dependency is contacted on the request. status is "degraded" while any of them is not up.
+func (h *StatusHandler) Get(r *httpx.Request) httpx.Response {
+	deps := h.Deps.Status()
+	status := "ok"
+	for _, d := range deps {
+		if d.Status != "up" {
+			status = "degraded"
+			break
+		}
+	}
+	body := statusPage{Status: status, Dependencies: deps, GeneratedAt: time.Now().UTC()}
+	return httpx.JSON(http.StatusOK, body).WithHeader("Cache-Control", "public, max-age=5")
+}
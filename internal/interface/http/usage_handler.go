@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	userapp "github.com/oksasatya/go-ddd-clean-architecture/internal/application"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/response"
+)
+
+const (
+	usageDefaultLimit = 100
+	usageMaxLimit     = 1000
+)
+
+type UsageHandler struct {
+	Svc    *userapp.UsageService
+	Logger *logrus.Logger
+}
+
+func NewUsageHandler(svc *userapp.UsageService, logger *logrus.Logger) *UsageHandler {
+	return &UsageHandler{Svc: svc, Logger: logger}
+}
+
+// parseUsageTime accepts RFC3339 or a plain date (YYYY-MM-DD, UTC midnight)
+func parseUsageTime(v string, def time.Time) (time.Time, error) {
+	if v == "" {
+		return def, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, v)
+}
+
+// Report returns rolled-up usage for billing and capacity planning (admin).
+// Query: from, to (default last 24h), subject (user:<id>, key:<fingerprint>, ...), group_by (subject|route), limit.
+func (h *UsageHandler) Report(c *gin.Context) {
+	now := time.Now().UTC()
+	to, err := parseUsageTime(c.Query("to"), now)
+	if err != nil {
+		response.Error[any](c, http.StatusBadRequest, "invalid to", nil)
+		return
+	}
+	from, err := parseUsageTime(c.Query("from"), to.Add(-24*time.Hour))
+	if err != nil {
+		response.Error[any](c, http.StatusBadRequest, "invalid from", nil)
+		return
+	}
+	limit := usageDefaultLimit
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			response.Error[any](c, http.StatusBadRequest, "invalid limit", nil)
+			return
+		}
+		limit = min(n, usageMaxLimit)
+	}
+	groupBy := c.DefaultQuery("group_by", userapp.UsageGroupSubject)
+	rows, err := h.Svc.Report(c.Request.Context(), from, to, c.Query("subject"), groupBy, limit)
+	if err != nil {
+		if errors.Is(err, userapp.ErrUsageInvalidQuery) {
+			response.Error[any](c, http.StatusBadRequest, "invalid usage query", nil)
+			return
+		}
+		serverError(c, h.Logger, err, "failed to load usage")
+		return
+	}
+	items := make([]map[string]any, 0, len(rows))
+	for _, r := range rows {
+		item := map[string]any{"requests": r.Requests, "bytes_in": r.BytesIn, "bytes_out": r.BytesOut}
+		if groupBy == userapp.UsageGroupRoute {
+			item["method"], item["route"] = r.Method, r.Route
+		} else {
+			item["subject"] = r.Subject
+		}
+		items = append(items, item)
+	}
+	response.Success[any](c, http.StatusOK, map[string]any{"from": from, "to": to, "group_by": groupBy, "items": items}, "ok", nil)
+}
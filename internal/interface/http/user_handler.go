@@ -7,6 +7,7 @@ import (
 	"errors"
 	"net/http"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -18,6 +19,7 @@ import (
 	"github.com/sirupsen/logrus"
 
 	userapp "github.com/oksasatya/go-ddd-clean-architecture/internal/application"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
 	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
 	"github.com/oksasatya/go-ddd-clean-architecture/pkg/mailer"
 	"github.com/oksasatya/go-ddd-clean-architecture/pkg/response"
@@ -39,26 +41,69 @@ type UserHandler struct {
 	Cfg     *config.Config
 	RDB     *redis.Client
 	DB      *pgxpool.Pool
+	Geo     tpl.GeoResolver
+	Prefs   *userapp.NotificationPreferenceService
+	Anomaly *userapp.LoginAnomalyService
+	Revokes *userapp.SessionRevokeService // "this wasn't me" links in security emails
+	// BackupCodes lets LoginOTPConfirm accept a recovery code instead of the OTP; set after construction
+	BackupCodes *userapp.BackupCodeService
+	// ProfileChanges holds email changes in PUT /api/profile for confirmation; nil rejects them
+	ProfileChanges *ProfileChangeHandler
+	// Audit records successful logins with the flow's correlation id; set after construction
+	Audit *userapp.AuditService
+	// Metrics counts login outcomes by country/ASN; nil = off
+	Metrics *helpers.AuthMetrics
+	// Sessions flags trusted devices in GET /api/sessions; nil reports none. Set after construction
+	Sessions *userapp.SessionListService
 }
 
-func NewUserHandler(svc *userapp.Service, jwt *helpers.JWTManager, logger *logrus.Logger, cookieDomain string, cookieSecure bool, pub *helpers.RabbitPublisher, cfg *config.Config, rdb *redis.Client, db *pgxpool.Pool) *UserHandler {
-	return &UserHandler{Svc: svc, JWT: jwt, Logger: logger, Cookies: helpers.NewCookie(cookieDomain, cookieSecure), Pub: pub, Cfg: cfg, RDB: rdb, DB: db}
+func NewUserHandler(svc *userapp.Service, jwt *helpers.JWTManager, logger *logrus.Logger, cookies *helpers.Manager, pub *helpers.RabbitPublisher, cfg *config.Config, rdb *redis.Client, db *pgxpool.Pool, geo tpl.GeoResolver, prefs *userapp.NotificationPreferenceService, anomaly *userapp.LoginAnomalyService) *UserHandler {
+	return &UserHandler{Svc: svc, JWT: jwt, Logger: logger, Cookies: cookies, Pub: pub, Cfg: cfg, RDB: rdb, DB: db, Geo: geo, Prefs: prefs, Anomaly: anomaly}
 }
 
 type loginRequest struct {
 	Name     string `json:"name"`
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required,pwd"`
+	DeviceID string `json:"device_id"` // token-in-body mode: trusted device id returned by OTP confirm
 }
 
 type updateProfileRequest struct {
 	Name      string `json:"name"`
 	AvatarURL string `json:"avatar_url"`
+	Email     string `json:"email" binding:"omitempty,email"` // pending until confirmed (PROFILE_CHANGE_APPROVAL)
 }
 
-// setTokenCookies centralizes auth cookie setting to avoid duplication
-func (h *UserHandler) setTokenCookies(c *gin.Context, pair userapp.TokenPair) {
+// tokensInBody reports whether tokens travel in JSON bodies instead of cookies (AUTH_TOKEN_IN_BODY)
+func (h *UserHandler) tokensInBody() bool { return h.Cfg != nil && h.Cfg.AuthTokenInBody }
+
+// deliverTokens sets the auth and device cookies, or in token-in-body mode adds them to payload
+// (the device_id must then be sent with the refresh token)
+func (h *UserHandler) deliverTokens(c *gin.Context, pair userapp.TokenPair, payload map[string]any) {
+	if h.tokensInBody() {
+		payload["token_type"] = "Bearer"
+		payload["access_token"] = pair.AccessToken
+		payload["refresh_token"] = pair.RefreshToken
+		if pair.DeviceID != "" {
+			payload["device_id"] = pair.DeviceID
+		}
+		return
+	}
 	h.Cookies.SetPair(c, pair.AccessToken, pair.AccessTokenExpiry, pair.RefreshToken, pair.RefreshTokenExpiry)
+	setDeviceCookie(c, h.Cookies, pair)
+}
+
+// setDeviceCookie keeps the device_id cookie alive for at least as long as the refresh token
+// and the 30-day trusted-device window
+func setDeviceCookie(c *gin.Context, cookies *helpers.Manager, pair userapp.TokenPair) {
+	if pair.DeviceID == "" {
+		return
+	}
+	exp := time.Now().Add(30 * 24 * time.Hour)
+	if pair.RefreshTokenExpiry.After(exp) {
+		exp = pair.RefreshTokenExpiry
+	}
+	cookies.SetDeviceID(c, pair.DeviceID, exp)
 }
 
 func (h *UserHandler) isAdmin(ctx context.Context, userID string) (bool, error) {
@@ -85,28 +130,54 @@ func (h *UserHandler) isAdmin(ctx context.Context, userID string) (bool, error)
 	return false, nil
 }
 
+// throttleDetails is the per-account attempt state middleware.AccountGuard left for this request
+// (attempts_remaining, retry_after_seconds), for failure responses; nil on unguarded routes.
+func throttleDetails(c *gin.Context) any {
+	if v, ok := c.Get("throttle"); ok {
+		return v
+	}
+	return nil
+}
+
+// accountBlocked answers 403 when err is a suspension or ban; it reports false for other errors.
+func accountBlocked(c *gin.Context, err error) bool {
+	if !errors.Is(err, entity.ErrAccountSuspended) && !errors.Is(err, entity.ErrAccountBanned) {
+		return false
+	}
+	response.Error[any](c, http.StatusForbidden, err.Error(), nil)
+	return true
+}
+
 func (h *UserHandler) Login(c *gin.Context) {
 	var req loginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.Error[any](c, http.StatusBadRequest, "invalid payload", validation.ToDetails(err))
+		response.Error[any](c, http.StatusBadRequest, "invalid payload", validation.Details(err))
 		return
 	}
+	h.startLoginFlow(c, "")
 
 	u, err := h.Svc.Authenticate(c.Request.Context(), req.Email, req.Password)
+	if errors.Is(err, userapp.ErrEmailNotVerified) {
+		h.requireVerification(c, u)
+		return
+	}
+	if accountBlocked(c, err) {
+		return
+	}
 	if err != nil {
-		status := http.StatusUnauthorized
-		msg := "invalid credentials"
 		if !errors.Is(err, userapp.ErrInvalidCredentials) {
-			status = http.StatusInternalServerError
-			msg = "login failed"
+			serverError(c, h.Logger, err, "login failed")
+			return
 		}
-		response.Error[any](c, status, msg, nil)
+		h.loginStep(c, "", "password_rejected", nil)
+		h.Metrics.Observe(clientIP(c), helpers.AuthBadPassword)
+		response.Error[any](c, http.StatusUnauthorized, "invalid credentials", throttleDetails(c))
 		return
 	}
 
 	// Only admins may proceed
 	if ok, aerr := h.isAdmin(c.Request.Context(), u.ID); aerr != nil {
-		response.Error[any](c, http.StatusInternalServerError, "login unavailable", nil)
+		serverError(c, h.Logger, aerr, "login unavailable")
 		return
 	} else if !ok {
 		response.Error[any](c, http.StatusForbidden, "forbidden", nil)
@@ -114,26 +185,43 @@ func (h *UserHandler) Login(c *gin.Context) {
 	}
 
 	// Check trusted device (30 days)
-	deviceID, _ := c.Cookie("device_id")
+	deviceID := req.DeviceID
+	if !h.tokensInBody() {
+		deviceID, _ = h.Cookies.Get(c, helpers.CookieDeviceID)
+	}
 	trusted := false
 	if deviceID != "" && h.RDB != nil {
 		if v, _ := h.RDB.Get(c, helpers.KeyTrustedDevice(u.ID, deviceID)).Result(); v == "1" {
 			trusted = true
 		}
 	}
+	// A login from a new country or network needs the OTP even on a trusted device
+	ip := clientIP(c)
+	assessment := h.Anomaly.Assess(c.Request.Context(), u.ID, ip)
+	if assessment.Suspicious() {
+		trusted = false
+	}
 
 	if trusted {
-		pair, ierr := h.Svc.IssueTokens(c.Request.Context(), u)
+		pair, ierr := h.Svc.IssueTokens(h.sessionContext(c, deviceID, assessment.Geo), u)
+		if accountBlocked(c, ierr) {
+			return
+		}
 		if ierr != nil {
-			response.Error[any](c, http.StatusInternalServerError, "login failed", nil)
+			serverError(c, h.Logger, ierr, "login failed")
 			return
 		}
-		h.setTokenCookies(c, pair)
+		h.Anomaly.Record(c.Request.Context(), u.ID, assessment)
+		h.loginStep(c, u.ID, "tokens_issued", map[string]any{"method": "trusted_device", "location": tpl.FormatGeo(assessment.Geo)})
+		h.auditLogin(c, u, "trusted_device", assessment.Geo)
+		h.Metrics.Observe(ip, helpers.AuthSuccess)
 		payload := map[string]any{
 			"user_id": u.ID,
 			"email":   u.Email,
 			"name":    u.Name,
 		}
+		h.deliverTokens(c, pair, payload)
+		h.flagUnverified(payload, u)
 		response.Success(c, http.StatusOK, payload, "login successful", map[string]any{"access_expires_at": pair.AccessTokenExpiry, "refresh_expires_at": pair.RefreshTokenExpiry})
 		return
 	}
@@ -143,19 +231,96 @@ func (h *UserHandler) Login(c *gin.Context) {
 		response.Error[any](c, http.StatusServiceUnavailable, "otp unavailable", nil)
 		return
 	}
-	code, err := helpers.GenOTPCode()
+	delivery, err := h.sendLoginCode(c, u, assessment)
 	if err != nil {
 		response.Error[any](c, http.StatusInternalServerError, "otp generation failed", nil)
 		return
 	}
-	_ = h.RDB.Set(c, helpers.KeyLoginOTP(u.ID), code, 10*time.Minute).Err()
+	if h.BackupCodes != nil {
+		_ = h.RDB.Set(c, helpers.KeyLoginPasswordOK(u.ID), "1", 10*time.Minute).Err()
+	}
+
+	payload := map[string]any{
+		"requires_otp": true,
+	}
+	delivery.addTo(payload)
+	if assessment.Suspicious() {
+		payload["new_location"] = true
+	}
+	h.flagUnverified(payload, u)
+	response.Success[any](c, http.StatusAccepted, payload, "otp required", nil)
+}
 
-	ip := c.GetString("real_ip")
-	if ip == "" {
-		ip = c.ClientIP()
+// ListSessions - GET /api/sessions: the caller's active sessions with the client each was issued to
+// and whether its device is still trusted for logins without an OTP. The trusted-device id is a
+// credential, so only a short fingerprint of it is returned.
+func (h *UserHandler) ListSessions(c *gin.Context) {
+	sessions, err := h.Svc.ListSessions(c.Request.Context(), c.GetString("userID"))
+	if err != nil {
+		serverError(c, h.Logger, err, "failed to list sessions")
+		return
+	}
+	trusted, err := h.Sessions.Trusted(c.Request.Context(), sessions)
+	if err != nil {
+		// the listing stays useful without the flag; report every device untrusted
+		h.Logger.WithError(err).Warn("trusted device check failed")
+		trusted = make([]bool, len(sessions))
 	}
-	ua := c.GetHeader("User-Agent")
-	resolver := tpl.IPAPIResolver{}
+	current := c.GetString("sessionID")
+	out := make([]map[string]any, 0, len(sessions))
+	for i, sess := range sessions {
+		out = append(out, sessionItem(sess, current, trusted[i]))
+	}
+	response.Success[any](c, http.StatusOK, out, "ok", nil)
+}
+
+// sessionItem is one session of a listing; current is the caller's session id, "" for none
+func sessionItem(sess entity.Session, current string, trusted bool) map[string]any {
+	item := map[string]any{
+		"id":             sess.ID,
+		"current":        current != "" && sess.ID == current,
+		"ip":             sess.IP,
+		"user_agent":     sess.UserAgent,
+		"location":       sess.Location,
+		"device":         "",
+		"trusted_device": trusted,
+		"created_at":     sess.CreatedAt,
+	}
+	if sess.DeviceID != "" {
+		item["device"] = hashToken(sess.DeviceID)[:12]
+	}
+	if !sess.UpdatedAt.IsZero() {
+		item["last_active_at"] = sess.UpdatedAt
+	}
+	if !sess.ExpiresAt.IsZero() {
+		item["expires_at"] = sess.ExpiresAt
+	}
+	return item
+}
+
+// sessionContext carries the login's client into IssueTokens so the new session records it
+func (h *UserHandler) sessionContext(c *gin.Context, deviceID string, g tpl.Geo) context.Context {
+	return userapp.WithSessionClient(c.Request.Context(), userapp.SessionClient{
+		IP:        clientIP(c),
+		UserAgent: c.GetHeader("User-Agent"),
+		Location:  tpl.FormatGeo(g),
+		DeviceID:  deviceID,
+	})
+}
+
+// sendLoginCode stores a fresh 6-digit login code for 10 minutes and emails it; the code is
+// confirmed through LoginOTPConfirm. A failed publish is reported in the delivery, not as an error.
+func (h *UserHandler) sendLoginCode(c *gin.Context, u *entity.User, assessment userapp.LoginAssessment) (loginCodeDelivery, error) {
+	code, err := helpers.GenOTPCode()
+	if err != nil {
+		return loginCodeDelivery{}, err
+	}
+	if err := h.RDB.Set(c, helpers.KeyLoginOTP(u.ID), code, 10*time.Minute).Err(); err != nil {
+		return loginCodeDelivery{}, err
+	}
+	h.Anomaly.Remember(c.Request.Context(), u.ID, assessment)
+	h.holdLoginFlow(c, u)
+
 	data := tpl.NewLoginOTPData(
 		h.Cfg,
 		u.Name,
@@ -163,25 +328,168 @@ func (h *UserHandler) Login(c *gin.Context) {
 		code,
 		tpl.WithTime(time.Now()),
 		tpl.WithExpiresIn(10*time.Minute),
-		tpl.WithIP(ip),
-		tpl.WithUserAgent(ua),
-		tpl.WithGeoFromIP(c.Request.Context(), resolver, ip),
+		tpl.WithIP(assessment.IP),
+		tpl.WithUserAgent(c.GetHeader("User-Agent")),
+		h.geoOption(c, assessment),
 	)
-	job := mailer.EmailJob{To: u.Email, Template: "universal", Data: data}
-	if h.Cfg != nil && h.Cfg.MailSendEnabled && h.Pub != nil {
-		go func(job mailer.EmailJob) {
-			ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-			defer cancel()
-			_ = h.Pub.PublishJSON(ctx, job)
-		}(job)
+	if h.Cfg == nil || !h.Cfg.MailSendEnabled || h.Pub == nil {
+		h.loginStep(c, u.ID, "otp_sent", map[string]any{"delivery_state": helpers.DeliveryDisabled})
+		return loginCodeDelivery{State: helpers.DeliveryDisabled}, nil
+	}
+	job := mailer.EmailJob{To: u.Email, Locale: emailLocale(c), Template: "universal", Data: tpl.ToMap(data), Envelope: mailer.Envelope{Variables: emailVariables(c, u.ID)}, JobMeta: emailJobMeta(c, u.ID)}
+	d := loginCodeDelivery{ID: uuid.NewString(), State: helpers.DeliveryQueued}
+	// Recorded before publishing so the worker's outcome is always the later event
+	if err := helpers.RecordEmailDelivery(c.Request.Context(), h.RDB, d.ID, d.State); err != nil {
+		// Not pollable, but the code itself may still arrive
+		d.ID = ""
+	}
+	job.DeliveryID = d.ID
+	// Published inline so the client learns whether the code is on its way
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	defer cancel()
+	if err := h.Pub.PublishEmail(ctx, job); err != nil {
+		d.State = helpers.DeliveryNotQueued
+		if h.Logger != nil {
+			h.Logger.WithError(err).WithField("user_id", u.ID).Warn("enqueue login otp email failed")
+		}
+		_ = helpers.RecordEmailDelivery(c.Request.Context(), h.RDB, d.ID, d.State)
 	}
+	h.loginStep(c, u.ID, "otp_sent", map[string]any{"delivery_state": d.State, "delivery_id": d.ID, "new_location": assessment.Suspicious()})
+	return d, nil
+}
 
-	response.Success[any](c, http.StatusAccepted, map[string]any{
-		"requires_otp": true,
-	}, "otp required", nil)
+// loginCodeDelivery is the outcome of publishing a login code email; ID is empty when it cannot be polled
+type loginCodeDelivery struct {
+	ID    string
+	State string
+}
+
+// addTo reports the delivery in a login payload
+func (d loginCodeDelivery) addTo(payload map[string]any) {
+	payload["delivery_state"] = d.State
+	if d.ID != "" {
+		payload["delivery_id"] = d.ID
+	}
 }
 
-// LoginOTPConfirm - POST /api/login/otp/confirm {email, code, remember_device}
+// LoginOTPStatus - GET /api/login/otp/status/:id: the latest delivery state of a login code email
+// with its event history (queued or not_queued, then the worker's sent, failed (retrying), invalid or
+// rate_limited). Delivery ids are unguessable and expire after an hour.
+func (h *UserHandler) LoginOTPStatus(c *gin.Context) {
+	d, err := helpers.GetEmailDelivery(c.Request.Context(), h.RDB, c.Param("id"))
+	if errors.Is(err, helpers.ErrDeliveryNotFound) {
+		response.Error[any](c, http.StatusNotFound, "delivery not found", nil)
+		return
+	}
+	if err != nil {
+		response.Error[any](c, http.StatusServiceUnavailable, "delivery status unavailable", nil)
+		return
+	}
+	response.Success[any](c, http.StatusOK, d, "ok", nil)
+}
+
+// LoginCode - POST /api/login/code {email}: passwordless login (LOGIN_CODE_ENABLED). Emails a 6-digit
+// code to a registered account, confirmed via POST /api/login/otp/confirm. The answer is the same
+// whether or not the account exists.
+func (h *UserHandler) LoginCode(c *gin.Context) {
+	var req struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error[any](c, http.StatusBadRequest, "invalid payload", validation.Details(err))
+		return
+	}
+	if h.RDB == nil || h.Pub == nil {
+		response.Error[any](c, http.StatusServiceUnavailable, "otp unavailable", nil)
+		return
+	}
+	h.startLoginFlow(c, "")
+	sent := map[string]any{"requires_otp": true}
+	u, err := h.Svc.GetUserByEmail(c.Request.Context(), req.Email)
+	if err != nil || u == nil {
+		response.Success[any](c, http.StatusAccepted, sent, "code sent if the account exists", nil)
+		return
+	}
+	// Same policy as password login: only admins may proceed (checked again on confirm)
+	if ok, aerr := h.isAdmin(c.Request.Context(), u.ID); aerr != nil {
+		serverError(c, h.Logger, aerr, "login unavailable")
+		return
+	} else if !ok {
+		response.Success[any](c, http.StatusAccepted, sent, "code sent if the account exists", nil)
+		return
+	}
+	assessment := h.Anomaly.Assess(c.Request.Context(), u.ID, clientIP(c))
+	// The delivery state is not returned here: it would tell registered accounts apart
+	if _, err := h.sendLoginCode(c, u, assessment); err != nil {
+		response.Error[any](c, http.StatusInternalServerError, "otp generation failed", nil)
+		return
+	}
+	response.Success[any](c, http.StatusAccepted, sent, "code sent if the account exists", nil)
+}
+
+// geoOption reuses the location resolved for the anomaly check instead of looking the IP up again
+func (h *UserHandler) geoOption(c *gin.Context, a userapp.LoginAssessment) tpl.Option {
+	if tpl.FormatGeo(a.Geo) != "" {
+		return tpl.WithGeo(a.Geo)
+	}
+	return tpl.WithGeoFromIP(c.Request.Context(), h.Geo, a.IP)
+}
+
+// sendSuspiciousLogin sends the "was this you?" email for a login from a new location, with a
+// one-click link (SESSION_REVOKE_URL?token=...) that ends the sessions of the device that logged in.
+func (h *UserHandler) sendSuspiciousLogin(c *gin.Context, u *entity.User, a userapp.LoginAssessment, deviceID string) {
+	if h.Pub == nil || h.Cfg == nil || !h.Cfg.MailSendEnabled {
+		return
+	}
+	opts := []tpl.Option{
+		tpl.WithTime(time.Now()),
+		tpl.WithIP(a.IP),
+		tpl.WithUserAgent(c.GetHeader("User-Agent")),
+		h.geoOption(c, a),
+	}
+	if link := h.Revokes.Link(c.Request.Context(), u.ID, deviceID, userapp.RevokeFromSuspiciousLogin); link != "" {
+		opts = append(opts, tpl.WithRevokeURL(link))
+	}
+	job := mailer.EmailJob{To: u.Email, Locale: emailLocale(c), Template: "universal", Data: tpl.ToMap(tpl.NewSuspiciousLoginData(h.Cfg, u.Name, u.Email, opts...)), Envelope: mailer.Envelope{Variables: emailVariables(c, u.ID)}, JobMeta: emailJobMeta(c, u.ID)}
+	go func(job mailer.EmailJob) {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		if err := h.Pub.PublishEmail(ctx, job); err != nil && h.Logger != nil {
+			h.Logger.WithError(err).WithField("user_id", u.ID).Warn("enqueue suspicious login email failed")
+		}
+	}(job)
+}
+
+// flagUnverified marks the login payload under the warn verification policy.
+func (h *UserHandler) flagUnverified(payload map[string]any, u *entity.User) {
+	if h.Svc.VerifyPolicy == userapp.VerifyPolicyWarn && !u.IsVerified {
+		payload["email_verified"] = false
+	}
+}
+
+// requireVerification answers a login blocked by the verification policy. The one-time resend
+// token lets the client request a new verification email without a session.
+func (h *UserHandler) requireVerification(c *gin.Context, u *entity.User) {
+	data := map[string]any{"requires_verification": true}
+	if h.RDB != nil {
+		buf := make([]byte, 32)
+		if _, err := rand.Read(buf); err == nil {
+			tok := base64.RawURLEncoding.EncodeToString(buf)
+			if err := h.RDB.Set(c, helpers.KeyVerifyResend(tok), u.ID, 15*time.Minute).Err(); err == nil {
+				data["resend"] = map[string]any{
+					"method":     http.MethodPost,
+					"path":       "/api/auth/verify/resend",
+					"token":      tok,
+					"expires_in": int((15 * time.Minute).Seconds()),
+				}
+			}
+		}
+	}
+	response.Error[any](c, http.StatusForbidden, "email not verified", data)
+}
+
+// LoginOTPConfirm - POST /api/login/otp/confirm {email, code, remember_device}. code is the emailed
+// 6-digit OTP or, when backup codes are enabled, one of the user's recovery codes (xxxxx-xxxxx).
 func (h *UserHandler) LoginOTPConfirm(c *gin.Context) {
 	var req struct {
 		Email          string `json:"email" binding:"required,email"`
@@ -189,94 +497,216 @@ func (h *UserHandler) LoginOTPConfirm(c *gin.Context) {
 		RememberDevice bool   `json:"remember_device"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.Error[any](c, http.StatusBadRequest, "invalid payload", validation.ToDetails(err))
+		response.Error[any](c, http.StatusBadRequest, "invalid payload", validation.Details(err))
 		return
 	}
 	if h.RDB == nil {
 		response.Error[any](c, http.StatusServiceUnavailable, "otp unavailable", nil)
 		return
 	}
-	// Normalize and validate OTP format (6 digits)
+	// Normalize and validate OTP format (6 digits); anything else may be a backup code
 	req.Code = strings.TrimSpace(req.Code)
-	if ok, _ := regexp.MatchString(`^[0-9]{6}$`, req.Code); !ok {
-		response.Error[any](c, http.StatusUnauthorized, "invalid or expired code", nil)
+	isOTP, _ := regexp.MatchString(`^[0-9]{6}$`, req.Code)
+	if !isOTP && h.BackupCodes == nil {
+		response.Error[any](c, http.StatusUnauthorized, "invalid or expired code", throttleDetails(c))
 		return
 	}
 
 	u, err := h.Svc.GetUserByEmail(c.Request.Context(), req.Email)
 	if err != nil || u == nil {
-		response.Error[any](c, http.StatusUnauthorized, "invalid code", nil)
+		response.Error[any](c, http.StatusUnauthorized, "invalid code", throttleDetails(c))
+		return
+	}
+	h.resumeLoginFlow(c, u)
+	if accountBlocked(c, u.AccountError()) {
 		return
 	}
 
 	// Only admins may proceed
 	if ok, aerr := h.isAdmin(c.Request.Context(), u.ID); aerr != nil {
-		response.Error[any](c, http.StatusInternalServerError, "login unavailable", nil)
+		serverError(c, h.Logger, aerr, "login unavailable")
 		return
 	} else if !ok {
 		response.Error[any](c, http.StatusForbidden, "forbidden", nil)
 		return
 	}
 
-	stored, err := h.RDB.Get(c, helpers.KeyLoginOTP(u.ID)).Result()
-	if err != nil || stored == "" {
-		response.Error[any](c, http.StatusUnauthorized, "invalid or expired code", nil)
-		return
+	meta := map[string]any{}
+	method := "otp"
+	if isOTP {
+		stored, err := h.RDB.Get(c, helpers.KeyLoginOTP(u.ID)).Result()
+		if err != nil || stored == "" {
+			response.Error[any](c, http.StatusUnauthorized, "invalid or expired code", throttleDetails(c))
+			return
+		}
+		if stored != req.Code {
+			h.holdLoginFlow(c, u) // the flow goes on until the code expires
+			h.loginStep(c, u.ID, "otp_rejected", nil)
+			h.Metrics.Observe(clientIP(c), helpers.AuthOTPFailed)
+			response.Error[any](c, http.StatusUnauthorized, "invalid or expired code", throttleDetails(c))
+			return
+		}
+	} else {
+		// Only after a password login: a passwordless code request must not make a backup code enough
+		if v, _ := h.RDB.Get(c, helpers.KeyLoginPasswordOK(u.ID)).Result(); v != "1" {
+			response.Error[any](c, http.StatusUnauthorized, "invalid or expired code", throttleDetails(c))
+			return
+		}
+		st, err := h.BackupCodes.Redeem(c.Request.Context(), u.ID, req.Code)
+		if errors.Is(err, userapp.ErrBackupCodeInvalid) {
+			h.holdLoginFlow(c, u)
+			h.loginStep(c, u.ID, "otp_rejected", map[string]any{"method": "backup_code"})
+			h.Metrics.Observe(clientIP(c), helpers.AuthOTPFailed)
+			response.Error[any](c, http.StatusUnauthorized, "invalid or expired code", throttleDetails(c))
+			return
+		}
+		if err != nil {
+			serverError(c, h.Logger, err, "login unavailable")
+			return
+		}
+		method = "backup_code"
+		meta["backup_codes_remaining"] = st.Remaining
+		if st.Low {
+			meta["backup_codes_low"] = true
+		}
 	}
-	if stored != req.Code {
-		response.Error[any](c, http.StatusUnauthorized, "invalid or expired code", nil)
+	// Consume OTP (a backup code login also ends the pending one)
+	_ = h.RDB.Del(c, helpers.KeyLoginOTP(u.ID), helpers.KeyLoginPasswordOK(u.ID)).Err()
+	h.loginStep(c, u.ID, "otp_confirmed", map[string]any{"method": method})
+
+	// Keep the browser's device id; IssueTokens mints one when there is none
+	deviceID := ""
+	if !h.tokensInBody() {
+		deviceID, _ = h.Cookies.Get(c, helpers.CookieDeviceID)
+	}
+	a, assessed := h.Anomaly.Pending(c.Request.Context(), u.ID)
+	pair, err := h.Svc.IssueTokens(h.sessionContext(c, deviceID, a.Geo), u)
+	if accountBlocked(c, err) {
 		return
 	}
-	// Consume OTP
-	_ = h.RDB.Del(c, helpers.KeyLoginOTP(u.ID)).Err()
-
-	pair, err := h.Svc.IssueTokens(c.Request.Context(), u)
 	if err != nil {
-		response.Error[any](c, http.StatusInternalServerError, "login failed", nil)
+		serverError(c, h.Logger, err, "login failed")
 		return
 	}
-
-	// Remember device if requested
-	if req.RememberDevice {
-		// generate a device id and set trusted for 30 days
-		buf := make([]byte, 32)
-		if _, err := rand.Read(buf); err == nil {
-			devID := base64.RawURLEncoding.EncodeToString(buf)
-			exp := time.Now().Add(30 * 24 * time.Hour)
-			_ = h.RDB.Set(c, helpers.KeyTrustedDevice(u.ID, devID), "1", 30*24*time.Hour).Err()
-			h.Cookies.SetDeviceID(c, devID, exp)
+	h.loginStep(c, u.ID, "tokens_issued", map[string]any{"method": method, "location": tpl.FormatGeo(a.Geo)})
+	h.auditLogin(c, u, method, a.Geo)
+	h.Metrics.Observe(clientIP(c), helpers.AuthSuccess)
+	if assessed {
+		h.Anomaly.Record(c.Request.Context(), u.ID, a)
+		if a.Suspicious() {
+			h.sendSuspiciousLogin(c, u, a, pair.DeviceID)
 		}
 	}
 
-	h.setTokenCookies(c, pair)
 	payload := map[string]any{
 		"user_id": u.ID,
 		"email":   u.Email,
 		"name":    u.Name,
 	}
-	response.Success(c, http.StatusOK, payload, "login successful", map[string]any{"access_expires_at": pair.AccessTokenExpiry, "refresh_expires_at": pair.RefreshTokenExpiry})
+	// Remember device if requested: trusted for 30 days (deliverTokens hands out its id)
+	if req.RememberDevice && pair.DeviceID != "" {
+		_ = h.RDB.Set(c, helpers.KeyTrustedDevice(u.ID, pair.DeviceID), "1", 30*24*time.Hour).Err()
+	}
+
+	h.deliverTokens(c, pair, payload)
+	meta["access_expires_at"], meta["refresh_expires_at"] = pair.AccessTokenExpiry, pair.RefreshTokenExpiry
+	response.Success(c, http.StatusOK, payload, "login successful", meta)
 }
 
+// Refresh rotates the token pair. The device_id (cookie, or body in token-in-body mode) and the
+// User-Agent's client family must match the session's binding under SESSION_DEVICE_BINDING=enforce,
+// so a stolen refresh token alone is useless; mismatches are audited either way.
 func (h *UserHandler) Refresh(c *gin.Context) {
-	refresh, err := c.Cookie("refresh_token")
+	var refresh, deviceID string
+	var err error
+	if h.tokensInBody() {
+		var req struct {
+			RefreshToken string `json:"refresh_token"`
+			DeviceID     string `json:"device_id"`
+		}
+		err = c.ShouldBindJSON(&req)
+		refresh, deviceID = strings.TrimSpace(req.RefreshToken), req.DeviceID
+	} else {
+		refresh, err = h.Cookies.Get(c, helpers.CookieRefreshToken)
+		deviceID, _ = h.Cookies.Get(c, helpers.CookieDeviceID)
+	}
 	if err != nil || refresh == "" {
 		response.Error[any](c, http.StatusUnauthorized, "missing refresh token", nil)
 		return
 	}
-	pair, _, err := h.Svc.Refresh(c.Request.Context(), refresh)
+	ctx := userapp.WithSessionClient(c.Request.Context(), userapp.SessionClient{IP: clientIP(c), UserAgent: c.GetHeader("User-Agent"), DeviceID: deviceID})
+	pair, _, err := h.Svc.Refresh(ctx, refresh)
+	if accountBlocked(c, err) {
+		return
+	}
 	if err != nil {
 		response.Error[any](c, http.StatusUnauthorized, "invalid refresh token", nil)
 		return
 	}
-	h.setTokenCookies(c, pair)
-	response.Success[any](c, http.StatusOK, map[string]any{"refreshed": true}, "token refreshed", map[string]any{"access_expires_at": pair.AccessTokenExpiry, "refresh_expires_at": pair.RefreshTokenExpiry})
+	payload := map[string]any{"refreshed": true}
+	h.deliverTokens(c, pair, payload)
+	response.Success[any](c, http.StatusOK, payload, "token refreshed", map[string]any{"access_expires_at": pair.AccessTokenExpiry, "refresh_expires_at": pair.RefreshTokenExpiry})
+}
+
+type scopedTokenRequest struct {
+	Scopes   []string `json:"scopes" binding:"required,min=1"`
+	Audience string   `json:"audience"` // optional; defaults to JWT_AUDIENCE
+	TTL      string   `json:"ttl"`      // optional Go duration, capped at the access token TTL
+}
+
+// IssueScopedToken mints a bearer access token with a subset of the caller's scopes
+// (e.g. read-only) bound to the current session, so revoking the session revokes it too.
+// Personal access tokens have no session to bind to and are refused.
+func (h *UserHandler) IssueScopedToken(c *gin.Context) {
+	if c.GetString("personalTokenID") != "" {
+		response.Error[any](c, http.StatusForbidden, "personal access tokens cannot issue tokens", nil)
+		return
+	}
+	var req scopedTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error[any](c, http.StatusBadRequest, "invalid payload", validation.Details(err))
+		return
+	}
+	scopes, err := helpers.ReduceScopes(c.GetStringSlice("scopes"), req.Scopes)
+	if err != nil {
+		response.Error[any](c, http.StatusForbidden, "requested scopes exceed the current token", nil)
+		return
+	}
+	audience := h.JWT.Audience
+	if req.Audience != "" {
+		allowed := h.Cfg != nil && slices.Contains(h.Cfg.ScopedAudiences(), req.Audience)
+		if !allowed {
+			response.Error[any](c, http.StatusBadRequest, "audience not allowed", nil)
+			return
+		}
+		audience = req.Audience
+	}
+	var ttl time.Duration
+	if req.TTL != "" {
+		d, perr := time.ParseDuration(req.TTL)
+		if perr != nil || d <= 0 {
+			response.Error[any](c, http.StatusBadRequest, "invalid ttl", nil)
+			return
+		}
+		ttl = d
+	}
+	token, exp, err := h.JWT.GenerateScopedAccessToken(c.GetString("userID"), c.GetString("sessionID"), audience, scopes, ttl)
+	if err != nil {
+		serverError(c, h.Logger, err, "failed to issue token")
+		return
+	}
+	response.Success[any](c, http.StatusCreated, map[string]any{
+		"access_token": token,
+		"token_type":   "Bearer",
+		"expires_at":   exp,
+		"scope":        strings.Join(scopes, " "),
+		"audience":     audience,
+	}, "token issued", nil)
 }
 
 func (h *UserHandler) Logout(c *gin.Context) {
 	// Clear only auth cookies; keep device_id so trusted device remains for 30 days
-	c.SetSameSite(http.SameSiteLaxMode)
-	c.SetCookie("access_token", "", -1, "/", h.Cookies.Domain, h.Cookies.Secure, true)
-	c.SetCookie("refresh_token", "", -1, "/", h.Cookies.Domain, h.Cookies.Secure, true)
+	h.Cookies.ClearPair(c)
 	response.Success[any](c, http.StatusOK, map[string]any{"logged_out": true}, "logged out", nil)
 }
 
@@ -288,12 +718,13 @@ func (h *UserHandler) GetProfile(c *gin.Context) {
 		return
 	}
 	response.Success(c, http.StatusOK, gin.H{
-		"id":         u.ID,
-		"email":      u.Email,
-		"name":       u.Name,
-		"avatar_url": u.AvatarURL,
-		"created_at": u.CreatedAt,
-		"updated_at": u.UpdatedAt,
+		"id":          u.ID,
+		"external_id": u.ExternalID,
+		"email":       u.Email,
+		"name":        u.Name,
+		"avatar_url":  h.Svc.AvatarURL(c.Request.Context(), u),
+		"created_at":  u.CreatedAt,
+		"updated_at":  u.UpdatedAt,
 	}, "profile", nil)
 }
 
@@ -302,12 +733,29 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 
 	var req updateProfileRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.Error[any](c, http.StatusBadRequest, "invalid payload", validation.ToDetails(err))
+		response.Error[any](c, http.StatusBadRequest, "invalid payload", validation.Details(err))
 		return
 	}
 
 	before, _ := h.Svc.GetProfile(uid)
 
+	// Sensitive fields are never applied directly: they wait for the emailed confirmation
+	var pending *userapp.PendingProfileChange
+	if req.Email != "" {
+		if h.ProfileChanges == nil {
+			response.Error[any](c, http.StatusBadRequest, "email changes are not enabled", map[string]string{"email": "cannot be changed"})
+			return
+		}
+		if before == nil {
+			response.Error[any](c, http.StatusNotFound, "user not found", nil)
+			return
+		}
+		var ok bool
+		if pending, ok = h.ProfileChanges.request(c, before, userapp.ProfileFieldEmail, req.Email); !ok {
+			return
+		}
+	}
+
 	u, err := h.Svc.UpdateProfile(
 		c.Request.Context(),
 		uid,
@@ -321,14 +769,18 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 		return
 	}
 
-	response.Success(c, http.StatusOK, gin.H{
+	profile := gin.H{
 		"id":         u.ID,
 		"email":      u.Email,
 		"name":       u.Name,
-		"avatar_url": u.AvatarURL,
+		"avatar_url": h.Svc.AvatarURL(c.Request.Context(), u),
 		"created_at": u.CreatedAt,
 		"updated_at": u.UpdatedAt,
-	}, "profile updated", nil)
+	}
+	if pending != nil {
+		profile["pending_changes"] = []userapp.PendingProfileChange{*pending}
+	}
+	response.Success(c, http.StatusOK, profile, "profile updated", nil)
 
 	if h.Pub != nil && before != nil {
 		changes := map[string]string{}
@@ -343,6 +795,10 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 		if len(changes) == 0 {
 			return
 		}
+		// Non-security email: honor the user's opt-out
+		if !h.Prefs.Allowed(c.Request.Context(), u.ID, tpl.ProfileUpdated) {
+			return
+		}
 
 		data := tpl.NewProfileUpdatedData(
 			h.Cfg,
@@ -350,19 +806,23 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 			u.Email, // email
 			changes,
 			tpl.WithTime(time.Now()),
+			tpl.WithUnsubscribeURL(h.Prefs.UnsubscribeLink(u.ID, tpl.ProfileUpdated)),
 		)
 
 		job := mailer.EmailJob{
 			To:       u.Email,
+			Locale:   emailLocale(c),
 			Template: "universal",
-			Data:     data,
+			Data:     tpl.ToMap(data),
+			Envelope: mailer.Envelope{Variables: emailVariables(c, u.ID)},
+			JobMeta:  emailJobMeta(c, u.ID),
 		}
 
 		if h.Cfg != nil && h.Cfg.MailSendEnabled {
 			go func(job mailer.EmailJob) {
 				ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 				defer cancel()
-				if err := h.Pub.PublishJSON(ctx, job); err != nil && h.Logger != nil {
+				if err := h.Pub.PublishEmail(ctx, job); err != nil && h.Logger != nil {
 					h.Logger.WithError(err).Warn("failed to enqueue profile updated email")
 				}
 			}(job)
@@ -370,7 +830,7 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 	}
 }
 
-// Search allows searching users via Elasticsearch.
+// Search allows searching users via Elasticsearch; data is a page of hits with the total count.
 func (h *UserHandler) Search(c *gin.Context) {
 	q := c.Query("q")
 	if q == "" {
@@ -383,10 +843,42 @@ func (h *UserHandler) Search(c *gin.Context) {
 			size = v
 		}
 	}
-	res, err := h.Svc.SearchUsers(c.Request.Context(), q, size)
+	from := 0
+	if s := c.Query("from"); s != "" {
+		v, err := strconv.Atoi(s)
+		if err != nil || v < 0 {
+			response.Error[any](c, http.StatusBadRequest, "invalid from", nil)
+			return
+		}
+		from = v
+	}
+	// Sparse fieldset, e.g. fields=id,name for autocomplete
+	fields, err := helpers.ParseFields(c.Query("fields"), userapp.UserSearchFields)
 	if err != nil {
-		response.Error[any](c, http.StatusInternalServerError, "search failed", err.Error())
+		response.Error[any](c, http.StatusBadRequest, "invalid fields", map[string]any{"error": err.Error(), "allowed": userapp.UserSearchFields})
 		return
 	}
+	quota, err := h.Svc.ConsumeSearchQuota(c.Request.Context(), c.GetString("userID"))
+	resetSec := int(quota.Reset.Seconds())
+	if quota.Limit > 0 {
+		c.Header("X-Search-Quota-Limit", strconv.FormatInt(quota.Limit, 10))
+		c.Header("X-Search-Quota-Remaining", strconv.FormatInt(quota.Remaining(), 10))
+		c.Header("X-Search-Quota-Reset", strconv.Itoa(resetSec))
+	}
+	if errors.Is(err, helpers.ErrQuotaExceeded) {
+		c.Header("Retry-After", strconv.Itoa(max(resetSec, 1)))
+		response.Error[any](c, http.StatusTooManyRequests, "daily search quota exceeded", map[string]any{"limit": quota.Limit, "reset_in": resetSec})
+		return
+	}
+	res, cached, err := h.Svc.SearchUsers(c.Request.Context(), userapp.UserSearchQuery{Q: q, From: from, Size: size, Fields: fields})
+	if err != nil {
+		serverError(c, h.Logger, err, "search failed")
+		return
+	}
+	if cached {
+		c.Header("X-Cache", "HIT")
+	} else {
+		c.Header("X-Cache", "MISS")
+	}
 	response.Success[any](c, http.StatusOK, res, "search results", nil)
 }
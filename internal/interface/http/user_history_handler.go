@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	userapp "github.com/oksasatya/go-ddd-clean-architecture/internal/application"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/response"
+)
+
+type UserHistoryHandler struct {
+	Svc    *userapp.UserHistoryService
+	Logger *logrus.Logger
+}
+
+func NewUserHistoryHandler(svc *userapp.UserHistoryService, logger *logrus.Logger) *UserHistoryHandler {
+	return &UserHistoryHandler{Svc: svc, Logger: logger}
+}
+
+type userEventView struct {
+	Seq       int64                         `json:"seq"`
+	Type      string                        `json:"type"`
+	Actor     string                        `json:"actor"`
+	Changes   map[string]entity.FieldChange `json:"changes"`
+	CreatedAt time.Time                     `json:"created_at"`
+}
+
+// History GET /api/admin/users/:id/history?after=<seq>&limit=<n>
+// Lists the user's change events in order; pass the last seq as after for the next page.
+func (h *UserHistoryHandler) History(c *gin.Context) {
+	after, err := strconv.ParseInt(c.DefaultQuery("after", "0"), 10, 64)
+	if err != nil || after < 0 {
+		response.Error[any](c, http.StatusBadRequest, "invalid after", nil)
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 || limit > 200 {
+		response.Error[any](c, http.StatusBadRequest, "invalid limit (1-200)", nil)
+		return
+	}
+	items, err := h.Svc.History(c.Request.Context(), c.Param("id"), after, limit)
+	if err != nil {
+		serverError(c, h.Logger, err, "failed to load history")
+		return
+	}
+	out := make([]userEventView, 0, len(items))
+	for _, ev := range items {
+		out = append(out, userEventView{Seq: ev.Seq, Type: ev.Type, Actor: ev.Actor, Changes: ev.Changes, CreatedAt: ev.CreatedAt})
+	}
+	var next any
+	if len(out) == limit {
+		next = out[len(out)-1].Seq
+	}
+	response.Success[any](c, http.StatusOK, gin.H{"items": out, "next_after": next}, "ok", nil)
+}
+
+// State GET /api/admin/users/:id/history/state?until=<seq>
+// Replays the history into the user's state as of seq until (default: latest).
+func (h *UserHistoryHandler) State(c *gin.Context) {
+	until, err := strconv.ParseInt(c.DefaultQuery("until", "0"), 10, 64)
+	if err != nil || until < 0 {
+		response.Error[any](c, http.StatusBadRequest, "invalid until", nil)
+		return
+	}
+	snap, err := h.Svc.Replay(c.Request.Context(), c.Param("id"), until)
+	if err != nil {
+		if errors.Is(err, userapp.ErrUserNotFound) {
+			response.Error[any](c, http.StatusNotFound, "no history for user", nil)
+			return
+		}
+		serverError(c, h.Logger, err, "failed to replay history")
+		return
+	}
+	response.Success[any](c, http.StatusOK, snap, "ok", nil)
+}
@@ -1,25 +1,59 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/redis/go-redis/v9"
 
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
 	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
 	"github.com/oksasatya/go-ddd-clean-architecture/pkg/response"
 )
 
-// Auth validates access token and ensures an active session exists in Redis.
-// It sets userID, userName, and userEmail in the Gin context on success.
-func Auth(rdb *redis.Client, jwt *helpers.JWTManager) gin.HandlerFunc {
+// accessToken reads the token from the Authorization bearer header, falling back to the access_token cookie
+func accessToken(c *gin.Context) string {
+	if h := c.GetHeader("Authorization"); len(h) > 7 && strings.EqualFold(h[:7], "Bearer ") {
+		return strings.TrimSpace(h[7:])
+	}
+	token, _ := c.Cookie(helpers.DefaultCookies().Name(helpers.CookieAccessToken))
+	return token
+}
+
+// OptionalAuth runs Auth only when the request carries an access token, so public endpoints can
+// also serve signed-in callers; an invalid token is still rejected.
+func OptionalAuth(sessions repository.SessionStore, jwt *helpers.JWTManager, policy helpers.SessionPolicy, tokens repository.TokenAuthenticator) gin.HandlerFunc {
+	auth := Auth(sessions, jwt, policy, tokens)
 	return func(c *gin.Context) {
-		token, err := c.Cookie("access_token")
-		if err != nil || token == "" {
+		if accessToken(c) == "" {
+			c.Next()
+			return
+		}
+		auth(c)
+	}
+}
+
+// Auth validates access token and ensures its session is still current in the session store.
+// It sets userID, userName, userEmail, sessionID and scopes in the Gin context on success.
+// With a sliding policy the session TTL is extended on every authenticated request.
+// Personal access tokens (pat_...) are resolved by tokens instead (rejected when nil); they set
+// personalTokenID rather than sessionID.
+func Auth(sessions repository.SessionStore, jwt *helpers.JWTManager, policy helpers.SessionPolicy, tokens repository.TokenAuthenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := accessToken(c)
+		if token == "" {
 			response.Error[any](c, http.StatusUnauthorized, "missing access token", nil)
 			c.Abort()
 			return
 		}
+		if strings.HasPrefix(token, entity.PersonalTokenPrefix) {
+			personalTokenAuth(c, tokens, token)
+			return
+		}
 		claims, err := jwt.ParseAccessToken(token)
 		if err != nil {
 			response.Error[any](c, http.StatusUnauthorized, "invalid access token", err.Error())
@@ -27,23 +61,110 @@ func Auth(rdb *redis.Client, jwt *helpers.JWTManager) gin.HandlerFunc {
 			return
 		}
 
-		// Retrieve session from Redis as a hash and validate session id
-		key := "user:session:" + claims.UserID
-		data, err := rdb.HGetAll(c.Request.Context(), key).Result()
-		if err != nil || len(data) == 0 {
-			response.Error[any](c, http.StatusUnauthorized, "session not found", nil)
+		sess, err := sessions.Get(c.Request.Context(), claims.UserID, claims.SessionID)
+		if errors.Is(err, entity.ErrAccountSuspended) || errors.Is(err, entity.ErrAccountBanned) {
+			response.Error[any](c, http.StatusForbidden, err.Error(), nil)
 			c.Abort()
 			return
 		}
-		if sid, ok := data["sid"]; !ok || sid == "" || sid != claims.SessionID {
-			response.Error[any](c, http.StatusUnauthorized, "session expired", nil)
+		if err != nil {
+			msg := "session not found"
+			if errors.Is(err, repository.ErrSessionMismatch) {
+				msg = "session expired"
+			}
+			response.Error[any](c, http.StatusUnauthorized, msg, nil)
 			c.Abort()
 			return
 		}
+		if policy.Sliding {
+			ttl, ok := policy.NextTTL(sess.CreatedAt, time.Now())
+			if !ok {
+				_ = sessions.Revoke(c.Request.Context(), sess.UserID, sess.ID)
+				response.Error[any](c, http.StatusUnauthorized, "session expired", nil)
+				c.Abort()
+				return
+			}
+			_ = sessions.Touch(c.Request.Context(), sess.UserID, sess.ID, ttl)
+		}
+
+		c.Set("userID", sess.UserID)   // required by handlers
+		c.Set("userName", sess.Name)   // extra convenience
+		c.Set("userEmail", sess.Email) // extra convenience
+		c.Set("sessionID", claims.SessionID)
+		c.Set("scopes", claims.Scopes())
+		// history entries written during this request name the signed-in user, and so do its log lines
+		ctx := repository.WithActor(c.Request.Context(), "user:"+sess.UserID)
+		c.Request = c.Request.WithContext(helpers.WithLogger(ctx, helpers.FromContext(ctx).WithField("user_id", sess.UserID)))
+		if len(claims.Custom) > 0 {
+			c.Set(customClaimsKey, claims.Custom)
+		}
+		c.Next()
+	}
+}
+
+// personalTokenAuth authenticates the request with a personal access token and sets the same
+// context values as a session, with the token's scopes
+func personalTokenAuth(c *gin.Context, tokens repository.TokenAuthenticator, token string) {
+	if tokens == nil {
+		response.Error[any](c, http.StatusUnauthorized, "invalid access token", nil)
+		c.Abort()
+		return
+	}
+	pat, u, err := tokens.Authenticate(c.Request.Context(), token, c.ClientIP())
+	switch {
+	case errors.Is(err, entity.ErrAccountSuspended) || errors.Is(err, entity.ErrAccountBanned):
+		response.Error[any](c, http.StatusForbidden, err.Error(), nil)
+		c.Abort()
+		return
+	case errors.Is(err, repository.ErrNotFound):
+		response.Error[any](c, http.StatusUnauthorized, "invalid access token", nil)
+		c.Abort()
+		return
+	case err != nil:
+		helpers.FromContext(c.Request.Context()).WithError(err).Error("personal access token lookup failed")
+		response.Error[any](c, http.StatusServiceUnavailable, "authentication unavailable", nil)
+		c.Abort()
+		return
+	}
+
+	c.Set("userID", u.ID)
+	c.Set("userName", u.Name)
+	c.Set("userEmail", u.Email)
+	c.Set("personalTokenID", pat.ID)
+	c.Set("scopes", pat.Scopes)
+	ctx := repository.WithActor(c.Request.Context(), "user:"+u.ID)
+	c.Request = c.Request.WithContext(helpers.WithLogger(ctx, helpers.FromContext(ctx).WithField("user_id", u.ID).WithField("token_id", pat.ID)))
+	c.Next()
+}
 
-		c.Set("userID", data["user_id"])  // required by handlers
-		c.Set("userName", data["name"])   // extra convenience
-		c.Set("userEmail", data["email"]) // extra convenience
+const customClaimsKey = "customClaims"
+
+// CustomClaims returns the custom claims ClaimsEnrichers stamped on the request's access token (nil when none).
+// Must run after Auth.
+func CustomClaims(c *gin.Context) map[string]any {
+	v, _ := c.Get(customClaimsKey)
+	m, _ := v.(map[string]any)
+	return m
+}
+
+// CustomClaim returns one custom claim; numbers decode as float64 and lists as []any.
+func CustomClaim(c *gin.Context, key string) (any, bool) {
+	v, ok := CustomClaims(c)[key]
+	return v, ok
+}
+
+// RequireScopes allows the request only when the access token carries every given scope.
+// Must run after Auth.
+func RequireScopes(scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		have := c.GetStringSlice("scopes")
+		for _, s := range scopes {
+			if !slices.Contains(have, s) {
+				response.Error[any](c, http.StatusForbidden, "insufficient scope", map[string]any{"required": scopes})
+				c.Abort()
+				return
+			}
+		}
 		c.Next()
 	}
 }
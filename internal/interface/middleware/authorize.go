@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/response"
+)
+
+// RoleChecker resolves role membership for a user (implemented by application.RoleService).
+type RoleChecker interface {
+	HasRole(ctx context.Context, userID, role string) (bool, error)
+}
+
+// PermissionChecker resolves effective permissions for a user (implemented by application.RoleService).
+type PermissionChecker interface {
+	HasPermission(ctx context.Context, userID, permission string) (bool, error)
+}
+
+// RequireRole allows the request only when the authenticated user holds role.
+// Must run after Auth so userID is present in the context.
+func RequireRole(rc RoleChecker, role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid := c.GetString("userID")
+		if uid == "" {
+			response.Error[any](c, http.StatusUnauthorized, "unauthorized", nil)
+			c.Abort()
+			return
+		}
+		ok, err := rc.HasRole(c.Request.Context(), uid, role)
+		if err != nil {
+			response.Error[any](c, http.StatusInternalServerError, "authorization unavailable", nil)
+			c.Abort()
+			return
+		}
+		if !ok {
+			response.Error[any](c, http.StatusForbidden, "forbidden", nil)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequirePermission allows the request only when one of the user's roles grants permission.
+// Must run after Auth so userID is present in the context.
+func RequirePermission(pc PermissionChecker, permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid := c.GetString("userID")
+		if uid == "" {
+			response.Error[any](c, http.StatusUnauthorized, "unauthorized", nil)
+			c.Abort()
+			return
+		}
+		ok, err := pc.HasPermission(c.Request.Context(), uid, permission)
+		if err != nil {
+			response.Error[any](c, http.StatusInternalServerError, "authorization unavailable", nil)
+			c.Abort()
+			return
+		}
+		if !ok {
+			response.Error[any](c, http.StatusForbidden, "forbidden", nil)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+)
+
+// DebugBodyHeader carries a signed token (see helpers.SignDebugToken) that enables body logging for one request
+const DebugBodyHeader = "X-Debug-Body-Log"
+
+// bodyLogWriter tees the first max bytes of the response
+type bodyLogWriter struct {
+	gin.ResponseWriter
+	buf       bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (w *bodyLogWriter) capture(b []byte) {
+	room := max(w.max-w.buf.Len(), 0)
+	if len(b) > room {
+		b, w.truncated = b[:room], true
+	}
+	w.buf.Write(b)
+}
+
+func (w *bodyLogWriter) Write(b []byte) (int, error) {
+	w.capture(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *bodyLogWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+// DebugBodyLog logs redacted request and response bodies (up to maxBytes each) for routes listed in routes
+// (Gin route templates, e.g. /api/orgs/:org) or for requests carrying a valid DebugBodyHeader token.
+// Meant for staging; the body is re-attached so handlers still read it in full.
+func DebugBodyLog(logger *logrus.Logger, routes []string, secret string, maxBytes int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !slices.Contains(routes, c.FullPath()) && !helpers.VerifyDebugToken(secret, c.GetHeader(DebugBodyHeader), time.Now()) {
+			c.Next()
+			return
+		}
+		start := time.Now()
+		var reqBody []byte
+		reqTruncated := false
+		if c.Request.Body != nil {
+			reqBody, _ = io.ReadAll(io.LimitReader(c.Request.Body, int64(maxBytes)+1))
+			c.Request.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(reqBody), c.Request.Body), c.Request.Body}
+			if len(reqBody) > maxBytes {
+				reqBody, reqTruncated = reqBody[:maxBytes], true
+			}
+		}
+		w := &bodyLogWriter{ResponseWriter: c.Writer, max: maxBytes}
+		c.Writer = w
+
+		c.Next()
+
+		logger.WithFields(logrus.Fields{
+			"request_id":         c.GetString("request_id"),
+			"method":             c.Request.Method,
+			"route":              c.FullPath(),
+			"path":               c.Request.URL.Path,
+			"status":             w.Status(),
+			"duration_ms":        time.Since(start).Milliseconds(),
+			"user_id":            c.GetString("userID"),
+			"request_headers":    helpers.RedactHeaders(c.Request.Header),
+			"request_body":       helpers.RedactBody(c.ContentType(), reqBody),
+			"request_truncated":  reqTruncated,
+			"response_body":      helpers.RedactBody(w.Header().Get("Content-Type"), w.buf.Bytes()),
+			"response_truncated": w.truncated,
+		}).Info("debug body log")
+	}
+}
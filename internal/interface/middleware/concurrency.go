@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/response"
+)
+
+// ConcurrencyLimit caps in-flight requests with a semaphore. A request that finds it full waits up to
+// queueWait for a slot, then gets 503 with Retry-After. limit <= 0 disables the limiter.
+// Each call creates its own semaphore, so share the returned handler to share the limit.
+func ConcurrencyLimit(limit int, queueWait, retryAfter time.Duration) gin.HandlerFunc {
+	if limit <= 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+	sem := make(chan struct{}, limit)
+	retry := strconv.Itoa(max(1, int(retryAfter.Seconds())))
+	return func(c *gin.Context) {
+		select {
+		case sem <- struct{}{}:
+		default:
+			if !waitSlot(c, sem, queueWait) {
+				c.Header("Retry-After", retry)
+				response.Error[any](c, http.StatusServiceUnavailable, "server busy, retry later", nil)
+				c.Abort()
+				return
+			}
+		}
+		defer func() { <-sem }()
+		c.Next()
+	}
+}
+
+func waitSlot(c *gin.Context, sem chan struct{}, wait time.Duration) bool {
+	if wait <= 0 {
+		return false
+	}
+	t := time.NewTimer(wait)
+	defer t.Stop()
+	select {
+	case sem <- struct{}{}:
+		return true
+	case <-t.C:
+		return false
+	case <-c.Request.Context().Done():
+		return false
+	}
+}
@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/response"
+)
+
+// Degraded makes response meta.degraded list the dependencies whose circuit breaker is open,
+// so clients know results may be partial (e.g. empty search results while Elasticsearch is down)
+func Degraded(breakers *helpers.Breakers) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if breakers != nil {
+			c.Set(response.DegradedKey, breakers)
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/response"
+)
+
+// Request-scoped feature flag overrides: FeatureFlagsHeader lists them ("search_canary=on") and
+// FeatureTokenHeader signs that exact value (see helpers.SignFeatureToken)
+const (
+	FeatureFlagsHeader = "X-Feature-Flags"
+	FeatureTokenHeader = "X-Feature-Flags-Token"
+)
+
+// FeatureFlags lets trusted callers force feature flags on or off for one request, e.g. to canary a
+// new search backend. Requests without FeatureFlagsHeader pass untouched; with it, the token must
+// verify against secret and every flag must be known, otherwise the request fails with 400 so a
+// canary never silently runs the default path. Applied overrides go into the request context (see
+// helpers.Features), the request logger (feature_overrides), a log line and the response header.
+// Register it after request_logger.
+func FeatureFlags(secret string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		raw := c.GetHeader(FeatureFlagsHeader)
+		if raw == "" {
+			c.Next()
+			return
+		}
+		if !helpers.VerifyFeatureToken(secret, raw, c.GetHeader(FeatureTokenHeader), time.Now()) {
+			response.Error[any](c, http.StatusBadRequest, "invalid feature flags token", nil)
+			c.Abort()
+			return
+		}
+		flags, err := helpers.ParseFeatureFlags(raw)
+		if err != nil {
+			response.Error[any](c, http.StatusBadRequest, err.Error(), nil)
+			c.Abort()
+			return
+		}
+		applied := helpers.FormatFeatureFlags(flags)
+		ctx := helpers.WithFeatureOverrides(c.Request.Context(), flags)
+		entry := helpers.FromContext(ctx).WithField("feature_overrides", applied)
+		c.Request = c.Request.WithContext(helpers.WithLogger(ctx, entry))
+		c.Set("featureOverrides", applied)
+		c.Header(FeatureFlagsHeader, applied)
+		entry.WithField("path", c.Request.URL.Path).Info("feature overrides applied")
+		c.Next()
+	}
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/response"
+)
+
+// InternalClient admits trusted internal services, identified either by an X-API-Key header
+// matching one of apiKeys or by a verified mTLS client certificate whose CN is in clientCNs.
+// It sets internalClient in the Gin context to "key:<fingerprint>" (first 8 bytes of the key's SHA-256, hex)
+// or "cn:<name>".
+func InternalClient(apiKeys, clientCNs []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if key := c.GetHeader("X-API-Key"); key != "" {
+			for _, k := range apiKeys {
+				if subtle.ConstantTimeCompare([]byte(key), []byte(k)) == 1 {
+					sum := sha256.Sum256([]byte(k))
+					c.Set("internalClient", "key:"+hex.EncodeToString(sum[:8]))
+					c.Next()
+					return
+				}
+			}
+		}
+		// Only chains verified against TLS_CLIENT_CA_FILE count; unverified peer certs are ignored
+		if tlsState := c.Request.TLS; tlsState != nil && len(tlsState.VerifiedChains) > 0 && len(tlsState.VerifiedChains[0]) > 0 {
+			cn := tlsState.VerifiedChains[0][0].Subject.CommonName
+			if cn != "" && slices.Contains(clientCNs, cn) {
+				c.Set("internalClient", "cn:"+cn)
+				c.Next()
+				return
+			}
+		}
+		response.Error[any](c, http.StatusUnauthorized, "client not authorized", nil)
+		c.Abort()
+	}
+}
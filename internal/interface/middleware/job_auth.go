@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/response"
+)
+
+// JobAuth admits external schedulers to the internal jobs API, identified either by an X-Job-Token
+// header signed with secret for the job in the :name path parameter (empty for the listing), or by
+// an OIDC ID token (Authorization: Bearer) issued by provider for audience to a verified email in
+// emails, such as the token Cloud Scheduler or Cloud Tasks attach for a service account. Without
+// emails no OIDC token is accepted: anyone can get an ID token for any audience. It sets jobCaller in
+// the Gin context to "token" or "oidc:<email>".
+func JobAuth(secret string, provider *helpers.OIDCProvider, audience string, emails []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if tok := c.GetHeader("X-Job-Token"); tok != "" && helpers.VerifyJobToken(secret, c.Param("name"), tok, time.Now()) {
+			c.Set("jobCaller", "token")
+			c.Next()
+			return
+		}
+		if raw, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok && provider != nil && len(emails) > 0 {
+			claims, err := provider.VerifyServiceToken(c.Request.Context(), strings.TrimSpace(raw), audience)
+			if err == nil && claims.EmailVerified && slices.Contains(emails, strings.ToLower(claims.Email)) {
+				c.Set("jobCaller", "oidc:"+claims.Email)
+				c.Next()
+				return
+			}
+			if err != nil {
+				helpers.FromContext(c.Request.Context()).WithError(err).Warn("job OIDC token rejected")
+			}
+		}
+		response.Error[any](c, http.StatusUnauthorized, "client not authorized", nil)
+		c.Abort()
+	}
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/response"
+)
+
+// LoadShedPolicy maps Gin route templates to the dependencies they cannot work without. A key
+// ending in "*" matches every route with that prefix; the longest matching key wins. Routes not
+// in the policy (login, refresh, OTP confirm) are never shed.
+type LoadShedPolicy map[string][]string
+
+// needs returns the dependencies of route, or nil when it is not covered
+func (p LoadShedPolicy) needs(route string) []string {
+	if deps, ok := p[route]; ok {
+		return deps
+	}
+	best := ""
+	for key := range p {
+		prefix, ok := strings.CutSuffix(key, "*")
+		if ok && strings.HasPrefix(route, prefix) && len(prefix) >= len(best) {
+			best = key
+		}
+	}
+	if best == "" {
+		return nil
+	}
+	return p[best]
+}
+
+// LoadShed answers 503 with Retry-After for routes whose dependencies the monitor reports
+// degraded, before they queue up on timeouts, so the capacity left goes to the auth-critical
+// routes. The error details list the missing dependencies.
+func LoadShed(health *helpers.DependencyMonitor, policy LoadShedPolicy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deps := policy.needs(c.FullPath())
+		if len(deps) == 0 {
+			c.Next()
+			return
+		}
+		var missing []string
+		for _, d := range health.Degraded() {
+			if slices.Contains(deps, d) {
+				missing = append(missing, d)
+			}
+		}
+		if len(missing) == 0 {
+			c.Next()
+			return
+		}
+		health.RecordShed(c.FullPath())
+		c.Header("Retry-After", "5")
+		response.Error[any](c, http.StatusServiceUnavailable, "temporarily unavailable, retry later", map[string]any{"degraded": missing})
+		c.Abort()
+	}
+}
@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/response"
+)
+
+// OrgMembership resolves a user's organization role (implemented by application.OrganizationService).
+type OrgMembership interface {
+	MemberRole(ctx context.Context, orgID, userID string) (string, error)
+}
+
+// RequireOrgRole allows the request only when the user is a member of the organization in the :org
+// path parameter with at least minRole (atLeast ranks roles). Sets orgID and orgRole in the context.
+// Must run after Auth so userID is present in the context.
+func RequireOrgRole(om OrgMembership, atLeast func(role, minRole string) bool, minRole string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid := c.GetString("userID")
+		if uid == "" {
+			response.Error[any](c, http.StatusUnauthorized, "unauthorized", nil)
+			c.Abort()
+			return
+		}
+		orgID := c.Param("org")
+		role, err := om.MemberRole(c.Request.Context(), orgID, uid)
+		if err != nil {
+			response.Error[any](c, http.StatusInternalServerError, "authorization unavailable", nil)
+			c.Abort()
+			return
+		}
+		if role == "" {
+			// Do not reveal whether the organization exists
+			response.Error[any](c, http.StatusNotFound, "organization not found", nil)
+			c.Abort()
+			return
+		}
+		if !atLeast(role, minRole) {
+			response.Error[any](c, http.StatusForbidden, "forbidden", nil)
+			c.Abort()
+			return
+		}
+		c.Set("orgID", orgID)
+		c.Set("orgRole", role)
+		c.Next()
+	}
+}
+
+// OrgRequestQuota counts a request against an organization (implemented by application.OrgQuotaService).
+type OrgRequestQuota interface {
+	ConsumeRequest(ctx context.Context, orgID string) (helpers.QuotaResult, error)
+}
+
+// OrgQuota enforces the per-organization rate (429) and daily request quota (402) for the organization
+// resolved by RequireOrgRole. Unexpected errors fail open.
+func OrgQuota(q OrgRequestQuota) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orgID := c.GetString("orgID")
+		if orgID == "" {
+			c.Next()
+			return
+		}
+		res, err := q.ConsumeRequest(c.Request.Context(), orgID)
+		resetSec := int(res.Reset.Seconds())
+		if res.Limit > 0 {
+			c.Header("X-Org-Quota-Limit", strconv.FormatInt(res.Limit, 10))
+			c.Header("X-Org-Quota-Remaining", strconv.FormatInt(res.Remaining(), 10))
+			c.Header("X-Org-Quota-Reset", strconv.Itoa(resetSec))
+		}
+		switch {
+		case errors.Is(err, helpers.ErrRateLimited):
+			c.Header("Retry-After", strconv.Itoa(max(resetSec, 1)))
+			response.Error[any](c, http.StatusTooManyRequests, "organization rate limit exceeded", map[string]any{"limit": res.Limit, "reset_in": resetSec})
+			c.Abort()
+			return
+		case errors.Is(err, helpers.ErrQuotaExceeded):
+			response.Error[any](c, http.StatusPaymentRequired, "organization request quota exceeded", map[string]any{"quota": "requests", "limit": res.Limit, "reset_in": resetSec})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -3,6 +3,8 @@ package middleware
 import (
 	"github.com/gin-gonic/gin"
 	"net"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
 )
 
 // AllowPrivateIP returns a middleware function that allows requests
@@ -20,3 +22,26 @@ func AllowPrivateIP() AllowFunc {
 		return private
 	}
 }
+
+// AllowFromStore bypasses the limit for the client IP, user ID or X-API-Key on the runtime
+// allowlist (managed at /api/admin/rate-limit/bypass). User entries only match once auth has run.
+func AllowFromStore(b *helpers.RateBypass) AllowFunc {
+	if b == nil {
+		return nil
+	}
+	return func(c *gin.Context) bool {
+		return b.Allows(ipFromCtx(c), c.GetString("userID"), c.GetHeader("X-API-Key"))
+	}
+}
+
+// AllowAny bypasses when any of fns does; nil entries are skipped
+func AllowAny(fns ...AllowFunc) AllowFunc {
+	return func(c *gin.Context) bool {
+		for _, fn := range fns {
+			if fn != nil && fn(c) {
+				return true
+			}
+		}
+		return false
+	}
+}
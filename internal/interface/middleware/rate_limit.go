@@ -9,6 +9,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
 
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/keyspace"
 	"github.com/oksasatya/go-ddd-clean-architecture/pkg/response"
 )
 
@@ -34,25 +35,17 @@ func normalizePath(c *gin.Context) string {
 // Example: combine client IP and route path for more granular limiting
 type KeyFunc func(c *gin.Context) string
 
-// KeyByIP returns a key function that limits by client IP only
+// KeyByIP returns a key function that limits by client IP only (real_ip, see RealIP)
 func KeyByIP() KeyFunc {
 	return func(c *gin.Context) string {
-		ip := c.ClientIP()
-		if ip == "" {
-			ip = "unknown"
-		}
-		return "rl:ip:" + ip
+		return keyspace.Key("rl:ip:" + ipFromCtx(c))
 	}
 }
 
 // KeyByIPAndPath returns a key function that limits by client IP and request path
 func KeyByIPAndPath() KeyFunc {
 	return func(c *gin.Context) string {
-		ip := c.ClientIP()
-		if ip == "" {
-			ip = "unknown"
-		}
-		return "rl:path:" + normalizePath(c) + ":ip:" + ip
+		return keyspace.Key("rl:path:" + normalizePath(c) + ":ip:" + ipFromCtx(c))
 	}
 }
 
@@ -60,9 +53,9 @@ func KeyByUserID() KeyFunc {
 	return func(c *gin.Context) string {
 		uid := c.GetString("userID")
 		if uid == "" {
-			return "rl:user:anon:ip:" + ipFromCtx(c)
+			return keyspace.Key("rl:user:anon:ip:" + ipFromCtx(c))
 		}
-		return "rl:user:" + uid
+		return keyspace.Key("rl:user:" + uid)
 	}
 }
 
@@ -111,11 +104,8 @@ func RateLimit(rdb *redis.Client, max int, window time.Duration, keyFn KeyFunc,
 		count := toInt(countI)
 
 		// TTL untuk header reset
-		ttl, _ := rdb.TTL(ctx, key).Result()
-		resetSec := 0
-		if ttl > 0 {
-			resetSec = int(ttl.Seconds())
-		}
+		ttl, _ := rdb.PTTL(ctx, key).Result()
+		resetSec := ceilSeconds(ttl)
 
 		// Standard headers
 		// https://datatracker.ietf.org/doc/html/rfc6585#section-4
@@ -126,17 +116,35 @@ func RateLimit(rdb *redis.Client, max int, window time.Duration, keyFn KeyFunc,
 
 		// Exceeded
 		if int(count) > max {
-			if resetSec > 0 {
-				c.Header("Retry-After", strconv.Itoa(resetSec))
-			}
-			response.Error[any](c, http.StatusTooManyRequests, "rate limit exceeded", nil)
-			c.Abort()
+			throttled(c, "rate limit exceeded", resetSec, map[string]any{"limit": max})
 			return
 		}
 		c.Next()
 	}
 }
 
+// throttled aborts with 429 and the details a frontend needs for a countdown:
+// retry_after_seconds and attempts_remaining (always 0 here), plus extra
+func throttled(c *gin.Context, msg string, retryAfter int, extra map[string]any) {
+	details := map[string]any{"retry_after_seconds": retryAfter, "attempts_remaining": 0}
+	for k, v := range extra {
+		details[k] = v
+	}
+	if retryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+	}
+	response.Error[any](c, http.StatusTooManyRequests, msg, details)
+	c.Abort()
+}
+
+// ceilSeconds rounds a wait up so clients never retry a moment too early
+func ceilSeconds(d time.Duration) int {
+	if d <= 0 {
+		return 0
+	}
+	return int((d + time.Second - 1) / time.Second)
+}
+
 func toInt(v interface{}) int {
 	switch x := v.(type) {
 	case int64:
@@ -5,37 +5,120 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
 )
 
 // RealIP sets the real client IP into Gin context (key: "real_ip").
-// Priority:
-// 1) CF-Connecting-IP (Cloudflare)
-// 2) X-Forwarded-For (left-most)
-// 3) fallback to c.ClientIP()
-func RealIP() gin.HandlerFunc {
+// Forwarding headers are only believed when the direct peer is a trusted proxy:
+// 1) CF-Connecting-IP, when the peer is a Cloudflare address
+// 2) Forwarded (RFC 7239 for=) or else X-Forwarded-For: the right-most hop that is not a trusted proxy
+// 3) otherwise the peer address itself
+// Left-most forwarded values are client-supplied and can be spoofed, so the chain is walked from the right.
+func RealIP(trusted *helpers.TrustedProxies) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		peer := remoteIP(c)
+		if !trusted.Contains(peer) {
+			setRealIP(c, peer)
+			c.Next()
+			return
+		}
 		// 1) Cloudflare header
-		if cf := strings.TrimSpace(c.GetHeader("CF-Connecting-IP")); cf != "" {
-			if ip := net.ParseIP(cf); ip != nil {
-				c.Set("real_ip", ip.String())
+		if trusted.IsCloudflare(peer) {
+			if ip := net.ParseIP(strings.TrimSpace(c.GetHeader("CF-Connecting-IP"))); ip != nil {
+				setRealIP(c, ip)
 				c.Next()
 				return
 			}
 		}
-		// 2) X-Forwarded-For: take left-most
-		if xff := c.GetHeader("X-Forwarded-For"); xff != "" {
-			parts := strings.Split(xff, ",")
-			if len(parts) > 0 {
-				first := strings.TrimSpace(parts[0])
-				if ip := net.ParseIP(first); ip != nil {
-					c.Set("real_ip", ip.String())
-					c.Next()
-					return
+		// 2) Forwarded / X-Forwarded-For chain, rightmost untrusted hop
+		chain := forwardedFor(c.Request.Header.Values("Forwarded"))
+		if len(chain) == 0 {
+			chain = xForwardedFor(c.Request.Header.Values("X-Forwarded-For"))
+		}
+		if ip := rightmostUntrusted(chain, trusted); ip != nil {
+			setRealIP(c, ip)
+			c.Next()
+			return
+		}
+		// 3) Fallback
+		setRealIP(c, peer)
+		c.Next()
+	}
+}
+
+// rightmostUntrusted walks the chain from the proxy closest to us; all-trusted chains yield the left-most hop.
+// An unparseable hop (unknown, obfuscated identifier) ends the walk without a result.
+func rightmostUntrusted(chain []string, trusted *helpers.TrustedProxies) net.IP {
+	var last net.IP
+	for i := len(chain) - 1; i >= 0; i-- {
+		ip := net.ParseIP(chain[i])
+		if ip == nil {
+			return nil
+		}
+		if !trusted.Contains(ip) {
+			return ip
+		}
+		last = ip
+	}
+	return last
+}
+
+func xForwardedFor(values []string) []string {
+	var out []string
+	for _, v := range values {
+		for _, p := range strings.Split(v, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				out = append(out, p)
+			}
+		}
+	}
+	return out
+}
+
+// forwardedFor extracts the for= node of every element of RFC 7239 Forwarded headers, in order,
+// without quotes, brackets or ports (e.g. for="[2001:db8::1]:4711" -> 2001:db8::1)
+func forwardedFor(values []string) []string {
+	var out []string
+	for _, v := range values {
+		for _, elem := range strings.Split(v, ",") {
+			for _, pair := range strings.Split(elem, ";") {
+				k, val, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if !ok || !strings.EqualFold(strings.TrimSpace(k), "for") {
+					continue
 				}
+				out = append(out, forwardedNode(strings.Trim(strings.TrimSpace(val), `"`)))
 			}
 		}
-		// 3) Fallback
+	}
+	return out
+}
+
+func forwardedNode(node string) string {
+	if strings.HasPrefix(node, "[") {
+		if end := strings.Index(node, "]"); end > 0 {
+			return node[1:end]
+		}
+		return node
+	}
+	if host, _, err := net.SplitHostPort(node); err == nil {
+		return host
+	}
+	return node
+}
+
+func remoteIP(c *gin.Context) net.IP {
+	host, _, err := net.SplitHostPort(strings.TrimSpace(c.Request.RemoteAddr))
+	if err != nil {
+		host = strings.TrimSpace(c.Request.RemoteAddr)
+	}
+	return net.ParseIP(host)
+}
+
+func setRealIP(c *gin.Context, ip net.IP) {
+	if ip == nil {
 		c.Set("real_ip", c.ClientIP())
-		c.Next()
+		return
 	}
+	c.Set("real_ip", ip.String())
 }
@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/response"
+)
+
+// Recovery turns a handler panic into a 500 with the standard error envelope, logs it with the
+// request context (request_id, user, route) and stack, and hands it to reporter (optional).
+// Panics from a client that already went away are logged at warn level and not reported.
+func Recovery(logger *logrus.Logger, reporter helpers.ErrorReporter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			// net/http uses this to abort the response silently
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+			fields := logrus.Fields{
+				"request_id": c.GetString("request_id"),
+				"user_id":    c.GetString("userID"),
+				"method":     c.Request.Method,
+				"path":       c.Request.URL.Path,
+				"route":      c.FullPath(),
+				"ip":         c.ClientIP(),
+				"panic":      fmt.Sprint(rec),
+			}
+			if brokenConnection(rec) {
+				logger.WithFields(fields).Warn("client connection lost")
+				c.Abort()
+				return
+			}
+			logger.WithFields(fields).WithField("stack", string(debug.Stack())).Error("panic recovered")
+			if reporter != nil {
+				reporter.Report(helpers.ErrorEvent{
+					Type:      "panic",
+					Message:   fmt.Sprint(rec),
+					Frames:    helpers.CallerFrames(2),
+					RequestID: c.GetString("request_id"),
+					UserID:    c.GetString("userID"),
+					Method:    c.Request.Method,
+					URL:       c.Request.URL.Path,
+					Route:     c.FullPath(),
+					IP:        c.ClientIP(),
+					UserAgent: c.Request.UserAgent(),
+					Time:      time.Now(),
+				})
+			}
+			if c.Writer.Written() {
+				// headers are out; the client sees a truncated response
+				c.Abort()
+				return
+			}
+			response.ErrorCode[any](c, http.StatusInternalServerError, response.CodeInternal, "internal server error", nil)
+			c.Abort()
+		}()
+		c.Next()
+	}
+}
+
+// brokenConnection reports a panic caused by writing to a client that disconnected
+func brokenConnection(rec any) bool {
+	err, ok := rec.(error)
+	if !ok {
+		return false
+	}
+	var ne *net.OpError
+	if errors.As(err, &ne) {
+		var se *os.SyscallError
+		if errors.As(ne, &se) {
+			return errors.Is(se.Err, syscall.EPIPE) || errors.Is(se.Err, syscall.ECONNRESET)
+		}
+	}
+	return strings.Contains(strings.ToLower(err.Error()), "broken pipe")
+}
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+)
+
+// RequestLogger stores a logger with request_id, route and ip bound in the request context, for
+// helpers.FromContext; Auth adds user_id once the caller is known. Register it after request_id
+// and real_ip.
+func RequestLogger(logger *logrus.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		entry := logger.WithFields(logrus.Fields{
+			"request_id": c.GetString("request_id"),
+			"route":      c.Request.Method + " " + route,
+			"ip":         ipFromCtx(c),
+		})
+		c.Request = c.Request.WithContext(helpers.WithLogger(c.Request.Context(), entry))
+		c.Next()
+	}
+}
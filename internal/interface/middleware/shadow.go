@@ -0,0 +1,153 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+)
+
+// ShadowHeader marks mirrored requests, so the alternate upstream can skip side effects and metrics
+const ShadowHeader = "X-Shadow-Request"
+
+// hopHeaders are connection-scoped and never forwarded to the shadow upstream
+var hopHeaders = []string{"Connection", "Keep-Alive", "Proxy-Authorization", "Te", "Trailer", "Transfer-Encoding", "Upgrade", "Accept-Encoding"}
+
+// ShadowConfig configures Shadow
+type ShadowConfig struct {
+	Upstream    *url.URL
+	Percent     int           // share of eligible requests mirrored, 0-100
+	Routes      []string      // Gin route templates to mirror; empty = every GET/HEAD route
+	Ignore      []string      // JSON paths left out of the comparison (e.g. meta.request_id)
+	Timeout     time.Duration // per mirrored request
+	MaxInflight int           // mirrored requests in flight; beyond it samples are dropped
+	MaxBytes    int           // response bytes compared from each side
+}
+
+// Shadow mirrors a sample of read traffic (GET/HEAD) to an alternate upstream after this service
+// has answered, and logs where the two responses differ. Clients only ever see the primary
+// response; the mirror runs in the background and is dropped when MaxInflight are pending. Meant
+// for strangler-pattern migrations: point Upstream at the service taking over a set of routes.
+func Shadow(logger *logrus.Logger, cfg ShadowConfig) gin.HandlerFunc {
+	client := &http.Client{
+		Timeout: cfg.Timeout,
+		// Redirects are compared, not followed
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	slots := make(chan struct{}, max(cfg.MaxInflight, 1))
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if (c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead) || route == "" ||
+			(len(cfg.Routes) > 0 && !slices.Contains(cfg.Routes, route)) ||
+			c.GetHeader(ShadowHeader) != "" || rand.IntN(100) >= cfg.Percent {
+			c.Next()
+			return
+		}
+		w := &bodyLogWriter{ResponseWriter: c.Writer, max: cfg.MaxBytes}
+		c.Writer = w
+		start := time.Now()
+
+		c.Next()
+
+		select {
+		case slots <- struct{}{}:
+		default:
+			logger.WithField("route", route).Debug("shadow request dropped: too many in flight")
+			return
+		}
+		req := shadowRequest(c, cfg.Upstream)
+		primary := shadowResult{status: w.Status(), body: w.buf.Bytes(), truncated: w.truncated, took: time.Since(start)}
+		go func() {
+			defer func() { <-slots }()
+			compareShadow(logger, client, req, route, primary, cfg)
+		}()
+	}
+}
+
+type shadowResult struct {
+	status    int
+	body      []byte
+	truncated bool
+	took      time.Duration
+}
+
+// shadowRequest copies the client request for the upstream, detached from the request context
+func shadowRequest(c *gin.Context, upstream *url.URL) *http.Request {
+	target := *upstream
+	target.Path = singleJoiningSlash(upstream.Path, c.Request.URL.Path)
+	target.RawQuery = c.Request.URL.RawQuery
+	req, _ := http.NewRequestWithContext(context.Background(), c.Request.Method, target.String(), nil)
+	req.Header = c.Request.Header.Clone()
+	for _, h := range hopHeaders {
+		req.Header.Del(h)
+	}
+	req.Header.Set(ShadowHeader, "1")
+	if id := c.GetString("request_id"); id != "" {
+		req.Header.Set("X-Request-ID", id)
+	}
+	req.Header.Set("X-Forwarded-For", c.ClientIP())
+	return req
+}
+
+func compareShadow(logger *logrus.Logger, client *http.Client, req *http.Request, route string, primary shadowResult, cfg ShadowConfig) {
+	entry := logger.WithFields(logrus.Fields{
+		"request_id": req.Header.Get("X-Request-ID"),
+		"method":     req.Method,
+		"route":      route,
+		"path":       req.URL.Path,
+		"status":     primary.status,
+		"primary_ms": primary.took.Milliseconds(),
+	})
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		entry.WithError(err).Warn("shadow request failed")
+		return
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, int64(cfg.MaxBytes)+1))
+	truncated := len(body) > cfg.MaxBytes
+	if truncated {
+		body = body[:cfg.MaxBytes]
+	}
+	entry = entry.WithFields(logrus.Fields{"shadow_status": resp.StatusCode, "shadow_ms": time.Since(start).Milliseconds()})
+
+	var diffs []string
+	if resp.StatusCode != primary.status {
+		diffs = append(diffs, "status")
+	}
+	switch {
+	case req.Method == http.MethodHead:
+	case primary.truncated || truncated:
+		// Cut-off JSON cannot be parsed; compare the prefixes
+		if !bytes.Equal(primary.body, body) {
+			diffs = append(diffs, "body")
+		}
+	default:
+		diffs = append(diffs, helpers.JSONDiff(primary.body, body, cfg.Ignore, 20)...)
+	}
+	if len(diffs) == 0 {
+		entry.Debug("shadow response matches")
+		return
+	}
+	entry.WithField("diff", diffs).Warn("shadow response differs")
+}
+
+func singleJoiningSlash(a, b string) string {
+	switch aslash, bslash := len(a) > 0 && a[len(a)-1] == '/', len(b) > 0 && b[0] == '/'; {
+	case aslash && bslash:
+		return a + b[1:]
+	case !aslash && !bslash && b != "":
+		return a + "/" + b
+	}
+	return a + b
+}
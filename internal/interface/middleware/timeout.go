@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/response"
+)
+
+// TimeoutFunc returns a route's own timeout (0 = none) and whether it overrides the default
+type TimeoutFunc func(c *gin.Context) (time.Duration, bool)
+
+// Timeout bounds each request's context by d, or by override's value for routes that have one, so
+// Postgres and Elasticsearch calls give up with the client instead of piling up. The handler still
+// runs on the request goroutine: when the deadline has passed and it answers with a server error
+// (most likely caused by the cancelled context) or nothing at all, the client gets a 504 envelope instead.
+func Timeout(d time.Duration, override TimeoutFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit := d
+		if override != nil {
+			if od, ok := override(c); ok {
+				limit = od
+			}
+		}
+		if limit <= 0 {
+			c.Next()
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), limit)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		tw := &timeoutWriter{ResponseWriter: c.Writer, ctx: ctx}
+		c.Writer = tw
+		c.Next()
+		c.Writer = tw.ResponseWriter
+		if tw.swallowed || (!tw.Written() && errors.Is(ctx.Err(), context.DeadlineExceeded)) {
+			response.ErrorCode[any](c, http.StatusGatewayTimeout, response.CodeTimeout, "request timed out", map[string]any{"timeout_ms": limit.Milliseconds()})
+			c.Abort()
+		}
+	}
+}
+
+// timeoutWriter drops a server error written after the deadline so Timeout can answer 504 instead
+type timeoutWriter struct {
+	gin.ResponseWriter
+	ctx       context.Context
+	swallowed bool
+}
+
+func (w *timeoutWriter) swallow() bool {
+	if !w.swallowed && !w.ResponseWriter.Written() && w.Status() >= http.StatusInternalServerError &&
+		errors.Is(w.ctx.Err(), context.DeadlineExceeded) {
+		w.swallowed = true
+	}
+	return w.swallowed
+}
+
+func (w *timeoutWriter) Write(b []byte) (int, error) {
+	if w.swallow() {
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *timeoutWriter) WriteString(s string) (int, error) {
+	if w.swallow() {
+		return len(s), nil
+	}
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *timeoutWriter) WriteHeaderNow() {
+	if !w.swallow() {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+func (w *timeoutWriter) Written() bool {
+	return w.swallowed || w.ResponseWriter.Written()
+}
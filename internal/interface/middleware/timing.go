@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/response"
+)
+
+// Timing records when the request started (response meta reports duration_ms from it) and
+// observes the total handling time per route. Register it first so it covers every middleware.
+func Timing(latency *helpers.LatencyHistograms) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Set(response.StartKey, start)
+		c.Next()
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched" // 404s: keep raw paths out of the metric labels
+		}
+		latency.Observe(c.Request.Method, route, time.Since(start))
+	}
+}
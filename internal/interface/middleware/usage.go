@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UsageRecorder stores metered requests (implemented by application.UsageService)
+type UsageRecorder interface {
+	Record(ctx context.Context, subject, method, route string, bytesIn, bytesOut int64)
+}
+
+// Usage meters every matched route after it has run. The subject is user:<id> for authenticated
+// users, the internalClient value for internal callers and "anonymous" otherwise.
+func Usage(rec UsageRecorder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		route := c.FullPath()
+		if route == "" {
+			return // unmatched paths would only add cardinality
+		}
+		subject := "anonymous"
+		if uid := c.GetString("userID"); uid != "" {
+			subject = "user:" + uid
+		} else if client := c.GetString("internalClient"); client != "" {
+			subject = client
+		}
+		var in, out int64
+		if c.Request.ContentLength > 0 {
+			in = c.Request.ContentLength
+		}
+		if n := c.Writer.Size(); n > 0 {
+			out = int64(n)
+		}
+		rec.Record(c.Request.Context(), subject, c.Request.Method, route, in, out)
+	}
+}
@@ -1,88 +1,584 @@
 package router
 
 import (
+	"context"
+	"errors"
 	"expvar"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
 
+	"github.com/oksasatya/go-ddd-clean-architecture/config"
 	appuser "github.com/oksasatya/go-ddd-clean-architecture/internal/application"
 	"github.com/oksasatya/go-ddd-clean-architecture/internal/container"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
 	repouser "github.com/oksasatya/go-ddd-clean-architecture/internal/domain/repository"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/infrastructure/eventing"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/infrastructure/gcsstore"
 	pginfra "github.com/oksasatya/go-ddd-clean-architecture/internal/infrastructure/postgres"
 	handlers "github.com/oksasatya/go-ddd-clean-architecture/internal/interface/http"
 	"github.com/oksasatya/go-ddd-clean-architecture/internal/interface/middleware"
 	"github.com/oksasatya/go-ddd-clean-architecture/internal/router/modules"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/router/route"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/worker"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/events"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
 )
 
 type UserModuleDeps struct {
 	Repo    repouser.UserRepository
 	Service *appuser.Service
 	Handler *handlers.UserHandler
+	Prefs   *appuser.NotificationPreferenceService
+	Revokes *appuser.SessionRevokeService
 }
 
-func buildUserDeps() UserModuleDeps {
+// userRepository is the Postgres user repository; with USER_EVENTS_EXCHANGE set, writes also emit
+// user lifecycle events through the event bus (forwarded to RabbitMQ by forwardUserEvents)
+func userRepository() repouser.UserRepository {
 	repo := pginfra.NewUserRepository(container.GetPGPool())
+	repo.Dispatch = publishDomainEvents
+	if container.GetUserEventPub() == nil {
+		return repo
+	}
+	return eventing.NewUserRepository(repo, func(ev events.UserEvent) {
+		if !container.GetEventBus().Publish(helpers.TopicUserEvent, ev) {
+			container.GetLogger().WithField("event", ev.Type).WithField("user_id", ev.User.ID).Warn("user event dropped: event bus full or closed")
+		}
+	})
+}
+
+// publishDomainEvents hands committed aggregate events to the bus; subscribers run off the request path
+func publishDomainEvents(_ context.Context, evs []entity.DomainEvent) {
+	bus := container.GetEventBus()
+	if bus == nil {
+		return
+	}
+	for _, ev := range evs {
+		if !bus.Publish(helpers.TopicDomainEvent, ev) {
+			container.GetLogger().WithField("event", ev.EventName()).Warn("domain event dropped: event bus full or closed")
+		}
+	}
+}
+
+// logDomainEvents records every domain event at debug level
+func logDomainEvents() {
+	bus := container.GetEventBus()
+	if bus == nil {
+		return
+	}
+	bus.Subscribe(helpers.TopicDomainEvent, func(_ context.Context, payload any) {
+		if ev, ok := payload.(entity.DomainEvent); ok {
+			container.GetLogger().WithField("event", ev.EventName()).Debug("domain event")
+		}
+	})
+}
+
+// forwardUserEvents publishes bus user events to the user events exchange
+func forwardUserEvents() {
+	pub, bus := container.GetUserEventPub(), container.GetEventBus()
+	if pub == nil || bus == nil {
+		return
+	}
+	bus.Subscribe(helpers.TopicUserEvent, func(ctx context.Context, payload any) {
+		ev, ok := payload.(events.UserEvent)
+		if !ok {
+			return
+		}
+		pubCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		if err := pub.PublishUserEvent(pubCtx, ev); err != nil {
+			container.GetLogger().WithError(err).WithField("event", ev.Type).WithField("user_id", ev.User.ID).Warn("publish user event failed")
+		}
+	})
+}
+
+func buildUserDeps() UserModuleDeps {
+	repo := userRepository()
 
 	service := appuser.NewService(
 		repo,
 		container.GetJWT(),
 		container.GetGCS(),
 		container.GetConfig().GCSBucket,
-		container.GetRedis(),
+		container.GetSessionStore(),
 		container.GetLogger(),
-		container.GetES(),
-		container.GetConfig().ESUsersIndex,
+		container.GetSearch(),
+		container.GetConfig().LoginEmailVerification,
+		helpers.NewSessionPolicy(container.GetConfig()),
 	)
 
+	cfg := container.GetConfig()
+	service.Redis = container.GetRedis()
+	service.SearchDailyQuota = cfg.SearchDailyQuota
+	service.SearchCacheTTL = cfg.SearchCacheTTL
+	if s := container.GetSearch(); s != nil && s.Backend() == repouser.SearchElasticsearch {
+		service.SearchBreaker = container.GetBreakers().Get(helpers.DependencySearch)
+	}
+	service.SearchCanary = container.GetSearchCanary()
+	service.Features = container.GetFeatures()
+	service.DeviceBinding = cfg.SessionDeviceBinding
+	service.RefreshGrace = cfg.RefreshGracePeriod
+	if cfg.AvatarSignedURLs && container.GetGCS() != nil && cfg.GCSBucket != "" {
+		service.AvatarStorage = gcsstore.NewStorage(container.GetGCS(), cfg.GCSBucket)
+		service.AvatarURLTTL = cfg.AvatarSignedURLTTL
+	}
+	prefs := appuser.NewNotificationPreferenceService(pginfra.NewNotificationPreferenceRepository(container.GetPGPool()), container.GetLogger(), cfg.UnsubscribeURL, cfg.UnsubscribeSecret)
+	var anomaly *appuser.LoginAnomalyService
+	if cfg.LoginAnomalyEnabled {
+		anomaly = appuser.NewLoginAnomalyService(container.GetRedis(), container.GetGeo(), container.GetLogger(), cfg.LoginHistoryRetention)
+	}
+
 	handler := handlers.NewUserHandler(
 		service,
 		container.GetJWT(),
 		container.GetLogger(),
-		container.GetConfig().CookieDomain,
-		container.GetConfig().CookieSecure,
+		helpers.NewCookieFromConfig(container.GetConfig()),
 		container.GetRabbitPub(),
 		container.GetConfig(),
 		container.GetRedis(),
 		container.GetPGPool(),
+		container.GetGeo(),
+		prefs,
+		anomaly,
 	)
 
+	handler.Metrics = container.GetAuthMetrics()
+	revokes := appuser.NewSessionRevokeService(container.GetSessionStore(), container.GetRedis(), container.GetLogger(), cfg.SessionRevokeURL, cfg.SessionRevokeSecret, cfg.SessionRevokeTTL)
+	handler.Revokes = revokes
+	handler.Sessions = appuser.NewSessionListService(repo, container.GetSessionStore(), container.GetRedisBatch())
+
 	return UserModuleDeps{
 		Repo:    repo,
 		Service: service,
 		Handler: handler,
+		Prefs:   prefs,
+		Revokes: revokes,
 	}
 }
 
-func buildAuthHandler(repo repouser.UserRepository) *handlers.AuthHandler {
-	return handlers.NewAuthHandler(
+// elasticsearchSearch is the Elasticsearch user search, whether SEARCH_BACKEND or
+// SEARCH_CANARY_BACKEND; nil when neither uses it
+func elasticsearchSearch() repouser.SearchService {
+	for _, s := range []repouser.SearchService{container.GetSearch(), container.GetSearchCanary()} {
+		if s != nil && s.Backend() == repouser.SearchElasticsearch {
+			return s
+		}
+	}
+	return nil
+}
+
+// pwnedPasswords is the breached password check, nil when PWNED_PASSWORDS_ENABLED is off
+func pwnedPasswords(cfg *config.Config) *helpers.PwnedPasswords {
+	if cfg == nil || !cfg.PwnedPasswordsEnabled {
+		return nil
+	}
+	return helpers.NewPwnedPasswords(container.GetRedis(), cfg.PwnedPasswordsURL, cfg.PwnedPasswordsMinCount, cfg.PwnedPasswordsCacheTTL, cfg.PwnedPasswordsTimeout, cfg.PwnedPasswordsFailOpen)
+}
+
+func buildAuthHandler(repo repouser.UserRepository, audit *appuser.AuditService, revokes *appuser.SessionRevokeService) *handlers.AuthHandler {
+	h := handlers.NewAuthHandler(
 		repo,
 		container.GetRedis(),
+		container.GetSessionStore(),
 		container.GetLogger(),
 		container.GetConfig(),
 		container.GetRabbitPub(),
-		container.GetPGPool(),
+		audit,
+		container.GetGeo(),
+		revokes,
 	)
+	h.Pwned = pwnedPasswords(container.GetConfig())
+	if cfg := container.GetConfig(); cfg != nil {
+		h.Links = helpers.NewDeepLinks(cfg.DeepLinkSecret, cfg.DeepLinkRedirectOriginList())
+	}
+	return h
+}
+
+// buildAuditService records audit logs in Postgres and, with Elasticsearch, indexes them via the event bus
+func buildAuditService(r *Registry) *appuser.AuditService {
+	if container.GetPGPool() == nil {
+		return nil
+	}
+	es := container.GetES()
+	svc := appuser.NewAuditService(pginfra.NewAuditRepository(container.GetPGPool()), container.GetEventBus(), es, container.GetConfig().ESAuditIndex, container.GetLogger())
+	// Batched writes off the request path; flushed on shutdown before the event bus closes
+	if cfg := container.GetConfig(); cfg.AuditAsync {
+		svc.Writer = appuser.NewAuditWriter(svc.Repo, container.GetRedis(), container.GetEventBus(), container.GetLogger(), cfg.AuditBufferSize, cfg.AuditBatchSize, cfg.AuditFlushInterval)
+		svc.Writer.Start()
+		r.Shutdown.Register("audit writer", helpers.ShutdownFlush, helpers.CloseCtx(svc.Writer.Close))
+	}
+	if es != nil && container.GetEventBus() != nil {
+		container.GetEventBus().Subscribe(helpers.TopicAuditLogged, svc.IndexEntry)
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			if err := svc.EnsureIndex(ctx); err != nil {
+				container.GetLogger().WithError(err).Warn("audit index not ensured")
+			}
+		}()
+	}
+	return svc
+}
+
+// buildGuards wires the middleware the Registry applies to declared routes
+func buildGuards(roles *appuser.RoleService, orgs *appuser.OrganizationService, quotas *appuser.OrgQuotaService, heavy gin.HandlerFunc) Guards {
+	rdb := container.GetRedis()
+	return Guards{
+		Auth: middleware.Auth(container.GetSessionStore(), container.GetJWT(), helpers.NewSessionPolicy(container.GetConfig()), container.GetTokenAuthenticator()),
+		Role: func(role string) gin.HandlerFunc {
+			return middleware.RequireRole(roles, role)
+		},
+		Permission: func(permission string) gin.HandlerFunc {
+			return middleware.RequirePermission(roles, permission)
+		},
+		Scopes: middleware.RequireScopes,
+		Org: func(minRole string) gin.HandlerFunc {
+			return middleware.RequireOrgRole(orgs, appuser.OrgRoleAtLeast, minRole)
+		},
+		OrgQuota:    middleware.OrgQuota(quotas),
+		RateLimits:  rateLimiters(rdb, rateSpecs, middleware.AllowFromStore(container.GetRateBypass())),
+		RateSpecs:   rateSpecs,
+		Concurrency: map[string]gin.HandlerFunc{route.ConcurrencyHeavy: heavy},
+	}
+}
+
+// rateSpecs are the limits behind each rate limit class
+var rateSpecs = map[string][]RateSpec{
+	route.RateAuth:  {{Limit: 10, Window: time.Minute, Key: "ip"}},
+	route.RateUser:  {{Limit: 300, Window: time.Minute, Key: "ip"}, {Limit: 120, Window: time.Minute, Key: "user"}},
+	route.RateAdmin: {{Limit: 60, Window: time.Minute, Key: "user"}},
+}
+
+// RateLimiters builds the production rate limit classes on rdb (e.g. for handler tests)
+func RateLimiters(rdb *redis.Client) map[string][]gin.HandlerFunc {
+	return rateLimiters(rdb, rateSpecs, nil)
+}
+
+// rateLimiters builds each class's limiters; allow exempts requests from all of them
+func rateLimiters(rdb *redis.Client, specs map[string][]RateSpec, allow middleware.AllowFunc) map[string][]gin.HandlerFunc {
+	out := make(map[string][]gin.HandlerFunc, len(specs))
+	for class, list := range specs {
+		for _, s := range list {
+			key := middleware.KeyByIP()
+			if s.Key == "user" {
+				key = middleware.KeyByUserID()
+			}
+			out[class] = append(out[class], middleware.RateLimit(rdb, s.Limit, s.Window, key, allow))
+		}
+	}
+	return out
+}
+
+func heavyLimiter(cfg *config.Config) gin.HandlerFunc {
+	if cfg == nil {
+		return middleware.ConcurrencyLimit(0, 0, 0)
+	}
+	return middleware.ConcurrencyLimit(cfg.HeavyInflightRequests, cfg.InflightQueueWait, time.Second)
+}
+
+func orgQuotaDefaults(cfg *config.Config) appuser.OrgQuotaDefaults {
+	if cfg == nil {
+		return appuser.OrgQuotaDefaults{}
+	}
+	return appuser.OrgQuotaDefaults{RatePerMinute: cfg.OrgRateLimitPerMinute, RequestsPerDay: cfg.OrgRequestQuotaDaily, EmailsPerDay: cfg.OrgEmailQuotaDaily}
 }
 
 // InitModules initializes all application modules and registers them with the router registry
 // This function should be called once during application startup to wire up all modules
 func InitModules(r *Registry) {
+	forwardUserEvents()
+	logDomainEvents()
+	roleSvc := appuser.NewRoleService(pginfra.NewRoleRepository(container.GetPGPool()), pginfra.NewUserRepository(container.GetPGPool()), container.GetLogger())
+	orgRepo := pginfra.NewOrganizationRepository(container.GetPGPool())
+	inviteSvc := appuser.NewInvitationService(pginfra.NewInvitationRepository(container.GetPGPool()), userRepository(), pginfra.NewRoleRepository(container.GetPGPool()), orgRepo, container.GetLogger(), container.GetConfig().InviteTTL)
+	inviteSvc.Pwned = pwnedPasswords(container.GetConfig())
+	quotaSvc := appuser.NewOrgQuotaService(orgRepo, container.GetRedis(), container.GetLogger(), orgQuotaDefaults(container.GetConfig()))
+	orgSvc := appuser.NewOrganizationService(orgRepo, pginfra.NewUserRepository(container.GetPGPool()), inviteSvc, quotaSvc, container.GetLogger())
+	// Personal access tokens, accepted by every Auth middleware, so set up before the guards
+	var personalTokens *appuser.PersonalTokenService
+	if cfg := container.GetConfig(); cfg != nil && cfg.PersonalTokensMax > 0 {
+		personalTokens = appuser.NewPersonalTokenService(pginfra.NewPersonalTokenRepository(container.GetPGPool()), pginfra.NewUserRepository(container.GetPGPool()), container.GetLogger(), cfg.PersonalTokenTTL, cfg.PersonalTokenMaxTTL, cfg.PersonalTokensMax)
+		container.SetTokenAuthenticator(personalTokens)
+	}
+	// One limiter shared by every heavy route, declared or registered by modules
+	heavy := heavyLimiter(container.GetConfig())
+	r.Guards = buildGuards(roleSvc, orgSvc, quotaSvc, heavy)
+
+	// API usage metering (Redis counters, periodic rollup to Postgres)
+	usageSvc := appuser.NewUsageService(pginfra.NewUsageRepository(container.GetPGPool()), container.GetRedis(), container.GetLogger())
+	if cfg := container.GetConfig(); cfg != nil && cfg.UsageMeteringEnabled {
+		r.Use(middleware.Usage(usageSvc))
+		go usageSvc.Run(context.Background(), cfg.UsageRollupInterval)
+	}
+
 	userDeps := buildUserDeps()
-	r.Add(modules.New(userDeps.Handler, container.GetJWT()))
+	r.Add(modules.New(userDeps.Handler, container.GetJWT(), heavy))
 	// Email module
 	if container.GetRabbitPub() != nil {
-		emailHandler := handlers.NewEmailHandler(container.GetRabbitPub(), container.GetLogger(), container.GetConfig())
-		r.Add(modules.NewEmailModule(emailHandler, container.GetJWT()))
+		emailHandler := handlers.NewEmailHandler(container.GetRabbitPub(), container.GetLogger(), container.GetConfig(), quotaSvc)
+		emailHandler.Roles = roleSvc
+		r.AddRoutes(modules.NewEmailModule(emailHandler))
 	}
 	// Auth module
-	authHandler := buildAuthHandler(userDeps.Repo)
+	auditSvc := buildAuditService(r)
+	userDeps.Handler.Audit = auditSvc
+	userDeps.Service.Audit = auditSvc
+	authHandler := buildAuthHandler(userDeps.Repo, auditSvc, userDeps.Revokes)
 	r.Add(modules.NewAuthModule(authHandler, container.GetJWT()))
+	// Email notification preferences and unsubscribe links
+	r.AddRoutes(modules.NewNotificationModule(handlers.NewNotificationHandler(userDeps.Prefs, container.GetLogger())))
+	// 2FA backup codes, redeemed by the user handler's OTP confirm
+	if cfg := container.GetConfig(); cfg != nil && cfg.BackupCodesCount > 0 {
+		backupCodes := appuser.NewBackupCodeService(pginfra.NewBackupCodeRepository(container.GetPGPool()), container.GetLogger(), cfg.BackupCodesCount, cfg.BackupCodesWarnBelow)
+		userDeps.Handler.BackupCodes = backupCodes
+		r.AddRoutes(modules.NewBackupCodeModule(handlers.NewBackupCodeHandler(backupCodes, auditSvc, container.GetLogger())))
+	}
+	if personalTokens != nil {
+		r.AddRoutes(modules.NewPersonalTokenModule(handlers.NewPersonalTokenHandler(personalTokens, auditSvc, container.GetLogger())))
+	}
+	// Email changes held until the new address confirms them (PUT /api/profile {email})
+	if cfg := container.GetConfig(); cfg != nil && cfg.ProfileChangeApproval {
+		changes := appuser.NewProfileChangeService(userDeps.Handler.Svc, container.GetRedis(), container.GetLogger(), cfg.ProfileChangeTTL)
+		changeHandler := handlers.NewProfileChangeHandler(changes, auditSvc, container.GetRabbitPub(), cfg, container.GetLogger())
+		userDeps.Handler.ProfileChanges = changeHandler
+		r.AddRoutes(modules.NewProfileChangeModule(changeHandler))
+	}
+	// Encrypted per-user values (phone number), only with FIELD_ENCRYPTION_KEYS
+	if cfg := container.GetConfig(); cfg != nil && cfg.FieldEncryptionKeys != "" {
+		fc, err := helpers.NewFieldCipher(cfg.FieldEncryptionKeyMap(), cfg.FieldEncryptionKeyID)
+		if err != nil {
+			container.GetLogger().WithError(err).Fatal("invalid FIELD_ENCRYPTION_KEYS")
+		}
+		secrets := appuser.NewUserSecretService(pginfra.NewUserSecretRepository(container.GetPGPool()), fc, container.GetLogger())
+		r.AddRoutes(modules.NewPhoneModule(handlers.NewPhoneHandler(secrets, auditSvc, container.GetLogger())))
+	}
+	// Provider identities linked to local accounts (OIDC/SAML)
+	identitySvc := appuser.NewIdentityService(pginfra.NewIdentityRepository(container.GetPGPool()), userDeps.Repo, container.GetLogger(), container.GetConfig().IdentityAutoLink)
+	r.AddRoutes(modules.NewIdentityModule(handlers.NewIdentityHandler(identitySvc, container.GetLogger())))
+	// OIDC login against an external IdP (only when configured)
+	if cfg := container.GetConfig(); cfg != nil && cfg.OIDCIssuer != "" {
+		provider := helpers.NewOIDCProvider(helpers.OIDCConfig{
+			Issuer:       cfg.OIDCIssuer,
+			ClientID:     cfg.OIDCClientID,
+			ClientSecret: cfg.OIDCClientSecret,
+			RedirectURL:  cfg.OIDCRedirectURL,
+			Scopes:       strings.Fields(cfg.OIDCScopes),
+		})
+		oidcSvc := appuser.NewOIDCService(provider, identitySvc, pginfra.NewRoleRepository(container.GetPGPool()), container.GetRedis(), container.GetLogger(), cfg.OIDCGroupsClaim, cfg.OIDCGroupRoleMap())
+		h := handlers.NewOIDCHandler(oidcSvc, userDeps.Service, roleSvc, helpers.NewCookieFromConfig(cfg), container.GetLogger(), cfg.OIDCPostLoginRedirect)
+		r.Add(modules.NewOIDCModule(h, container.GetJWT()))
+	}
+	// SAML 2.0 SSO (only when configured)
+	if cfg := container.GetConfig(); cfg != nil && cfg.SAMLEnabled() {
+		provider := helpers.NewSAMLProvider(helpers.SAMLConfig{
+			EntityID:        cfg.SAMLSPEntityID,
+			ACSURL:          cfg.SAMLACSURL,
+			IDPMetadataURL:  cfg.SAMLIDPMetadataURL,
+			IDPMetadataFile: cfg.SAMLIDPMetadataFile,
+		})
+		attrs := appuser.SAMLAttributeMap{Email: cfg.SAMLAttrEmail, Name: cfg.SAMLAttrName, Groups: cfg.SAMLAttrGroups}
+		samlSvc := appuser.NewSAMLService(provider, identitySvc, pginfra.NewRoleRepository(container.GetPGPool()), container.GetRedis(), container.GetLogger(), attrs, cfg.SAMLGroupRoleMap(), cfg.SAMLAllowIDPInitiated)
+		h := handlers.NewSAMLHandler(samlSvc, userDeps.Service, roleSvc, helpers.NewCookieFromConfig(cfg), container.GetLogger(), cfg.SAMLPostLoginRedirect)
+		r.Add(modules.NewSAMLModule(h, container.GetJWT()))
+	}
+	// Token introspection for internal services (only when callers are configured)
+	if cfg := container.GetConfig(); cfg != nil && (len(cfg.IntrospectionKeys()) > 0 || len(cfg.IntrospectionCNs()) > 0) {
+		h := handlers.NewIntrospectHandler(container.GetJWT(), container.GetSessionStore(), container.GetLogger())
+		r.Add(modules.NewIntrospectModule(h, cfg.IntrospectionKeys(), cfg.IntrospectionCNs()))
+	}
+	// Role/permission management (admin only)
+	r.AddRoutes(modules.NewRoleModule(handlers.NewRoleHandler(roleSvc, container.GetLogger())))
+	// First-run admin bootstrap (public, token protected, closed once an admin exists)
+	setupSvc := appuser.NewSetupService(userDeps.Repo, pginfra.NewRoleRepository(container.GetPGPool()), container.GetRedis(), container.GetLogger(), container.GetConfig().SetupToken)
+	setupSvc.Pwned = pwnedPasswords(container.GetConfig())
+	if err := prepareSetup(setupSvc); err != nil {
+		container.GetLogger().WithError(err).Warn("admin setup check failed")
+	}
+	r.AddRoutes(modules.NewSetupModule(handlers.NewSetupHandler(setupSvc, auditSvc, container.GetLogger())))
+	// Invitations (admin issue/list/revoke, public accept)
+	r.AddRoutes(modules.NewInvitationModule(handlers.NewInvitationHandler(inviteSvc, container.GetRabbitPub(), container.GetConfig(), container.GetLogger())))
+	// Maintenance jobs external schedulers trigger through /internal/jobs (registered below)
+	jobs := appuser.NewJobService(container.GetRedis(), container.GetLogger(), container.GetConfig().JobsTimeout)
+	// Users search index: outbox sync (ensures the aliases, then replays from its checkpoint) and
+	// admin reindex (only when SEARCH_BACKEND or SEARCH_CANARY_BACKEND is elasticsearch)
+	var indexSync *appuser.UserIndexSync
+	if es, s := container.GetES(), elasticsearchSearch(); es != nil && s != nil {
+		cfg := container.GetConfig()
+		idx := appuser.NewUserIndexService(userDeps.Repo, es, container.GetRedis(), container.GetLogger(), cfg.ESUsersIndex, cfg.ESUsersWriteAlias)
+		indexSync = appuser.NewUserIndexSync(pginfra.NewUserEventRepository(container.GetPGPool()), userDeps.Repo, idx, s, container.GetRedis(), container.GetLogger(), cfg.UserSearchSyncInterval)
+		indexSync.Start()
+		r.OnShutdown("user index sync", indexSync.Close)
+		r.AddRoutes(modules.NewSearchAdminModule(handlers.NewSearchAdminHandler(idx, container.GetLogger())))
+		jobs.Register(appuser.Job{Name: "users_reindex", Description: "rebuild the users search index (started in the background)", Run: func(ctx context.Context) (any, error) {
+			err := idx.StartReindex(ctx)
+			if errors.Is(err, appuser.ErrReindexRunning) {
+				return nil, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			return map[string]any{"state": "running"}, nil
+		}})
+	}
+	// Background exports (users, audit logs): queued in export_jobs and run by a worker on every
+	// instance, which writes the CSV to GCS_BUCKET (without GCS, requests answer 503)
+	var auditExport *appuser.AuditExportService
+	if auditSvc != nil {
+		auditExport = appuser.NewAuditExportService(auditSvc.Repo, int64(container.GetConfig().AuditExportMaxRows))
+	}
+	exports := appuser.NewExportService(pginfra.NewExportJobRepository(container.GetPGPool()), userDeps.Repo, auditExport, container.GetGCS(), container.GetConfig().GCSBucket, container.GetLogger(), container.GetConfig().ExportPollInterval)
+	if exports.CanRun() {
+		exports.Start()
+		r.OnShutdown("export worker", exports.Close)
+		container.SetExportRunner(exports)
+		// With the exports queue consumed, a new job is announced there so it starts at once
+		if cfg := container.GetConfig(); slices.Contains(cfg.WorkerQueueList(), helpers.WorkerQueueExports) && container.GetRabbitPub() != nil {
+			pub := container.GetRabbitPub()
+			exports.Notify = func(ctx context.Context, jobID string) error {
+				return pub.PublishToQueue(ctx, cfg.RabbitMQExportQueue, worker.ExportMessageType, map[string]string{"job_id": jobID})
+			}
+		}
+	}
+	r.AddRoutes(modules.NewExportModule(handlers.NewExportHandler(exports, container.GetLogger())))
+	// Admin broadcast emails: queued in email_broadcasts and enqueued by a worker on every instance
+	// with the email queue (without it, or with MAIL_SEND_ENABLED=false, requests answer 503)
+	var broadcastPub appuser.EmailPublisher
+	if cfg := container.GetConfig(); cfg.MailSendEnabled && container.GetRabbitPub() != nil {
+		broadcastPub = container.GetRabbitPub()
+	}
+	broadcasts := appuser.NewBroadcastService(pginfra.NewEmailBroadcastRepository(container.GetPGPool()), pginfra.NewRoleRepository(container.GetPGPool()), orgRepo, userDeps.Prefs, broadcastPub, container.GetLogger(), container.GetConfig().BroadcastBatchSize, container.GetConfig().BroadcastRate, container.GetConfig().ExportPollInterval)
+	broadcasts.Senders = container.GetConfig().MailFromAllowList()
+	if broadcasts.CanRun() {
+		broadcasts.Start()
+		r.OnShutdown("broadcast worker", broadcasts.Close)
+	}
+	r.AddRoutes(modules.NewBroadcastModule(handlers.NewBroadcastHandler(broadcasts, container.GetLogger())))
+	// Weekly activity digest emails (one instance per pass; needs the audit log and the email queue)
+	if cfg := container.GetConfig(); cfg != nil && cfg.DigestEnabled && cfg.MailSendEnabled && auditSvc != nil && container.GetRabbitPub() != nil && container.GetRedis() != nil {
+		digests := appuser.NewDigestService(userDeps.Repo, auditSvc.Repo, pginfra.NewUserEventRepository(container.GetPGPool()), userDeps.Prefs, container.GetRabbitPub(), container.GetRedis(), cfg, container.GetLogger())
+		digests.Start()
+		r.OnShutdown("activity digest", digests.Close)
+		jobs.Register(appuser.Job{Name: "activity_digests", Description: "enqueue the weekly activity digests that are due", Run: func(ctx context.Context) (any, error) {
+			rep, err := digests.RunOnce(ctx, time.Now())
+			if rep == nil {
+				return nil, err
+			}
+			return rep, err
+		}})
+	}
+	// Mailgun status webhooks recorded in email_events and, with EMAIL_EVENTS_WEBHOOK_URL, forwarded
+	// to product services as signed webhooks by a poller on every instance
+	if cfg := container.GetConfig(); cfg != nil && cfg.MailgunWebhookSigningKey != "" && r.Enabled(config.ModuleEmail) {
+		emailEvents := appuser.NewEmailEventService(pginfra.NewEmailEventRepository(container.GetPGPool()), cfg.EmailEventsWebhookURL, cfg.EmailEventsWebhookSecret, cfg.EmailEventsWebhookMaxAttempts, cfg.EmailEventsWebhookTimeout, cfg.EmailEventsPollInterval, container.GetLogger())
+		if emailEvents.Forwarding() {
+			emailEvents.Start()
+			r.OnShutdown("email event forwarder", emailEvents.Close)
+		}
+		r.AddRoutes(modules.NewEmailEventModule(handlers.NewEmailEventHandler(emailEvents, cfg.MailgunWebhookSigningKey, container.GetLogger())))
+	}
+	// Audit log search (admin only; 503 without Elasticsearch) and CSV export
+	if auditSvc != nil {
+		r.AddRoutes(modules.NewAuditModule(handlers.NewAuditHandler(auditSvc, auditExport, exports, container.GetLogger())))
+		// Auth flow lookup by correlation id: audit entries plus the login/email trail
+		r.AddRoutes(modules.NewCorrelationModule(handlers.NewCorrelationHandler(appuser.NewCorrelationService(auditSvc.Repo, pginfra.NewEmailEventRepository(container.GetPGPool()), container.GetRedis()), container.GetLogger())))
+	}
+	// Per-user change history and replay (admin only)
+	historySvc := appuser.NewUserHistoryService(pginfra.NewUserEventRepository(container.GetPGPool()))
+	historySvc.Users = userDeps.Repo
+	r.AddRoutes(modules.NewUserHistoryModule(handlers.NewUserHistoryHandler(historySvc, container.GetLogger())))
+	// Account suspension and bans (admin only)
+	statusSvc := appuser.NewAccountStatusService(userDeps.Repo, container.GetSessionStore(), container.GetRedis(), container.GetLogger())
+	r.AddRoutes(modules.NewAccountStatusModule(handlers.NewAccountStatusHandler(statusSvc, auditSvc, container.GetRabbitPub(), container.GetConfig(), container.GetLogger())))
+	// Sessions of many users with their trusted-device status, batched reads (admin only)
+	r.AddRoutes(modules.NewSessionListModule(handlers.NewSessionListHandler(userDeps.Handler.Sessions, container.GetLogger())))
+	// API usage report (admin only)
+	r.AddRoutes(modules.NewUsageModule(handlers.NewUsageHandler(usageSvc, container.GetLogger())))
+	// Runtime CORS allowlist (admin only)
+	if origins := container.GetCORSOrigins(); origins != nil {
+		r.AddRoutes(modules.NewCORSAdminModule(handlers.NewCORSAdminHandler(origins, container.GetLogger())))
+	}
+	// Runtime rate limit bypass entries (admin only)
+	if bypass := container.GetRateBypass(); bypass != nil {
+		r.AddRoutes(modules.NewRateBypassModule(handlers.NewRateBypassHandler(bypass, container.GetLogger())))
+	}
+	// Route listing with guards and rate limits (admin only)
+	r.AddRoutes(modules.NewRoutesModule(handlers.NewRoutesHandler(r.Routes)))
+	// Effective configuration, modules and versions from startup (admin only)
+	r.AddRoutes(modules.NewConfigModule(handlers.NewConfigHandler(container.GetStartup)))
+	if cfg := container.GetConfig(); cfg != nil && cfg.StatusPageEnabled {
+		r.AddRoutes(modules.NewStatusModule(handlers.NewStatusHandler(container.GetDependencies())))
+	}
+	// Organizations and membership
+	r.AddRoutes(modules.NewOrgModule(handlers.NewOrgHandler(orgSvc, quotaSvc, container.GetRabbitPub(), container.GetConfig(), container.GetLogger())))
+	// Dev module: captured emails listing when the file mail driver is active (never in production)
+	if cfg := container.GetConfig(); cfg != nil && cfg.Env != "production" && strings.EqualFold(cfg.MailDriver, "file") {
+		r.Add(modules.NewDevModule(handlers.NewDevHandler(cfg)))
+	}
+	// Readiness (always) and pool metrics (behind flag) at the root level
+	var queues *helpers.QueueProbe
+	if pub, cfg := container.GetRabbitPub(), container.GetConfig(); pub != nil && cfg != nil {
+		queues = helpers.EmailQueueProbe(cfg, pub.Channel)
+	}
+	health := handlers.NewHealthHandler(container.GetPGPool(), container.GetRedis(), container.GetRabbitPub(), container.GetDrain(), queues, container.GetLatency())
+	if auditSvc != nil {
+		health.Audit = auditSvc.Writer
+	}
+	health.Search = indexSync
+	health.Deps = container.GetDependencies()
+	health.Auth = container.GetAuthMetrics()
+	if b := container.GetRedisBatch(); b != nil {
+		health.Batches = b.Metrics
+	}
+	// Redis key hygiene sweep (one instance at a time): every KEY_HYGIENE_INTERVAL and as a job
+	if cfg := container.GetConfig(); cfg != nil && container.GetRedis() != nil {
+		hygiene := appuser.NewKeyHygieneService(pginfra.NewUserRepository(container.GetPGPool()), container.GetRedis(), container.GetLogger())
+		health.Hygiene = hygiene
+		if cfg.KeyHygieneInterval > 0 {
+			go hygiene.Run(context.Background(), cfg.KeyHygieneInterval)
+		}
+		jobs.Register(appuser.Job{Name: "key_hygiene", Description: "delete Redis session, device and OTP keys left behind", Run: func(ctx context.Context) (any, error) {
+			rep, err := hygiene.Sweep(ctx, jobs.Timeout)
+			if rep == nil {
+				return nil, err
+			}
+			return rep, err
+		}})
+	}
+	r.Engine.GET("/readyz", health.Readyz)
+	// Drain trigger for deploy tooling, restricted to internal clients (same callers as introspection)
+	if cfg := container.GetConfig(); cfg != nil && (len(cfg.IntrospectionKeys()) > 0 || len(cfg.IntrospectionCNs()) > 0) {
+		r.Engine.POST("/internal/drain", middleware.InternalClient(cfg.IntrospectionKeys(), cfg.IntrospectionCNs()), health.StartDrain)
+	}
+	// Jobs API for Cloud Scheduler, Cloud Tasks or cron (X-Job-Token or a service account OIDC token)
+	if cfg := container.GetConfig(); cfg != nil && cfg.JobsEnabled() {
+		var provider *helpers.OIDCProvider
+		if cfg.JobsOIDCAudience != "" {
+			provider = helpers.NewOIDCProvider(helpers.OIDCConfig{Issuer: cfg.JobsOIDCIssuer})
+		}
+		auth := middleware.JobAuth(cfg.JobsSecret, provider, cfg.JobsOIDCAudience, cfg.JobsOIDCEmailList())
+		jh := handlers.NewJobHandler(jobs, container.GetLogger())
+		r.Engine.GET("/internal/jobs", auth, jh.List)
+		r.Engine.POST("/internal/jobs/:name", auth, jh.Run)
+	}
+	if cfg := container.GetConfig(); cfg != nil && cfg.MetricsEnabled {
+		rl := middleware.RateLimit(container.GetRedis(), 120, time.Minute, middleware.KeyByIP(), nil)
+		r.Engine.GET("/metrics", rl, health.Metrics)
+	}
 	// Debug module (under /api) behind feature flag ONLY when explicitly enabled
-	if cfg := container.GetConfig(); cfg != nil && cfg.DebugMetricsEnabled {
+	if cfg := container.GetConfig(); cfg != nil && cfg.DebugMetricsEnabled && r.Enabled(config.ModuleDebug) {
 		r.Add(modules.NewDebugModule())
 		// Root-level alias for expvar metrics
 		rl := middleware.RateLimit(container.GetRedis(), 120, time.Minute, middleware.KeyByIP(), nil)
 		r.Engine.GET("/debug/vars", rl, gin.WrapH(expvar.Handler()))
 	}
 }
+
+func prepareSetup(svc *appuser.SetupService) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return svc.Prepare(ctx)
+}
@@ -0,0 +1,187 @@
+package router
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/router/route"
+)
+
+// RateSpec is one limiter of a rate limit class, kept alongside Guards.RateLimits for listings
+type RateSpec struct {
+	Limit  int
+	Window time.Duration
+	Key    string // ip or user
+}
+
+func (s RateSpec) String() string { return fmt.Sprintf("%d per %s by %s", s.Limit, s.Window, s.Key) }
+
+// Routes lists every route on the engine, sorted by path then method. Declared routes carry their
+// guards; routes a Module registers by hand are attributed to it; the rest belong to "engine".
+func (r *Registry) Routes() []route.Info {
+	api := handlerNames(r.API.Handlers)
+	engine := handlerNames(r.Engine.Handlers)
+	out := make([]route.Info, 0, len(r.Engine.Routes()))
+	for _, ri := range r.Engine.Routes() {
+		key := ri.Method + " " + ri.Path
+		if info, ok := r.declared[key]; ok {
+			info.Middleware = append(append([]string{}, api...), info.Middleware...)
+			out = append(out, info)
+			continue
+		}
+		info := route.Info{Method: ri.Method, Path: ri.Path, Module: "engine", Handler: funcName(ri.HandlerFunc), Auth: "custom", Middleware: engine}
+		if mod, ok := r.owners[key]; ok {
+			info.Module, info.Middleware = mod, api
+		}
+		out = append(out, info)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Path != out[j].Path {
+			return out[i].Path < out[j].Path
+		}
+		return out[i].Method < out[j].Method
+	})
+	return out
+}
+
+// Modules lists the registered modules by name, sorted; modules of disabled subsystems are left out
+func (r *Registry) Modules() []string {
+	seen := map[string]bool{}
+	out := make([]string, 0, len(r.modules)+len(r.routes))
+	for _, m := range r.modules {
+		seen[moduleName(m)] = true
+	}
+	for _, m := range r.routes {
+		seen[moduleName(m)] = true
+	}
+	for name := range seen {
+		if r.skipped[name] {
+			continue
+		}
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// SkippedModules lists, sorted, the modules RegisterAll left out because their subsystem is disabled
+func (r *Registry) SkippedModules() []string {
+	out := make([]string, 0, len(r.skipped))
+	for name := range r.skipped {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// routeKeys snapshots the engine's routes so Register calls can be attributed to a Module
+func (r *Registry) routeKeys() map[string]bool {
+	keys := map[string]bool{}
+	for _, ri := range r.Engine.Routes() {
+		keys[ri.Method+" "+ri.Path] = true
+	}
+	return keys
+}
+
+func (r *Registry) record(module string, rt route.Route, guards []string) {
+	info := route.Info{
+		Method:      rt.Method,
+		Path:        joinPath(r.API.BasePath(), rt.Path),
+		Module:      module,
+		Handler:     routeHandlerName(rt),
+		Auth:        "jwt",
+		Role:        rt.Role,
+		Permission:  rt.Permission,
+		Scopes:      rt.Scopes,
+		OrgRole:     rt.OrgRole,
+		RateLimit:   rt.RateLimit,
+		Concurrency: rt.Concurrency,
+		Timeout:     rt.Timeout,
+		Middleware:  guards,
+		Request:     route.SchemaOf(rt.Request),
+		Response:    route.SchemaOf(rt.Response),
+	}
+	if rt.Public {
+		info.Auth = "public"
+	}
+	if d := rt.Deprecation; d != nil {
+		info.Deprecated = true
+		if !d.Sunset.IsZero() {
+			info.Sunset = d.Sunset.UTC().Format(time.RFC3339)
+		}
+	}
+	for _, s := range r.Guards.RateSpecs[rt.RateLimit] {
+		info.RateLimits = append(info.RateLimits, s.String())
+	}
+	if r.declared == nil {
+		r.declared = map[string]route.Info{}
+	}
+	r.declared[info.Method+" "+info.Path] = info
+}
+
+// TimeoutClass returns the timeout class declared for the route gin matched (method and c.FullPath())
+func (r *Registry) TimeoutClass(method, fullPath string) string {
+	return r.declared[method+" "+fullPath].Timeout
+}
+
+func moduleName(m any) string {
+	t := reflect.TypeOf(m)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Name()
+}
+
+func handlerNames(hs gin.HandlersChain) []string {
+	out := make([]string, 0, len(hs))
+	for _, h := range hs {
+		out = append(out, funcName(h))
+	}
+	return out
+}
+
+// routeHandlerName names the handler a Route declares, not the httpx adapter around it
+func routeHandlerName(rt route.Route) string {
+	if rt.Handler == nil && rt.Func != nil {
+		return funcName(rt.Func)
+	}
+	return funcName(rt.Handler)
+}
+
+// funcName shortens a handler's symbol: .../middleware.Timing.func1 -> middleware.Timing,
+// .../http.(*UserHandler).Login-fm -> http.(*UserHandler).Login
+func funcName(h any) string {
+	v := reflect.ValueOf(h)
+	if !v.IsValid() || v.IsNil() {
+		return ""
+	}
+	fn := runtime.FuncForPC(v.Pointer())
+	if fn == nil {
+		return "?"
+	}
+	name := strings.TrimSuffix(fn.Name(), "-fm")
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	for {
+		i := strings.LastIndex(name, ".func")
+		if i < 0 || strings.ContainsAny(name[i+len(".func"):], "(.") {
+			break
+		}
+		name = name[:i]
+	}
+	return name
+}
+
+func joinPath(base, p string) string {
+	if p == "" {
+		return base
+	}
+	return strings.TrimRight(base, "/") + "/" + strings.TrimLeft(p, "/")
+}
@@ -1,8 +1,24 @@
 package router
 
-import "github.com/gin-gonic/gin"
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/router/route"
+)
 
 // Module describes a feature module that can register its routes on a RouterGroup
 type Module interface {
 	Register(rg *gin.RouterGroup)
 }
+
+// RouteModule declares its routes with metadata instead of wiring middleware by hand;
+// the Registry applies auth, rate limit and role/permission guards centrally
+type RouteModule interface {
+	Routes() []route.Route
+}
+
+// SubsystemModule is implemented by modules that belong to a subsystem MODULES_DISABLED can turn
+// off (config.ModuleEmail, ...); the Registry skips them while it is disabled
+type SubsystemModule interface {
+	Subsystem() string
+}
@@ -0,0 +1,28 @@
+package modules
+
+import (
+	"net/http"
+
+	handlers "github.com/oksasatya/go-ddd-clean-architecture/internal/interface/http"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/router/route"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+)
+
+// AccountStatusModule exposes suspending, banning and signing out users under /admin (admin only)
+type AccountStatusModule struct {
+	Handler *handlers.AccountStatusHandler
+}
+
+func NewAccountStatusModule(h *handlers.AccountStatusHandler) *AccountStatusModule {
+	return &AccountStatusModule{Handler: h}
+}
+
+func (m *AccountStatusModule) Routes() []route.Route {
+	return []route.Route{
+		{Method: http.MethodPost, Path: "/admin/users/:id/suspend", Handler: m.Handler.Suspend, Role: AdminRole, Scopes: []string{helpers.ScopeWrite}, RateLimit: route.RateAdmin, Request: handlers.AccountStatusRequest{}},
+		{Method: http.MethodPost, Path: "/admin/users/:id/unsuspend", Handler: m.Handler.Unsuspend, Role: AdminRole, Scopes: []string{helpers.ScopeWrite}, RateLimit: route.RateAdmin},
+		{Method: http.MethodPost, Path: "/admin/users/:id/ban", Handler: m.Handler.Ban, Role: AdminRole, Scopes: []string{helpers.ScopeWrite}, RateLimit: route.RateAdmin, Request: handlers.AccountStatusRequest{}},
+		{Method: http.MethodPost, Path: "/admin/users/:id/unban", Handler: m.Handler.Unban, Role: AdminRole, Scopes: []string{helpers.ScopeWrite}, RateLimit: route.RateAdmin},
+		{Method: http.MethodPost, Path: "/admin/users/:id/revoke-sessions", Handler: m.Handler.RevokeSessions, Role: AdminRole, Scopes: []string{helpers.ScopeWrite}, RateLimit: route.RateAdmin},
+	}
+}
@@ -0,0 +1,26 @@
+package modules
+
+import (
+	"net/http"
+
+	handlers "github.com/oksasatya/go-ddd-clean-architecture/internal/interface/http"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/router/route"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+)
+
+// AuditModule exposes audit log search and CSV export under /admin (admin only)
+type AuditModule struct {
+	Handler *handlers.AuditHandler
+}
+
+func NewAuditModule(h *handlers.AuditHandler) *AuditModule {
+	return &AuditModule{Handler: h}
+}
+
+func (m *AuditModule) Routes() []route.Route {
+	return []route.Route{
+		{Method: http.MethodGet, Path: "/admin/audit-logs/search", Handler: m.Handler.Search, Role: AdminRole, Scopes: []string{helpers.ScopeRead}, RateLimit: route.RateAdmin},
+		{Method: http.MethodGet, Path: "/admin/audit-logs/export", Handler: m.Handler.Export, Role: AdminRole, Scopes: []string{helpers.ScopeRead}, RateLimit: route.RateAdmin, Concurrency: route.ConcurrencyHeavy, Timeout: route.TimeoutLong},
+		{Method: http.MethodGet, Path: "/admin/audit-logs/export/:id", Handler: m.Handler.ExportJob, Role: AdminRole, Scopes: []string{helpers.ScopeRead}, RateLimit: route.RateAdmin},
+	}
+}
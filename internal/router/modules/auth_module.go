@@ -25,16 +25,22 @@ func (m *AuthModule) Register(rg *gin.RouterGroup) {
 	verifyConfirmLimiter := middleware.RateLimit(container.GetRedis(), 30, time.Minute, middleware.KeyByIPAndPath(), nil)
 	resetInitLimiter := middleware.RateLimit(container.GetRedis(), 5, time.Minute, middleware.KeyByIPAndPath(), nil)
 	resetConfirmLimiter := middleware.RateLimit(container.GetRedis(), 30, time.Minute, middleware.KeyByIPAndPath(), nil)
+	verifyResendLimiter := middleware.RateLimit(container.GetRedis(), 5, time.Minute, middleware.KeyByIPAndPath(), nil)
 
 	rg.POST("/auth/verify/confirm", verifyConfirmLimiter, m.Handler.VerifyConfirm)
-	rg.POST("/auth/reset/init", resetInitLimiter, m.Handler.ResetInit)
+	// Resend: a signed-in user, or the one-time token from a login blocked on verification
+	optionalAuth := middleware.OptionalAuth(container.GetSessionStore(), m.JWT, helpers.NewSessionPolicy(container.GetConfig()), container.GetTokenAuthenticator())
+	rg.POST("/auth/verify/resend", verifyResendLimiter, optionalAuth, m.Handler.VerifyResend)
+	rg.POST("/auth/reset/init", resetInitLimiter, accountGuard("reset", false), m.Handler.ResetInit)
+	rg.GET("/auth/reset/validate", resetConfirmLimiter, m.Handler.ResetValidate)
 	rg.POST("/auth/reset/confirm", resetConfirmLimiter, m.Handler.ResetConfirm)
+	rg.POST("/auth/sessions/revoke", resetConfirmLimiter, m.Handler.SessionRevoke)
 
 	// Protected verify init with user-based rate limit
 	auth := rg.Group("/")
-	auth.Use(middleware.Auth(container.GetRedis(), m.JWT))
+	auth.Use(middleware.Auth(container.GetSessionStore(), m.JWT, helpers.NewSessionPolicy(container.GetConfig()), container.GetTokenAuthenticator()))
 	auth.Use(middleware.RateLimit(container.GetRedis(), 5, time.Minute, middleware.KeyByUserID(), nil))
 	{
-		auth.POST("/auth/verify/init", m.Handler.VerifyInit)
+		auth.POST("/auth/verify/init", middleware.RequireScopes(helpers.ScopeWrite), m.Handler.VerifyInit)
 	}
 }
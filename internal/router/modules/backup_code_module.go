@@ -0,0 +1,26 @@
+package modules
+
+import (
+	"net/http"
+
+	handlers "github.com/oksasatya/go-ddd-clean-architecture/internal/interface/http"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/router/route"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+)
+
+// BackupCodeModule exposes the signed-in user's 2FA recovery codes; they are redeemed at
+// POST /api/login/otp/confirm in place of the emailed code
+type BackupCodeModule struct {
+	Handler *handlers.BackupCodeHandler
+}
+
+func NewBackupCodeModule(h *handlers.BackupCodeHandler) *BackupCodeModule {
+	return &BackupCodeModule{Handler: h}
+}
+
+func (m *BackupCodeModule) Routes() []route.Route {
+	return []route.Route{
+		{Method: http.MethodGet, Path: "/auth/backup-codes", Handler: m.Handler.Status, Scopes: []string{helpers.ScopeRead}, RateLimit: route.RateUser},
+		{Method: http.MethodPost, Path: "/auth/backup-codes", Handler: m.Handler.Regenerate, Scopes: []string{helpers.ScopeWrite}, RateLimit: route.RateUser},
+	}
+}
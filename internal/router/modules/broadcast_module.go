@@ -0,0 +1,30 @@
+package modules
+
+import (
+	"net/http"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/config"
+	handlers "github.com/oksasatya/go-ddd-clean-architecture/internal/interface/http"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/router/route"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+)
+
+// BroadcastModule exposes admin broadcast emails under /admin (admin only)
+type BroadcastModule struct {
+	Handler *handlers.BroadcastHandler
+}
+
+func NewBroadcastModule(h *handlers.BroadcastHandler) *BroadcastModule {
+	return &BroadcastModule{Handler: h}
+}
+
+func (m *BroadcastModule) Routes() []route.Route {
+	return []route.Route{
+		{Method: http.MethodPost, Path: "/admin/emails/broadcast", Handler: m.Handler.Create, Role: AdminRole, Scopes: []string{helpers.ScopeWrite}, RateLimit: route.RateAdmin, Request: handlers.BroadcastRequest{}},
+		{Method: http.MethodGet, Path: "/admin/emails/broadcast/:id", Handler: m.Handler.Get, Role: AdminRole, Scopes: []string{helpers.ScopeRead}, RateLimit: route.RateAdmin},
+		{Method: http.MethodPost, Path: "/admin/emails/broadcast/:id/cancel", Handler: m.Handler.Cancel, Role: AdminRole, Scopes: []string{helpers.ScopeWrite}, RateLimit: route.RateAdmin},
+	}
+}
+
+// Subsystem puts the module under MODULES_DISABLED=email
+func (m *BroadcastModule) Subsystem() string { return config.ModuleEmail }
@@ -0,0 +1,24 @@
+package modules
+
+import (
+	"net/http"
+
+	handlers "github.com/oksasatya/go-ddd-clean-architecture/internal/interface/http"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/router/route"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+)
+
+// ConfigModule exposes the effective configuration under /admin (admin only)
+type ConfigModule struct {
+	Handler *handlers.ConfigHandler
+}
+
+func NewConfigModule(h *handlers.ConfigHandler) *ConfigModule {
+	return &ConfigModule{Handler: h}
+}
+
+func (m *ConfigModule) Routes() []route.Route {
+	return []route.Route{
+		{Method: http.MethodGet, Path: "/admin/config", Handler: m.Handler.Get, Role: AdminRole, Scopes: []string{helpers.ScopeRead}, RateLimit: route.RateAdmin, Response: helpers.StartupReport{}},
+	}
+}
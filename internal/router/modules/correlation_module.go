@@ -0,0 +1,24 @@
+package modules
+
+import (
+	"net/http"
+
+	handlers "github.com/oksasatya/go-ddd-clean-architecture/internal/interface/http"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/router/route"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+)
+
+// CorrelationModule exposes the auth flow lookup under /admin (admin only)
+type CorrelationModule struct {
+	Handler *handlers.CorrelationHandler
+}
+
+func NewCorrelationModule(h *handlers.CorrelationHandler) *CorrelationModule {
+	return &CorrelationModule{Handler: h}
+}
+
+func (m *CorrelationModule) Routes() []route.Route {
+	return []route.Route{
+		{Method: http.MethodGet, Path: "/admin/correlations/:id", Func: m.Handler.Get, Role: AdminRole, Scopes: []string{helpers.ScopeRead}, RateLimit: route.RateAdmin},
+	}
+}
@@ -0,0 +1,26 @@
+package modules
+
+import (
+	"net/http"
+
+	handlers "github.com/oksasatya/go-ddd-clean-architecture/internal/interface/http"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/router/route"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+)
+
+// CORSAdminModule manages the runtime CORS allowlist under /admin (admin only)
+type CORSAdminModule struct {
+	Handler *handlers.CORSAdminHandler
+}
+
+func NewCORSAdminModule(h *handlers.CORSAdminHandler) *CORSAdminModule {
+	return &CORSAdminModule{Handler: h}
+}
+
+func (m *CORSAdminModule) Routes() []route.Route {
+	return []route.Route{
+		{Method: http.MethodGet, Path: "/admin/cors/origins", Handler: m.Handler.List, Role: AdminRole, Scopes: []string{helpers.ScopeRead}, RateLimit: route.RateAdmin},
+		{Method: http.MethodPost, Path: "/admin/cors/origins", Handler: m.Handler.Add, Role: AdminRole, Scopes: []string{helpers.ScopeWrite}, RateLimit: route.RateAdmin},
+		{Method: http.MethodDelete, Path: "/admin/cors/origins", Handler: m.Handler.Remove, Role: AdminRole, Scopes: []string{helpers.ScopeWrite}, RateLimit: route.RateAdmin},
+	}
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/oksasatya/go-ddd-clean-architecture/config"
 	"github.com/oksasatya/go-ddd-clean-architecture/internal/container"
 	"github.com/oksasatya/go-ddd-clean-architecture/internal/interface/middleware"
 )
@@ -19,3 +20,6 @@ func (m *DebugModule) Register(rg *gin.RouterGroup) {
 	rl := middleware.RateLimit(container.GetRedis(), 120, time.Minute, middleware.KeyByIP(), nil)
 	rg.GET("/debug/vars", rl, gin.WrapH(expvar.Handler()))
 }
+
+// Subsystem puts the module under MODULES_DISABLED=debug
+func (m *DebugModule) Subsystem() string { return config.ModuleDebug }
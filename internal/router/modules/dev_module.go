@@ -0,0 +1,27 @@
+package modules
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/config"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/container"
+	handlers "github.com/oksasatya/go-ddd-clean-architecture/internal/interface/http"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/interface/middleware"
+)
+
+// DevModule mounts development-only endpoints; only registered outside production.
+type DevModule struct {
+	Handler *handlers.DevHandler
+}
+
+func NewDevModule(h *handlers.DevHandler) *DevModule { return &DevModule{Handler: h} }
+
+func (m *DevModule) Register(rg *gin.RouterGroup) {
+	rl := middleware.RateLimit(container.GetRedis(), 120, time.Minute, middleware.KeyByIP(), nil)
+	rg.GET("/dev/emails", rl, m.Handler.ListEmails)
+}
+
+// Subsystem puts the module under MODULES_DISABLED=debug
+func (m *DevModule) Subsystem() string { return config.ModuleDebug }
@@ -0,0 +1,28 @@
+package modules
+
+import (
+	"net/http"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/config"
+	handlers "github.com/oksasatya/go-ddd-clean-architecture/internal/interface/http"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/router/route"
+)
+
+// EmailEventModule receives provider status webhooks; they are authenticated by their signature,
+// not a session, and are not rate limited so provider bursts are never dropped.
+type EmailEventModule struct {
+	Handler *handlers.EmailEventHandler
+}
+
+func NewEmailEventModule(h *handlers.EmailEventHandler) *EmailEventModule {
+	return &EmailEventModule{Handler: h}
+}
+
+func (m *EmailEventModule) Routes() []route.Route {
+	return []route.Route{
+		{Method: http.MethodPost, Path: "/webhooks/mailgun", Handler: m.Handler.Mailgun, Public: true},
+	}
+}
+
+// Subsystem puts the module under MODULES_DISABLED=email
+func (m *EmailEventModule) Subsystem() string { return config.ModuleEmail }
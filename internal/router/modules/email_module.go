@@ -1,33 +1,30 @@
 package modules
 
 import (
-	"time"
+	"net/http"
 
-	"github.com/gin-gonic/gin"
-
-	"github.com/oksasatya/go-ddd-clean-architecture/internal/container"
+	"github.com/oksasatya/go-ddd-clean-architecture/config"
 	handlers "github.com/oksasatya/go-ddd-clean-architecture/internal/interface/http"
-	"github.com/oksasatya/go-ddd-clean-architecture/internal/interface/middleware"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/router/route"
 	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
 )
 
 type EmailModule struct {
 	Handler *handlers.EmailHandler
-	JWT     *helpers.JWTManager
 }
 
-func NewEmailModule(h *handlers.EmailHandler, jwt *helpers.JWTManager) *EmailModule {
-	return &EmailModule{Handler: h, JWT: jwt}
+func NewEmailModule(h *handlers.EmailHandler) *EmailModule {
+	return &EmailModule{Handler: h}
 }
 
-func (m *EmailModule) Register(rg *gin.RouterGroup) {
-	// Protected email endpoints
-	auth := rg.Group("/")
-	auth.Use(middleware.Auth(container.GetRedis(), m.JWT))
-	auth.Use(
-		middleware.RateLimit(container.GetRedis(), 60, time.Minute, middleware.KeyByUserID(), nil),
-	)
-	{
-		auth.POST("/email/send", m.Handler.Send)
+// Routes declares the protected email endpoints; JWT and per-user limits come from the Registry
+func (m *EmailModule) Routes() []route.Route {
+	return []route.Route{
+		{Method: http.MethodPost, Path: "/email/send", Handler: m.Handler.Send, Scopes: []string{helpers.ScopeWrite}, RateLimit: route.RateUser, Request: handlers.SendEmailRequest{}},
+		// Same endpoint on behalf of an organization (counts against its email quota)
+		{Method: http.MethodPost, Path: "/orgs/:org/email/send", Handler: m.Handler.Send, Scopes: []string{helpers.ScopeWrite}, RateLimit: route.RateUser, OrgRole: OrgAdmin, Request: handlers.SendEmailRequest{}},
 	}
 }
+
+// Subsystem puts the module under MODULES_DISABLED=email
+func (m *EmailModule) Subsystem() string { return config.ModuleEmail }
@@ -0,0 +1,25 @@
+package modules
+
+import (
+	"net/http"
+
+	handlers "github.com/oksasatya/go-ddd-clean-architecture/internal/interface/http"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/router/route"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+)
+
+// ExportModule exposes background exports (users, audit logs) under /admin (admin only)
+type ExportModule struct {
+	Handler *handlers.ExportHandler
+}
+
+func NewExportModule(h *handlers.ExportHandler) *ExportModule {
+	return &ExportModule{Handler: h}
+}
+
+func (m *ExportModule) Routes() []route.Route {
+	return []route.Route{
+		{Method: http.MethodPost, Path: "/admin/exports", Handler: m.Handler.Create, Role: AdminRole, Scopes: []string{helpers.ScopeRead}, RateLimit: route.RateAdmin, Request: handlers.CreateExportRequest{}},
+		{Method: http.MethodGet, Path: "/admin/exports/:id", Handler: m.Handler.Get, Role: AdminRole, Scopes: []string{helpers.ScopeRead}, RateLimit: route.RateAdmin},
+	}
+}
@@ -0,0 +1,26 @@
+package modules
+
+import (
+	"net/http"
+
+	handlers "github.com/oksasatya/go-ddd-clean-architecture/internal/interface/http"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/router/route"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+)
+
+// IdentityModule lets a signed-in user list and unlink external identities
+// Linking starts at GET /api/auth/oidc/link or /api/auth/saml/link.
+type IdentityModule struct {
+	Handler *handlers.IdentityHandler
+}
+
+func NewIdentityModule(h *handlers.IdentityHandler) *IdentityModule {
+	return &IdentityModule{Handler: h}
+}
+
+func (m *IdentityModule) Routes() []route.Route {
+	return []route.Route{
+		{Method: http.MethodGet, Path: "/identities", Handler: m.Handler.List, Scopes: []string{helpers.ScopeRead}, RateLimit: route.RateUser},
+		{Method: http.MethodDelete, Path: "/identities/:provider", Handler: m.Handler.Unlink, Scopes: []string{helpers.ScopeWrite}, RateLimit: route.RateUser},
+	}
+}
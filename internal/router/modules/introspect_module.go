@@ -0,0 +1,31 @@
+package modules
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/container"
+	handlers "github.com/oksasatya/go-ddd-clean-architecture/internal/interface/http"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/interface/middleware"
+)
+
+// IntrospectModule exposes POST /api/auth/introspect to trusted internal services
+// (API key or mTLS client certificate); it is not reachable with user credentials.
+type IntrospectModule struct {
+	Handler   *handlers.IntrospectHandler
+	APIKeys   []string
+	ClientCNs []string
+}
+
+func NewIntrospectModule(h *handlers.IntrospectHandler, apiKeys, clientCNs []string) *IntrospectModule {
+	return &IntrospectModule{Handler: h, APIKeys: apiKeys, ClientCNs: clientCNs}
+}
+
+func (m *IntrospectModule) Register(rg *gin.RouterGroup) {
+	rg.POST("/auth/introspect",
+		middleware.InternalClient(m.APIKeys, m.ClientCNs),
+		middleware.RateLimit(container.GetRedis(), 600, time.Minute, middleware.KeyByIP(), nil),
+		m.Handler.Introspect,
+	)
+}
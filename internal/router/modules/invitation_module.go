@@ -0,0 +1,27 @@
+package modules
+
+import (
+	"net/http"
+
+	handlers "github.com/oksasatya/go-ddd-clean-architecture/internal/interface/http"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/router/route"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+)
+
+// InvitationModule exposes invitation management under /admin and the public accept endpoint
+type InvitationModule struct {
+	Handler *handlers.InvitationHandler
+}
+
+func NewInvitationModule(h *handlers.InvitationHandler) *InvitationModule {
+	return &InvitationModule{Handler: h}
+}
+
+func (m *InvitationModule) Routes() []route.Route {
+	return []route.Route{
+		{Method: http.MethodPost, Path: "/admin/invitations", Handler: m.Handler.Create, Role: AdminRole, Scopes: []string{helpers.ScopeWrite}, RateLimit: route.RateAdmin, Request: handlers.CreateInvitationRequest{}},
+		{Method: http.MethodGet, Path: "/admin/invitations", Handler: m.Handler.List, Role: AdminRole, Scopes: []string{helpers.ScopeRead}, RateLimit: route.RateAdmin},
+		{Method: http.MethodDelete, Path: "/admin/invitations/:id", Handler: m.Handler.Revoke, Role: AdminRole, Scopes: []string{helpers.ScopeWrite}, RateLimit: route.RateAdmin},
+		{Method: http.MethodPost, Path: "/auth/invitations/accept", Handler: m.Handler.Accept, Public: true, RateLimit: route.RateAuth, Request: handlers.AcceptInvitationRequest{}},
+	}
+}
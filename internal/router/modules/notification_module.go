@@ -0,0 +1,26 @@
+package modules
+
+import (
+	"net/http"
+
+	handlers "github.com/oksasatya/go-ddd-clean-architecture/internal/interface/http"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/router/route"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+)
+
+// NotificationModule lets users manage email category opt-outs; unsubscribe links work without a session.
+type NotificationModule struct {
+	Handler *handlers.NotificationHandler
+}
+
+func NewNotificationModule(h *handlers.NotificationHandler) *NotificationModule {
+	return &NotificationModule{Handler: h}
+}
+
+func (m *NotificationModule) Routes() []route.Route {
+	return []route.Route{
+		{Method: http.MethodGet, Path: "/notifications/preferences", Handler: m.Handler.Preferences, Scopes: []string{helpers.ScopeRead}, RateLimit: route.RateUser},
+		{Method: http.MethodPut, Path: "/notifications/preferences", Handler: m.Handler.UpdatePreferences, Scopes: []string{helpers.ScopeWrite}, RateLimit: route.RateUser},
+		{Method: http.MethodPost, Path: "/notifications/unsubscribe", Handler: m.Handler.Unsubscribe, Public: true, RateLimit: route.RateAuth},
+	}
+}
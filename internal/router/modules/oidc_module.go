@@ -0,0 +1,32 @@
+package modules
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/container"
+	handlers "github.com/oksasatya/go-ddd-clean-architecture/internal/interface/http"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/interface/middleware"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+)
+
+// OIDCModule exposes the OpenID Connect login flow
+// Public: GET /api/auth/oidc/login, GET /api/auth/oidc/callback; JWT: GET /api/auth/oidc/link
+type OIDCModule struct {
+	Handler *handlers.OIDCHandler
+	JWT     *helpers.JWTManager
+}
+
+func NewOIDCModule(h *handlers.OIDCHandler, jwt *helpers.JWTManager) *OIDCModule {
+	return &OIDCModule{Handler: h, JWT: jwt}
+}
+
+func (m *OIDCModule) Register(rg *gin.RouterGroup) {
+	limiter := middleware.RateLimit(container.GetRedis(), 30, time.Minute, middleware.KeyByIPAndPath(), nil)
+	rg.GET("/auth/oidc/login", limiter, m.Handler.Login)
+	rg.GET("/auth/oidc/callback", limiter, m.Handler.Callback)
+
+	// Link the IdP identity to the signed-in account
+	rg.GET("/auth/oidc/link", middleware.Auth(container.GetSessionStore(), m.JWT, helpers.NewSessionPolicy(container.GetConfig()), container.GetTokenAuthenticator()), limiter, middleware.RequireScopes(helpers.ScopeWrite), m.Handler.Link)
+}
@@ -0,0 +1,42 @@
+package modules
+
+import (
+	"net/http"
+
+	handlers "github.com/oksasatya/go-ddd-clean-architecture/internal/interface/http"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/router/route"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+)
+
+// Organization member roles used as route OrgRole requirements
+const (
+	OrgMember = "member"
+	OrgAdmin  = "admin"
+)
+
+// OrgModule exposes organizations and membership management
+// Routes under /orgs/:org are guarded by the caller's role in that organization.
+type OrgModule struct {
+	Handler *handlers.OrgHandler
+}
+
+func NewOrgModule(h *handlers.OrgHandler) *OrgModule {
+	return &OrgModule{Handler: h}
+}
+
+func (m *OrgModule) Routes() []route.Route {
+	read := []string{helpers.ScopeRead}
+	write := []string{helpers.ScopeWrite}
+	return []route.Route{
+		{Method: http.MethodPost, Path: "/orgs", Handler: m.Handler.Create, Scopes: write, RateLimit: route.RateUser, Request: handlers.CreateOrgRequest{}},
+		{Method: http.MethodGet, Path: "/orgs", Handler: m.Handler.List, Scopes: read, RateLimit: route.RateUser},
+		{Method: http.MethodGet, Path: "/orgs/:org", Handler: m.Handler.Get, Scopes: read, RateLimit: route.RateUser, OrgRole: OrgMember},
+		{Method: http.MethodGet, Path: "/orgs/:org/usage", Handler: m.Handler.Usage, Scopes: read, RateLimit: route.RateUser, OrgRole: OrgMember},
+		{Method: http.MethodGet, Path: "/orgs/:org/members", Handler: m.Handler.ListMembers, Scopes: read, RateLimit: route.RateUser, OrgRole: OrgMember},
+		{Method: http.MethodPost, Path: "/orgs/:org/members", Handler: m.Handler.InviteMember, Scopes: write, RateLimit: route.RateUser, OrgRole: OrgAdmin, Request: handlers.InviteMemberRequest{}},
+		{Method: http.MethodPut, Path: "/orgs/:org/members/:user", Handler: m.Handler.SetMemberRole, Scopes: write, RateLimit: route.RateUser, OrgRole: OrgAdmin, Request: handlers.SetMemberRoleRequest{}},
+		// Members may remove themselves; the service enforces admin/owner rules for others
+		{Method: http.MethodDelete, Path: "/orgs/:org/members/:user", Handler: m.Handler.RemoveMember, Scopes: write, RateLimit: route.RateUser, OrgRole: OrgMember},
+		{Method: http.MethodPut, Path: "/admin/orgs/:org/limits", Handler: m.Handler.SetLimits, Role: AdminRole, Scopes: write, RateLimit: route.RateAdmin, Request: handlers.SetOrgLimitsRequest{}},
+	}
+}
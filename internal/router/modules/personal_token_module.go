@@ -0,0 +1,27 @@
+package modules
+
+import (
+	"net/http"
+
+	handlers "github.com/oksasatya/go-ddd-clean-architecture/internal/interface/http"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/router/route"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+)
+
+// PersonalTokenModule exposes the signed-in user's personal access tokens; they are accepted as
+// Authorization: Bearer pat_... wherever an access token is
+type PersonalTokenModule struct {
+	Handler *handlers.PersonalTokenHandler
+}
+
+func NewPersonalTokenModule(h *handlers.PersonalTokenHandler) *PersonalTokenModule {
+	return &PersonalTokenModule{Handler: h}
+}
+
+func (m *PersonalTokenModule) Routes() []route.Route {
+	return []route.Route{
+		{Method: http.MethodGet, Path: "/auth/personal-tokens", Handler: m.Handler.List, Scopes: []string{helpers.ScopeRead}, RateLimit: route.RateUser, Response: []handlers.PersonalTokenView{}},
+		{Method: http.MethodPost, Path: "/auth/personal-tokens", Handler: m.Handler.Create, Scopes: []string{helpers.ScopeWrite}, RateLimit: route.RateUser, Request: handlers.CreatePersonalTokenRequest{}},
+		{Method: http.MethodDelete, Path: "/auth/personal-tokens/:id", Handler: m.Handler.Revoke, Scopes: []string{helpers.ScopeWrite}, RateLimit: route.RateUser},
+	}
+}
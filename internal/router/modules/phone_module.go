@@ -0,0 +1,27 @@
+package modules
+
+import (
+	"net/http"
+
+	handlers "github.com/oksasatya/go-ddd-clean-architecture/internal/interface/http"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/router/route"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+)
+
+// PhoneModule exposes the signed-in user's phone number, encrypted at rest; mounted only when
+// FIELD_ENCRYPTION_KEYS is set
+type PhoneModule struct {
+	Handler *handlers.PhoneHandler
+}
+
+func NewPhoneModule(h *handlers.PhoneHandler) *PhoneModule {
+	return &PhoneModule{Handler: h}
+}
+
+func (m *PhoneModule) Routes() []route.Route {
+	return []route.Route{
+		{Method: http.MethodGet, Path: "/profile/phone", Handler: m.Handler.Get, Scopes: []string{helpers.ScopeRead}, RateLimit: route.RateUser, Response: handlers.PhoneView{}},
+		{Method: http.MethodPut, Path: "/profile/phone", Handler: m.Handler.Put, Scopes: []string{helpers.ScopeWrite}, RateLimit: route.RateUser, Request: handlers.SetPhoneRequest{}, Response: handlers.PhoneView{}},
+		{Method: http.MethodDelete, Path: "/profile/phone", Handler: m.Handler.Delete, Scopes: []string{helpers.ScopeWrite}, RateLimit: route.RateUser},
+	}
+}
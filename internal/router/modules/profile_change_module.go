@@ -0,0 +1,27 @@
+package modules
+
+import (
+	"net/http"
+
+	handlers "github.com/oksasatya/go-ddd-clean-architecture/internal/interface/http"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/router/route"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+)
+
+// ProfileChangeModule exposes pending sensitive profile changes; they are requested through
+// PUT /api/profile and applied by the public confirm endpoint behind the emailed link
+type ProfileChangeModule struct {
+	Handler *handlers.ProfileChangeHandler
+}
+
+func NewProfileChangeModule(h *handlers.ProfileChangeHandler) *ProfileChangeModule {
+	return &ProfileChangeModule{Handler: h}
+}
+
+func (m *ProfileChangeModule) Routes() []route.Route {
+	return []route.Route{
+		{Method: http.MethodGet, Path: "/profile/pending-changes", Handler: m.Handler.Pending, Scopes: []string{helpers.ScopeRead}, RateLimit: route.RateUser},
+		{Method: http.MethodDelete, Path: "/profile/pending-changes/:field", Handler: m.Handler.Cancel, Scopes: []string{helpers.ScopeWrite}, RateLimit: route.RateUser},
+		{Method: http.MethodPost, Path: "/profile/changes/confirm", Handler: m.Handler.Confirm, Public: true, RateLimit: route.RateAuth},
+	}
+}
@@ -0,0 +1,26 @@
+package modules
+
+import (
+	"net/http"
+
+	handlers "github.com/oksasatya/go-ddd-clean-architecture/internal/interface/http"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/router/route"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+)
+
+// RateBypassModule manages the runtime rate limit allowlist under /admin (admin only)
+type RateBypassModule struct {
+	Handler *handlers.RateBypassHandler
+}
+
+func NewRateBypassModule(h *handlers.RateBypassHandler) *RateBypassModule {
+	return &RateBypassModule{Handler: h}
+}
+
+func (m *RateBypassModule) Routes() []route.Route {
+	return []route.Route{
+		{Method: http.MethodGet, Path: "/admin/rate-limit/bypass", Handler: m.Handler.List, Role: AdminRole, Scopes: []string{helpers.ScopeRead}, RateLimit: route.RateAdmin, Response: []helpers.RateBypassEntry{}},
+		{Method: http.MethodPost, Path: "/admin/rate-limit/bypass", Handler: m.Handler.Add, Role: AdminRole, Scopes: []string{helpers.ScopeWrite}, RateLimit: route.RateAdmin, Request: handlers.AddRateBypassRequest{}, Response: helpers.RateBypassEntry{}},
+		{Method: http.MethodDelete, Path: "/admin/rate-limit/bypass", Handler: m.Handler.Remove, Role: AdminRole, Scopes: []string{helpers.ScopeWrite}, RateLimit: route.RateAdmin},
+	}
+}
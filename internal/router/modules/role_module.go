@@ -0,0 +1,47 @@
+package modules
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	handlers "github.com/oksasatya/go-ddd-clean-architecture/internal/interface/http"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/router/route"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+)
+
+// AdminRole is the role required for the /admin management endpoints.
+const AdminRole = "admin"
+
+// RoleModule exposes role and permission management under /admin (admin only)
+// Guards (JWT, admin role, rate limit) are applied by the Registry from the route metadata.
+type RoleModule struct {
+	Handler *handlers.RoleHandler
+}
+
+func NewRoleModule(h *handlers.RoleHandler) *RoleModule {
+	return &RoleModule{Handler: h}
+}
+
+func (m *RoleModule) Routes() []route.Route {
+	admin := func(method, path string, h gin.HandlerFunc, body any) route.Route {
+		scope := helpers.ScopeWrite
+		if method == http.MethodGet {
+			scope = helpers.ScopeRead
+		}
+		return route.Route{Method: method, Path: "/admin" + path, Handler: h, Role: AdminRole, Scopes: []string{scope}, RateLimit: route.RateAdmin, Request: body}
+	}
+	return []route.Route{
+		admin(http.MethodGet, "/roles", m.Handler.ListRoles, nil),
+		admin(http.MethodPost, "/roles", m.Handler.CreateRole, handlers.CreateRoleRequest{}),
+		admin(http.MethodGet, "/permissions", m.Handler.ListPermissions, nil),
+		admin(http.MethodPost, "/roles/:role/permissions", m.Handler.AttachPermission, handlers.AttachPermissionRequest{}),
+		admin(http.MethodDelete, "/roles/:role/permissions/:permission", m.Handler.DetachPermission, nil),
+		admin(http.MethodPost, "/roles/:role/members/assign", m.Handler.AssignRoleBulk, handlers.RoleMembersRequest{}),
+		admin(http.MethodPost, "/roles/:role/members/revoke", m.Handler.RevokeRoleBulk, handlers.RoleMembersRequest{}),
+		admin(http.MethodPost, "/roles/:role/sync", m.Handler.SyncRole, handlers.SyncRoleRequest{}),
+		admin(http.MethodPost, "/users/:id/roles", m.Handler.AssignRole, handlers.AssignRoleRequest{}),
+		admin(http.MethodDelete, "/users/:id/roles/:role", m.Handler.RevokeRole, nil),
+		admin(http.MethodGet, "/users/:id/permissions", m.Handler.UserPermissions, nil),
+	}
+}
@@ -0,0 +1,24 @@
+package modules
+
+import (
+	"net/http"
+
+	handlers "github.com/oksasatya/go-ddd-clean-architecture/internal/interface/http"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/router/route"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+)
+
+// RoutesModule exposes the route listing under /admin (admin only)
+type RoutesModule struct {
+	Handler *handlers.RoutesHandler
+}
+
+func NewRoutesModule(h *handlers.RoutesHandler) *RoutesModule {
+	return &RoutesModule{Handler: h}
+}
+
+func (m *RoutesModule) Routes() []route.Route {
+	return []route.Route{
+		{Method: http.MethodGet, Path: "/admin/routes", Handler: m.Handler.Routes, Role: AdminRole, Scopes: []string{helpers.ScopeRead}, RateLimit: route.RateAdmin, Response: []route.Info{}},
+	}
+}
@@ -0,0 +1,33 @@
+package modules
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/container"
+	handlers "github.com/oksasatya/go-ddd-clean-architecture/internal/interface/http"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/interface/middleware"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+)
+
+// SAMLModule exposes the SAML 2.0 service provider endpoints
+// Public: GET /api/auth/saml/metadata, GET /api/auth/saml/login, POST /api/auth/saml/acs; JWT: GET /api/auth/saml/link
+type SAMLModule struct {
+	Handler *handlers.SAMLHandler
+	JWT     *helpers.JWTManager
+}
+
+func NewSAMLModule(h *handlers.SAMLHandler, jwt *helpers.JWTManager) *SAMLModule {
+	return &SAMLModule{Handler: h, JWT: jwt}
+}
+
+func (m *SAMLModule) Register(rg *gin.RouterGroup) {
+	limiter := middleware.RateLimit(container.GetRedis(), 30, time.Minute, middleware.KeyByIPAndPath(), nil)
+	rg.GET("/auth/saml/metadata", m.Handler.Metadata)
+	rg.GET("/auth/saml/login", limiter, m.Handler.Login)
+	rg.POST("/auth/saml/acs", limiter, m.Handler.ACS)
+
+	// Link the IdP identity to the signed-in account
+	rg.GET("/auth/saml/link", middleware.Auth(container.GetSessionStore(), m.JWT, helpers.NewSessionPolicy(container.GetConfig()), container.GetTokenAuthenticator()), limiter, middleware.RequireScopes(helpers.ScopeWrite), m.Handler.Link)
+}
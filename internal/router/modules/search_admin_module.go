@@ -0,0 +1,29 @@
+package modules
+
+import (
+	"net/http"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/config"
+	handlers "github.com/oksasatya/go-ddd-clean-architecture/internal/interface/http"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/router/route"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+)
+
+// SearchAdminModule exposes search index maintenance under /admin (admin only)
+type SearchAdminModule struct {
+	Handler *handlers.SearchAdminHandler
+}
+
+func NewSearchAdminModule(h *handlers.SearchAdminHandler) *SearchAdminModule {
+	return &SearchAdminModule{Handler: h}
+}
+
+func (m *SearchAdminModule) Routes() []route.Route {
+	return []route.Route{
+		{Method: http.MethodPost, Path: "/admin/search/users/reindex", Handler: m.Handler.Reindex, Role: AdminRole, Scopes: []string{helpers.ScopeWrite}, RateLimit: route.RateAdmin},
+		{Method: http.MethodGet, Path: "/admin/search/users/reindex", Handler: m.Handler.ReindexStatus, Role: AdminRole, Scopes: []string{helpers.ScopeRead}, RateLimit: route.RateAdmin},
+	}
+}
+
+// Subsystem puts the module under MODULES_DISABLED=search
+func (m *SearchAdminModule) Subsystem() string { return config.ModuleSearch }
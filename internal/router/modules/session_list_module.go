@@ -0,0 +1,24 @@
+package modules
+
+import (
+	"net/http"
+
+	handlers "github.com/oksasatya/go-ddd-clean-architecture/internal/interface/http"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/router/route"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+)
+
+// SessionListModule exposes the sessions of many users at once under /admin (admin only)
+type SessionListModule struct {
+	Handler *handlers.SessionListHandler
+}
+
+func NewSessionListModule(h *handlers.SessionListHandler) *SessionListModule {
+	return &SessionListModule{Handler: h}
+}
+
+func (m *SessionListModule) Routes() []route.Route {
+	return []route.Route{
+		{Method: http.MethodGet, Path: "/admin/sessions", Handler: m.Handler.List, Role: AdminRole, Scopes: []string{helpers.ScopeRead}, RateLimit: route.RateAdmin},
+	}
+}
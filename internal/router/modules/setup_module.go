@@ -0,0 +1,25 @@
+package modules
+
+import (
+	"net/http"
+
+	handlers "github.com/oksasatya/go-ddd-clean-architecture/internal/interface/http"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/router/route"
+)
+
+// SetupModule exposes the first-run admin bootstrap; both routes are public and the create
+// route is closed once an admin exists
+type SetupModule struct {
+	Handler *handlers.SetupHandler
+}
+
+func NewSetupModule(h *handlers.SetupHandler) *SetupModule {
+	return &SetupModule{Handler: h}
+}
+
+func (m *SetupModule) Routes() []route.Route {
+	return []route.Route{
+		{Method: http.MethodGet, Path: "/setup/admin", Handler: m.Handler.Status, Public: true, RateLimit: route.RateAuth},
+		{Method: http.MethodPost, Path: "/setup/admin", Handler: m.Handler.CreateAdmin, Public: true, RateLimit: route.RateAuth, Request: handlers.SetupAdminRequest{}},
+	}
+}
@@ -0,0 +1,23 @@
+package modules
+
+import (
+	"net/http"
+
+	handlers "github.com/oksasatya/go-ddd-clean-architecture/internal/interface/http"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/router/route"
+)
+
+// StatusModule exposes the public dependency status for ops status pages
+type StatusModule struct {
+	Handler *handlers.StatusHandler
+}
+
+func NewStatusModule(h *handlers.StatusHandler) *StatusModule {
+	return &StatusModule{Handler: h}
+}
+
+func (m *StatusModule) Routes() []route.Route {
+	return []route.Route{
+		{Method: http.MethodGet, Path: "/status", Func: m.Handler.Get, Public: true, RateLimit: route.RateUser, Response: handlers.StatusPage{}},
+	}
+}
@@ -0,0 +1,24 @@
+package modules
+
+import (
+	"net/http"
+
+	handlers "github.com/oksasatya/go-ddd-clean-architecture/internal/interface/http"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/router/route"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+)
+
+// UsageModule exposes the API usage report under /admin (admin only)
+type UsageModule struct {
+	Handler *handlers.UsageHandler
+}
+
+func NewUsageModule(h *handlers.UsageHandler) *UsageModule {
+	return &UsageModule{Handler: h}
+}
+
+func (m *UsageModule) Routes() []route.Route {
+	return []route.Route{
+		{Method: http.MethodGet, Path: "/admin/usage", Handler: m.Handler.Report, Role: AdminRole, Scopes: []string{helpers.ScopeRead}, RateLimit: route.RateAdmin, Concurrency: route.ConcurrencyHeavy, Timeout: route.TimeoutLong},
+	}
+}
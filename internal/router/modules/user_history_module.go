@@ -0,0 +1,25 @@
+package modules
+
+import (
+	"net/http"
+
+	handlers "github.com/oksasatya/go-ddd-clean-architecture/internal/interface/http"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/router/route"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+)
+
+// UserHistoryModule exposes a user's change history and replayed state under /admin (admin only)
+type UserHistoryModule struct {
+	Handler *handlers.UserHistoryHandler
+}
+
+func NewUserHistoryModule(h *handlers.UserHistoryHandler) *UserHistoryModule {
+	return &UserHistoryModule{Handler: h}
+}
+
+func (m *UserHistoryModule) Routes() []route.Route {
+	return []route.Route{
+		{Method: http.MethodGet, Path: "/admin/users/:id/history", Handler: m.Handler.History, Role: AdminRole, Scopes: []string{helpers.ScopeRead}, RateLimit: route.RateAdmin},
+		{Method: http.MethodGet, Path: "/admin/users/:id/history/state", Handler: m.Handler.State, Role: AdminRole, Scopes: []string{helpers.ScopeRead}, RateLimit: route.RateAdmin},
+	}
+}
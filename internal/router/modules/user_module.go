@@ -13,17 +13,19 @@ import (
 )
 
 // Module wires user HTTP handlers and JWT middleware into routes
-// Public: POST /api/login, POST /api/refresh
-// Protected: POST /api/logout, GET /api/profile, PUT /api/profile
+// Public: POST /api/login, GET /api/login/otp/status/:id, POST /api/login/code (LOGIN_CODE_ENABLED), POST /api/refresh
+// Protected: POST /api/logout, GET /api/profile, PUT /api/profile, GET /api/sessions, POST /api/auth/token
+// Protected routes check access token scopes (read for GET, write for mutations)
 // All routes are registered under the given RouterGroup (usually /api)
 
 type Module struct {
 	Handler *handlers.UserHandler
 	JWT     *helpers.JWTManager
+	Heavy   gin.HandlerFunc // in-flight limiter for expensive routes (search); nil = none
 }
 
-func New(h *handlers.UserHandler, jwt *helpers.JWTManager) *Module {
-	return &Module{Handler: h, JWT: jwt}
+func New(h *handlers.UserHandler, jwt *helpers.JWTManager, heavy gin.HandlerFunc) *Module {
+	return &Module{Handler: h, JWT: jwt, Heavy: heavy}
 }
 
 func (m *Module) Register(rg *gin.RouterGroup) {
@@ -32,23 +34,55 @@ func (m *Module) Register(rg *gin.RouterGroup) {
 	refreshLimiter := middleware.RateLimit(container.GetRedis(), 60, time.Minute, middleware.KeyByIP(), nil) // 60 req/min per IP
 	otpConfirmLimiter := middleware.RateLimit(container.GetRedis(), 60, time.Minute, middleware.KeyByIPAndPath(), nil)
 
-	rg.POST("/login", loginLimiter, m.Handler.Login)
-	rg.POST("/login/otp/confirm", otpConfirmLimiter, m.Handler.LoginOTPConfirm)
+	rg.POST("/login", loginLimiter, accountGuard("login", true), m.Handler.Login)
+	rg.POST("/login/otp/confirm", otpConfirmLimiter, accountGuard("otp", true), m.Handler.LoginOTPConfirm)
+	rg.GET("/login/otp/status/:id", otpConfirmLimiter, m.Handler.LoginOTPStatus)
+	if cfg := container.GetConfig(); cfg != nil && cfg.LoginCodeEnabled {
+		rg.POST("/login/code", loginLimiter, accountGuard("code", false), m.Handler.LoginCode)
+	}
 	rg.POST("/refresh", refreshLimiter, m.Handler.Refresh)
 
 	// Protected
 	auth := rg.Group("/")
-	auth.Use(middleware.Auth(container.GetRedis(), m.JWT))
+	auth.Use(middleware.Auth(container.GetSessionStore(), m.JWT, helpers.NewSessionPolicy(container.GetConfig()), container.GetTokenAuthenticator()))
 	// Apply a softer per-IP limiter to all protected routes
 	auth.Use(
 		middleware.RateLimit(container.GetRedis(), 300, time.Minute, middleware.KeyByIP(), nil),
 		middleware.RateLimit(container.GetRedis(), 120, time.Minute, middleware.KeyByUserID(), nil),
 	)
 	{
-		auth.POST("/logout", m.Handler.Logout)
-		auth.GET("/profile", m.Handler.GetProfile)
-		auth.PUT("/profile", m.Handler.UpdateProfile)
+		read := middleware.RequireScopes(helpers.ScopeRead)
+		write := middleware.RequireScopes(helpers.ScopeWrite)
+		auth.POST("/logout", write, m.Handler.Logout)
+		auth.GET("/profile", read, m.Handler.GetProfile)
+		auth.PUT("/profile", write, m.Handler.UpdateProfile)
+		auth.GET("/sessions", read, m.Handler.ListSessions)
 		// Search users via Elasticsearch
-		auth.GET("/users/search", m.Handler.Search)
+		search := []gin.HandlerFunc{read}
+		if m.Heavy != nil {
+			search = append(search, m.Heavy)
+		}
+		auth.GET("/users/search", append(search, m.Handler.Search)...)
+		// Reduced-scope bearer tokens (scopes must be a subset of the caller's)
+		auth.POST("/auth/token", m.Handler.IssueScopedToken)
+	}
+}
+
+// accountGuard builds the per-account brute-force limiter from ACCOUNT_GUARD_* settings
+func accountGuard(scope string, resetOnSuccess bool) gin.HandlerFunc {
+	cfg := container.GetConfig()
+	if cfg == nil {
+		return func(c *gin.Context) { c.Next() }
+	}
+	opts := middleware.AccountGuardOptions{
+		Free:           cfg.AccountGuardFreeAttempts,
+		BaseDelay:      cfg.AccountGuardBaseDelay,
+		MaxDelay:       cfg.AccountGuardMaxDelay,
+		Window:         cfg.AccountGuardWindow,
+		ResetOnSuccess: resetOnSuccess,
+	}
+	if m := container.GetAuthMetrics(); m != nil && scope != "reset" {
+		opts.OnLocked = func(ip string) { m.Observe(ip, helpers.AuthLocked) }
 	}
+	return middleware.AccountGuard(container.GetRedis(), scope, opts)
 }
@@ -0,0 +1,48 @@
+package router
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Pipeline names the global middleware an engine can run. A factory returns nil when its own
+// settings leave it off (e.g. the access log without HTTP_LOG_ENABLED).
+type Pipeline map[string]func() gin.HandlerFunc
+
+// UseGlobal mounts the named middleware on the engine in the given order and returns the names
+// actually enabled. Unknown or repeated names are errors. Call it before registering any route:
+// gin copies middleware into routes (and the /api group) when they are created.
+func (r *Registry) UseGlobal(p Pipeline, order []string) ([]string, error) {
+	seen := map[string]bool{}
+	var hs []gin.HandlerFunc
+	var enabled []string
+	for _, name := range order {
+		factory, ok := p[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown middleware %q (available: %v)", name, p.names())
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("middleware %q listed twice", name)
+		}
+		seen[name] = true
+		if h := factory(); h != nil {
+			hs = append(hs, h)
+			enabled = append(enabled, name)
+		}
+	}
+	r.Engine.Use(hs...)
+	// Recreate the API group so it inherits the engine middleware
+	r.API = r.Engine.Group(r.API.BasePath())
+	return enabled, nil
+}
+
+func (p Pipeline) names() []string {
+	out := make([]string, 0, len(p))
+	for name := range p {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
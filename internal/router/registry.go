@@ -1,32 +1,250 @@
 package router
 
-import "github.com/gin-gonic/gin"
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/config"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/container"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/router/route"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/httpx"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/response"
+)
+
+// Guards holds the middleware factories the Registry uses for declared routes
+type Guards struct {
+	Auth       gin.HandlerFunc
+	Role       func(role string) gin.HandlerFunc
+	Permission func(permission string) gin.HandlerFunc
+	Scopes     func(scopes ...string) gin.HandlerFunc
+	Org        func(minRole string) gin.HandlerFunc
+	OrgQuota   gin.HandlerFunc // optional; runs after Org on org-scoped routes
+	RateLimits map[string][]gin.HandlerFunc
+	// RateSpecs describes each rate limit class for route listings (optional)
+	RateSpecs map[string][]RateSpec
+	// Concurrency maps a class to a shared limiter (one semaphore per class)
+	Concurrency map[string]gin.HandlerFunc
+}
 
 type Registry struct {
 	Engine      *gin.Engine
 	API         *gin.RouterGroup
+	Guards      Guards
 	middlewares []gin.HandlerFunc
 	modules     []Module
+	routes      []RouteModule
+	declared    map[string]route.Info // "METHOD /path" of routes mounted from metadata
+	owners      map[string]string     // "METHOD /path" -> module that registered it by hand
+	// Shutdown closes what modules start; the container's registry when set
+	Shutdown *helpers.ShutdownRegistry
+	// Disabled holds the subsystems turned off by MODULES_DISABLED
+	Disabled map[string]bool
+	skipped  map[string]bool // modules left out because their subsystem is disabled
 }
 
 func NewRegistry(engine *gin.Engine) *Registry {
+	// Unmatched paths and methods get the standard envelope instead of gin's plain-text body; the
+	// global middleware still runs first, so meta carries the request id
+	engine.HandleMethodNotAllowed = true
+	engine.NoRoute(noRoute)
+	engine.NoMethod(noMethod)
 	api := engine.Group("/api")
-	return &Registry{Engine: engine, API: api}
+	sd := container.GetShutdown()
+	if sd == nil {
+		sd = helpers.NewShutdownRegistry(container.GetLogger(), 0)
+	}
+	return &Registry{Engine: engine, API: api, Shutdown: sd}
 }
 
 func (r *Registry) Use(mw ...gin.HandlerFunc) {
 	r.middlewares = append(r.middlewares, mw...)
 }
 
+// OnShutdown registers a module's background component (a job loop, a buffered writer) to close
+// in the ShutdownBackground phase: after the HTTP server stops, before the event bus closes
+func (r *Registry) OnShutdown(name string, fn func(context.Context)) {
+	r.Shutdown.Register(name, helpers.ShutdownBackground, helpers.CloseCtx(fn))
+}
+
 func (r *Registry) Add(mod Module) {
 	r.modules = append(r.modules, mod)
 }
 
+// AddRoutes adds a module whose routes are guarded by the Registry
+func (r *Registry) AddRoutes(mod RouteModule) {
+	r.routes = append(r.routes, mod)
+}
+
 func (r *Registry) RegisterAll() {
 	if len(r.middlewares) > 0 {
 		r.API.Use(r.middlewares...)
 	}
+	r.owners = map[string]string{}
+	r.skipped = map[string]bool{}
 	for _, m := range r.modules {
+		if !r.enabled(m) {
+			r.skipped[moduleName(m)] = true
+			continue
+		}
+		before := r.routeKeys()
 		m.Register(r.API)
+		for key := range r.routeKeys() {
+			if !before[key] {
+				r.owners[key] = moduleName(m)
+			}
+		}
+	}
+	for _, m := range r.routes {
+		if !r.enabled(m) {
+			r.skipped[moduleName(m)] = true
+			continue
+		}
+		mounted, dropped := 0, 0
+		for _, rt := range m.Routes() {
+			if r.Disabled[config.ModuleAdmin] && (rt.Path == "/admin" || strings.HasPrefix(rt.Path, "/admin/")) {
+				dropped++
+				continue
+			}
+			r.mount(moduleName(m), rt)
+			mounted++
+		}
+		// Admin-only modules are skipped as a whole
+		if mounted == 0 && dropped > 0 {
+			r.skipped[moduleName(m)] = true
+		}
+	}
+}
+
+// Enabled reports whether the subsystem is on (not in MODULES_DISABLED)
+func (r *Registry) Enabled(subsystem string) bool {
+	return !r.Disabled[subsystem]
+}
+
+// enabled reports whether m's subsystem, if it has one, is on
+func (r *Registry) enabled(m any) bool {
+	s, ok := m.(SubsystemModule)
+	return !ok || r.Enabled(s.Subsystem())
+}
+
+// chain builds the handler chain for a declared route: deprecation headers, auth, scopes, rate limit, role, permission, org (+ quota),
+// concurrency, handler. The concurrency slot is taken last so rejected requests never hold one.
+// Missing guards for a declared requirement panic at startup rather than serving unguarded routes.
+// The labels name each guard in order, for route listings.
+func (r *Registry) chain(rt route.Route) ([]gin.HandlerFunc, []string) {
+	var hs []gin.HandlerFunc
+	var labels []string
+	// First, so rejections by the guards below carry the headers too
+	if rt.Deprecation != nil {
+		hs = append(hs, deprecationHeaders(*rt.Deprecation))
+		labels = append(labels, "deprecation")
+	}
+	if !rt.Public {
+		if r.Guards.Auth == nil {
+			panic(fmt.Sprintf("router: %s %s requires auth but no auth guard is configured", rt.Method, rt.Path))
+		}
+		hs = append(hs, r.Guards.Auth)
+		labels = append(labels, "auth")
+	}
+	if len(rt.Scopes) > 0 {
+		if rt.Public || r.Guards.Scopes == nil {
+			panic(fmt.Sprintf("router: %s %s requires scopes %v but no scope guard applies", rt.Method, rt.Path, rt.Scopes))
+		}
+		hs = append(hs, r.Guards.Scopes(rt.Scopes...))
+		labels = append(labels, "scopes("+strings.Join(rt.Scopes, ",")+")")
+	}
+	if rt.RateLimit != "" {
+		rl, ok := r.Guards.RateLimits[rt.RateLimit]
+		if !ok {
+			panic(fmt.Sprintf("router: %s %s uses unknown rate limit class %q", rt.Method, rt.Path, rt.RateLimit))
+		}
+		hs = append(hs, rl...)
+		labels = append(labels, "ratelimit("+rt.RateLimit+")")
+	}
+	if rt.Role != "" {
+		if r.Guards.Role == nil {
+			panic(fmt.Sprintf("router: %s %s requires role %q but no role guard is configured", rt.Method, rt.Path, rt.Role))
+		}
+		hs = append(hs, r.Guards.Role(rt.Role))
+		labels = append(labels, "role("+rt.Role+")")
+	}
+	if rt.Permission != "" {
+		if r.Guards.Permission == nil {
+			panic(fmt.Sprintf("router: %s %s requires permission %q but no permission guard is configured", rt.Method, rt.Path, rt.Permission))
+		}
+		hs = append(hs, r.Guards.Permission(rt.Permission))
+		labels = append(labels, "permission("+rt.Permission+")")
+	}
+	if rt.OrgRole != "" {
+		if rt.Public || r.Guards.Org == nil {
+			panic(fmt.Sprintf("router: %s %s requires org role %q but no org guard applies", rt.Method, rt.Path, rt.OrgRole))
+		}
+		hs = append(hs, r.Guards.Org(rt.OrgRole))
+		labels = append(labels, "org("+rt.OrgRole+")")
+		if r.Guards.OrgQuota != nil {
+			hs = append(hs, r.Guards.OrgQuota)
+			labels = append(labels, "orgquota")
+		}
+	}
+	if rt.Concurrency != "" {
+		cl, ok := r.Guards.Concurrency[rt.Concurrency]
+		if !ok {
+			panic(fmt.Sprintf("router: %s %s uses unknown concurrency class %q", rt.Method, rt.Path, rt.Concurrency))
+		}
+		hs = append(hs, cl)
+		labels = append(labels, "concurrency("+rt.Concurrency+")")
+	}
+	if rt.Handler == nil && rt.Func != nil {
+		return append(hs, httpx.Gin(rt.Func)), labels
+	}
+	return append(hs, rt.Handler), labels
+}
+
+func (r *Registry) mount(module string, rt route.Route) {
+	hs, labels := r.chain(rt)
+	r.API.Handle(rt.Method, rt.Path, hs...)
+	r.record(module, rt, labels)
+}
+
+// noRoute answers requests no route matches
+func noRoute(c *gin.Context) {
+	response.ErrorCode[any](c, http.StatusNotFound, response.CodeRouteNotFound, "route not found", map[string]any{"method": c.Request.Method, "path": c.Request.URL.Path})
+}
+
+// noMethod answers requests whose path only has routes for other methods; gin has set Allow to them
+func noMethod(c *gin.Context) {
+	allowed := strings.Split(c.Writer.Header().Get("Allow"), ", ")
+	response.ErrorCode[any](c, http.StatusMethodNotAllowed, response.CodeMethodNotAllowed, "method not allowed", map[string]any{"method": c.Request.Method, "allowed": allowed})
+}
+
+// deprecationHeaders announces a deprecated route on every response
+func deprecationHeaders(d route.Deprecation) gin.HandlerFunc {
+	deprecation := "true"
+	if !d.Since.IsZero() {
+		deprecation = "@" + strconv.FormatInt(d.Since.Unix(), 10)
+	}
+	var links []string
+	if d.Successor != "" {
+		links = append(links, "<"+d.Successor+`>; rel="successor-version"`)
+	}
+	if d.Link != "" {
+		links = append(links, "<"+d.Link+`>; rel="deprecation"`)
+	}
+	return func(c *gin.Context) {
+		h := c.Writer.Header()
+		h.Set("Deprecation", deprecation)
+		if !d.Sunset.IsZero() {
+			h.Set("Sunset", d.Sunset.UTC().Format(http.TimeFormat))
+		}
+		for _, l := range links {
+			h.Add("Link", l)
+		}
+		c.Set(response.DeprecatedKey, true)
+		c.Next()
 	}
 }
@@ -0,0 +1,23 @@
+package route
+
+// Info describes a mounted endpoint for route listings (GET /api/admin/routes, server --routes)
+type Info struct {
+	Method      string   `json:"method"`
+	Path        string   `json:"path"`
+	Module      string   `json:"module"`
+	Handler     string   `json:"handler"`
+	Auth        string   `json:"auth"` // public, jwt, or custom (guards wired by hand, not from metadata)
+	Role        string   `json:"role,omitempty"`
+	Permission  string   `json:"permission,omitempty"`
+	Scopes      []string `json:"scopes,omitempty"`
+	OrgRole     string   `json:"org_role,omitempty"`
+	RateLimit   string   `json:"rate_limit,omitempty"`
+	RateLimits  []string `json:"rate_limits,omitempty"` // e.g. "10 per 1m0s by ip"
+	Concurrency string   `json:"concurrency,omitempty"`
+	Timeout     string   `json:"timeout,omitempty"`
+	Deprecated  bool     `json:"deprecated,omitempty"`
+	Sunset      string   `json:"sunset,omitempty"`   // RFC 3339
+	Middleware  []string `json:"middleware"`         // global middleware then route guards, in order
+	Request     *Schema  `json:"request,omitempty"`  // JSON body (Route.Request)
+	Response    *Schema  `json:"response,omitempty"` // data of the response envelope (Route.Response)
+}
@@ -0,0 +1,58 @@
+package route
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/httpx"
+)
+
+// Rate limit classes a Route can reference; the Registry maps each class to middleware.
+const (
+	RateAuth  = "auth"  // strict per-IP limit for credential endpoints
+	RateUser  = "user"  // per-user limit for authenticated traffic
+	RateAdmin = "admin" // per-user limit for administrative endpoints
+)
+
+// Concurrency classes cap in-flight requests for expensive routes (Postgres aggregations, Elasticsearch).
+const (
+	ConcurrencyHeavy = "heavy"
+)
+
+// Timeout classes override REQUEST_TIMEOUT for routes that legitimately run longer.
+const (
+	TimeoutLong = "long" // REQUEST_TIMEOUT_LONG: exports, aggregations, bulk operations
+	TimeoutNone = "none" // streaming responses bounded by their own logic
+)
+
+// Route declares a single endpoint together with the guards the Registry must apply
+// Paths are relative to the API group (usually /api)
+type Route struct {
+	Method      string
+	Path        string
+	Handler     gin.HandlerFunc
+	Func        httpx.Handler // framework-neutral handler, mounted through httpx.Gin when Handler is nil
+	Public      bool          // skip JWT auth
+	Role        string        // required role (optional)
+	Permission  string        // required permission (optional)
+	Scopes      []string      // required access token scopes (optional)
+	RateLimit   string        // rate limit class (optional)
+	OrgRole     string        // minimum role in the organization named by the :org path param (optional)
+	Concurrency string        // in-flight limit class (optional)
+	Timeout     string        // request timeout class (optional; REQUEST_TIMEOUT otherwise)
+	Deprecation *Deprecation  // marks the route deprecated (optional)
+	// Request and Response are zero values of the JSON body and response data types, e.g.
+	// handlers.LoginRequest{}; listed as schemas for client generation (optional)
+	Request  any
+	Response any
+}
+
+// Deprecation announces a route's retirement. The Registry answers every request to it with
+// Deprecation (RFC 9745), Sunset (RFC 8594) and Link headers and flags meta.deprecated.
+type Deprecation struct {
+	Since     time.Time // when it was deprecated; zero sends "Deprecation: true"
+	Sunset    time.Time // when it stops being served (optional)
+	Successor string    // replacement endpoint, sent as rel="successor-version" (optional)
+	Link      string    // migration notes, sent as rel="deprecation" (optional)
+}
@@ -0,0 +1,132 @@
+package route
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Schema describes the JSON shape of a request body or response data type, derived by reflection
+// from the value a Route declares; cmd/sdkgen generates typed clients from it
+type Schema struct {
+	Type     string   `json:"type"`               // object, array, map, string, integer, number, boolean or any
+	Name     string   `json:"name,omitempty"`     // Go type name of a named struct
+	Format   string   `json:"format,omitempty"`   // date-time for time.Time, duration for time.Duration
+	Nullable bool     `json:"nullable,omitempty"` // pointer: null or omitted means unset
+	Fields   []Field  `json:"fields,omitempty"`   // object properties
+	Elem     *Schema  `json:"elem,omitempty"`     // array items, map values
+	Enum     []string `json:"enum,omitempty"`     // allowed values (binding oneof)
+}
+
+// Field is one property of an object Schema
+type Field struct {
+	Name     string  `json:"name"` // JSON name
+	Schema   *Schema `json:"schema"`
+	Required bool    `json:"required,omitempty"` // binding:"required"
+	Optional bool    `json:"optional,omitempty"` // omitempty: may be missing from responses
+	Rules    string  `json:"rules,omitempty"`    // the binding tag, e.g. required,email
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	durationType  = reflect.TypeOf(time.Duration(0))
+	rawJSONType   = reflect.TypeOf(json.RawMessage{})
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// SchemaOf describes the JSON encoding of v's type; nil for a nil v. A struct that contains
+// itself is described once; the inner occurrence carries only its name.
+func SchemaOf(v any) *Schema {
+	if v == nil {
+		return nil
+	}
+	return schemaOf(reflect.TypeOf(v), map[reflect.Type]bool{})
+}
+
+func schemaOf(t reflect.Type, seen map[reflect.Type]bool) *Schema {
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == durationType:
+		return &Schema{Type: "integer", Format: "duration"}
+	case t == rawJSONType:
+		return &Schema{Type: "any"}
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		s := schemaOf(t.Elem(), seen)
+		s.Nullable = true
+		return s
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"} // base64
+		}
+		return &Schema{Type: "array", Elem: schemaOf(t.Elem(), seen)}
+	case reflect.Map:
+		return &Schema{Type: "map", Elem: schemaOf(t.Elem(), seen)}
+	case reflect.Struct:
+		if t.Implements(marshalerType) || reflect.PointerTo(t).Implements(marshalerType) {
+			return &Schema{Type: "any", Name: t.Name()}
+		}
+		if seen[t] {
+			return &Schema{Type: "object", Name: t.Name()}
+		}
+		seen[t] = true
+		defer delete(seen, t)
+		return &Schema{Type: "object", Name: t.Name(), Fields: structFields(t, seen)}
+	}
+	return &Schema{Type: "any"}
+}
+
+// structFields lists t's JSON properties the way encoding/json names them, with embedded
+// structs flattened
+func structFields(t reflect.Type, seen map[reflect.Type]bool) []Field {
+	var out []Field
+	for i := range t.NumField() {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			et := f.Type
+			if et.Kind() == reflect.Pointer {
+				et = et.Elem()
+			}
+			if et.Kind() == reflect.Struct {
+				out = append(out, structFields(et, seen)...)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		rules := f.Tag.Get("binding")
+		field := Field{Name: name, Schema: schemaOf(f.Type, seen), Rules: rules, Optional: slices.Contains(strings.Split(opts, ","), "omitempty")}
+		for _, r := range strings.Split(rules, ",") {
+			switch {
+			case r == "required":
+				field.Required = true
+			case strings.HasPrefix(r, "oneof="):
+				field.Schema.Enum = strings.Fields(strings.TrimPrefix(r, "oneof="))
+			}
+		}
+		out = append(out, field)
+	}
+	return out
+}
@@ -0,0 +1,66 @@
+package router
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/config"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/container"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+)
+
+const startupProbeTimeout = 2 * time.Second
+
+// StartupReport describes the process once every module is registered: build, modules and
+// subsystems, route count, server versions of the container's connections and the effective
+// configuration
+func (r *Registry) StartupReport(ctx context.Context) *helpers.StartupReport {
+	rep := &helpers.StartupReport{
+		StartedAt:    time.Now().UTC(),
+		Build:        helpers.ReadBuildInfo(),
+		Modules:      r.Modules(),
+		Subsystems:   map[string]bool{},
+		Skipped:      r.SkippedModules(),
+		Routes:       len(r.Routes()),
+		Dependencies: serverVersions(ctx),
+	}
+	for _, name := range config.Subsystems {
+		rep.Subsystems[name] = r.Enabled(name)
+	}
+	if cfg := container.GetConfig(); cfg != nil {
+		rep.App, rep.Env, rep.Config = cfg.AppName, cfg.Env, cfg.Effective()
+	}
+	return rep
+}
+
+// serverVersions asks Postgres, Redis and RabbitMQ for their versions; "unknown" when one does not answer
+func serverVersions(ctx context.Context) map[string]string {
+	ctx, cancel := context.WithTimeout(ctx, startupProbeTimeout)
+	defer cancel()
+	out := map[string]string{}
+	if pool := container.GetPGPool(); pool != nil {
+		out["postgres"] = "unknown"
+		var v string
+		if err := pool.QueryRow(ctx, "SHOW server_version").Scan(&v); err == nil {
+			out["postgres"] = v
+		}
+	}
+	if rdb := container.GetRedis(); rdb != nil {
+		out["redis"] = "unknown"
+		if info, err := rdb.Info(ctx, "server").Result(); err == nil {
+			for _, line := range strings.Split(info, "\n") {
+				if v, ok := strings.CutPrefix(strings.TrimSpace(line), "redis_version:"); ok {
+					out["redis"] = v
+				}
+			}
+		}
+	}
+	if pub := container.GetRabbitPub(); pub != nil {
+		out["rabbitmq"] = "unknown"
+		if v := pub.ServerVersion(); v != "" {
+			out["rabbitmq"] = v
+		}
+	}
+	return out
+}
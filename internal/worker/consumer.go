@@ -0,0 +1,146 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/google/uuid"
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+)
+
+// Message outcomes counted by QueueMetrics
+const (
+	OutcomeAcked    = "acked"
+	OutcomeRequeued = "requeued"
+)
+
+// Consumer consumes every queue of a Registry. Each queue gets its own channel, so its prefetch
+// and a channel error stay its own, and Concurrency goroutines sharing its deliveries. Acks,
+// requeues and dead-lettering are decided here from the handler's error, the same for every queue.
+// It is used by cmd/email_worker and by cmd/main when RUN_EMBEDDED_WORKER=true.
+type Consumer struct {
+	Open     func() (*amqp.Channel, error) // e.g. Connection.Channel or RabbitPublisher.Channel
+	Registry *Registry
+	Metrics  *QueueMetrics // optional message counters
+}
+
+func NewConsumer(open func() (*amqp.Channel, error), reg *Registry) *Consumer {
+	return &Consumer{Open: open, Registry: reg}
+}
+
+type consuming struct {
+	q    *Queue
+	ch   *amqp.Channel
+	tag  string
+	msgs <-chan amqp.Delivery
+}
+
+// Run declares and consumes every queue until ctx is cancelled or the channels close. On
+// cancellation the consumers are cancelled on the broker and in-flight messages are finished first.
+// A queue that cannot be consumed stops the others and is returned as the error.
+func (c *Consumer) Run(ctx context.Context) error {
+	var started []consuming
+	stop := func() {
+		for _, s := range started {
+			_ = s.ch.Cancel(s.tag, false)
+		}
+	}
+	closeAll := func() {
+		for _, s := range started {
+			_ = s.ch.Close()
+		}
+	}
+	for _, q := range c.Registry.Queues() {
+		s, err := c.start(q)
+		if err != nil {
+			stop()
+			closeAll()
+			return fmt.Errorf("queue %s: %w", q.Name(), err)
+		}
+		started = append(started, s)
+	}
+	go func() {
+		<-ctx.Done()
+		// Stops new deliveries; each msgs is closed once its buffered deliveries drain
+		stop()
+	}()
+
+	var wg sync.WaitGroup
+	for _, s := range started {
+		for range max(s.q.Concurrency, 1) {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for msg := range s.msgs {
+					c.process(s.q, msg)
+				}
+			}()
+		}
+	}
+	wg.Wait()
+	closeAll()
+	return nil
+}
+
+func (c *Consumer) start(q *Queue) (consuming, error) {
+	ch, err := c.Open()
+	if err != nil {
+		return consuming{}, fmt.Errorf("channel: %w", err)
+	}
+	if err := helpers.DeclareTopology(ch, q.Topology); err != nil {
+		_ = ch.Close()
+		return consuming{}, fmt.Errorf("declare: %w", err)
+	}
+	// Prefetch biar fair dispatch, and enough to keep every goroutine busy
+	if err := ch.Qos(max(q.Prefetch, q.Concurrency, 1), 0, false); err != nil {
+		_ = ch.Close()
+		return consuming{}, fmt.Errorf("qos: %w", err)
+	}
+	tag := q.Kind + "-worker-" + uuid.NewString()
+	msgs, err := ch.Consume(q.Name(), tag, false, false, false, false, nil)
+	if err != nil {
+		_ = ch.Close()
+		return consuming{}, fmt.Errorf("consume: %w", err)
+	}
+	return consuming{q: q, ch: ch, tag: tag, msgs: msgs}, nil
+}
+
+// process runs the message's handler and settles the delivery
+func (c *Consumer) process(q *Queue, msg amqp.Delivery) {
+	// In-flight messages are not tied to the shutdown context so work is never cut off halfway
+	ctx := context.Background()
+
+	h := q.handler(msg.Type)
+	var err error
+	if h == nil {
+		err = Permanent(fmt.Errorf("no handler for message type %q", msg.Type))
+	} else {
+		err = h.Handle(ctx, msg)
+	}
+	if err == nil {
+		c.Metrics.Observe(q.Kind, msg.Type, OutcomeAcked)
+		_ = msg.Ack(false)
+		return
+	}
+
+	dead := IsPermanent(err) || q.Retries.Exhausted(ctx, retryKey(err, msg), msg.Redelivered)
+	if obs, ok := h.(FailureObserver); ok {
+		obs.Failed(ctx, msg, err, dead)
+	} else if dead {
+		log.Printf("queue=%s type=%s message_id=%s dead-lettered: %v", q.Name(), msg.Type, msg.MessageId, err)
+	} else {
+		log.Printf("queue=%s type=%s message_id=%s failed, requeued: %v", q.Name(), msg.Type, msg.MessageId, err)
+	}
+	if dead {
+		// Reject without requeue so the broker moves it to the DLQ
+		c.Metrics.Observe(q.Kind, msg.Type, OutcomeDeadLettered)
+		_ = msg.Nack(false, false)
+		return
+	}
+	c.Metrics.Observe(q.Kind, msg.Type, OutcomeRequeued)
+	_ = msg.Nack(false, true)
+}
@@ -0,0 +1,42 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/keyspace"
+)
+
+// JobDedup remembers processed EmailJob.DedupKey values for TTL so a job published twice (publisher
+// retry) or delivered twice (at-least-once redelivery) is sent once. Redis errors fail open.
+type JobDedup struct {
+	Redis *redis.Client
+	TTL   time.Duration
+}
+
+func NewJobDedup(rdb *redis.Client, ttl time.Duration) *JobDedup {
+	return &JobDedup{Redis: rdb, TTL: ttl}
+}
+
+func keyEmailDedup(k string) string { return keyspace.Key("email:dedup:" + k) }
+
+// Claim marks key as processed and reports whether this is its first claim; nil-safe.
+func (d *JobDedup) Claim(ctx context.Context, key string) bool {
+	if d == nil || d.Redis == nil || d.TTL <= 0 || key == "" {
+		return true
+	}
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	ok, err := d.Redis.SetNX(ctx, keyEmailDedup(key), 1, d.TTL).Result()
+	return err != nil || ok
+}
+
+// Release forgets key after a failed send so the redelivered job is not skipped.
+func (d *JobDedup) Release(ctx context.Context, key string) {
+	if d == nil || d.Redis == nil || key == "" {
+		return
+	}
+	_ = d.Redis.Del(ctx, keyEmailDedup(key)).Err()
+}
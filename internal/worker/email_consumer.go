@@ -0,0 +1,159 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/mailer"
+	mailtpl "github.com/oksasatya/go-ddd-clean-architecture/pkg/mailer/templates"
+)
+
+// EmailConsumer renders and sends EmailJob messages; it is the handler of the emails queue.
+type EmailConsumer struct {
+	Sender  mailer.Sender
+	Geo     mailtpl.GeoResolver
+	Limit   *RecipientLimit // optional per-recipient cap
+	Dedup   *JobDedup       // optional; skips jobs whose DedupKey was already sent
+	Metrics *EmailMetrics   // optional job counters
+	Redis   *redis.Client   // optional; records the outcome of jobs with a DeliveryID for status polling
+	// CorrelationTTL keeps the outcome of jobs with a CorrelationID in their flow's trail (0 = not recorded)
+	CorrelationTTL time.Duration
+}
+
+func NewEmailConsumer(sender mailer.Sender, geo mailtpl.GeoResolver) *EmailConsumer {
+	return &EmailConsumer{Sender: sender, Geo: geo}
+}
+
+// Handle sends one job. Jobs that cannot be decoded or rendered are permanent failures; a failed
+// send is retried under the job's DedupKey.
+func (w *EmailConsumer) Handle(ctx context.Context, msg amqp.Delivery) error {
+	// Older payload shapes are upgraded here; a newer one than this worker knows is dead-lettered
+	job, err := mailer.DecodeEmailJob(msg.Body)
+	if err != nil {
+		return Permanent(fmt.Errorf("bad message: %w", err))
+	}
+
+	helpers.EnsureRecipientAndEmail(&job)
+
+	// Localize times if we can
+	helpers.LocalizeTimesIfPossible(ctx, w.Geo, job.Data, job.Locale)
+
+	// Render
+	subject := job.Subject
+	text := job.Text
+	html := job.HTML
+
+	if job.Template != "" {
+		if strings.EqualFold(job.Template, "universal") {
+			// Producers validate on enqueue; this catches jobs published around the registry
+			if _, verr := mailtpl.ValidateData(job.Data); verr != nil {
+				return Permanent(fmt.Errorf("invalid universal data: %w", verr))
+			}
+			if loc, ok := job.Data["Location"]; !ok || fmt.Sprintf("%v", loc) == "" {
+				if ipVal, okIP := job.Data["IP"]; okIP && w.Geo != nil {
+					if g, err := w.Geo.Lookup(ctx, fmt.Sprintf("%v", ipVal)); err == nil {
+						job.Data["Location"] = mailtpl.FormatGeo(g)
+					}
+				}
+			}
+			htmlStr, rerr := mailtpl.RenderHTML("universal", job.Data)
+			if rerr != nil {
+				return Permanent(fmt.Errorf("render universal failed: %w", rerr))
+			}
+			html = htmlStr
+			subject = helpers.SubjectForUniversal(job.Data, job.Locale)
+		} else {
+			s, t, h, rerr := mailtpl.Render(job.Template, job.Data)
+			if rerr != nil {
+				return Permanent(fmt.Errorf("render %s failed: %w", job.Template, rerr))
+			}
+			subject, text, html = s, t, h
+		}
+	}
+
+	// Tag by email type so Mailgun analytics split per email out of the box
+	if len(job.Tags) == 0 {
+		if typ, _ := job.Data["Type"].(string); typ != "" {
+			job.Tags = []string{typ}
+		}
+	}
+
+	// Already sent under this dedup key (publisher retry or redelivery)
+	if !w.Dedup.Claim(ctx, job.DedupKey) {
+		logJob(job, "duplicate email job skipped: dedup_key=%s", job.DedupKey)
+		w.observe(ctx, job, OutcomeDuplicate)
+		return nil
+	}
+
+	// Over the recipient's cap: drop (ack) rather than retry, the point is to stop the storm
+	if !w.Limit.Allow(ctx, job) {
+		logJob(job, "recipient limit reached, email dropped: template=%s type=%v", job.Template, job.Data["Type"])
+		w.observe(ctx, job, OutcomeLimited)
+		return nil
+	}
+
+	// Send
+	c, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+	if err := w.Sender.Send(c, job.To, subject, text, html, job.Envelope); err != nil {
+		w.Dedup.Release(ctx, job.DedupKey)
+		return WithRetryKey(job.DedupKey, fmt.Errorf("send failed: %w", err))
+	}
+	w.observe(ctx, job, OutcomeSent)
+	return nil
+}
+
+// Failed logs a failed job with its producer ids and records its outcome
+func (w *EmailConsumer) Failed(ctx context.Context, msg amqp.Delivery, err error, deadLettered bool) {
+	job, derr := mailer.DecodeEmailJob(msg.Body)
+	if derr != nil {
+		// Salvage the producer ids so the dead-lettered message can still be traced
+		_ = json.Unmarshal(msg.Body, &job.JobMeta)
+	}
+	switch {
+	case IsPermanent(err):
+		logJob(job, "%v", err)
+		w.observe(ctx, job, OutcomeInvalid)
+	case deadLettered:
+		// Out of attempts: the consumer rejects it without requeue so it moves to the DLQ
+		logJob(job, "%v, dead-lettered", err)
+		w.observe(ctx, job, OutcomeDeadLettered)
+	default:
+		logJob(job, "%v", err)
+		w.observe(ctx, job, OutcomeFailed)
+	}
+}
+
+// observe counts the job outcome and, for tracked jobs, records it as the delivery state and in
+// the trail of the job's auth flow
+func (w *EmailConsumer) observe(ctx context.Context, job mailer.EmailJob, outcome string) {
+	w.Metrics.Observe(job, outcome)
+	if w.Redis == nil || (job.DeliveryID == "" && job.CorrelationID == "") {
+		return
+	}
+	c, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	if err := helpers.RecordEmailDelivery(c, w.Redis, job.DeliveryID, outcome); err != nil {
+		logJob(job, "record delivery state failed: %v", err)
+	}
+	ev := helpers.CorrelationEvent{Source: helpers.CorrelationEmail, Event: outcome, UserID: job.UserID, Fields: map[string]any{"email_type": jobType(job)}}
+	if job.DeliveryID != "" {
+		ev.Fields["delivery_id"] = job.DeliveryID
+	}
+	if err := helpers.RecordCorrelation(c, w.Redis, job.CorrelationID, w.CorrelationTTL, ev); err != nil {
+		logJob(job, "record correlation trail failed: %v", err)
+	}
+}
+
+// logJob prefixes a worker log line with the request_id and user_id of the producing API call
+func logJob(job mailer.EmailJob, format string, args ...any) {
+	log.Printf("request_id=%s user_id=%s "+format, append([]any{job.RequestID, job.UserID}, args...)...)
+}
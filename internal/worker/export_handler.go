@@ -0,0 +1,34 @@
+package worker
+
+import (
+	"context"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// ExportMessageType is the AMQP type of the messages that announce a queued export job
+const ExportMessageType = "export.requested"
+
+// ExportRunner claims and runs one queued export job, false when none is left
+// (application.ExportService)
+type ExportRunner interface {
+	RunNext(ctx context.Context) (bool, error)
+}
+
+// ExportHandler consumes the exports queue. Export jobs live in Postgres and every instance also
+// polls for them; a message only wakes a worker at once, so the handler runs queued jobs until none
+// is left whatever the message says, and a job another instance got to first is not an error.
+type ExportHandler struct {
+	Runner ExportRunner
+}
+
+func NewExportHandler(r ExportRunner) *ExportHandler { return &ExportHandler{Runner: r} }
+
+func (h *ExportHandler) Handle(ctx context.Context, _ amqp.Delivery) error {
+	for {
+		ran, err := h.Runner.RunNext(ctx)
+		if err != nil || !ran {
+			return err
+		}
+	}
+}
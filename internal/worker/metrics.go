@@ -0,0 +1,149 @@
+package worker
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/mailer"
+)
+
+// Job outcomes counted by EmailMetrics
+const (
+	OutcomeSent         = "sent"
+	OutcomeFailed       = "failed"        // send error, requeued
+	OutcomeDeadLettered = "dead_lettered" // send kept failing, moved to the DLQ
+	OutcomeInvalid      = "invalid"       // undecodable or unrenderable, dead-lettered
+	OutcomeDuplicate    = "duplicate"     // dedup key already sent
+	OutcomeLimited      = "rate_limited"
+)
+
+// recentFailures bounds the request_id/user_id labelled series so they cannot grow without limit
+const recentFailures = 20
+
+type failedJob struct {
+	meta    mailer.JobMeta
+	typ     string
+	outcome string
+	at      time.Time
+}
+
+// EmailMetrics counts processed jobs by email type and outcome, and keeps the last few failed
+// jobs with their producer request_id and user_id so an alert can be traced to the API call.
+type EmailMetrics struct {
+	mu       sync.Mutex
+	counts   map[[2]string]int64 // {type, outcome}
+	failures []failedJob
+}
+
+func NewEmailMetrics() *EmailMetrics {
+	return &EmailMetrics{counts: map[[2]string]int64{}}
+}
+
+// Observe records one job; nil-safe.
+func (m *EmailMetrics) Observe(job mailer.EmailJob, outcome string) {
+	if m == nil {
+		return
+	}
+	typ := jobType(job)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counts[[2]string{typ, outcome}]++
+	if outcome == OutcomeFailed || outcome == OutcomeInvalid || outcome == OutcomeDeadLettered {
+		// A requeued job fails again under the same labels; keep one series per job
+		kept := m.failures[:0]
+		for _, f := range m.failures {
+			if f.meta != job.JobMeta || f.typ != typ || f.outcome != outcome {
+				kept = append(kept, f)
+			}
+		}
+		m.failures = append(kept, failedJob{meta: job.JobMeta, typ: typ, outcome: outcome, at: time.Now()})
+		if len(m.failures) > recentFailures {
+			m.failures = m.failures[len(m.failures)-recentFailures:]
+		}
+	}
+}
+
+// WriteMetrics appends the counters and recent failures in Prometheus text format.
+func (m *EmailMetrics) WriteMetrics(b *strings.Builder) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	keys := make([][2]string, 0, len(m.counts))
+	for k := range m.counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i][0] < keys[j][0] || keys[i][0] == keys[j][0] && keys[i][1] < keys[j][1]
+	})
+	b.WriteString("# HELP email_worker_jobs_total Email jobs processed by type and outcome.\n# TYPE email_worker_jobs_total counter\n")
+	for _, k := range keys {
+		fmt.Fprintf(b, "email_worker_jobs_total{type=%q,outcome=%q} %d\n", k[0], k[1], m.counts[k])
+	}
+	b.WriteString("# HELP email_worker_recent_failure_timestamp_seconds Last failed jobs with the request that enqueued them.\n# TYPE email_worker_recent_failure_timestamp_seconds gauge\n")
+	for _, f := range m.failures {
+		fmt.Fprintf(b, "email_worker_recent_failure_timestamp_seconds{type=%q,outcome=%q,request_id=%q,user_id=%q} %d\n",
+			f.typ, f.outcome, f.meta.RequestID, f.meta.UserID, f.at.Unix())
+	}
+}
+
+// jobType is the label for a job: the universal email type, the template name or "raw"
+func jobType(job mailer.EmailJob) string {
+	if typ, _ := job.Data["Type"].(string); typ != "" {
+		return typ
+	}
+	if job.Template != "" {
+		return job.Template
+	}
+	return "raw"
+}
+
+// QueueMetrics counts settled messages by queue kind, AMQP message type and outcome (acked,
+// requeued, dead_lettered), whatever handled them
+type QueueMetrics struct {
+	mu     sync.Mutex
+	counts map[[3]string]int64 // {queue, type, outcome}
+}
+
+func NewQueueMetrics() *QueueMetrics {
+	return &QueueMetrics{counts: map[[3]string]int64{}}
+}
+
+// Observe records one message; nil-safe.
+func (m *QueueMetrics) Observe(queue, msgType, outcome string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counts[[3]string{queue, msgType, outcome}]++
+}
+
+// WriteMetrics appends the counters in Prometheus text format.
+func (m *QueueMetrics) WriteMetrics(b *strings.Builder) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	keys := make([][3]string, 0, len(m.counts))
+	for k := range m.counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		for n := range keys[i] {
+			if keys[i][n] != keys[j][n] {
+				return keys[i][n] < keys[j][n]
+			}
+		}
+		return false
+	})
+	b.WriteString("# HELP worker_messages_total Queue messages settled by queue, message type and outcome.\n# TYPE worker_messages_total counter\n")
+	for _, k := range keys {
+		fmt.Fprintf(b, "worker_messages_total{queue=%q,type=%q,outcome=%q} %d\n", k[0], k[1], k[2], m.counts[k])
+	}
+}
@@ -0,0 +1,83 @@
+package worker
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/keyspace"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/mailer"
+	mailtpl "github.com/oksasatya/go-ddd-clean-architecture/pkg/mailer/templates"
+)
+
+// RecipientLimit caps emails per recipient address per hour and per day so a bug or abuse of
+// /api/email/send cannot flood one inbox; cc and bcc recipients count like to. Security emails are
+// exempt: only the server enqueues them, /api/email/send and broadcasts reject their types. Redis
+// errors fail open.
+type RecipientLimit struct {
+	Redis  *redis.Client
+	Hourly int // <= 0 = unlimited
+	Daily  int // <= 0 = unlimited
+}
+
+func NewRecipientLimit(rdb *redis.Client, hourly, daily int) *RecipientLimit {
+	return &RecipientLimit{Redis: rdb, Hourly: hourly, Daily: daily}
+}
+
+// Allow counts the job against each of its recipients and reports whether it may be sent, false
+// when any recipient is over a limit; nil-safe.
+func (l *RecipientLimit) Allow(ctx context.Context, job mailer.EmailJob) bool {
+	if l == nil || l.Redis == nil || (l.Hourly <= 0 && l.Daily <= 0) {
+		return true
+	}
+	if typ, _ := job.Data["Type"].(string); job.Template != "" && mailtpl.IsSecurity(typ) {
+		return true
+	}
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	allowed := true
+	for _, rcpt := range recipients(job) {
+		sum := sha256.Sum256([]byte(rcpt))
+		id := hex.EncodeToString(sum[:12])
+		for _, w := range []struct {
+			key    string
+			limit  int
+			window time.Duration
+		}{
+			{keyspace.Key("email:rcpt:h:" + id), l.Hourly, time.Hour},
+			{keyspace.Key("email:rcpt:d:" + id), l.Daily, 24 * time.Hour},
+		} {
+			if w.limit <= 0 {
+				continue
+			}
+			res, err := helpers.ConsumeQuota(ctx, l.Redis, w.key, 1, int64(w.limit), w.window)
+			if err == nil && res.Exceeded() {
+				allowed = false
+			}
+		}
+	}
+	return allowed
+}
+
+// recipients are the job's to, cc and bcc addresses, normalized and once each
+func recipients(job mailer.EmailJob) []string {
+	seen := map[string]bool{}
+	out := make([]string, 0, 1+len(job.CC)+len(job.BCC))
+	for _, addr := range append(append([]string{job.To}, job.CC...), job.BCC...) {
+		if a, err := mail.ParseAddress(addr); err == nil {
+			addr = a.Address
+		}
+		addr = strings.ToLower(strings.TrimSpace(addr))
+		if addr != "" && !seen[addr] {
+			seen[addr] = true
+			out = append(out, addr)
+		}
+	}
+	return out
+}
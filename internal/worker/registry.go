@@ -0,0 +1,135 @@
+package worker
+
+import (
+	"context"
+	"errors"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+)
+
+// Handler processes one message. nil acks it; an error wrapped with Permanent dead-letters it at
+// once; any other error requeues it until the queue's retry budget for the message is spent, then
+// dead-letters it. The context is not cancelled on shutdown, so work is never cut off halfway.
+type Handler interface {
+	Handle(ctx context.Context, msg amqp.Delivery) error
+}
+
+// HandlerFunc adapts a function to Handler
+type HandlerFunc func(ctx context.Context, msg amqp.Delivery) error
+
+func (f HandlerFunc) Handle(ctx context.Context, msg amqp.Delivery) error { return f(ctx, msg) }
+
+// FailureObserver is implemented by handlers that record what became of a message they failed;
+// the consumer logs failures of other handlers itself.
+type FailureObserver interface {
+	Failed(ctx context.Context, msg amqp.Delivery, err error, deadLettered bool)
+}
+
+type permanentError struct{ err error }
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as one a retry cannot fix (bad payload, rejected by the receiver)
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was marked with Permanent
+func IsPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
+
+type retryKeyError struct {
+	key string
+	err error
+}
+
+func (e *retryKeyError) Error() string { return e.err.Error() }
+func (e *retryKeyError) Unwrap() error { return e.err }
+
+// WithRetryKey names the attempts of a failed message for the retry budget, e.g. a job's dedup key
+// that survives republishing; without one the AMQP message id is used
+func WithRetryKey(key string, err error) error {
+	if err == nil || key == "" {
+		return err
+	}
+	return &retryKeyError{key: key, err: err}
+}
+
+func retryKey(err error, msg amqp.Delivery) string {
+	var k *retryKeyError
+	if errors.As(err, &k) {
+		return k.key
+	}
+	return msg.MessageId
+}
+
+// Queue is one consumed queue: its topology, how many messages it handles at once and its
+// handlers by AMQP message type
+type Queue struct {
+	Kind        string // WORKER_QUEUES name (emails, webhooks, exports)
+	Topology    helpers.RabbitTopology
+	Concurrency int          // messages handled at once (default 1)
+	Prefetch    int          // unacknowledged deliveries held, at least Concurrency (default 16)
+	Retries     *RetryBudget // failed attempts allowed before dead-lettering (nil = one redelivery)
+
+	handlers map[string]Handler
+}
+
+// Name is the broker queue name
+func (q *Queue) Name() string { return q.Topology.Queue }
+
+// Handle routes messages of msgType to h; "" catches every type without its own handler
+func (q *Queue) Handle(msgType string, h Handler) *Queue {
+	q.handlers[msgType] = h
+	return q
+}
+
+// handler returns the handler for msgType, falling back to the queue's catch-all
+func (q *Queue) handler(msgType string) Handler {
+	if h, ok := q.handlers[msgType]; ok {
+		return h
+	}
+	return q.handlers[""]
+}
+
+// Registry maps queues and message types to handlers. Messages without a handler for their type
+// (and no catch-all) are dead-lettered.
+type Registry struct {
+	queues []*Queue
+}
+
+func NewRegistry() *Registry { return &Registry{} }
+
+// Add registers a queue, replacing one with the same kind, and returns it for Handle
+func (r *Registry) Add(kind string, t helpers.RabbitTopology) *Queue {
+	q := &Queue{Kind: kind, Topology: t, Concurrency: 1, Prefetch: 16, handlers: map[string]Handler{}}
+	for i, old := range r.queues {
+		if old.Kind == kind {
+			r.queues[i] = q
+			return q
+		}
+	}
+	r.queues = append(r.queues, q)
+	return q
+}
+
+// Queue returns the queue registered under kind, nil if none
+func (r *Registry) Queue(kind string) *Queue {
+	for _, q := range r.queues {
+		if q.Kind == kind {
+			return q
+		}
+	}
+	return nil
+}
+
+// Queues returns the registered queues in the order they were added
+func (r *Registry) Queues() []*Queue { return r.queues }
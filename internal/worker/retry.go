@@ -0,0 +1,47 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/keyspace"
+)
+
+// RetryBudget counts failed attempts per message (by its retry key) so a message that keeps failing
+// is dead-lettered after Max attempts instead of being requeued forever. Classic queues do not
+// count redeliveries, so the count lives in Redis; without it a message gets one redelivery.
+type RetryBudget struct {
+	Redis *redis.Client
+	Max   int
+	TTL   time.Duration // how long a message's count is kept after its last failure
+	Scope string        // key namespace, one per queue ("email" for the email queue)
+}
+
+func NewRetryBudget(rdb *redis.Client, max int) *RetryBudget {
+	return &RetryBudget{Redis: rdb, Max: max, TTL: 24 * time.Hour, Scope: "email"}
+}
+
+func keyAttempts(scope, k string) string { return keyspace.Key(scope + ":attempts:" + k) }
+
+// Exhausted records a failed attempt and reports whether the message should be dead-lettered
+// rather than requeued; a nil budget allows no redelivery beyond the first.
+func (b *RetryBudget) Exhausted(ctx context.Context, key string, redelivered bool) bool {
+	if b == nil || b.Redis == nil || b.Max <= 0 || key == "" {
+		return redelivered
+	}
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	n, err := b.Redis.Incr(ctx, keyAttempts(b.Scope, key)).Result()
+	if err != nil {
+		return redelivered
+	}
+	_ = b.Redis.Expire(ctx, keyAttempts(b.Scope, key), b.TTL).Err()
+	if n >= int64(b.Max) {
+		// A job requeued from the DLQ starts with a fresh budget
+		_ = b.Redis.Del(ctx, keyAttempts(b.Scope, key)).Err()
+		return true
+	}
+	return false
+}
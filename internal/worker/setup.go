@@ -0,0 +1,60 @@
+package worker
+
+import (
+	"log"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/config"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+)
+
+// Deps are what the queue handlers need. A queue whose handler has nothing to work with is left
+// out with a log line: emails without an Email consumer (MAIL_SEND_ENABLED=false, no mail driver),
+// exports without an Exports runner (cmd/email_worker has no Postgres or GCS).
+type Deps struct {
+	Email   *EmailConsumer
+	Exports ExportRunner
+	Redis   *redis.Client // retry budgets; nil = one redelivery per message
+}
+
+// NewRegistryFromConfig registers the queues WORKER_QUEUES lists with their handlers,
+// WORKER_CONCURRENCY and WORKER_PREFETCH. Unknown queue kinds are errors.
+func NewRegistryFromConfig(cfg *config.Config, deps Deps) (*Registry, error) {
+	reg := NewRegistry()
+	for _, kind := range cfg.WorkerQueueList() {
+		t, err := helpers.WorkerTopology(cfg, kind)
+		if err != nil {
+			return nil, err
+		}
+		var (
+			h       Handler
+			msgType string // "" = every message on the queue
+			retries *RetryBudget
+		)
+		switch kind {
+		case helpers.WorkerQueueEmails:
+			if deps.Email == nil {
+				log.Printf("worker queue %s skipped: email sending unavailable", kind)
+				continue
+			}
+			h, retries = deps.Email, NewRetryBudget(deps.Redis, cfg.EmailMaxAttempts)
+		case helpers.WorkerQueueWebhooks:
+			h, msgType = NewWebhookHandler(cfg.WebhookSigningSecret, cfg.WebhookTimeout), helpers.WebhookMessageType
+			retries = NewRetryBudget(deps.Redis, cfg.WebhookMaxAttempts)
+			retries.Scope = "webhook"
+		case helpers.WorkerQueueExports:
+			if deps.Exports == nil {
+				log.Printf("worker queue %s skipped: exports run only in the embedded worker with GCS configured", kind)
+				continue
+			}
+			h, retries = NewExportHandler(deps.Exports), NewRetryBudget(deps.Redis, 3)
+			retries.Scope = "export"
+		}
+		q := reg.Add(kind, t).Handle(msgType, h)
+		q.Concurrency = cfg.WorkerConcurrencyFor(kind)
+		q.Prefetch = cfg.WorkerPrefetch
+		q.Retries = retries
+	}
+	return reg, nil
+}
@@ -0,0 +1,76 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+)
+
+// webhookErrorBytes bounds how much of a failed response ends up in the log
+const webhookErrorBytes = 512
+
+// WebhookHandler delivers helpers.WebhookJob messages from the webhooks queue: the payload is
+// POSTed to the job's URL with X-Webhook-Id, X-Webhook-Event and, with a Secret, X-Webhook-Signature
+// (see helpers.SignWebhook). A 2xx answer acks the job; any other 4xx except 408 and 429
+// dead-letters it at once, the receiver will not change its mind; anything else is retried.
+type WebhookHandler struct {
+	Client *http.Client
+	Secret string
+}
+
+func NewWebhookHandler(secret string, timeout time.Duration) *WebhookHandler {
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return &WebhookHandler{Client: &http.Client{Timeout: timeout}, Secret: secret}
+}
+
+func (h *WebhookHandler) Handle(ctx context.Context, msg amqp.Delivery) error {
+	var job helpers.WebhookJob
+	if err := json.Unmarshal(msg.Body, &job); err != nil {
+		return Permanent(fmt.Errorf("bad webhook job: %w", err))
+	}
+	if job.URL == "" {
+		return Permanent(fmt.Errorf("webhook job %s has no url", job.ID))
+	}
+	body := []byte(job.Payload)
+	if len(body) == 0 {
+		body = []byte("null")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, job.URL, bytes.NewReader(body))
+	if err != nil {
+		return Permanent(fmt.Errorf("webhook job %s: %w", job.ID, err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Id", job.ID)
+	req.Header.Set("X-Webhook-Event", job.Event)
+	if job.RequestID != "" {
+		req.Header.Set("X-Request-ID", job.RequestID)
+	}
+	if h.Secret != "" {
+		req.Header.Set("X-Webhook-Signature", helpers.SignWebhook(h.Secret, time.Now(), body))
+	}
+	res, err := h.Client.Do(req)
+	if err != nil {
+		return WithRetryKey(job.ID, fmt.Errorf("webhook %s: %w", job.ID, err))
+	}
+	defer res.Body.Close()
+	if res.StatusCode/100 == 2 {
+		_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+		return nil
+	}
+	msgBody, _ := io.ReadAll(io.LimitReader(res.Body, webhookErrorBytes))
+	err = fmt.Errorf("webhook %s answered %d: %s", job.ID, res.StatusCode, bytes.TrimSpace(msgBody))
+	if res.StatusCode/100 == 4 && res.StatusCode != http.StatusRequestTimeout && res.StatusCode != http.StatusTooManyRequests {
+		return Permanent(err)
+	}
+	return WithRetryKey(job.ID, err)
+}
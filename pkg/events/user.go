@@ -0,0 +1,48 @@
+// Package events defines the payloads published to the user events exchange (USER_EVENTS_EXCHANGE).
+// Other services bind their own queues to it, e.g. with "user.*" on the topic exchange.
+package events
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// User lifecycle event types; each is also the routing key.
+const (
+	UserCreated  = "user.created"
+	UserVerified = "user.verified"
+	UserUpdated  = "user.updated"
+	UserDeleted  = "user.deleted" // reserved: the API has no account deletion yet
+)
+
+// UserEventVersion is bumped on breaking payload changes; consumers should ignore versions they do not know.
+const UserEventVersion = 1
+
+// UserEvent is the message body. Delivery is at least once: dedupe on ID.
+type UserEvent struct {
+	ID         string    `json:"id"`
+	Type       string    `json:"type"`
+	Version    int       `json:"version"`
+	OccurredAt time.Time `json:"occurred_at"`
+	User       User      `json:"user"`
+	// Changes names the fields a user.updated event changed (name, avatar_url, email, password)
+	Changes []string `json:"changes,omitempty"`
+}
+
+// User is the public view of an account carried by events; it never includes credentials.
+type User struct {
+	ID         string    `json:"id"`
+	ExternalID string    `json:"external_id,omitempty"` // ULID the API shows for the user
+	Email      string    `json:"email"`
+	Name       string    `json:"name"`
+	AvatarURL  string    `json:"avatar_url,omitempty"`
+	Verified   bool      `json:"verified"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// NewUserEvent stamps a fresh ID (a UUIDv7, so IDs sort by time), the current version and time.
+func NewUserEvent(typ string, u User, changes ...string) UserEvent {
+	return UserEvent{ID: uuid.Must(uuid.NewV7()).String(), Type: typ, Version: UserEventVersion, OccurredAt: time.Now().UTC(), User: u, Changes: changes}
+}
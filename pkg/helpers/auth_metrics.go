@@ -0,0 +1,191 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	mailtpl "github.com/oksasatya/go-ddd-clean-architecture/pkg/mailer/templates"
+)
+
+// Login outcomes counted by AuthMetrics
+const (
+	AuthSuccess     = "success"
+	AuthBadPassword = "bad_password" // wrong password or unknown account
+	AuthLocked      = "locked"       // rejected by the per-account guard while locked
+	AuthOTPFailed   = "otp_failed"   // wrong or expired OTP / backup code
+)
+
+const (
+	authGeoTTL     = time.Hour
+	authGeoCache   = 10000 // cached IPs; the cache is dropped when full
+	authGeoTimeout = 2 * time.Second
+	authOther      = "other"
+	authUnknown    = "unknown"
+)
+
+type authKey struct{ outcome, country, asn string }
+
+type authEvent struct {
+	ip      string
+	outcome string
+}
+
+type authGeo struct {
+	country, asn string
+	expires      time.Time
+}
+
+// AuthMetrics counts login outcomes by country and network (ASN), so a dashboard can spot
+// credential stuffing: a burst of bad_password from a few hosting ASNs. Observe never blocks the
+// request: the geo lookup runs in a background goroutine against a per-IP cache, and events that do
+// not fit the queue are counted without location. Past MaxSeries distinct label sets, new
+// locations are counted as "other" to bound cardinality.
+type AuthMetrics struct {
+	Geo       mailtpl.GeoResolver
+	MaxSeries int
+
+	events  chan authEvent
+	dropped atomic.Int64
+
+	mu     sync.Mutex
+	counts map[authKey]int64
+	geo    map[string]authGeo
+
+	stop    chan struct{}
+	done    chan struct{}
+	runOnce sync.Once
+	closed  atomic.Bool
+}
+
+func NewAuthMetrics(geo mailtpl.GeoResolver, maxSeries int) *AuthMetrics {
+	if maxSeries <= 0 {
+		maxSeries = 1000
+	}
+	return &AuthMetrics{
+		Geo: geo, MaxSeries: maxSeries,
+		events: make(chan authEvent, 1024),
+		counts: map[authKey]int64{}, geo: map[string]authGeo{},
+		stop: make(chan struct{}), done: make(chan struct{}),
+	}
+}
+
+// Observe records one login outcome from ip; nil-safe and non-blocking
+func (m *AuthMetrics) Observe(ip, outcome string) {
+	if m == nil {
+		return
+	}
+	select {
+	case m.events <- authEvent{ip: ip, outcome: outcome}:
+	default:
+		m.dropped.Add(1)
+		m.add(authKey{outcome, authUnknown, authUnknown})
+	}
+}
+
+// Start resolves and counts queued events until Close
+func (m *AuthMetrics) Start() {
+	if m == nil {
+		return
+	}
+	m.runOnce.Do(func() { go m.run() })
+}
+
+func (m *AuthMetrics) run() {
+	defer close(m.done)
+	for {
+		select {
+		case <-m.stop:
+			return
+		case ev := <-m.events:
+			country, asn := m.locate(ev.ip)
+			m.add(authKey{ev.outcome, country, asn})
+		}
+	}
+}
+
+// Close stops the worker; events still queued are dropped
+func (m *AuthMetrics) Close(ctx context.Context) {
+	if m == nil || !m.closed.CompareAndSwap(false, true) {
+		return
+	}
+	close(m.stop)
+	m.runOnce.Do(func() { close(m.done) }) // never started
+	select {
+	case <-m.done:
+	case <-ctx.Done():
+	}
+}
+
+// locate returns the country code and ASN of ip, cached for an hour; "unknown" when the lookup fails
+func (m *AuthMetrics) locate(ip string) (string, string) {
+	now := time.Now()
+	m.mu.Lock()
+	g, ok := m.geo[ip]
+	m.mu.Unlock()
+	if ok && now.Before(g.expires) {
+		return g.country, g.asn
+	}
+	g = authGeo{country: authUnknown, asn: authUnknown, expires: now.Add(authGeoTTL)}
+	if m.Geo != nil && ip != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), authGeoTimeout)
+		res, err := m.Geo.Lookup(ctx, ip)
+		cancel()
+		if err == nil {
+			if res.CountryCode != "" {
+				g.country = res.CountryCode
+			}
+			if res.ASN != "" {
+				g.asn = res.ASN
+			}
+		}
+	}
+	m.mu.Lock()
+	if len(m.geo) >= authGeoCache {
+		clear(m.geo)
+	}
+	m.geo[ip] = g
+	m.mu.Unlock()
+	return g.country, g.asn
+}
+
+func (m *AuthMetrics) add(k authKey) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.counts[k]; !ok && len(m.counts) >= m.MaxSeries {
+		k.country, k.asn = authOther, authOther
+	}
+	m.counts[k]++
+}
+
+// WriteMetrics appends auth_login_attempts_total in Prometheus text format. nil-safe.
+func (m *AuthMetrics) WriteMetrics(b *strings.Builder) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	keys := make([]authKey, 0, len(m.counts))
+	for k := range m.counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, c := keys[i], keys[j]
+		if a.outcome != c.outcome {
+			return a.outcome < c.outcome
+		}
+		if a.country != c.country {
+			return a.country < c.country
+		}
+		return a.asn < c.asn
+	})
+	b.WriteString("# HELP auth_login_attempts_total Login attempts by outcome, country and ASN of the client.\n# TYPE auth_login_attempts_total counter\n")
+	for _, k := range keys {
+		fmt.Fprintf(b, "auth_login_attempts_total{outcome=%q,country=%q,asn=%q} %d\n", k.outcome, k.country, k.asn, m.counts[k])
+	}
+	m.mu.Unlock()
+	fmt.Fprintf(b, "# HELP auth_login_metrics_unresolved_total Login attempts counted without location because the lookup queue was full.\n# TYPE auth_login_metrics_unresolved_total counter\nauth_login_metrics_unresolved_total %d\n", m.dropped.Load())
+}
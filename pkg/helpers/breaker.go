@@ -0,0 +1,150 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"net"
+	"slices"
+	"sync"
+	"time"
+
+	mailtpl "github.com/oksasatya/go-ddd-clean-architecture/pkg/mailer/templates"
+)
+
+// Dependencies guarded by a circuit breaker; the names are what response meta.degraded lists
+const (
+	DependencySearch = "search" // Elasticsearch
+	DependencyEmail  = "email"  // RabbitMQ email publisher
+	DependencyGeo    = "geo"    // IP geolocation lookups
+)
+
+// ErrBreakerOpen is returned instead of calling a dependency whose breaker is open
+var ErrBreakerOpen = errors.New("dependency unavailable (circuit open)")
+
+// Breaker is a consecutive-failure circuit breaker. After Threshold failures in a row it opens
+// and rejects calls for Cooldown; then one trial call is let through, and its result closes or
+// reopens the breaker. A nil *Breaker allows everything.
+type Breaker struct {
+	Name      string
+	Threshold int
+	Cooldown  time.Duration
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time // zero while closed
+	trial    bool      // a half-open trial call is in flight
+}
+
+// Allow reports whether a call may go to the dependency
+func (b *Breaker) Allow() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.openedAt.IsZero() {
+		return true
+	}
+	if b.trial || time.Since(b.openedAt) < b.Cooldown {
+		return false
+	}
+	b.trial = true
+	return true
+}
+
+// Record feeds the result of an allowed call to the breaker
+func (b *Breaker) Record(err error) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+	if err == nil {
+		b.failures, b.openedAt = 0, time.Time{}
+		return
+	}
+	b.failures++
+	if !b.openedAt.IsZero() || b.failures >= b.Threshold {
+		b.openedAt = time.Now()
+	}
+}
+
+// Open reports whether the breaker is rejecting calls or waiting on a trial call
+func (b *Breaker) Open() bool {
+	if b == nil {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return !b.openedAt.IsZero()
+}
+
+// Breakers holds one Breaker per dependency name, created on first use with shared settings
+type Breakers struct {
+	Threshold int
+	Cooldown  time.Duration
+
+	mu sync.Mutex
+	m  map[string]*Breaker
+}
+
+func NewBreakers(threshold int, cooldown time.Duration) *Breakers {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &Breakers{Threshold: threshold, Cooldown: cooldown, m: map[string]*Breaker{}}
+}
+
+// Get returns the breaker for name; nil for a nil *Breakers, so callers need no checks
+func (bs *Breakers) Get(name string) *Breaker {
+	if bs == nil {
+		return nil
+	}
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	b, ok := bs.m[name]
+	if !ok {
+		b = &Breaker{Name: name, Threshold: bs.Threshold, Cooldown: bs.Cooldown}
+		bs.m[name] = b
+	}
+	return b
+}
+
+// Degraded returns the sorted names of open breakers (implements response.DegradedReporter)
+func (bs *Breakers) Degraded() []string {
+	if bs == nil {
+		return nil
+	}
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	var out []string
+	for name, b := range bs.m {
+		if b.Open() {
+			out = append(out, name)
+		}
+	}
+	slices.Sort(out)
+	return out
+}
+
+// BreakerGeo guards a GeoResolver: lookups fail fast while its breaker is open. Only transport
+// failures (timeouts, network errors) count; unknown or private addresses do not.
+type BreakerGeo struct {
+	Resolver mailtpl.GeoResolver
+	Breaker  *Breaker
+}
+
+func (g BreakerGeo) Lookup(ctx context.Context, ip string) (mailtpl.Geo, error) {
+	if !g.Breaker.Allow() {
+		return mailtpl.Geo{}, ErrBreakerOpen
+	}
+	geo, err := g.Resolver.Lookup(ctx, ip)
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded) {
+		g.Breaker.Record(err)
+	} else {
+		g.Breaker.Record(nil)
+	}
+	return geo, err
+}
@@ -2,43 +2,114 @@ package helpers
 
 import (
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/config"
+)
+
+// Cookie base names; the Manager adds its Prefix
+const (
+	CookieAccessToken  = "access_token"
+	CookieRefreshToken = "refresh_token"
+	CookieDeviceID     = "device_id"
 )
 
 type Manager struct {
-	Domain string
-	Secure bool
+	Domain   string
+	Secure   bool
+	Path     string
+	SameSite http.SameSite
+	// Prefix is prepended to every cookie name, including __Host- or __Secure- when enabled
+	Prefix string
 }
 
+var defaultCookies *Manager
+
 func NewCookie(domain string, secure bool) *Manager {
-	return &Manager{Domain: domain, Secure: secure}
+	return &Manager{Domain: domain, Secure: secure, Path: "/", SameSite: http.SameSiteLaxMode}
+}
+
+// NewCookieFromConfig applies COOKIE_* settings. __Host-/__Secure- prefixes and SameSite=None need
+// Secure cookies, so without COOKIE_SECURE they are dropped (SameSite falls back to Lax);
+// __Host- also forces Path=/ and no Domain, as browsers require.
+func NewCookieFromConfig(cfg *config.Config) *Manager {
+	m := NewCookie(cfg.CookieDomain, cfg.CookieSecure)
+	if p := strings.TrimSpace(cfg.CookiePath); p != "" {
+		m.Path = p
+	}
+	m.SameSite = parseSameSite(cfg.CookieSameSite)
+	if m.SameSite == http.SameSiteNoneMode && !m.Secure {
+		m.SameSite = http.SameSiteLaxMode
+	}
+	m.Prefix = cfg.CookieNamePrefix
+	if m.Secure {
+		switch strings.ToLower(strings.TrimSpace(cfg.CookieSecurePrefix)) {
+		case "host":
+			m.Prefix = "__Host-" + m.Prefix
+			m.Domain, m.Path = "", "/"
+		case "secure":
+			m.Prefix = "__Secure-" + m.Prefix
+		}
+	}
+	defaultCookies = m
+	return m
+}
+
+// DefaultCookies returns the last Manager built from config (used by middleware reading cookies)
+func DefaultCookies() *Manager { return defaultCookies }
+
+func parseSameSite(v string) http.SameSite {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteLaxMode
+	}
+}
+
+// Name returns the full cookie name for a base name; nil-safe
+func (m *Manager) Name(base string) string {
+	if m == nil {
+		return base
+	}
+	return m.Prefix + base
+}
+
+// Get reads a cookie by base name
+func (m *Manager) Get(c *gin.Context, base string) (string, error) {
+	return c.Cookie(m.Name(base))
+}
+
+func (m *Manager) set(c *gin.Context, base, value string, maxAge int) {
+	c.SetSameSite(m.SameSite)
+	c.SetCookie(m.Name(base), value, maxAge, m.Path, m.Domain, m.Secure, true)
 }
 
 func (m *Manager) SetPair(c *gin.Context, access string, aexp time.Time, refresh string, rexp time.Time) {
-	c.SetSameSite(http.SameSiteLaxMode)
-	aMax := maxAgeFrom(aexp)
-	rMax := maxAgeFrom(rexp)
+	m.set(c, CookieAccessToken, access, maxAgeFrom(aexp))
+	m.set(c, CookieRefreshToken, refresh, maxAgeFrom(rexp))
+}
 
-	c.SetCookie("access_token", access, aMax, "/", m.Domain, m.Secure, true)
-	c.SetCookie("refresh_token", refresh, rMax, "/", m.Domain, m.Secure, true)
+// ClearPair removes the auth cookies but keeps device_id, so a trusted device stays trusted
+func (m *Manager) ClearPair(c *gin.Context) {
+	m.set(c, CookieAccessToken, "", -1)
+	m.set(c, CookieRefreshToken, "", -1)
 }
 
 func (m *Manager) Clear(c *gin.Context) {
-	c.SetSameSite(http.SameSiteLaxMode)
-	c.SetCookie("access_token", "", -1, "/", m.Domain, m.Secure, true)
-	c.SetCookie("refresh_token", "", -1, "/", m.Domain, m.Secure, true)
-	// Match HttpOnly=true used when setting device_id
-	c.SetCookie("device_id", "", -1, "/", m.Domain, m.Secure, true)
+	m.ClearPair(c)
+	m.set(c, CookieDeviceID, "", -1)
 }
 
 // SetDeviceID stores a long-lived device identifier cookie used to recognize trusted devices.
 func (m *Manager) SetDeviceID(c *gin.Context, deviceID string, exp time.Time) {
-	c.SetSameSite(http.SameSiteLaxMode)
-	dMax := maxAgeFrom(exp)
 	// HttpOnly for better security; sent automatically on requests.
-	c.SetCookie("device_id", deviceID, dMax, "/", m.Domain, m.Secure, true)
+	m.set(c, CookieDeviceID, deviceID, maxAgeFrom(exp))
 }
 
 func maxAgeFrom(exp time.Time) int {
@@ -0,0 +1,100 @@
+package helpers
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/keyspace"
+)
+
+// HeaderCorrelationID returns the correlation id of an auth flow to the client (and support)
+const HeaderCorrelationID = "X-Correlation-ID"
+
+// Sources of correlation trail events
+const (
+	CorrelationLogin = "login" // login history: password or code accepted, OTP sent, tokens issued
+	CorrelationEmail = "email" // email events: queued by the API, then the worker's outcome
+)
+
+// correlationTrailMax bounds a trail so a looping client cannot grow it without limit
+const correlationTrailMax = 200
+
+type correlationKey struct{}
+
+// NewCorrelationID starts a new auth flow
+func NewCorrelationID() string { return uuid.NewString() }
+
+// WithCorrelationID attaches the flow's correlation id to ctx; audit entries and email jobs made
+// with ctx carry it
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationKey{}, id)
+}
+
+// CorrelationIDFrom returns the id attached by WithCorrelationID, or ""
+func CorrelationIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(correlationKey{}).(string)
+	return id
+}
+
+// KeyLoginCorrelation carries a login's correlation id from the password (or code request) step
+// to the OTP confirm step
+func KeyLoginCorrelation(userID string) string {
+	return keyspace.Key("user:login:correlation:" + userID)
+}
+
+// KeyCorrelation is the Redis list of trail events recorded for one correlation id, oldest first
+func KeyCorrelation(id string) string { return keyspace.Key("correlation:" + id) }
+
+// CorrelationEvent is one step of an auth flow outside the audit log
+type CorrelationEvent struct {
+	Source string         `json:"source"`
+	Event  string         `json:"event"`
+	UserID string         `json:"user_id,omitempty"`
+	Fields map[string]any `json:"fields,omitempty"`
+	At     time.Time      `json:"at"`
+}
+
+// RecordCorrelation appends ev to the trail of id for ttl; a no-op without Redis, id or ttl.
+// The trail is best effort: a failed write never fails the flow it describes.
+func RecordCorrelation(ctx context.Context, rdb *redis.Client, id string, ttl time.Duration, ev CorrelationEvent) error {
+	if rdb == nil || id == "" || ttl <= 0 {
+		return nil
+	}
+	if ev.At.IsZero() {
+		ev.At = time.Now().UTC()
+	}
+	b, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	key := KeyCorrelation(id)
+	pipe := rdb.TxPipeline()
+	pipe.RPush(ctx, key, b)
+	pipe.LTrim(ctx, key, -correlationTrailMax, -1)
+	pipe.Expire(ctx, key, ttl)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// GetCorrelation returns the trail of id, oldest first (empty when unknown or expired)
+func GetCorrelation(ctx context.Context, rdb *redis.Client, id string) ([]CorrelationEvent, error) {
+	out := []CorrelationEvent{}
+	if rdb == nil || id == "" {
+		return out, nil
+	}
+	raw, err := rdb.LRange(ctx, KeyCorrelation(id), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range raw {
+		var ev CorrelationEvent
+		if json.Unmarshal([]byte(r), &ev) == nil && ev.Event != "" {
+			out = append(out, ev)
+		}
+	}
+	return out, nil
+}
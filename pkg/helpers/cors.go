@@ -0,0 +1,207 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/keyspace"
+)
+
+// KeyCORSOrigins is the Redis set of origin patterns allowed in addition to CORS_ALLOWED_ORIGINS
+func KeyCORSOrigins() string { return keyspace.Key("cors:origins") }
+
+var ErrInvalidOrigin = errors.New("invalid origin")
+
+// originPattern is a parsed allowed origin: exact, "*." subdomain wildcard and/or ":*" any port
+type originPattern struct {
+	raw       string
+	scheme    string
+	host      string // without the "*." prefix when subdomain is set
+	port      string // "" = default port, "*" = any
+	subdomain bool
+}
+
+// parseOriginPattern validates an allowed-origin pattern: "https://app.example.com",
+// "https://*.example.com" (any subdomain, not the apex) and "http://localhost:*" (any port).
+// Paths, queries and bare "*" are rejected.
+func parseOriginPattern(s string) (originPattern, error) {
+	s = strings.TrimSuffix(strings.TrimSpace(s), "/")
+	scheme, rest, ok := strings.Cut(s, "://")
+	if !ok || (scheme != "http" && scheme != "https") {
+		return originPattern{}, fmt.Errorf("origin %q: scheme must be http or https", s)
+	}
+	if rest == "" || strings.ContainsAny(rest, "/?#@") {
+		return originPattern{}, fmt.Errorf("origin %q: expected scheme://host[:port]", s)
+	}
+	p := originPattern{raw: s, scheme: scheme, host: rest}
+	if h, port, ok := strings.Cut(rest, ":"); ok {
+		if port != "*" && strings.Trim(port, "0123456789") != "" || port == "" {
+			return originPattern{}, fmt.Errorf("origin %q: invalid port", s)
+		}
+		p.host, p.port = h, port
+	}
+	if h, ok := strings.CutPrefix(p.host, "*."); ok {
+		p.host, p.subdomain = h, true
+	}
+	p.host = strings.ToLower(p.host)
+	if p.host == "" || strings.Contains(p.host, "*") || !strings.Contains(p.host, ".") && p.subdomain {
+		return originPattern{}, fmt.Errorf("origin %q: wildcards are only allowed as a leading \"*.\" on a domain", s)
+	}
+	p.raw = strings.ToLower(s)
+	return p, nil
+}
+
+func (p originPattern) match(scheme, host, port string) bool {
+	if scheme != p.scheme || (p.port != "*" && port != p.port) {
+		return false
+	}
+	if p.subdomain {
+		return strings.HasSuffix(host, "."+p.host)
+	}
+	return host == p.host
+}
+
+// CORSOrigins decides which browser origins may call the API: the static CORS_ALLOWED_ORIGINS
+// patterns plus the Redis set KeyCORSOrigins, reloaded every refresh interval so edits made on
+// one replica reach the others. Lookups never touch Redis.
+type CORSOrigins struct {
+	rdb     *redis.Client
+	logger  *logrus.Logger
+	static  []originPattern
+	dynamic atomic.Pointer[[]originPattern]
+}
+
+// NewCORSOrigins parses the static patterns; an invalid one is an error
+func NewCORSOrigins(rdb *redis.Client, logger *logrus.Logger, static []string) (*CORSOrigins, error) {
+	o := &CORSOrigins{rdb: rdb, logger: logger}
+	for _, s := range static {
+		p, err := parseOriginPattern(s)
+		if err != nil {
+			return nil, err
+		}
+		o.static = append(o.static, p)
+	}
+	o.dynamic.Store(&[]originPattern{})
+	return o, nil
+}
+
+// Allow reports whether a request from origin may be served (for cors.Config.AllowOriginFunc)
+func (o *CORSOrigins) Allow(origin string) bool {
+	if o == nil {
+		return false
+	}
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	scheme, host, port := u.Scheme, strings.ToLower(u.Hostname()), u.Port()
+	for _, p := range o.static {
+		if p.match(scheme, host, port) {
+			return true
+		}
+	}
+	for _, p := range *o.dynamic.Load() {
+		if p.match(scheme, host, port) {
+			return true
+		}
+	}
+	return false
+}
+
+// Static returns the CORS_ALLOWED_ORIGINS patterns
+func (o *CORSOrigins) Static() []string {
+	out := make([]string, 0, len(o.static))
+	for _, p := range o.static {
+		out = append(out, p.raw)
+	}
+	return out
+}
+
+// Dynamic returns the Redis-managed patterns straight from Redis
+func (o *CORSOrigins) Dynamic(ctx context.Context) ([]string, error) {
+	if o.rdb == nil {
+		return []string{}, nil
+	}
+	return o.rdb.SMembers(ctx, KeyCORSOrigins()).Result()
+}
+
+// Add validates pattern, stores it in Redis and applies it on this replica right away
+func (o *CORSOrigins) Add(ctx context.Context, pattern string) (string, error) {
+	if o.rdb == nil {
+		return "", errors.New("cors allowlist requires redis")
+	}
+	p, err := parseOriginPattern(pattern)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidOrigin, err)
+	}
+	if err := o.rdb.SAdd(ctx, KeyCORSOrigins(), p.raw).Err(); err != nil {
+		return "", err
+	}
+	return p.raw, o.Reload(ctx)
+}
+
+// Remove deletes pattern from Redis; it reports whether it was present
+func (o *CORSOrigins) Remove(ctx context.Context, pattern string) (bool, error) {
+	if o.rdb == nil {
+		return false, nil
+	}
+	n, err := o.rdb.SRem(ctx, KeyCORSOrigins(), strings.ToLower(strings.TrimSuffix(strings.TrimSpace(pattern), "/"))).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, o.Reload(ctx)
+}
+
+// Reload replaces the Redis-managed patterns; malformed entries are skipped with a warning
+func (o *CORSOrigins) Reload(ctx context.Context) error {
+	members, err := o.Dynamic(ctx)
+	if err != nil {
+		return err
+	}
+	patterns := make([]originPattern, 0, len(members))
+	for _, m := range members {
+		p, perr := parseOriginPattern(m)
+		if perr != nil {
+			o.logger.WithError(perr).Warn("ignoring invalid cors origin in redis")
+			continue
+		}
+		patterns = append(patterns, p)
+	}
+	o.dynamic.Store(&patterns)
+	return nil
+}
+
+// Run reloads the Redis-managed patterns now and then every interval until ctx is cancelled.
+// Failures keep the previous list. It returns immediately without Redis.
+func (o *CORSOrigins) Run(ctx context.Context, interval time.Duration) {
+	if o == nil || o.rdb == nil {
+		return
+	}
+	reload := func() {
+		if err := o.Reload(ctx); err != nil && ctx.Err() == nil {
+			o.logger.WithError(err).Warn("cors origins reload failed; keeping current list")
+		}
+	}
+	reload()
+	if interval <= 0 {
+		return
+	}
+	tk := time.NewTicker(interval)
+	defer tk.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tk.C:
+			reload()
+		}
+	}
+}
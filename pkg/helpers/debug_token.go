@@ -0,0 +1,51 @@
+package helpers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxDebugTokenTTL bounds how far in the future a debug token may expire
+const MaxDebugTokenTTL = time.Hour
+
+func debugTokenMAC(secret, expiry string) string {
+	m := hmac.New(sha256.New, []byte(secret))
+	m.Write([]byte(expiry))
+	return hex.EncodeToString(m.Sum(nil))
+}
+
+// SignDebugToken returns "<unix expiry>.<hex HMAC-SHA256(secret, expiry)>" for the X-Debug-Body-Log header
+func SignDebugToken(secret string, expiry time.Time) string {
+	exp := strconv.FormatInt(expiry.Unix(), 10)
+	return exp + "." + debugTokenMAC(secret, exp)
+}
+
+// VerifyDebugToken checks the signature and that the token expires in the future but within MaxDebugTokenTTL
+func VerifyDebugToken(secret, token string, now time.Time) bool {
+	return verifyExpiringToken(secret, token, "", now)
+}
+
+// verifyExpiringToken checks a "<unix expiry>.<hex HMAC-SHA256(secret, expiry+signed)>" token that
+// expires in the future but within MaxDebugTokenTTL
+func verifyExpiringToken(secret, token, signed string, now time.Time) bool {
+	if secret == "" {
+		return false
+	}
+	exp, mac, ok := strings.Cut(token, ".")
+	if !ok {
+		return false
+	}
+	unix, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil {
+		return false
+	}
+	expiry := time.Unix(unix, 0)
+	if !expiry.After(now) || expiry.Sub(now) > MaxDebugTokenTTL {
+		return false
+	}
+	return hmac.Equal([]byte(mac), []byte(debugTokenMAC(secret, exp+signed)))
+}
@@ -0,0 +1,229 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Core dependencies probed by DependencyMonitor, next to the breaker-guarded Dependency* names;
+// Elasticsearch is probed as DependencySearch so a failing ping sheds the same routes as its breaker
+const (
+	DependencyPostgres = "postgres"
+	DependencyRedis    = "redis"
+	DependencyRabbitMQ = "rabbitmq"
+	DependencyMailgun  = "mailgun"
+)
+
+// dependencySamples is how many probe latencies each dependency keeps for its percentiles
+const dependencySamples = 60
+
+// DependencyProbe reports whether a dependency is reachable; nil means healthy
+type DependencyProbe func(ctx context.Context) error
+
+// DependencyMonitor probes dependencies in the background, the same checks /readyz runs, and
+// reports the failing ones together with the open circuit breakers, plus the latency of recent
+// probes. Requests read the last result, so deciding whether to shed one or rendering the status
+// page costs no round trip. A nil *DependencyMonitor reports nothing.
+type DependencyMonitor struct {
+	Probes   map[string]DependencyProbe
+	Every    map[string]time.Duration // probes run less often than Interval, e.g. third-party APIs (optional)
+	Breakers *Breakers                // optional
+	Interval time.Duration
+	Timeout  time.Duration
+
+	mu    sync.RWMutex
+	state map[string]*probeState
+	shed  map[string]int64 // refused requests by route
+}
+
+// probeState is the last result of one probe and a ring of its recent latencies
+type probeState struct {
+	up        bool
+	checkedAt time.Time
+	samples   []time.Duration
+	next      int
+}
+
+func NewDependencyMonitor(probes map[string]DependencyProbe, breakers *Breakers, interval time.Duration) *DependencyMonitor {
+	if interval <= 0 {
+		interval = 2 * time.Second
+	}
+	return &DependencyMonitor{
+		Probes: probes, Every: map[string]time.Duration{}, Breakers: breakers, Interval: interval, Timeout: min(interval, 2*time.Second),
+		state: map[string]*probeState{}, shed: map[string]int64{},
+	}
+}
+
+// Run probes every Interval until ctx is cancelled
+func (m *DependencyMonitor) Run(ctx context.Context) {
+	t := time.NewTicker(m.Interval)
+	defer t.Stop()
+	for {
+		m.Check(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// Check runs every due probe once, concurrently, and stores the results
+func (m *DependencyMonitor) Check(ctx context.Context) {
+	c, cancel := context.WithTimeout(ctx, m.Timeout)
+	defer cancel()
+	now := time.Now()
+	var wg sync.WaitGroup
+	for name, probe := range m.Probes {
+		m.mu.RLock()
+		st := m.state[name]
+		due := st == nil || now.Sub(st.checkedAt) >= m.Every[name]
+		m.mu.RUnlock()
+		if !due {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			err := probe(c)
+			m.record(name, err == nil, time.Since(start))
+		}()
+	}
+	wg.Wait()
+}
+
+func (m *DependencyMonitor) record(name string, up bool, took time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	st := m.state[name]
+	if st == nil {
+		st = &probeState{}
+		m.state[name] = st
+	}
+	st.up, st.checkedAt = up, time.Now()
+	if len(st.samples) < dependencySamples {
+		st.samples = append(st.samples, took)
+		return
+	}
+	st.samples[st.next] = took
+	st.next = (st.next + 1) % dependencySamples
+}
+
+// Degraded returns the sorted names of failing dependencies and open breakers
+// (implements response.DegradedReporter)
+func (m *DependencyMonitor) Degraded() []string {
+	if m == nil {
+		return nil
+	}
+	var out []string
+	m.mu.RLock()
+	for name, st := range m.state {
+		if !st.up {
+			out = append(out, name)
+		}
+	}
+	m.mu.RUnlock()
+	out = append(out, m.Breakers.Degraded()...)
+	slices.Sort(out)
+	return slices.Compact(out)
+}
+
+// RecordShed counts a request refused on route because of a degraded dependency
+func (m *DependencyMonitor) RecordShed(route string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	m.shed[route]++
+	m.mu.Unlock()
+}
+
+// DependencyStatus is one dependency on the status page
+type DependencyStatus struct {
+	Name      string     `json:"name"`
+	Status    string     `json:"status"` // up, down, or degraded (reachable but its circuit breaker is open)
+	P50MS     float64    `json:"p50_ms"`
+	P95MS     float64    `json:"p95_ms"`
+	CheckedAt *time.Time `json:"checked_at,omitempty"`
+}
+
+// Status returns every probed dependency and open breaker, sorted by name, with the p50/p95 of
+// the recent probe latencies. Nothing is probed on the caller's time.
+func (m *DependencyMonitor) Status() []DependencyStatus {
+	if m == nil {
+		return []DependencyStatus{}
+	}
+	open := m.Breakers.Degraded()
+	out := []DependencyStatus{}
+	m.mu.RLock()
+	for name, st := range m.state {
+		ds := DependencyStatus{Name: name, Status: "up", P50MS: percentileMS(st.samples, 0.50), P95MS: percentileMS(st.samples, 0.95)}
+		at := st.checkedAt.UTC()
+		ds.CheckedAt = &at
+		switch {
+		case !st.up:
+			ds.Status = "down"
+		case slices.Contains(open, name):
+			ds.Status = "degraded"
+		}
+		out = append(out, ds)
+	}
+	m.mu.RUnlock()
+	for _, name := range open {
+		if !slices.ContainsFunc(out, func(d DependencyStatus) bool { return d.Name == name }) {
+			out = append(out, DependencyStatus{Name: name, Status: "degraded"})
+		}
+	}
+	slices.SortFunc(out, func(a, b DependencyStatus) int { return strings.Compare(a.Name, b.Name) })
+	return out
+}
+
+// percentileMS is the nearest-rank percentile of samples in milliseconds (0 without samples)
+func percentileMS(samples []time.Duration, p float64) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	sorted := slices.Clone(samples)
+	slices.Sort(sorted)
+	i := int(math.Ceil(p*float64(len(sorted)))) - 1
+	d := sorted[max(i, 0)]
+	return math.Round(float64(d.Microseconds())/10) / 100
+}
+
+// WriteMetrics appends per-dependency health and shed requests in Prometheus text format. nil-safe.
+func (m *DependencyMonitor) WriteMetrics(b *strings.Builder) {
+	if m == nil {
+		return
+	}
+	down := m.Degraded()
+	names := make([]string, 0, len(m.Probes))
+	for name := range m.Probes {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	b.WriteString("# HELP dependency_up Whether the last background probe of a dependency succeeded.\n# TYPE dependency_up gauge\n")
+	for _, name := range names {
+		up := 1
+		if slices.Contains(down, name) {
+			up = 0
+		}
+		fmt.Fprintf(b, "dependency_up{dependency=%q} %d\n", name, up)
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	routes := make([]string, 0, len(m.shed))
+	for r := range m.shed {
+		routes = append(routes, r)
+	}
+	slices.Sort(routes)
+	b.WriteString("# HELP http_requests_shed_total Requests refused with 503 because a dependency they need was degraded.\n# TYPE http_requests_shed_total counter\n")
+	for _, r := range routes {
+		fmt.Fprintf(b, "http_requests_shed_total{route=%q} %d\n", r, m.shed[r])
+	}
+}
@@ -0,0 +1,34 @@
+package helpers
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// DrainState is flipped once when the process starts draining for shutdown (signal or /internal/drain).
+// Readiness reports failure from then on so load balancers stop routing new traffic here.
+type DrainState struct {
+	draining atomic.Bool
+	once     sync.Once
+	done     chan struct{}
+}
+
+func NewDrainState() *DrainState {
+	return &DrainState{done: make(chan struct{})}
+}
+
+// Start begins draining; it reports false when draining had already started.
+func (d *DrainState) Start() bool {
+	started := false
+	d.once.Do(func() {
+		d.draining.Store(true)
+		close(d.done)
+		started = true
+	})
+	return started
+}
+
+func (d *DrainState) Draining() bool { return d != nil && d.draining.Load() }
+
+// Done is closed when draining starts
+func (d *DrainState) Done() <-chan struct{} { return d.done }
@@ -0,0 +1,87 @@
+package helpers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/keyspace"
+)
+
+// Delivery states set by the producer; the worker records its job outcome (sent, failed,
+// invalid, duplicate, rate_limited) as later events of the same delivery
+const (
+	DeliveryQueued    = "queued"     // about to be published to the email queue
+	DeliveryNotQueued = "not_queued" // publish failed or timed out
+	DeliveryDisabled  = "disabled"   // MAIL_SEND_ENABLED=false
+)
+
+// EmailDeliveryTTL bounds how long a delivery can be polled
+const EmailDeliveryTTL = time.Hour
+
+// ErrDeliveryNotFound is returned for unknown or expired delivery ids
+var ErrDeliveryNotFound = errors.New("email delivery not found")
+
+// KeyEmailDelivery is the Redis list of events recorded for one tracked email job, oldest first
+func KeyEmailDelivery(id string) string {
+	return keyspace.Key("email:delivery:" + id)
+}
+
+// EmailDeliveryEvent is one state change of a tracked email job
+type EmailDeliveryEvent struct {
+	State string    `json:"state"`
+	At    time.Time `json:"at"`
+}
+
+// EmailDelivery is a tracked email job; State is the state of its latest event
+type EmailDelivery struct {
+	ID        string               `json:"delivery_id"`
+	State     string               `json:"state"`
+	UpdatedAt time.Time            `json:"updated_at"`
+	Events    []EmailDeliveryEvent `json:"events"`
+}
+
+// RecordEmailDelivery appends state as an event of delivery id; a no-op without Redis or id.
+// Events are only appended, so a late producer write cannot hide the worker's outcome.
+func RecordEmailDelivery(ctx context.Context, rdb *redis.Client, id, state string) error {
+	if rdb == nil || id == "" {
+		return nil
+	}
+	ev, err := json.Marshal(EmailDeliveryEvent{State: state, At: time.Now().UTC()})
+	if err != nil {
+		return err
+	}
+	key := KeyEmailDelivery(id)
+	pipe := rdb.TxPipeline()
+	pipe.RPush(ctx, key, ev)
+	pipe.Expire(ctx, key, EmailDeliveryTTL)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// GetEmailDelivery returns the events of delivery id, or ErrDeliveryNotFound
+func GetEmailDelivery(ctx context.Context, rdb *redis.Client, id string) (EmailDelivery, error) {
+	if rdb == nil || id == "" {
+		return EmailDelivery{}, ErrDeliveryNotFound
+	}
+	raw, err := rdb.LRange(ctx, KeyEmailDelivery(id), 0, -1).Result()
+	if err != nil {
+		return EmailDelivery{}, err
+	}
+	d := EmailDelivery{ID: id, Events: make([]EmailDeliveryEvent, 0, len(raw))}
+	for _, r := range raw {
+		var ev EmailDeliveryEvent
+		if json.Unmarshal([]byte(r), &ev) == nil && ev.State != "" {
+			d.Events = append(d.Events, ev)
+		}
+	}
+	if len(d.Events) == 0 {
+		return EmailDelivery{}, ErrDeliveryNotFound
+	}
+	last := d.Events[len(d.Events)-1]
+	d.State, d.UpdatedAt = last.State, last.At
+	return d, nil
+}
@@ -0,0 +1,52 @@
+package helpers
+
+import (
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/config"
+)
+
+// EmailPolicy decides which spellings of an address count as the same account. Addresses are
+// always trimmed and lowercased; the folds are opt-in per deployment because they merge
+// mailboxes some providers treat as distinct.
+type EmailPolicy struct {
+	// FoldGmail drops dots and +tags in gmail.com local parts and maps googlemail.com to gmail.com
+	FoldGmail bool
+	// PlusDomains are domains whose +tag is dropped ("*" = every domain)
+	PlusDomains []string
+}
+
+// EmailPolicyFromConfig reads EMAIL_FOLD_GMAIL and EMAIL_FOLD_PLUS_DOMAINS
+func EmailPolicyFromConfig(cfg *config.Config) EmailPolicy {
+	return EmailPolicy{FoldGmail: cfg.EmailFoldGmail, PlusDomains: cfg.EmailFoldPlusDomainList()}
+}
+
+var emailPolicy atomic.Pointer[EmailPolicy]
+
+// SetEmailPolicy installs the policy NormalizeEmail applies; call it once at startup
+func SetEmailPolicy(p EmailPolicy) { emailPolicy.Store(&p) }
+
+// NormalizeEmail returns the key an address is looked up and deduplicated by (users.normalized_email)
+func NormalizeEmail(email string) string {
+	email = strings.ToLower(strings.TrimSpace(email))
+	p := emailPolicy.Load()
+	if p == nil {
+		return email
+	}
+	local, domain, ok := strings.Cut(email, "@")
+	if !ok || local == "" || strings.Contains(domain, "@") {
+		return email
+	}
+	if p.FoldGmail && (domain == "gmail.com" || domain == "googlemail.com") {
+		local, _, _ = strings.Cut(local, "+")
+		return strings.ReplaceAll(local, ".", "") + "@gmail.com"
+	}
+	if slices.Contains(p.PlusDomains, "*") || slices.Contains(p.PlusDomains, domain) {
+		if tagless, _, _ := strings.Cut(local, "+"); tagless != "" {
+			local = tagless
+		}
+	}
+	return local + "@" + domain
+}
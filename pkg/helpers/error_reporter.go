@@ -0,0 +1,181 @@
+package helpers
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/config"
+)
+
+// StackFrame is one call in an ErrorEvent stack, outermost first
+type StackFrame struct {
+	Function string `json:"function"`
+	File     string `json:"filename"`
+	Line     int    `json:"lineno"`
+}
+
+// CallerFrames returns the calling goroutine's stack, skipping skip frames above the caller
+func CallerFrames(skip int) []StackFrame {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(skip+2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	var out []StackFrame
+	for {
+		f, more := frames.Next()
+		out = append(out, StackFrame{Function: f.Function, File: f.File, Line: f.Line})
+		if !more {
+			break
+		}
+	}
+	// Trackers expect the crashing frame last
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return out
+}
+
+// ErrorEvent is an unexpected failure worth a human look, such as a recovered panic
+type ErrorEvent struct {
+	Type      string // e.g. "panic"
+	Message   string
+	Frames    []StackFrame
+	RequestID string
+	UserID    string
+	Method    string
+	URL       string
+	Route     string
+	IP        string
+	UserAgent string
+	Time      time.Time
+}
+
+// ErrorReporter forwards ErrorEvents to an error tracker; Report must not block the caller
+type ErrorReporter interface {
+	Report(ev ErrorEvent)
+}
+
+// SentryReporter sends events to a Sentry-compatible store endpoint (Sentry, GlitchTip) from a
+// background queue. Events arriving while the queue is full are dropped with a warning.
+type SentryReporter struct {
+	endpoint string
+	key      string
+	env      string
+	release  string
+	http     *http.Client
+	logger   *logrus.Logger
+	queue    chan ErrorEvent
+}
+
+// NewSentryReporter parses a DSN of the form https://<key>@<host>/<project id>
+func NewSentryReporter(dsn, env, release string, logger *logrus.Logger) (*SentryReporter, error) {
+	u, err := url.Parse(dsn)
+	if err != nil || u.User == nil || u.User.Username() == "" || u.Host == "" {
+		return nil, fmt.Errorf("error tracker dsn: expected scheme://key@host/project")
+	}
+	path := strings.Trim(u.Path, "/")
+	i := strings.LastIndex(path, "/")
+	prefix, project := "", path
+	if i >= 0 {
+		prefix, project = "/"+path[:i], path[i+1:]
+	}
+	if project == "" {
+		return nil, fmt.Errorf("error tracker dsn: missing project id")
+	}
+	return &SentryReporter{
+		endpoint: fmt.Sprintf("%s://%s%s/api/%s/store/", u.Scheme, u.Host, prefix, project),
+		key:      u.User.Username(),
+		env:      env,
+		release:  release,
+		http:     &http.Client{Timeout: 10 * time.Second},
+		logger:   logger,
+		queue:    make(chan ErrorEvent, 100),
+	}, nil
+}
+
+// ErrorReporterFromConfig returns nil when ERROR_TRACKER_DSN is unset.
+func ErrorReporterFromConfig(cfg *config.Config, logger *logrus.Logger) (*SentryReporter, error) {
+	if cfg.ErrorTrackerDSN == "" {
+		return nil, nil
+	}
+	return NewSentryReporter(cfg.ErrorTrackerDSN, cfg.Env, BuildVersion(), logger)
+}
+
+func (r *SentryReporter) Report(ev ErrorEvent) {
+	if r == nil {
+		return
+	}
+	select {
+	case r.queue <- ev:
+	default:
+		r.logger.WithField("request_id", ev.RequestID).Warn("error tracker queue full; event dropped")
+	}
+}
+
+// Run sends queued events until ctx is done. nil-safe.
+func (r *SentryReporter) Run(ctx context.Context) {
+	if r == nil {
+		return
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev := <-r.queue:
+			if err := r.send(ctx, ev); err != nil && ctx.Err() == nil {
+				r.logger.WithError(err).WithField("request_id", ev.RequestID).Warn("error tracker send failed")
+			}
+		}
+	}
+}
+
+func (r *SentryReporter) send(ctx context.Context, ev ErrorEvent) error {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	body := map[string]any{
+		"event_id":    hex.EncodeToString(id),
+		"timestamp":   ev.Time.UTC().Format(time.RFC3339),
+		"level":       "error",
+		"platform":    "go",
+		"logger":      "http",
+		"environment": r.env,
+		"release":     r.release,
+		"exception": map[string]any{"values": []map[string]any{{
+			"type":       ev.Type,
+			"value":      ev.Message,
+			"stacktrace": map[string]any{"frames": ev.Frames},
+		}}},
+		"request": map[string]any{"method": ev.Method, "url": ev.URL, "headers": map[string]string{"User-Agent": ev.UserAgent}},
+		"user":    map[string]any{"id": ev.UserID, "ip_address": ev.IP},
+		"tags":    map[string]string{"request_id": ev.RequestID, "route": ev.Route},
+	}
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.endpoint, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Sentry-Auth", "Sentry sentry_version=7, sentry_client=go-ddd-clean-architecture/"+r.release+", sentry_key="+r.key)
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("error tracker: %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package helpers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/elastic/go-elasticsearch/v8"
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+)
+
+// Elasticsearch index and alias management used for zero-downtime reindexing.
+
+func esResponseError(res *esapi.Response, op string) error {
+	b, _ := io.ReadAll(io.LimitReader(res.Body, 4096))
+	return fmt.Errorf("es %s: %s: %s", op, res.Status(), strings.TrimSpace(string(b)))
+}
+
+func esJSON(v any) io.Reader {
+	b, _ := json.Marshal(v)
+	return bytes.NewReader(b)
+}
+
+// ESCreateIndex creates an index with the given settings/mappings/aliases body
+func ESCreateIndex(ctx context.Context, es *elasticsearch.Client, name string, body any) error {
+	res, err := es.Indices.Create(name, es.Indices.Create.WithContext(ctx), es.Indices.Create.WithBody(esJSON(body)))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = res.Body.Close() }()
+	if res.IsError() {
+		return esResponseError(res, "create index "+name)
+	}
+	return nil
+}
+
+// ESIndexExists reports whether name resolves to an index or alias
+func ESIndexExists(ctx context.Context, es *elasticsearch.Client, name string) (bool, error) {
+	res, err := es.Indices.Exists([]string{name}, es.Indices.Exists.WithContext(ctx))
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = res.Body.Close() }()
+	switch res.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	}
+	return false, esResponseError(res, "exists "+name)
+}
+
+// ESAliasIndices returns the indices an alias points to (nil when the alias does not exist)
+func ESAliasIndices(ctx context.Context, es *elasticsearch.Client, alias string) ([]string, error) {
+	res, err := es.Indices.GetAlias(es.Indices.GetAlias.WithContext(ctx), es.Indices.GetAlias.WithName(alias))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = res.Body.Close() }()
+	if res.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if res.IsError() {
+		return nil, esResponseError(res, "get alias "+alias)
+	}
+	var body map[string]json.RawMessage
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	out := make([]string, 0, len(body))
+	for index := range body {
+		out = append(out, index)
+	}
+	return out, nil
+}
+
+// ESUpdateAliases applies alias actions ({"add": {...}}, {"remove": {...}}, {"remove_index": {...}}) atomically
+func ESUpdateAliases(ctx context.Context, es *elasticsearch.Client, actions []map[string]any) error {
+	res, err := es.Indices.UpdateAliases(esJSON(map[string]any{"actions": actions}), es.Indices.UpdateAliases.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = res.Body.Close() }()
+	if res.IsError() {
+		return esResponseError(res, "update aliases")
+	}
+	return nil
+}
+
+// ESDeleteIndices deletes the given indices
+func ESDeleteIndices(ctx context.Context, es *elasticsearch.Client, names ...string) error {
+	if len(names) == 0 {
+		return nil
+	}
+	res, err := es.Indices.Delete(names, es.Indices.Delete.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = res.Body.Close() }()
+	if res.IsError() {
+		return esResponseError(res, "delete "+strings.Join(names, ","))
+	}
+	return nil
+}
+
+// ESBulkDoc is one document for ESBulk
+type ESBulkDoc struct {
+	ID  string
+	Doc any
+}
+
+// ESBulk writes docs to index with op "index" (overwrite) or "create" (skip existing; conflicts are not failures).
+// It returns the number of failed items.
+func ESBulk(ctx context.Context, es *elasticsearch.Client, index, op string, docs []ESBulkDoc) (int, error) {
+	if len(docs) == 0 {
+		return 0, nil
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, d := range docs {
+		_ = enc.Encode(map[string]any{op: map[string]any{"_index": index, "_id": d.ID}})
+		_ = enc.Encode(d.Doc)
+	}
+	res, err := es.Bulk(&buf, es.Bulk.WithContext(ctx))
+	if err != nil {
+		return len(docs), err
+	}
+	defer func() { _ = res.Body.Close() }()
+	if res.IsError() {
+		return len(docs), esResponseError(res, "bulk")
+	}
+	var parsed struct {
+		Errors bool                              `json:"errors"`
+		Items  []map[string]struct{ Status int } `json:"items"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&parsed); err != nil {
+		return len(docs), err
+	}
+	failed := 0
+	if parsed.Errors {
+		for _, item := range parsed.Items {
+			for _, r := range item {
+				if r.Status >= 300 && !(op == "create" && r.Status == http.StatusConflict) {
+					failed++
+				}
+			}
+		}
+	}
+	return failed, nil
+}
@@ -0,0 +1,126 @@
+package helpers
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Event topics published on the in-process bus
+const (
+	TopicAuditLogged = "audit.logged"
+	TopicUserEvent   = "user.event"   // events.UserEvent, forwarded to USER_EVENTS_EXCHANGE
+	TopicDomainEvent = "domain.event" // entity.DomainEvent raised by aggregates, published after commit
+)
+
+// EventHandler processes one event; it runs on the bus worker, never on the publisher's goroutine.
+type EventHandler func(ctx context.Context, payload any)
+
+type busEvent struct {
+	topic   string
+	payload any
+}
+
+// EventBus is an in-process asynchronous publish/subscribe bus with a bounded queue.
+// Publish never blocks: when the queue is full the event is dropped and counted, so slow
+// subscribers (e.g. indexing into Elasticsearch) cannot add latency to requests.
+type EventBus struct {
+	logger *logrus.Logger
+	queue  chan busEvent
+
+	mu       sync.RWMutex
+	handlers map[string][]EventHandler
+
+	dropped atomic.Int64
+	closed  atomic.Bool
+	once    sync.Once
+	done    chan struct{}
+}
+
+// NewEventBus starts a bus with the given queue size and number of workers
+func NewEventBus(buffer, workers int, logger *logrus.Logger) *EventBus {
+	if buffer <= 0 {
+		buffer = 1024
+	}
+	if workers <= 0 {
+		workers = 1
+	}
+	b := &EventBus{logger: logger, queue: make(chan busEvent, buffer), handlers: map[string][]EventHandler{}, done: make(chan struct{})}
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ev := range b.queue {
+				b.dispatch(ev)
+			}
+		}()
+	}
+	go func() { wg.Wait(); close(b.done) }()
+	return b
+}
+
+// Subscribe registers h for topic; register subscribers before events are published.
+func (b *EventBus) Subscribe(topic string, h EventHandler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[topic] = append(b.handlers[topic], h)
+}
+
+// Publish queues an event; it reports false when the bus is nil, closed or full.
+func (b *EventBus) Publish(topic string, payload any) (ok bool) {
+	if b == nil || b.closed.Load() {
+		return false
+	}
+	defer func() {
+		// Close may race with a publish in flight
+		if recover() != nil {
+			ok = false
+		}
+	}()
+	select {
+	case b.queue <- busEvent{topic: topic, payload: payload}:
+		return true
+	default:
+		if n := b.dropped.Add(1); b.logger != nil && n&(n-1) == 0 {
+			b.logger.WithFields(logrus.Fields{"topic": topic, "dropped": n}).Warn("event bus full, dropping events")
+		}
+		return false
+	}
+}
+
+// Dropped returns how many events were dropped because the queue was full
+func (b *EventBus) Dropped() int64 { return b.dropped.Load() }
+
+func (b *EventBus) dispatch(ev busEvent) {
+	b.mu.RLock()
+	hs := b.handlers[ev.topic]
+	b.mu.RUnlock()
+	for _, h := range hs {
+		func() {
+			defer func() {
+				if r := recover(); r != nil && b.logger != nil {
+					b.logger.WithFields(logrus.Fields{"topic": ev.topic, "panic": r}).Error("event handler panicked")
+				}
+			}()
+			h(context.Background(), ev.payload)
+		}()
+	}
+}
+
+// Close stops accepting events and waits (until ctx is done) for queued events to be handled.
+func (b *EventBus) Close(ctx context.Context) {
+	if b == nil {
+		return
+	}
+	b.once.Do(func() {
+		b.closed.Store(true)
+		close(b.queue)
+	})
+	select {
+	case <-b.done:
+	case <-ctx.Done():
+	}
+}
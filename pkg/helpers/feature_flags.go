@@ -0,0 +1,123 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Feature flags
+const (
+	// FeatureSearchCanary sends user search to SEARCH_CANARY_BACKEND instead of SEARCH_BACKEND
+	FeatureSearchCanary = "search_canary"
+)
+
+// KnownFeatures are the flags FEATURE_FLAGS and request overrides may set
+var KnownFeatures = []string{FeatureSearchCanary}
+
+var ErrInvalidFeatureFlags = errors.New("invalid feature flags")
+
+// ParseFeatureFlags reads "name=on,other=off" (on/off, true/false, 1/0; a bare name means on).
+// Names must be in KnownFeatures.
+func ParseFeatureFlags(s string) (map[string]bool, error) {
+	out := map[string]bool{}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, val, hasVal := strings.Cut(part, "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !slices.Contains(KnownFeatures, name) {
+			return nil, fmt.Errorf("%w: unknown flag %q", ErrInvalidFeatureFlags, name)
+		}
+		on := true
+		if hasVal {
+			switch strings.ToLower(strings.TrimSpace(val)) {
+			case "on":
+				on = true
+			case "off":
+				on = false
+			default:
+				b, err := strconv.ParseBool(strings.TrimSpace(val))
+				if err != nil {
+					return nil, fmt.Errorf("%w: %q is not on or off", ErrInvalidFeatureFlags, part)
+				}
+				on = b
+			}
+		}
+		out[name] = on
+	}
+	return out, nil
+}
+
+// FormatFeatureFlags is flags as "name=on,other=off", sorted by name
+func FormatFeatureFlags(flags map[string]bool) string {
+	names := make([]string, 0, len(flags))
+	for n := range flags {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	parts := make([]string, len(names))
+	for i, n := range names {
+		v := "off"
+		if flags[n] {
+			v = "on"
+		}
+		parts[i] = n + "=" + v
+	}
+	return strings.Join(parts, ",")
+}
+
+// SignFeatureToken returns the X-Feature-Flags-Token for the X-Feature-Flags value flags:
+// "<unix expiry>.<hex HMAC-SHA256(secret, expiry + "." + flags)>". The signature covers the flags,
+// so a token cannot be replayed with other overrides.
+func SignFeatureToken(secret, flags string, expiry time.Time) string {
+	exp := strconv.FormatInt(expiry.Unix(), 10)
+	return exp + "." + debugTokenMAC(secret, exp+"."+flags)
+}
+
+// VerifyFeatureToken checks token against the exact flags header value; like debug tokens it must
+// expire within MaxDebugTokenTTL
+func VerifyFeatureToken(secret, flags, token string, now time.Time) bool {
+	return verifyExpiringToken(secret, token, "."+flags, now)
+}
+
+type featureOverridesKey struct{}
+
+// WithFeatureOverrides returns ctx carrying per-request flag overrides
+func WithFeatureOverrides(ctx context.Context, flags map[string]bool) context.Context {
+	return context.WithValue(ctx, featureOverridesKey{}, flags)
+}
+
+// FeatureOverrides returns the request's flag overrides, nil when there are none
+func FeatureOverrides(ctx context.Context) map[string]bool {
+	flags, _ := ctx.Value(featureOverridesKey{}).(map[string]bool)
+	return flags
+}
+
+// Features answers whether a flag is on: the request's override when it has one, else the default
+// from FEATURE_FLAGS. Unset flags are off.
+type Features struct {
+	defaults map[string]bool
+}
+
+func NewFeatures(defaults map[string]bool) *Features {
+	return &Features{defaults: defaults}
+}
+
+// Enabled reports whether name is on for ctx; nil-safe (only overrides count)
+func (f *Features) Enabled(ctx context.Context, name string) bool {
+	if on, ok := FeatureOverrides(ctx)[name]; ok {
+		return on
+	}
+	if f == nil {
+		return false
+	}
+	return f.defaults[name]
+}
@@ -0,0 +1,123 @@
+package helpers
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrFieldCipherOff     = errors.New("field encryption not configured")
+	ErrFieldKeyUnknown    = errors.New("field encryption key not configured")
+	ErrFieldCiphertextBad = errors.New("invalid field ciphertext")
+)
+
+// FieldCipher encrypts sensitive column values with AES-256-GCM. Ciphertexts are
+// "<key id>:<base64url nonce+sealed>", so any configured key decrypts what it wrote while only the
+// current key encrypts; rotating means adding a key, making it current and re-encrypting the rows
+// still on older ids. The associated data (e.g. "<user id>:phone") binds a value to its row, so a
+// ciphertext copied onto another user or field does not decrypt.
+// A nil *FieldCipher encrypts nothing and answers ErrFieldCipherOff.
+type FieldCipher struct {
+	current string
+	keys    map[string]cipher.AEAD
+}
+
+// NewFieldCipher builds a cipher from key id -> base64 32-byte key (std or url alphabet). current
+// names the encrypting key; empty picks the only key. No keys means nil: encryption is off.
+func NewFieldCipher(keys map[string]string, current string) (*FieldCipher, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	fc := &FieldCipher{current: current, keys: map[string]cipher.AEAD{}}
+	for id, enc := range keys {
+		if id == "" || strings.Contains(id, ":") {
+			return nil, fmt.Errorf("field key id %q: must be non-empty without ':'", id)
+		}
+		raw, err := base64.StdEncoding.DecodeString(enc)
+		if err != nil {
+			raw, err = base64.RawURLEncoding.DecodeString(strings.TrimRight(enc, "="))
+		}
+		if err != nil || len(raw) != 32 {
+			return nil, fmt.Errorf("field key %s: want 32 bytes of base64", id)
+		}
+		block, err := aes.NewCipher(raw)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		fc.keys[id] = aead
+		if current == "" && len(keys) == 1 {
+			fc.current = id
+		}
+	}
+	if fc.current == "" {
+		return nil, errors.New("several field keys configured: name the current one")
+	}
+	if _, ok := fc.keys[fc.current]; !ok {
+		return nil, fmt.Errorf("current field key %q is not among the configured keys", current)
+	}
+	return fc, nil
+}
+
+// KeyID is the id of the key new values are encrypted with
+func (f *FieldCipher) KeyID() string {
+	if f == nil {
+		return ""
+	}
+	return f.current
+}
+
+// Encrypt seals plaintext with the current key; aad must be given again to decrypt
+func (f *FieldCipher) Encrypt(plaintext, aad string) (string, error) {
+	if f == nil {
+		return "", ErrFieldCipherOff
+	}
+	aead := f.keys[f.current]
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), []byte(aad))
+	return f.current + ":" + base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a ciphertext written by Encrypt under any configured key
+func (f *FieldCipher) Decrypt(ciphertext, aad string) (string, error) {
+	if f == nil {
+		return "", ErrFieldCipherOff
+	}
+	id, body, ok := strings.Cut(ciphertext, ":")
+	if !ok {
+		return "", ErrFieldCiphertextBad
+	}
+	aead, ok := f.keys[id]
+	if !ok {
+		return "", ErrFieldKeyUnknown
+	}
+	sealed, err := base64.RawURLEncoding.DecodeString(body)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", ErrFieldCiphertextBad
+	}
+	plain, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(aad))
+	if err != nil {
+		return "", ErrFieldCiphertextBad
+	}
+	return string(plain), nil
+}
+
+// CiphertextKeyID is the id of the key a ciphertext was written with ("" when malformed)
+func CiphertextKeyID(ciphertext string) string {
+	id, _, ok := strings.Cut(ciphertext, ":")
+	if !ok {
+		return ""
+	}
+	return id
+}
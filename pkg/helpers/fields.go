@@ -0,0 +1,24 @@
+package helpers
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ParseFields parses a sparse fieldset parameter (fields=id,name) against the fields an endpoint exposes.
+// An empty value returns nil (all fields); duplicates are dropped and unknown names are an error.
+func ParseFields(raw string, allowed []string) ([]string, error) {
+	var out []string
+	for _, f := range strings.Split(raw, ",") {
+		f = strings.ToLower(strings.TrimSpace(f))
+		if f == "" || slices.Contains(out, f) {
+			continue
+		}
+		if !slices.Contains(allowed, f) {
+			return nil, fmt.Errorf("unknown field %q", f)
+		}
+		out = append(out, f)
+	}
+	return out, nil
+}
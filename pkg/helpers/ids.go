@@ -0,0 +1,137 @@
+package helpers
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Identifier formats for public IDs. Both sort by creation time, so listings can page on them, and
+// neither reveals the internal UUID primary key.
+//
+//   - ULID: 26 Crockford base32 characters, 48-bit millisecond timestamp then 80 random bits
+//     (https://github.com/ulid/spec); used for users.external_id
+//   - UUIDv7: a time-ordered UUID (RFC 9562) for new rows of UUID columns
+var (
+	ErrInvalidULID   = errors.New("invalid ULID")
+	ErrInvalidUUIDv7 = errors.New("invalid UUIDv7")
+)
+
+// ULIDLength is the length of an encoded ULID
+const ULIDLength = 26
+
+// crockfordValue decodes a base32 character (case-insensitive), -1 for anything else
+var crockfordValue = func() [256]int8 {
+	var t [256]int8
+	for i := range t {
+		t[i] = -1
+	}
+	for i := 0; i < len(crockford); i++ {
+		c := crockford[i]
+		t[c] = int8(i)
+		if c >= 'a' {
+			t[c-'a'+'A'] = int8(i)
+		}
+	}
+	return t
+}()
+
+// ulidSource keeps ULIDs from one process strictly increasing: within a millisecond the random
+// part of the previous ID is incremented instead of drawn again
+var ulidSource struct {
+	sync.Mutex
+	ms   uint64
+	last [10]byte
+}
+
+// NewULID returns a new ULID for the current time
+func NewULID() string { return NewULIDAt(time.Now()) }
+
+// NewULIDAt returns a new ULID for t; IDs made in the same millisecond by this process still sort
+// in the order they were made
+func NewULIDAt(t time.Time) string {
+	ms := uint64(t.UnixMilli())
+	var id [16]byte
+	ulidSource.Lock()
+	if ms != ulidSource.ms || !incrementEntropy(&ulidSource.last) {
+		if _, err := rand.Read(ulidSource.last[:]); err != nil {
+			ulidSource.Unlock()
+			panic("helpers: crypto/rand failed: " + err.Error())
+		}
+		ulidSource.ms = ms
+	}
+	copy(id[6:], ulidSource.last[:])
+	ulidSource.Unlock()
+
+	id[0], id[1], id[2] = byte(ms>>40), byte(ms>>32), byte(ms>>24)
+	id[3], id[4], id[5] = byte(ms>>16), byte(ms>>8), byte(ms)
+	return encodeULID(id)
+}
+
+// incrementEntropy adds one to b; false when it overflowed
+func incrementEntropy(b *[10]byte) bool {
+	for i := len(b) - 1; i >= 0; i-- {
+		b[i]++
+		if b[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// encodeULID writes the 128 bits of id as 26 upper-case base32 characters, the first carrying 3 bits
+func encodeULID(id [16]byte) string {
+	hi := binary.BigEndian.Uint64(id[:8])
+	lo := binary.BigEndian.Uint64(id[8:])
+	out := make([]byte, ULIDLength)
+	for i := ULIDLength - 1; i >= 0; i-- {
+		out[i] = crockford[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return strings.ToUpper(string(out))
+}
+
+// ParseULID validates s (either case) and returns its canonical upper-case form and timestamp
+func ParseULID(s string) (string, time.Time, error) {
+	if len(s) != ULIDLength || crockfordValue[s[0]] < 0 || crockfordValue[s[0]] > 7 {
+		return "", time.Time{}, ErrInvalidULID
+	}
+	var ms uint64
+	for i := 0; i < ULIDLength; i++ {
+		v := crockfordValue[s[i]]
+		if v < 0 {
+			return "", time.Time{}, ErrInvalidULID
+		}
+		if i < 10 {
+			ms = ms<<5 | uint64(v)
+		}
+	}
+	return strings.ToUpper(s), time.UnixMilli(int64(ms)).UTC(), nil
+}
+
+// IsULID reports whether s is a well-formed ULID
+func IsULID(s string) bool {
+	_, _, err := ParseULID(s)
+	return err == nil
+}
+
+// NewUUIDv7 returns a new time-ordered UUID in its canonical string form
+func NewUUIDv7() string {
+	return uuid.Must(uuid.NewV7()).String()
+}
+
+// ParseUUIDv7 validates that s is a version 7 UUID and returns its canonical form and timestamp
+func ParseUUIDv7(s string) (string, time.Time, error) {
+	u, err := uuid.Parse(s)
+	if err != nil || u.Version() != 7 {
+		return "", time.Time{}, ErrInvalidUUIDv7
+	}
+	sec, nsec := u.Time().UnixTime()
+	return u.String(), time.Unix(sec, nsec).UTC(), nil
+}
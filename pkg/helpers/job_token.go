@@ -0,0 +1,19 @@
+package helpers
+
+import (
+	"strconv"
+	"time"
+)
+
+// SignJobToken returns the X-Job-Token for job (empty for the job listing):
+// "<unix expiry>.<hex HMAC-SHA256(secret, expiry + "." + job)>". The signature covers the job name,
+// so a token cannot trigger another job.
+func SignJobToken(secret, job string, expiry time.Time) string {
+	exp := strconv.FormatInt(expiry.Unix(), 10)
+	return exp + "." + debugTokenMAC(secret, exp+"."+job)
+}
+
+// VerifyJobToken checks token against job; like debug tokens it must expire within MaxDebugTokenTTL
+func VerifyJobToken(secret, job, token string, now time.Time) bool {
+	return verifyExpiringToken(secret, token, "."+job, now)
+}
@@ -0,0 +1,99 @@
+package helpers
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+)
+
+// JSONDiff compares two JSON documents and returns the dotted paths where they differ (array
+// elements as path.N), at most limit of them (0 = all). Paths listed in ignore, and everything
+// under them, are skipped. Bodies that are not JSON are compared byte for byte under the path "body".
+func JSONDiff(a, b []byte, ignore []string, limit int) []string {
+	var va, vb any
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		if string(a) != string(b) {
+			return []string{"body"}
+		}
+		return nil
+	}
+	d := jsonDiffer{ignore: ignore, limit: limit}
+	d.walk("", va, vb)
+	return d.out
+}
+
+type jsonDiffer struct {
+	ignore []string
+	limit  int
+	out    []string
+}
+
+func (d *jsonDiffer) add(path string) bool {
+	if path == "" {
+		path = "$"
+	}
+	d.out = append(d.out, path)
+	return d.limit <= 0 || len(d.out) < d.limit
+}
+
+// walk records the differences under path; it returns false once the limit is reached
+func (d *jsonDiffer) walk(path string, a, b any) bool {
+	if slices.Contains(d.ignore, path) {
+		return true
+	}
+	switch av := a.(type) {
+	case map[string]any:
+		bv, ok := b.(map[string]any)
+		if !ok {
+			return d.add(path)
+		}
+		keys := make([]string, 0, len(av)+len(bv))
+		for k := range av {
+			keys = append(keys, k)
+		}
+		for k := range bv {
+			if _, ok := av[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if !d.walk(joinJSONPath(path, k), av[k], bv[k]) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		bv, ok := b.([]any)
+		if !ok {
+			return d.add(path)
+		}
+		for i := range max(len(av), len(bv)) {
+			p := joinJSONPath(path, strconv.Itoa(i))
+			if i >= len(av) || i >= len(bv) {
+				if !d.add(p) {
+					return false
+				}
+				continue
+			}
+			if !d.walk(p, av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		if !reflect.DeepEqual(a, b) {
+			return d.add(path)
+		}
+		return true
+	}
+}
+
+func joinJSONPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -1,7 +1,11 @@
 package helpers
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -13,16 +17,38 @@ type JWTManager struct {
 	RefreshSecret []byte
 	AccessTTL     time.Duration
 	RefreshTTL    time.Duration
+	// Audience is stamped on access tokens and required when parsing them (empty disables the check)
+	Audience string
+	// Leeway tolerates clock skew (and a request in flight as its token expires) on exp, nbf and iat
+	Leeway time.Duration
+
+	enrichers []ClaimsEnricher
 }
 
+// ClaimsEnricher returns custom claims (e.g. roles, org_id, locale) to stamp on a user's access tokens.
+// They are carried under the "ext" claim, so they can never override uid, sid or scope.
+type ClaimsEnricher func(ctx context.Context, userID string) (map[string]any, error)
+
+// Access token scopes. Tokens without a scope claim carry DefaultScopes.
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+)
+
+// DefaultScopes are granted to session (cookie) access tokens
+var DefaultScopes = []string{ScopeRead, ScopeWrite}
+
+var ErrScopeNotAllowed = errors.New("scope not allowed")
+
 var defaultManager *JWTManager
 
-func NewJWTManager(accessSecret, refreshSecret string, accessTTL, refreshTTL time.Duration) *JWTManager {
+func NewJWTManager(accessSecret, refreshSecret string, accessTTL, refreshTTL time.Duration, audience string) *JWTManager {
 	m := &JWTManager{
 		AccessSecret:  []byte(accessSecret),
 		RefreshSecret: []byte(refreshSecret),
 		AccessTTL:     accessTTL,
 		RefreshTTL:    refreshTTL,
+		Audience:      audience,
 	}
 	defaultManager = m
 	return m
@@ -34,24 +60,110 @@ func DefaultJWT() *JWTManager { return defaultManager }
 type Claims struct {
 	UserID    string `json:"uid"`
 	SessionID string `json:"sid"`
+	Scope     string `json:"scope,omitempty"` // space-delimited, as in RFC 8693
+	// Custom holds the claims added by registered ClaimsEnrichers (access tokens only)
+	Custom map[string]any `json:"ext,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// Scopes returns the token scopes, falling back to DefaultScopes for tokens without a scope claim
+func (c *Claims) Scopes() []string {
+	if strings.TrimSpace(c.Scope) == "" {
+		return slices.Clone(DefaultScopes)
+	}
+	return strings.Fields(c.Scope)
+}
+
+// HasScopes reports whether the token carries every one of the given scopes
+func (c *Claims) HasScopes(required ...string) bool {
+	have := c.Scopes()
+	for _, s := range required {
+		if !slices.Contains(have, s) {
+			return false
+		}
+	}
+	return true
+}
+
+// AddClaimsEnricher registers e for every access token issued afterwards; register enrichers at
+// startup, before the server handles requests. Later enrichers win on key conflicts.
+func (m *JWTManager) AddClaimsEnricher(e ClaimsEnricher) {
+	m.enrichers = append(m.enrichers, e)
+}
+
+// customClaims runs the registered enrichers; a failing enricher fails token generation
+func (m *JWTManager) customClaims(userID string) (map[string]any, error) {
+	if len(m.enrichers) == 0 {
+		return nil, nil
+	}
+	out := map[string]any{}
+	for _, e := range m.enrichers {
+		extra, err := e(context.Background(), userID)
+		if err != nil {
+			return nil, fmt.Errorf("claims enricher: %w", err)
+		}
+		for k, v := range extra {
+			out[k] = v
+		}
+	}
+	if len(out) == 0 {
+		return nil, nil
+	}
+	return out, nil
+}
+
 func (m *JWTManager) GenerateAccessToken(userID string, sessionID string) (string, time.Time, error) {
-	exp := time.Now().Add(m.AccessTTL)
+	return m.GenerateScopedAccessToken(userID, sessionID, m.Audience, DefaultScopes, m.AccessTTL)
+}
+
+// GenerateScopedAccessToken issues an access token limited to the given audience and scopes.
+// ttl is capped at AccessTTL.
+func (m *JWTManager) GenerateScopedAccessToken(userID, sessionID, audience string, scopes []string, ttl time.Duration) (string, time.Time, error) {
+	if ttl <= 0 || ttl > m.AccessTTL {
+		ttl = m.AccessTTL
+	}
+	custom, err := m.customClaims(userID)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	now := time.Now()
+	exp := now.Add(ttl)
 	claims := &Claims{
 		UserID:    userID,
 		SessionID: sessionID,
+		Scope:     strings.Join(scopes, " "),
+		Custom:    custom,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(exp),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			IssuedAt:  jwt.NewNumericDate(now),
 		},
 	}
+	if audience != "" {
+		claims.Audience = jwt.ClaimStrings{audience}
+	}
 	t := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	s, err := t.SignedString(m.AccessSecret)
 	return s, exp, err
 }
 
+// ReduceScopes validates that requested is a non-empty subset of granted
+func ReduceScopes(granted, requested []string) ([]string, error) {
+	if len(requested) == 0 {
+		return nil, ErrScopeNotAllowed
+	}
+	out := make([]string, 0, len(requested))
+	for _, s := range requested {
+		s = strings.ToLower(strings.TrimSpace(s))
+		if !slices.Contains(granted, s) {
+			return nil, ErrScopeNotAllowed
+		}
+		if !slices.Contains(out, s) {
+			out = append(out, s)
+		}
+	}
+	return out, nil
+}
+
 func (m *JWTManager) GenerateRefreshToken(userID string, sessionID string) (string, time.Time, error) {
 	exp := time.Now().Add(m.RefreshTTL)
 	claims := &Claims{
@@ -67,22 +179,39 @@ func (m *JWTManager) GenerateRefreshToken(userID string, sessionID string) (stri
 	return s, exp, err
 }
 
+// ParseAccessToken validates an access token, requiring the manager's audience when configured
 func (m *JWTManager) ParseAccessToken(tokenStr string) (*Claims, error) {
-	return parseToken(tokenStr, m.AccessSecret)
+	if m.Audience != "" {
+		return parseToken(tokenStr, m.AccessSecret, jwt.WithLeeway(m.Leeway), jwt.WithAudience(m.Audience))
+	}
+	claims, err := parseToken(tokenStr, m.AccessSecret, jwt.WithLeeway(m.Leeway))
+	if err != nil {
+		return nil, err
+	}
+	// without a configured audience, tokens minted for other audiences are still foreign
+	if len(claims.Audience) > 0 {
+		return nil, jwt.ErrTokenInvalidAudience
+	}
+	return claims, nil
+}
+
+// ParseAccessTokenAnyAudience validates signature and expiry but accepts any audience (used by introspection)
+func (m *JWTManager) ParseAccessTokenAnyAudience(tokenStr string) (*Claims, error) {
+	return parseToken(tokenStr, m.AccessSecret, jwt.WithLeeway(m.Leeway))
 }
 
 func (m *JWTManager) ParseRefreshToken(tokenStr string) (*Claims, error) {
-	return parseToken(tokenStr, m.RefreshSecret)
+	return parseToken(tokenStr, m.RefreshSecret, jwt.WithLeeway(m.Leeway))
 }
 
-func parseToken(tokenStr string, secret []byte) (*Claims, error) {
+func parseToken(tokenStr string, secret []byte, opts ...jwt.ParserOption) (*Claims, error) {
 	claims := &Claims{}
 	tkn, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, errors.New("unexpected signing method")
 		}
 		return secret, nil
-	})
+	}, opts...)
 	if err != nil {
 		return nil, err
 	}
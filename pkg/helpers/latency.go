@@ -0,0 +1,85 @@
+package helpers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// latencyBuckets are histogram upper bounds in seconds (Prometheus defaults)
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type latencyKey struct{ method, route string }
+
+type latencyHist struct {
+	counts []uint64 // per bucket, non-cumulative; last = +Inf
+	sum    float64
+	total  uint64
+}
+
+// LatencyHistograms records request durations per method and route template.
+type LatencyHistograms struct {
+	mu    sync.Mutex
+	hists map[latencyKey]*latencyHist
+}
+
+func NewLatencyHistograms() *LatencyHistograms {
+	return &LatencyHistograms{hists: map[latencyKey]*latencyHist{}}
+}
+
+// Observe adds one request; route should be the template (e.g. /api/users/:id) to bound cardinality. nil-safe.
+func (l *LatencyHistograms) Observe(method, route string, d time.Duration) {
+	if l == nil {
+		return
+	}
+	s := d.Seconds()
+	i := sort.SearchFloat64s(latencyBuckets, s)
+	k := latencyKey{method, route}
+	l.mu.Lock()
+	h := l.hists[k]
+	if h == nil {
+		h = &latencyHist{counts: make([]uint64, len(latencyBuckets)+1)}
+		l.hists[k] = h
+	}
+	h.counts[i]++
+	h.sum += s
+	h.total++
+	l.mu.Unlock()
+}
+
+// WriteMetrics appends http_request_duration_seconds in Prometheus text format. nil-safe.
+func (l *LatencyHistograms) WriteMetrics(b *strings.Builder) {
+	if l == nil {
+		return
+	}
+	const name = "http_request_duration_seconds"
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.hists) == 0 {
+		return
+	}
+	keys := make([]latencyKey, 0, len(l.hists))
+	for k := range l.hists {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].route != keys[j].route {
+			return keys[i].route < keys[j].route
+		}
+		return keys[i].method < keys[j].method
+	})
+	fmt.Fprintf(b, "# HELP %s Server processing time per route.\n# TYPE %s histogram\n", name, name)
+	for _, k := range keys {
+		h := l.hists[k]
+		labels := fmt.Sprintf("method=%q,route=%q", k.method, k.route)
+		var cum uint64
+		for i, le := range latencyBuckets {
+			cum += h.counts[i]
+			fmt.Fprintf(b, "%s_bucket{%s,le=\"%g\"} %d\n", name, labels, le, cum)
+		}
+		fmt.Fprintf(b, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, labels, h.total)
+		fmt.Fprintf(b, "%s_sum{%s} %g\n%s_count{%s} %d\n", name, labels, h.sum, name, labels, h.total)
+	}
+}
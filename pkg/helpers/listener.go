@@ -0,0 +1,146 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// Restart modes (RESTART_MODE) for upgrading the server binary without dropping connections
+const (
+	RestartModeOff       = ""
+	RestartModeReusePort = "reuseport" // the new process binds the same port next to the old one
+	RestartModeInherit   = "inherit"   // SIGHUP re-executes the binary, handing over the listening socket
+)
+
+const (
+	listenFDsEnv   = "LISTEN_FDS"
+	listenPIDEnv   = "LISTEN_PID"
+	upgradeFDEnv   = "UPGRADE_READY_FD"
+	firstListenFD  = 3 // systemd socket activation convention
+	readyByte      = 'R'
+	upgradeReadyFD = firstListenFD + 1
+)
+
+// Listen returns the server's listener: the socket inherited from a parent (LISTEN_FDS, set by
+// Upgrade or systemd socket activation) when present, otherwise a new one on addr, with
+// SO_REUSEPORT when reusePort is set. inherited reports which.
+func Listen(addr string, reusePort bool) (ln net.Listener, inherited bool, err error) {
+	if ln, err := inheritedListener(); ln != nil || err != nil {
+		return ln, ln != nil, err
+	}
+	lc := net.ListenConfig{}
+	if reusePort {
+		lc.Control = func(_, _ string, c syscall.RawConn) error {
+			var serr error
+			if err := c.Control(func(fd uintptr) {
+				serr = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_REUSEPORT, 1)
+			}); err != nil {
+				return err
+			}
+			return serr
+		}
+	}
+	ln, err = lc.Listen(context.Background(), "tcp", addr)
+	return ln, false, err
+}
+
+func inheritedListener() (net.Listener, error) {
+	n, _ := strconv.Atoi(os.Getenv(listenFDsEnv))
+	if n < 1 {
+		return nil, nil
+	}
+	if pid := os.Getenv(listenPIDEnv); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return nil, nil // meant for another process
+	}
+	_ = os.Unsetenv(listenFDsEnv)
+	_ = os.Unsetenv(listenPIDEnv)
+	f := os.NewFile(firstListenFD, "listener")
+	defer func() { _ = f.Close() }()
+	ln, err := net.FileListener(f)
+	if err != nil {
+		return nil, fmt.Errorf("inherited listener: %w", err)
+	}
+	return ln, nil
+}
+
+// Upgrade starts a new copy of this binary (same args and environment) that inherits ln, and waits
+// up to timeout for it to call NotifyReady. On success the caller should drain and exit; on error the
+// child has been killed and the caller keeps serving.
+func Upgrade(ln net.Listener, timeout time.Duration) (*os.Process, error) {
+	fl, ok := ln.(interface{ File() (*os.File, error) })
+	if !ok {
+		return nil, errors.New("listener cannot be handed over")
+	}
+	lf, err := fl.File()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = lf.Close() }()
+	ready, readyW, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = ready.Close() }()
+
+	exe, err := os.Executable()
+	if err != nil {
+		_ = readyW.Close()
+		return nil, err
+	}
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	cmd.ExtraFiles = []*os.File{lf, readyW} // fds 3 and 4
+	cmd.Env = append(os.Environ(), listenFDsEnv+"=1", upgradeFDEnv+"="+strconv.Itoa(upgradeReadyFD))
+	err = cmd.Start()
+	_ = readyW.Close()
+	if err != nil {
+		return nil, err
+	}
+	go func() { _ = cmd.Wait() }() // reap if it dies early; after a handover it outlives us
+
+	got := make(chan error, 1)
+	go func() {
+		b := make([]byte, 1)
+		if _, err := ready.Read(b); err != nil || b[0] != readyByte {
+			got <- errors.New("new process exited before it was ready")
+			return
+		}
+		got <- nil
+	}()
+	select {
+	case err = <-got:
+	case <-time.After(timeout):
+		err = fmt.Errorf("new process not ready after %s", timeout)
+	}
+	if err != nil {
+		_ = cmd.Process.Kill()
+		return nil, err
+	}
+	return cmd.Process, nil
+}
+
+// StartedByUpgrade reports whether a parent started this process with Upgrade and awaits NotifyReady
+func StartedByUpgrade() bool { return os.Getenv(upgradeFDEnv) != "" }
+
+// NotifyReady tells the parent that started this process with Upgrade that it is serving, so the
+// parent can drain. It is a no-op for a process that was not started by Upgrade.
+func NotifyReady() error {
+	fd, _ := strconv.Atoi(os.Getenv(upgradeFDEnv))
+	if fd < firstListenFD {
+		return nil
+	}
+	_ = os.Unsetenv(upgradeFDEnv)
+	f := os.NewFile(uintptr(fd), "upgrade-ready")
+	defer func() { _ = f.Close() }()
+	_, err := f.Write([]byte{readyByte})
+	return err
+}
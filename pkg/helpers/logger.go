@@ -1,11 +1,19 @@
 package helpers
 
 import (
+	"context"
 	"os"
+	"sync/atomic"
 
+	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 )
 
+type loggerKey struct{}
+
+// defaultLogger backs FromContext when a context carries no request logger
+var defaultLogger atomic.Pointer[logrus.Logger]
+
 // NewLogger creates a configured Logrus logger
 func NewLogger(appName, env string) *logrus.Logger {
 	logger := logrus.New()
@@ -18,9 +26,33 @@ func NewLogger(appName, env string) *logrus.Logger {
 		logger.SetFormatter(&logrus.JSONFormatter{})
 	}
 	logger.WithFields(logrus.Fields{"app": appName, "env": env}).Info("logger initialized")
+	defaultLogger.Store(logger)
 	return logger
 }
 
+// WithLogger stores a request-scoped logger entry in ctx
+func WithLogger(ctx context.Context, entry *logrus.Entry) context.Context {
+	return context.WithValue(ctx, loggerKey{}, entry)
+}
+
+// FromContext returns the logger stored by WithLogger, with the request's correlation fields bound.
+// Without one it returns the last logger NewLogger built (or logrus' standard logger), so it is
+// always safe to log through. A *gin.Context is looked up through its request context.
+func FromContext(ctx context.Context) *logrus.Entry {
+	if c, ok := ctx.(*gin.Context); ok && c.Request != nil {
+		ctx = c.Request.Context()
+	}
+	if ctx != nil {
+		if e, ok := ctx.Value(loggerKey{}).(*logrus.Entry); ok && e != nil {
+			return e
+		}
+	}
+	if l := defaultLogger.Load(); l != nil {
+		return logrus.NewEntry(l)
+	}
+	return logrus.NewEntry(logrus.StandardLogger())
+}
+
 // LogError Convenience methods to keep a unified logging interface
 func LogError(logger *logrus.Logger, msg string, err error, fields logrus.Fields) {
 	if fields == nil {
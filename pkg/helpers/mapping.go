@@ -8,22 +8,23 @@ import (
 	mailtpl "github.com/oksasatya/go-ddd-clean-architecture/pkg/mailer/templates"
 )
 
-func SubjectForUniversal(data map[string]any) string {
-	typeStr := fmt.Sprintf("%v", data["Type"])
-	switch strings.ToLower(typeStr) {
-	case mailtpl.LoginNotification:
-		return "New login to your account"
-	case mailtpl.VerifyEmail:
-		return "Verify your email address"
-	case mailtpl.ForgotPassword:
-		return "Reset your password"
-	case mailtpl.ProfileUpdated:
-		return "Your profile was updated successfully"
-	case mailtpl.LoginOTP:
-		return "Your login verification code"
-	default:
+// SubjectForUniversal resolves the subject for a universal email from the
+// locale-specific subject templates, falling back to a generic subject.
+// Type is matched case-insensitively, as the templates compare it lowercased.
+func SubjectForUniversal(data map[string]any, locale string) string {
+	if t, ok := data["Type"].(string); ok && t != strings.ToLower(strings.TrimSpace(t)) {
+		normalized := make(map[string]any, len(data))
+		for k, v := range data {
+			normalized[k] = v
+		}
+		normalized["Type"] = strings.ToLower(strings.TrimSpace(t))
+		data = normalized
+	}
+	subject, err := mailtpl.RenderSubject("universal", locale, data)
+	if err != nil || subject == "" {
 		return "Notification"
 	}
+	return subject
 }
 
 func EnsureRecipientAndEmail(job *mailer.EmailJob) {
@@ -37,16 +38,3 @@ func EnsureRecipientAndEmail(job *mailer.EmailJob) {
 		job.Data["RecipientEmail"] = job.To
 	}
 }
-
-func MapLegacyToUniversal(job *mailer.EmailJob) {
-	switch strings.ToLower(job.Template) {
-	case "login_notification", "verify_email", "forgot_password", "profile_updated", "login_otp":
-		if job.Data == nil {
-			job.Data = map[string]any{}
-		}
-		if _, ok := job.Data["Type"]; !ok || fmt.Sprintf("%v", job.Data["Type"]) == "" {
-			job.Data["Type"] = job.Template
-		}
-		job.Template = "universal"
-	}
-}
@@ -0,0 +1,61 @@
+package helpers
+
+import (
+	"sync"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// memoryRedisTick is how often MemoryRedis expires keys; TTLs run late by at most this much
+const memoryRedisTick = 500 * time.Millisecond
+
+// MemoryRedis is an in-process Redis server (miniredis) for development without Docker. Sessions,
+// login OTPs, rate limits and every other Redis-backed feature run unchanged against it, but the
+// data lives in this process only: it is lost on restart and not shared with the standalone email
+// worker or adminctl. miniredis only expires keys when told time has passed, so a ticker advances it.
+type MemoryRedis struct {
+	srv  *miniredis.Miniredis
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// StartMemoryRedis listens on a free loopback port; connect with Addr
+func StartMemoryRedis() (*MemoryRedis, error) {
+	srv, err := miniredis.Run()
+	if err != nil {
+		return nil, err
+	}
+	m := &MemoryRedis{srv: srv, stop: make(chan struct{}), done: make(chan struct{})}
+	go m.expire()
+	return m, nil
+}
+
+func (m *MemoryRedis) expire() {
+	defer close(m.done)
+	t := time.NewTicker(memoryRedisTick)
+	defer t.Stop()
+	last := time.Now()
+	for {
+		select {
+		case <-m.stop:
+			return
+		case now := <-t.C:
+			m.srv.FastForward(now.Sub(last))
+			last = now
+		}
+	}
+}
+
+// Addr is the host:port the server listens on
+func (m *MemoryRedis) Addr() string { return m.srv.Addr() }
+
+// Close stops the server; its data is gone
+func (m *MemoryRedis) Close() {
+	m.once.Do(func() {
+		close(m.stop)
+		<-m.done
+		m.srv.Close()
+	})
+}
@@ -3,18 +3,34 @@ package helpers
 import (
 	"crypto/rand"
 	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/keyspace"
 )
 
 // OTP helpers
 
 // KeyLoginOTP is the Redis key for storing OTP codes for login
 func KeyLoginOTP(uid string) string {
-	return "login:otp:" + uid
+	return keyspace.Key("login:otp:" + uid)
+}
+
+// KeyLoginPasswordOK marks that the user passed the password step of a login still waiting for its
+// second factor; backup codes are only accepted while it exists (they replace the OTP, not the password)
+func KeyLoginPasswordOK(uid string) string {
+	return keyspace.Key("login:pwok:" + uid)
 }
 
 // KeyTrustedDevice is the Redis key for storing trusted devices for a user
 func KeyTrustedDevice(uid, dev string) string {
-	return "login:trusted:" + uid + ":" + dev
+	return keyspace.Key("login:trusted:" + uid + ":" + dev)
+}
+
+// KeyVerifyResend is the Redis key for the one-time token a login blocked on email verification
+// may use to request a new verification email
+func KeyVerifyResend(token string) string {
+	return keyspace.Key("login:verify:resend:" + token)
 }
 
 // GenOTPCode generates a secure random 6-digit OTP code as a zero-padded string
@@ -31,3 +47,33 @@ func GenOTPCode() (string, error) {
 	code := n % 1000000
 	return fmt.Sprintf("%06d", code), nil
 }
+
+// crockford is Crockford's base32 alphabet: no i, l, o or u, so codes survive being read aloud
+const crockford = "0123456789abcdefghjkmnpqrstvwxyz"
+
+// GenBackupCode generates a 2FA recovery code of 10 base32 characters (50 bits) as "xxxxx-xxxxx"
+func GenBackupCode() (string, error) {
+	b := make([]byte, 10)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	for i := range b {
+		b[i] = crockford[b[i]&31]
+	}
+	return string(b[:5]) + "-" + string(b[5:]), nil
+}
+
+// NormalizeBackupCode undoes the ways users retype a code: case, spaces, dashes and look-alikes
+func NormalizeBackupCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		switch r = unicode.ToLower(r); r {
+		case ' ', '-':
+			return -1
+		case 'o':
+			return '0'
+		case 'i', 'l':
+			return '1'
+		}
+		return r
+	}, code)
+}
@@ -0,0 +1,140 @@
+package helpers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"runtime/pprof"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/config"
+)
+
+// Profiler records CPU and heap profiles every Interval and pushes them as pprof to a
+// Pyroscope-compatible /ingest endpoint. Tags (env, version, service) end up as labels.
+type Profiler struct {
+	ServerURL string
+	AuthToken string
+	App       string
+	Tags      map[string]string
+	Interval  time.Duration
+	HTTP      *http.Client
+	Logger    *logrus.Logger
+}
+
+func NewProfiler(serverURL, authToken, app string, tags map[string]string, interval time.Duration, logger *logrus.Logger) *Profiler {
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	return &Profiler{ServerURL: strings.TrimRight(serverURL, "/"), AuthToken: authToken, App: app, Tags: tags,
+		Interval: interval, HTTP: &http.Client{Timeout: 10 * time.Second}, Logger: logger}
+}
+
+// ProfilerFromConfig returns nil unless PROFILING_ENABLED and PROFILING_SERVER_URL are set.
+func ProfilerFromConfig(cfg *config.Config, service string, logger *logrus.Logger) *Profiler {
+	if !cfg.ProfilingEnabled || cfg.ProfilingServerURL == "" {
+		return nil
+	}
+	tags := map[string]string{"env": cfg.Env, "version": BuildVersion(), "service": service}
+	return NewProfiler(cfg.ProfilingServerURL, cfg.ProfilingAuthToken, cfg.AppName, tags, cfg.ProfilingInterval, logger)
+}
+
+// Run profiles until ctx is done. Push failures are logged and the next interval carries on. nil-safe.
+func (p *Profiler) Run(ctx context.Context) {
+	if p == nil {
+		return
+	}
+	for {
+		from := time.Now()
+		var cpu bytes.Buffer
+		cpuOn := pprof.StartCPUProfile(&cpu) == nil // fails when another CPU profile is running
+		select {
+		case <-ctx.Done():
+			if cpuOn {
+				pprof.StopCPUProfile()
+			}
+			return
+		case <-time.After(p.Interval):
+		}
+		until := time.Now()
+		if cpuOn {
+			pprof.StopCPUProfile()
+			p.push(ctx, "cpu", cpu.Bytes(), from, until)
+		}
+		var heap bytes.Buffer
+		if err := pprof.Lookup("heap").WriteTo(&heap, 0); err == nil {
+			p.push(ctx, "heap", heap.Bytes(), from, until)
+		}
+	}
+}
+
+func (p *Profiler) push(ctx context.Context, kind string, profile []byte, from, until time.Time) {
+	if err := p.upload(ctx, profile, from, until); err != nil && p.Logger != nil && ctx.Err() == nil {
+		p.Logger.WithError(err).WithField("profile", kind).Warn("profile push failed")
+	}
+}
+
+func (p *Profiler) upload(ctx context.Context, profile []byte, from, until time.Time) error {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, err := mw.CreateFormFile("profile", "profile.pprof")
+	if err != nil {
+		return err
+	}
+	if _, err := fw.Write(profile); err != nil {
+		return err
+	}
+	if err := mw.Close(); err != nil {
+		return err
+	}
+
+	q := url.Values{}
+	q.Set("name", p.name())
+	q.Set("from", strconv.FormatInt(from.Unix(), 10))
+	q.Set("until", strconv.FormatInt(until.Unix(), 10))
+	q.Set("format", "pprof")
+	q.Set("spyName", "gospy")
+	q.Set("sampleRate", "100")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.ServerURL+"/ingest?"+q.Encode(), &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	if p.AuthToken != "" {
+		req.Header.Set("Authorization", "Bearer "+p.AuthToken)
+	}
+	resp, err := p.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("ingest: status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// name renders app{k=v,...} with sorted keys; characters the label syntax reserves become '_'
+func (p *Profiler) name() string {
+	keys := make([]string, 0, len(p.Tags))
+	for k, v := range p.Tags {
+		if v != "" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	clean := strings.NewReplacer("{", "_", "}", "_", ",", "_", "=", "_", " ", "_")
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, clean.Replace(k)+"="+clean.Replace(p.Tags[k]))
+	}
+	return clean.Replace(p.App) + "{" + strings.Join(parts, ",") + "}"
+}
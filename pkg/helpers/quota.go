@@ -0,0 +1,61 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	ErrRateLimited   = errors.New("rate limit exceeded")
+	ErrQuotaExceeded = errors.New("quota exceeded")
+)
+
+// QuotaResult is the state of a fixed-window counter after a Consume/Peek
+type QuotaResult struct {
+	Limit int64 // <= 0 means unlimited
+	Used  int64
+	Reset time.Duration // time until the window resets
+}
+
+func (r QuotaResult) Exceeded() bool { return r.Limit > 0 && r.Used > r.Limit }
+
+func (r QuotaResult) Remaining() int64 {
+	if r.Limit <= 0 || r.Used >= r.Limit {
+		return 0
+	}
+	return r.Limit - r.Used
+}
+
+// Atomic INCRBY; set the window TTL on first use; return count and remaining TTL (ms)
+var quotaScript = redis.NewScript(`
+local current = redis.call("INCRBY", KEYS[1], ARGV[1])
+if current == tonumber(ARGV[1]) then
+  redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return {current, redis.call("PTTL", KEYS[1])}
+`)
+
+// ConsumeQuota adds n to the counter at key (expiring after window) and reports usage against limit.
+func ConsumeQuota(ctx context.Context, rdb *redis.Client, key string, n, limit int64, window time.Duration) (QuotaResult, error) {
+	res, err := quotaScript.Run(ctx, rdb, []string{key}, n, window.Milliseconds()).Int64Slice()
+	if err != nil {
+		return QuotaResult{Limit: limit}, err
+	}
+	return QuotaResult{Limit: limit, Used: res[0], Reset: time.Duration(res[1]) * time.Millisecond}, nil
+}
+
+// PeekQuota reads the counter at key without consuming.
+func PeekQuota(ctx context.Context, rdb *redis.Client, key string, limit int64) (QuotaResult, error) {
+	used, err := rdb.Get(ctx, key).Int64()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return QuotaResult{Limit: limit}, err
+	}
+	ttl, _ := rdb.PTTL(ctx, key).Result()
+	if ttl < 0 {
+		ttl = 0
+	}
+	return QuotaResult{Limit: limit, Used: used, Reset: ttl}, nil
+}
@@ -0,0 +1,293 @@
+package helpers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/keyspace"
+)
+
+// KeyRateBypass is the Redis hash of rate limit bypass entries ("<kind>:<value>" -> JSON entry)
+func KeyRateBypass() string { return keyspace.Key("ratelimit:bypass") }
+
+// Rate limit bypass entry kinds
+const (
+	BypassIP     = "ip"
+	BypassCIDR   = "cidr"
+	BypassUser   = "user"    // user ID; only limiters that run after authentication see it
+	BypassAPIKey = "api_key" // X-API-Key header; stored as its SHA-256
+)
+
+var ErrInvalidBypass = errors.New("invalid bypass entry")
+
+// RateBypassEntry exempts matching requests from rate limits
+type RateBypassEntry struct {
+	Kind      string     `json:"kind"`
+	Value     string     `json:"value"` // normalized IP, CIDR, user ID, or the API key's SHA-256 (hex)
+	Note      string     `json:"note,omitempty"`
+	AddedBy   string     `json:"added_by,omitempty"`
+	AddedAt   time.Time  `json:"added_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+func (e RateBypassEntry) field() string { return e.Kind + ":" + e.Value }
+
+func (e RateBypassEntry) expired(now time.Time) bool {
+	return e.ExpiresAt != nil && !now.Before(*e.ExpiresAt)
+}
+
+// hashAPIKey is how API keys are stored and matched; Redis never holds the key itself
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeBypass validates value for kind and returns its stored form
+func normalizeBypass(kind, value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", fmt.Errorf("%w: value is required", ErrInvalidBypass)
+	}
+	switch kind {
+	case BypassIP:
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return "", fmt.Errorf("%w: %q is not an IP address", ErrInvalidBypass, value)
+		}
+		return ip.String(), nil
+	case BypassCIDR:
+		_, n, err := net.ParseCIDR(value)
+		if err != nil {
+			return "", fmt.Errorf("%w: %q is not a CIDR", ErrInvalidBypass, value)
+		}
+		if ones, _ := n.Mask.Size(); ones == 0 {
+			return "", fmt.Errorf("%w: %q would exempt every address", ErrInvalidBypass, value)
+		}
+		return n.String(), nil
+	case BypassUser:
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return "", fmt.Errorf("%w: %q is not a user ID", ErrInvalidBypass, value)
+		}
+		return id.String(), nil
+	case BypassAPIKey:
+		return hashAPIKey(value), nil
+	}
+	return "", fmt.Errorf("%w: kind must be ip, cidr, user or api_key", ErrInvalidBypass)
+}
+
+type bypassSet struct {
+	ips   map[string]*time.Time
+	users map[string]*time.Time
+	keys  map[string]*time.Time
+	cidrs []bypassNet
+}
+
+type bypassNet struct {
+	net     *net.IPNet
+	expires *time.Time
+}
+
+func live(exp *time.Time, now time.Time) bool { return exp == nil || now.Before(*exp) }
+
+// RateBypass holds the runtime rate limit allowlist: IPs, CIDRs, user IDs and API keys kept in the
+// Redis hash KeyRateBypass and reloaded every refresh interval, so edits made on one replica reach
+// the others. Entries may expire. Lookups never touch Redis.
+type RateBypass struct {
+	rdb    *redis.Client
+	logger *logrus.Logger
+	set    atomic.Pointer[bypassSet]
+}
+
+func NewRateBypass(rdb *redis.Client, logger *logrus.Logger) *RateBypass {
+	b := &RateBypass{rdb: rdb, logger: logger}
+	b.set.Store(&bypassSet{})
+	return b
+}
+
+// Allows reports whether a request from ip, by userID or with apiKey skips rate limits; nil-safe
+func (b *RateBypass) Allows(ip, userID, apiKey string) bool {
+	if b == nil {
+		return false
+	}
+	s, now := b.set.Load(), time.Now()
+	if userID != "" {
+		if exp, ok := s.users[userID]; ok && live(exp, now) {
+			return true
+		}
+	}
+	if apiKey != "" && len(s.keys) > 0 {
+		if exp, ok := s.keys[hashAPIKey(apiKey)]; ok && live(exp, now) {
+			return true
+		}
+	}
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	if exp, ok := s.ips[parsed.String()]; ok && live(exp, now) {
+		return true
+	}
+	for _, n := range s.cidrs {
+		if n.net.Contains(parsed) && live(n.expires, now) {
+			return true
+		}
+	}
+	return false
+}
+
+// List returns the entries straight from Redis, expired ones left out, sorted by kind and value
+func (b *RateBypass) List(ctx context.Context) ([]RateBypassEntry, error) {
+	out := []RateBypassEntry{}
+	if b.rdb == nil {
+		return out, nil
+	}
+	raw, err := b.rdb.HGetAll(ctx, KeyRateBypass()).Result()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for field, v := range raw {
+		var e RateBypassEntry
+		if err := json.Unmarshal([]byte(v), &e); err != nil || e.Kind == "" {
+			b.logger.WithField("entry", field).Warn("ignoring invalid rate limit bypass entry in redis")
+			continue
+		}
+		if !e.expired(now) {
+			out = append(out, e)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Kind != out[j].Kind {
+			return out[i].Kind < out[j].Kind
+		}
+		return out[i].Value < out[j].Value
+	})
+	return out, nil
+}
+
+// Add validates e, stores it (replacing an entry for the same value) and applies it on this
+// replica right away; ttl > 0 makes it expire
+func (b *RateBypass) Add(ctx context.Context, e RateBypassEntry, ttl time.Duration) (RateBypassEntry, error) {
+	if b.rdb == nil {
+		return RateBypassEntry{}, errors.New("rate limit bypass requires redis")
+	}
+	v, err := normalizeBypass(e.Kind, e.Value)
+	if err != nil {
+		return RateBypassEntry{}, err
+	}
+	e.Value, e.AddedAt, e.ExpiresAt = v, time.Now().UTC(), nil
+	if ttl > 0 {
+		exp := e.AddedAt.Add(ttl)
+		e.ExpiresAt = &exp
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return RateBypassEntry{}, err
+	}
+	if err := b.rdb.HSet(ctx, KeyRateBypass(), e.field(), data).Err(); err != nil {
+		return RateBypassEntry{}, err
+	}
+	return e, b.Reload(ctx)
+}
+
+// Remove deletes the entry for value (an API key or its listed hash); it reports whether it existed
+func (b *RateBypass) Remove(ctx context.Context, kind, value string) (bool, error) {
+	if b.rdb == nil {
+		return false, nil
+	}
+	v, err := normalizeBypass(kind, value)
+	if err != nil {
+		return false, err
+	}
+	fields := []string{kind + ":" + v}
+	if kind == BypassAPIKey {
+		fields = append(fields, kind+":"+strings.ToLower(strings.TrimSpace(value)))
+	}
+	n, err := b.rdb.HDel(ctx, KeyRateBypass(), fields...).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, b.Reload(ctx)
+}
+
+// Reload replaces the in-memory allowlist with the Redis entries
+func (b *RateBypass) Reload(ctx context.Context) error {
+	entries, err := b.List(ctx)
+	if err != nil {
+		return err
+	}
+	s := &bypassSet{ips: map[string]*time.Time{}, users: map[string]*time.Time{}, keys: map[string]*time.Time{}}
+	for _, e := range entries {
+		switch e.Kind {
+		case BypassIP:
+			s.ips[e.Value] = e.ExpiresAt
+		case BypassUser:
+			s.users[e.Value] = e.ExpiresAt
+		case BypassAPIKey:
+			s.keys[e.Value] = e.ExpiresAt
+		case BypassCIDR:
+			if _, n, err := net.ParseCIDR(e.Value); err == nil {
+				s.cidrs = append(s.cidrs, bypassNet{net: n, expires: e.ExpiresAt})
+			}
+		}
+	}
+	b.set.Store(s)
+	return nil
+}
+
+// Run reloads the allowlist now and then every interval until ctx is cancelled, dropping expired
+// entries from Redis. Failures keep the previous list. It returns immediately without Redis.
+func (b *RateBypass) Run(ctx context.Context, interval time.Duration) {
+	if b == nil || b.rdb == nil {
+		return
+	}
+	reload := func() {
+		b.prune(ctx)
+		if err := b.Reload(ctx); err != nil && ctx.Err() == nil {
+			b.logger.WithError(err).Warn("rate limit bypass reload failed; keeping current list")
+		}
+	}
+	reload()
+	if interval <= 0 {
+		return
+	}
+	tk := time.NewTicker(interval)
+	defer tk.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tk.C:
+			reload()
+		}
+	}
+}
+
+// prune deletes expired entries
+func (b *RateBypass) prune(ctx context.Context) {
+	raw, err := b.rdb.HGetAll(ctx, KeyRateBypass()).Result()
+	if err != nil {
+		return
+	}
+	now := time.Now()
+	for field, v := range raw {
+		var e RateBypassEntry
+		if json.Unmarshal([]byte(v), &e) == nil && e.expired(now) {
+			b.rdb.HDel(ctx, KeyRateBypass(), field)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// substrings of normalized (lowercase, no _ or -) keys whose values are always redacted
+var sensitiveKeyParts = []string{
+	"password", "passwd", "secret", "token", "authorization", "cookie", "apikey", "otp",
+	"samlresponse", "assertion", "cardnumber", "creditcard", "cvv", "ssn", "phone", "address",
+}
+
+// exact normalized keys that are too short to match as substrings
+var sensitiveKeys = map[string]bool{"code": true, "pin": true, "key": true}
+
+var sensitiveHeaders = map[string]bool{
+	"Authorization": true, "Proxy-Authorization": true, "Cookie": true, "Set-Cookie": true,
+	"X-Api-Key": true, "X-Debug-Body-Log": true,
+}
+
+var (
+	emailRe   = regexp.MustCompile(`([A-Za-z0-9._%+-])[A-Za-z0-9._%+-]*(@[A-Za-z0-9.-]+\.[A-Za-z]{2,})`)
+	jsonKVRe  = regexp.MustCompile(`("([^"\\]+)"\s*:\s*)("(?:[^"\\]|\\.)*"|[^,}\]\s]+)`)
+	bearerRe  = regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9._~+/=-]+`)
+	jwtLikeRe = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+)
+
+// SensitiveKey reports whether a field name (JSON key, form field) holds a secret or PII
+func SensitiveKey(k string) bool {
+	n := strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(k))
+	if sensitiveKeys[n] {
+		return true
+	}
+	for _, p := range sensitiveKeyParts {
+		if strings.Contains(n, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactHeaders copies headers, replacing credentials with [REDACTED]
+func RedactHeaders(h http.Header) map[string]string {
+	out := make(map[string]string, len(h))
+	for k, v := range h {
+		if sensitiveHeaders[http.CanonicalHeaderKey(k)] {
+			out[k] = redacted
+			continue
+		}
+		out[k] = strings.Join(v, ", ")
+	}
+	return out
+}
+
+// RedactBody returns a loggable copy of a request/response body: JSON and form values under sensitive keys are
+// replaced, emails are masked (j***@example.com) and tokens removed. Bodies that cannot be parsed (e.g. truncated)
+// fall back to pattern-based redaction; multipart bodies are omitted.
+func RedactBody(contentType string, body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	ct := strings.ToLower(contentType)
+	switch {
+	case strings.Contains(ct, "multipart/"):
+		return "[multipart body omitted]"
+	case strings.Contains(ct, "application/x-www-form-urlencoded"):
+		if vals, err := url.ParseQuery(string(body)); err == nil {
+			for k, vs := range vals {
+				for i := range vs {
+					vs[i] = redactValue(k, vs[i])
+				}
+			}
+			return vals.Encode()
+		}
+	case strings.Contains(ct, "json") || json.Valid(body):
+		var v any
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.UseNumber()
+		if err := dec.Decode(&v); err == nil {
+			if b, err := json.Marshal(redactJSON(v)); err == nil {
+				return string(b)
+			}
+		}
+	}
+	return redactText(string(body))
+}
+
+func redactValue(key, v string) string {
+	if SensitiveKey(key) {
+		return redacted
+	}
+	return redactText(v)
+}
+
+func redactJSON(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, val := range t {
+			if SensitiveKey(k) {
+				t[k] = redacted
+				continue
+			}
+			t[k] = redactJSON(val)
+		}
+		return t
+	case []any:
+		for i := range t {
+			t[i] = redactJSON(t[i])
+		}
+		return t
+	case string:
+		return redactText(t)
+	}
+	return v
+}
+
+func redactText(s string) string {
+	s = jsonKVRe.ReplaceAllStringFunc(s, func(m string) string {
+		sub := jsonKVRe.FindStringSubmatch(m)
+		if SensitiveKey(sub[2]) {
+			return sub[1] + `"` + redacted + `"`
+		}
+		return m
+	})
+	s = bearerRe.ReplaceAllString(s, "${1}"+redacted)
+	s = jwtLikeRe.ReplaceAllString(s, redacted)
+	return emailRe.ReplaceAllString(s, "${1}***${2}")
+}
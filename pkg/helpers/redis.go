@@ -18,6 +18,11 @@ func NewRedisClient(addr, password string, db int) *redis.Client {
 	})
 }
 
+// NewRedisClientFromOptions initializes a redis client from parsed options (e.g. Config.RedisOptions)
+func NewRedisClientFromOptions(opts *redis.Options) *redis.Client {
+	return redis.NewClient(opts)
+}
+
 func RedisSetJSON(ctx context.Context, rdb *redis.Client, key string, value interface{}, ttl time.Duration) error {
 	b, err := json.Marshal(value)
 	if err != nil {
@@ -0,0 +1,201 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultRedisBatchSize bounds the keys of one pipeline or MGET, so a large listing neither
+// stalls Redis nor buffers an unbounded reply
+const DefaultRedisBatchSize = 500
+
+// redisBatchSizeBuckets are histogram upper bounds for commands per pipeline
+var redisBatchSizeBuckets = []float64{1, 5, 10, 25, 50, 100, 250, 500, 1000}
+
+// RedisBatch runs reads for many keys in pipelines of at most Size keys, so listing the
+// sessions of a page of users (SessionStore.ListByUsers: HGETALL and TTL per user) costs a round
+// trip per batch instead of one per key. Each pipeline
+// is recorded in Metrics under the caller's op name.
+type RedisBatch struct {
+	Redis   *redis.Client
+	Size    int
+	Metrics *RedisBatchMetrics // optional
+}
+
+func NewRedisBatch(rdb *redis.Client, metrics *RedisBatchMetrics) *RedisBatch {
+	return &RedisBatch{Redis: rdb, Size: DefaultRedisBatchSize, Metrics: metrics}
+}
+
+func (b *RedisBatch) size() int {
+	if b.Size <= 0 {
+		return DefaultRedisBatchSize
+	}
+	return b.Size
+}
+
+// Pipeline calls queue for items [0, n), which adds the item's commands to pipe, and executes them
+// in pipelines of at most Size items. redis.Nil replies are not errors: check each command's result.
+func (b *RedisBatch) Pipeline(ctx context.Context, op string, n int, queue func(pipe redis.Pipeliner, i int)) error {
+	for start := 0; start < n; start += b.size() {
+		end := min(start+b.size(), n)
+		pipe := b.Redis.Pipeline()
+		for i := start; i < end; i++ {
+			queue(pipe, i)
+		}
+		cmds, began := pipe.Len(), time.Now()
+		_, err := pipe.Exec(ctx)
+		b.Metrics.Observe(op, cmds, time.Since(began), err)
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return err
+		}
+	}
+	return nil
+}
+
+// MGet returns the string value of each key, "" for a missing key, with one MGET per Size keys
+func (b *RedisBatch) MGet(ctx context.Context, op string, keys []string) ([]string, error) {
+	out := make([]string, len(keys))
+	for start := 0; start < len(keys); start += b.size() {
+		end := min(start+b.size(), len(keys))
+		began := time.Now()
+		vals, err := b.Redis.MGet(ctx, keys[start:end]...).Result()
+		b.Metrics.Observe(op, end-start, time.Since(began), err)
+		if err != nil {
+			return nil, err
+		}
+		for i, v := range vals {
+			if s, ok := v.(string); ok {
+				out[start+i] = s
+			}
+		}
+	}
+	return out, nil
+}
+
+// TrustedDevice is a device id of a user to check with TrustedDevices
+type TrustedDevice struct {
+	UserID   string
+	DeviceID string
+}
+
+// TrustedDevices reports for each pair whether the device is still trusted for the user's logins
+// (KeyTrustedDevice); pairs without a device id are not. nil-safe: a nil batch trusts nothing.
+func (b *RedisBatch) TrustedDevices(ctx context.Context, pairs []TrustedDevice) ([]bool, error) {
+	out := make([]bool, len(pairs))
+	if b == nil || b.Redis == nil {
+		return out, nil
+	}
+	keys := make([]string, 0, len(pairs))
+	idx := make([]int, 0, len(pairs))
+	for i, p := range pairs {
+		if p.UserID != "" && p.DeviceID != "" {
+			keys = append(keys, KeyTrustedDevice(p.UserID, p.DeviceID))
+			idx = append(idx, i)
+		}
+	}
+	if len(keys) == 0 {
+		return out, nil
+	}
+	vals, err := b.MGet(ctx, "trusted_devices", keys)
+	if err != nil {
+		return out, err
+	}
+	for n, v := range vals {
+		out[idx[n]] = v == "1"
+	}
+	return out, nil
+}
+
+type redisBatchStats struct {
+	sizes     []uint64 // per size bucket, non-cumulative; last = +Inf
+	sizeSum   uint64
+	latencies []uint64 // per latency bucket, non-cumulative; last = +Inf
+	latSum    float64
+	total     uint64
+	errors    uint64
+}
+
+// RedisBatchMetrics records the pipelines RedisBatch runs per op: how many commands each carried,
+// how long it took and how many failed.
+type RedisBatchMetrics struct {
+	mu  sync.Mutex
+	ops map[string]*redisBatchStats
+}
+
+func NewRedisBatchMetrics() *RedisBatchMetrics {
+	return &RedisBatchMetrics{ops: map[string]*redisBatchStats{}}
+}
+
+// Observe adds one pipeline; a redis.Nil reply is not a failure. nil-safe.
+func (m *RedisBatchMetrics) Observe(op string, size int, d time.Duration, err error) {
+	if m == nil {
+		return
+	}
+	si := sort.SearchFloat64s(redisBatchSizeBuckets, float64(size))
+	li := sort.SearchFloat64s(latencyBuckets, d.Seconds())
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := m.ops[op]
+	if s == nil {
+		s = &redisBatchStats{sizes: make([]uint64, len(redisBatchSizeBuckets)+1), latencies: make([]uint64, len(latencyBuckets)+1)}
+		m.ops[op] = s
+	}
+	s.sizes[si]++
+	s.sizeSum += uint64(size)
+	s.latencies[li]++
+	s.latSum += d.Seconds()
+	s.total++
+	if err != nil && !errors.Is(err, redis.Nil) {
+		s.errors++
+	}
+}
+
+// WriteMetrics appends redis_pipeline_commands, redis_pipeline_duration_seconds and
+// redis_pipeline_errors_total in Prometheus text format. nil-safe.
+func (m *RedisBatchMetrics) WriteMetrics(b *strings.Builder) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.ops) == 0 {
+		return
+	}
+	ops := make([]string, 0, len(m.ops))
+	for op := range m.ops {
+		ops = append(ops, op)
+	}
+	sort.Strings(ops)
+	b.WriteString("# HELP redis_pipeline_commands Commands per batched Redis pipeline.\n# TYPE redis_pipeline_commands histogram\n")
+	for _, op := range ops {
+		s := m.ops[op]
+		writeHistogram(b, "redis_pipeline_commands", fmt.Sprintf("op=%q", op), redisBatchSizeBuckets, s.sizes, float64(s.sizeSum), s.total)
+	}
+	b.WriteString("# HELP redis_pipeline_duration_seconds Round trip of batched Redis pipelines.\n# TYPE redis_pipeline_duration_seconds histogram\n")
+	for _, op := range ops {
+		s := m.ops[op]
+		writeHistogram(b, "redis_pipeline_duration_seconds", fmt.Sprintf("op=%q", op), latencyBuckets, s.latencies, s.latSum, s.total)
+	}
+	b.WriteString("# HELP redis_pipeline_errors_total Batched Redis pipelines that failed.\n# TYPE redis_pipeline_errors_total counter\n")
+	for _, op := range ops {
+		fmt.Fprintf(b, "redis_pipeline_errors_total{op=%q} %d\n", op, m.ops[op].errors)
+	}
+}
+
+// writeHistogram writes one labelled histogram series from non-cumulative bucket counts
+func writeHistogram(b *strings.Builder, name, labels string, bounds []float64, counts []uint64, sum float64, total uint64) {
+	var cum uint64
+	for i, le := range bounds {
+		cum += counts[i]
+		fmt.Fprintf(b, "%s_bucket{%s,le=\"%g\"} %d\n", name, labels, le, cum)
+	}
+	fmt.Fprintf(b, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, labels, total)
+	fmt.Fprintf(b, "%s_sum{%s} %g\n%s_count{%s} %d\n", name, labels, sum, name, labels, total)
+}
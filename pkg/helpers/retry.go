@@ -0,0 +1,66 @@
+package helpers
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/config"
+)
+
+// RetryConfig controls startup connection retries with exponential backoff.
+type RetryConfig struct {
+	Attempts   int           // total attempts; <= 1 means a single try
+	Initial    time.Duration // first backoff
+	MaxBackoff time.Duration // backoff cap
+}
+
+// Retry calls fn until it succeeds, attempts are exhausted, or ctx is done.
+// Backoff doubles after each failure up to MaxBackoff. The last error is returned.
+func Retry(ctx context.Context, rc RetryConfig, name string, logger *logrus.Logger, fn func() error) error {
+	attempts := rc.Attempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	backoff := rc.Initial
+	if backoff <= 0 {
+		backoff = time.Second
+	}
+	var err error
+	for i := 1; i <= attempts; i++ {
+		if err = fn(); err == nil {
+			return nil
+		}
+		if i == attempts {
+			break
+		}
+		if logger != nil {
+			logger.WithError(err).WithFields(logrus.Fields{
+				"dependency": name,
+				"attempt":    i,
+				"of":         attempts,
+				"retry_in":   backoff.String(),
+			}).Warn("dependency not ready, retrying")
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if rc.MaxBackoff > 0 && backoff > rc.MaxBackoff {
+			backoff = rc.MaxBackoff
+		}
+	}
+	return err
+}
+
+// StartupRetry builds the RetryConfig for boot-time dependency connections.
+func StartupRetry(cfg *config.Config) RetryConfig {
+	return RetryConfig{
+		Attempts:   cfg.StartupRetryAttempts,
+		Initial:    cfg.StartupRetryBackoff,
+		MaxBackoff: cfg.StartupRetryMaxBackoff,
+	}
+}
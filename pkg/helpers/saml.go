@@ -0,0 +1,462 @@
+package helpers
+
+import (
+	"bytes"
+	"compress/flate"
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/beevik/etree"
+	"github.com/google/uuid"
+	dsig "github.com/russellhaering/goxmldsig"
+	"github.com/russellhaering/goxmldsig/etreeutils"
+)
+
+const (
+	nsSAMLAssertion = "urn:oasis:names:tc:SAML:2.0:assertion"
+	nsSAMLProtocol  = "urn:oasis:names:tc:SAML:2.0:protocol"
+	nsSAMLMetadata  = "urn:oasis:names:tc:SAML:2.0:metadata"
+
+	samlBindingRedirect = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect"
+	samlBindingPOST     = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST"
+	samlStatusSuccess   = "urn:oasis:names:tc:SAML:2.0:status:Success"
+	samlNameIDEmail     = "urn:oasis:names:tc:SAML:1.1:nameid-format:emailAddress"
+	samlBearer          = "urn:oasis:names:tc:SAML:2.0:cm:bearer"
+)
+
+var (
+	ErrSAMLEncrypted  = errors.New("saml: encrypted assertions are not supported")
+	ErrSAMLInvalid    = errors.New("saml: invalid response")
+	ErrSAMLNotSuccess = errors.New("saml: idp returned a non-success status")
+	ErrSAMLSignature  = errors.New("saml: signature verification failed")
+)
+
+// maxXMLDocumentSize bounds IdP metadata and SAML responses
+const maxXMLDocumentSize = 1 << 20
+
+// SAMLConfig holds service-provider settings and where to load IdP metadata from
+type SAMLConfig struct {
+	EntityID        string
+	ACSURL          string
+	IDPMetadataURL  string
+	IDPMetadataFile string
+	ClockSkew       time.Duration
+}
+
+// SAMLProvider is a minimal SAML 2.0 service provider: SP metadata, HTTP-Redirect AuthnRequests
+// and HTTP-POST responses with signed (unencrypted) assertions.
+// IdP metadata is loaded lazily so an unavailable IdP does not block startup.
+type SAMLProvider struct {
+	cfg    SAMLConfig
+	client *http.Client
+
+	mu  sync.Mutex
+	idp *samlIDP
+}
+
+type samlIDP struct {
+	EntityID string
+	SSOURL   string
+	Certs    []*x509.Certificate
+}
+
+// SAMLAssertion is the validated subset of an assertion used for login
+type SAMLAssertion struct {
+	ID           string
+	Issuer       string
+	NameID       string
+	NameIDFormat string
+	NotOnOrAfter time.Time
+	Attributes   map[string][]string
+}
+
+// Attr returns the first value of the attribute (matched by Name or FriendlyName)
+func (a *SAMLAssertion) Attr(name string) string {
+	if v := a.Attributes[name]; len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+func NewSAMLProvider(cfg SAMLConfig) *SAMLProvider {
+	if cfg.ClockSkew <= 0 {
+		cfg.ClockSkew = 2 * time.Minute
+	}
+	return &SAMLProvider{cfg: cfg, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Metadata returns the SP EntityDescriptor XML for registration with the IdP
+func (p *SAMLProvider) Metadata() []byte {
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	fmt.Fprintf(&b, `<md:EntityDescriptor xmlns:md="%s" entityID="%s">`, nsSAMLMetadata, xmlEscape(p.cfg.EntityID))
+	fmt.Fprintf(&b, `<md:SPSSODescriptor AuthnRequestsSigned="false" WantAssertionsSigned="true" protocolSupportEnumeration="%s">`, nsSAMLProtocol)
+	fmt.Fprintf(&b, `<md:NameIDFormat>%s</md:NameIDFormat>`, samlNameIDEmail)
+	fmt.Fprintf(&b, `<md:AssertionConsumerService Binding="%s" Location="%s" index="0" isDefault="true"/>`, samlBindingPOST, xmlEscape(p.cfg.ACSURL))
+	b.WriteString(`</md:SPSSODescriptor></md:EntityDescriptor>`)
+	return b.Bytes()
+}
+
+func xmlEscape(s string) string {
+	var b bytes.Buffer
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+func (p *SAMLProvider) loadIDP(ctx context.Context) (*samlIDP, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.idp != nil {
+		return p.idp, nil
+	}
+	var raw []byte
+	var err error
+	switch {
+	case p.cfg.IDPMetadataFile != "":
+		raw, err = os.ReadFile(p.cfg.IDPMetadataFile)
+	case p.cfg.IDPMetadataURL != "":
+		raw, err = p.fetch(ctx, p.cfg.IDPMetadataURL)
+	default:
+		err = errors.New("saml: no IdP metadata configured")
+	}
+	if err != nil {
+		return nil, err
+	}
+	idp, err := parseIDPMetadata(raw)
+	if err != nil {
+		return nil, err
+	}
+	p.idp = idp
+	return idp, nil
+}
+
+func (p *SAMLProvider) fetch(ctx context.Context, u string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("saml: GET %s: %s", u, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxXMLDocumentSize))
+}
+
+func parseIDPMetadata(raw []byte) (*samlIDP, error) {
+	var md struct {
+		EntityID string `xml:"entityID,attr"`
+		IDP      struct {
+			Keys []struct {
+				Use   string   `xml:"use,attr"`
+				Certs []string `xml:"KeyInfo>X509Data>X509Certificate"`
+			} `xml:"KeyDescriptor"`
+			SSO []struct {
+				Binding  string `xml:"Binding,attr"`
+				Location string `xml:"Location,attr"`
+			} `xml:"SingleSignOnService"`
+		} `xml:"IDPSSODescriptor"`
+	}
+	if err := xml.Unmarshal(raw, &md); err != nil {
+		return nil, fmt.Errorf("saml: parse idp metadata: %w", err)
+	}
+	idp := &samlIDP{EntityID: md.EntityID}
+	for _, s := range md.IDP.SSO {
+		if s.Binding == samlBindingRedirect {
+			idp.SSOURL = s.Location
+		}
+	}
+	for _, k := range md.IDP.Keys {
+		if k.Use != "" && k.Use != "signing" {
+			continue
+		}
+		for _, c := range k.Certs {
+			der, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(c), ""))
+			if err != nil {
+				continue
+			}
+			if cert, err := x509.ParseCertificate(der); err == nil {
+				idp.Certs = append(idp.Certs, cert)
+			}
+		}
+	}
+	if idp.EntityID == "" || idp.SSOURL == "" || len(idp.Certs) == 0 {
+		return nil, errors.New("saml: idp metadata needs entityID, an HTTP-Redirect SSO endpoint and a signing certificate")
+	}
+	return idp, nil
+}
+
+// AuthnRequestURL builds an HTTP-Redirect AuthnRequest and returns the URL and request ID
+func (p *SAMLProvider) AuthnRequestURL(ctx context.Context, relayState string) (string, string, error) {
+	idp, err := p.loadIDP(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	id := "_" + uuid.NewString()
+	req := fmt.Sprintf(`<samlp:AuthnRequest xmlns:samlp="%s" xmlns:saml="%s" ID="%s" Version="2.0" IssueInstant="%s" Destination="%s" AssertionConsumerServiceURL="%s" ProtocolBinding="%s"><saml:Issuer>%s</saml:Issuer><samlp:NameIDPolicy Format="%s" AllowCreate="true"/></samlp:AuthnRequest>`,
+		nsSAMLProtocol, nsSAMLAssertion, id, time.Now().UTC().Format(time.RFC3339), xmlEscape(idp.SSOURL), xmlEscape(p.cfg.ACSURL), samlBindingPOST, xmlEscape(p.cfg.EntityID), samlNameIDEmail)
+
+	var buf bytes.Buffer
+	fw, _ := flate.NewWriter(&buf, flate.DefaultCompression)
+	_, _ = fw.Write([]byte(req))
+	_ = fw.Close()
+
+	q := url.Values{}
+	q.Set("SAMLRequest", base64.StdEncoding.EncodeToString(buf.Bytes()))
+	if relayState != "" {
+		q.Set("RelayState", relayState)
+	}
+	sep := "?"
+	if strings.Contains(idp.SSOURL, "?") {
+		sep = "&"
+	}
+	return idp.SSOURL + sep + q.Encode(), id, nil
+}
+
+type samlAssertionXML struct {
+	ID      string `xml:"ID,attr"`
+	Issuer  string `xml:"Issuer"`
+	Subject struct {
+		NameID struct {
+			Format string `xml:"Format,attr"`
+			Value  string `xml:",chardata"`
+		} `xml:"NameID"`
+		Confirmations []struct {
+			Method string `xml:"Method,attr"`
+			Data   struct {
+				InResponseTo string `xml:"InResponseTo,attr"`
+				Recipient    string `xml:"Recipient,attr"`
+				NotOnOrAfter string `xml:"NotOnOrAfter,attr"`
+			} `xml:"SubjectConfirmationData"`
+		} `xml:"SubjectConfirmation"`
+	} `xml:"Subject"`
+	Conditions struct {
+		NotBefore    string `xml:"NotBefore,attr"`
+		NotOnOrAfter string `xml:"NotOnOrAfter,attr"`
+		Audiences    []struct {
+			Audience []string `xml:"Audience"`
+		} `xml:"AudienceRestriction"`
+	} `xml:"Conditions"`
+	Attributes []struct {
+		Name         string   `xml:"Name,attr"`
+		FriendlyName string   `xml:"FriendlyName,attr"`
+		Values       []string `xml:"AttributeValue"`
+	} `xml:"AttributeStatement>Attribute"`
+}
+
+// ParseResponse validates a base64 HTTP-POST SAMLResponse. requestIDs lists the outstanding
+// AuthnRequest IDs; an empty list only accepts IdP-initiated assertions (no InResponseTo).
+// Signatures are checked by goxmldsig and only the signed element it returns is read, which
+// defeats signature wrapping.
+func (p *SAMLProvider) ParseResponse(ctx context.Context, samlResponse string, requestIDs []string) (*SAMLAssertion, error) {
+	idp, err := p.loadIDP(ctx)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(samlResponse))
+	if err != nil || len(raw) > maxXMLDocumentSize {
+		return nil, ErrSAMLInvalid
+	}
+	doc := etree.NewDocument()
+	if err := doc.ReadFromBytes(raw); err != nil {
+		return nil, ErrSAMLInvalid
+	}
+	resp := doc.Root()
+	if resp == nil || resp.Tag != "Response" || resp.NamespaceURI() != nsSAMLProtocol {
+		return nil, ErrSAMLInvalid
+	}
+	if d := resp.SelectAttrValue("Destination", ""); d != "" && d != p.cfg.ACSURL {
+		return nil, fmt.Errorf("%w: destination mismatch", ErrSAMLInvalid)
+	}
+	status := samlChild(resp, nsSAMLProtocol, "Status")
+	if status == nil {
+		return nil, ErrSAMLInvalid
+	}
+	if code := samlChild(status, nsSAMLProtocol, "StatusCode"); code == nil || code.SelectAttrValue("Value", "") != samlStatusSuccess {
+		return nil, ErrSAMLNotSuccess
+	}
+	if len(samlChildren(resp, nsSAMLAssertion, "EncryptedAssertion")) > 0 {
+		return nil, ErrSAMLEncrypted
+	}
+	assertions := samlChildren(resp, nsSAMLAssertion, "Assertion")
+	if len(assertions) != 1 {
+		return nil, fmt.Errorf("%w: expected exactly one assertion", ErrSAMLInvalid)
+	}
+
+	// Prefer the assertion signature; fall back to a signed Response. Only verified elements are parsed.
+	verified, err := p.verify(assertions[0], idp.Certs)
+	if errors.Is(err, dsig.ErrMissingSignature) {
+		signedResp, rErr := p.verify(resp, idp.Certs)
+		if rErr != nil {
+			return nil, rErr
+		}
+		inner := samlChildren(signedResp, nsSAMLAssertion, "Assertion")
+		if len(inner) != 1 {
+			return nil, ErrSAMLInvalid
+		}
+		if verified, err = detachXML(inner[0]); err != nil {
+			return nil, ErrSAMLInvalid
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	out := etree.NewDocument()
+	out.SetRoot(verified)
+	signed, err := out.WriteToBytes()
+	if err != nil {
+		return nil, ErrSAMLInvalid
+	}
+	var a samlAssertionXML
+	if err := xml.Unmarshal(signed, &a); err != nil {
+		return nil, ErrSAMLInvalid
+	}
+	return p.validate(&a, idp, requestIDs)
+}
+
+// verify checks the enveloped signature of el against the IdP certificates and returns the
+// signed copy of el (without the signature)
+func (p *SAMLProvider) verify(el *etree.Element, certs []*x509.Certificate) (*etree.Element, error) {
+	// The copy carries the namespaces declared by its ancestors, which canonicalization needs
+	detached, err := detachXML(el)
+	if err != nil {
+		return nil, ErrSAMLInvalid
+	}
+	// A signature covers exactly one element; extra references are never produced by IdPs
+	for _, sig := range detached.FindElements(".//Signature") {
+		if len(sig.FindElements("./SignedInfo/Reference")) > 1 {
+			return nil, fmt.Errorf("%w: signature with multiple references", ErrSAMLInvalid)
+		}
+	}
+	vc := dsig.NewDefaultValidationContext(&dsig.MemoryX509CertificateStore{Roots: certs})
+	verified, err := vc.Validate(detached)
+	if errors.Is(err, dsig.ErrMissingSignature) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSAMLSignature, err)
+	}
+	return verified, nil
+}
+
+func detachXML(el *etree.Element) (*etree.Element, error) {
+	ns, err := etreeutils.NSBuildParentContext(el)
+	if err != nil {
+		return nil, err
+	}
+	return etreeutils.NSDetatch(ns, el)
+}
+
+func samlChildren(el *etree.Element, ns, local string) []*etree.Element {
+	var out []*etree.Element
+	for _, c := range el.ChildElements() {
+		if c.Tag == local && c.NamespaceURI() == ns {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func samlChild(el *etree.Element, ns, local string) *etree.Element {
+	if c := samlChildren(el, ns, local); len(c) > 0 {
+		return c[0]
+	}
+	return nil
+}
+
+func (p *SAMLProvider) validate(a *samlAssertionXML, idp *samlIDP, requestIDs []string) (*SAMLAssertion, error) {
+	now := time.Now()
+	skew := p.cfg.ClockSkew
+	// The ID keys the replay cache; the schema requires it
+	if strings.TrimSpace(a.ID) == "" {
+		return nil, fmt.Errorf("%w: assertion without ID", ErrSAMLInvalid)
+	}
+	if strings.TrimSpace(a.Issuer) != idp.EntityID {
+		return nil, fmt.Errorf("%w: issuer mismatch", ErrSAMLInvalid)
+	}
+	if t, ok := parseSAMLTime(a.Conditions.NotBefore); ok && now.Add(skew).Before(t) {
+		return nil, fmt.Errorf("%w: assertion not yet valid", ErrSAMLInvalid)
+	}
+	notOnOrAfter, hasExpiry := parseSAMLTime(a.Conditions.NotOnOrAfter)
+	if hasExpiry && !now.Add(-skew).Before(notOnOrAfter) {
+		return nil, fmt.Errorf("%w: assertion expired", ErrSAMLInvalid)
+	}
+	audienceOK := false
+	for _, r := range a.Conditions.Audiences {
+		if slices.Contains(r.Audience, p.cfg.EntityID) {
+			audienceOK = true
+		}
+	}
+	if !audienceOK {
+		return nil, fmt.Errorf("%w: audience mismatch", ErrSAMLInvalid)
+	}
+
+	confirmed := false
+	for _, sc := range a.Subject.Confirmations {
+		d := sc.Data
+		if sc.Method != samlBearer || d.Recipient != p.cfg.ACSURL {
+			continue
+		}
+		exp, ok := parseSAMLTime(d.NotOnOrAfter)
+		if !ok || !now.Add(-skew).Before(exp) {
+			continue
+		}
+		if d.InResponseTo != "" && !slices.Contains(requestIDs, d.InResponseTo) {
+			continue
+		}
+		if d.InResponseTo == "" && len(requestIDs) > 0 {
+			continue
+		}
+		confirmed = true
+		// the earlier of the two expiries bounds the replay cache entry
+		if !hasExpiry || exp.Before(notOnOrAfter) {
+			notOnOrAfter, hasExpiry = exp, true
+		}
+	}
+	if !confirmed {
+		return nil, fmt.Errorf("%w: subject confirmation failed", ErrSAMLInvalid)
+	}
+
+	out := &SAMLAssertion{
+		ID:           a.ID,
+		Issuer:       strings.TrimSpace(a.Issuer),
+		NameID:       strings.TrimSpace(a.Subject.NameID.Value),
+		NameIDFormat: a.Subject.NameID.Format,
+		NotOnOrAfter: notOnOrAfter,
+		Attributes:   map[string][]string{},
+	}
+	for _, attr := range a.Attributes {
+		vals := make([]string, 0, len(attr.Values))
+		for _, v := range attr.Values {
+			vals = append(vals, strings.TrimSpace(v))
+		}
+		out.Attributes[attr.Name] = append(out.Attributes[attr.Name], vals...)
+		if attr.FriendlyName != "" && attr.FriendlyName != attr.Name {
+			out.Attributes[attr.FriendlyName] = append(out.Attributes[attr.FriendlyName], vals...)
+		}
+	}
+	return out, nil
+}
+
+func parseSAMLTime(v string) (time.Time, bool) {
+	if v == "" {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339Nano, v)
+	return t, err == nil
+}
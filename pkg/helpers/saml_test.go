@@ -0,0 +1,172 @@
+package helpers
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/beevik/etree"
+	dsig "github.com/russellhaering/goxmldsig"
+)
+
+const (
+	testSAMLEntity = "https://sp.example.com/saml/metadata"
+	testSAMLACS    = "https://sp.example.com/saml/acs"
+	testSAMLIssuer = "https://idp.example.com"
+)
+
+type samlFixture struct {
+	p      *SAMLProvider
+	signer *dsig.SigningContext
+}
+
+func newSAMLFixture(t *testing.T) *samlFixture {
+	t.Helper()
+	ks := dsig.RandomKeyStoreForTest()
+	_, der, err := ks.GetKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := NewSAMLProvider(SAMLConfig{EntityID: testSAMLEntity, ACSURL: testSAMLACS})
+	p.idp = &samlIDP{EntityID: testSAMLIssuer, SSOURL: "https://idp.example.com/sso", Certs: []*x509.Certificate{cert}}
+	signer := dsig.NewDefaultSigningContext(ks)
+	// IdPs sign with exclusive C14N so assertions keep verifying once embedded in a response
+	signer.Canonicalizer = dsig.MakeC14N10ExclusiveCanonicalizerWithPrefixList("")
+	return &samlFixture{p: p, signer: signer}
+}
+
+func testAssertion(id, nameID string) string {
+	now := time.Now().UTC()
+	exp := now.Add(5 * time.Minute).Format(time.RFC3339)
+	idAttr := ""
+	if id != "" {
+		idAttr = fmt.Sprintf(` ID="%s"`, id)
+	}
+	return fmt.Sprintf(`<saml:Assertion xmlns:saml="%s"%s Version="2.0" IssueInstant="%s">`+
+		`<saml:Issuer>%s</saml:Issuer>`+
+		`<saml:Subject><saml:NameID Format="%s">%s</saml:NameID>`+
+		`<saml:SubjectConfirmation Method="%s"><saml:SubjectConfirmationData Recipient="%s" NotOnOrAfter="%s"/></saml:SubjectConfirmation></saml:Subject>`+
+		`<saml:Conditions NotBefore="%s" NotOnOrAfter="%s"><saml:AudienceRestriction><saml:Audience>%s</saml:Audience></saml:AudienceRestriction></saml:Conditions>`+
+		`</saml:Assertion>`,
+		nsSAMLAssertion, idAttr, now.Format(time.RFC3339), testSAMLIssuer, samlNameIDEmail, nameID,
+		samlBearer, testSAMLACS, exp, now.Add(-time.Minute).Format(time.RFC3339), exp, testSAMLEntity)
+}
+
+func testResponse(assertions ...string) string {
+	return fmt.Sprintf(`<samlp:Response xmlns:samlp="%s" ID="_resp" Version="2.0" Destination="%s">`+
+		`<samlp:Status><samlp:StatusCode Value="%s"/></samlp:Status>%s</samlp:Response>`,
+		nsSAMLProtocol, testSAMLACS, samlStatusSuccess, strings.Join(assertions, ""))
+}
+
+// sign returns doc with an enveloped signature over its root element
+func (f *samlFixture) sign(t *testing.T, doc string) string {
+	t.Helper()
+	d := etree.NewDocument()
+	if err := d.ReadFromString(doc); err != nil {
+		t.Fatal(err)
+	}
+	signed, err := f.signer.SignEnveloped(d.Root())
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.SetRoot(signed)
+	out, err := d.WriteToString()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func (f *samlFixture) parse(resp string) (*SAMLAssertion, error) {
+	return f.p.ParseResponse(context.Background(), base64.StdEncoding.EncodeToString([]byte(resp)), nil)
+}
+
+func TestSAMLParseResponseSignedAssertion(t *testing.T) {
+	f := newSAMLFixture(t)
+	a, err := f.parse(testResponse(f.sign(t, testAssertion("_a1", "user@example.com"))))
+	if err != nil {
+		t.Fatalf("ParseResponse: %v", err)
+	}
+	if a.NameID != "user@example.com" || a.ID != "_a1" {
+		t.Fatalf("got NameID %q ID %q", a.NameID, a.ID)
+	}
+}
+
+func TestSAMLParseResponseSignedResponse(t *testing.T) {
+	f := newSAMLFixture(t)
+	a, err := f.parse(f.sign(t, testResponse(testAssertion("_a1", "user@example.com"))))
+	if err != nil {
+		t.Fatalf("ParseResponse: %v", err)
+	}
+	if a.NameID != "user@example.com" {
+		t.Fatalf("got NameID %q", a.NameID)
+	}
+}
+
+func TestSAMLParseResponseRejectsWrappedAssertion(t *testing.T) {
+	f := newSAMLFixture(t)
+	genuine := f.sign(t, testAssertion("_a1", "user@example.com"))
+	// The forged assertion reuses the ID and hides the genuine signed one inside its subject
+	forged := strings.Replace(testAssertion("_a1", "admin@example.com"), "</saml:Subject>", genuine+"</saml:Subject>", 1)
+	if _, err := f.parse(testResponse(forged)); err == nil {
+		t.Fatal("wrapped assertion was accepted")
+	}
+	// ... or puts the genuine one next to it
+	if _, err := f.parse(testResponse(testAssertion("_a2", "admin@example.com"), genuine)); err == nil {
+		t.Fatal("second assertion was accepted")
+	}
+}
+
+func TestSAMLParseResponseCommentInNameID(t *testing.T) {
+	f := newSAMLFixture(t)
+	signed := f.sign(t, testAssertion("_a1", "user@example.com.evil.test"))
+	// Exclusive C14N drops comments, so the signature still verifies; the full text must be read
+	injected := strings.Replace(signed, "user@example.com.evil.test", "user@example.com<!---->.evil.test", 1)
+	a, err := f.parse(testResponse(injected))
+	if err != nil {
+		return
+	}
+	if a.NameID != "user@example.com.evil.test" {
+		t.Fatalf("comment truncated NameID to %q", a.NameID)
+	}
+}
+
+func TestSAMLParseResponseRejectsUnsignedAssertionInSignedResponse(t *testing.T) {
+	f := newSAMLFixture(t)
+	signed := f.sign(t, testResponse(testAssertion("_a1", "user@example.com")))
+	// Swap the covered assertion for a forged one: the response signature no longer matches
+	forged := strings.Replace(signed, "user@example.com", "admin@example.com", 1)
+	if _, err := f.parse(forged); !errors.Is(err, ErrSAMLSignature) {
+		t.Fatalf("got %v, want ErrSAMLSignature", err)
+	}
+	if _, err := f.parse(testResponse(testAssertion("_a1", "user@example.com"))); err == nil {
+		t.Fatal("unsigned response was accepted")
+	}
+}
+
+func TestSAMLParseResponseRejectsWrongReference(t *testing.T) {
+	f := newSAMLFixture(t)
+	// A genuine signature over another assertion, moved into the forged one
+	other := f.sign(t, testAssertion("_other", "user@example.com"))
+	sig := other[strings.Index(other, "<ds:Signature"):strings.Index(other, "</saml:Assertion>")]
+	forged := strings.Replace(testAssertion("_a1", "admin@example.com"), "</saml:Assertion>", sig+"</saml:Assertion>", 1)
+	if _, err := f.parse(testResponse(forged)); err == nil {
+		t.Fatal("signature referencing another element was accepted")
+	}
+}
+
+func TestSAMLParseResponseRejectsAssertionWithoutID(t *testing.T) {
+	f := newSAMLFixture(t)
+	if _, err := f.parse(f.sign(t, testResponse(testAssertion("", "user@example.com")))); !errors.Is(err, ErrSAMLInvalid) {
+		t.Fatalf("got %v, want ErrSAMLInvalid", err)
+	}
+}
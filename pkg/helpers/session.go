@@ -0,0 +1,80 @@
+package helpers
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/config"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/keyspace"
+)
+
+// KeySession is the Redis hash holding the user's active session (sid); access and refresh
+// tokens are only accepted while their sid matches it. Prefixed with the local region.
+func KeySession(uid string) string {
+	return KeySessionIn(keyspace.Region(), uid)
+}
+
+// KeySessionIn is KeySession in the keyspace of region (session replication)
+func KeySessionIn(region, uid string) string {
+	return keyspace.InRegion(region, "user:session:"+uid)
+}
+
+// KeyAccountBlocked marks a suspended or banned user ("suspended"/"banned") so the session store
+// rejects their tokens even if a session survives the revocation. Prefixed with the local region.
+func KeyAccountBlocked(uid string) string {
+	return KeyAccountBlockedIn(keyspace.Region(), uid)
+}
+
+// KeyAccountBlockedIn is KeyAccountBlocked in the keyspace of region
+func KeyAccountBlockedIn(region, uid string) string {
+	return keyspace.InRegion(region, "user:blocked:"+uid)
+}
+
+// ForgetTrustedDevices drops the user's pending login OTP and all trusted devices, so the next
+// login goes through the OTP step again.
+func ForgetTrustedDevices(ctx context.Context, rdb *redis.Client, uid string) error {
+	if err := rdb.Del(ctx, KeyLoginOTP(uid), KeyLoginPasswordOK(uid)).Err(); err != nil {
+		return err
+	}
+	iter := rdb.Scan(ctx, 0, KeyTrustedDevice(uid, "*"), 200).Iterator()
+	var keys []string
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	return rdb.Del(ctx, keys...).Err()
+}
+
+// SessionPolicy controls session lifetime. With Sliding off a session lives TTL from login (or the
+// last refresh); with Sliding on every authenticated request pushes expiry to now+TTL, but never
+// past MaxLifetime after login.
+type SessionPolicy struct {
+	TTL         time.Duration
+	Sliding     bool
+	MaxLifetime time.Duration
+}
+
+func NewSessionPolicy(cfg *config.Config) SessionPolicy {
+	return SessionPolicy{TTL: cfg.SessionTTL, Sliding: cfg.SessionSliding, MaxLifetime: cfg.SessionMaxLifetime}
+}
+
+// NextTTL returns the TTL to set on activity for a session created at createdAt; ok is false once
+// the absolute lifetime is used up. A zero createdAt (unknown) is not capped.
+func (p SessionPolicy) NextTTL(createdAt, now time.Time) (ttl time.Duration, ok bool) {
+	ttl = p.TTL
+	if p.MaxLifetime <= 0 || createdAt.IsZero() {
+		return ttl, true
+	}
+	remaining := createdAt.Add(p.MaxLifetime).Sub(now)
+	if remaining <= 0 {
+		return 0, false
+	}
+	return min(ttl, remaining), true
+}
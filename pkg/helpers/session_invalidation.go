@@ -0,0 +1,91 @@
+package helpers
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/keyspace"
+)
+
+// ChannelSessionInvalidate is the Redis pub/sub channel announcing revoked sessions to every replica
+func ChannelSessionInvalidate() string { return keyspace.Key("session:invalidate") }
+
+// SessionInvalidation names a revoked session; an empty SessionID means all of the user's sessions
+type SessionInvalidation struct {
+	UserID    string `json:"user_id"`
+	SessionID string `json:"session_id,omitempty"`
+}
+
+// SessionInvalidationHandler purges per-process state for a revoked session (local caches, open streams)
+type SessionInvalidationHandler func(ctx context.Context, inv SessionInvalidation)
+
+// SessionInvalidations fans session revocations out over Redis pub/sub. Every replica runs Run, so
+// handlers registered with OnInvalidate fire on all of them, including the one that revoked.
+type SessionInvalidations struct {
+	rdb      *redis.Client
+	logger   *logrus.Logger
+	mu       sync.RWMutex
+	handlers []SessionInvalidationHandler
+}
+
+func NewSessionInvalidations(rdb *redis.Client, logger *logrus.Logger) *SessionInvalidations {
+	return &SessionInvalidations{rdb: rdb, logger: logger}
+}
+
+// OnInvalidate registers h; handlers run sequentially on the subscriber goroutine and should be quick
+func (s *SessionInvalidations) OnInvalidate(h SessionInvalidationHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers = append(s.handlers, h)
+}
+
+// Publish announces inv to all replicas; it is a no-op on a nil receiver
+func (s *SessionInvalidations) Publish(ctx context.Context, inv SessionInvalidation) error {
+	if s == nil || s.rdb == nil {
+		return nil
+	}
+	b, err := json.Marshal(inv)
+	if err != nil {
+		return err
+	}
+	return s.rdb.Publish(ctx, ChannelSessionInvalidate(), b).Err()
+}
+
+// Run dispatches invalidations until ctx is done. go-redis resubscribes after connection loss;
+// messages published while disconnected are missed, so handlers must only purge caches, never hold truth.
+func (s *SessionInvalidations) Run(ctx context.Context) {
+	sub := s.rdb.Subscribe(ctx, ChannelSessionInvalidate())
+	defer func() { _ = sub.Close() }()
+	ch := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			var inv SessionInvalidation
+			if err := json.Unmarshal([]byte(msg.Payload), &inv); err != nil || inv.UserID == "" {
+				if s.logger != nil {
+					s.logger.WithField("payload", msg.Payload).Warn("ignoring malformed session invalidation")
+				}
+				continue
+			}
+			s.dispatch(ctx, inv)
+		}
+	}
+}
+
+func (s *SessionInvalidations) dispatch(ctx context.Context, inv SessionInvalidation) {
+	s.mu.RLock()
+	hs := s.handlers
+	s.mu.RUnlock()
+	for _, h := range hs {
+		h(ctx, inv)
+	}
+}
@@ -0,0 +1,149 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Shutdown phases: components close in ascending phase, and in registration order within a phase.
+// A phase only starts once everything before it has closed (or timed out), so producers stop
+// before the queues they write to, and stores close last.
+const (
+	ShutdownHTTP       = 10 // the HTTP server: stop accepting, finish in-flight requests
+	ShutdownBackground = 20 // background jobs (exports, digests, index sync, metrics workers)
+	ShutdownFlush      = 25 // buffered writers, after the jobs and requests that feed them (audit writer)
+	ShutdownEvents     = 30 // the in-process event bus, after everything that publishes to it
+	ShutdownConsumers  = 40 // the embedded email worker
+	ShutdownClients    = 50 // outbound clients and pollers (RabbitMQ, GCS, probes, refreshers)
+	ShutdownStores     = 60 // Redis and Postgres
+)
+
+var ErrCloseTimeout = errors.New("did not close in time")
+
+type shutdownEntry struct {
+	name     string
+	phase    int
+	timeout  time.Duration
+	close    func(context.Context) error
+	sequence int
+}
+
+// ShutdownRegistry closes the process's components in phase order on shutdown. Each component
+// gets its own timeout (Timeout unless it registered one); one that overruns is logged and left
+// behind so the rest still close. Run executes once; later calls are no-ops, so it is safe to
+// both defer it and call it at the end of main.
+type ShutdownRegistry struct {
+	Logger  *logrus.Logger
+	Timeout time.Duration
+
+	mu      sync.Mutex
+	entries []shutdownEntry
+	ran     bool
+}
+
+func NewShutdownRegistry(logger *logrus.Logger, timeout time.Duration) *ShutdownRegistry {
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return &ShutdownRegistry{Logger: logger, Timeout: timeout}
+}
+
+// Register adds a component closed in phase with the default timeout
+func (s *ShutdownRegistry) Register(name string, phase int, fn func(context.Context) error) {
+	s.RegisterTimeout(name, phase, 0, fn)
+}
+
+// RegisterTimeout adds a component with its own timeout (0 = the default)
+func (s *ShutdownRegistry) RegisterTimeout(name string, phase int, timeout time.Duration, fn func(context.Context) error) {
+	if s == nil || fn == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, shutdownEntry{name: name, phase: phase, timeout: timeout, close: fn, sequence: len(s.entries)})
+}
+
+// CloseFunc adapts a Close() without context or error
+func CloseFunc(fn func()) func(context.Context) error {
+	return func(context.Context) error { fn(); return nil }
+}
+
+// CloseErr adapts a Close() error
+func CloseErr(fn func() error) func(context.Context) error {
+	return func(context.Context) error { return fn() }
+}
+
+// CloseCtx adapts a Close(ctx) that waits until ctx is done
+func CloseCtx(fn func(context.Context)) func(context.Context) error {
+	return func(ctx context.Context) error { fn(ctx); return nil }
+}
+
+// Run closes every registered component and returns the failures joined (nil when all closed).
+// Cancelling ctx cuts every remaining timeout short.
+func (s *ShutdownRegistry) Run(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	if s.ran {
+		s.mu.Unlock()
+		return nil
+	}
+	s.ran = true
+	entries := append([]shutdownEntry(nil), s.entries...)
+	s.mu.Unlock()
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].phase != entries[j].phase {
+			return entries[i].phase < entries[j].phase
+		}
+		return entries[i].sequence < entries[j].sequence
+	})
+	var errs []error
+	for _, e := range entries {
+		start := time.Now()
+		err := s.closeOne(ctx, e)
+		fields := logrus.Fields{"component": e.name, "phase": e.phase, "duration_ms": time.Since(start).Milliseconds()}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", e.name, err))
+			if s.Logger != nil {
+				s.Logger.WithError(err).WithFields(fields).Warn("shutdown: component failed to close")
+			}
+			continue
+		}
+		if s.Logger != nil {
+			s.Logger.WithFields(fields).Info("shutdown: component closed")
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *ShutdownRegistry) closeOne(ctx context.Context, e shutdownEntry) error {
+	timeout := e.timeout
+	if timeout <= 0 {
+		timeout = s.Timeout
+	}
+	cctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("panic: %v", r)
+			}
+		}()
+		done <- e.close(cctx)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-cctx.Done():
+		return ErrCloseTimeout
+	}
+}
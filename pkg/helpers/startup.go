@@ -0,0 +1,36 @@
+package helpers
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// StartupReport is what the process started with: build, mounted modules and routes, the versions
+// of the servers it connected to and the effective configuration (secrets masked). It is logged
+// once at startup and served at GET /api/admin/config.
+type StartupReport struct {
+	App          string            `json:"app"`
+	Env          string            `json:"env"`
+	StartedAt    time.Time         `json:"started_at"`
+	Build        BuildInfo         `json:"build"`
+	Modules      []string          `json:"modules"`
+	Subsystems   map[string]bool   `json:"subsystems"`                // MODULES_DISABLED ones are false
+	Skipped      []string          `json:"skipped_modules,omitempty"` // modules of disabled subsystems
+	Routes       int               `json:"routes"`
+	Dependencies map[string]string `json:"dependencies"` // server versions: postgres, redis, rabbitmq
+	Config       map[string]any    `json:"config"`
+}
+
+// Log writes the report as one structured entry
+func (s *StartupReport) Log(logger *logrus.Logger) {
+	if s == nil || logger == nil {
+		return
+	}
+	logger.WithFields(logrus.Fields{
+		"app": s.App, "env": s.Env, "version": s.Build.Version, "go_version": s.Build.GoVersion,
+		"revision": s.Build.Revision, "libraries": s.Build.Modules, "modules": s.Modules,
+		"subsystems": s.Subsystems, "skipped_modules": s.Skipped,
+		"routes": s.Routes, "dependencies": s.Dependencies, "config": s.Config,
+	}).Info("startup")
+}
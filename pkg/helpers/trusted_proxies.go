@@ -0,0 +1,214 @@
+package helpers
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Trusted proxy presets usable in TRUSTED_PROXIES
+const (
+	ProxyPresetCloudflare = "cloudflare"
+	ProxyPresetPrivate    = "private"
+)
+
+var cloudflareIPURLs = []string{"https://www.cloudflare.com/ips-v4", "https://www.cloudflare.com/ips-v6"}
+
+// CloudflareRanges is the built-in copy of Cloudflare's published ranges, used until (or if) a fetch succeeds
+var CloudflareRanges = []string{
+	// IPv4
+	"173.245.48.0/20",
+	"103.21.244.0/22",
+	"103.22.200.0/22",
+	"103.31.4.0/22",
+	"141.101.64.0/18",
+	"108.162.192.0/18",
+	"190.93.240.0/20",
+	"188.114.96.0/20",
+	"197.234.240.0/22",
+	"198.41.128.0/17",
+	"162.158.0.0/15",
+	"104.16.0.0/13",
+	"104.24.0.0/14",
+	"172.64.0.0/13",
+	"131.0.72.0/22",
+	// IPv6
+	"2400:cb00::/32",
+	"2606:4700::/32",
+	"2803:f800::/32",
+	"2405:b500::/32",
+	"2405:8100::/32",
+	"2a06:98c0::/29",
+	"2c0f:f248::/32",
+}
+
+// PrivateRanges covers loopback and private networks (ingress controllers, sidecars, internal load balancers)
+var PrivateRanges = []string{"127.0.0.0/8", "10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "::1/128", "fc00::/7"}
+
+// TrustedProxies is the set of proxy CIDRs whose forwarding headers are believed.
+// The set is swapped atomically so the Cloudflare refresh can run while requests are served.
+type TrustedProxies struct {
+	static     []string
+	cloudflare bool
+	nets       atomic.Pointer[[]*net.IPNet]
+	cfNets     atomic.Pointer[[]*net.IPNet]
+	cidrs      atomic.Pointer[[]string]
+}
+
+// NewTrustedProxies parses a TRUSTED_PROXIES list of CIDRs, bare IPs and presets (cloudflare, private).
+// The cloudflare preset starts from CloudflareRanges; call RefreshCloudflare to fetch the current list.
+func NewTrustedProxies(list []string) (*TrustedProxies, error) {
+	t := &TrustedProxies{}
+	for _, item := range list {
+		switch strings.ToLower(item) {
+		case ProxyPresetCloudflare:
+			t.cloudflare = true
+		case ProxyPresetPrivate:
+			t.static = append(t.static, PrivateRanges...)
+		default:
+			t.static = append(t.static, item)
+		}
+	}
+	var cf []string
+	if t.cloudflare {
+		cf = CloudflareRanges
+	}
+	if err := t.set(cf); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *TrustedProxies) set(cloudflare []string) error {
+	cidrs := append(append([]string{}, t.static...), cloudflare...)
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for i, c := range cidrs {
+		if !strings.Contains(c, "/") {
+			if ip := net.ParseIP(c); ip != nil && ip.To4() != nil {
+				c += "/32"
+			} else {
+				c += "/128"
+			}
+			cidrs[i] = c
+		}
+		_, n, err := net.ParseCIDR(c)
+		if err != nil {
+			return fmt.Errorf("trusted proxy %q: %w", c, err)
+		}
+		nets = append(nets, n)
+	}
+	cfNets := nets[len(t.static):]
+	t.nets.Store(&nets)
+	t.cfNets.Store(&cfNets)
+	t.cidrs.Store(&cidrs)
+	return nil
+}
+
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// CIDRs returns the current list (for gin's SetTrustedProxies)
+func (t *TrustedProxies) CIDRs() []string {
+	if t == nil {
+		return nil
+	}
+	return *t.cidrs.Load()
+}
+
+// Contains reports whether ip is a trusted proxy
+func (t *TrustedProxies) Contains(ip net.IP) bool {
+	if t == nil || ip == nil {
+		return false
+	}
+	return containsIP(*t.nets.Load(), ip)
+}
+
+// IsCloudflare reports whether ip belongs to the cloudflare preset (only then is CF-Connecting-IP meaningful)
+func (t *TrustedProxies) IsCloudflare(ip net.IP) bool {
+	if t == nil || ip == nil {
+		return false
+	}
+	return containsIP(*t.cfNets.Load(), ip)
+}
+
+// FetchCloudflareRanges downloads Cloudflare's published IPv4 and IPv6 ranges
+func FetchCloudflareRanges(ctx context.Context, client *http.Client) ([]string, error) {
+	var out []string
+	for _, u := range cloudflareIPURLs {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			_ = resp.Body.Close()
+			return nil, fmt.Errorf("cloudflare ranges: GET %s: %s", u, resp.Status)
+		}
+		sc := bufio.NewScanner(io.LimitReader(resp.Body, 1<<16))
+		for sc.Scan() {
+			line := strings.TrimSpace(sc.Text())
+			if _, _, err := net.ParseCIDR(line); err == nil {
+				out = append(out, line)
+			}
+		}
+		_ = resp.Body.Close()
+		if err := sc.Err(); err != nil {
+			return nil, err
+		}
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("cloudflare ranges: empty response")
+	}
+	return out, nil
+}
+
+// RefreshCloudflare fetches the Cloudflare ranges now and then every interval until ctx is cancelled.
+// Failures keep the previous list. It returns immediately when the cloudflare preset is not used.
+func (t *TrustedProxies) RefreshCloudflare(ctx context.Context, interval time.Duration, logger *logrus.Logger) {
+	if t == nil || !t.cloudflare {
+		return
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	refresh := func() {
+		ranges, err := FetchCloudflareRanges(ctx, client)
+		if err == nil {
+			err = t.set(ranges)
+		}
+		if err != nil {
+			logger.WithError(err).Warn("cloudflare ip ranges refresh failed; keeping current list")
+			return
+		}
+		logger.Infof("trusted proxies: loaded %d cloudflare ranges", len(ranges))
+	}
+	refresh()
+	if interval <= 0 {
+		return
+	}
+	tk := time.NewTicker(interval)
+	defer tk.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tk.C:
+			refresh()
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package helpers
+
+import "strings"
+
+// uaFamilies maps User-Agent markers to browser families, checked in order: Edge and Opera
+// also claim Chrome, Chrome also claims Safari
+var uaFamilies = []struct{ marker, family string }{
+	{"edg/", "edge"}, {"edga/", "edge"}, {"edgios/", "edge"},
+	{"opr/", "opera"}, {"opera", "opera"},
+	{"samsungbrowser/", "samsung"},
+	{"firefox/", "firefox"}, {"fxios/", "firefox"},
+	{"crios/", "chrome"}, {"chrome/", "chrome"}, {"chromium/", "chrome"},
+	{"safari/", "safari"},
+}
+
+// UAFamily is the client family of a User-Agent: the browser ("chrome", "firefox", ...) or, for
+// other clients, the first product name ("okhttp", "curl", "myapp"). Versions are dropped, so it
+// stays the same across updates. Empty for an empty User-Agent.
+func UAFamily(ua string) string {
+	ua = strings.ToLower(strings.TrimSpace(ua))
+	if ua == "" {
+		return ""
+	}
+	for _, f := range uaFamilies {
+		if strings.Contains(ua, f.marker) {
+			return f.family
+		}
+	}
+	product, _, _ := strings.Cut(ua, " ")
+	product, _, _ = strings.Cut(product, "/")
+	return product
+}
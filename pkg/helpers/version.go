@@ -0,0 +1,76 @@
+package helpers
+
+import (
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+// Version is set at build time: go build -ldflags "-X github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers.Version=v1.2.3"
+var Version = ""
+
+// BuildVersion returns Version, else the VCS revision stamped by go build, else "dev".
+func BuildVersion() string {
+	if Version != "" {
+		return Version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range info.Settings {
+			if s.Key == "vcs.revision" && len(s.Value) >= 12 {
+				return s.Value[:12]
+			}
+		}
+	}
+	return "dev"
+}
+
+// keyModules are the libraries whose versions startup reports list (module path prefixes)
+var keyModules = []string{
+	"github.com/gin-gonic/gin",
+	"github.com/jackc/pgx/",
+	"github.com/redis/go-redis/",
+	"github.com/rabbitmq/amqp091-go",
+	"github.com/golang-migrate/migrate/",
+	"github.com/elastic/go-elasticsearch/",
+	"github.com/mailgun/mailgun-go/",
+	"github.com/sirupsen/logrus",
+	"github.com/golang-jwt/jwt/",
+	"cloud.google.com/go/storage",
+}
+
+// BuildInfo describes the running binary
+type BuildInfo struct {
+	Version   string            `json:"version"`
+	GoVersion string            `json:"go_version"`
+	Revision  string            `json:"revision,omitempty"`
+	Modified  bool              `json:"modified,omitempty"` // built from a dirty tree
+	Modules   map[string]string `json:"modules"`            // versions of the key libraries
+}
+
+// ReadBuildInfo reports the binary's version, toolchain, VCS revision and key library versions
+func ReadBuildInfo() BuildInfo {
+	b := BuildInfo{Version: BuildVersion(), GoVersion: runtime.Version(), Modules: map[string]string{}}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return b
+	}
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			b.Revision = s.Value
+		case "vcs.modified":
+			b.Modified = s.Value == "true"
+		}
+	}
+	for _, d := range info.Deps {
+		if d.Replace != nil {
+			d = d.Replace
+		}
+		for _, prefix := range keyModules {
+			if strings.HasPrefix(d.Path, prefix) {
+				b.Modules[d.Path] = d.Version
+			}
+		}
+	}
+	return b
+}
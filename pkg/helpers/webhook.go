@@ -0,0 +1,33 @@
+package helpers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// WebhookMessageType is the AMQP type of WebhookJob messages on the webhooks queue
+const WebhookMessageType = "webhook"
+
+// WebhookJob is a webhooks queue message: the worker POSTs Payload to URL as JSON. ID is sent as
+// X-Webhook-Id so receivers can drop the duplicates at-least-once delivery brings.
+type WebhookJob struct {
+	ID        string          `json:"id"`
+	URL       string          `json:"url"`
+	Event     string          `json:"event"`
+	Payload   json.RawMessage `json:"payload"`
+	RequestID string          `json:"request_id,omitempty"`
+}
+
+// SignWebhook is the "t=<unix>,v1=<hex>" signature of body sent at t, where v1 is HMAC-SHA256 of
+// "<t>.<body>" with secret; receivers should reject stale t
+func SignWebhook(secret string, t time.Time, body []byte) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	m := hmac.New(sha256.New, []byte(secret))
+	m.Write([]byte(ts + "."))
+	m.Write(body)
+	return "t=" + ts + ",v1=" + hex.EncodeToString(m.Sum(nil))
+}
@@ -0,0 +1,149 @@
+// Package httpx lets handlers be written against a framework-neutral Request and Response instead
+// of *gin.Context. A Handler reads its input from the Request and returns the Response to send;
+// adapters mount it on Gin (Gin) or on anything speaking net/http (Std: chi, http.ServeMux), and
+// unit tests call it directly:
+//
+//	res := h.Get(httpx.NewRequest(httptest.NewRequest(http.MethodGet, "/", nil), map[string]string{"id": "abc"}))
+//	if res.Status != http.StatusNotFound { ... }
+//
+// Responses are written in the standard envelope of pkg/response either way.
+package httpx
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/response"
+)
+
+// Handler serves one request
+type Handler func(r *Request) Response
+
+// Request is the incoming request with its path params and the values set by middleware
+// (userID, sessionID, scopes, orgID, ...)
+type Request struct {
+	*http.Request
+	Route string // matched route template, e.g. /api/admin/correlations/:id ("" when unknown)
+
+	param func(name string) string
+	value func(key string) any
+}
+
+type valueKey string
+
+// NewRequest wraps r for calling a Handler without a router; values come from WithValue
+func NewRequest(r *http.Request, params map[string]string) *Request {
+	return &Request{Request: r, param: func(name string) string { return params[name] }, value: contextValue(r)}
+}
+
+// WithValue returns r carrying a middleware value under key, for net/http middleware and tests
+func WithValue(r *http.Request, key string, v any) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), valueKey(key), v))
+}
+
+func contextValue(r *http.Request) func(string) any {
+	return func(key string) any { return r.Context().Value(valueKey(key)) }
+}
+
+// Param returns the path param name, or ""
+func (r *Request) Param(name string) string { return r.param(name) }
+
+// Query returns the first query value of name, or ""
+func (r *Request) Query(name string) string { return r.URL.Query().Get(name) }
+
+// Value returns the middleware value stored under key, or nil
+func (r *Request) Value(key string) any { return r.value(key) }
+
+// String returns the middleware value under key when it is a string
+func (r *Request) String(key string) string {
+	s, _ := r.value(key).(string)
+	return s
+}
+
+// UserID is the authenticated user (set by the auth guard)
+func (r *Request) UserID() string { return r.String("userID") }
+
+// Decode reads a JSON body into v and validates it with the binding rules; errors suit validation.Details
+func (r *Request) Decode(v any) error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return err
+	}
+	return binding.Validator.ValidateStruct(v)
+}
+
+// Response is what a Handler answers: Data on success, or an error body when Err is set
+type Response struct {
+	Status int
+	Data   any
+	Err    *response.ErrorBody
+	Header http.Header
+}
+
+// OK answers 200 with data
+func OK(data any) Response { return Response{Status: http.StatusOK, Data: data} }
+
+// JSON answers status with data
+func JSON(status int, data any) Response { return Response{Status: status, Data: data} }
+
+// Error answers status with an error message and optional details
+func Error(status int, message string, details any) Response {
+	return Response{Status: status, Err: &response.ErrorBody{Message: message, Details: details}}
+}
+
+// WithHeader returns the response with a header added
+func (r Response) WithHeader(key, value string) Response {
+	h := r.Header.Clone()
+	if h == nil {
+		h = http.Header{}
+	}
+	h.Add(key, value)
+	r.Header = h
+	return r
+}
+
+// Gin mounts h on Gin: path params, the route template and context values come from c
+func Gin(h Handler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		res := h(&Request{Request: c.Request, Route: c.FullPath(), param: c.Param, value: func(key string) any {
+			v, _ := c.Get(key)
+			return v
+		}})
+		for k, vs := range res.Header {
+			for _, v := range vs {
+				c.Writer.Header().Add(k, v)
+			}
+		}
+		if res.Err != nil {
+			response.ErrorCode[any](c, res.Status, res.Err.Code, res.Err.Message, res.Err.Details)
+			return
+		}
+		response.Success[any](c, res.Status, res.Data, "", nil)
+	}
+}
+
+// ParamFunc reads a path param from a net/http request; chi.URLParam and
+// (*http.Request).PathValue both fit
+type ParamFunc func(r *http.Request, name string) string
+
+// Std mounts h on a net/http router. Path params are read through param (nil = none); context
+// values are those set with WithValue.
+func Std(h Handler, param ParamFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := NewRequest(r, nil)
+		req.Route = r.Pattern
+		if param != nil {
+			req.param = func(name string) string { return param(r, name) }
+		}
+		res := h(req)
+		for k, vs := range res.Header {
+			for _, v := range vs {
+				w.Header().Add(k, v)
+			}
+		}
+		response.Write[any](w, r, res.Status, res.Data, res.Err)
+	})
+}
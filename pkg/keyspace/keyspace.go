@@ -0,0 +1,50 @@
+// Package keyspace builds every Redis key and channel name the app uses, so instances of several
+// environments (or apps) can share one Redis without colliding. Keys are
+//
+//	<prefix><key>           e.g. app:staging:login:otp:42
+//	<prefix><region>:<key>  e.g. app:staging:eu-west:user:session:42 (regional keys)
+//
+// where prefix is REDIS_KEY_PREFIX and region is REGION. Both are set once at startup, before any
+// key is built; key functions are evaluated when called, never stored in package-level values.
+package keyspace
+
+import "sync/atomic"
+
+var (
+	prefix atomic.Value // string
+	region atomic.Value // string
+)
+
+// SetPrefix sets the prefix of every key (REDIS_KEY_PREFIX, e.g. "app:prod:"); "" keeps keys bare
+func SetPrefix(p string) { prefix.Store(p) }
+
+// Prefix returns the configured key prefix
+func Prefix() string {
+	p, _ := prefix.Load().(string)
+	return p
+}
+
+// SetRegion sets the local region (REGION). Session and cache keys are prefixed with it so
+// active-active deployments sharing a Redis keyspace do not overwrite each other.
+func SetRegion(r string) { region.Store(r) }
+
+// Region returns the local region, "" for a single-region deployment
+func Region() string {
+	r, _ := region.Load().(string)
+	return r
+}
+
+// Key returns k in the app's keyspace
+func Key(k string) string { return Prefix() + k }
+
+// InRegion returns k in the keyspace of region ("app:prod:eu-west:user:session:42"); an empty
+// region leaves it unregioned
+func InRegion(region, k string) string {
+	if region == "" {
+		return Key(k)
+	}
+	return Key(region + ":" + k)
+}
+
+// Regional returns k in the keyspace of the local region (session and cache keys)
+func Regional(k string) string { return InRegion(Region(), k) }
@@ -0,0 +1,114 @@
+package mailer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CapturedEmail is an email recorded by the log or file driver instead of being sent.
+type CapturedEmail struct {
+	ID        string    `json:"id"`
+	To        string    `json:"to"`
+	Subject   string    `json:"subject"`
+	Text      string    `json:"text,omitempty"`
+	HTML      string    `json:"html,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	Envelope
+}
+
+func newCapturedEmail(to, subject, text, html string, env Envelope) CapturedEmail {
+	return CapturedEmail{
+		ID:        uuid.NewString(),
+		To:        to,
+		Envelope:  env,
+		Subject:   subject,
+		Text:      text,
+		HTML:      html,
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+// LogSender writes emails to the process log.
+type LogSender struct{}
+
+func NewLogSender() *LogSender { return &LogSender{} }
+
+func (s *LogSender) Send(_ context.Context, to, subject, text, html string, env Envelope) error {
+	e := newCapturedEmail(to, subject, text, html, env)
+	log.Printf("mail captured (log driver): id=%s to=%s from=%q cc=%v bcc=%v subject=%q text_len=%d html_len=%d", e.ID, e.To, e.From, e.CC, e.BCC, e.Subject, len(e.Text), len(e.HTML))
+	return nil
+}
+
+// FileSender writes each email as a JSON file into Dir (one file per email).
+type FileSender struct {
+	Dir string
+}
+
+func NewFileSender(dir string) (*FileSender, error) {
+	if strings.TrimSpace(dir) == "" {
+		return nil, fmt.Errorf("mail capture dir is empty")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create mail capture dir: %w", err)
+	}
+	return &FileSender{Dir: dir}, nil
+}
+
+func (s *FileSender) Send(_ context.Context, to, subject, text, html string, env Envelope) error {
+	e := newCapturedEmail(to, subject, text, html, env)
+	b, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return err
+	}
+	// Prefix with a sortable timestamp so the newest emails list first
+	name := fmt.Sprintf("%s-%s.json", e.CreatedAt.Format("20060102T150405.000000000"), e.ID)
+	return os.WriteFile(filepath.Join(s.Dir, name), b, 0o644)
+}
+
+// ListCaptured returns up to limit captured emails from dir, newest first.
+func ListCaptured(dir string, limit int) ([]CapturedEmail, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []CapturedEmail{}, nil
+		}
+		return nil, err
+	}
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".json") {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(names)))
+	if limit > 0 && len(names) > limit {
+		names = names[:limit]
+	}
+	out := make([]CapturedEmail, 0, len(names))
+	for _, n := range names {
+		b, err := os.ReadFile(filepath.Join(dir, n))
+		if err != nil {
+			continue
+		}
+		var e CapturedEmail
+		if err := json.Unmarshal(b, &e); err != nil {
+			continue
+		}
+		out = append(out, e)
+	}
+	return out, nil
+}
+
+var (
+	_ Sender = (*LogSender)(nil)
+	_ Sender = (*FileSender)(nil)
+)
@@ -1,13 +1,125 @@
 package mailer
 
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+
+	mailtpl "github.com/oksasatya/go-ddd-clean-architecture/pkg/mailer/templates"
+)
+
+// ErrInvalidJob wraps every EmailJob.Validate failure
+var ErrInvalidJob = errors.New("invalid email job")
+
 // EmailJob is the JSON payload put on the RabbitMQ queue for sending email.
 // Html is optional; Text is recommended as fallback.
 // You can also use a template by specifying Template and Data.
 type EmailJob struct {
+	Version  int            `json:"version,omitempty"` // payload shape, see EmailJobVersion
 	To       string         `json:"to"`
 	Subject  string         `json:"subject,omitempty"`
 	Text     string         `json:"text,omitempty"`
 	HTML     string         `json:"html,omitempty"`
-	Template string         `json:"template,omitempty"` // e.g. "login_notification", "verify_email", "forgot_password", "profile_updated"
+	Template string         `json:"template,omitempty"` // "universal" with Data["Type"] (v1 payloads may name the type directly)
 	Data     map[string]any `json:"data,omitempty"`
+	Locale   string         `json:"locale,omitempty"`    // e.g. "en", "id"; selects <template>.subject.<locale>.tmpl
+	DedupKey string         `json:"dedup_key,omitempty"` // jobs sharing a key are sent once (see EMAIL_DEDUP_TTL)
+	Envelope                // optional from override, reply-to, cc, bcc
+	JobMeta                 // producer request, for worker logs and metrics
+}
+
+// JobMeta ties a job back to the API request that enqueued it
+type JobMeta struct {
+	RequestID  string `json:"request_id,omitempty"`
+	UserID     string `json:"user_id,omitempty"`
+	DeliveryID string `json:"delivery_id,omitempty"` // set when the producer polls the job's delivery state
+	// CorrelationID is the auth flow the email belongs to; the worker adds its outcome to the flow's trail
+	CorrelationID string `json:"correlation_id,omitempty"`
+}
+
+// Validate checks what the worker needs to render and send the job: a parseable recipient, a
+// known template (or a raw subject with text/html) and the Data keys its email type requires.
+// It runs before enqueueing, on an upgraded job, so bad jobs are rejected instead of dead-lettered.
+func (j EmailJob) Validate() error {
+	if j.Version != EmailJobVersion {
+		return fmt.Errorf("%w: version %d, want %d (call Upgrade)", ErrInvalidJob, j.Version, EmailJobVersion)
+	}
+	if strings.TrimSpace(j.To) == "" {
+		return fmt.Errorf("%w: to is required", ErrInvalidJob)
+	}
+	if _, err := mail.ParseAddress(j.To); err != nil {
+		return fmt.Errorf("%w: to %q is not a valid address", ErrInvalidJob, j.To)
+	}
+	if err := j.Envelope.validate(); err != nil {
+		return err
+	}
+	if j.Template == "" {
+		if strings.TrimSpace(j.Subject) == "" || (j.Text == "" && j.HTML == "") {
+			return fmt.Errorf("%w: either template or subject with text/html is required", ErrInvalidJob)
+		}
+		return nil
+	}
+
+	if !strings.EqualFold(j.Template, mailtpl.Universal) {
+		return fmt.Errorf("%w: unknown template %q", ErrInvalidJob, j.Template)
+	}
+	if _, err := mailtpl.ValidateData(j.Data); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidJob, err)
+	}
+	return nil
+}
+
+func (e Envelope) validate() error {
+	if err := checkAddress("from", e.From); err != nil {
+		return err
+	}
+	if err := checkAddress("reply_to", e.ReplyTo); err != nil {
+		return err
+	}
+	if len(e.CC)+len(e.BCC) > MaxCopies {
+		return fmt.Errorf("%w: at most %d cc and bcc recipients", ErrInvalidJob, MaxCopies)
+	}
+	for _, addr := range e.CC {
+		if err := checkAddress("cc", addr); err != nil {
+			return err
+		}
+	}
+	for _, addr := range e.BCC {
+		if err := checkAddress("bcc", addr); err != nil {
+			return err
+		}
+	}
+	if len(e.Tags) > MaxTags {
+		return fmt.Errorf("%w: at most %d tags", ErrInvalidJob, MaxTags)
+	}
+	for _, t := range e.Tags {
+		if strings.TrimSpace(t) == "" || len(t) > 128 {
+			return fmt.Errorf("%w: tag %q must be 1-128 characters", ErrInvalidJob, t)
+		}
+	}
+	for k := range e.Variables {
+		if strings.TrimSpace(k) == "" {
+			return fmt.Errorf("%w: variable names must not be empty", ErrInvalidJob)
+		}
+	}
+	return nil
+}
+
+// checkAddress accepts an empty optional address
+func checkAddress(field, addr string) error {
+	if addr == "" {
+		return nil
+	}
+	if _, err := mail.ParseAddress(addr); err != nil {
+		return fmt.Errorf("%w: %s %q is not a valid address", ErrInvalidJob, field, addr)
+	}
+	return nil
+}
+
+func dataString(data map[string]any, key string) string {
+	if v, ok := data[key]; ok && v != nil {
+		return strings.TrimSpace(fmt.Sprint(v))
+	}
+	return ""
 }
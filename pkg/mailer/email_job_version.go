@@ -0,0 +1,73 @@
+package mailer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	mailtpl "github.com/oksasatya/go-ddd-clean-architecture/pkg/mailer/templates"
+)
+
+// EmailJobVersion is the payload shape producers write. Workers upgrade older payloads on decode,
+// so bump it together with a new entry in migrations and producers can deploy after workers.
+//
+//	1: no version field; template may name a legacy email (verify_email, ...) directly
+//	2: template is "universal" and data.Type selects the email
+const EmailJobVersion = 2
+
+var ErrUnsupportedJobVersion = errors.New("email job version not supported by this worker")
+
+// migrations[v] upgrades a version v payload to v+1
+var migrations = map[int]func(*EmailJob){
+	1: migrateLegacyTemplate,
+}
+
+// migrateLegacyTemplate rewrites v1 legacy template names to universal with data.Type
+func migrateLegacyTemplate(j *EmailJob) {
+	if !mailtpl.IsAlias(j.Template) {
+		return
+	}
+	if j.Data == nil {
+		j.Data = map[string]any{}
+	}
+	if dataString(j.Data, "Type") == "" {
+		j.Data["Type"] = strings.ToLower(j.Template)
+	}
+	j.Template = mailtpl.Universal
+}
+
+// Upgrade migrates the job to EmailJobVersion; a missing version means 1.
+func (j *EmailJob) Upgrade() error {
+	if j.Version == 0 {
+		j.Version = 1
+	}
+	if j.Version > EmailJobVersion {
+		return fmt.Errorf("%w: %d (max %d)", ErrUnsupportedJobVersion, j.Version, EmailJobVersion)
+	}
+	for j.Version < EmailJobVersion {
+		if m := migrations[j.Version]; m != nil {
+			m(j)
+		}
+		j.Version++
+	}
+	normalizeType(j)
+	return nil
+}
+
+// normalizeType lowercases data.Type; templates and RequiredData match it case-sensitively,
+// so "Verify_Email" from a producer would otherwise render the generic email.
+func normalizeType(j *EmailJob) {
+	if t := dataString(j.Data, "Type"); t != "" {
+		j.Data["Type"] = strings.ToLower(strings.TrimSpace(t))
+	}
+}
+
+// DecodeEmailJob parses a queued payload and upgrades it to the current version.
+func DecodeEmailJob(b []byte) (EmailJob, error) {
+	var j EmailJob
+	if err := json.Unmarshal(b, &j); err != nil {
+		return j, err
+	}
+	return j, j.Upgrade()
+}
@@ -19,14 +19,50 @@ func NewMailgun(domain, apiKey, sender string) *Mailgun {
 }
 
 // Send sends an email via Mailgun. html is optional; if provided it will be used as HTML body.
-func (m *Mailgun) Send(ctx context.Context, to, subject, text, html string) error {
+// env.From replaces the configured Sender for this message.
+func (m *Mailgun) Send(ctx context.Context, to, subject, text, html string, env Envelope) error {
 	client := mg.NewMailgun(m.Domain, m.APIKey)
-	msg := client.NewMessage(m.Sender, subject, text, to)
+	from := m.Sender
+	if env.From != "" {
+		from = env.From
+	}
+	msg := client.NewMessage(from, subject, text, to)
 	if html != "" {
 		msg.SetHtml(html)
 	}
+	if env.ReplyTo != "" {
+		msg.SetReplyTo(env.ReplyTo)
+	}
+	for _, cc := range env.CC {
+		msg.AddCC(cc)
+	}
+	for _, bcc := range env.BCC {
+		msg.AddBCC(bcc)
+	}
+	if len(env.Tags) > 0 {
+		if err := msg.AddTag(env.Tags...); err != nil {
+			return err
+		}
+	}
+	if env.TrackClicks != nil {
+		msg.SetTrackingClicks(*env.TrackClicks)
+	}
+	if env.TrackOpens != nil {
+		msg.SetTrackingOpens(*env.TrackOpens)
+	}
+	for k, v := range env.Variables {
+		if err := msg.AddVariable(k, v); err != nil {
+			return err
+		}
+	}
 	c, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 	_, _, err := client.Send(c, msg)
 	return err
 }
+
+// Ping checks that the Mailgun API answers for the configured domain (status probes)
+func (m *Mailgun) Ping(ctx context.Context) error {
+	_, err := mg.NewMailgun(m.Domain, m.APIKey).GetDomain(ctx, m.Domain)
+	return err
+}
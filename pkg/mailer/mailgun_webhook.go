@@ -0,0 +1,123 @@
+package mailer
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrWebhookSignature = errors.New("invalid webhook signature")
+	ErrWebhookStale     = errors.New("webhook timestamp outside the allowed window")
+	ErrWebhookPayload   = errors.New("malformed webhook payload")
+)
+
+// Status events a provider webhook reports, normalized across providers
+const (
+	StatusDelivered    = "delivered"
+	StatusBounced      = "bounced"
+	StatusDeferred     = "deferred"
+	StatusComplained   = "complained"
+	StatusUnsubscribed = "unsubscribed"
+)
+
+// StatusEvent is one delivery outcome from a provider webhook
+type StatusEvent struct {
+	ID         string // provider event id, unique per event
+	Status     string // one of the Status* constants; empty for events nobody tracks (opens, clicks)
+	Recipient  string
+	MessageID  string
+	Reason     string
+	Variables  map[string]string // custom variables the email was sent with
+	OccurredAt time.Time
+}
+
+type mailgunWebhook struct {
+	Signature struct {
+		Timestamp string `json:"timestamp"`
+		Token     string `json:"token"`
+		Signature string `json:"signature"`
+	} `json:"signature"`
+	EventData struct {
+		ID             string         `json:"id"`
+		Event          string         `json:"event"`
+		Timestamp      float64        `json:"timestamp"`
+		Recipient      string         `json:"recipient"`
+		Severity       string         `json:"severity"`
+		Reason         string         `json:"reason"`
+		UserVariables  map[string]any `json:"user-variables"`
+		DeliveryStatus struct {
+			Message     string `json:"message"`
+			Description string `json:"description"`
+		} `json:"delivery-status"`
+		Message struct {
+			Headers struct {
+				MessageID string `json:"message-id"`
+			} `json:"headers"`
+		} `json:"message"`
+	} `json:"event-data"`
+}
+
+// ParseMailgunWebhook verifies a Mailgun webhook body (HMAC-SHA256 of timestamp+token with the
+// webhook signing key, timestamp within maxAge of now) and returns its event. A replayed body
+// carries the same event id, so storing events by id makes replays harmless.
+func ParseMailgunWebhook(body []byte, signingKey string, maxAge time.Duration) (StatusEvent, error) {
+	var p mailgunWebhook
+	if err := json.Unmarshal(body, &p); err != nil {
+		return StatusEvent{}, fmt.Errorf("%w: %v", ErrWebhookPayload, err)
+	}
+	sig := p.Signature
+	m := hmac.New(sha256.New, []byte(signingKey))
+	m.Write([]byte(sig.Timestamp + sig.Token))
+	want, err := hex.DecodeString(sig.Signature)
+	if signingKey == "" || err != nil || !hmac.Equal(m.Sum(nil), want) {
+		return StatusEvent{}, ErrWebhookSignature
+	}
+	ts, err := strconv.ParseInt(sig.Timestamp, 10, 64)
+	if err != nil {
+		return StatusEvent{}, ErrWebhookSignature
+	}
+	if maxAge > 0 && time.Since(time.Unix(ts, 0)).Abs() > maxAge {
+		return StatusEvent{}, ErrWebhookStale
+	}
+
+	d := p.EventData
+	ev := StatusEvent{ID: d.ID, Recipient: d.Recipient, MessageID: d.Message.Headers.MessageID, Reason: d.Reason}
+	sec, frac := math.Modf(d.Timestamp)
+	ev.OccurredAt = time.Unix(int64(sec), int64(frac*1e9)).UTC()
+	switch d.Event {
+	case "delivered":
+		ev.Status = StatusDelivered
+	case "failed":
+		ev.Status = StatusDeferred
+		if d.Severity == "permanent" {
+			ev.Status = StatusBounced
+		}
+	case "complained":
+		ev.Status = StatusComplained
+	case "unsubscribed":
+		ev.Status = StatusUnsubscribed
+	}
+	if desc := d.DeliveryStatus.Description; desc != "" {
+		ev.Reason = desc
+	} else if msg := d.DeliveryStatus.Message; msg != "" && ev.Status != StatusDelivered {
+		ev.Reason = msg
+	}
+	if len(d.UserVariables) > 0 {
+		ev.Variables = make(map[string]string, len(d.UserVariables))
+		for k, v := range d.UserVariables {
+			if s, ok := v.(string); ok {
+				ev.Variables[k] = s
+			} else if b, err := json.Marshal(v); err == nil {
+				ev.Variables[k] = string(b)
+			}
+		}
+	}
+	return ev, nil
+}
@@ -0,0 +1,74 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"strings"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/config"
+)
+
+// Sender delivers a rendered email. Mailgun sends for real; the log and file
+// drivers capture emails locally for end-to-end testing.
+type Sender interface {
+	Send(ctx context.Context, to, subject, text, html string, env Envelope) error
+}
+
+// Envelope holds optional per-message settings: sender and extra recipients, plus Mailgun tags,
+// tracking and custom variables. The zero value sends from the configured sender with the
+// domain's tracking defaults.
+type Envelope struct {
+	From    string   `json:"from,omitempty"` // overrides MAILGUN_SENDER, e.g. "Support <support@example.com>"; see SenderAllowed
+	ReplyTo string   `json:"reply_to,omitempty"`
+	CC      []string `json:"cc,omitempty"`  // at most MaxCopies together with BCC
+	BCC     []string `json:"bcc,omitempty"` // at most MaxCopies together with CC
+
+	Tags        []string          `json:"tags,omitempty"`         // o:tag, at most MaxTags; the worker defaults to the email type
+	TrackClicks *bool             `json:"track_clicks,omitempty"` // o:tracking-clicks; nil = domain setting
+	TrackOpens  *bool             `json:"track_opens,omitempty"`  // o:tracking-opens; nil = domain setting
+	Variables   map[string]string `json:"variables,omitempty"`    // v:<key>, echoed in webhooks (user_id, request_id)
+}
+
+// MaxTags is Mailgun's limit of tags per message
+const MaxTags = 3
+
+// MaxCopies caps the cc and bcc recipients of one message together
+const MaxCopies = 10
+
+// SenderAllowed reports whether from may replace the configured sender: allow lists lowercase
+// addresses ("support@example.com") and domains ("example.com" or "@example.com"). An empty
+// allowlist permits no override.
+func SenderAllowed(from string, allow []string) bool {
+	addr, err := mail.ParseAddress(from)
+	if err != nil {
+		return false
+	}
+	email := strings.ToLower(addr.Address)
+	_, domain, _ := strings.Cut(email, "@")
+	for _, a := range allow {
+		if a == email || strings.TrimPrefix(a, "@") == domain {
+			return true
+		}
+	}
+	return false
+}
+
+// NewSender selects a Sender from cfg.MailDriver (mailgun, log, file).
+func NewSender(cfg *config.Config) (Sender, error) {
+	switch strings.ToLower(strings.TrimSpace(cfg.MailDriver)) {
+	case "", "mailgun":
+		if cfg.MailgunDomain == "" || cfg.MailgunAPIKey == "" || cfg.MailgunSender == "" {
+			return nil, fmt.Errorf("mailgun not configured")
+		}
+		return NewMailgun(cfg.MailgunDomain, cfg.MailgunAPIKey, cfg.MailgunSender), nil
+	case "log":
+		return NewLogSender(), nil
+	case "file":
+		return NewFileSender(cfg.MailCaptureDir)
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", cfg.MailDriver)
+	}
+}
+
+var _ Sender = (*Mailgun)(nil)
@@ -3,10 +3,13 @@ package templates
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
 	"time"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/config"
 )
 
 // Geo save lookup result
@@ -15,6 +18,9 @@ type Geo struct {
 	Region   string // state/province
 	Country  string
 	Timezone string
+	// CountryCode (ISO 3166-1 alpha-2) and ASN identify the location and network for login anomaly checks
+	CountryCode string
+	ASN         string
 }
 
 type GeoResolver interface {
@@ -49,7 +55,7 @@ func (r IPAPIResolver) Lookup(ctx context.Context, ip string) (Geo, error) {
 		r.Client = &http.Client{Timeout: 2 * time.Second}
 	}
 
-	url := fmt.Sprintf("http://ip-api.com/json/%s?fields=status,message,country,regionName,city,timezone", ip)
+	url := fmt.Sprintf("http://ip-api.com/json/%s?fields=status,message,country,countryCode,regionName,city,timezone,as", ip)
 	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	resp, err := r.Client.Do(req)
 	if err != nil {
@@ -58,12 +64,14 @@ func (r IPAPIResolver) Lookup(ctx context.Context, ip string) (Geo, error) {
 	defer resp.Body.Close()
 
 	var body struct {
-		Status     string `json:"status"`
-		Message    string `json:"message"`
-		Country    string `json:"country"`
-		RegionName string `json:"regionName"`
-		City       string `json:"city"`
-		Timezone   string `json:"timezone"`
+		Status      string `json:"status"`
+		Message     string `json:"message"`
+		Country     string `json:"country"`
+		CountryCode string `json:"countryCode"`
+		AS          string `json:"as"` // "AS15169 Google LLC"
+		RegionName  string `json:"regionName"`
+		City        string `json:"city"`
+		Timezone    string `json:"timezone"`
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
 		return Geo{}, err
@@ -71,5 +79,90 @@ func (r IPAPIResolver) Lookup(ctx context.Context, ip string) (Geo, error) {
 	if strings.ToLower(body.Status) != "success" {
 		return Geo{}, fmt.Errorf("geo lookup failed: %s", body.Message)
 	}
-	return Geo{City: body.City, Region: body.RegionName, Country: body.Country, Timezone: body.Timezone}, nil
+	asn, _, _ := strings.Cut(body.AS, " ")
+	return Geo{City: body.City, Region: body.RegionName, Country: body.Country, Timezone: body.Timezone, CountryCode: body.CountryCode, ASN: asn}, nil
+}
+
+// MaxMindResolver implements GeoResolver using the MaxMind GeoIP2 City web service
+type MaxMindResolver struct {
+	AccountID  string
+	LicenseKey string
+	Client     *http.Client
+}
+
+func (r MaxMindResolver) Lookup(ctx context.Context, ip string) (Geo, error) {
+	ip = strings.TrimSpace(ip)
+	if ip == "" {
+		return Geo{}, fmt.Errorf("empty ip")
+	}
+	if r.Client == nil {
+		r.Client = &http.Client{Timeout: 2 * time.Second}
+	}
+
+	url := fmt.Sprintf("https://geoip.maxmind.com/geoip/v2.1/city/%s", ip)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	req.SetBasicAuth(r.AccountID, r.LicenseKey)
+	resp, err := r.Client.Do(req)
+	if err != nil {
+		return Geo{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Geo{}, fmt.Errorf("geo lookup failed: status %d", resp.StatusCode)
+	}
+
+	type names struct {
+		Names map[string]string `json:"names"`
+	}
+	var body struct {
+		City    names `json:"city"`
+		Country struct {
+			names
+			ISOCode string `json:"iso_code"`
+		} `json:"country"`
+		Subdivisions []names `json:"subdivisions"`
+		Location     struct {
+			TimeZone string `json:"time_zone"`
+		} `json:"location"`
+		Traits struct {
+			ASN int `json:"autonomous_system_number"`
+		} `json:"traits"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return Geo{}, err
+	}
+	g := Geo{City: body.City.Names["en"], Country: body.Country.Names["en"], Timezone: body.Location.TimeZone, CountryCode: body.Country.ISOCode}
+	if body.Traits.ASN > 0 {
+		g.ASN = fmt.Sprintf("AS%d", body.Traits.ASN)
+	}
+	if len(body.Subdivisions) > 0 {
+		g.Region = body.Subdivisions[0].Names["en"]
+	}
+	return g, nil
+}
+
+// NoopResolver disables geo lookups; every lookup fails so callers skip enrichment
+type NoopResolver struct{}
+
+var errGeoDisabled = errors.New("geo lookup disabled")
+
+func (NoopResolver) Lookup(context.Context, string) (Geo, error) { return Geo{}, errGeoDisabled }
+
+// NewGeoResolver selects a GeoResolver from cfg.GeoProvider (ipapi, maxmind, none).
+// Unknown providers and maxmind without credentials fall back to none.
+func NewGeoResolver(cfg *config.Config) GeoResolver {
+	if cfg == nil {
+		return IPAPIResolver{}
+	}
+	switch strings.ToLower(strings.TrimSpace(cfg.GeoProvider)) {
+	case "", "ipapi":
+		return IPAPIResolver{}
+	case "maxmind":
+		if cfg.MaxMindAccountID == "" || cfg.MaxMindLicenseKey == "" {
+			return NoopResolver{}
+		}
+		return MaxMindResolver{AccountID: cfg.MaxMindAccountID, LicenseKey: cfg.MaxMindLicenseKey}
+	default:
+		return NoopResolver{}
+	}
 }
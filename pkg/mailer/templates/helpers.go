@@ -22,8 +22,13 @@ func WithTime(t time.Time) Option {
 }
 func WithVerifyURL(url string) Option { return func(d *EmailData) { d.VerifyURL = url } }
 func WithResetURL(url string) Option  { return func(d *EmailData) { d.ResetURL = url } }
-func WithChanges(ch map[string]string) Option {
-	return func(d *EmailData) { d.Changes = ch }
+func WithRevokeURL(url string) Option { return func(d *EmailData) { d.RevokeURL = url } }
+func WithUnsubscribeURL(url string) Option {
+	return func(d *EmailData) {
+		if url != "" {
+			d.UnsubscribeURL = url
+		}
+	}
 }
 
 func setLocation(d *EmailData, loc string) {
@@ -93,31 +98,61 @@ func NewBaseEmailData(cfg *config.Config, typ string, name, email, recipient str
 	return d
 }
 
-func NewLoginNotificationData(cfg *config.Config, name, email, recipientEmail string, opts ...Option) map[string]any {
+func NewLoginNotificationData(cfg *config.Config, name, email, recipientEmail string, opts ...Option) *EmailData {
 	d := NewBaseEmailData(cfg, LoginNotification, name, email, recipientEmail, opts...)
-	return ToMap(d)
+	return &d
 }
 
-func NewVerifyEmailData(cfg *config.Config, name, email, verifyURL string, opts ...Option) map[string]any {
+func NewVerifyEmailData(cfg *config.Config, name, email, verifyURL string, opts ...Option) *EmailData {
 	opts = append([]Option{WithVerifyURL(verifyURL)}, opts...)
 	d := NewBaseEmailData(cfg, VerifyEmail, name, email, email, opts...)
-	return ToMap(d)
+	return &d
 }
 
-func NewForgotPasswordData(cfg *config.Config, name, email, recipient string, opts ...Option) map[string]any {
+func NewForgotPasswordData(cfg *config.Config, name, email, recipient string, opts ...Option) *EmailData {
 	d := NewBaseEmailData(cfg, ForgotPassword, name, email, recipient, opts...)
-	return ToMap(d)
+	return &d
+}
+
+func NewProfileUpdatedData(cfg *config.Config, name, email string, changes map[string]string, opts ...Option) *ProfileUpdatedData {
+	return &ProfileUpdatedData{EmailData: NewBaseEmailData(cfg, ProfileUpdated, name, email, email, opts...), Changes: changes}
+}
+
+func NewLoginOTPData(cfg *config.Config, name, email, code string, opts ...Option) *LoginOTPData {
+	return &LoginOTPData{EmailData: NewBaseEmailData(cfg, LoginOTP, name, email, email, opts...), Code: code}
+}
+
+func NewPasswordChangedData(cfg *config.Config, name, email string, opts ...Option) *EmailData {
+	d := NewBaseEmailData(cfg, PasswordChanged, name, email, email, opts...)
+	return &d
+}
+
+func NewInvitationData(cfg *config.Config, email, inviteURL, role string, opts ...Option) *InvitationData {
+	return &InvitationData{EmailData: NewBaseEmailData(cfg, Invitation, email, email, email, opts...), InviteURL: inviteURL, Role: role}
+}
+
+func NewSuspiciousLoginData(cfg *config.Config, name, email string, opts ...Option) *EmailData {
+	d := NewBaseEmailData(cfg, SuspiciousLogin, name, email, email, opts...)
+	return &d
+}
+
+// NewAccountStatusData builds an AccountSuspended, AccountBanned or AccountReinstated email
+func NewAccountStatusData(cfg *config.Config, typ, name, email, reason string, opts ...Option) *AccountStatusData {
+	return &AccountStatusData{EmailData: NewBaseEmailData(cfg, typ, name, email, email, opts...), Reason: reason}
 }
 
-func NewProfileUpdatedData(cfg *config.Config, name, email string, changes map[string]string, opts ...Option) map[string]any {
-	opts = append([]Option{WithChanges(changes)}, opts...)
-	d := NewBaseEmailData(cfg, ProfileUpdated, name, email, email, opts...)
-	return ToMap(d)
+// NewConfirmEmailChangeData builds the email sent to newEmail with the link confirming the change
+func NewConfirmEmailChangeData(cfg *config.Config, name, newEmail, confirmURL string, opts ...Option) *EmailData {
+	opts = append([]Option{WithVerifyURL(confirmURL)}, opts...)
+	d := NewBaseEmailData(cfg, ConfirmEmailChange, name, newEmail, newEmail, opts...)
+	return &d
 }
 
-func NewLoginOTPData(cfg *config.Config, name, email, code string, opts ...Option) map[string]any {
-	// put code and expires into data
-	base := NewBaseEmailData(cfg, LoginOTP, name, email, email, opts...)
-	base.Code = code
-	return ToMap(base)
+// NewActivityDigestData builds the weekly activity summary; entries are already formatted in the user's timezone
+func NewActivityDigestData(cfg *config.Config, name, email, period, timezone string, loginCount int, logins, changes, security []DigestEntry, opts ...Option) *ActivityDigestData {
+	return &ActivityDigestData{
+		EmailData:  NewBaseEmailData(cfg, ActivityDigest, name, email, email, opts...),
+		PeriodText: period, Timezone: timezone, LoginCount: loginCount,
+		Logins: logins, MoreLogins: max(loginCount-len(logins), 0), ProfileChanges: changes, SecurityEvents: security,
+	}
 }
@@ -0,0 +1,474 @@
+package templates
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/html"
+)
+
+// CSS inlining: many mail clients (Outlook, older Gmail apps) drop <style> blocks, so rules are
+// copied into each element's style attribute after rendering. Rules that cannot be inlined
+// (@media, pseudo-classes, attribute or sibling selectors) stay in a <style> block.
+
+type cssDecl struct {
+	prop, value string
+	important   bool
+}
+
+// cssSelector is one compound selector chain, matched right to left
+type cssSelector struct {
+	parts       []cssCompound
+	combinators []byte // combinators[i] joins parts[i] and parts[i+1]: ' ' descendant, '>' child
+	specificity [3]int // ids, classes, tags
+}
+
+type cssCompound struct {
+	tag     string // "" or "*" = any
+	id      string
+	classes []string
+}
+
+type cssRule struct {
+	selector cssSelector
+	decls    []cssDecl
+	order    int
+}
+
+type stylesheet struct {
+	rules    []cssRule
+	retained string // CSS kept in a <style> block
+}
+
+var (
+	sheetMu    sync.Mutex
+	sheetCache = map[string]cachedSheet{} // by template name
+)
+
+type cachedSheet struct {
+	css   string
+	sheet *stylesheet
+}
+
+// sheetFor parses css once per template; a changed css (template reload) is parsed again.
+func sheetFor(name, css string) *stylesheet {
+	sheetMu.Lock()
+	defer sheetMu.Unlock()
+	if c, ok := sheetCache[name]; ok && c.css == css {
+		return c.sheet
+	}
+	s := parseStylesheet(css)
+	sheetCache[name] = cachedSheet{css: css, sheet: s}
+	return s
+}
+
+// InlineCSS moves the document's <style> rules into style attributes. name keys the parsed
+// stylesheet cache (one per template).
+func InlineCSS(name, doc string) (string, error) {
+	root, err := html.Parse(strings.NewReader(doc))
+	if err != nil {
+		return "", err
+	}
+	var styles []*html.Node
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "style" {
+			styles = append(styles, n)
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(root)
+	if len(styles) == 0 {
+		return doc, nil
+	}
+	var css strings.Builder
+	for _, s := range styles {
+		for c := s.FirstChild; c != nil; c = c.NextSibling {
+			css.WriteString(c.Data)
+			css.WriteByte('\n')
+		}
+	}
+	sheet := sheetFor(name, css.String())
+
+	applyRules(root, sheet.rules)
+
+	// Keep what could not be inlined in the first <style>, drop the rest
+	for i, s := range styles {
+		if i == 0 && strings.TrimSpace(sheet.retained) != "" {
+			for c := s.FirstChild; c != nil; c = s.FirstChild {
+				s.RemoveChild(c)
+			}
+			s.AppendChild(&html.Node{Type: html.TextNode, Data: sheet.retained})
+			continue
+		}
+		s.Parent.RemoveChild(s)
+	}
+
+	var buf bytes.Buffer
+	if err := html.Render(&buf, root); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func applyRules(root *html.Node, rules []cssRule) {
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data != "head" && !inHead(n) {
+			var matched []cssRule
+			for _, r := range rules {
+				if r.selector.matches(n) {
+					matched = append(matched, r)
+				}
+			}
+			if len(matched) > 0 {
+				setStyle(n, matched)
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(root)
+}
+
+func inHead(n *html.Node) bool {
+	for p := n.Parent; p != nil; p = p.Parent {
+		if p.Type == html.ElementNode && p.Data == "head" {
+			return true
+		}
+	}
+	return false
+}
+
+// setStyle merges matched rules (by specificity, then source order), the element's own style
+// attribute, then !important declarations.
+func setStyle(n *html.Node, matched []cssRule) {
+	sort.SliceStable(matched, func(i, j int) bool {
+		a, b := matched[i].selector.specificity, matched[j].selector.specificity
+		if a != b {
+			return a[0] < b[0] || (a[0] == b[0] && (a[1] < b[1] || (a[1] == b[1] && a[2] < b[2])))
+		}
+		return matched[i].order < matched[j].order
+	})
+	var props []string
+	values := map[string]string{}
+	set := func(d cssDecl) {
+		if _, ok := values[d.prop]; !ok {
+			props = append(props, d.prop)
+		}
+		v := d.value
+		if d.important {
+			v += " !important"
+		}
+		values[d.prop] = v
+	}
+	for _, r := range matched {
+		for _, d := range r.decls {
+			if !d.important {
+				set(d)
+			}
+		}
+	}
+	attr := -1
+	for i, a := range n.Attr {
+		if a.Key == "style" {
+			attr = i
+			for _, d := range parseDecls(a.Val) {
+				set(d)
+			}
+		}
+	}
+	for _, r := range matched {
+		for _, d := range r.decls {
+			if d.important {
+				set(d)
+			}
+		}
+	}
+	var b strings.Builder
+	for _, p := range props {
+		b.WriteString(p + ": " + values[p] + "; ")
+	}
+	style := strings.TrimSpace(b.String())
+	if attr >= 0 {
+		n.Attr[attr].Val = style
+	} else {
+		n.Attr = append(n.Attr, html.Attribute{Key: "style", Val: style})
+	}
+}
+
+func (s cssSelector) matches(n *html.Node) bool {
+	return matchFrom(n, s.parts, s.combinators, len(s.parts)-1)
+}
+
+func matchFrom(n *html.Node, parts []cssCompound, combs []byte, i int) bool {
+	if !parts[i].matches(n) {
+		return false
+	}
+	if i == 0 {
+		return true
+	}
+	if combs[i-1] == '>' {
+		p := n.Parent
+		return p != nil && p.Type == html.ElementNode && matchFrom(p, parts, combs, i-1)
+	}
+	for p := n.Parent; p != nil && p.Type == html.ElementNode; p = p.Parent {
+		if matchFrom(p, parts, combs, i-1) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c cssCompound) matches(n *html.Node) bool {
+	if c.tag != "" && c.tag != "*" && c.tag != n.Data {
+		return false
+	}
+	if c.id == "" && len(c.classes) == 0 {
+		return true
+	}
+	var id string
+	var classes []string
+	for _, a := range n.Attr {
+		switch a.Key {
+		case "id":
+			id = a.Val
+		case "class":
+			classes = strings.Fields(a.Val)
+		}
+	}
+	if c.id != "" && c.id != id {
+		return false
+	}
+	for _, want := range c.classes {
+		found := false
+		for _, have := range classes {
+			if have == want {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// ---- CSS parsing (enough for email templates, not a full CSS parser) ----
+
+func parseStylesheet(css string) *stylesheet {
+	css = stripComments(css)
+	s := &stylesheet{}
+	var retained strings.Builder
+	order := 0
+	for i := 0; i < len(css); {
+		// skip whitespace
+		for i < len(css) && isSpace(css[i]) {
+			i++
+		}
+		if i >= len(css) {
+			break
+		}
+		if css[i] == '@' {
+			end := atRuleEnd(css, i)
+			retained.WriteString(strings.TrimSpace(css[i:end]) + "\n")
+			i = end
+			continue
+		}
+		open := strings.IndexByte(css[i:], '{')
+		if open < 0 {
+			break
+		}
+		open += i
+		end := blockEnd(css, open)
+		selectors := css[i:open]
+		body := css[open+1 : max(end-1, open+1)]
+		i = end
+
+		decls := parseDecls(body)
+		for _, raw := range strings.Split(selectors, ",") {
+			raw = strings.TrimSpace(raw)
+			if raw == "" {
+				continue
+			}
+			sel, ok := parseSelector(raw)
+			if !ok {
+				retained.WriteString(raw + " { " + strings.TrimSpace(body) + " }\n")
+				continue
+			}
+			s.rules = append(s.rules, cssRule{selector: sel, decls: decls, order: order})
+			order++
+		}
+	}
+	s.retained = retained.String()
+	return s
+}
+
+func stripComments(css string) string {
+	var b strings.Builder
+	for {
+		start := strings.Index(css, "/*")
+		if start < 0 {
+			b.WriteString(css)
+			return b.String()
+		}
+		b.WriteString(css[:start])
+		end := strings.Index(css[start+2:], "*/")
+		if end < 0 {
+			return b.String()
+		}
+		css = css[start+2+end+2:]
+	}
+}
+
+// atRuleEnd returns the index after an at-rule: its ';' or its closing brace
+func atRuleEnd(css string, i int) int {
+	for j := i; j < len(css); j++ {
+		switch css[j] {
+		case ';':
+			return j + 1
+		case '{':
+			return blockEnd(css, j)
+		}
+	}
+	return len(css)
+}
+
+// blockEnd returns the index after the brace closing the block opened at open
+func blockEnd(css string, open int) int {
+	depth := 0
+	for j := open; j < len(css); j++ {
+		switch css[j] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return j + 1
+			}
+		}
+	}
+	return len(css)
+}
+
+func parseDecls(body string) []cssDecl {
+	var out []cssDecl
+	depth := 0
+	var quote byte
+	start := 0
+	flush := func(end int) {
+		prop, value, ok := strings.Cut(body[start:end], ":")
+		prop, value = strings.ToLower(strings.TrimSpace(prop)), strings.TrimSpace(value)
+		if !ok || prop == "" || value == "" {
+			return
+		}
+		d := cssDecl{prop: prop, value: value}
+		if v, found := strings.CutSuffix(value, "!important"); found {
+			d.value, d.important = strings.TrimSpace(v), true
+		}
+		out = append(out, d)
+	}
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ';' && depth == 0:
+			flush(i)
+			start = i + 1
+		}
+	}
+	flush(len(body))
+	return out
+}
+
+// parseSelector handles type, universal, class and id selectors joined by descendant or child
+// combinators; anything else is reported as not inlinable.
+func parseSelector(raw string) (cssSelector, bool) {
+	if strings.ContainsAny(raw, ":[+~") {
+		return cssSelector{}, false
+	}
+	raw = strings.ReplaceAll(raw, ">", " > ")
+	var sel cssSelector
+	pendingChild := false
+	for _, tok := range strings.Fields(raw) {
+		if tok == ">" {
+			if len(sel.parts) == 0 || pendingChild {
+				return cssSelector{}, false
+			}
+			pendingChild = true
+			continue
+		}
+		c, ok := parseCompound(tok)
+		if !ok {
+			return cssSelector{}, false
+		}
+		if len(sel.parts) > 0 {
+			comb := byte(' ')
+			if pendingChild {
+				comb = '>'
+			}
+			sel.combinators = append(sel.combinators, comb)
+		}
+		pendingChild = false
+		sel.parts = append(sel.parts, c)
+		if c.id != "" {
+			sel.specificity[0]++
+		}
+		sel.specificity[1] += len(c.classes)
+		if c.tag != "" && c.tag != "*" {
+			sel.specificity[2]++
+		}
+	}
+	if len(sel.parts) == 0 || pendingChild {
+		return cssSelector{}, false
+	}
+	return sel, true
+}
+
+func parseCompound(tok string) (cssCompound, bool) {
+	var c cssCompound
+	i := 0
+	for i < len(tok) && tok[i] != '.' && tok[i] != '#' {
+		i++
+	}
+	c.tag = strings.ToLower(tok[:i])
+	for i < len(tok) {
+		kind := tok[i]
+		j := i + 1
+		for j < len(tok) && tok[j] != '.' && tok[j] != '#' {
+			j++
+		}
+		name := tok[i+1 : j]
+		if name == "" {
+			return c, false
+		}
+		if kind == '#' {
+			if c.id != "" {
+				return c, false
+			}
+			c.id = name
+		} else {
+			c.classes = append(c.classes, name)
+		}
+		i = j
+	}
+	return c, true
+}
+
+func isSpace(b byte) bool { return b == ' ' || b == '\n' || b == '\t' || b == '\r' || b == '\f' }
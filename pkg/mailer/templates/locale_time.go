@@ -0,0 +1,146 @@
+package templates
+
+import (
+	"strings"
+	"time"
+)
+
+// dateLocale holds a locale's month and weekday names and how it orders a date (Go layouts using
+// the English names, which FormatTimeIn swaps for the locale's)
+type dateLocale struct {
+	months   [12]string
+	days     [7]string // Sunday first, as time.Weekday
+	date     string
+	dateTime string
+}
+
+var dateLocales = map[string]dateLocale{
+	"en": {
+		months:   [12]string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+		days:     [7]string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"},
+		date:     "02 January 2006",
+		dateTime: "02 January 2006, 15:04",
+	},
+	"id": {
+		months:   [12]string{"Januari", "Februari", "Maret", "April", "Mei", "Juni", "Juli", "Agustus", "September", "Oktober", "November", "Desember"},
+		days:     [7]string{"Minggu", "Senin", "Selasa", "Rabu", "Kamis", "Jumat", "Sabtu"},
+		date:     "2 January 2006",
+		dateTime: "2 January 2006 pukul 15.04",
+	},
+}
+
+// dateLocaleFor resolves locale ("id", "id-ID", "ID_id") to a known locale, falling back to DefaultLocale
+func dateLocaleFor(locale string) dateLocale {
+	l := strings.ToLower(strings.TrimSpace(locale))
+	if i := strings.IndexAny(l, "-_"); i > 0 {
+		l = l[:i]
+	}
+	if dl, ok := dateLocales[l]; ok {
+		return dl
+	}
+	return dateLocales[DefaultLocale]
+}
+
+// FormatTimeIn formats t with a Go layout, naming months and weekdays (full and three-letter) in locale
+func FormatTimeIn(t time.Time, layout, locale string) string {
+	out := t.Format(layout)
+	dl := dateLocaleFor(locale)
+	en := dateLocales[DefaultLocale]
+	m, d := t.Month()-1, t.Weekday()
+	if dl.months[m] == en.months[m] && dl.days[d] == en.days[d] {
+		return out
+	}
+	// Full names first: "January" also contains "Jan"
+	return strings.NewReplacer(
+		en.months[m], dl.months[m], en.days[d], dl.days[d],
+		en.months[m][:3], dl.months[m][:3], en.days[d][:3], dl.days[d][:3],
+	).Replace(out)
+}
+
+// FormatDate is t's date in locale's order and month names ("02 January 2006", "2 Januari 2006")
+func FormatDate(t time.Time, locale string) string {
+	return FormatTimeIn(t, dateLocaleFor(locale).date, locale)
+}
+
+// FormatDateTime is t's date and time in locale's order ("02 January 2006, 15:04", "2 Januari 2006 pukul 15.04")
+func FormatDateTime(t time.Time, locale string) string {
+	return FormatTimeIn(t, dateLocaleFor(locale).dateTime, locale)
+}
+
+// LocalizeTimes rewrites Time and ExpiresAtText in job data from TimeAt and ExpiresAt, formatted in
+// locale and shown in loc (UTC when nil; the zone abbreviation is added unless loc is UTC). Data
+// without those timestamps is left as the producer wrote it.
+func LocalizeTimes(data map[string]any, locale string, loc *time.Location) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	set := func(from, to string) {
+		t, ok := toTime(data[from])
+		if !ok || t.IsZero() {
+			return
+		}
+		t = t.In(loc)
+		s := FormatDateTime(t, locale)
+		if loc != time.UTC {
+			s += " " + t.Format("MST")
+		}
+		data[to] = s
+	}
+	set("TimeAt", "Time")
+	set("ExpiresAt", "ExpiresAtText")
+	if strings.TrimSpace(locale) != "" {
+		data["Locale"] = locale
+	}
+}
+
+// toTime accepts a time.Time, the RFC 3339 string it becomes in job data, or its String() form
+func toTime(v any) (time.Time, bool) {
+	switch x := v.(type) {
+	case time.Time:
+		return x, true
+	case *time.Time:
+		if x != nil {
+			return *x, true
+		}
+	case string:
+		for _, l := range []string{time.RFC3339, "2006-01-02 15:04:05 -0700 MST", "2006-01-02 15:04:05 -0700"} {
+			if t, err := time.Parse(l, x); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// localeFuncs are the template functions for locale-aware dates; t may be a time.Time or an
+// RFC 3339 string, and an unknown, empty or missing locale means English:
+//
+//	{{formatDate .TimeAt .Locale}}  {{formatDateTime .ExpiresAt .Locale}}  {{formatTimeIn .TimeAt "Monday, 2 January" .Locale}}
+func localeFuncs() map[string]any {
+	return map[string]any{
+		"formatDate": func(v, locale any) string {
+			if t, ok := toTime(v); ok {
+				return FormatDate(t, localeArg(locale))
+			}
+			return ""
+		},
+		"formatDateTime": func(v, locale any) string {
+			if t, ok := toTime(v); ok {
+				return FormatDateTime(t, localeArg(locale))
+			}
+			return ""
+		},
+		"formatTimeIn": func(v any, layout string, locale any) string {
+			if t, ok := toTime(v); ok {
+				return FormatTimeIn(t, layout, localeArg(locale))
+			}
+			return ""
+		},
+	}
+}
+
+// localeArg lets templates pass .Locale even when the data has none
+func localeArg(v any) string {
+	s, _ := v.(string)
+	return s
+}
@@ -0,0 +1,163 @@
+package templates
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrInvalidData wraps every ValidateData failure
+var ErrInvalidData = errors.New("invalid email data")
+
+// TemplateData is the data of one email type: a struct embedding EmailData plus the fields only
+// that type's section reads. Build it with the New...Data constructors and put it on a job with ToMap.
+type TemplateData interface {
+	base() *EmailData
+}
+
+func (d *EmailData) base() *EmailData { return d }
+
+// Spec registers an email type, a section of the universal template
+type Spec struct {
+	Type     string
+	New      func() TemplateData // empty data of the type, decoded into when validating job data
+	Required []string            // Data keys that must be set, checked at enqueue and before rendering
+	Security bool                // account security email: never opted out of or rate limited
+}
+
+// Data of the types with fields of their own; the rest use EmailData as is
+type (
+	ProfileUpdatedData struct {
+		EmailData
+		Changes map[string]string `json:"Changes"`
+	}
+	LoginOTPData struct {
+		EmailData
+		Code string `json:"Code"`
+	}
+	InvitationData struct {
+		EmailData
+		InviteURL string `json:"InviteURL"`
+		Role      string `json:"Role"`
+	}
+	// AccountStatusData serves AccountSuspended, AccountBanned and AccountReinstated
+	AccountStatusData struct {
+		EmailData
+		Reason string `json:"Reason"`
+	}
+	// ActivityDigestData is a week of account activity; times are in the user's Timezone
+	ActivityDigestData struct {
+		EmailData
+		PeriodText     string        `json:"PeriodText"`
+		Timezone       string        `json:"Timezone"`
+		LoginCount     int           `json:"LoginCount"`
+		Logins         []DigestEntry `json:"Logins"`     // the latest few
+		MoreLogins     int           `json:"MoreLogins"` // LoginCount - len(Logins)
+		ProfileChanges []DigestEntry `json:"ProfileChanges"`
+		SecurityEvents []DigestEntry `json:"SecurityEvents"`
+	}
+)
+
+// DigestEntry is one line of the activity digest
+type DigestEntry struct {
+	Time   string `json:"Time"`
+	Text   string `json:"Text"`
+	Detail string `json:"Detail"`
+}
+
+func common() TemplateData { return &EmailData{} }
+
+var registry = map[string]Spec{}
+
+func register(specs ...Spec) {
+	for _, s := range specs {
+		if _, dup := registry[s.Type]; dup {
+			panic("templates: email type registered twice: " + s.Type)
+		}
+		registry[s.Type] = s
+	}
+}
+
+func init() {
+	register(
+		Spec{Type: LoginNotification, New: common, Security: true},
+		Spec{Type: VerifyEmail, New: common, Required: []string{"VerifyURL"}, Security: true},
+		Spec{Type: ForgotPassword, New: common, Required: []string{"ResetURL"}, Security: true},
+		Spec{Type: ProfileUpdated, New: func() TemplateData { return &ProfileUpdatedData{} }, Required: []string{"Changes"}},
+		Spec{Type: LoginOTP, New: func() TemplateData { return &LoginOTPData{} }, Required: []string{"Code"}, Security: true},
+		Spec{Type: Invitation, New: func() TemplateData { return &InvitationData{} }, Required: []string{"InviteURL"}},
+		Spec{Type: PasswordChanged, New: common, Security: true},
+		Spec{Type: SuspiciousLogin, New: common, Security: true},
+		Spec{Type: AccountSuspended, New: func() TemplateData { return &AccountStatusData{} }, Security: true},
+		Spec{Type: AccountBanned, New: func() TemplateData { return &AccountStatusData{} }, Security: true},
+		Spec{Type: AccountReinstated, New: func() TemplateData { return &AccountStatusData{} }, Security: true},
+		Spec{Type: ConfirmEmailChange, New: common, Required: []string{"VerifyURL"}, Security: true},
+		Spec{Type: ActivityDigest, New: func() TemplateData { return &ActivityDigestData{} }, Required: []string{"PeriodText"}},
+	)
+}
+
+// Lookup returns the registered spec of an email type
+func Lookup(typ string) (Spec, bool) {
+	s, ok := registry[typ]
+	return s, ok
+}
+
+// Types returns the registered email types, sorted
+func Types() []string {
+	out := make([]string, 0, len(registry))
+	for t := range registry {
+		out = append(out, t)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// IsSecurity reports email types sent for account security (never opted out of or rate limited).
+func IsSecurity(typ string) bool {
+	return registry[typ].Security
+}
+
+// ValidateData checks universal template data against the spec of its Type: the type is
+// registered, every value decodes into the type's data struct and the required keys are set.
+func ValidateData(data map[string]any) (TemplateData, error) {
+	typ, _ := data["Type"].(string)
+	if typ == "" {
+		return nil, fmt.Errorf("%w: Type is required", ErrInvalidData)
+	}
+	spec, ok := registry[typ]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown email type %q", ErrInvalidData, typ)
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidData, err)
+	}
+	d := spec.New()
+	if err := json.Unmarshal(raw, d); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidData, typ, err)
+	}
+	for _, key := range spec.Required {
+		if unset(data[key]) {
+			return nil, fmt.Errorf("%w: %s is required for %s", ErrInvalidData, key, typ)
+		}
+	}
+	return d, nil
+}
+
+func unset(v any) bool {
+	switch x := v.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(x) == ""
+	case map[string]any:
+		return len(x) == 0
+	case map[string]string:
+		return len(x) == 0
+	case []any:
+		return len(x) == 0
+	}
+	return false
+}
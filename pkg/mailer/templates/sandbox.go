@@ -0,0 +1,167 @@
+package templates
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	htmpl "html/template"
+	"regexp"
+	"strconv"
+	"sync/atomic"
+	texttpl "text/template"
+	"text/template/parse"
+	"time"
+)
+
+var (
+	ErrRenderTimeout  = errors.New("template rendering timed out")
+	ErrOutputTooLarge = errors.New("template output too large")
+	ErrFuncDisabled   = errors.New("template function not available")
+)
+
+// Render limits, applied to every template (MAIL_TEMPLATE_TIMEOUT, MAIL_TEMPLATE_MAX_BYTES)
+var (
+	renderTimeout  atomic.Int64 // time.Duration
+	renderMaxBytes atomic.Int64
+)
+
+func init() {
+	SetLimits(2*time.Second, 1<<20)
+}
+
+// SetLimits bounds one template execution: it fails with ErrRenderTimeout after timeout and with
+// ErrOutputTooLarge past maxBytes of output. Zero or negative disables a limit.
+func SetLimits(timeout time.Duration, maxBytes int64) {
+	renderTimeout.Store(int64(timeout))
+	renderMaxBytes.Store(maxBytes)
+}
+
+// cappedBuffer fails writes past max bytes or once the render was abandoned, which stops the
+// template at its next output
+type cappedBuffer struct {
+	buf     bytes.Buffer
+	max     int64
+	aborted atomic.Bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if b.aborted.Load() {
+		return 0, ErrRenderTimeout
+	}
+	if b.max > 0 && int64(b.buf.Len()+len(p)) > b.max {
+		return 0, ErrOutputTooLarge
+	}
+	return b.buf.Write(p)
+}
+
+// execute runs tpl within the render limits. On timeout the caller gets ErrRenderTimeout at once;
+// the execution itself stops at its next write. Loops are bounded by the data they range over,
+// and checkRanges keeps external templates from ranging over large constants.
+func execute(tpl executor, data any) (string, error) {
+	out := &cappedBuffer{max: renderMaxBytes.Load()}
+	timeout := time.Duration(renderTimeout.Load())
+	if timeout <= 0 {
+		err := tpl.Execute(out, data)
+		return out.buf.String(), err
+	}
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("template panicked: %v", r)
+			}
+		}()
+		done <- tpl.Execute(out, data)
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		if errors.Is(err, ErrOutputTooLarge) {
+			err = ErrOutputTooLarge
+		}
+		return out.buf.String(), err
+	case <-timer.C:
+		out.aborted.Store(true)
+		return "", ErrRenderTimeout
+	}
+}
+
+// wideVerb matches printf verbs with a width or precision of four digits or more, which would
+// allocate that much output from a few bytes of template
+var wideVerb = regexp.MustCompile(`%[-+# 0]*(\d{4,}|\*)|%[-+# 0]*\d*\.(\d{4,}|\*)`)
+
+func sandboxPrintf(format string, args ...any) (string, error) {
+	if wideVerb.MatchString(format) {
+		return "", fmt.Errorf("printf: width or precision too large in %q", format)
+	}
+	return fmt.Sprintf(format, args...), nil
+}
+
+// sandboxFuncs is the function map for templates loaded from MAIL_TEMPLATES_DIR: the usual helpers,
+// printf without unbounded padding, and no call (data never carries functions on purpose)
+func sandboxFuncs() map[string]any {
+	m := baseFuncs()
+	m["printf"] = sandboxPrintf
+	m["call"] = func(...any) (any, error) { return nil, ErrFuncDisabled }
+	return m
+}
+
+var (
+	sandboxHTMLFuncMap = htmpl.FuncMap(sandboxFuncs())
+	sandboxTextFuncMap = texttpl.FuncMap(sandboxFuncs())
+)
+
+// maxConstRange is the largest integer an external template may range over ({{range 10}})
+const maxConstRange = 1000
+
+// checkRanges rejects {{range N}} with N above maxConstRange: such a loop burns CPU without
+// writing, so the output cap and timeout would not stop it
+func checkRanges(filename string, trees []*parse.Tree) error {
+	var walk func(n parse.Node) error
+	walk = func(n parse.Node) error {
+		switch x := n.(type) {
+		case *parse.ListNode:
+			if x == nil {
+				return nil
+			}
+			for _, c := range x.Nodes {
+				if err := walk(c); err != nil {
+					return err
+				}
+			}
+		case *parse.RangeNode:
+			for _, cmd := range x.Pipe.Cmds {
+				for _, arg := range cmd.Args {
+					if num, ok := arg.(*parse.NumberNode); ok && (!num.IsInt || num.Int64 > maxConstRange) {
+						return fmt.Errorf("%s: range over %s exceeds %s", filename, num.Text, strconv.Itoa(maxConstRange))
+					}
+				}
+			}
+			if err := walk(x.List); err != nil {
+				return err
+			}
+			return walk(x.ElseList)
+		case *parse.IfNode:
+			if err := walk(x.List); err != nil {
+				return err
+			}
+			return walk(x.ElseList)
+		case *parse.WithNode:
+			if err := walk(x.List); err != nil {
+				return err
+			}
+			return walk(x.ElseList)
+		}
+		return nil
+	}
+	for _, t := range trees {
+		if t == nil || t.Root == nil {
+			continue
+		}
+		if err := walk(t.Root); err != nil {
+			return err
+		}
+	}
+	return nil
+}
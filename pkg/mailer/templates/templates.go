@@ -1,14 +1,19 @@
 package templates
 
 import (
-	"bytes"
 	"embed"
 	"encoding/json"
 	"fmt"
 	htmpl "html/template"
+	"io"
+	"io/fs"
+	"os"
 	"reflect"
 	"strings"
+	"sync"
+	"sync/atomic"
 	texttpl "text/template"
+	"text/template/parse"
 	"time"
 )
 
@@ -17,7 +22,8 @@ var FS embed.FS
 
 type EmailType string
 
-// EmailData defines standard fields for email templates.
+// EmailData holds the fields every email type shares. Each type's data struct embeds it and adds
+// the fields only its section uses (see registry.go).
 type EmailData struct {
 	// Basic info
 	Name           string `json:"Name"`
@@ -36,24 +42,24 @@ type EmailData struct {
 	PrivacyURL     string `json:"PrivacyURL"`
 	UnsubscribeURL string `json:"UnsubscribeURL"`
 
-	// Action URLs
+	// Action URLs (reset and verify default to the configured pages)
 	ResetURL  string `json:"ResetURL"`
 	VerifyURL string `json:"VerifyURL"`
+	RevokeURL string `json:"RevokeURL"` // one-click "this wasn't me" session revoke
 
 	// Additional data
-	ExpiresAt     time.Time         `json:"ExpiresAt"`
-	ExpiresAtText string            `json:"ExpiresAtText"`
-	IP            string            `json:"IP"`
-	Time          string            `json:"Time"`
-	TimeAt        time.Time         `json:"TimeAt"`
-	UserAgent     string            `json:"UserAgent"`
-	Location      string            `json:"Location"`
-	Changes       map[string]string `json:"Changes"`
-	Code          string            `json:"Code"` // for OTP codes
-}
-
-// ToMap converts EmailData to a map[string]any for EmailJob.Data
-func ToMap(d EmailData) map[string]any {
+	ExpiresAt     time.Time `json:"ExpiresAt"`
+	ExpiresAtText string    `json:"ExpiresAtText"`
+	IP            string    `json:"IP"`
+	Time          string    `json:"Time"`
+	TimeAt        time.Time `json:"TimeAt"`
+	UserAgent     string    `json:"UserAgent"`
+	Location      string    `json:"Location"`
+	Locale        string    `json:"Locale,omitempty"` // the job's locale; Time and ExpiresAtText are formatted in it
+}
+
+// ToMap converts typed email data to a map[string]any for EmailJob.Data
+func ToMap(d TemplateData) map[string]any {
 	b, _ := json.Marshal(d)
 	var m map[string]any
 	_ = json.Unmarshal(b, &m)
@@ -86,12 +92,16 @@ func defaultFn(fallback any, value any) any {
 // ---- FuncMaps (dibangun dari satu sumber) ----
 
 func baseFuncs() map[string]any {
-	return map[string]any{
+	funcs := map[string]any{
 		"now":        func() time.Time { return time.Now().UTC() },
 		"formatTime": func(t time.Time, layout string) string { return t.Format(layout) },
 		"upper":      strings.ToUpper,
 		"default":    defaultFn,
 	}
+	for name, fn := range localeFuncs() {
+		funcs[name] = fn
+	}
+	return funcs
 }
 
 var (
@@ -107,33 +117,174 @@ const (
 	ForgotPassword    = "forgot_password"
 	ProfileUpdated    = "profile_updated"
 	LoginOTP          = "login_otp"
+	Invitation        = "invitation"
+	PasswordChanged   = "password_changed"
+	SuspiciousLogin   = "suspicious_login"
+	AccountSuspended  = "account_suspended"
+	AccountBanned     = "account_banned"
+	AccountReinstated = "account_reinstated"
+	// ConfirmEmailChange goes to the new address of a pending email change (see ProfileChangeService)
+	ConfirmEmailChange = "confirm_email_change"
+	// ActivityDigest is the weekly account activity summary (see DigestService)
+	ActivityDigest = "activity_digest"
+
+	// Universal is the single template file set; the names above select its section via Data["Type"]
+	Universal = "universal"
 )
 
-// renderFile loads and renders a single template file from the embedded FS.
-// isHTML indicates whether to use html/template (true) or text/template (false).
-func renderFile(filename string, isHTML bool, data any) (string, error) {
-	var (
-		buf bytes.Buffer
-		err error
-	)
+// IsAlias reports a legacy template name that is rendered as universal with Type set to the name.
+func IsAlias(name string) bool {
+	switch strings.ToLower(name) {
+	case LoginNotification, VerifyEmail, ForgotPassword, ProfileUpdated, LoginOTP:
+		return true
+	}
+	return false
+}
+
+// ---- Parsed template cache ----
+
+// executor is satisfied by both *html/template.Template and *text/template.Template
+type executor interface {
+	Execute(w io.Writer, data any) error
+}
+
+type cachedTemplate struct {
+	tpl     executor
+	modTime time.Time
+}
+
+var (
+	cacheMu sync.RWMutex
+	cache   = map[string]cachedTemplate{} // by filename
 
+	// source is the embedded FS unless UseDir switched to files on disk; reload re-parses changed files.
+	// Templates from disk are sandboxed (see sandbox.go).
+	source fs.FS = FS
+	reload bool
+
+	// inline moves <style> rules into style attributes after RenderHTML (see InlineCSS)
+	inline atomic.Bool
+)
+
+// SetInlineCSS toggles the CSS inlining pass on rendered HTML (MAIL_INLINE_CSS).
+func SetInlineCSS(on bool) { inline.Store(on) }
+
+// UseDir renders templates from dir instead of the embedded copies (MAIL_TEMPLATES_DIR, for development).
+// Files are re-parsed whenever their modification time changes, so edits show up without a restart.
+func UseDir(dir string) {
+	cacheMu.Lock()
+	source, reload = os.DirFS(dir), true
+	cache = map[string]cachedTemplate{}
+	cacheMu.Unlock()
+}
+
+// Setup prepares rendering for a worker: templates come from dir when set (see UseDir) and are preloaded.
+func Setup(dir string) error {
+	if dir != "" {
+		UseDir(dir)
+	}
+	return Preload()
+}
+
+// Invalidate drops every parsed template; the next render parses again.
+func Invalidate() {
+	cacheMu.Lock()
+	cache = map[string]cachedTemplate{}
+	cacheMu.Unlock()
+	sheetMu.Lock()
+	sheetCache = map[string]cachedSheet{}
+	sheetMu.Unlock()
+}
+
+// Preload parses every template once so syntax errors surface at startup instead of per message.
+func Preload() error {
+	cacheMu.RLock()
+	src := source
+	cacheMu.RUnlock()
+	names, err := fs.Glob(src, "*.tmpl")
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if _, err := parsed(name, strings.HasSuffix(name, ".html.tmpl")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parsed returns the cached template for filename, parsing it on first use (and, in reload mode,
+// after the file changed).
+func parsed(filename string, isHTML bool) (executor, error) {
+	cacheMu.RLock()
+	src, hot := source, reload
+	c, ok := cache[filename]
+	cacheMu.RUnlock()
+
+	var modTime time.Time
+	if hot {
+		info, err := fs.Stat(src, filename)
+		if err != nil {
+			return nil, err
+		}
+		modTime = info.ModTime()
+	}
+	if ok && c.modTime.Equal(modTime) {
+		return c.tpl, nil
+	}
+
+	var tpl executor
+	var trees []*parse.Tree
 	if isHTML {
-		tpl, e := htmpl.New(filename).Funcs(htmlFuncMap).ParseFS(FS, filename)
-		if e != nil {
-			return "", fmt.Errorf("parse html %q: %w", filename, e)
+		funcs := htmlFuncMap
+		if hot {
+			funcs = sandboxHTMLFuncMap
+		}
+		t, err := htmpl.New(filename).Funcs(funcs).ParseFS(src, filename)
+		if err != nil {
+			return nil, fmt.Errorf("parse html %q: %w", filename, err)
 		}
-		err = tpl.Execute(&buf, data)
+		for _, st := range t.Templates() {
+			trees = append(trees, st.Tree)
+		}
+		tpl = t
 	} else {
-		tpl, e := texttpl.New(filename).Funcs(textFuncMap).ParseFS(FS, filename)
-		if e != nil {
-			return "", fmt.Errorf("parse text %q: %w", filename, e)
+		funcs := textFuncMap
+		if hot {
+			funcs = sandboxTextFuncMap
+		}
+		t, err := texttpl.New(filename).Funcs(funcs).ParseFS(src, filename)
+		if err != nil {
+			return nil, fmt.Errorf("parse text %q: %w", filename, err)
+		}
+		for _, st := range t.Templates() {
+			trees = append(trees, st.Tree)
+		}
+		tpl = t
+	}
+	if hot {
+		if err := checkRanges(filename, trees); err != nil {
+			return nil, err
 		}
-		err = tpl.Execute(&buf, data)
 	}
+	cacheMu.Lock()
+	cache[filename] = cachedTemplate{tpl: tpl, modTime: modTime}
+	cacheMu.Unlock()
+	return tpl, nil
+}
+
+// renderFile renders a single template file, parsed once and cached, within the render limits.
+// isHTML indicates whether to use html/template (true) or text/template (false).
+func renderFile(filename string, isHTML bool, data any) (string, error) {
+	tpl, err := parsed(filename, isHTML)
+	if err != nil {
+		return "", err
+	}
+	out, err := execute(tpl, data)
 	if err != nil {
 		return "", fmt.Errorf("exec %q: %w", filename, err)
 	}
-	return buf.String(), nil
+	return out, nil
 }
 
 // Render loads and renders subject, text, and html templates for the given base name.
@@ -147,14 +298,46 @@ func Render(name string, data any) (subject string, text string, html string, er
 	if err != nil {
 		return "", "", "", err
 	}
-	html, err = renderFile(name+".html.tmpl", true, data)
+	html, err = RenderHTML(name, data)
 	if err != nil {
 		return "", "", "", err
 	}
 	return subject, text, html, nil
 }
 
-// RenderHTML renders just an HTML template: <name>.html.tmpl
+// DefaultLocale is used when a job carries no locale or the locale has no subject file.
+const DefaultLocale = "en"
+
+// RenderSubject renders the locale-specific subject template for the given base name.
+// Resolution order: <name>.subject.<locale>.tmpl, <name>.subject.<DefaultLocale>.tmpl, <name>.subject.tmpl
+func RenderSubject(name, locale string, data any) (string, error) {
+	locale = strings.ToLower(strings.TrimSpace(locale))
+	candidates := make([]string, 0, 3)
+	if locale != "" && locale != DefaultLocale {
+		candidates = append(candidates, name+".subject."+locale+".tmpl")
+	}
+	candidates = append(candidates, name+".subject."+DefaultLocale+".tmpl", name+".subject.tmpl")
+	cacheMu.RLock()
+	src := source
+	cacheMu.RUnlock()
+	for _, filename := range candidates {
+		if _, err := fs.Stat(src, filename); err != nil {
+			continue
+		}
+		s, err := renderFile(filename, false, data)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(s), nil
+	}
+	return "", fmt.Errorf("no subject template for %q", name)
+}
+
+// RenderHTML renders just an HTML template: <name>.html.tmpl, with CSS inlined when enabled
 func RenderHTML(name string, data any) (string, error) {
-	return renderFile(name+".html.tmpl", true, data)
+	out, err := renderFile(name+".html.tmpl", true, data)
+	if err != nil || !inline.Load() {
+		return out, err
+	}
+	return InlineCSS(name, out)
 }
@@ -0,0 +1,105 @@
+package templates
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/config"
+)
+
+func benchData() *LoginOTPData {
+	cfg := &config.Config{AppName: "Bench", CompanyName: "Bench Inc."}
+	return NewLoginOTPData(cfg, "Ada", "ada@example.com", "123456", WithTime(time.Now()), WithExpiresIn(10*time.Minute))
+}
+
+// useEmbedded puts the package back on the embedded templates when the benchmark ends
+func useEmbedded(b *testing.B) {
+	b.Helper()
+	b.Cleanup(func() {
+		cacheMu.Lock()
+		source, reload = FS, false
+		cacheMu.Unlock()
+		Invalidate()
+	})
+}
+
+// copyTemplates writes the embedded templates into a temp dir for the reload benchmarks
+func copyTemplates(b *testing.B) string {
+	b.Helper()
+	dir := b.TempDir()
+	names, err := fs.Glob(FS, "*.tmpl")
+	if err != nil {
+		b.Fatal(err)
+	}
+	for _, name := range names {
+		raw, err := FS.ReadFile(name)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), raw, 0o644); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func renderOrFail(b *testing.B, data any) {
+	if _, err := RenderHTML(Universal, data); err != nil {
+		b.Fatal(err)
+	}
+}
+
+// BenchmarkRenderHTMLCached renders from the parsed-template cache, as workers do after Preload
+func BenchmarkRenderHTMLCached(b *testing.B) {
+	useEmbedded(b)
+	data := benchData()
+	if err := Preload(); err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	for b.Loop() {
+		renderOrFail(b, data)
+	}
+}
+
+// BenchmarkRenderHTMLCold parses the template for every render, the cost the cache saves
+func BenchmarkRenderHTMLCold(b *testing.B) {
+	useEmbedded(b)
+	data := benchData()
+	b.ReportAllocs()
+	for b.Loop() {
+		Invalidate()
+		renderOrFail(b, data)
+	}
+}
+
+// BenchmarkRenderHTMLReload covers MAIL_TEMPLATES_DIR: unchanged files cost a stat per render,
+// a changed modification time invalidates the cached template and parses it again.
+func BenchmarkRenderHTMLReload(b *testing.B) {
+	useEmbedded(b)
+	data := benchData()
+	dir := copyTemplates(b)
+	UseDir(dir)
+
+	b.Run("unchanged", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			renderOrFail(b, data)
+		}
+	})
+	b.Run("changed", func(b *testing.B) {
+		file := filepath.Join(dir, Universal+".html.tmpl")
+		mod := time.Now()
+		b.ReportAllocs()
+		for b.Loop() {
+			mod = mod.Add(time.Second)
+			if err := os.Chtimes(file, mod, mod); err != nil {
+				b.Fatal(err)
+			}
+			renderOrFail(b, data)
+		}
+	})
+}
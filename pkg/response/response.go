@@ -1,6 +1,7 @@
 package response
 
 import (
+	"encoding/json"
 	"net"
 	"net/http"
 	"strings"
@@ -9,19 +10,54 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// StartKey is the gin context key holding the request start time (set by middleware.Timing)
+const StartKey = "request_start"
+
+// DeprecatedKey is the gin context key set on requests to deprecated routes
+const DeprecatedKey = "route_deprecated"
+
+// DegradedKey is the gin context key holding a DegradedReporter (set by middleware.Degraded)
+const DegradedKey = "degraded_dependencies"
+
+// DegradedReporter lists dependencies that are currently unavailable (open circuit breakers)
+type DegradedReporter interface {
+	Degraded() []string
+}
+
+// EnvelopeVersion is the schema version of Envelope, reported in meta.version. Bump it for breaking
+// changes to the envelope and announce the old shape's retirement through route deprecations first.
+const EnvelopeVersion = "1"
+
 type Meta struct {
+	Version   string    `json:"version"`
 	RequestID string    `json:"request_id"`
 	Timestamp time.Time `json:"timestamp"`
 	Status    int       `json:"status"`
 	IP        string    `json:"ip"`
 	OS        string    `json:"os"`
+	// DurationMS is server processing time up to writing this response
+	DurationMS float64 `json:"duration_ms,omitempty"`
+	// Deprecated is set on responses from deprecated routes (see the Deprecation and Sunset headers)
+	Deprecated bool `json:"deprecated,omitempty"`
+	// Degraded names dependencies that are down (e.g. ["search"]); results may be partial
+	Degraded []string `json:"degraded,omitempty"`
 }
 
 type ErrorBody struct {
+	// Code is a stable machine-readable identifier, set on errors the router itself answers
+	Code    string      `json:"code,omitempty"`
 	Message string      `json:"message"`
 	Details interface{} `json:"details,omitempty"`
 }
 
+// Error codes of router-level responses (unmatched routes, panics, timeouts)
+const (
+	CodeRouteNotFound    = "route_not_found"
+	CodeMethodNotAllowed = "method_not_allowed"
+	CodeInternal         = "internal_error"
+	CodeTimeout          = "timeout"
+)
+
 type Envelope[T any] struct {
 	Meta  Meta       `json:"meta"`
 	Data  T          `json:"data,omitempty"`
@@ -29,18 +65,30 @@ type Envelope[T any] struct {
 }
 
 func makeMeta(ctx *gin.Context, status int) Meta {
-	if status == 0 {
-		status = http.StatusOK
-	}
-	ua := ctx.GetHeader("User-Agent")
-
 	ip := ctx.GetString("real_ip")
 	if ip == "" || net.ParseIP(ip) == nil {
 		ip = ctx.ClientIP()
 	}
 
+	m := newMeta(status, ctx.GetHeader("User-Agent"), ip, ctx.GetString("request_id"))
+	if start, ok := ctx.Value(StartKey).(time.Time); ok {
+		m.DurationMS = float64(time.Since(start).Microseconds()) / 1000
+	}
+	m.Deprecated = ctx.GetBool(DeprecatedKey)
+	// Read when the response is written, so a breaker this request tripped is already listed
+	if r, ok := ctx.Value(DegradedKey).(DegradedReporter); ok && r != nil {
+		m.Degraded = r.Degraded()
+	}
+	return m
+}
+
+func newMeta(status int, ua, ip, requestID string) Meta {
+	if status == 0 {
+		status = http.StatusOK
+	}
 	return Meta{
-		RequestID: ctx.GetString("request_id"),
+		Version:   EnvelopeVersion,
+		RequestID: requestID,
 		Timestamp: time.Now().UTC().Round(time.Millisecond),
 		Status:    status,
 		IP:        ip,
@@ -58,11 +106,16 @@ func Success[T any](ctx *gin.Context, status int, data T, _ string, _ interface{
 
 // Error responds with the standard envelope carrying an error body. The `err` parameter is used as details.
 func Error[T any](ctx *gin.Context, status int, message string, err interface{}) Envelope[T] {
+	return ErrorCode[T](ctx, status, "", message, err)
+}
+
+// ErrorCode is Error with a machine-readable error.code
+func ErrorCode[T any](ctx *gin.Context, status int, code, message string, err interface{}) Envelope[T] {
 	if status == 0 {
 		status = http.StatusBadRequest
 	}
 	m := makeMeta(ctx, status)
-	body := &ErrorBody{Message: message}
+	body := &ErrorBody{Code: code, Message: message}
 	if err != nil {
 		body.Details = err
 	}
@@ -71,6 +124,25 @@ func Error[T any](ctx *gin.Context, status int, message string, err interface{})
 	return env
 }
 
+// Write responds with the standard envelope outside Gin (net/http, chi). Meta comes from the
+// request alone: X-Request-ID and the remote address; body nil means success.
+func Write[T any](w http.ResponseWriter, r *http.Request, status int, data T, body *ErrorBody) Envelope[T] {
+	ip := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+	m := newMeta(status, r.UserAgent(), ip, r.Header.Get("X-Request-ID"))
+	env := Envelope[T]{Meta: m, Data: data, Error: body}
+	if body != nil {
+		var zero T
+		env.Data = zero
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(m.Status)
+	_ = json.NewEncoder(w).Encode(env)
+	return env
+}
+
 // parseOSFromUA extracts a friendly OS string from User-Agent; best-effort.
 func parseOSFromUA(ua string) string {
 	if ua == "" {
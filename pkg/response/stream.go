@@ -0,0 +1,99 @@
+package response
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NDJSONContentType is the media type of streamed responses, one JSON value per line
+const NDJSONContentType = "application/x-ndjson"
+
+// Flush defaults for Stream
+const (
+	DefaultStreamFlushEvery    = 500
+	DefaultStreamFlushInterval = time.Second
+)
+
+// Trailer is the last line of a stream, {"trailer": {...}}: the envelope meta (request id, status,
+// duration), how many records came before it and, when the stream broke off, the error. A stream
+// without a trailer was cut short.
+type Trailer struct {
+	Meta     Meta       `json:"meta"`
+	Count    int64      `json:"count"`
+	Complete bool       `json:"complete"`
+	Error    *ErrorBody `json:"error,omitempty"`
+}
+
+// Stream writes a large result set as NDJSON straight to the client: each record is encoded as it
+// is written and the response is flushed every FlushEvery records or FlushInterval, so memory stays
+// flat whatever the size. The status is sent with the first line; failures after that can only be
+// reported in the trailer Close writes.
+type Stream struct {
+	FlushEvery    int
+	FlushInterval time.Duration
+
+	c         *gin.Context
+	enc       *json.Encoder
+	count     int64
+	pending   int
+	lastFlush time.Time
+	closed    bool
+}
+
+// NewStream starts a 200 NDJSON response; set FlushEvery/FlushInterval before the first Write
+func NewStream(c *gin.Context) *Stream {
+	h := c.Writer.Header()
+	h.Set("Content-Type", NDJSONContentType)
+	h.Set("Cache-Control", "no-store")
+	h.Set("X-Accel-Buffering", "no") // nginx would otherwise hold the flushed chunks back
+	c.Status(http.StatusOK)
+	return &Stream{
+		FlushEvery: DefaultStreamFlushEvery, FlushInterval: DefaultStreamFlushInterval,
+		c: c, enc: json.NewEncoder(c.Writer), lastFlush: time.Now(),
+	}
+}
+
+// Write sends one record. It fails once the client has gone away, so producers stop early.
+func (s *Stream) Write(v any) error {
+	if err := s.c.Request.Context().Err(); err != nil {
+		return err
+	}
+	if err := s.enc.Encode(v); err != nil {
+		return err
+	}
+	s.count++
+	s.pending++
+	if s.pending >= s.FlushEvery || time.Since(s.lastFlush) >= s.FlushInterval {
+		s.flush()
+	}
+	return nil
+}
+
+// Count is the number of records written so far
+func (s *Stream) Count() int64 { return s.count }
+
+func (s *Stream) flush() {
+	s.c.Writer.Flush()
+	s.pending, s.lastFlush = 0, time.Now()
+}
+
+// Close writes the trailer and flushes; err (what stopped the producer, if anything) is reported
+// in it with message msg and a 500 meta status. Only the first call writes.
+func (s *Stream) Close(err error, msg string) {
+	if s.closed {
+		return
+	}
+	s.closed = true
+	status := http.StatusOK
+	t := Trailer{Count: s.count, Complete: err == nil}
+	if err != nil {
+		status = http.StatusInternalServerError
+		t.Error = &ErrorBody{Message: msg}
+	}
+	t.Meta = makeMeta(s.c, status)
+	_ = s.enc.Encode(map[string]Trailer{"trailer": t})
+	s.flush()
+}
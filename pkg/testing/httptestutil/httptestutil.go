@@ -0,0 +1,198 @@
+// Package httptestutil lowers the cost of handler tests: it builds a Registry wired with the real auth,
+// scope and rate limit guards over a miniredis-backed session store, issues signed tokens for test
+// users, performs requests and decodes the standard response envelope. Handlers that need Redis
+// (OTP, account lockouts, caches) take Env.Redis.
+//
+//	env := httptestutil.New(t)
+//	env.Registry.AddRoutes(modules.NewEmailModule(handler))
+//	user := env.Login(t, "user-1")
+//	res := env.Do(t, http.MethodPost, "/api/email/send", body, user)
+//	res.AssertStatus(t, http.StatusAccepted)
+package httptestutil
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/domain/entity"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/infrastructure/redisstore"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/interface/middleware"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/router"
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/router/route"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/response"
+)
+
+// Env is a test server: a Registry on a gin engine with auth backed by Sessions and JWT.
+// Rate limit classes are the production ones, counted in Miniredis (FastForward it to reset
+// windows); the concurrency class is a no-op. Set Registry.Guards.Role/Permission/Org for
+// routes that need them. Routes are mounted on the first Do.
+type Env struct {
+	Engine    *gin.Engine
+	Registry  *router.Registry
+	Miniredis *miniredis.Miniredis
+	Redis     *redis.Client
+	Sessions  *redisstore.SessionStore
+	JWT       *helpers.JWTManager
+	Cookies   *helpers.Manager
+
+	mount sync.Once
+}
+
+// Identity is a logged-in test user
+type Identity struct {
+	UserID       string
+	SessionID    string
+	AccessToken  string
+	RefreshToken string
+	// Bearer sends the access token in the Authorization header instead of the cookie
+	Bearer bool
+}
+
+func New(t testing.TB) *Env {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(middleware.Timing(nil), middleware.RequestIDMiddleware())
+
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+	sessions := redisstore.NewSessionStore(rdb)
+	jwt := helpers.NewJWTManager("test-access-secret", "test-refresh-secret", 15*time.Minute, 24*time.Hour, "")
+	cookies := helpers.DefaultCookies()
+	if cookies == nil {
+		cookies = helpers.NewCookie("", false)
+	}
+
+	pass := func(c *gin.Context) { c.Next() }
+	reg := router.NewRegistry(engine)
+	reg.Guards = router.Guards{
+		Auth:        middleware.Auth(sessions, jwt, helpers.SessionPolicy{}, nil),
+		Scopes:      middleware.RequireScopes,
+		RateLimits:  router.RateLimiters(rdb),
+		Concurrency: map[string]gin.HandlerFunc{route.ConcurrencyHeavy: pass},
+	}
+	return &Env{Engine: engine, Registry: reg, Miniredis: mr, Redis: rdb, Sessions: sessions, JWT: jwt, Cookies: cookies}
+}
+
+// Login creates a session for userID and signs access and refresh tokens for it.
+func (e *Env) Login(t testing.TB, userID string) *Identity {
+	t.Helper()
+	sess := &entity.Session{ID: uuid.NewString(), UserID: userID, Email: userID + "@example.test", Name: userID}
+	if err := e.Sessions.Create(context.Background(), sess, time.Hour); err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	access, _, err := e.JWT.GenerateAccessToken(userID, sess.ID)
+	if err != nil {
+		t.Fatalf("sign access token: %v", err)
+	}
+	refresh, _, err := e.JWT.GenerateRefreshToken(userID, sess.ID)
+	if err != nil {
+		t.Fatalf("sign refresh token: %v", err)
+	}
+	return &Identity{UserID: userID, SessionID: sess.ID, AccessToken: access, RefreshToken: refresh}
+}
+
+// Do sends a request (body is JSON-encoded unless it is a string, []byte or io.Reader) as the
+// given identity, or anonymously when as is nil.
+func (e *Env) Do(t testing.TB, method, path string, body any, as *Identity, headers ...string) *Response {
+	t.Helper()
+	e.mount.Do(e.Registry.RegisterAll)
+
+	var r io.Reader
+	switch b := body.(type) {
+	case nil:
+	case io.Reader:
+		r = b
+	case string:
+		r = strings.NewReader(b)
+	case []byte:
+		r = bytes.NewReader(b)
+	default:
+		raw, err := json.Marshal(b)
+		if err != nil {
+			t.Fatalf("encode body: %v", err)
+		}
+		r = bytes.NewReader(raw)
+	}
+	req := httptest.NewRequest(method, path, r)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	if as != nil {
+		if as.Bearer {
+			req.Header.Set("Authorization", "Bearer "+as.AccessToken)
+		} else {
+			req.AddCookie(&http.Cookie{Name: e.Cookies.Name(helpers.CookieAccessToken), Value: as.AccessToken})
+			req.AddCookie(&http.Cookie{Name: e.Cookies.Name(helpers.CookieRefreshToken), Value: as.RefreshToken})
+		}
+	}
+	w := httptest.NewRecorder()
+	e.Engine.ServeHTTP(w, req)
+	return &Response{w}
+}
+
+// Response wraps the recorder with envelope assertions.
+type Response struct {
+	*httptest.ResponseRecorder
+}
+
+// Envelope decodes the standard response envelope, keeping data raw.
+func (r *Response) Envelope(t testing.TB) response.Envelope[json.RawMessage] {
+	t.Helper()
+	var env response.Envelope[json.RawMessage]
+	if err := json.Unmarshal(r.Body.Bytes(), &env); err != nil {
+		t.Fatalf("decode envelope: %v\nbody: %s", err, r.Body.String())
+	}
+	return env
+}
+
+// Data decodes the envelope's data into v.
+func (r *Response) Data(t testing.TB, v any) {
+	t.Helper()
+	env := r.Envelope(t)
+	if err := json.Unmarshal(env.Data, v); err != nil {
+		t.Fatalf("decode data: %v\ndata: %s", err, env.Data)
+	}
+}
+
+// AssertStatus fails unless the HTTP status and the envelope's meta.status equal want.
+func (r *Response) AssertStatus(t testing.TB, want int) {
+	t.Helper()
+	if r.Code != want {
+		t.Fatalf("status = %d, want %d\nbody: %s", r.Code, want, r.Body.String())
+	}
+	if env := r.Envelope(t); env.Meta.Status != want {
+		t.Fatalf("meta.status = %d, want %d", env.Meta.Status, want)
+	}
+}
+
+// AssertError fails unless the response has status want and an error whose message contains msg.
+func (r *Response) AssertError(t testing.TB, want int, msg string) {
+	t.Helper()
+	r.AssertStatus(t, want)
+	env := r.Envelope(t)
+	if env.Error == nil {
+		t.Fatalf("expected an error envelope, got data: %s", env.Data)
+	}
+	if !strings.Contains(env.Error.Message, msg) {
+		t.Fatalf("error message = %q, want it to contain %q", env.Error.Message, msg)
+	}
+}
@@ -0,0 +1,73 @@
+package httptestutil_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/oksasatya/go-ddd-clean-architecture/internal/router/route"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/helpers"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/response"
+	"github.com/oksasatya/go-ddd-clean-architecture/pkg/testing/httptestutil"
+)
+
+type whoamiModule struct{}
+
+func (whoamiModule) Routes() []route.Route {
+	whoami := func(c *gin.Context) {
+		response.Success[any](c, http.StatusOK, gin.H{"user_id": c.GetString("userID")}, "ok", nil)
+	}
+	return []route.Route{
+		{Method: http.MethodGet, Path: "/whoami", Handler: whoami, Scopes: []string{helpers.ScopeRead}, RateLimit: route.RateUser},
+		{Method: http.MethodPost, Path: "/ping", Handler: whoami, Public: true, RateLimit: route.RateAuth},
+	}
+}
+
+func newEnv(t *testing.T) *httptestutil.Env {
+	env := httptestutil.New(t)
+	env.Registry.AddRoutes(whoamiModule{})
+	return env
+}
+
+func TestLoginAndDo(t *testing.T) {
+	env := newEnv(t)
+	for _, bearer := range []bool{false, true} {
+		user := env.Login(t, "user-1")
+		user.Bearer = bearer
+		res := env.Do(t, http.MethodGet, "/api/whoami", nil, user)
+		res.AssertStatus(t, http.StatusOK)
+		var data struct {
+			UserID string `json:"user_id"`
+		}
+		res.Data(t, &data)
+		if data.UserID != "user-1" {
+			t.Fatalf("user_id = %q, want user-1", data.UserID)
+		}
+	}
+}
+
+func TestAnonymousRequestIsRejected(t *testing.T) {
+	env := newEnv(t)
+	env.Do(t, http.MethodGet, "/api/whoami", nil, nil).AssertError(t, http.StatusUnauthorized, "")
+}
+
+func TestRevokedSessionIsRejected(t *testing.T) {
+	env := newEnv(t)
+	user := env.Login(t, "user-1")
+	if err := env.Sessions.Revoke(t.Context(), user.UserID, ""); err != nil {
+		t.Fatal(err)
+	}
+	env.Do(t, http.MethodGet, "/api/whoami", nil, user).AssertError(t, http.StatusUnauthorized, "")
+}
+
+func TestRateLimitIsCountedInRedis(t *testing.T) {
+	env := newEnv(t)
+	for i := 0; i < 10; i++ {
+		env.Do(t, http.MethodPost, "/api/ping", map[string]any{}, nil).AssertStatus(t, http.StatusOK)
+	}
+	env.Do(t, http.MethodPost, "/api/ping", map[string]any{}, nil).AssertStatus(t, http.StatusTooManyRequests)
+	env.Miniredis.FastForward(time.Minute)
+	env.Do(t, http.MethodPost, "/api/ping", map[string]any{}, nil).AssertStatus(t, http.StatusOK)
+}
@@ -16,9 +16,25 @@ import (
 )
 
 var (
-	trans ut.Translator
+	trans  ut.Translator
+	format = FormatMap
 )
 
+// Shapes of error.details produced by Details (VALIDATION_ERROR_FORMAT)
+const (
+	FormatMap        = "map"        // {field: message}
+	FormatStructured = "structured" // {"fields": {field: message}, "errors": [ValidationsError]}
+)
+
+// SetFormat selects the error.details shape returned by Details; unknown values keep FormatMap
+func SetFormat(f string) {
+	if strings.EqualFold(f, FormatStructured) {
+		format = FormatStructured
+		return
+	}
+	format = FormatMap
+}
+
 // Init configures the global validator used by Gin's binding.
 // - Uses JSON tag names in errors.
 // - Registers alias tags for common validations.
@@ -95,10 +111,108 @@ func ToDetails(err error) map[string]string {
 	return map[string]string{"payload": "invalid payload"}
 }
 
-// ValidationsError represents a structured validation error
+// Details converts a binding error into error.details in the configured format (see SetFormat)
+func Details(err error) any {
+	if err == nil {
+		return nil
+	}
+	if format == FormatStructured {
+		return map[string]any{"fields": ToDetails(err), "errors": ToErrors(err)}
+	}
+	return ToDetails(err)
+}
+
+// ValidationsError represents a structured validation error. Field is the JSON path of the input
+// (nested fields dotted, list items indexed: items[0].name), Code a stable identifier to map to
+// UI messages, Param the rule argument (e.g. 8 for min=8). Message is translated like ToDetails.
 type ValidationsError struct {
 	Field   string `json:"field"`
 	Tag     string `json:"tag"`
-	Value   string `json:"value"`
+	Param   string `json:"param,omitempty"`
+	Code    string `json:"code"`
 	Message string `json:"message"`
 }
+
+// tagCodes maps validator tags (aliases resolved) to error codes; min/max/gt/lt depend on the
+// field kind (see code)
+var tagCodes = map[string]string{
+	"required":         "required",
+	"required_if":      "required",
+	"required_unless":  "required",
+	"required_with":    "required",
+	"required_without": "required",
+	"email":            "invalid_email",
+	"url":              "invalid_url",
+	"uri":              "invalid_url",
+	"uuid":             "invalid_uuid",
+	"e164":             "invalid_phone",
+	"oneof":            "not_allowed",
+	"eqfield":          "mismatch",
+	"nefield":          "must_differ",
+	"containsany":      "missing_characters",
+	"alphanum":         "invalid_characters",
+	"numeric":          "not_numeric",
+	"datetime":         "invalid_datetime",
+}
+
+// ToErrors converts validation/binding errors into structured errors, one per failed rule
+func ToErrors(err error) []ValidationsError {
+	if err == nil {
+		return nil
+	}
+	var se *json.SyntaxError
+	var ute *json.UnmarshalTypeError
+	if errors.As(err, &se) {
+		return []ValidationsError{{Field: "payload", Tag: "json", Code: "invalid_json", Message: "invalid json"}}
+	}
+	if errors.As(err, &ute) {
+		field := ute.Field
+		if field == "" {
+			field = "payload"
+		}
+		return []ValidationsError{{Field: field, Tag: "type", Param: ute.Type.String(), Code: "invalid_type", Message: "invalid json"}}
+	}
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return []ValidationsError{{Field: "payload", Tag: "payload", Code: "invalid_payload", Message: "invalid payload"}}
+	}
+	out := make([]ValidationsError, 0, len(verrs))
+	for _, fe := range verrs {
+		msg := fe.Error()
+		if trans != nil {
+			msg = fe.Translate(trans)
+		}
+		out = append(out, ValidationsError{Field: fieldPath(fe), Tag: fe.Tag(), Param: fe.Param(), Code: code(fe), Message: msg})
+	}
+	return out
+}
+
+// fieldPath is the namespace without the top-level struct name
+func fieldPath(fe validator.FieldError) string {
+	if _, rest, ok := strings.Cut(fe.Namespace(), "."); ok {
+		return rest
+	}
+	return fe.Field()
+}
+
+func code(fe validator.FieldError) string {
+	sized := fe.Kind() == reflect.String || fe.Kind() == reflect.Slice || fe.Kind() == reflect.Map || fe.Kind() == reflect.Array
+	switch fe.ActualTag() {
+	case "min", "gte", "gt":
+		if sized {
+			return "too_short"
+		}
+		return "too_small"
+	case "max", "lte", "lt":
+		if sized {
+			return "too_long"
+		}
+		return "too_large"
+	case "len":
+		return "wrong_length"
+	}
+	if c, ok := tagCodes[fe.ActualTag()]; ok {
+		return c
+	}
+	return "invalid"
+}